@@ -16,30 +16,39 @@ package grpc
 
 import (
 	"context"
-	"errors"
 
 	"google.golang.org/grpc"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
 	"github.com/jaegertracing/jaeger/model/converter/thrift/jaeger"
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
+	baggageProto "github.com/jaegertracing/jaeger/proto-gen/api_v2/baggage"
 	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
 	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
 )
 
-// SamplingManager returns sampling decisions from collector over gRPC.
+// SamplingManager returns sampling decisions and baggage restrictions from collector over gRPC.
 type SamplingManager struct {
-	client api_v2.SamplingManagerClient
+	client        api_v2.SamplingManagerClient
+	baggageClient baggageProto.BaggageRestrictionManagerClient
+	agentTags     map[string]string
 }
 
-// NewConfigManager creates gRPC sampling manager.
-func NewConfigManager(conn *grpc.ClientConn) *SamplingManager {
+// NewConfigManager creates gRPC sampling manager. The agent tags are sent to the collector
+// as tags of the client attributes of the sampling strategy requests.
+func NewConfigManager(conn *grpc.ClientConn, agentTags map[string]string) *SamplingManager {
 	return &SamplingManager{
-		client: api_v2.NewSamplingManagerClient(conn),
+		client:        api_v2.NewSamplingManagerClient(conn),
+		baggageClient: baggageProto.NewBaggageRestrictionManagerClient(conn),
+		agentTags:     agentTags,
 	}
 }
 
-// GetSamplingStrategy returns sampling strategies from collector.
+// GetSamplingStrategy returns sampling strategies from collector. The client attributes of the
+// context, with the agent tags, are sent in the request metadata.
 func (s *SamplingManager) GetSamplingStrategy(ctx context.Context, serviceName string) (*sampling.SamplingStrategyResponse, error) {
+	attributes := strategystore.GetClientAttributes(ctx).WithTags(s.agentTags)
+	ctx = strategystore.OutgoingContextWithClientAttributes(ctx, attributes)
 	r, err := s.client.GetSamplingStrategy(ctx, &api_v2.SamplingStrategyParameters{ServiceName: serviceName})
 	if err != nil {
 		return nil, err
@@ -48,6 +57,17 @@ func (s *SamplingManager) GetSamplingStrategy(ctx context.Context, serviceName s
 }
 
 // GetBaggageRestrictions returns baggage restrictions from collector.
-func (s *SamplingManager) GetBaggageRestrictions(_ context.Context, _ string) ([]*baggage.BaggageRestriction, error) {
-	return nil, errors.New("baggage not implemented")
+func (s *SamplingManager) GetBaggageRestrictions(ctx context.Context, serviceName string) ([]*baggage.BaggageRestriction, error) {
+	r, err := s.baggageClient.GetBaggageRestrictions(ctx, &baggageProto.BaggageRestrictionsParameters{ServiceName: serviceName})
+	if err != nil {
+		return nil, err
+	}
+	restrictions := make([]*baggage.BaggageRestriction, len(r.GetBaggageRestrictions()))
+	for i, br := range r.GetBaggageRestrictions() {
+		restrictions[i] = &baggage.BaggageRestriction{
+			BaggageKey:     br.GetBaggageKey(),
+			MaxValueLength: br.GetMaxValueLength(),
+		}
+	}
+	return restrictions, nil
 }
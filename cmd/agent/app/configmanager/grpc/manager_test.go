@@ -23,8 +23,13 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
+	baggageProto "github.com/jaegertracing/jaeger/proto-gen/api_v2/baggage"
+	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
 	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
 )
 
@@ -40,17 +45,39 @@ func TestSamplingManager_GetSamplingStrategy(t *testing.T) {
 	defer close(t, conn)
 	require.NoError(t, err)
 	defer s.GracefulStop()
-	manager := NewConfigManager(conn)
+	manager := NewConfigManager(conn, nil)
 	resp, err := manager.GetSamplingStrategy(context.Background(), "any")
 	require.NoError(t, err)
 	assert.Equal(t, &sampling.SamplingStrategyResponse{StrategyType: sampling.SamplingStrategyType_PROBABILISTIC}, resp)
 }
 
+func TestSamplingManager_GetSamplingStrategy_clientAttributes(t *testing.T) {
+	handler := &mockSamplingHandler{}
+	s, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {
+		api_v2.RegisterSamplingManagerServer(s, handler)
+	})
+	conn, err := grpc.Dial(addr.String(), grpc.WithInsecure())
+	defer close(t, conn)
+	require.NoError(t, err)
+	defer s.GracefulStop()
+	manager := NewConfigManager(conn, map[string]string{"region": "eu", "zone": "a"})
+	ctx := strategystore.ContextWithClientAttributes(context.Background(), &strategystore.ClientAttributes{
+		Environment: "canary",
+		Tags:        map[string]string{"zone": "b"},
+	})
+	_, err = manager.GetSamplingStrategy(ctx, "any")
+	require.NoError(t, err)
+	assert.Equal(t, &strategystore.ClientAttributes{
+		Environment: "canary",
+		Tags:        map[string]string{"region": "eu", "zone": "b"},
+	}, handler.attributes)
+}
+
 func TestSamplingManager_GetSamplingStrategy_error(t *testing.T) {
 	conn, err := grpc.Dial("foo", grpc.WithInsecure())
 	defer close(t, conn)
 	require.NoError(t, err)
-	manager := NewConfigManager(conn)
+	manager := NewConfigManager(conn, nil)
 	resp, err := manager.GetSamplingStrategy(context.Background(), "any")
 	require.Nil(t, resp)
 	require.Error(t, err)
@@ -58,16 +85,46 @@ func TestSamplingManager_GetSamplingStrategy_error(t *testing.T) {
 }
 
 func TestSamplingManager_GetBaggageRestrictions(t *testing.T) {
-	manager := NewConfigManager(nil)
+	s, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {
+		baggageProto.RegisterBaggageRestrictionManagerServer(s, &mockBaggageHandler{})
+	})
+	conn, err := grpc.Dial(addr.String(), grpc.WithInsecure())
+	defer close(t, conn)
+	require.NoError(t, err)
+	defer s.GracefulStop()
+	manager := NewConfigManager(conn, nil)
+	rest, err := manager.GetBaggageRestrictions(context.Background(), "foo")
+	require.NoError(t, err)
+	assert.Equal(t, []*baggage.BaggageRestriction{{BaggageKey: "foo-key", MaxValueLength: 10}}, rest)
+}
+
+func TestSamplingManager_GetBaggageRestrictions_error(t *testing.T) {
+	s, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {})
+	conn, err := grpc.Dial(addr.String(), grpc.WithInsecure())
+	defer close(t, conn)
+	require.NoError(t, err)
+	defer s.GracefulStop()
+	manager := NewConfigManager(conn, nil)
 	rest, err := manager.GetBaggageRestrictions(context.Background(), "foo")
 	require.Nil(t, rest)
-	assert.EqualError(t, err, "baggage not implemented")
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+type mockBaggageHandler struct {
+}
+
+func (*mockBaggageHandler) GetBaggageRestrictions(_ context.Context, params *baggageProto.BaggageRestrictionsParameters) (*baggageProto.BaggageRestrictionsResponse, error) {
+	return &baggageProto.BaggageRestrictionsResponse{
+		BaggageRestrictions: []*baggageProto.BaggageRestriction{{BaggageKey: params.ServiceName + "-key", MaxValueLength: 10}},
+	}, nil
 }
 
 type mockSamplingHandler struct {
+	attributes *strategystore.ClientAttributes
 }
 
-func (*mockSamplingHandler) GetSamplingStrategy(context.Context, *api_v2.SamplingStrategyParameters) (*api_v2.SamplingStrategyResponse, error) {
+func (h *mockSamplingHandler) GetSamplingStrategy(ctx context.Context, _ *api_v2.SamplingStrategyParameters) (*api_v2.SamplingStrategyResponse, error) {
+	h.attributes = strategystore.ClientAttributesFromIncomingContext(ctx)
 	return &api_v2.SamplingStrategyResponse{StrategyType: api_v2.SamplingStrategyType_PROBABILISTIC}, nil
 }
 
@@ -52,7 +52,7 @@ func NewCollectorProxy(builder *ConnBuilder, agentTags map[string]string, mFacto
 	return &ProxyBuilder{
 		conn:      conn,
 		reporter:  r3,
-		manager:   configmanager.WrapWithMetrics(grpcManager.NewConfigManager(conn), grpcMetrics),
+		manager:   configmanager.WrapWithMetrics(grpcManager.NewConfigManager(conn, agentTags), grpcMetrics),
 		tlsCloser: &builder.TLS,
 	}, nil
 }
@@ -37,6 +37,7 @@ import (
 	agentGrpcRep "github.com/jaegertracing/jaeger/cmd/agent/app/reporter/grpc"
 	"github.com/jaegertracing/jaeger/cmd/all-in-one/setupcontext"
 	collectorApp "github.com/jaegertracing/jaeger/cmd/collector/app"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/throughput"
 	"github.com/jaegertracing/jaeger/cmd/docs"
 	"github.com/jaegertracing/jaeger/cmd/env"
 	"github.com/jaegertracing/jaeger/cmd/flags"
@@ -44,11 +45,15 @@ import (
 	"github.com/jaegertracing/jaeger/cmd/query/app/querysvc"
 	"github.com/jaegertracing/jaeger/cmd/status"
 	"github.com/jaegertracing/jaeger/pkg/config"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/pkg/version"
 	metricsPlugin "github.com/jaegertracing/jaeger/plugin/metrics"
 	ss "github.com/jaegertracing/jaeger/plugin/sampling/strategystore"
+	"github.com/jaegertracing/jaeger/plugin/sampling/strategystore/adaptive"
+	"github.com/jaegertracing/jaeger/plugin/sampling/strategystore/static"
 	"github.com/jaegertracing/jaeger/plugin/storage"
 	"github.com/jaegertracing/jaeger/ports"
+	"github.com/jaegertracing/jaeger/proto-gen/api_v2/introspection"
 	"github.com/jaegertracing/jaeger/storage/dependencystore"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 	storageMetrics "github.com/jaegertracing/jaeger/storage/spanstore/metrics"
@@ -122,7 +127,7 @@ by default uses only in-memory database.`,
 			}
 
 			strategyStoreFactory.InitFromViper(v, logger)
-			if err := strategyStoreFactory.Initialize(metricsFactory, logger); err != nil {
+			if err := strategyStoreFactory.Initialize(metricsFactory, storageFactory, logger); err != nil {
 				logger.Fatal("Failed to init sampling strategy store factory", zap.Error(err))
 			}
 			strategyStore, err := strategyStoreFactory.CreateStrategyStore()
@@ -133,21 +138,57 @@ by default uses only in-memory database.`,
 			aOpts := new(agentApp.Builder).InitFromViper(v)
 			repOpts := new(agentRep.Options).InitFromViper(v, logger)
 			grpcBuilder := agentGrpcRep.NewConnBuilder().InitFromViper(v)
-			cOpts := new(collectorApp.CollectorOptions).InitFromViper(v)
+			cOpts, err := new(collectorApp.CollectorOptions).InitFromViper(v)
+			if err != nil {
+				logger.Fatal("Failed to initialize collector", zap.Error(err))
+			}
+			if err := storageFactory.ValidateTenancy(cOpts.Tenancy); err != nil {
+				logger.Fatal("Invalid multi-tenancy configuration", zap.Error(err))
+			}
 			qOpts := new(queryApp.QueryOptions).InitFromViper(v, logger)
 
 			// collector
+			var samplingIntrospection introspection.SamplingIntrospectionServer
+			introspector, isAdaptive := strategyStore.(adaptive.Introspector)
+			if isAdaptive {
+				samplingIntrospection = adaptive.NewGRPCIntrospectionHandler(introspector)
+			}
 			c := collectorApp.New(&collectorApp.CollectorParams{
-				ServiceName:    "jaeger-collector",
-				Logger:         logger,
-				MetricsFactory: metricsFactory,
-				SpanWriter:     spanWriter,
-				StrategyStore:  strategyStore,
-				HealthCheck:    svc.HC(),
+				ServiceName:           "jaeger-collector",
+				Logger:                logger,
+				MetricsFactory:        metricsFactory,
+				SpanWriter:            spanWriter,
+				StrategyStore:         strategyStore,
+				HealthCheck:           svc.HC(),
+				SamplingIntrospection: samplingIntrospection,
 			})
 			if err := c.Start(cOpts); err != nil {
 				log.Fatal(err)
 			}
+			if throughputHandler := c.ThroughputHandler(); throughputHandler != nil {
+				svc.Admin.Handle(throughput.Route, throughputHandler)
+			}
+			if isAdaptive {
+				svc.Admin.Handle(adaptive.IntrospectionRoute, adaptive.NewIntrospectionHandler(introspector))
+			}
+			if manager, ok := strategyStore.(static.Manager); ok && manager.ManagementEnabled() {
+				strategiesStore, err := storageFactory.CreateSamplingStrategiesStore()
+				if err != nil {
+					logger.Fatal("Failed to create sampling strategies store", zap.Error(err))
+				}
+				managementHandler, err := manager.Manage(strategiesStore)
+				if err != nil {
+					logger.Fatal("Failed to manage sampling strategies", zap.Error(err))
+				}
+				if managementHandler != nil {
+					svc.Admin.Handle(static.StrategiesRoute, managementHandler)
+					svc.Admin.Handle(static.StrategyChangesRoute, managementHandler)
+				}
+			}
+			// without a metrics storage, serve the RED metrics computed by the collector
+			if spanMetricsReader := c.SpanMetricsReader(); spanMetricsReader != nil && fc.MetricsStorageType == "" {
+				metricsQueryService = spanMetricsReader
+			}
 
 			// agent
 			// if the agent reporter grpc host:port was not explicitly set then use whatever the collector is listening on
@@ -179,6 +220,9 @@ by default uses only in-memory database.`,
 				agent.Stop()
 				_ = cp.Close()
 				_ = c.Close()
+				if closer, ok := strategyStore.(io.Closer); ok {
+					_ = closer.Close()
+				}
 				_ = querySrv.Close()
 				if closer, ok := spanWriter.(io.Closer); ok {
 					if err := closer.Close(); err != nil {
@@ -209,6 +253,7 @@ by default uses only in-memory database.`,
 		agentGrpcRep.AddFlags,
 		collectorApp.AddFlags,
 		queryApp.AddFlags,
+		tenancy.AddFlags,
 		strategyStoreFactory.AddFlags,
 		metricsReaderFactory.AddFlags,
 	)
@@ -0,0 +1,85 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// APIKey is a static token accepted as a bearer token.
+type APIKey struct {
+	Key      string   `json:"key"`
+	Subject  string   `json:"subject"`
+	Services []string `json:"services"`
+}
+
+type apiKeysFile struct {
+	Keys []APIKey `json:"keys"`
+}
+
+type apiKeyAuthenticator struct {
+	// keys are indexed by their hash to avoid timing attacks on the comparison
+	keys map[[sha256.Size]byte]*Identity
+}
+
+// NewAPIKeyAuthenticator creates an authenticator accepting the static API keys of the file.
+func NewAPIKeyAuthenticator(path string) (Authenticator, error) {
+	bytes, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	var file apiKeysFile
+	if err := json.Unmarshal(bytes, &file); err != nil {
+		return nil, fmt.Errorf("cannot parse API keys file: %w", err)
+	}
+	return newAPIKeyAuthenticator(file.Keys)
+}
+
+func newAPIKeyAuthenticator(keys []APIKey) (*apiKeyAuthenticator, error) {
+	a := &apiKeyAuthenticator{keys: make(map[[sha256.Size]byte]*Identity, len(keys))}
+	// entries are identified by their index in errors so that no part of the keys ends up in the logs
+	for i, k := range keys {
+		if k.Key == "" {
+			return nil, fmt.Errorf("API key %d: key cannot be empty", i)
+		}
+		if k.Subject == "" {
+			return nil, fmt.Errorf("API key %d: subject cannot be empty", i)
+		}
+		a.keys[sha256.Sum256([]byte(k.Key))] = &Identity{
+			Subject:  k.Subject,
+			Method:   MethodAPIKey,
+			Services: k.Services,
+		}
+	}
+	return a, nil
+}
+
+func (a *apiKeyAuthenticator) Authenticate(credentials Credentials) (*Identity, error) {
+	// JWTs are left to the JWT authenticator
+	if credentials.Token == "" || strings.Count(credentials.Token, ".") == 2 {
+		return nil, errNotApplicable
+	}
+	id, ok := a.keys[sha256.Sum256([]byte(credentials.Token))]
+	if !ok {
+		return nil, errors.New("unknown API key")
+	}
+	return id, nil
+}
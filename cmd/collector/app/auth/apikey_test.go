@@ -0,0 +1,51 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAPIKeyAuthenticator(t *testing.T) {
+	_, err := NewAPIKeyAuthenticator(writeFile(t, `not json`))
+	assert.Contains(t, err.Error(), "cannot parse API keys file")
+
+	_, err = NewAPIKeyAuthenticator(writeFile(t, `{"keys": [{"key": "", "subject": "team"}]}`))
+	assert.EqualError(t, err, "API key 0: key cannot be empty")
+
+	_, err = NewAPIKeyAuthenticator(writeFile(t, `{"keys": [{"key": "key", "subject": "team"}, {"key": "secretkey"}]}`))
+	assert.EqualError(t, err, "API key 1: subject cannot be empty")
+}
+
+func TestAPIKeyAuthenticate(t *testing.T) {
+	a, err := newAPIKeyAuthenticator([]APIKey{{Key: "secret", Subject: "team"}})
+	require.NoError(t, err)
+
+	id, err := a.Authenticate(Credentials{Token: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, "team", id.Subject)
+
+	_, err = a.Authenticate(Credentials{Token: "other"})
+	assert.EqualError(t, err, "unknown API key")
+
+	_, err = a.Authenticate(Credentials{})
+	assert.Equal(t, errNotApplicable, err)
+
+	_, err = a.Authenticate(Credentials{Token: "a.b.c"})
+	assert.Equal(t, errNotApplicable, err)
+}
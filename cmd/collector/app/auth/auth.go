@@ -0,0 +1,175 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Authentication methods reported in Identity.Method
+const (
+	MethodAPIKey = "api-key"
+	MethodJWT    = "jwt"
+	MethodMTLS   = "mtls"
+)
+
+var (
+	// ErrUnauthenticated is returned when a request carries no credentials accepted by any authenticator.
+	ErrUnauthenticated = errors.New("missing or invalid credentials")
+	// errNotApplicable is returned by an authenticator that cannot handle the credentials of a request.
+	errNotApplicable = errors.New("credentials not applicable")
+)
+
+// Identity is the authenticated client that submitted spans.
+type Identity struct {
+	// Subject identifies the client, e.g. the name of the API key, the JWT subject or the certificate common name
+	Subject string
+	// Method is the authentication method that accepted the credentials
+	Method string
+	// Services is the list of service names the client may report spans for, empty means any service
+	Services []string
+}
+
+// AllowsService returns true if the identity may report spans for the given service.
+func (id *Identity) AllowsService(service string) bool {
+	if id == nil || len(id.Services) == 0 {
+		return true
+	}
+	for _, s := range id.Services {
+		if s == service {
+			return true
+		}
+	}
+	return false
+}
+
+// Credentials are the credentials extracted from an incoming request.
+type Credentials struct {
+	// Token is the bearer token of the Authorization header
+	Token string
+	// VerifiedChains are the verified client certificate chains of a TLS connection
+	VerifiedChains [][]*x509.Certificate
+}
+
+// Authenticator authenticates the credentials of a request.
+// It returns errNotApplicable if the credentials are meant for another authenticator.
+type Authenticator interface {
+	Authenticate(credentials Credentials) (*Identity, error)
+}
+
+// Options holds the configuration of the authenticators of the collector ingest endpoints.
+type Options struct {
+	// APIKeysFile is the path to a JSON file with static API keys
+	APIKeysFile string
+	// JWKSFile is the path to a JSON Web Key Set file used to validate JWTs
+	JWKSFile string
+	// JWTIssuer is the expected issuer of JWTs, empty means any issuer
+	JWTIssuer string
+	// JWTAudience is the expected audience of JWTs, empty means any audience
+	JWTAudience string
+	// JWTServicesClaim is the JWT claim holding the allowed service names
+	JWTServicesClaim string
+	// MTLS enables identifying clients by their verified TLS client certificate
+	MTLS bool
+}
+
+// Enabled returns true if at least one authenticator is configured.
+func (o Options) Enabled() bool {
+	return o.APIKeysFile != "" || o.JWKSFile != "" || o.MTLS
+}
+
+// Manager authenticates requests with the configured authenticators, in order.
+// A nil Manager accepts every request without an identity.
+type Manager struct {
+	authenticators []Authenticator
+}
+
+// NewManager creates a Manager with the authenticators enabled by the options,
+// it returns nil if authentication is not enabled.
+func NewManager(options Options) (*Manager, error) {
+	if !options.Enabled() {
+		return nil, nil
+	}
+	m := &Manager{}
+	if options.APIKeysFile != "" {
+		a, err := NewAPIKeyAuthenticator(options.APIKeysFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load API keys: %w", err)
+		}
+		m.authenticators = append(m.authenticators, a)
+	}
+	if options.JWKSFile != "" {
+		a, err := NewJWTAuthenticator(JWTOptions{
+			JWKSFile:      options.JWKSFile,
+			Issuer:        options.JWTIssuer,
+			Audience:      options.JWTAudience,
+			ServicesClaim: options.JWTServicesClaim,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot load JWKS: %w", err)
+		}
+		m.authenticators = append(m.authenticators, a)
+	}
+	if options.MTLS {
+		m.authenticators = append(m.authenticators, NewMTLSAuthenticator())
+	}
+	return m, nil
+}
+
+// Authenticate returns the identity from the first authenticator accepting the credentials.
+func (m *Manager) Authenticate(credentials Credentials) (*Identity, error) {
+	if m == nil {
+		return nil, nil
+	}
+	for _, a := range m.authenticators {
+		id, err := a.Authenticate(credentials)
+		if err == errNotApplicable {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", err, ErrUnauthenticated)
+		}
+		return id, nil
+	}
+	return nil, ErrUnauthenticated
+}
+
+// bearerToken returns the token of an Authorization header value with the Bearer scheme.
+func bearerToken(authorization string) string {
+	const prefix = "bearer "
+	if len(authorization) > len(prefix) && strings.EqualFold(authorization[:len(prefix)], prefix) {
+		return strings.TrimSpace(authorization[len(prefix):])
+	}
+	return ""
+}
+
+type identityKeyType string
+
+const identityKey = identityKeyType("identity")
+
+// WithIdentity returns a new context with the identity.
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey, id)
+}
+
+// GetIdentity returns the identity stored in the context, or nil.
+func GetIdentity(ctx context.Context) *Identity {
+	id, _ := ctx.Value(identityKey).(*Identity)
+	return id
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "file.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestIdentityAllowsService(t *testing.T) {
+	var nilID *Identity
+	assert.True(t, nilID.AllowsService("any"))
+	assert.True(t, (&Identity{}).AllowsService("any"))
+	id := &Identity{Services: []string{"frontend", "backend"}}
+	assert.True(t, id.AllowsService("backend"))
+	assert.False(t, id.AllowsService("db"))
+}
+
+func TestNewManagerDisabled(t *testing.T) {
+	m, err := NewManager(Options{})
+	require.NoError(t, err)
+	assert.Nil(t, m)
+	id, err := m.Authenticate(Credentials{})
+	assert.NoError(t, err)
+	assert.Nil(t, id)
+}
+
+func TestNewManagerErrors(t *testing.T) {
+	_, err := NewManager(Options{APIKeysFile: "/does/not/exist"})
+	assert.Contains(t, err.Error(), "cannot load API keys")
+	_, err = NewManager(Options{JWKSFile: "/does/not/exist"})
+	assert.Contains(t, err.Error(), "cannot load JWKS")
+}
+
+func TestManagerAuthenticate(t *testing.T) {
+	keysFile := writeFile(t, `{"keys": [{"key": "secret", "subject": "team", "services": ["frontend"]}]}`)
+	m, err := NewManager(Options{APIKeysFile: keysFile, MTLS: true})
+	require.NoError(t, err)
+
+	id, err := m.Authenticate(Credentials{Token: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{Subject: "team", Method: MethodAPIKey, Services: []string{"frontend"}}, id)
+
+	_, err = m.Authenticate(Credentials{Token: "wrong"})
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	_, err = m.Authenticate(Credentials{})
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "agent-1"}}
+	id, err = m.Authenticate(Credentials{VerifiedChains: [][]*x509.Certificate{{cert}}})
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{Subject: "agent-1", Method: MethodMTLS}, id)
+}
+
+func TestBearerToken(t *testing.T) {
+	assert.Equal(t, "abc", bearerToken("Bearer abc"))
+	assert.Equal(t, "abc", bearerToken("bearer  abc"))
+	assert.Equal(t, "", bearerToken("Basic abc"))
+	assert.Equal(t, "", bearerToken("Bearer "))
+}
+
+func TestIdentityContext(t *testing.T) {
+	assert.Nil(t, GetIdentity(context.Background()))
+	id := &Identity{Subject: "team"}
+	assert.Equal(t, id, GetIdentity(WithIdentity(context.Background(), id)))
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// CredentialsFromGRPC extracts the credentials of a gRPC request.
+func CredentialsFromGRPC(ctx context.Context) Credentials {
+	var creds Credentials
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			creds.Token = bearerToken(values[0])
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			creds.VerifiedChains = tlsInfo.State.VerifiedChains
+		}
+	}
+	return creds
+}
+
+// NewUnaryServerInterceptor returns an interceptor that rejects unauthenticated calls with
+// Unauthenticated and stores the identity of authenticated calls in their context.
+// Only the methods of the services with the given names, e.g. "jaeger.api_v2.CollectorService",
+// are authenticated, so that other services on the same server remain open.
+func NewUnaryServerInterceptor(m *Manager, services ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if m == nil || !matchesService(info.FullMethod, services) {
+			return handler(ctx, req)
+		}
+		id, err := m.Authenticate(CredentialsFromGRPC(ctx))
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return handler(WithIdentity(ctx, id), req)
+	}
+}
+
+// matchesService returns true if the full method name, e.g. /jaeger.api_v2.CollectorService/PostSpans,
+// belongs to one of the services.
+func matchesService(fullMethod string, services []string) bool {
+	for _, s := range services {
+		if strings.HasPrefix(fullMethod, "/"+s+"/") {
+			return true
+		}
+	}
+	return false
+}
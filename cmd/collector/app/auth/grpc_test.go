@@ -0,0 +1,82 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestCredentialsFromGRPC(t *testing.T) {
+	assert.Equal(t, Credentials{}, CredentialsFromGRPC(context.Background()))
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer secret"))
+	chains := [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "agent"}}}}
+	ctx = peer.NewContext(ctx, &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{VerifiedChains: chains}},
+	})
+	assert.Equal(t, Credentials{Token: "secret", VerifiedChains: chains}, CredentialsFromGRPC(ctx))
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	a, err := newAPIKeyAuthenticator([]APIKey{{Key: "secret", Subject: "team"}})
+	require.NoError(t, err)
+	m := &Manager{authenticators: []Authenticator{a}}
+
+	var gotID *Identity
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		gotID = GetIdentity(ctx)
+		return "ok", nil
+	}
+	guarded := &grpc.UnaryServerInfo{FullMethod: "/test.Guarded/Post"}
+	open := &grpc.UnaryServerInfo{FullMethod: "/test.Open/Get"}
+	withToken := func(token string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+	}
+
+	interceptor := NewUnaryServerInterceptor(m, "test.Guarded")
+
+	_, err = interceptor(context.Background(), nil, guarded, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = interceptor(withToken("wrong"), nil, guarded, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	resp, err := interceptor(withToken("secret"), nil, guarded, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+	require.NotNil(t, gotID)
+	assert.Equal(t, "team", gotID.Subject)
+
+	gotID = nil
+	_, err = interceptor(context.Background(), nil, open, handler)
+	require.NoError(t, err)
+	assert.Nil(t, gotID)
+
+	_, err = NewUnaryServerInterceptor(nil, "test.Guarded")(context.Background(), nil, guarded, handler)
+	assert.NoError(t, err)
+}
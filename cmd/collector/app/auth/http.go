@@ -0,0 +1,43 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package auth
+
+import (
+	"net/http"
+)
+
+// CredentialsFromHTTP extracts the credentials of an HTTP request.
+func CredentialsFromHTTP(r *http.Request) Credentials {
+	credentials := Credentials{Token: bearerToken(r.Header.Get("Authorization"))}
+	if r.TLS != nil {
+		credentials.VerifiedChains = r.TLS.VerifiedChains
+	}
+	return credentials
+}
+
+// NewHTTPHandler returns a handler that rejects unauthenticated requests with 401 and
+// stores the identity of authenticated requests in their context.
+func NewHTTPHandler(m *Manager, h http.Handler) http.Handler {
+	if m == nil {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := m.Authenticate(CredentialsFromHTTP(r))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), id)))
+	})
+}
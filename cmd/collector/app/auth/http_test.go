@@ -0,0 +1,82 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentialsFromHTTP(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/api/traces", nil)
+	r.Header.Set("Authorization", "Bearer secret")
+	assert.Equal(t, Credentials{Token: "secret"}, CredentialsFromHTTP(r))
+
+	chains := [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "agent"}}}}
+	r.TLS = &tls.ConnectionState{VerifiedChains: chains}
+	assert.Equal(t, Credentials{Token: "secret", VerifiedChains: chains}, CredentialsFromHTTP(r))
+}
+
+func TestHTTPHandler(t *testing.T) {
+	var gotID *Identity
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID = GetIdentity(r.Context())
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	// authentication disabled
+	w := httptest.NewRecorder()
+	NewHTTPHandler(nil, next).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/traces", nil))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Nil(t, gotID)
+
+	a, err := newAPIKeyAuthenticator([]APIKey{{Key: "secret", Subject: "team"}})
+	require.NoError(t, err)
+	handler := NewHTTPHandler(&Manager{authenticators: []Authenticator{a}}, next)
+
+	testCases := []struct {
+		authorization string
+		status        int
+	}{
+		{authorization: "", status: http.StatusUnauthorized},
+		{authorization: "Bearer wrong", status: http.StatusUnauthorized},
+		{authorization: "Bearer secret", status: http.StatusAccepted},
+	}
+	for _, test := range testCases {
+		t.Run(test.authorization, func(t *testing.T) {
+			gotID = nil
+			r := httptest.NewRequest(http.MethodPost, "/api/traces", nil)
+			if test.authorization != "" {
+				r.Header.Set("Authorization", test.authorization)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			assert.Equal(t, test.status, w.Code)
+			if test.status == http.StatusAccepted {
+				require.NotNil(t, gotID)
+				assert.Equal(t, "team", gotID.Subject)
+			} else {
+				assert.Nil(t, gotID)
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultServicesClaim is the default JWT claim holding the service names a client may report.
+const DefaultServicesClaim = "services"
+
+// JWTOptions holds the configuration of the JWT authenticator.
+type JWTOptions struct {
+	// JWKSFile is the path to a JSON Web Key Set file with the keys that sign the tokens
+	JWKSFile string
+	// Issuer is the expected "iss" claim, empty means any issuer
+	Issuer string
+	// Audience is the expected "aud" claim, empty means any audience
+	Audience string
+	// ServicesClaim is the claim holding the service names, either a list or a space separated string
+	ServicesClaim string
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+var jwtHashes = map[string]crypto.Hash{
+	"256": crypto.SHA256,
+	"384": crypto.SHA384,
+	"512": crypto.SHA512,
+}
+
+type jwtAuthenticator struct {
+	options JWTOptions
+	keys    map[string]crypto.PublicKey
+	now     func() time.Time
+}
+
+// NewJWTAuthenticator creates an authenticator accepting JWTs signed by a key of the JWKS file.
+// RS256, RS384, RS512, ES256, ES384 and ES512 signatures are supported.
+func NewJWTAuthenticator(options JWTOptions) (Authenticator, error) {
+	return newJWTAuthenticator(options)
+}
+
+func newJWTAuthenticator(options JWTOptions) (*jwtAuthenticator, error) {
+	bytes, err := ioutil.ReadFile(filepath.Clean(options.JWKSFile))
+	if err != nil {
+		return nil, err
+	}
+	keys, err := parseJWKS(bytes)
+	if err != nil {
+		return nil, err
+	}
+	if options.ServicesClaim == "" {
+		options.ServicesClaim = DefaultServicesClaim
+	}
+	return &jwtAuthenticator{
+		options: options,
+		keys:    keys,
+		now:     time.Now,
+	}, nil
+}
+
+func parseJWKS(bytes []byte) (map[string]crypto.PublicKey, error) {
+	var set jsonWebKeySet
+	if err := json.Unmarshal(bytes, &set); err != nil {
+		return nil, fmt.Errorf("cannot parse JWKS: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS has no signing keys")
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(bytes), nil
+}
+
+func (a *jwtAuthenticator) Authenticate(credentials Credentials) (*Identity, error) {
+	parts := strings.Split(credentials.Token, ".")
+	if len(parts) != 3 {
+		return nil, errNotApplicable
+	}
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("invalid JWT header: %w", err)
+	}
+	if err := a.verifySignature(header, parts[0]+"."+parts[1], parts[2]); err != nil {
+		return nil, err
+	}
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("invalid JWT claims: %w", err)
+	}
+	if err := a.verifyClaims(claims); err != nil {
+		return nil, err
+	}
+	subject, _ := claims["sub"].(string)
+	return &Identity{
+		Subject:  subject,
+		Method:   MethodJWT,
+		Services: stringsClaim(claims[a.options.ServicesClaim]),
+	}, nil
+}
+
+func (a *jwtAuthenticator) verifySignature(header jwtHeader, signed, signature string) error {
+	key, ok := a.keys[header.Kid]
+	if !ok {
+		return fmt.Errorf("unknown JWT key %q", header.Kid)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("invalid JWT signature: %w", err)
+	}
+	if len(header.Alg) != 5 {
+		return fmt.Errorf("unsupported JWT algorithm %q", header.Alg)
+	}
+	hash, ok := jwtHashes[header.Alg[2:]]
+	if !ok {
+		return fmt.Errorf("unsupported JWT algorithm %q", header.Alg)
+	}
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if header.Alg[:2] != "RS" {
+			return fmt.Errorf("JWT algorithm %q does not match the RSA key", header.Alg)
+		}
+		if err := rsa.VerifyPKCS1v15(key, hash, digest, sig); err != nil {
+			return errors.New("invalid JWT signature")
+		}
+	case *ecdsa.PublicKey:
+		if header.Alg[:2] != "ES" {
+			return fmt.Errorf("JWT algorithm %q does not match the EC key", header.Alg)
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return errors.New("invalid JWT signature")
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return errors.New("invalid JWT signature")
+		}
+	default:
+		return fmt.Errorf("unsupported JWT algorithm %q", header.Alg)
+	}
+	return nil
+}
+
+func (a *jwtAuthenticator) verifyClaims(claims map[string]interface{}) error {
+	now := a.now()
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.New("JWT has no expiration")
+	}
+	if !now.Before(time.Unix(int64(exp), 0)) {
+		return errors.New("JWT has expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Before(time.Unix(int64(nbf), 0)) {
+		return errors.New("JWT is not valid yet")
+	}
+	if a.options.Issuer != "" && claims["iss"] != a.options.Issuer {
+		return errors.New("unexpected JWT issuer")
+	}
+	if a.options.Audience != "" {
+		found := false
+		for _, aud := range stringsClaim(claims["aud"]) {
+			if aud == a.options.Audience {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.New("unexpected JWT audience")
+		}
+	}
+	return nil
+}
+
+// stringsClaim returns the values of a claim that is either a list or a space separated string.
+func stringsClaim(claim interface{}) []string {
+	switch v := claim.(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	bytes, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes, v)
+}
@@ -0,0 +1,206 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Unix(1600000000, 0)
+
+type testKeys struct {
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return testKeys{rsa: rsaKey, ec: ecKey}
+}
+
+func (k testKeys) jwks() string {
+	enc := func(b *big.Int) string { return base64.RawURLEncoding.EncodeToString(b.Bytes()) }
+	return fmt.Sprintf(`{"keys": [
+		{"kty": "RSA", "kid": "rsa", "use": "sig", "n": %q, "e": %q},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": %q, "y": %q},
+		{"kty": "RSA", "kid": "enc", "use": "enc"}
+	]}`, enc(k.rsa.N), enc(big.NewInt(int64(k.rsa.E))), enc(k.ec.X), enc(k.ec.Y))
+}
+
+func (k testKeys) sign(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	seg := func(v interface{}) string {
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	signed := seg(map[string]string{"alg": alg, "kid": kid}) + "." + seg(claims)
+	hash := jwtHashes[alg[2:]]
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+	var sig []byte
+	if alg[:2] == "RS" {
+		var err error
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k.rsa, hash, digest)
+		require.NoError(t, err)
+	} else {
+		r, s, err := ecdsa.Sign(rand.Reader, k.ec, digest)
+		require.NoError(t, err)
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func newTestJWTAuthenticator(t *testing.T, keys testKeys, options JWTOptions) *jwtAuthenticator {
+	options.JWKSFile = writeFile(t, keys.jwks())
+	a, err := newJWTAuthenticator(options)
+	require.NoError(t, err)
+	a.now = func() time.Time { return testNow }
+	return a
+}
+
+func TestNewJWTAuthenticatorErrors(t *testing.T) {
+	testCases := []struct {
+		jwks string
+		err  string
+	}{
+		{jwks: `not json`, err: "cannot parse JWKS"},
+		{jwks: `{"keys": []}`, err: "JWKS has no signing keys"},
+		{jwks: `{"keys": [{"kty": "oct", "kid": "a"}]}`, err: `invalid key "a": unsupported key type "oct"`},
+		{jwks: `{"keys": [{"kty": "EC", "kid": "a", "crv": "P-1"}]}`, err: `invalid key "a": unsupported curve "P-1"`},
+		{jwks: `{"keys": [{"kty": "EC", "kid": "a", "crv": "P-256", "x": "AQ", "y": "AQ"}]}`, err: `invalid key "a": point is not on the curve`},
+		{jwks: `{"keys": [{"kty": "RSA", "kid": "a", "n": "!"}]}`, err: `invalid key "a"`},
+	}
+	for _, test := range testCases {
+		t.Run(test.err, func(t *testing.T) {
+			_, err := NewJWTAuthenticator(JWTOptions{JWKSFile: writeFile(t, test.jwks)})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.err)
+		})
+	}
+	_, err := NewJWTAuthenticator(JWTOptions{JWKSFile: "/does/not/exist"})
+	assert.Error(t, err)
+}
+
+func TestJWTAuthenticate(t *testing.T) {
+	keys := newTestKeys(t)
+	a := newTestJWTAuthenticator(t, keys, JWTOptions{Issuer: "issuer", Audience: "jaeger"})
+	assert.Equal(t, DefaultServicesClaim, a.options.ServicesClaim)
+
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"sub":      "team",
+			"iss":      "issuer",
+			"aud":      []string{"other", "jaeger"},
+			"exp":      testNow.Add(time.Minute).Unix(),
+			"services": "frontend backend",
+		}
+	}
+	for _, alg := range []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"} {
+		t.Run(alg, func(t *testing.T) {
+			kid := "rsa"
+			if alg[:2] == "ES" {
+				kid = "ec"
+			}
+			id, err := a.Authenticate(Credentials{Token: keys.sign(t, alg, kid, valid())})
+			require.NoError(t, err)
+			assert.Equal(t, &Identity{Subject: "team", Method: MethodJWT, Services: []string{"frontend", "backend"}}, id)
+		})
+	}
+
+	testCases := []struct {
+		name   string
+		alg    string
+		kid    string
+		update func(claims map[string]interface{})
+		err    string
+	}{
+		{name: "unknown key", alg: "RS256", kid: "other", err: `unknown JWT key "other"`},
+		{name: "PSS alg", alg: "PS256", kid: "rsa", err: `JWT algorithm "PS256" does not match the RSA key`},
+		{name: "alg mismatch", alg: "RS256", kid: "ec", err: `JWT algorithm "RS256" does not match the EC key`},
+		{name: "no expiration", alg: "RS256", kid: "rsa", update: func(c map[string]interface{}) { delete(c, "exp") }, err: "JWT has no expiration"},
+		{name: "expired", alg: "ES256", kid: "ec", update: func(c map[string]interface{}) { c["exp"] = testNow.Unix() }, err: "JWT has expired"},
+		{name: "not before", alg: "RS256", kid: "rsa", update: func(c map[string]interface{}) { c["nbf"] = testNow.Add(time.Minute).Unix() }, err: "JWT is not valid yet"},
+		{name: "issuer", alg: "RS256", kid: "rsa", update: func(c map[string]interface{}) { c["iss"] = "other" }, err: "unexpected JWT issuer"},
+		{name: "audience", alg: "RS256", kid: "rsa", update: func(c map[string]interface{}) { c["aud"] = "other" }, err: "unexpected JWT audience"},
+	}
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			claims := valid()
+			if test.update != nil {
+				test.update(claims)
+			}
+			_, err := a.Authenticate(Credentials{Token: keys.sign(t, test.alg, test.kid, claims)})
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestJWTAuthenticateInvalidTokens(t *testing.T) {
+	keys := newTestKeys(t)
+	a := newTestJWTAuthenticator(t, keys, JWTOptions{})
+	token := keys.sign(t, "RS256", "rsa", map[string]interface{}{"exp": testNow.Add(time.Minute).Unix()})
+	parts := strings.Split(token, ".")
+
+	_, err := a.Authenticate(Credentials{Token: "opaque"})
+	assert.Equal(t, errNotApplicable, err)
+
+	_, err = a.Authenticate(Credentials{Token: "!." + parts[1] + "." + parts[2]})
+	assert.Contains(t, err.Error(), "invalid JWT header")
+
+	_, err = a.Authenticate(Credentials{Token: parts[0] + "." + parts[1] + ".!"})
+	assert.Contains(t, err.Error(), "invalid JWT signature")
+
+	_, err = a.Authenticate(Credentials{Token: parts[0] + ".e30." + parts[2]})
+	assert.EqualError(t, err, "invalid JWT signature")
+
+	ecToken := strings.Split(keys.sign(t, "ES256", "ec", map[string]interface{}{}), ".")
+	_, err = a.Authenticate(Credentials{Token: ecToken[0] + "." + ecToken[1] + ".AAAA"})
+	assert.EqualError(t, err, "invalid JWT signature")
+
+	assert.EqualError(t, a.verifySignature(jwtHeader{Alg: "none", Kid: "rsa"}, "", ""), `unsupported JWT algorithm "none"`)
+	assert.EqualError(t, a.verifySignature(jwtHeader{Alg: "RS128", Kid: "rsa"}, "", ""), `unsupported JWT algorithm "RS128"`)
+	a.keys["other"] = struct{}{}
+	assert.EqualError(t, a.verifySignature(jwtHeader{Alg: "HS256", Kid: "other"}, "", ""), `unsupported JWT algorithm "HS256"`)
+
+	id, err := a.Authenticate(Credentials{Token: token})
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{Method: MethodJWT}, id)
+}
+
+func TestStringsClaim(t *testing.T) {
+	assert.Equal(t, []string{"a", "b"}, stringsClaim("a b"))
+	assert.Equal(t, []string{"a", "b"}, stringsClaim([]interface{}{"a", 1.0, "b"}))
+	assert.Nil(t, stringsClaim(1.0))
+	assert.Nil(t, stringsClaim(nil))
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package auth
+
+type mtlsAuthenticator struct{}
+
+// NewMTLSAuthenticator creates an authenticator identifying clients by the common name
+// of their verified TLS client certificate. The server must be configured with a client CA.
+func NewMTLSAuthenticator() Authenticator {
+	return mtlsAuthenticator{}
+}
+
+func (mtlsAuthenticator) Authenticate(credentials Credentials) (*Identity, error) {
+	if len(credentials.VerifiedChains) == 0 || len(credentials.VerifiedChains[0]) == 0 {
+		return nil, errNotApplicable
+	}
+	return &Identity{
+		Subject: credentials.VerifiedChains[0][0].Subject.CommonName,
+		Method:  MethodMTLS,
+	}, nil
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baggage
+
+import (
+	"context"
+
+	baggageProto "github.com/jaegertracing/jaeger/proto-gen/api_v2/baggage"
+	tBaggage "github.com/jaegertracing/jaeger/thrift-gen/baggage"
+)
+
+// GRPCHandler serves the baggage restrictions over gRPC.
+type GRPCHandler struct {
+	manager tBaggage.BaggageRestrictionManager
+}
+
+// NewGRPCHandler creates a handler that serves the baggage restrictions of the manager.
+func NewGRPCHandler(manager tBaggage.BaggageRestrictionManager) GRPCHandler {
+	return GRPCHandler{
+		manager: manager,
+	}
+}
+
+// GetBaggageRestrictions returns the baggage restrictions of a service.
+func (h GRPCHandler) GetBaggageRestrictions(ctx context.Context, param *baggageProto.BaggageRestrictionsParameters) (*baggageProto.BaggageRestrictionsResponse, error) {
+	restrictions, err := h.manager.GetBaggageRestrictions(ctx, param.GetServiceName())
+	if err != nil {
+		return nil, err
+	}
+	resp := &baggageProto.BaggageRestrictionsResponse{
+		BaggageRestrictions: make([]*baggageProto.BaggageRestriction, len(restrictions)),
+	}
+	for i, r := range restrictions {
+		resp.BaggageRestrictions[i] = &baggageProto.BaggageRestriction{
+			BaggageKey:     r.BaggageKey,
+			MaxValueLength: r.MaxValueLength,
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baggage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	baggageProto "github.com/jaegertracing/jaeger/proto-gen/api_v2/baggage"
+	tBaggage "github.com/jaegertracing/jaeger/thrift-gen/baggage"
+)
+
+type mockManager struct {
+	restrictions []*tBaggage.BaggageRestriction
+	err          error
+}
+
+func (m mockManager) GetBaggageRestrictions(_ context.Context, _ string) ([]*tBaggage.BaggageRestriction, error) {
+	return m.restrictions, m.err
+}
+
+func TestGRPCHandler(t *testing.T) {
+	h := NewGRPCHandler(mockManager{restrictions: []*tBaggage.BaggageRestriction{{BaggageKey: "key", MaxValueLength: 10}}})
+	resp, err := h.GetBaggageRestrictions(context.Background(), &baggageProto.BaggageRestrictionsParameters{ServiceName: "foo"})
+	require.NoError(t, err)
+	assert.Equal(t, &baggageProto.BaggageRestrictionsResponse{
+		BaggageRestrictions: []*baggageProto.BaggageRestriction{{BaggageKey: "key", MaxValueLength: 10}},
+	}, resp)
+
+	h = NewGRPCHandler(mockManager{err: errors.New("boom")})
+	resp, err = h.GetBaggageRestrictions(context.Background(), &baggageProto.BaggageRestrictionsParameters{ServiceName: "foo"})
+	assert.EqualError(t, err, "boom")
+	assert.Nil(t, resp)
+}
@@ -0,0 +1,28 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baggage
+
+import "time"
+
+// DefaultMaxValueLength is the maximum length of a baggage value when the restrictions file does not specify one.
+const DefaultMaxValueLength = 2048
+
+// Options holds the configuration of the baggage restriction store.
+type Options struct {
+	// RestrictionsFile is the path to the baggage restrictions file in JSON format
+	RestrictionsFile string
+	// ReloadInterval is the time interval to check and reload the restrictions file, zero disables reloading
+	ReloadInterval time.Duration
+}
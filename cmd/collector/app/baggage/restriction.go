@@ -0,0 +1,35 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baggage
+
+// restriction allows a baggage key, MaxValueLength falls back to the default of the file if zero.
+type restriction struct {
+	BaggageKey     string `json:"baggage_key"`
+	MaxValueLength int32  `json:"max_value_length"`
+}
+
+// serviceRestrictions defines the baggage restrictions of a service.
+type serviceRestrictions struct {
+	Service      string         `json:"service"`
+	Restrictions []*restriction `json:"restrictions"`
+}
+
+// restrictions holds the default baggage restrictions and the service specific ones,
+// which replace the defaults for that service.
+type restrictions struct {
+	DefaultMaxValueLength int32                  `json:"default_max_value_length"`
+	DefaultRestrictions   []*restriction         `json:"default_restrictions"`
+	ServiceRestrictions   []*serviceRestrictions `json:"service_restrictions"`
+}
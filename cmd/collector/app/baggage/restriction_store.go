@@ -0,0 +1,187 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baggage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	tBaggage "github.com/jaegertracing/jaeger/thrift-gen/baggage"
+)
+
+// RestrictionStore serves the baggage restrictions of a JSON file, which is reloaded periodically.
+type RestrictionStore struct {
+	logger *zap.Logger
+
+	storedRestrictions atomic.Value // holds *storedRestrictions
+
+	cancelFunc context.CancelFunc
+}
+
+type storedRestrictions struct {
+	defaultRestrictions []*tBaggage.BaggageRestriction
+	serviceRestrictions map[string][]*tBaggage.BaggageRestriction
+}
+
+// NewRestrictionStore creates a store with the baggage restrictions of the file of the options.
+func NewRestrictionStore(options Options, logger *zap.Logger) (*RestrictionStore, error) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	s := &RestrictionStore{
+		logger:     logger,
+		cancelFunc: cancelFunc,
+	}
+	bytes, err := s.load(options.RestrictionsFile)
+	if err != nil {
+		cancelFunc()
+		return nil, err
+	}
+	if err := s.update(bytes); err != nil {
+		cancelFunc()
+		return nil, err
+	}
+	if options.ReloadInterval > 0 {
+		go s.autoUpdate(ctx, options.ReloadInterval, options.RestrictionsFile, string(bytes))
+	}
+	return s, nil
+}
+
+// GetBaggageRestrictions implements baggage.BaggageRestrictionManager#GetBaggageRestrictions.
+func (s *RestrictionStore) GetBaggageRestrictions(_ context.Context, serviceName string) ([]*tBaggage.BaggageRestriction, error) {
+	stored := s.storedRestrictions.Load().(*storedRestrictions)
+	if r, ok := stored.serviceRestrictions[serviceName]; ok {
+		return r, nil
+	}
+	return stored.defaultRestrictions, nil
+}
+
+// Close stops reloading the restrictions file.
+func (s *RestrictionStore) Close() error {
+	s.cancelFunc()
+	return nil
+}
+
+func (s *RestrictionStore) load(path string) ([]byte, error) {
+	bytes, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baggage restrictions file %s: %w", path, err)
+	}
+	return bytes, nil
+}
+
+func (s *RestrictionStore) autoUpdate(ctx context.Context, interval time.Duration, path string, lastValue string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			lastValue = s.reload(path, lastValue)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *RestrictionStore) reload(path string, lastValue string) string {
+	newValue, err := s.load(path)
+	if err != nil {
+		s.logger.Error("failed to re-load baggage restrictions", zap.Error(err))
+		return lastValue
+	}
+	if lastValue == string(newValue) {
+		return lastValue
+	}
+	if err := s.update(newValue); err != nil {
+		s.logger.Error("failed to update baggage restrictions", zap.Error(err))
+		return lastValue
+	}
+	s.logger.Info("Updated baggage restrictions", zap.String("file", path))
+	return string(newValue)
+}
+
+func (s *RestrictionStore) update(bytes []byte) error {
+	var r restrictions
+	if err := json.Unmarshal(bytes, &r); err != nil {
+		return fmt.Errorf("failed to unmarshal baggage restrictions: %w", err)
+	}
+	stored, err := parseRestrictions(&r)
+	if err != nil {
+		return err
+	}
+	s.storedRestrictions.Store(stored)
+	return nil
+}
+
+func parseRestrictions(r *restrictions) (*storedRestrictions, error) {
+	maxValueLength := r.DefaultMaxValueLength
+	if maxValueLength < 0 {
+		return nil, fmt.Errorf("invalid default max value length %d", maxValueLength)
+	}
+	if maxValueLength == 0 {
+		maxValueLength = DefaultMaxValueLength
+	}
+	defaults, err := parseServiceRestrictions(r.DefaultRestrictions, maxValueLength)
+	if err != nil {
+		return nil, fmt.Errorf("invalid default baggage restrictions: %w", err)
+	}
+	stored := &storedRestrictions{
+		defaultRestrictions: defaults,
+		serviceRestrictions: make(map[string][]*tBaggage.BaggageRestriction, len(r.ServiceRestrictions)),
+	}
+	for _, sr := range r.ServiceRestrictions {
+		if _, ok := stored.serviceRestrictions[sr.Service]; ok {
+			return nil, fmt.Errorf("duplicate baggage restrictions for service %q", sr.Service)
+		}
+		restrictions, err := parseServiceRestrictions(sr.Restrictions, maxValueLength)
+		if err != nil {
+			return nil, fmt.Errorf("invalid baggage restrictions for service %q: %w", sr.Service, err)
+		}
+		stored.serviceRestrictions[sr.Service] = restrictions
+	}
+	return stored, nil
+}
+
+func parseServiceRestrictions(restrictions []*restriction, defaultMaxValueLength int32) ([]*tBaggage.BaggageRestriction, error) {
+	keys := make(map[string]struct{}, len(restrictions))
+	parsed := make([]*tBaggage.BaggageRestriction, 0, len(restrictions))
+	for _, r := range restrictions {
+		if r.BaggageKey == "" {
+			return nil, errors.New("baggage key cannot be empty")
+		}
+		if _, ok := keys[r.BaggageKey]; ok {
+			return nil, fmt.Errorf("duplicate baggage key %q", r.BaggageKey)
+		}
+		if r.MaxValueLength < 0 {
+			return nil, fmt.Errorf("invalid max value length %d for baggage key %q", r.MaxValueLength, r.BaggageKey)
+		}
+		keys[r.BaggageKey] = struct{}{}
+		maxValueLength := r.MaxValueLength
+		if maxValueLength == 0 {
+			maxValueLength = defaultMaxValueLength
+		}
+		parsed = append(parsed, &tBaggage.BaggageRestriction{
+			BaggageKey:     r.BaggageKey,
+			MaxValueLength: maxValueLength,
+		})
+	}
+	return parsed, nil
+}
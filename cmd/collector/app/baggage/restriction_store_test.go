@@ -0,0 +1,143 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baggage
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/pkg/testutils"
+	tBaggage "github.com/jaegertracing/jaeger/thrift-gen/baggage"
+)
+
+const testRestrictions = `{
+	"default_max_value_length": 128,
+	"default_restrictions": [
+		{"baggage_key": "session-id"},
+		{"baggage_key": "tenant", "max_value_length": 16}
+	],
+	"service_restrictions": [
+		{"service": "frontend", "restrictions": [{"baggage_key": "user-id", "max_value_length": 32}]},
+		{"service": "db", "restrictions": []}
+	]
+}`
+
+func writeRestrictions(t *testing.T, path, content string) {
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+}
+
+func TestRestrictionStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baggage.json")
+	writeRestrictions(t, path, testRestrictions)
+
+	store, err := NewRestrictionStore(Options{RestrictionsFile: path}, zap.NewNop())
+	require.NoError(t, err)
+	defer store.Close()
+
+	r, err := store.GetBaggageRestrictions(context.Background(), "frontend")
+	require.NoError(t, err)
+	assert.Equal(t, []*tBaggage.BaggageRestriction{{BaggageKey: "user-id", MaxValueLength: 32}}, r)
+
+	r, err = store.GetBaggageRestrictions(context.Background(), "db")
+	require.NoError(t, err)
+	assert.Empty(t, r)
+
+	r, err = store.GetBaggageRestrictions(context.Background(), "other")
+	require.NoError(t, err)
+	assert.Equal(t, []*tBaggage.BaggageRestriction{
+		{BaggageKey: "session-id", MaxValueLength: 128},
+		{BaggageKey: "tenant", MaxValueLength: 16},
+	}, r)
+}
+
+func TestRestrictionStoreDefaultMaxValueLength(t *testing.T) {
+	stored, err := parseRestrictions(&restrictions{
+		DefaultRestrictions: []*restriction{{BaggageKey: "key"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []*tBaggage.BaggageRestriction{{BaggageKey: "key", MaxValueLength: DefaultMaxValueLength}}, stored.defaultRestrictions)
+}
+
+func TestRestrictionStoreErrors(t *testing.T) {
+	_, err := NewRestrictionStore(Options{RestrictionsFile: "/does/not/exist"}, zap.NewNop())
+	assert.Contains(t, err.Error(), "failed to read baggage restrictions file")
+
+	testCases := []struct {
+		content string
+		err     string
+	}{
+		{content: `not json`, err: "failed to unmarshal baggage restrictions"},
+		{content: `{"default_max_value_length": -1}`, err: "invalid default max value length -1"},
+		{content: `{"default_restrictions": [{"baggage_key": ""}]}`, err: "invalid default baggage restrictions: baggage key cannot be empty"},
+		{content: `{"default_restrictions": [{"baggage_key": "a"}, {"baggage_key": "a"}]}`, err: `invalid default baggage restrictions: duplicate baggage key "a"`},
+		{content: `{"service_restrictions": [{"service": "s", "restrictions": [{"baggage_key": "a", "max_value_length": -5}]}]}`, err: `invalid baggage restrictions for service "s": invalid max value length -5 for baggage key "a"`},
+		{content: `{"service_restrictions": [{"service": "s"}, {"service": "s"}]}`, err: `duplicate baggage restrictions for service "s"`},
+	}
+	for _, test := range testCases {
+		t.Run(test.err, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "baggage.json")
+			writeRestrictions(t, path, test.content)
+			_, err := NewRestrictionStore(Options{RestrictionsFile: path}, zap.NewNop())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.err)
+		})
+	}
+}
+
+func TestRestrictionStoreAutoUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baggage.json")
+	writeRestrictions(t, path, `{"default_restrictions": [{"baggage_key": "a"}]}`)
+
+	logger, logBuffer := testutils.NewLogger()
+	store, err := NewRestrictionStore(Options{RestrictionsFile: path, ReloadInterval: 10 * time.Millisecond}, logger)
+	require.NoError(t, err)
+	defer store.Close()
+
+	// an invalid file keeps the last known restrictions
+	writeRestrictions(t, path, `{"default_restrictions": [{"baggage_key": ""}]}`)
+	for i := 0; i < 100 && !strings.Contains(logBuffer.String(), "failed to update baggage restrictions"); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, strings.Contains(logBuffer.String(), "failed to update baggage restrictions"))
+	r, err := store.GetBaggageRestrictions(context.Background(), "any")
+	require.NoError(t, err)
+	assert.Equal(t, "a", r[0].BaggageKey)
+
+	writeRestrictions(t, path, `{"default_restrictions": [{"baggage_key": "b"}]}`)
+	for i := 0; i < 100; i++ {
+		if r, _ := store.GetBaggageRestrictions(context.Background(), "any"); r[0].BaggageKey == "b" {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	r, err = store.GetBaggageRestrictions(context.Background(), "any")
+	require.NoError(t, err)
+	assert.Equal(t, []*tBaggage.BaggageRestriction{{BaggageKey: "b", MaxValueLength: DefaultMaxValueLength}}, r)
+}
+
+func TestRestrictionStoreReloadMissingFile(t *testing.T) {
+	logger, logBuffer := testutils.NewLogger()
+	store := &RestrictionStore{logger: logger}
+	assert.Equal(t, "last", store.reload("/does/not/exist", "last"))
+	assert.True(t, strings.Contains(logBuffer.String(), "failed to re-load baggage restrictions"))
+}
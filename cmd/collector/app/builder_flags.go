@@ -17,21 +17,79 @@ package app
 
 import (
 	"flag"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/viper"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/auth"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/baggage"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/enrichment"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/filereceiver"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/loadshedding"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/spanfilter"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/spanmetrics"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/throughput"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/validation"
 	"github.com/jaegertracing/jaeger/cmd/flags"
 	"github.com/jaegertracing/jaeger/pkg/config/tlscfg"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/ports"
 )
 
 const (
+	collectorAuthAPIKeysFile      = "collector.auth.api-keys-file"
+	collectorEnrichmentFile       = "collector.enrichment.mapping-file"
+	collectorSpanFilterFile       = "collector.span-filter.rules-file"
+	collectorBaggageFile          = "collector.baggage-restrictions.file"
+	collectorLoadShedding         = "collector.load-shedding.enabled"
+	collectorReservedCritical     = "collector.load-shedding.reserved-critical"
+	collectorReservedHigh         = "collector.load-shedding.reserved-high"
+	collectorReservedNormal       = "collector.load-shedding.reserved-normal"
+	collectorRetryAfter           = "collector.load-shedding.retry-after"
+	collectorBaggageReload        = "collector.baggage-restrictions.reload-interval"
+	collectorAuthJWKSFile         = "collector.auth.jwks-file"
+	collectorAuthJWTIssuer        = "collector.auth.jwt-issuer"
+	collectorAuthJWTAudience      = "collector.auth.jwt-audience"
+	collectorAuthJWTServicesClaim = "collector.auth.jwt-services-claim"
+	collectorAuthMTLS             = "collector.auth.mtls"
 	collectorDynQueueSizeMemory   = "collector.queue-size-memory"
 	collectorGRPCHostPort         = "collector.grpc-server.host-port"
 	collectorHTTPHostPort         = "collector.http-server.host-port"
+	collectorHTTPMaxRequestSize   = "collector.http-server.max-request-size"
 	collectorNumWorkers           = "collector.num-workers"
+	collectorOperationNamesFile   = "collector.operation-names.rules-file"
+	collectorOperationNamesMax    = "collector.operation-names.max-per-service"
+	collectorOperationNamesSvcs   = "collector.operation-names.max-services"
 	collectorQueueSize            = "collector.queue-size"
+	collectorSpanMetricsEnabled   = "collector.spanmetrics.enabled"
+	collectorSpanMetricsBuckets   = "collector.spanmetrics.latency-buckets"
+	collectorSpanMetricsRes       = "collector.spanmetrics.resolution"
+	collectorSpanMetricsRetention = "collector.spanmetrics.retention"
+	collectorMaxTagValueLength    = "collector.span-limits.max-tag-value-length"
+	collectorMaxTags              = "collector.span-limits.max-tags"
+	collectorMaxLogs              = "collector.span-limits.max-logs"
+	collectorMaxSpanSize          = "collector.span-limits.max-span-size"
+	collectorMaxSpansPerTrace     = "collector.span-limits.max-spans-per-trace"
+	collectorTraceWindow          = "collector.span-limits.trace-window"
+	collectorDedupEnabled         = "collector.dedup.enabled"
+	collectorDedupWindow          = "collector.dedup.window"
+	collectorDedupMaxSpans        = "collector.dedup.max-spans"
+	collectorValidation           = "collector.validation.enabled"
+	collectorValidationAction     = "collector.validation.action"
+	collectorMaxClockSkew         = "collector.validation.max-clock-skew"
+	collectorQuarantineFile       = "collector.validation.quarantine-file"
+	collectorQuarantineMaxSize    = "collector.validation.quarantine-max-size"
+	collectorFileReceiverDir      = "collector.file-receiver.directory"
+	collectorFileReceiverState    = "collector.file-receiver.state-file"
+	collectorFileReceiverPoll     = "collector.file-receiver.poll-interval"
 	collectorTags                 = "collector.tags"
+	collectorThroughputEnabled    = "collector.throughput.enabled"
+	collectorThroughputMaxSvcs    = "collector.throughput.max-services"
+	collectorThroughputMaxOps     = "collector.throughput.max-operations"
 	collectorZipkinAllowedHeaders = "collector.zipkin.allowed-headers"
 	collectorZipkinAllowedOrigins = "collector.zipkin.allowed-origins"
 	collectorZipkinHTTPHostPort   = "collector.zipkin.host-port"
@@ -55,6 +113,8 @@ type CollectorOptions struct {
 	NumWorkers int
 	// CollectorHTTPHostPort is the host:port address that the collector service listens in on for http requests
 	CollectorHTTPHostPort string
+	// CollectorHTTPMaxRequestSize is the max size in bytes of the body of the span requests, before and after decompression
+	CollectorHTTPMaxRequestSize int64
 	// CollectorGRPCHostPort is the host:port address that the collector service listens in on for gRPC requests
 	CollectorGRPCHostPort string
 	// TLSGRPC configures secure transport for gRPC endpoint to collect spans
@@ -69,6 +129,40 @@ type CollectorOptions struct {
 	CollectorZipkinAllowedOrigins string
 	// CollectorZipkinAllowedHeaders is a list of headers that the Zipkin collector service allowes the client to use with cross-domain requests
 	CollectorZipkinAllowedHeaders string
+	// OperationNameRulesFile is the path to a JSON file with rules normalizing operation names
+	OperationNameRulesFile string
+	// MaxOperationsPerService caps the number of distinct operation names per service, 0 means no limit
+	MaxOperationsPerService int
+	// MaxOperationServices caps the number of services whose operation names are counted for MaxOperationsPerService
+	MaxOperationServices int
+	// SpanLimits holds the limits on the size of spans and traces
+	SpanLimits sanitizer.SpanLimitsOptions
+	// DedupEnabled determines if the collector drops duplicate spans
+	DedupEnabled bool
+	// Dedup holds the configuration of the span de-duplication
+	Dedup sanitizer.SpanDeduperOptions
+	// Validation holds the configuration of the detection of malformed spans
+	Validation validation.Options
+	// SpanMetricsEnabled determines if the collector computes RED metrics from the spans it processes
+	SpanMetricsEnabled bool
+	// SpanMetrics holds the configuration of the span metrics aggregation
+	SpanMetrics spanmetrics.Options
+	// Tenancy holds the multi-tenancy configuration of the span ingestion endpoints
+	Tenancy tenancy.Options
+	// Auth holds the configuration of the authentication of the span ingestion endpoints
+	Auth auth.Options
+	// Enrichment holds the configuration of the metadata process tags added to the spans
+	Enrichment enrichment.Options
+	// SpanFilter holds the configuration of the rules dropping spans
+	SpanFilter spanfilter.Options
+	// LoadShedding holds the configuration of the priority-aware load shedding of the queue
+	LoadShedding loadshedding.Options
+	// FileReceiver holds the configuration of the ingestion of span files
+	FileReceiver filereceiver.Options
+	// BaggageRestrictions holds the configuration of the baggage restrictions served to the agents and clients
+	BaggageRestrictions baggage.Options
+	// Throughput holds the configuration of the live per-service throughput statistics
+	Throughput throughput.Options
 }
 
 // AddFlags adds flags for CollectorOptions
@@ -77,29 +171,169 @@ func AddFlags(flags *flag.FlagSet) {
 	flags.Int(collectorQueueSize, DefaultQueueSize, "The queue size of the collector")
 	flags.String(collectorGRPCHostPort, ports.PortToHostPort(ports.CollectorGRPC), "The host:port (e.g. 127.0.0.1:14250 or :14250) of the collector's GRPC server")
 	flags.String(collectorHTTPHostPort, ports.PortToHostPort(ports.CollectorHTTP), "The host:port (e.g. 127.0.0.1:14268 or :14268) of the collector's HTTP server")
+	flags.Int64(collectorHTTPMaxRequestSize, handler.DefaultMaxRequestSize, "The max size in bytes of the body of the span requests to the collector's HTTP server, before and after decompression. Larger requests are rejected with 413")
 	flags.String(collectorTags, "", "One or more tags to be added to the Process tags of all spans passing through this collector. Ex: key1=value1,key2=${envVar:defaultValue}")
 	flags.String(collectorZipkinAllowedHeaders, "content-type", "Comma separated list of allowed headers for the Zipkin collector service, default content-type")
 	flags.String(collectorZipkinAllowedOrigins, "*", "Comma separated list of allowed origins for the Zipkin collector service, default accepts all")
 	flags.String(collectorZipkinHTTPHostPort, "", "The host:port (e.g. 127.0.0.1:9411 or :9411) of the collector's Zipkin server (disabled by default)")
 	flags.Uint(collectorDynQueueSizeMemory, 0, "(experimental) The max memory size in MiB to use for the dynamic queue.")
+	flags.String(collectorOperationNamesFile, "", "The path to a JSON file with templates or regexes normalizing operation names per service")
+	flags.Int(collectorMaxTagValueLength, 0, "The max length in bytes of tag and log field values, longer values are truncated (0 means no limit)")
+	flags.Int(collectorMaxTags, 0, "The max number of tags per span including the tags added by the collector, further tags of the client are dropped (0 means no limit)")
+	flags.Int(collectorMaxLogs, 0, "The max number of logs per span, further logs are dropped (0 means no limit)")
+	flags.Int(collectorMaxSpanSize, 0, "The max size in bytes of a span after truncation, larger spans are dropped (0 means no limit)")
+	flags.Int(collectorMaxSpansPerTrace, 0, "The max number of spans per trace accepted within the trace window, further spans are dropped (0 means no limit)")
+	flags.Duration(collectorTraceWindow, sanitizer.DefaultTraceWindow, "The period over which spans per trace are counted for --"+collectorMaxSpansPerTrace)
+	flags.Bool(collectorDedupEnabled, false, "Whether to drop the spans identical to a span received within the de-duplication window, typically delivered twice because of client retries")
+	flags.Duration(collectorDedupWindow, sanitizer.DefaultDedupWindow, "The period during which a span is remembered to detect its duplicates")
+	flags.Int(collectorDedupMaxSpans, sanitizer.DefaultDedupMaxSpans, "The max number of spans remembered to detect duplicates, each one uses about 64 bytes of memory. The oldest spans are forgotten early when the limit is reached")
+	flags.Bool(collectorValidation, false, "Whether to detect malformed spans: zero trace or span IDs, negative durations, start times in the future and spans referencing themselves")
+	flags.String(collectorValidationAction, string(validation.DefaultAction), "What to do with malformed spans: repair (fix the defects that can be fixed, the other spans are quarantined if a quarantine file is set and dropped otherwise), reject or quarantine")
+	flags.Duration(collectorMaxClockSkew, validation.DefaultMaxClockSkew, "The max time a span may start after the current time of the collector before it is considered malformed")
+	flags.String(collectorQuarantineFile, "", "The path to a file where malformed spans are appended as JSON lines with their defects, instead of being dropped")
+	flags.Int64(collectorQuarantineMaxSize, validation.DefaultQuarantineMaxSize, "The size in bytes after which the quarantine file is rotated, replacing the previously rotated file with the .1 suffix")
+	flags.Bool(collectorSpanMetricsEnabled, false, "Whether to compute request rate, error rate and latency metrics per service, operation and span kind from the processed spans. The metrics are exposed on the admin /metrics endpoint when --metrics-backend=prometheus")
+	flags.String(collectorSpanMetricsBuckets, formatBuckets(spanmetrics.DefaultLatencyBuckets), "Comma separated list of the upper bounds in milliseconds of the span latency histogram buckets")
+	flags.Duration(collectorSpanMetricsRes, spanmetrics.DefaultResolution, "The interval between two data points of span metrics kept in memory for the Monitor tab when no metrics storage is configured")
+	flags.Duration(collectorSpanMetricsRetention, spanmetrics.DefaultRetention, "The period of span metrics data points kept in memory for the Monitor tab when no metrics storage is configured")
+	flags.String(collectorEnrichmentFile, "", "The path to a YAML or JSON file with rules adding metadata such as the owning team as process tags to the spans, by service name and process tags glob patterns. The file is reloaded when it changes, it should be replaced atomically")
+	flags.String(collectorSpanFilterFile, "", `The path to a YAML or JSON file with rules dropping the matching spans, e.g. {"rules": [{"name": "health-checks", "drop": "operation =~ \"^GET /health\" || duration < 1ms"}]}. The expressions can use service, operation, duration, flags.debug, flags.sampled, flags.firehose, tags["key"] and process.tags["key"]. The file is reloaded when it changes, it should be replaced atomically`)
+	flags.Bool(collectorLoadShedding, false, "Whether to shed the spans of the lowest priority first when the queue fills up, instead of dropping incoming spans regardless of their priority. Spans are classified as critical (debug), high (error or lower bound sampler), low (firehose) and normal (other spans); batches are shed as a whole and take the priority of their most important span")
+	flags.Float64(collectorReservedCritical, loadshedding.DefaultReservedCritical, "The fraction of the queue capacity reserved for critical spans when load shedding is enabled")
+	flags.Float64(collectorReservedHigh, loadshedding.DefaultReservedHigh, "The fraction of the queue capacity reserved for high priority spans and above when load shedding is enabled")
+	flags.Float64(collectorReservedNormal, loadshedding.DefaultReservedNormal, "The fraction of the queue capacity reserved for normal priority spans and above when load shedding is enabled")
+	flags.Duration(collectorRetryAfter, loadshedding.DefaultRetryAfter, "The delay after which clients are asked to retry shed spans")
+	flags.String(collectorFileReceiverDir, "", "The path to a directory of span files to ingest, for sites that cannot stream spans to the collector. Files are tailed and can be newline-delimited api_v2 JSON PostSpansRequest (*.json, *.jsonl, *.ndjson), size-delimited api_v2 protobuf PostSpansRequest (*.pb, *.protobuf) or newline-delimited Zipkin v2 JSON lists of spans (*.zipkin.json, *.zipkin.jsonl)")
+	flags.String(collectorFileReceiverState, "", "The path to the file where the ingestion progress of each span file is saved, so that files are not ingested again after a restart. Defaults to "+filereceiver.DefaultStateFileName+" in the span files directory")
+	flags.Duration(collectorFileReceiverPoll, filereceiver.DefaultPollInterval, "The interval between two scans of the span files directory for new files and new records")
+	flags.Bool(collectorThroughputEnabled, false, "Whether to track the spans and bytes received and the spans dropped per service and operation over sliding 10s, 1m and 5m windows, served on the admin "+throughput.Route+" endpoint")
+	flags.Int(collectorThroughputMaxSvcs, throughput.DefaultMaxServices, "The max number of services tracked for throughput statistics, the spans of further services are counted as '"+throughput.OtherServices+"'")
+	flags.Int(collectorThroughputMaxOps, throughput.DefaultMaxOperations, "The max number of operations tracked for throughput statistics over all services, the spans of further operations are counted as '"+throughput.OtherOperations+"'")
+	flags.String(collectorBaggageFile, "", "The path to the baggage restrictions file in JSON format, baggage restrictions are not served if empty. See the baggage documentation for the format of the file")
+	flags.Duration(collectorBaggageReload, 0, "Reload interval to check and reload the baggage restrictions file. Zero value means no reloading")
+	flags.String(collectorAuthAPIKeysFile, "", `The path to a JSON file with API keys accepted as bearer tokens by the span ingestion endpoints, e.g. {"keys": [{"key": "secret", "subject": "frontend-team", "services": ["frontend"]}]}`)
+	flags.String(collectorAuthJWKSFile, "", "The path to a JSON Web Key Set file with the keys of the JWTs accepted as bearer tokens by the span ingestion endpoints")
+	flags.String(collectorAuthJWTIssuer, "", "The issuer expected in the JWTs, any issuer is accepted if empty")
+	flags.String(collectorAuthJWTAudience, "", "The audience expected in the JWTs, any audience is accepted if empty")
+	flags.String(collectorAuthJWTServicesClaim, auth.DefaultServicesClaim, "The JWT claim with the service names the client may report spans for, any service is allowed if the claim is absent")
+	flags.Bool(collectorAuthMTLS, false, "Whether to accept clients presenting a TLS client certificate verified against the client CA of the ingestion endpoints, identified by the certificate common name")
+	flags.Int(collectorOperationNamesMax, 0, "The max number of distinct operation names per service, further operations are renamed to '"+sanitizer.OtherOperations+"' (0 means no limit)")
+	flags.Int(collectorOperationNamesSvcs, sanitizer.DefaultMaxServices, "The max number of services whose operation names are counted for the max operations per service, the least recently seen service is forgotten beyond it")
 
 	tlsGRPCFlagsConfig.AddFlags(flags)
 	tlsHTTPFlagsConfig.AddFlags(flags)
 }
 
 // InitFromViper initializes CollectorOptions with properties from viper
-func (cOpts *CollectorOptions) InitFromViper(v *viper.Viper) *CollectorOptions {
+func (cOpts *CollectorOptions) InitFromViper(v *viper.Viper) (*CollectorOptions, error) {
 	cOpts.CollectorGRPCHostPort = ports.FormatHostPort(v.GetString(collectorGRPCHostPort))
 	cOpts.CollectorHTTPHostPort = ports.FormatHostPort(v.GetString(collectorHTTPHostPort))
+	cOpts.CollectorHTTPMaxRequestSize = v.GetInt64(collectorHTTPMaxRequestSize)
 	cOpts.CollectorTags = flags.ParseJaegerTags(v.GetString(collectorTags))
 	cOpts.CollectorZipkinAllowedHeaders = v.GetString(collectorZipkinAllowedHeaders)
 	cOpts.CollectorZipkinAllowedOrigins = v.GetString(collectorZipkinAllowedOrigins)
 	cOpts.CollectorZipkinHTTPHostPort = ports.FormatHostPort(v.GetString(collectorZipkinHTTPHostPort))
 	cOpts.DynQueueSizeMemory = v.GetUint(collectorDynQueueSizeMemory) * 1024 * 1024 // we receive in MiB and store in bytes
 	cOpts.NumWorkers = v.GetInt(collectorNumWorkers)
+	cOpts.OperationNameRulesFile = v.GetString(collectorOperationNamesFile)
+	cOpts.MaxOperationsPerService = v.GetInt(collectorOperationNamesMax)
+	cOpts.MaxOperationServices = v.GetInt(collectorOperationNamesSvcs)
 	cOpts.QueueSize = v.GetInt(collectorQueueSize)
+	cOpts.SpanLimits = sanitizer.SpanLimitsOptions{
+		MaxTagValueLength: v.GetInt(collectorMaxTagValueLength),
+		MaxTags:           v.GetInt(collectorMaxTags),
+		MaxLogs:           v.GetInt(collectorMaxLogs),
+		MaxSpanSize:       v.GetInt(collectorMaxSpanSize),
+		MaxSpansPerTrace:  v.GetInt(collectorMaxSpansPerTrace),
+		TraceWindow:       v.GetDuration(collectorTraceWindow),
+	}
+	cOpts.DedupEnabled = v.GetBool(collectorDedupEnabled)
+	cOpts.Dedup = sanitizer.SpanDeduperOptions{
+		Window:   v.GetDuration(collectorDedupWindow),
+		MaxSpans: v.GetInt(collectorDedupMaxSpans),
+	}
+	cOpts.Validation = validation.Options{
+		Enabled:           v.GetBool(collectorValidation),
+		Action:            validation.Action(v.GetString(collectorValidationAction)),
+		MaxClockSkew:      v.GetDuration(collectorMaxClockSkew),
+		QuarantineFile:    v.GetString(collectorQuarantineFile),
+		QuarantineMaxSize: v.GetInt64(collectorQuarantineMaxSize),
+	}
+	cOpts.SpanMetricsEnabled = v.GetBool(collectorSpanMetricsEnabled)
+	latencyBuckets, err := parseBuckets(v.GetString(collectorSpanMetricsBuckets))
+	if err != nil {
+		return cOpts, fmt.Errorf("invalid %s: %w", collectorSpanMetricsBuckets, err)
+	}
+	cOpts.SpanMetrics = spanmetrics.Options{
+		LatencyBuckets: latencyBuckets,
+		Resolution:     v.GetDuration(collectorSpanMetricsRes),
+		Retention:      v.GetDuration(collectorSpanMetricsRetention),
+	}
+	cOpts.Tenancy = tenancy.InitFromViper(v)
+	cOpts.Enrichment = enrichment.Options{
+		MappingFile: v.GetString(collectorEnrichmentFile),
+	}
+	cOpts.SpanFilter = spanfilter.Options{
+		RulesFile: v.GetString(collectorSpanFilterFile),
+	}
+	cOpts.LoadShedding = loadshedding.Options{
+		Enabled:          v.GetBool(collectorLoadShedding),
+		ReservedCritical: v.GetFloat64(collectorReservedCritical),
+		ReservedHigh:     v.GetFloat64(collectorReservedHigh),
+		ReservedNormal:   v.GetFloat64(collectorReservedNormal),
+		RetryAfter:       v.GetDuration(collectorRetryAfter),
+	}
+	cOpts.FileReceiver = filereceiver.Options{
+		Directory:    v.GetString(collectorFileReceiverDir),
+		StateFile:    v.GetString(collectorFileReceiverState),
+		PollInterval: v.GetDuration(collectorFileReceiverPoll),
+	}
+	cOpts.Throughput = throughput.Options{
+		Enabled:       v.GetBool(collectorThroughputEnabled),
+		MaxServices:   v.GetInt(collectorThroughputMaxSvcs),
+		MaxOperations: v.GetInt(collectorThroughputMaxOps),
+	}
+	cOpts.BaggageRestrictions = baggage.Options{
+		RestrictionsFile: v.GetString(collectorBaggageFile),
+		ReloadInterval:   v.GetDuration(collectorBaggageReload),
+	}
+	cOpts.Auth = auth.Options{
+		APIKeysFile:      v.GetString(collectorAuthAPIKeysFile),
+		JWKSFile:         v.GetString(collectorAuthJWKSFile),
+		JWTIssuer:        v.GetString(collectorAuthJWTIssuer),
+		JWTAudience:      v.GetString(collectorAuthJWTAudience),
+		JWTServicesClaim: v.GetString(collectorAuthJWTServicesClaim),
+		MTLS:             v.GetBool(collectorAuthMTLS),
+	}
 	cOpts.TLSGRPC = tlsGRPCFlagsConfig.InitFromViper(v)
 	cOpts.TLSHTTP = tlsHTTPFlagsConfig.InitFromViper(v)
 
-	return cOpts
+	return cOpts, nil
+}
+
+func formatBuckets(buckets []float64) string {
+	values := make([]string, len(buckets))
+	for i, b := range buckets {
+		values[i] = strconv.FormatFloat(b, 'f', -1, 64)
+	}
+	return strings.Join(values, ",")
+}
+
+// parseBuckets parses a comma separated list of increasing bucket bounds, an empty list means the default buckets.
+func parseBuckets(value string) ([]float64, error) {
+	var buckets []float64
+	for _, s := range strings.Split(value, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		b, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bucket bound %q is not a number", s)
+		}
+		if len(buckets) > 0 && b <= buckets[len(buckets)-1] {
+			return nil, fmt.Errorf("bucket bounds must be in increasing order, got %v after %v", b, buckets[len(buckets)-1])
+		}
+		buckets = append(buckets, b)
+	}
+	return buckets, nil
 }
@@ -16,9 +16,22 @@ package app
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/auth"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/baggage"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/enrichment"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/filereceiver"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/loadshedding"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/spanfilter"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/spanmetrics"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/throughput"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/validation"
 	"github.com/jaegertracing/jaeger/pkg/config"
 )
 
@@ -51,3 +64,279 @@ func TestCollectorOptionsWithFlags_CheckFullHostPort(t *testing.T) {
 	assert.Equal(t, "127.0.0.1:1234", c.CollectorGRPCHostPort)
 	assert.Equal(t, "0.0.0.0:3456", c.CollectorZipkinHTTPHostPort)
 }
+
+func TestCollectorOptionsWithFlags_CheckHTTPMaxRequestSize(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{})
+	c.InitFromViper(v)
+	assert.Equal(t, int64(handler.DefaultMaxRequestSize), c.CollectorHTTPMaxRequestSize)
+
+	command.ParseFlags([]string{"--collector.http-server.max-request-size=1024"})
+	c.InitFromViper(v)
+	assert.Equal(t, int64(1024), c.CollectorHTTPMaxRequestSize)
+}
+
+func TestCollectorOptionsWithFlags_CheckOperationNames(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--collector.operation-names.rules-file=rules.json",
+		"--collector.operation-names.max-per-service=500",
+		"--collector.operation-names.max-services=100",
+	})
+	c.InitFromViper(v)
+
+	assert.Equal(t, "rules.json", c.OperationNameRulesFile)
+	assert.Equal(t, 500, c.MaxOperationsPerService)
+	assert.Equal(t, 100, c.MaxOperationServices)
+}
+
+func TestCollectorOptionsWithFlags_CheckSpanLimits(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--collector.span-limits.max-tag-value-length=1024",
+		"--collector.span-limits.max-tags=100",
+		"--collector.span-limits.max-logs=50",
+		"--collector.span-limits.max-span-size=65536",
+		"--collector.span-limits.max-spans-per-trace=10000",
+		"--collector.span-limits.trace-window=5m",
+	})
+	c.InitFromViper(v)
+
+	assert.Equal(t, sanitizer.SpanLimitsOptions{
+		MaxTagValueLength: 1024,
+		MaxTags:           100,
+		MaxLogs:           50,
+		MaxSpanSize:       65536,
+		MaxSpansPerTrace:  10000,
+		TraceWindow:       5 * time.Minute,
+	}, c.SpanLimits)
+}
+
+func TestCollectorOptionsWithFlags_CheckDedup(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--collector.dedup.enabled=true",
+		"--collector.dedup.window=30s",
+		"--collector.dedup.max-spans=1000",
+	})
+	c.InitFromViper(v)
+
+	assert.True(t, c.DedupEnabled)
+	assert.Equal(t, sanitizer.SpanDeduperOptions{
+		Window:   30 * time.Second,
+		MaxSpans: 1000,
+	}, c.Dedup)
+}
+
+func TestCollectorOptionsWithFlags_CheckSpanMetrics(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{})
+	c.InitFromViper(v)
+
+	assert.False(t, c.SpanMetricsEnabled)
+	assert.Equal(t, spanmetrics.DefaultLatencyBuckets, c.SpanMetrics.LatencyBuckets)
+
+	command.ParseFlags([]string{
+		"--collector.spanmetrics.enabled=true",
+		"--collector.spanmetrics.latency-buckets=1, 2.5,10",
+		"--collector.spanmetrics.resolution=10s",
+		"--collector.spanmetrics.retention=2h",
+	})
+	c.InitFromViper(v)
+
+	assert.True(t, c.SpanMetricsEnabled)
+	assert.Equal(t, spanmetrics.Options{
+		LatencyBuckets: []float64{1, 2.5, 10},
+		Resolution:     10 * time.Second,
+		Retention:      2 * time.Hour,
+	}, c.SpanMetrics)
+}
+
+func TestCollectorOptionsWithFlags_CheckAuth(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--collector.auth.api-keys-file=keys.json",
+		"--collector.auth.jwks-file=jwks.json",
+		"--collector.auth.jwt-issuer=issuer",
+		"--collector.auth.jwt-audience=jaeger",
+		"--collector.auth.mtls=true",
+	})
+	c.InitFromViper(v)
+
+	assert.Equal(t, auth.Options{
+		APIKeysFile:      "keys.json",
+		JWKSFile:         "jwks.json",
+		JWTIssuer:        "issuer",
+		JWTAudience:      "jaeger",
+		JWTServicesClaim: auth.DefaultServicesClaim,
+		MTLS:             true,
+	}, c.Auth)
+}
+
+func TestCollectorOptionsWithFlags_CheckBaggageRestrictions(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--collector.baggage-restrictions.file=baggage.json",
+		"--collector.baggage-restrictions.reload-interval=1m",
+	})
+	c.InitFromViper(v)
+
+	assert.Equal(t, baggage.Options{
+		RestrictionsFile: "baggage.json",
+		ReloadInterval:   time.Minute,
+	}, c.BaggageRestrictions)
+}
+
+func TestCollectorOptionsWithFlags_CheckEnrichment(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--collector.enrichment.mapping-file=mapping.yaml",
+	})
+	c.InitFromViper(v)
+
+	assert.Equal(t, enrichment.Options{MappingFile: "mapping.yaml"}, c.Enrichment)
+}
+
+func TestCollectorOptionsWithFlags_CheckSpanFilter(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--collector.span-filter.rules-file=filter.yaml",
+	})
+	c.InitFromViper(v)
+
+	assert.Equal(t, spanfilter.Options{RulesFile: "filter.yaml"}, c.SpanFilter)
+}
+
+func TestCollectorOptionsWithFlags_CheckValidation(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{})
+	c.InitFromViper(v)
+
+	assert.Equal(t, validation.Options{
+		Action:            validation.DefaultAction,
+		MaxClockSkew:      validation.DefaultMaxClockSkew,
+		QuarantineMaxSize: validation.DefaultQuarantineMaxSize,
+	}, c.Validation)
+
+	command.ParseFlags([]string{
+		"--collector.validation.enabled=true",
+		"--collector.validation.action=quarantine",
+		"--collector.validation.max-clock-skew=10m",
+		"--collector.validation.quarantine-file=quarantine.json",
+		"--collector.validation.quarantine-max-size=1024",
+	})
+	c.InitFromViper(v)
+
+	assert.Equal(t, validation.Options{
+		Enabled:           true,
+		Action:            validation.ActionQuarantine,
+		MaxClockSkew:      10 * time.Minute,
+		QuarantineFile:    "quarantine.json",
+		QuarantineMaxSize: 1024,
+	}, c.Validation)
+}
+
+func TestCollectorOptionsWithFlags_CheckFileReceiver(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--collector.file-receiver.directory=/spans",
+		"--collector.file-receiver.state-file=/state.json",
+		"--collector.file-receiver.poll-interval=1m",
+	})
+	c.InitFromViper(v)
+
+	assert.Equal(t, filereceiver.Options{
+		Directory:    "/spans",
+		StateFile:    "/state.json",
+		PollInterval: time.Minute,
+	}, c.FileReceiver)
+}
+
+func TestCollectorOptionsWithFlags_CheckLoadShedding(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{})
+	c.InitFromViper(v)
+
+	assert.Equal(t, loadshedding.Options{
+		ReservedCritical: loadshedding.DefaultReservedCritical,
+		ReservedHigh:     loadshedding.DefaultReservedHigh,
+		ReservedNormal:   loadshedding.DefaultReservedNormal,
+		RetryAfter:       loadshedding.DefaultRetryAfter,
+	}, c.LoadShedding)
+
+	command.ParseFlags([]string{
+		"--collector.load-shedding.enabled=true",
+		"--collector.load-shedding.reserved-critical=0.1",
+		"--collector.load-shedding.reserved-high=0.2",
+		"--collector.load-shedding.reserved-normal=0.25",
+		"--collector.load-shedding.retry-after=5s",
+	})
+	c.InitFromViper(v)
+
+	assert.Equal(t, loadshedding.Options{
+		Enabled:          true,
+		ReservedCritical: 0.1,
+		ReservedHigh:     0.2,
+		ReservedNormal:   0.25,
+		RetryAfter:       5 * time.Second,
+	}, c.LoadShedding)
+}
+
+func TestCollectorOptionsWithFlags_CheckThroughput(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{})
+	c.InitFromViper(v)
+
+	assert.Equal(t, throughput.Options{
+		MaxServices:   throughput.DefaultMaxServices,
+		MaxOperations: throughput.DefaultMaxOperations,
+	}, c.Throughput)
+
+	command.ParseFlags([]string{
+		"--collector.throughput.enabled=true",
+		"--collector.throughput.max-services=10",
+		"--collector.throughput.max-operations=100",
+	})
+	c.InitFromViper(v)
+
+	assert.Equal(t, throughput.Options{
+		Enabled:       true,
+		MaxServices:   10,
+		MaxOperations: 100,
+	}, c.Throughput)
+}
+
+func TestParseBuckets(t *testing.T) {
+	buckets, err := parseBuckets("1,,2")
+	require.NoError(t, err)
+	assert.Equal(t, []float64{1, 2}, buckets)
+	buckets, err = parseBuckets("")
+	require.NoError(t, err)
+	assert.Nil(t, buckets)
+
+	_, err = parseBuckets("1,x")
+	assert.EqualError(t, err, `bucket bound "x" is not a number`)
+	_, err = parseBuckets("2,1")
+	assert.EqualError(t, err, "bucket bounds must be in increasing order, got 1 after 2")
+}
+
+func TestCollectorOptionsWithFlags_InvalidSpanMetricsBuckets(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{"--collector.spanmetrics.latency-buckets=10,abc"})
+	_, err := c.InitFromViper(v)
+	assert.EqualError(t, err, `invalid collector.spanmetrics.latency-buckets: bucket bound "abc" is not a number`)
+}
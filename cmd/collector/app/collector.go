@@ -21,15 +21,28 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/uber/jaeger-lib/metrics"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/auth"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/baggage"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/enrichment"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/filereceiver"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/server"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/spanfilter"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/spanmetrics"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/throughput"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/validation"
 	"github.com/jaegertracing/jaeger/pkg/healthcheck"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
+	"github.com/jaegertracing/jaeger/proto-gen/api_v2/introspection"
+	"github.com/jaegertracing/jaeger/storage/metricsstore"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
+	tBaggage "github.com/jaegertracing/jaeger/thrift-gen/baggage"
 )
 
 // Collector returns the collector as a manageable unit of work
@@ -43,6 +56,16 @@ type Collector struct {
 	hCheck         *healthcheck.HealthCheck
 	spanProcessor  processor.SpanProcessor
 	spanHandlers   *SpanHandlers
+	spanMetrics    *spanmetrics.Aggregator
+	registerer     prometheus.Registerer
+	tenancyMgr     *tenancy.Manager
+	baggageStore   *baggage.RestrictionStore
+	enricher       *enrichment.Enricher
+	spanFilter     *spanfilter.Filter
+	validator      *validation.Validator
+	fileReceiver   *filereceiver.Receiver
+	throughput     *throughput.Tracker
+	introspection  introspection.SamplingIntrospectionServer
 
 	// state, read only
 	hServer                  *http.Server
@@ -60,6 +83,10 @@ type CollectorParams struct {
 	SpanWriter     spanstore.Writer
 	StrategyStore  strategystore.StrategyStore
 	HealthCheck    *healthcheck.HealthCheck
+	// MetricsRegisterer is where the span metrics are registered, prometheus.DefaultRegisterer by default
+	MetricsRegisterer prometheus.Registerer
+	// SamplingIntrospection explains the adaptive sampling probabilities over gRPC, it may be nil
+	SamplingIntrospection introspection.SamplingIntrospectionServer
 }
 
 // New constructs a new collector component, ready to be started
@@ -71,27 +98,108 @@ func New(params *CollectorParams) *Collector {
 		spanWriter:     params.SpanWriter,
 		strategyStore:  params.StrategyStore,
 		hCheck:         params.HealthCheck,
+		registerer:     params.MetricsRegisterer,
+		introspection:  params.SamplingIntrospection,
 	}
 }
 
 // Start the component and underlying dependencies
 func (c *Collector) Start(builderOpts *CollectorOptions) error {
+	c.tenancyMgr = tenancy.NewManager(&builderOpts.Tenancy)
+	authMgr, err := auth.NewManager(builderOpts.Auth)
+	if err != nil {
+		return fmt.Errorf("could not create authenticators: %w", err)
+	}
+	var baggageManager tBaggage.BaggageRestrictionManager
+	if builderOpts.BaggageRestrictions.RestrictionsFile != "" {
+		baggageStore, err := baggage.NewRestrictionStore(builderOpts.BaggageRestrictions, c.logger)
+		if err != nil {
+			return fmt.Errorf("could not create baggage restriction store: %w", err)
+		}
+		c.baggageStore = baggageStore
+		baggageManager = baggageStore
+	}
+	if builderOpts.Enrichment.MappingFile != "" {
+		enrichmentOpts := builderOpts.Enrichment
+		enrichmentOpts.MetricsFactory = c.metricsFactory
+		enricher, err := enrichment.NewEnricher(enrichmentOpts, c.logger)
+		if err != nil {
+			return fmt.Errorf("could not create span enricher: %w", err)
+		}
+		c.enricher = enricher
+	}
+	if builderOpts.SpanFilter.RulesFile != "" {
+		spanFilterOpts := builderOpts.SpanFilter
+		spanFilterOpts.MetricsFactory = c.metricsFactory
+		spanFilter, err := spanfilter.NewFilter(spanFilterOpts, c.logger)
+		if err != nil {
+			return fmt.Errorf("could not create span filter: %w", err)
+		}
+		c.spanFilter = spanFilter
+	}
+	if builderOpts.Validation.Enabled {
+		validationOpts := builderOpts.Validation
+		validationOpts.MetricsFactory = c.metricsFactory
+		validator, err := validation.NewValidator(validationOpts, c.logger)
+		if err != nil {
+			return fmt.Errorf("could not create span validator: %w", err)
+		}
+		c.validator = validator
+	}
+	if builderOpts.Throughput.Enabled {
+		c.throughput = throughput.NewTracker(builderOpts.Throughput)
+	}
 	handlerBuilder := &SpanHandlerBuilder{
-		SpanWriter:     c.spanWriter,
-		CollectorOpts:  *builderOpts,
-		Logger:         c.logger,
-		MetricsFactory: c.metricsFactory,
+		SpanWriter:        c.spanWriter,
+		CollectorOpts:     *builderOpts,
+		Logger:            c.logger,
+		MetricsFactory:    c.metricsFactory,
+		TenancyMgr:        c.tenancyMgr,
+		Validator:         c.validator,
+		Enricher:          c.enricher,
+		ThroughputTracker: c.throughput,
+	}
+	if c.spanFilter != nil {
+		handlerBuilder.SpanFilter = c.spanFilter.Accept
 	}
 
-	c.spanProcessor = handlerBuilder.BuildSpanProcessor()
+	if builderOpts.SpanMetricsEnabled {
+		spanMetricsOpts := builderOpts.SpanMetrics
+		spanMetricsOpts.Registerer = c.registerer
+		if spanMetricsOpts.Registerer == nil {
+			spanMetricsOpts.Registerer = prometheus.DefaultRegisterer
+		}
+		spanMetrics, err := spanmetrics.NewAggregator(spanMetricsOpts)
+		if err != nil {
+			return fmt.Errorf("could not create span metrics aggregator: %w", err)
+		}
+		c.spanMetrics = spanMetrics
+		handlerBuilder.PreSave = spanMetrics.ProcessSpan
+	}
+	if aggregator, ok := c.strategyStore.(strategystore.Aggregator); ok {
+		if handlerBuilder.PreSave != nil {
+			handlerBuilder.PreSave = ChainedProcessSpan(handlerBuilder.PreSave, aggregator.HandleRootSpan)
+		} else {
+			handlerBuilder.PreSave = aggregator.HandleRootSpan
+		}
+	}
+
+	spanProcessor, err := handlerBuilder.BuildSpanProcessor()
+	if err != nil {
+		return fmt.Errorf("could not create span processor: %w", err)
+	}
+	c.spanProcessor = spanProcessor
 	c.spanHandlers = handlerBuilder.BuildHandlers(c.spanProcessor)
 
 	grpcServer, err := server.StartGRPCServer(&server.GRPCServerParams{
-		HostPort:      builderOpts.CollectorGRPCHostPort,
-		Handler:       c.spanHandlers.GRPCHandler,
-		TLSConfig:     builderOpts.TLSGRPC,
-		SamplingStore: c.strategyStore,
-		Logger:        c.logger,
+		HostPort:              builderOpts.CollectorGRPCHostPort,
+		Handler:               c.spanHandlers.GRPCHandler,
+		TLSConfig:             builderOpts.TLSGRPC,
+		SamplingStore:         c.strategyStore,
+		BaggageManager:        baggageManager,
+		SamplingIntrospection: c.introspection,
+		Logger:                c.logger,
+		AuthMgr:               authMgr,
 	})
 	if err != nil {
 		return fmt.Errorf("could not start gRPC collector %w", err)
@@ -100,12 +208,17 @@ func (c *Collector) Start(builderOpts *CollectorOptions) error {
 
 	httpServer, err := server.StartHTTPServer(&server.HTTPServerParams{
 		HostPort:       builderOpts.CollectorHTTPHostPort,
+		MaxRequestSize: builderOpts.CollectorHTTPMaxRequestSize,
 		Handler:        c.spanHandlers.JaegerBatchesHandler,
+		SpanProcessor:  c.spanProcessor,
 		TLSConfig:      builderOpts.TLSHTTP,
 		HealthCheck:    c.hCheck,
 		MetricsFactory: c.metricsFactory,
 		SamplingStore:  c.strategyStore,
+		BaggageManager: baggageManager,
 		Logger:         c.logger,
+		TenancyMgr:     c.tenancyMgr,
+		AuthMgr:        authMgr,
 	})
 	if err != nil {
 		return fmt.Errorf("could not start the HTTP server %w", err)
@@ -122,12 +235,25 @@ func (c *Collector) Start(builderOpts *CollectorOptions) error {
 		AllowedOrigins: builderOpts.CollectorZipkinAllowedOrigins,
 		Logger:         c.logger,
 		MetricsFactory: c.metricsFactory,
+		TenancyMgr:     c.tenancyMgr,
+		AuthMgr:        authMgr,
 	})
 	if err != nil {
 		return fmt.Errorf("could not start the Zipkin server %w", err)
 	}
 	c.zkServer = zkServer
 
+	if builderOpts.FileReceiver.Directory != "" {
+		fileReceiverOpts := builderOpts.FileReceiver
+		fileReceiverOpts.MetricsFactory = c.metricsFactory
+		fileReceiver, err := filereceiver.NewReceiver(fileReceiverOpts, c.spanProcessor, c.spanHandlers.ZipkinSpansHandler, c.logger)
+		if err != nil {
+			return fmt.Errorf("could not create the file receiver: %w", err)
+		}
+		fileReceiver.Start()
+		c.fileReceiver = fileReceiver
+	}
+
 	c.publishOpts(builderOpts)
 
 	return nil
@@ -155,6 +281,10 @@ func (c *Collector) Close() error {
 		defer cancel()
 	}
 
+	if c.fileReceiver != nil {
+		_ = c.fileReceiver.Close()
+	}
+
 	// Zipkin server
 	if c.zkServer != nil {
 		timeout, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -168,6 +298,26 @@ func (c *Collector) Close() error {
 		c.logger.Error("failed to close span processor.", zap.Error(err))
 	}
 
+	if c.spanMetrics != nil {
+		_ = c.spanMetrics.Close()
+	}
+
+	if c.baggageStore != nil {
+		_ = c.baggageStore.Close()
+	}
+
+	if c.enricher != nil {
+		_ = c.enricher.Close()
+	}
+
+	if c.spanFilter != nil {
+		_ = c.spanFilter.Close()
+	}
+
+	if c.validator != nil {
+		_ = c.validator.Close()
+	}
+
 	// watchers actually never return errors from Close
 	_ = c.tlsGRPCCertWatcherCloser.Close()
 	_ = c.tlsHTTPCertWatcherCloser.Close()
@@ -175,6 +325,25 @@ func (c *Collector) Close() error {
 	return nil
 }
 
+// SpanMetricsReader returns the reader of the RED metrics computed from the processed spans,
+// or nil if span metrics are disabled.
+func (c *Collector) SpanMetricsReader() metricsstore.Reader {
+	if c.spanMetrics == nil {
+		return nil
+	}
+	return c.spanMetrics
+}
+
+// ThroughputHandler returns the handler of the live per-service throughput statistics endpoint,
+// or nil if throughput tracking is disabled.
+func (c *Collector) ThroughputHandler() http.Handler {
+	if c.throughput == nil {
+		return nil
+	}
+	queue, _ := c.spanProcessor.(throughput.QueueReporter)
+	return throughput.NewHTTPHandler(c.throughput, queue)
+}
+
 // SpanHandlers returns span handlers used by the Collector.
 func (c *Collector) SpanHandlers() *SpanHandlers {
 	return c.spanHandlers
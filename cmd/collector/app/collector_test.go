@@ -16,15 +16,29 @@ package app
 
 import (
 	"context"
+	"encoding/json"
 	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/uber/jaeger-lib/metrics/fork"
 	"github.com/uber/jaeger-lib/metrics/metricstest"
 	"go.uber.org/zap"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/baggage"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/enrichment"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/filereceiver"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/spanfilter"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/throughput"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/validation"
+	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/pkg/healthcheck"
 	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
 )
@@ -53,6 +67,173 @@ func TestNewCollector(t *testing.T) {
 	c.Start(collectorOpts)
 
 	// verify
+	assert.Nil(t, c.SpanMetricsReader())
+	assert.NoError(t, c.Close())
+}
+
+func TestCollectorSpanMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	// the collector can be restarted in the same process
+	for i := 0; i < 2; i++ {
+		c := New(&CollectorParams{
+			ServiceName:       "collector",
+			Logger:            zap.NewNop(),
+			MetricsFactory:    metricstest.NewFactory(time.Hour),
+			SpanWriter:        &fakeSpanWriter{},
+			StrategyStore:     &mockStrategyStore{},
+			HealthCheck:       healthcheck.New(),
+			MetricsRegisterer: registry,
+		})
+		collectorOpts := &CollectorOptions{SpanMetricsEnabled: true}
+
+		require.NoError(t, c.Start(collectorOpts))
+		assert.NotNil(t, c.SpanMetricsReader())
+		assert.NoError(t, c.Close())
+	}
+}
+
+func TestCollectorThroughput(t *testing.T) {
+	newCollector := func() *Collector {
+		return New(&CollectorParams{
+			ServiceName:    "collector",
+			Logger:         zap.NewNop(),
+			MetricsFactory: metricstest.NewFactory(time.Hour),
+			SpanWriter:     &fakeSpanWriter{},
+			StrategyStore:  &mockStrategyStore{},
+			HealthCheck:    healthcheck.New(),
+		})
+	}
+
+	c := newCollector()
+	require.NoError(t, c.Start(&CollectorOptions{}))
+	assert.Nil(t, c.ThroughputHandler())
+	assert.NoError(t, c.Close())
+
+	c = newCollector()
+	require.NoError(t, c.Start(&CollectorOptions{QueueSize: 10, Throughput: throughput.Options{Enabled: true}}))
+	handler := c.ThroughputHandler()
+	require.NotNil(t, handler)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, throughput.Route, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var response throughput.Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, &throughput.QueueStats{Capacity: 10}, response.Queue)
+	assert.NoError(t, c.Close())
+}
+
+func TestCollectorBaggageRestrictions(t *testing.T) {
+	newCollector := func() *Collector {
+		return New(&CollectorParams{
+			ServiceName:    "collector",
+			Logger:         zap.NewNop(),
+			MetricsFactory: metricstest.NewFactory(time.Hour),
+			SpanWriter:     &fakeSpanWriter{},
+			StrategyStore:  &mockStrategyStore{},
+			HealthCheck:    healthcheck.New(),
+		})
+	}
+
+	err := newCollector().Start(&CollectorOptions{BaggageRestrictions: baggage.Options{RestrictionsFile: "/does/not/exist"}})
+	assert.Contains(t, err.Error(), "could not create baggage restriction store")
+
+	path := filepath.Join(t.TempDir(), "baggage.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"default_restrictions": [{"baggage_key": "key"}]}`), 0600))
+	c := newCollector()
+	require.NoError(t, c.Start(&CollectorOptions{BaggageRestrictions: baggage.Options{RestrictionsFile: path}}))
+	require.NotNil(t, c.baggageStore)
+	assert.NoError(t, c.Close())
+}
+
+func TestCollectorEnrichment(t *testing.T) {
+	newCollector := func() *Collector {
+		return New(&CollectorParams{
+			ServiceName:    "collector",
+			Logger:         zap.NewNop(),
+			MetricsFactory: metricstest.NewFactory(time.Hour),
+			SpanWriter:     &fakeSpanWriter{},
+			StrategyStore:  &mockStrategyStore{},
+			HealthCheck:    healthcheck.New(),
+		})
+	}
+
+	err := newCollector().Start(&CollectorOptions{Enrichment: enrichment.Options{MappingFile: "/does/not/exist"}})
+	assert.Contains(t, err.Error(), "could not create span enricher")
+
+	path := filepath.Join(t.TempDir(), "mapping.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte("rules: [{tags: {team: a}}]"), 0600))
+	c := newCollector()
+	require.NoError(t, c.Start(&CollectorOptions{Enrichment: enrichment.Options{MappingFile: path}}))
+	require.NotNil(t, c.enricher)
+	assert.NoError(t, c.Close())
+}
+
+func TestCollectorSpanFilter(t *testing.T) {
+	newCollector := func() *Collector {
+		return New(&CollectorParams{
+			ServiceName:    "collector",
+			Logger:         zap.NewNop(),
+			MetricsFactory: metricstest.NewFactory(time.Hour),
+			SpanWriter:     &fakeSpanWriter{},
+			StrategyStore:  &mockStrategyStore{},
+			HealthCheck:    healthcheck.New(),
+		})
+	}
+
+	err := newCollector().Start(&CollectorOptions{SpanFilter: spanfilter.Options{RulesFile: "/does/not/exist"}})
+	assert.Contains(t, err.Error(), "could not create span filter")
+
+	path := filepath.Join(t.TempDir(), "filter.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`rules: [{name: health, drop: 'operation == "health"'}]`), 0600))
+	c := newCollector()
+	require.NoError(t, c.Start(&CollectorOptions{SpanFilter: spanfilter.Options{RulesFile: path}}))
+	require.NotNil(t, c.spanFilter)
+	assert.False(t, c.spanProcessor.(*spanProcessor).filterSpan(&model.Span{OperationName: "health"}))
+	assert.NoError(t, c.Close())
+}
+
+func TestCollectorValidation(t *testing.T) {
+	newCollector := func() *Collector {
+		return New(&CollectorParams{
+			ServiceName:    "collector",
+			Logger:         zap.NewNop(),
+			MetricsFactory: metricstest.NewFactory(time.Hour),
+			SpanWriter:     &fakeSpanWriter{},
+			StrategyStore:  &mockStrategyStore{},
+			HealthCheck:    healthcheck.New(),
+		})
+	}
+
+	err := newCollector().Start(&CollectorOptions{Validation: validation.Options{Enabled: true, Action: validation.ActionQuarantine}})
+	assert.EqualError(t, err, "could not create span validator: the quarantine action requires a quarantine file")
+
+	c := newCollector()
+	require.NoError(t, c.Start(&CollectorOptions{Validation: validation.Options{Enabled: true, Action: validation.ActionReject}}))
+	require.NotNil(t, c.validator)
+	assert.Nil(t, c.spanProcessor.(*spanProcessor).sanitizer(&model.Span{Process: &model.Process{ServiceName: "svc"}}))
+	assert.NoError(t, c.Close())
+}
+
+func TestCollectorFileReceiver(t *testing.T) {
+	newCollector := func() *Collector {
+		return New(&CollectorParams{
+			ServiceName:    "collector",
+			Logger:         zap.NewNop(),
+			MetricsFactory: metricstest.NewFactory(time.Hour),
+			SpanWriter:     &fakeSpanWriter{},
+			StrategyStore:  &mockStrategyStore{},
+			HealthCheck:    healthcheck.New(),
+		})
+	}
+
+	c := newCollector()
+	err := c.Start(&CollectorOptions{FileReceiver: filereceiver.Options{Directory: "/does/not/exist"}})
+	assert.Contains(t, err.Error(), "could not create the file receiver")
+	assert.NoError(t, c.Close())
+
+	c = newCollector()
+	require.NoError(t, c.Start(&CollectorOptions{FileReceiver: filereceiver.Options{Directory: t.TempDir()}}))
+	assert.NotNil(t, c.fileReceiver)
 	assert.NoError(t, c.Close())
 }
 
@@ -63,6 +244,34 @@ func (m *mockStrategyStore) GetSamplingStrategy(_ context.Context, serviceName s
 	return &sampling.SamplingStrategyResponse{}, nil
 }
 
+type aggregatorStrategyStore struct {
+	mockStrategyStore
+	rootSpans []*model.Span
+}
+
+func (m *aggregatorStrategyStore) HandleRootSpan(span *model.Span, tenant string) {
+	m.rootSpans = append(m.rootSpans, span)
+}
+
+func TestCollectorStrategyStoreAggregator(t *testing.T) {
+	strategyStore := &aggregatorStrategyStore{}
+	c := New(&CollectorParams{
+		ServiceName:       "collector",
+		Logger:            zap.NewNop(),
+		MetricsFactory:    metricstest.NewFactory(time.Hour),
+		SpanWriter:        &fakeSpanWriter{},
+		StrategyStore:     strategyStore,
+		HealthCheck:       healthcheck.New(),
+		MetricsRegisterer: prometheus.NewRegistry(),
+	})
+	// the root spans are handled after the span metrics
+	require.NoError(t, c.Start(&CollectorOptions{SpanMetricsEnabled: true}))
+	span := &model.Span{OperationName: "GET", Process: &model.Process{ServiceName: "frontend"}}
+	c.spanProcessor.(*spanProcessor).processSpan(span, "")
+	assert.Equal(t, []*model.Span{span}, strategyStore.rootSpans)
+	assert.NoError(t, c.Close())
+}
+
 func TestCollector_PublishOpts(t *testing.T) {
 	// prepare
 	hc := healthcheck.New()
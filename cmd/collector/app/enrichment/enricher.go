@@ -0,0 +1,117 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enrichment
+
+import (
+	"sync/atomic"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/fswatcher"
+)
+
+// Options holds the configuration of the span metadata enrichment.
+type Options struct {
+	// MappingFile is the path to the YAML or JSON file with the metadata rules, enrichment is disabled if empty
+	MappingFile string
+	// MetricsFactory is used to report the mapping file reloads
+	MetricsFactory metrics.Factory
+	// NewWatcher creates the watcher of the mapping file, fswatcher.NewWatcher is used if nil
+	NewWatcher func() (fswatcher.Watcher, error)
+}
+
+type enricherMetrics struct {
+	// Number of successful reloads of the mapping file
+	Reloads metrics.Counter `metric:"enrichment.reloads" tags:"result=ok"`
+	// Number of failed reloads of the mapping file, the last valid rules are kept
+	ReloadErrors metrics.Counter `metric:"enrichment.reloads" tags:"result=err"`
+}
+
+// Enricher adds metadata such as the owning team or the on-call rotation as process tags
+// to the spans, according to the rules of a mapping file which is reloaded when it changes.
+type Enricher struct {
+	options Options
+	logger  *zap.Logger
+	metrics enricherMetrics
+	rules   atomic.Value // []rule
+	watcher *fswatcher.FileWatcher
+}
+
+// NewEnricher loads the mapping file and starts watching it for changes.
+func NewEnricher(options Options, logger *zap.Logger) (*Enricher, error) {
+	if options.MetricsFactory == nil {
+		options.MetricsFactory = metrics.NullFactory
+	}
+	rules, err := loadRules(options.MappingFile)
+	if err != nil {
+		return nil, err
+	}
+	e := &Enricher{
+		options: options,
+		logger:  logger,
+	}
+	metrics.MustInit(&e.metrics, options.MetricsFactory, nil)
+	e.rules.Store(rules)
+
+	if e.watcher, err = fswatcher.NewFileWatcher(options.MappingFile, e.reload, logger, options.NewWatcher); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Enrich adds the process tags of all the rules matching the span. Tags already present
+// in the process are left untouched, and when several rules set the same tag the first one wins.
+// The process is modified in place, it must not be shared with spans enriched concurrently.
+func (e *Enricher) Enrich(span *model.Span) {
+	if span.Process == nil {
+		return
+	}
+	rules := e.rules.Load().([]rule)
+	added := false
+	for _, r := range rules {
+		if !r.matches(span.Process) {
+			continue
+		}
+		for _, tag := range r.tags {
+			if _, ok := model.KeyValues(span.Process.Tags).FindByKey(tag.Key); ok {
+				continue
+			}
+			span.Process.Tags = append(span.Process.Tags, tag)
+			added = true
+		}
+	}
+	if added {
+		model.KeyValues(span.Process.Tags).Sort()
+	}
+}
+
+func (e *Enricher) reload() {
+	rules, err := loadRules(e.options.MappingFile)
+	if err != nil {
+		e.metrics.ReloadErrors.Inc(1)
+		e.logger.Error("failed to reload the enrichment mapping file, keeping the previous rules", zap.Error(err))
+		return
+	}
+	e.rules.Store(rules)
+	e.metrics.Reloads.Inc(1)
+	e.logger.Info("reloaded the enrichment mapping file", zap.String("file", e.options.MappingFile), zap.Int("rules", len(rules)))
+}
+
+// Close stops watching the mapping file.
+func (e *Enricher) Close() error {
+	return e.watcher.Close()
+}
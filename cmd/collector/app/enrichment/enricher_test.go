@@ -0,0 +1,135 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enrichment
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/fswatcher"
+)
+
+const testMapping = `
+rules:
+  - service: checkout
+    process_tags:
+      hostname: "eu-*"
+    tags:
+      region: eu
+  - service: "check*"
+    tags:
+      team: checkout
+      region: unknown
+  - tags:
+      cost_center: shared
+`
+
+func TestEnrich(t *testing.T) {
+	file := writeMapping(t, t.TempDir(), "mapping.yaml", testMapping)
+	e, err := NewEnricher(Options{MappingFile: file}, zap.NewNop())
+	require.NoError(t, err)
+	defer e.Close()
+
+	span := &model.Span{Process: &model.Process{
+		ServiceName: "checkout",
+		Tags:        []model.KeyValue{model.String("hostname", "eu-1"), model.String("team", "from-client")},
+	}}
+	e.Enrich(span)
+	assert.Equal(t, []model.KeyValue{
+		model.String("cost_center", "shared"),
+		model.String("hostname", "eu-1"),
+		model.String("region", "eu"),
+		model.String("team", "from-client"),
+	}, span.Process.Tags)
+
+	span = &model.Span{Process: &model.Process{ServiceName: "frontend"}}
+	e.Enrich(span)
+	assert.Equal(t, []model.KeyValue{model.String("cost_center", "shared")}, span.Process.Tags)
+
+	assert.NotPanics(t, func() { e.Enrich(&model.Span{}) })
+}
+
+func TestEnricherReload(t *testing.T) {
+	dir := t.TempDir()
+	file := writeMapping(t, dir, "mapping.yaml", testMapping)
+	mf := metricstest.NewFactory(0)
+	e, err := NewEnricher(Options{MappingFile: file, MetricsFactory: mf}, zap.NewNop())
+	require.NoError(t, err)
+	defer e.Close()
+
+	// other files in the directory are ignored
+	writeMapping(t, dir, "other.yaml", "invalid")
+	require.NoError(t, ioutil.WriteFile(file, []byte("rules: [{tags: {team: new}}]"), 0600))
+	assert.Eventually(t, func() bool {
+		span := &model.Span{Process: &model.Process{ServiceName: "frontend"}}
+		e.Enrich(span)
+		return len(span.Process.Tags) == 1 && span.Process.Tags[0].VStr == "new"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// an invalid file does not replace the rules
+	require.NoError(t, ioutil.WriteFile(file, []byte("rules: ["), 0600))
+	assert.Eventually(t, func() bool {
+		counters, _ := mf.Snapshot()
+		return counters["enrichment.reloads|result=err"] > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	span := &model.Span{Process: &model.Process{ServiceName: "frontend"}}
+	e.Enrich(span)
+	assert.Equal(t, []model.KeyValue{model.String("team", "new")}, span.Process.Tags)
+}
+
+type fakeWatcher struct {
+	fswatcher.Watcher
+	addErr error
+	closed bool
+}
+
+func (w *fakeWatcher) Add(string) error {
+	return w.addErr
+}
+
+func (w *fakeWatcher) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestNewEnricherErrors(t *testing.T) {
+	_, err := NewEnricher(Options{MappingFile: filepath.Join(t.TempDir(), "missing.yaml")}, zap.NewNop())
+	assert.Contains(t, err.Error(), "failed to read the enrichment mapping file")
+
+	file := writeMapping(t, t.TempDir(), "mapping.yaml", testMapping)
+	_, err = NewEnricher(Options{
+		MappingFile: file,
+		NewWatcher:  func() (fswatcher.Watcher, error) { return nil, errors.New("no watcher") },
+	}, zap.NewNop())
+	assert.EqualError(t, err, "no watcher")
+
+	watcher := &fakeWatcher{addErr: os.ErrNotExist}
+	_, err = NewEnricher(Options{
+		MappingFile: file,
+		NewWatcher:  func() (fswatcher.Watcher, error) { return watcher, nil },
+	}, zap.NewNop())
+	assert.Equal(t, os.ErrNotExist, err)
+	assert.True(t, watcher.closed)
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enrichment
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/glob"
+)
+
+// mapping is the content of the mapping file, e.g.
+//
+//	rules:
+//	  - service: "checkout-*"
+//	    process_tags:
+//	      hostname: "eu-*"
+//	    tags:
+//	      team: checkout
+//	      tier: "1"
+//	      cost_center: cc-42
+//	      oncall: checkout-primary
+type mapping struct {
+	Rules []ruleJSON `json:"rules" yaml:"rules"`
+}
+
+type ruleJSON struct {
+	// Service is a glob pattern matched against the service name, any service matches if empty
+	Service string `json:"service" yaml:"service"`
+	// ProcessTags are glob patterns matched against the process tags of the span, all of them must match
+	ProcessTags map[string]string `json:"process_tags" yaml:"process_tags"`
+	// Tags are the process tags added to the matching spans
+	Tags map[string]string `json:"tags" yaml:"tags"`
+}
+
+// rule is a validated ruleJSON, with its tags in a deterministic order.
+type rule struct {
+	service     string
+	processTags map[string]string
+	tags        []model.KeyValue
+}
+
+// loadRules reads the mapping file, in JSON if its extension is .json and in YAML otherwise.
+func loadRules(file string) ([]rule, error) {
+	bytes, err := ioutil.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the enrichment mapping file: %w", err)
+	}
+	if len(bytes) == 0 {
+		// most likely the file is being written
+		return nil, fmt.Errorf("the enrichment mapping file %s is empty", file)
+	}
+	var m mapping
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		err = json.Unmarshal(bytes, &m)
+	} else {
+		err = yaml.UnmarshalStrict(bytes, &m)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the enrichment mapping file: %w", err)
+	}
+	return parseRules(m.Rules)
+}
+
+func parseRules(rulesJSON []ruleJSON) ([]rule, error) {
+	rules := make([]rule, 0, len(rulesJSON))
+	for i, r := range rulesJSON {
+		if err := glob.Validate(r.Service); err != nil {
+			return nil, fmt.Errorf("invalid service pattern of rule %d: %w", i, err)
+		}
+		for key, pattern := range r.ProcessTags {
+			if err := glob.Validate(pattern); err != nil {
+				return nil, fmt.Errorf("invalid pattern of process tag %s of rule %d: %w", key, i, err)
+			}
+		}
+		if len(r.Tags) == 0 {
+			return nil, fmt.Errorf("rule %d has no tags", i)
+		}
+		tags := make(model.KeyValues, 0, len(r.Tags))
+		for key, value := range r.Tags {
+			if key == "" {
+				return nil, fmt.Errorf("rule %d has a tag with an empty key", i)
+			}
+			tags = append(tags, model.String(key, value))
+		}
+		tags.Sort()
+		rules = append(rules, rule{
+			service:     r.Service,
+			processTags: r.ProcessTags,
+			tags:        tags,
+		})
+	}
+	return rules, nil
+}
+
+// matches returns true if the rule applies to the process.
+func (r rule) matches(process *model.Process) bool {
+	if r.service != "" && !glob.Match(r.service, process.ServiceName) {
+		return false
+	}
+	for key, pattern := range r.processTags {
+		tag, ok := model.KeyValues(process.Tags).FindByKey(key)
+		if !ok || !glob.Match(pattern, tag.AsString()) {
+			return false
+		}
+	}
+	return true
+}
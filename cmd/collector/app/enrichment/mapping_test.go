@@ -0,0 +1,123 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enrichment
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+func writeMapping(t *testing.T, dir, name, content string) string {
+	file := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(file, []byte(content), 0600))
+	return file
+}
+
+func TestLoadRules(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := writeMapping(t, dir, "mapping.yaml", `
+rules:
+  - service: "checkout-*"
+    process_tags:
+      hostname: "eu-*"
+    tags:
+      team: checkout
+      tier: 1
+`)
+	jsonFile := writeMapping(t, dir, "mapping.json", `{
+	"rules": [
+		{"service": "checkout-*", "process_tags": {"hostname": "eu-*"}, "tags": {"team": "checkout", "tier": "1"}}
+	]
+}`)
+	expected := []rule{{
+		service:     "checkout-*",
+		processTags: map[string]string{"hostname": "eu-*"},
+		tags:        []model.KeyValue{model.String("team", "checkout"), model.String("tier", "1")},
+	}}
+	for _, file := range []string{yamlFile, jsonFile} {
+		rules, err := loadRules(file)
+		require.NoError(t, err, file)
+		assert.Equal(t, expected, rules, file)
+	}
+}
+
+func TestLoadRulesErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{name: "empty.yaml", content: "", err: "is empty"},
+		{name: "invalid.json", content: `{"rules": [`, err: "failed to parse the enrichment mapping file"},
+		{name: "unknown-field.yaml", content: "rules:\n  - services: foo\n", err: "failed to parse the enrichment mapping file"},
+		{name: "service.yaml", content: "rules:\n  - service: '[a'\n    tags: {team: a}\n", err: "invalid service pattern of rule 0: syntax error in pattern"},
+		{name: "tag.yaml", content: "rules:\n  - process_tags: {ip: '[a'}\n    tags: {team: a}\n", err: "invalid pattern of process tag ip of rule 0: syntax error in pattern"},
+		{name: "no-tags.yaml", content: "rules:\n  - service: foo\n", err: "rule 0 has no tags"},
+		{name: "empty-key.json", content: `{"rules": [{"tags": {"": "a"}}]}`, err: "rule 0 has a tag with an empty key"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := loadRules(writeMapping(t, dir, test.name, test.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.err)
+		})
+	}
+
+	_, err := loadRules(filepath.Join(dir, "does-not-exist.yaml"))
+	assert.Contains(t, err.Error(), "failed to read the enrichment mapping file")
+}
+
+func TestRuleMatches(t *testing.T) {
+	r := rule{
+		service:     "checkout-*",
+		processTags: map[string]string{"hostname": "eu-*", "ip": "10.0.?.*"},
+	}
+	tests := []struct {
+		name     string
+		process  *model.Process
+		expected bool
+	}{
+		{
+			name:     "all match",
+			process:  &model.Process{ServiceName: "checkout-api", Tags: []model.KeyValue{model.String("hostname", "eu-1"), model.String("ip", "10.0.1.12")}},
+			expected: true,
+		},
+		{
+			name:    "service does not match",
+			process: &model.Process{ServiceName: "payment", Tags: []model.KeyValue{model.String("hostname", "eu-1"), model.String("ip", "10.0.1.12")}},
+		},
+		{
+			name:    "tag does not match",
+			process: &model.Process{ServiceName: "checkout-api", Tags: []model.KeyValue{model.String("hostname", "us-1"), model.String("ip", "10.0.1.12")}},
+		},
+		{
+			name:    "tag is missing",
+			process: &model.Process{ServiceName: "checkout-api", Tags: []model.KeyValue{model.String("hostname", "eu-1")}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, r.matches(test.process))
+		})
+	}
+	assert.True(t, rule{}.matches(&model.Process{ServiceName: "any"}))
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filereceiver
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+
+	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
+)
+
+// Format is the format of a span file, it is determined by the file extension.
+type Format string
+
+const (
+	// FormatJSON is used for *.json, *.jsonl and *.ndjson files, with one api_v2 PostSpansRequest
+	// in protobuf-JSON per line, i.e. the body accepted by /api/traces with Content-Type application/json.
+	FormatJSON Format = "json"
+	// FormatProto is used for *.pb and *.protobuf files, with api_v2 PostSpansRequest messages
+	// in protobuf, each one prefixed by its size as a varint.
+	FormatProto Format = "proto"
+	// FormatZipkinJSON is used for *.zipkin.json and *.zipkin.jsonl files, with one list of spans
+	// in the Zipkin v2 JSON format per line.
+	FormatZipkinJSON Format = "zipkin-json"
+)
+
+// formatOf returns the format of the file from its name, or false if it is not a span file.
+func formatOf(name string) (Format, bool) {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasPrefix(name, "."):
+		// hidden files, such as the state file
+		return "", false
+	case strings.HasSuffix(name, ".zipkin.json"), strings.HasSuffix(name, ".zipkin.jsonl"):
+		return FormatZipkinJSON, true
+	case strings.HasSuffix(name, ".json"), strings.HasSuffix(name, ".jsonl"), strings.HasSuffix(name, ".ndjson"):
+		return FormatJSON, true
+	case strings.HasSuffix(name, ".pb"), strings.HasSuffix(name, ".protobuf"):
+		return FormatProto, true
+	}
+	return "", false
+}
+
+// maxVarintSize is the max size of the varint prefixing the protobuf messages.
+const maxVarintSize = 10
+
+// nextRecord returns the first complete record of data and the number of bytes it spans, including
+// its delimiter, or 0 if data does not hold a complete record yet. An error is returned if the data is corrupt.
+func nextRecord(format Format, data []byte) ([]byte, int, error) {
+	if format != FormatProto {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			return nil, 0, nil
+		}
+		return bytes.TrimSpace(data[:i]), i + 1, nil
+	}
+	size, n := proto.DecodeVarint(data)
+	if n == 0 {
+		if len(data) >= maxVarintSize {
+			return nil, 0, fmt.Errorf("invalid message size prefix")
+		}
+		return nil, 0, nil
+	}
+	end := uint64(n) + size
+	if end < size {
+		return nil, 0, fmt.Errorf("invalid message size %d", size)
+	}
+	if uint64(len(data)) < end {
+		return nil, 0, nil
+	}
+	return data[n:end], int(end), nil
+}
+
+// decodeRequest decodes an api_v2 record.
+func decodeRequest(format Format, record []byte) (*api_v2.PostSpansRequest, error) {
+	var request api_v2.PostSpansRequest
+	var err error
+	if format == FormatProto {
+		err = proto.Unmarshal(record, &request)
+	} else {
+		err = jsonpb.Unmarshal(bytes.NewReader(record), &request)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i, span := range request.Batch.Spans {
+		if span == nil {
+			return nil, fmt.Errorf("span %d is null", i)
+		}
+		if span.Process == nil {
+			if request.Batch.Process == nil {
+				return nil, fmt.Errorf("span %d has no process and the batch has no process", i)
+			}
+			span.Process = request.Batch.Process
+		}
+	}
+	return &request, nil
+}
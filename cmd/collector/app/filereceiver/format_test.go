@@ -0,0 +1,92 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filereceiver
+
+import (
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		ok     bool
+	}{
+		{name: "spans.json", format: FormatJSON, ok: true},
+		{name: "spans.JSONL", format: FormatJSON, ok: true},
+		{name: "spans.ndjson", format: FormatJSON, ok: true},
+		{name: "spans.pb", format: FormatProto, ok: true},
+		{name: "spans.protobuf", format: FormatProto, ok: true},
+		{name: "spans.zipkin.json", format: FormatZipkinJSON, ok: true},
+		{name: "spans.zipkin.jsonl", format: FormatZipkinJSON, ok: true},
+		{name: DefaultStateFileName},
+		{name: "spans.txt"},
+	}
+	for _, test := range tests {
+		format, ok := formatOf(test.name)
+		assert.Equal(t, test.format, format, test.name)
+		assert.Equal(t, test.ok, ok, test.name)
+	}
+}
+
+func TestNextRecordLines(t *testing.T) {
+	record, n, err := nextRecord(FormatJSON, []byte(" {} \n{"))
+	require.NoError(t, err)
+	assert.Equal(t, "{}", string(record))
+	assert.Equal(t, 5, n)
+
+	_, n, err = nextRecord(FormatZipkinJSON, []byte("[{"))
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestNextRecordProto(t *testing.T) {
+	data := append(proto.EncodeVarint(3), []byte("abcd")...)
+	record, n, err := nextRecord(FormatProto, data)
+	require.NoError(t, err)
+	assert.Equal(t, "abc", string(record))
+	assert.Equal(t, 4, n)
+
+	// incomplete message and size prefix
+	_, n, err = nextRecord(FormatProto, data[:3])
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	_, n, err = nextRecord(FormatProto, []byte{0x80})
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	_, _, err = nextRecord(FormatProto, []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80})
+	assert.EqualError(t, err, "invalid message size prefix")
+}
+
+func TestDecodeRequest(t *testing.T) {
+	request, err := decodeRequest(FormatJSON, []byte(`{"batch": {"process": {"serviceName": "svc"}, "spans": [{"operationName": "op"}]}}`))
+	require.NoError(t, err)
+	require.Len(t, request.Batch.Spans, 1)
+	assert.Equal(t, "svc", request.Batch.Spans[0].Process.ServiceName)
+
+	_, err = decodeRequest(FormatJSON, []byte(`{"batch": {"spans": [{"operationName": "op"}]}}`))
+	assert.EqualError(t, err, "span 0 has no process and the batch has no process")
+	_, err = decodeRequest(FormatJSON, []byte(`{"batch": {"spans": [null]}}`))
+	assert.EqualError(t, err, "span 0 is null")
+	_, err = decodeRequest(FormatJSON, []byte(`{`))
+	assert.Error(t, err)
+	_, err = decodeRequest(FormatProto, []byte("not good"))
+	assert.Error(t, err)
+}
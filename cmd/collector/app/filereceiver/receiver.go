@@ -0,0 +1,360 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filereceiver
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/zipkin"
+)
+
+const (
+	// DefaultPollInterval is the default interval between two scans of the directory.
+	DefaultPollInterval = 5 * time.Second
+	// DefaultStateFileName is the name of the state file in the directory when no state file is configured.
+	DefaultStateFileName = ".file-receiver-state.json"
+
+	// maxRecordSize is the max size of a record, i.e. a line or a protobuf message
+	maxRecordSize = 4 * 1024 * 1024
+)
+
+var (
+	// errBusy is returned when the span processor cannot accept more spans, the record is retried later.
+	errBusy = errors.New("the span processor is busy")
+	// errCorrupt is returned when a file cannot be split into records.
+	errCorrupt = errors.New("corrupt span file")
+)
+
+// Options holds the configuration of the file receiver.
+type Options struct {
+	// Directory is the directory with the span files, the receiver is disabled if empty
+	Directory string
+	// StateFile is the path to the file where the progress per file is saved, DefaultStateFileName
+	// in the directory if empty
+	StateFile string
+	// PollInterval is the interval between two scans of the directory for new files and new records
+	PollInterval time.Duration
+	// MetricsFactory is used to report the ingested records and spans
+	MetricsFactory metrics.Factory
+}
+
+type receiverMetrics struct {
+	// Number of records ingested
+	Records metrics.Counter `metric:"file-receiver.records" tags:"result=ok"`
+	// Number of malformed records skipped
+	RecordErrors metrics.Counter `metric:"file-receiver.records" tags:"result=err"`
+	// Number of spans submitted to the span processor
+	Spans metrics.Counter `metric:"file-receiver.spans"`
+	// Number of times the ingestion was paused because the span processor was busy
+	Busy metrics.Counter `metric:"file-receiver.busy"`
+}
+
+// Receiver ingests the spans of the files in a directory. The files are tailed: new files and records
+// appended to the files are picked up every poll interval. Only complete records are ingested, i.e. lines
+// ending with a new line, or protobuf messages as long as their size prefix. The offset of the last ingested
+// record of each file is saved in the state file, so that the files are not ingested again after a restart.
+// Records are submitted again if the span processor drops some of their spans because its queue is full,
+// which may duplicate the accepted spans.
+type Receiver struct {
+	options       Options
+	logger        *zap.Logger
+	metrics       receiverMetrics
+	spanProcessor processor.SpanProcessor
+	zipkinHandler handler.ZipkinSpansHandler
+	state         *state
+	buf           []byte
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewReceiver creates a Receiver submitting the spans to the span processor, and the Zipkin spans to the Zipkin handler.
+func NewReceiver(options Options, spanProcessor processor.SpanProcessor, zipkinHandler handler.ZipkinSpansHandler, logger *zap.Logger) (*Receiver, error) {
+	if options.StateFile == "" {
+		options.StateFile = filepath.Join(options.Directory, DefaultStateFileName)
+	}
+	if options.PollInterval <= 0 {
+		options.PollInterval = DefaultPollInterval
+	}
+	if options.MetricsFactory == nil {
+		options.MetricsFactory = metrics.NullFactory
+	}
+	if info, err := os.Stat(options.Directory); err != nil {
+		return nil, fmt.Errorf("failed to access the file receiver directory: %w", err)
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("the file receiver directory %s is not a directory", options.Directory)
+	}
+	s, err := loadState(options.StateFile)
+	if err != nil {
+		return nil, err
+	}
+	r := &Receiver{
+		options:       options,
+		logger:        logger,
+		spanProcessor: spanProcessor,
+		zipkinHandler: zipkinHandler,
+		state:         s,
+		buf:           make([]byte, maxRecordSize),
+		stop:          make(chan struct{}),
+	}
+	metrics.MustInit(&r.metrics, options.MetricsFactory, nil)
+	return r, nil
+}
+
+// Start scans the directory now and then every poll interval.
+func (r *Receiver) Start() {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(r.options.PollInterval)
+		defer ticker.Stop()
+		for {
+			r.scan()
+			select {
+			case <-ticker.C:
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// scan ingests the new records of the files in the directory, in the order of their names.
+func (r *Receiver) scan() {
+	entries, err := ioutil.ReadDir(r.options.Directory)
+	if err != nil {
+		r.logger.Error("failed to list the file receiver directory", zap.Error(err))
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	present := make(map[string]struct{}, len(entries))
+	for _, entry := range entries {
+		format, ok := formatOf(entry.Name())
+		if !ok || !entry.Mode().IsRegular() {
+			continue
+		}
+		present[entry.Name()] = struct{}{}
+		select {
+		case <-r.stop:
+			return
+		default:
+		}
+		err := r.ingestFile(entry.Name(), format)
+		if errors.Is(err, errBusy) {
+			r.metrics.Busy.Inc(1)
+			return
+		}
+		if err != nil {
+			r.logger.Error("failed to ingest span file", zap.String("file", entry.Name()), zap.Error(err))
+		}
+	}
+	// forget the deleted files
+	changed := false
+	for name := range r.state.Files {
+		if _, ok := present[name]; !ok {
+			delete(r.state.Files, name)
+			changed = true
+		}
+	}
+	if changed {
+		r.saveState()
+	}
+}
+
+// ingestFile ingests the records of the file after the saved offset.
+func (r *Receiver) ingestFile(name string, format Format) error {
+	file, err := os.Open(filepath.Join(r.options.Directory, name))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	fs, err := r.fileState(name, file, info.Size())
+	if err != nil {
+		return err
+	}
+	for fs.Offset < info.Size() {
+		n, err := file.ReadAt(r.buf, fs.Offset)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		consumed, ingestErr := r.ingestRecords(name, format, r.buf[:n])
+		if consumed > 0 {
+			fs.Offset += int64(consumed)
+			if err := r.updateFingerprint(fs, file); err != nil {
+				return err
+			}
+			r.saveState()
+		}
+		if ingestErr == nil && consumed == 0 {
+			if n < len(r.buf) {
+				// the last record is incomplete, it is ingested once written
+				return nil
+			}
+			ingestErr = fmt.Errorf("%w: the record is larger than %d bytes", errCorrupt, maxRecordSize)
+		}
+		if errors.Is(ingestErr, errCorrupt) {
+			// the rest of the file cannot be split into records
+			r.metrics.RecordErrors.Inc(1)
+			offset := fs.Offset
+			fs.Offset = info.Size()
+			if err := r.updateFingerprint(fs, file); err != nil {
+				return err
+			}
+			r.saveState()
+			return fmt.Errorf("skipping the rest of the file from offset %d: %w", offset, ingestErr)
+		}
+		if ingestErr != nil {
+			return ingestErr
+		}
+	}
+	return nil
+}
+
+// fileState returns the state of the file, reset if the file was truncated or replaced.
+func (r *Receiver) fileState(name string, file *os.File, size int64) (*fileState, error) {
+	fs, ok := r.state.Files[name]
+	if !ok {
+		fs = &fileState{}
+		r.state.Files[name] = fs
+		return fs, nil
+	}
+	if fs.Offset > size {
+		r.logger.Info("span file was truncated, ingesting it from the beginning", zap.String("file", name))
+		*fs = fileState{}
+		return fs, nil
+	}
+	if fs.FingerprintSize == 0 {
+		return fs, nil
+	}
+	fp, err := fingerprint(file, fs.FingerprintSize)
+	if err != nil {
+		return nil, err
+	}
+	if fp != fs.Fingerprint {
+		r.logger.Info("span file was replaced, ingesting it from the beginning", zap.String("file", name))
+		*fs = fileState{}
+	}
+	return fs, nil
+}
+
+func (r *Receiver) updateFingerprint(fs *fileState, file *os.File) error {
+	if fs.FingerprintSize == fingerprintSize {
+		return nil
+	}
+	fs.FingerprintSize = fs.Offset
+	if fs.FingerprintSize > fingerprintSize {
+		fs.FingerprintSize = fingerprintSize
+	}
+	fp, err := fingerprint(file, fs.FingerprintSize)
+	fs.Fingerprint = fp
+	return err
+}
+
+// ingestRecords ingests the complete records of data, and returns the number of bytes ingested.
+func (r *Receiver) ingestRecords(name string, format Format, data []byte) (int, error) {
+	consumed := 0
+	for {
+		record, n, err := nextRecord(format, data[consumed:])
+		if err != nil {
+			return consumed, fmt.Errorf("%w: %v", errCorrupt, err)
+		}
+		if n == 0 {
+			return consumed, nil
+		}
+		if len(record) > 0 {
+			err := r.ingestRecord(format, record)
+			if errors.Is(err, errBusy) {
+				return consumed, err
+			}
+			if err != nil {
+				r.metrics.RecordErrors.Inc(1)
+				r.logger.Warn("skipping malformed record", zap.String("file", name), zap.Error(err))
+			} else {
+				r.metrics.Records.Inc(1)
+			}
+		}
+		consumed += n
+	}
+}
+
+func (r *Receiver) ingestRecord(format Format, record []byte) error {
+	if format == FormatZipkinJSON {
+		spans, err := zipkin.DeserializeJSONV2(record)
+		if err != nil {
+			return err
+		}
+		responses, err := r.zipkinHandler.SubmitZipkinBatch(spans, handler.SubmitBatchOptions{
+			InboundTransport: processor.FileTransport,
+		})
+		if err != nil {
+			return fmt.Errorf("%w: %v", errBusy, err)
+		}
+		for _, response := range responses {
+			if !response.Ok {
+				return errBusy
+			}
+		}
+		r.metrics.Spans.Inc(int64(len(spans)))
+		return nil
+	}
+	request, err := decodeRequest(format, record)
+	if err != nil {
+		return err
+	}
+	oks, err := r.spanProcessor.ProcessSpans(request.Batch.Spans, processor.SpansOptions{
+		SpanFormat:       processor.ProtoSpanFormat,
+		InboundTransport: processor.FileTransport,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", errBusy, err)
+	}
+	for _, ok := range oks {
+		if !ok {
+			return errBusy
+		}
+	}
+	r.metrics.Spans.Inc(int64(len(request.Batch.Spans)))
+	return nil
+}
+
+func (r *Receiver) saveState() {
+	if err := r.state.save(r.options.StateFile); err != nil {
+		r.logger.Error("failed to save the file receiver state", zap.Error(err))
+	}
+}
+
+// Close stops the ingestion.
+func (r *Receiver) Close() error {
+	close(r.stop)
+	r.wg.Wait()
+	return nil
+}
@@ -0,0 +1,296 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filereceiver
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
+	"github.com/jaegertracing/jaeger/thrift-gen/zipkincore"
+)
+
+type mockSpanProcessor struct {
+	mux   sync.Mutex
+	spans []*model.Span
+	opts  []processor.SpansOptions
+	// full makes the processor drop all spans, as when its queue is full
+	full bool
+}
+
+func (p *mockSpanProcessor) ProcessSpans(spans []*model.Span, opts processor.SpansOptions) ([]bool, error) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	oks := make([]bool, len(spans))
+	if p.full {
+		return oks, nil
+	}
+	p.spans = append(p.spans, spans...)
+	p.opts = append(p.opts, opts)
+	for i := range oks {
+		oks[i] = true
+	}
+	return oks, nil
+}
+
+func (p *mockSpanProcessor) getSpans() []*model.Span {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return p.spans
+}
+
+func (p *mockSpanProcessor) setFull(full bool) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.full = full
+}
+
+func (p *mockSpanProcessor) Close() error {
+	return nil
+}
+
+type mockZipkinHandler struct {
+	spans []*zipkincore.Span
+	err   error
+}
+
+func (h *mockZipkinHandler) SubmitZipkinBatch(spans []*zipkincore.Span, opts handler.SubmitBatchOptions) ([]*zipkincore.Response, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	h.spans = append(h.spans, spans...)
+	responses := make([]*zipkincore.Response, len(spans))
+	for i := range responses {
+		responses[i] = &zipkincore.Response{Ok: true}
+	}
+	return responses, nil
+}
+
+func jsonLine(operation string) string {
+	return `{"batch": {"process": {"serviceName": "svc"}, "spans": [{"operationName": "` + operation + `"}]}}` + "\n"
+}
+
+func protoRecord(t *testing.T, operation string) []byte {
+	bytes, err := proto.Marshal(&api_v2.PostSpansRequest{
+		Batch: model.Batch{
+			Process: &model.Process{ServiceName: "svc"},
+			Spans:   []*model.Span{{OperationName: operation}},
+		},
+	})
+	require.NoError(t, err)
+	return append(proto.EncodeVarint(uint64(len(bytes))), bytes...)
+}
+
+func appendFile(t *testing.T, path string, data []byte) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	_, err = f.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
+
+func operations(spans []*model.Span) []string {
+	var names []string
+	for _, span := range spans {
+		names = append(names, span.OperationName)
+	}
+	return names
+}
+
+func newTestReceiver(t *testing.T, dir string, sp *mockSpanProcessor, zh *mockZipkinHandler, mf *metricstest.Factory) *Receiver {
+	r, err := NewReceiver(Options{Directory: dir, MetricsFactory: mf}, sp, zh, zap.NewNop())
+	require.NoError(t, err)
+	return r
+}
+
+func TestNewReceiverErrors(t *testing.T) {
+	_, err := NewReceiver(Options{Directory: "/does/not/exist"}, &mockSpanProcessor{}, &mockZipkinHandler{}, zap.NewNop())
+	assert.Contains(t, err.Error(), "failed to access the file receiver directory")
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	require.NoError(t, ioutil.WriteFile(file, nil, 0600))
+	_, err = NewReceiver(Options{Directory: file}, &mockSpanProcessor{}, &mockZipkinHandler{}, zap.NewNop())
+	assert.EqualError(t, err, "the file receiver directory "+file+" is not a directory")
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, DefaultStateFileName), []byte("{"), 0600))
+	_, err = NewReceiver(Options{Directory: dir}, &mockSpanProcessor{}, &mockZipkinHandler{}, zap.NewNop())
+	assert.Contains(t, err.Error(), "failed to parse the file receiver state file")
+}
+
+func TestReceiverFormats(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.json"), []byte(jsonLine("json-1")+"\n"+jsonLine("json-2")), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "b.pb"), append(protoRecord(t, "proto-1"), protoRecord(t, "proto-2")...), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "c.zipkin.json"),
+		[]byte(`[{"traceId": "0000000000000001", "id": "0000000000000002", "name": "zipkin", "localEndpoint": {"serviceName": "zsvc"}}]`+"\n"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "d.txt"), []byte("ignored\n"), 0600))
+
+	sp := &mockSpanProcessor{}
+	zh := &mockZipkinHandler{}
+	mf := metricstest.NewFactory(0)
+	r := newTestReceiver(t, dir, sp, zh, mf)
+	r.scan()
+
+	assert.Equal(t, []string{"json-1", "json-2", "proto-1", "proto-2"}, operations(sp.getSpans()))
+	for _, opts := range sp.opts {
+		assert.Equal(t, processor.SpansOptions{SpanFormat: processor.ProtoSpanFormat, InboundTransport: processor.FileTransport}, opts)
+	}
+	require.Len(t, zh.spans, 1)
+	assert.Equal(t, "zipkin", zh.spans[0].Name)
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "file-receiver.records", Tags: map[string]string{"result": "ok"}, Value: 5},
+		metricstest.ExpectedMetric{Name: "file-receiver.spans", Value: 5},
+	)
+}
+
+func TestReceiverTailsFilesAndResumes(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "spans.jsonl")
+	appendFile(t, path, []byte(jsonLine("1")+`{"batch": `))
+
+	sp := &mockSpanProcessor{}
+	r := newTestReceiver(t, dir, sp, &mockZipkinHandler{}, metricstest.NewFactory(0))
+	r.scan()
+	assert.Equal(t, []string{"1"}, operations(sp.getSpans()))
+
+	// the incomplete line is ingested once completed
+	appendFile(t, path, []byte(`{"process": {"serviceName": "svc"}, "spans": [{"operationName": "2"}]}}`+"\n"))
+	r.scan()
+	assert.Equal(t, []string{"1", "2"}, operations(sp.getSpans()))
+
+	// a new receiver resumes from the saved state
+	appendFile(t, path, []byte(jsonLine("3")))
+	sp = &mockSpanProcessor{}
+	r = newTestReceiver(t, dir, sp, &mockZipkinHandler{}, metricstest.NewFactory(0))
+	r.scan()
+	assert.Equal(t, []string{"3"}, operations(sp.getSpans()))
+}
+
+func TestReceiverReplacedAndDeletedFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "spans.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(jsonLine("1")+jsonLine("2")), 0600))
+
+	sp := &mockSpanProcessor{}
+	r := newTestReceiver(t, dir, sp, &mockZipkinHandler{}, metricstest.NewFactory(0))
+	r.scan()
+	assert.Equal(t, []string{"1", "2"}, operations(sp.getSpans()))
+
+	// replaced by a longer file
+	require.NoError(t, ioutil.WriteFile(path, []byte(jsonLine("a")+jsonLine("b")+jsonLine("c")), 0600))
+	r.scan()
+	assert.Equal(t, []string{"1", "2", "a", "b", "c"}, operations(sp.getSpans()))
+
+	// truncated
+	require.NoError(t, ioutil.WriteFile(path, []byte(jsonLine("x")), 0600))
+	r.scan()
+	assert.Equal(t, []string{"1", "2", "a", "b", "c", "x"}, operations(sp.getSpans()))
+
+	require.NoError(t, os.Remove(path))
+	r.scan()
+	assert.Empty(t, r.state.Files)
+	s, err := loadState(r.options.StateFile)
+	require.NoError(t, err)
+	assert.Empty(t, s.Files)
+}
+
+func TestReceiverRetriesWhenBusy(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.json"), []byte(jsonLine("1")), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "b.json"), []byte(jsonLine("2")), 0600))
+
+	sp := &mockSpanProcessor{full: true}
+	mf := metricstest.NewFactory(0)
+	r := newTestReceiver(t, dir, sp, &mockZipkinHandler{}, mf)
+	r.scan()
+	assert.Empty(t, sp.getSpans())
+	assert.Equal(t, int64(0), r.state.Files["a.json"].Offset)
+	assert.NotContains(t, r.state.Files, "b.json")
+
+	sp.setFull(false)
+	r.scan()
+	assert.Equal(t, []string{"1", "2"}, operations(sp.getSpans()))
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "file-receiver.busy", Value: 1})
+}
+
+func TestReceiverZipkinBusy(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.zipkin.json"),
+		[]byte(`[{"traceId": "0000000000000001", "id": "0000000000000002", "name": "zipkin"}]`+"\n"), 0600))
+	zh := &mockZipkinHandler{err: processor.ErrBusy}
+	r := newTestReceiver(t, dir, &mockSpanProcessor{}, zh, metricstest.NewFactory(0))
+	r.scan()
+	assert.Equal(t, int64(0), r.state.Files["a.zipkin.json"].Offset)
+	zh.err = nil
+	r.scan()
+	assert.Len(t, zh.spans, 1)
+}
+
+func TestReceiverMalformedRecords(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.json"), []byte("{\n"+jsonLine("1")), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "b.zipkin.json"), []byte("[{]\n"), 0600))
+	corrupt := append(protoRecord(t, "2"), bytes.Repeat([]byte{0x80}, 12)...)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "c.pb"), corrupt, 0600))
+
+	sp := &mockSpanProcessor{}
+	mf := metricstest.NewFactory(0)
+	r := newTestReceiver(t, dir, sp, &mockZipkinHandler{}, mf)
+	r.scan()
+	assert.Equal(t, []string{"1", "2"}, operations(sp.getSpans()))
+	assert.Equal(t, int64(len(corrupt)), r.state.Files["c.pb"].Offset)
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "file-receiver.records", Tags: map[string]string{"result": "ok"}, Value: 2},
+		metricstest.ExpectedMetric{Name: "file-receiver.records", Tags: map[string]string{"result": "err"}, Value: 3},
+	)
+}
+
+func TestReceiverRecordTooLarge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.json")
+	require.NoError(t, ioutil.WriteFile(path, bytes.Repeat([]byte("x"), maxRecordSize+1), 0600))
+
+	mf := metricstest.NewFactory(0)
+	r := newTestReceiver(t, dir, &mockSpanProcessor{}, &mockZipkinHandler{}, mf)
+	r.scan()
+	assert.Equal(t, int64(maxRecordSize+1), r.state.Files["a.json"].Offset)
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "file-receiver.records", Tags: map[string]string{"result": "err"}, Value: 1})
+}
+
+func TestReceiverStartClose(t *testing.T) {
+	dir := t.TempDir()
+	sp := &mockSpanProcessor{}
+	r, err := NewReceiver(Options{Directory: dir, PollInterval: time.Millisecond}, sp, &mockZipkinHandler{}, zap.NewNop())
+	require.NoError(t, err)
+	r.Start()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.json"), []byte(jsonLine("1")), 0600))
+	assert.Eventually(t, func() bool { return len(sp.getSpans()) == 1 }, 5*time.Second, time.Millisecond)
+	assert.NoError(t, r.Close())
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filereceiver
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// fingerprintSize is the number of bytes at the beginning of a file used to detect that it was replaced.
+const fingerprintSize = 1024
+
+// state is the progress of the ingestion, persisted in the state file.
+type state struct {
+	Files map[string]*fileState `json:"files"`
+}
+
+// fileState is the progress of the ingestion of a file.
+type fileState struct {
+	// Offset is the position after the last ingested record
+	Offset int64 `json:"offset"`
+	// FingerprintSize is the number of bytes covered by the fingerprint, at most fingerprintSize
+	FingerprintSize int64 `json:"fingerprint_size"`
+	// Fingerprint is the FNV hash of the first FingerprintSize bytes of the file
+	Fingerprint uint64 `json:"fingerprint"`
+}
+
+func loadState(path string) (*state, error) {
+	s := &state{Files: make(map[string]*fileState)}
+	bytes, err := ioutil.ReadFile(filepath.Clean(path))
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the file receiver state file: %w", err)
+	}
+	if err := json.Unmarshal(bytes, s); err != nil {
+		return nil, fmt.Errorf("failed to parse the file receiver state file: %w", err)
+	}
+	if s.Files == nil {
+		s.Files = make(map[string]*fileState)
+	}
+	return s, nil
+}
+
+// save writes the state to a temporary file renamed to the state file, so that it is never partially written.
+func (s *state) save(path string) error {
+	bytes, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, bytes, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// fingerprint returns the hash of the first size bytes of the file.
+func fingerprint(file io.ReaderAt, size int64) (uint64, error) {
+	buf := make([]byte, size)
+	if _, err := file.ReadAt(buf, 0); err != nil && err != io.EOF {
+		return 0, err
+	}
+	h := fnv.New64a()
+	_, _ = h.Write(buf)
+	return h.Sum64(), nil
+}
@@ -16,12 +16,18 @@ package handler
 
 import (
 	"context"
+	"errors"
 
 	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/auth"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
 )
 
@@ -29,33 +35,60 @@ import (
 type GRPCHandler struct {
 	logger        *zap.Logger
 	spanProcessor processor.SpanProcessor
+	tenancyMgr    *tenancy.Manager
 }
 
 // NewGRPCHandler registers routes for this handler on the given router.
-func NewGRPCHandler(logger *zap.Logger, spanProcessor processor.SpanProcessor) *GRPCHandler {
+func NewGRPCHandler(logger *zap.Logger, spanProcessor processor.SpanProcessor, tenancyMgr *tenancy.Manager) *GRPCHandler {
 	return &GRPCHandler{
 		logger:        logger,
 		spanProcessor: spanProcessor,
+		tenancyMgr:    tenancyMgr,
 	}
 }
 
 // PostSpans implements gRPC CollectorService.
 func (g *GRPCHandler) PostSpans(ctx context.Context, r *api_v2.PostSpansRequest) (*api_v2.PostSpansResponse, error) {
-	for _, span := range r.GetBatch().Spans {
-		if span.GetProcess() == nil {
-			span.Process = r.Batch.Process
-		}
+	tenant, err := g.tenancyMgr.TenantFromGRPC(ctx)
+	if err != nil {
+		return nil, err
 	}
-	_, err := g.spanProcessor.ProcessSpans(r.GetBatch().Spans, processor.SpansOptions{
+	err = processBatch(g.spanProcessor, r.GetBatch(), processor.SpansOptions{
 		InboundTransport: processor.GRPCTransport,
 		SpanFormat:       processor.ProtoSpanFormat,
+		Tenant:           tenant,
+		Identity:         auth.GetIdentity(ctx),
 	})
 	if err != nil {
 		if err == processor.ErrBusy {
 			return nil, status.Errorf(codes.ResourceExhausted, err.Error())
 		}
+		var overloaded *processor.OverloadedError
+		if errors.As(err, &overloaded) {
+			return nil, overloadedStatus(overloaded).Err()
+		}
 		g.logger.Error("cannot process spans", zap.Error(err))
 		return nil, err
 	}
 	return &api_v2.PostSpansResponse{}, nil
 }
+
+// processBatch sets the process of the batch on the spans without one and submits them to the span processor.
+func processBatch(spanProcessor processor.SpanProcessor, batch model.Batch, opts processor.SpansOptions) error {
+	for _, span := range batch.Spans {
+		if span.GetProcess() == nil {
+			span.Process = batch.Process
+		}
+	}
+	_, err := spanProcessor.ProcessSpans(batch.Spans, opts)
+	return err
+}
+
+// overloadedStatus returns a retryable ResourceExhausted status with the retry delay of the error.
+func overloadedStatus(err *processor.OverloadedError) *status.Status {
+	st := status.New(codes.ResourceExhausted, err.Error())
+	if withDetails, e := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(err.RetryAfter)}); e == nil {
+		return withDetails
+	}
+	return st
+}
@@ -20,14 +20,20 @@ import (
 	"net"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
 	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
 )
 
@@ -35,12 +41,19 @@ type mockSpanProcessor struct {
 	expectedError error
 	mux           sync.Mutex
 	spans         []*model.Span
+	tenants       map[string]bool
+	spanFormat    processor.SpanFormat
 }
 
 func (p *mockSpanProcessor) ProcessSpans(spans []*model.Span, opts processor.SpansOptions) ([]bool, error) {
 	p.mux.Lock()
 	defer p.mux.Unlock()
 	p.spans = append(p.spans, spans...)
+	if p.tenants == nil {
+		p.tenants = make(map[string]bool)
+	}
+	p.tenants[opts.Tenant] = true
+	p.spanFormat = opts.SpanFormat
 	oks := make([]bool, len(spans))
 	return oks, p.expectedError
 }
@@ -55,6 +68,13 @@ func (p *mockSpanProcessor) reset() {
 	p.mux.Lock()
 	defer p.mux.Unlock()
 	p.spans = nil
+	p.tenants = nil
+}
+
+func (p *mockSpanProcessor) getTenants() map[string]bool {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return p.tenants
 }
 
 func (p *mockSpanProcessor) Close() error {
@@ -82,7 +102,7 @@ func newClient(t *testing.T, addr net.Addr) (api_v2.CollectorServiceClient, *grp
 func TestPostSpans(t *testing.T) {
 	processor := &mockSpanProcessor{}
 	server, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {
-		handler := NewGRPCHandler(zap.NewNop(), processor)
+		handler := NewGRPCHandler(zap.NewNop(), processor, &tenancy.Manager{})
 		api_v2.RegisterCollectorServiceServer(s, handler)
 	})
 	defer server.Stop()
@@ -114,7 +134,7 @@ func TestPostSpansWithError(t *testing.T) {
 	expectedError := errors.New("test-error")
 	processor := &mockSpanProcessor{expectedError: expectedError}
 	server, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {
-		handler := NewGRPCHandler(zap.NewNop(), processor)
+		handler := NewGRPCHandler(zap.NewNop(), processor, &tenancy.Manager{})
 		api_v2.RegisterCollectorServiceServer(s, handler)
 	})
 	defer server.Stop()
@@ -134,3 +154,63 @@ func TestPostSpansWithError(t *testing.T) {
 	require.Contains(t, err.Error(), expectedError.Error())
 	require.Len(t, processor.getSpans(), 1)
 }
+
+func TestPostSpansOverloaded(t *testing.T) {
+	processor := &mockSpanProcessor{expectedError: &processor.OverloadedError{Shed: 2, RetryAfter: 3 * time.Second}}
+	server, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {
+		handler := NewGRPCHandler(zap.NewNop(), processor, &tenancy.Manager{})
+		api_v2.RegisterCollectorServiceServer(s, handler)
+	})
+	defer server.Stop()
+	client, conn := newClient(t, addr)
+	defer conn.Close()
+	r, err := client.PostSpans(context.Background(), &api_v2.PostSpansRequest{
+		Batch: model.Batch{
+			Spans: []*model.Span{{OperationName: "fake-operation"}},
+		},
+	})
+	require.Error(t, err)
+	require.Nil(t, r)
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	assert.Equal(t, "server overloaded, 2 spans were shed, retry after 3s", st.Message())
+	require.Len(t, st.Details(), 1)
+	retryInfo, ok := st.Details()[0].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	assert.Equal(t, 3*time.Second, retryInfo.RetryDelay.AsDuration())
+}
+
+func TestPostSpansWithTenant(t *testing.T) {
+	processor := &mockSpanProcessor{}
+	tenancyMgr := tenancy.NewManager(&tenancy.Options{Enabled: true, Tenants: []string{"acme"}})
+	server, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {
+		handler := NewGRPCHandler(zap.NewNop(), processor, tenancyMgr)
+		api_v2.RegisterCollectorServiceServer(s, handler)
+	})
+	defer server.Stop()
+	client, conn := newClient(t, addr)
+	defer conn.Close()
+
+	request := &api_v2.PostSpansRequest{
+		Batch: model.Batch{Spans: []*model.Span{{OperationName: "fake-operation"}}},
+	}
+	tests := []struct {
+		tenant string
+		code   codes.Code
+	}{
+		{tenant: "acme", code: codes.OK},
+		{tenant: "", code: codes.PermissionDenied},
+		{tenant: "megacorp", code: codes.PermissionDenied},
+	}
+	for _, test := range tests {
+		ctx := context.Background()
+		if test.tenant != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, tenancy.DefaultHeader, test.tenant)
+		}
+		_, err := client.PostSpans(ctx, request)
+		assert.Equal(t, test.code, status.Code(err), test.tenant)
+	}
+	assert.Equal(t, map[string]bool{"acme": true}, processor.getTenants())
+	assert.Len(t, processor.getSpans(), 1)
+}
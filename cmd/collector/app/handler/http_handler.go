@@ -16,22 +16,37 @@
 package handler
 
 import (
+	"bytes"
+	"compress/gzip"
+	"errors"
 	"fmt"
 	"html"
+	"io"
 	"io/ioutil"
+	"math"
 	"mime"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
 	"github.com/gorilla/mux"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/auth"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
+	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
 	tJaeger "github.com/jaegertracing/jaeger/thrift-gen/jaeger"
 )
 
 const (
 	// UnableToReadBodyErrFormat is an error message for invalid requests
 	UnableToReadBodyErrFormat = "Unable to process request body: %v"
+	// DefaultMaxRequestSize is the default max size in bytes of a request body, before and after decompression.
+	DefaultMaxRequestSize = 64 << 20
 )
 
 var (
@@ -41,17 +56,36 @@ var (
 	}
 )
 
+var (
+	acceptedProtoFormats = map[string]struct{}{
+		"application/x-protobuf": {},
+		"application/protobuf":   {},
+	}
+)
+
 // APIHandler handles all HTTP calls to the collector
 type APIHandler struct {
 	jaegerBatchesHandler JaegerBatchesHandler
+	spanProcessor        processor.SpanProcessor
+	maxRequestSize       int64
 }
 
-// NewAPIHandler returns a new APIHandler
+// NewAPIHandler returns a new APIHandler. The span processor receives the spans
+// posted in the api_v2 protobuf or protobuf-JSON formats. Request bodies larger than
+// maxRequestSize bytes, before or after decompression, are rejected, DefaultMaxRequestSize
+// is used if maxRequestSize is not positive.
 func NewAPIHandler(
 	jaegerBatchesHandler JaegerBatchesHandler,
+	spanProcessor processor.SpanProcessor,
+	maxRequestSize int64,
 ) *APIHandler {
+	if maxRequestSize <= 0 {
+		maxRequestSize = DefaultMaxRequestSize
+	}
 	return &APIHandler{
 		jaegerBatchesHandler: jaegerBatchesHandler,
+		spanProcessor:        spanProcessor,
+		maxRequestSize:       maxRequestSize,
 	}
 }
 
@@ -60,12 +94,29 @@ func (aH *APIHandler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/api/traces", aH.SaveSpan).Methods(http.MethodPost)
 }
 
-// SaveSpan submits the span provided in the request body to the JaegerBatchesHandler
+// SaveSpan submits the spans provided in the request body, as a Thrift jaeger.Batch, or as an api_v2
+// PostSpansRequest in protobuf or protobuf-JSON depending on the Content-Type. The body may be gzipped.
 func (aH *APIHandler) SaveSpan(w http.ResponseWriter, r *http.Request) {
-	bodyBytes, err := ioutil.ReadAll(r.Body)
-	r.Body.Close()
+	defer r.Body.Close()
+	var bRead io.Reader = http.MaxBytesReader(w, r.Body, aH.maxRequestSize)
+	if strings.Contains(r.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(bRead)
+		if err != nil {
+			writeReadBodyError(w, err)
+			return
+		}
+		defer gz.Close()
+		bRead = gz
+	}
+
+	// one more byte than the limit is read to tell a body at the limit from a larger one
+	bodyBytes, err := ioutil.ReadAll(io.LimitReader(bRead, aH.maxRequestSize+1))
 	if err != nil {
-		http.Error(w, fmt.Sprintf(UnableToReadBodyErrFormat, err), http.StatusInternalServerError)
+		writeReadBodyError(w, err)
+		return
+	}
+	if int64(len(bodyBytes)) > aH.maxRequestSize {
+		http.Error(w, fmt.Sprintf("Request body exceeds the max size of %d bytes once decompressed", aH.maxRequestSize), http.StatusRequestEntityTooLarge)
 		return
 	}
 
@@ -76,23 +127,106 @@ func (aH *APIHandler) SaveSpan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if _, ok := acceptedThriftFormats[contentType]; !ok {
+	if _, ok := acceptedThriftFormats[contentType]; ok {
+		aH.saveThriftBatch(w, r, bodyBytes)
+		return
+	}
+
+	var request api_v2.PostSpansRequest
+	var spanFormat processor.SpanFormat
+	if _, ok := acceptedProtoFormats[contentType]; ok {
+		spanFormat = processor.ProtoSpanFormat
+		err = proto.Unmarshal(bodyBytes, &request)
+	} else if contentType == "application/json" {
+		spanFormat = processor.JSONSpanFormat
+		err = jsonpb.Unmarshal(bytes.NewReader(bodyBytes), &request)
+	} else {
 		http.Error(w, fmt.Sprintf("Unsupported content type: %v", html.EscapeString(contentType)), http.StatusBadRequest)
 		return
 	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf(UnableToReadBodyErrFormat, html.EscapeString(err.Error())), http.StatusBadRequest)
+		return
+	}
+	aH.saveProtoBatch(w, r, request.Batch, spanFormat)
+}
 
+func writeReadBodyError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	status := http.StatusInternalServerError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, gzip.ErrChecksum) || errors.Is(err, gzip.ErrHeader) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF):
+		status = http.StatusBadRequest
+	}
+	http.Error(w, fmt.Sprintf(UnableToReadBodyErrFormat, err), status)
+}
+
+func (aH *APIHandler) saveThriftBatch(w http.ResponseWriter, r *http.Request, bodyBytes []byte) {
 	tdes := thrift.NewTDeserializer()
 	batch := &tJaeger.Batch{}
-	if err = tdes.Read(r.Context(), batch, bodyBytes); err != nil {
+	if err := tdes.Read(r.Context(), batch, bodyBytes); err != nil {
 		http.Error(w, fmt.Sprintf(UnableToReadBodyErrFormat, err), http.StatusBadRequest)
 		return
 	}
 	batches := []*tJaeger.Batch{batch}
-	opts := SubmitBatchOptions{InboundTransport: processor.HTTPTransport}
-	if _, err = aH.jaegerBatchesHandler.SubmitBatches(batches, opts); err != nil {
+	opts := SubmitBatchOptions{
+		InboundTransport: processor.HTTPTransport,
+		Tenant:           tenancy.GetTenant(r.Context()),
+		Identity:         auth.GetIdentity(r.Context()),
+	}
+	if _, err := aH.jaegerBatchesHandler.SubmitBatches(batches, opts); err != nil {
+		if WriteOverloadedError(w, err) {
+			return
+		}
 		http.Error(w, fmt.Sprintf("Cannot submit Jaeger batch: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	w.WriteHeader(http.StatusAccepted)
 }
+
+func (aH *APIHandler) saveProtoBatch(w http.ResponseWriter, r *http.Request, batch model.Batch, spanFormat processor.SpanFormat) {
+	for i, span := range batch.Spans {
+		if span == nil {
+			http.Error(w, fmt.Sprintf(UnableToReadBodyErrFormat, fmt.Sprintf("span %d is null", i)), http.StatusBadRequest)
+			return
+		}
+		if span.Process == nil && batch.Process == nil {
+			http.Error(w, fmt.Sprintf(UnableToReadBodyErrFormat, fmt.Sprintf("span %d has no process and the batch has no process", i)), http.StatusBadRequest)
+			return
+		}
+	}
+	err := processBatch(aH.spanProcessor, batch, processor.SpansOptions{
+		InboundTransport: processor.HTTPTransport,
+		SpanFormat:       spanFormat,
+		Tenant:           tenancy.GetTenant(r.Context()),
+		Identity:         auth.GetIdentity(r.Context()),
+	})
+	if err != nil {
+		if WriteOverloadedError(w, err) {
+			return
+		}
+		if err == processor.ErrBusy {
+			http.Error(w, fmt.Sprintf("Cannot submit spans: %v", err), http.StatusServiceUnavailable)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Cannot submit spans: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// WriteOverloadedError responds with 503 and a Retry-After header if the error reports
+// spans shed because the collector is overloaded, and returns whether it did.
+func WriteOverloadedError(w http.ResponseWriter, err error) bool {
+	var overloaded *processor.OverloadedError
+	if !errors.As(err, &overloaded) {
+		return false
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(overloaded.RetryAfter.Seconds()))))
+	http.Error(w, overloaded.Error(), http.StatusServiceUnavailable)
+	return true
+}
@@ -17,21 +17,29 @@ package handler
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	jaegerClient "github.com/uber/jaeger-client-go"
 	"github.com/uber/jaeger-client-go/transport"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
 	"github.com/jaegertracing/jaeger/thrift-gen/jaeger"
 )
 
@@ -58,7 +66,7 @@ func (p *mockJaegerHandler) getBatches() []*jaeger.Batch {
 
 func initializeTestServer(err error) (*httptest.Server, *APIHandler) {
 	r := mux.NewRouter()
-	handler := NewAPIHandler(&mockJaegerHandler{err: err})
+	handler := NewAPIHandler(&mockJaegerHandler{err: err}, &mockSpanProcessor{expectedError: err}, 0)
 	handler.RegisterRoutes(r)
 	return httptest.NewServer(r), handler
 }
@@ -96,6 +104,201 @@ func TestThriftFormat(t *testing.T) {
 	assert.EqualValues(t, "Cannot submit Jaeger batch: Bad times ahead\n", resBodyStr)
 }
 
+func TestThriftFormatOverloaded(t *testing.T) {
+	batch := jaeger.Batch{Process: &jaeger.Process{ServiceName: "serviceName"}, Spans: []*jaeger.Span{{OperationName: "opName"}}}
+	someBytes, err := thrift.NewTSerializer().Write(context.Background(), &batch)
+	assert.NoError(t, err)
+	server, _ := initializeTestServer(&processor.OverloadedError{Shed: 1, RetryAfter: 1500 * time.Millisecond})
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+`/api/traces`, bytes.NewBuffer(someBytes))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-thrift")
+	res, err := httpClient.Do(req)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(t, "2", res.Header.Get("Retry-After"))
+	assert.Equal(t, "server overloaded, 1 spans were shed, retry after 1.5s\n", string(body))
+}
+
+func makePostSpansRequest() *api_v2.PostSpansRequest {
+	return &api_v2.PostSpansRequest{
+		Batch: model.Batch{
+			Process: &model.Process{ServiceName: "batch-service"},
+			Spans: []*model.Span{
+				{TraceID: model.NewTraceID(1, 2), SpanID: 3, OperationName: "op"},
+				{TraceID: model.NewTraceID(1, 2), SpanID: 4, OperationName: "op", Process: &model.Process{ServiceName: "span-service"}},
+			},
+		},
+	}
+}
+
+func TestProtoFormats(t *testing.T) {
+	protoBytes, err := proto.Marshal(makePostSpansRequest())
+	require.NoError(t, err)
+	jsonString, err := new(jsonpb.Marshaler).MarshalToString(makePostSpansRequest())
+	require.NoError(t, err)
+
+	tests := []struct {
+		contentType string
+		body        []byte
+		spanFormat  processor.SpanFormat
+	}{
+		{contentType: "application/x-protobuf", body: protoBytes, spanFormat: processor.ProtoSpanFormat},
+		{contentType: "application/protobuf", body: protoBytes, spanFormat: processor.ProtoSpanFormat},
+		{contentType: "application/json", body: []byte(jsonString), spanFormat: processor.JSONSpanFormat},
+		{contentType: "application/json; charset=utf-8", body: []byte(jsonString), spanFormat: processor.JSONSpanFormat},
+	}
+	for _, test := range tests {
+		t.Run(test.contentType, func(t *testing.T) {
+			server, handler := initializeTestServer(nil)
+			defer server.Close()
+
+			statusCode, resBodyStr, err := postBytes(test.contentType, server.URL+`/api/traces`, test.body)
+			require.NoError(t, err)
+			assert.EqualValues(t, http.StatusAccepted, statusCode)
+			assert.EqualValues(t, "", resBodyStr)
+
+			spans := handler.spanProcessor.(*mockSpanProcessor).getSpans()
+			require.Len(t, spans, 2)
+			assert.Equal(t, "batch-service", spans[0].Process.ServiceName)
+			assert.Equal(t, "span-service", spans[1].Process.ServiceName)
+			assert.Equal(t, test.spanFormat, handler.spanProcessor.(*mockSpanProcessor).spanFormat)
+			assert.Empty(t, handler.jaegerBatchesHandler.(*mockJaegerHandler).getBatches())
+		})
+	}
+}
+
+func TestProtoFormatErrors(t *testing.T) {
+	server, _ := initializeTestServer(nil)
+	defer server.Close()
+
+	statusCode, resBodyStr, err := postBytes("application/json", server.URL+`/api/traces`, []byte(`{"batch": {"spans": [{"operationName": "op"}]}}`))
+	require.NoError(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, statusCode)
+	assert.EqualValues(t, "Unable to process request body: span 0 has no process and the batch has no process\n", resBodyStr)
+
+	statusCode, resBodyStr, err = postBytes("application/json", server.URL+`/api/traces`, []byte(`{"batch": {"spans": [null]}}`))
+	require.NoError(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, statusCode)
+	assert.EqualValues(t, "Unable to process request body: span 0 is null\n", resBodyStr)
+
+	statusCode, resBodyStr, err = postBytes("application/json", server.URL+`/api/traces`, []byte(`{"batch": {"unknown": 1}}`))
+	require.NoError(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, statusCode)
+	assert.Contains(t, resBodyStr, "Unable to process request body: unknown field &#34;unknown&#34;")
+
+	statusCode, resBodyStr, err = postBytes("application/x-protobuf", server.URL+`/api/traces`, []byte("not good"))
+	require.NoError(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, statusCode)
+	assert.Contains(t, resBodyStr, "Unable to process request body: ")
+}
+
+func TestProtoFormatProcessorErrors(t *testing.T) {
+	body, err := proto.Marshal(makePostSpansRequest())
+	require.NoError(t, err)
+	tests := []struct {
+		err        error
+		statusCode int
+		body       string
+	}{
+		{err: fmt.Errorf("Bad times ahead"), statusCode: http.StatusInternalServerError, body: "Cannot submit spans: Bad times ahead\n"},
+		{err: processor.ErrBusy, statusCode: http.StatusServiceUnavailable, body: "Cannot submit spans: server busy\n"},
+		{err: &processor.OverloadedError{Shed: 2, RetryAfter: time.Second}, statusCode: http.StatusServiceUnavailable, body: "server overloaded, 2 spans were shed, retry after 1s\n"},
+	}
+	for _, test := range tests {
+		server, _ := initializeTestServer(test.err)
+		statusCode, resBodyStr, err := postBytes("application/x-protobuf", server.URL+`/api/traces`, body)
+		server.Close()
+		require.NoError(t, err)
+		assert.EqualValues(t, test.statusCode, statusCode)
+		assert.EqualValues(t, test.body, resBodyStr)
+	}
+}
+
+func TestGzipBody(t *testing.T) {
+	jsonString, err := new(jsonpb.Marshaler).MarshalToString(makePostSpansRequest())
+	require.NoError(t, err)
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	_, err = gz.Write([]byte(jsonString))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	server, handler := initializeTestServer(nil)
+	defer server.Close()
+
+	post := func(body []byte) (int, string) {
+		req, err := http.NewRequest(http.MethodPost, server.URL+`/api/traces`, bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Encoding", "gzip")
+		res, err := httpClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		resBody, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res.StatusCode, string(resBody)
+	}
+
+	statusCode, resBodyStr := post(gzipped.Bytes())
+	assert.EqualValues(t, http.StatusAccepted, statusCode)
+	assert.EqualValues(t, "", resBodyStr)
+	assert.Len(t, handler.spanProcessor.(*mockSpanProcessor).getSpans(), 2)
+
+	statusCode, resBodyStr = post([]byte("not gzipped"))
+	assert.EqualValues(t, http.StatusBadRequest, statusCode)
+	assert.EqualValues(t, "Unable to process request body: gzip: invalid header\n", resBodyStr)
+
+	statusCode, resBodyStr = post(gzipped.Bytes()[:gzipped.Len()-4])
+	assert.EqualValues(t, http.StatusBadRequest, statusCode)
+	assert.EqualValues(t, "Unable to process request body: unexpected EOF\n", resBodyStr)
+}
+
+func TestMaxRequestSize(t *testing.T) {
+	r := mux.NewRouter()
+	NewAPIHandler(&mockJaegerHandler{}, &mockSpanProcessor{}, 100).RegisterRoutes(r)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	post := func(body []byte, gzipped bool) (int, string) {
+		req, err := http.NewRequest(http.MethodPost, server.URL+`/api/traces`, bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if gzipped {
+			req.Header.Set("Content-Encoding", "gzip")
+		}
+		res, err := httpClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		resBody, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res.StatusCode, string(resBody)
+	}
+
+	statusCode, resBodyStr := post(bytes.Repeat([]byte(" "), 101), false)
+	assert.EqualValues(t, http.StatusRequestEntityTooLarge, statusCode)
+	assert.EqualValues(t, "Unable to process request body: http: request body too large\n", resBodyStr)
+
+	// the body is small once compressed, but not once decompressed
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	_, err := gz.Write(bytes.Repeat([]byte(" "), 10000))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	require.Less(t, gzipped.Len(), 100)
+	statusCode, resBodyStr = post(gzipped.Bytes(), true)
+	assert.EqualValues(t, http.StatusRequestEntityTooLarge, statusCode)
+	assert.EqualValues(t, "Request body exceeds the max size of 100 bytes once decompressed\n", resBodyStr)
+
+	// a body at the limit is accepted
+	statusCode, _ = post([]byte(`{}`+strings.Repeat(" ", 98)), false)
+	assert.EqualValues(t, http.StatusAccepted, statusCode)
+}
+
 func TestViaClient(t *testing.T) {
 	server, handler := initializeTestServer(nil)
 	defer server.Close()
@@ -159,7 +362,7 @@ func TestMalformedFormat(t *testing.T) {
 }
 
 func TestCannotReadBodyFromRequest(t *testing.T) {
-	handler := NewAPIHandler(&mockJaegerHandler{})
+	handler := NewAPIHandler(&mockJaegerHandler{}, &mockSpanProcessor{}, 0)
 	req, err := http.NewRequest(http.MethodPost, "whatever", &errReader{})
 	assert.NoError(t, err)
 	rw := dummyResponseWriter{}
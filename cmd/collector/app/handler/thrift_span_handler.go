@@ -18,6 +18,7 @@ package handler
 import (
 	"go.uber.org/zap"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/auth"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
 	zipkinS "github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer/zipkin"
 	"github.com/jaegertracing/jaeger/model"
@@ -30,6 +31,8 @@ import (
 // SubmitBatchOptions are passed to Submit methods of the handlers.
 type SubmitBatchOptions struct {
 	InboundTransport processor.InboundTransport
+	Tenant           string
+	Identity         *auth.Identity
 }
 
 // ZipkinSpansHandler consumes and handles zipkin spans
@@ -68,6 +71,8 @@ func (jbh *jaegerBatchesHandler) SubmitBatches(batches []*jaeger.Batch, options
 		oks, err := jbh.modelProcessor.ProcessSpans(mSpans, processor.SpansOptions{
 			InboundTransport: options.InboundTransport,
 			SpanFormat:       processor.JaegerSpanFormat,
+			Tenant:           options.Tenant,
+			Identity:         options.Identity,
 		})
 		if err != nil {
 			jbh.logger.Error("Collector failed to process span batch", zap.Error(err))
@@ -115,6 +120,8 @@ func (h *zipkinSpanHandler) SubmitZipkinBatch(spans []*zipkincore.Span, options
 	bools, err := h.modelProcessor.ProcessSpans(mSpans, processor.SpansOptions{
 		InboundTransport: options.InboundTransport,
 		SpanFormat:       processor.ZipkinSpanFormat,
+		Tenant:           options.Tenant,
+		Identity:         options.Identity,
 	})
 	if err != nil {
 		h.logger.Error("Collector failed to process Zipkin span batch", zap.Error(err))
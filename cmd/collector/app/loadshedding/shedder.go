@@ -0,0 +1,181 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadshedding
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+const (
+	// DefaultReservedCritical is the default fraction of the queue capacity reserved for critical spans.
+	DefaultReservedCritical = 0.05
+	// DefaultReservedHigh is the default fraction of the queue capacity reserved for high priority spans and above.
+	DefaultReservedHigh = 0.15
+	// DefaultReservedNormal is the default fraction of the queue capacity reserved for normal priority spans and above.
+	DefaultReservedNormal = 0.3
+	// DefaultRetryAfter is the default delay after which clients are asked to retry shed spans.
+	DefaultRetryAfter = time.Second
+
+	// samplerTypeLowerBound is the sampler type of the spans of rarely called operations,
+	// sampled by the lower bound of the adaptive sampler.
+	samplerTypeLowerBound = "lowerbound"
+)
+
+// Priority is the priority class of a span, when the collector is overloaded
+// the spans of the lowest priority class are shed first.
+type Priority int
+
+const (
+	// PriorityLow is the priority of firehose spans, which are not indexed.
+	PriorityLow Priority = iota
+	// PriorityNormal is the priority of the spans without other distinctive traits.
+	PriorityNormal
+	// PriorityHigh is the priority of error spans and of spans sampled by the lower bound sampler.
+	PriorityHigh
+	// PriorityCritical is the priority of debug spans.
+	PriorityCritical
+
+	numPriorities = int(PriorityCritical) + 1
+)
+
+var priorityNames = [numPriorities]string{"low", "normal", "high", "critical"}
+
+func (p Priority) String() string {
+	return priorityNames[p]
+}
+
+// Classify returns the priority class of the span.
+func Classify(span *model.Span) Priority {
+	switch {
+	case span.Flags.IsDebug():
+		return PriorityCritical
+	case span.HasErrorTag() || span.GetSamplerType() == samplerTypeLowerBound:
+		return PriorityHigh
+	case span.Flags.IsFirehoseEnabled():
+		return PriorityLow
+	}
+	return PriorityNormal
+}
+
+// Options holds the configuration of the load shedding.
+// Each reserved fraction of the queue capacity can only be used by the spans of that class and above.
+type Options struct {
+	// Enabled turns on the priority-aware load shedding
+	Enabled bool
+	// ReservedCritical is the fraction of the queue capacity reserved for critical spans
+	ReservedCritical float64
+	// ReservedHigh is the fraction of the queue capacity reserved for high priority spans and above
+	ReservedHigh float64
+	// ReservedNormal is the fraction of the queue capacity reserved for normal priority spans and above
+	ReservedNormal float64
+	// RetryAfter is the delay after which clients are asked to retry shed spans
+	RetryAfter time.Duration
+	// MetricsFactory is used to report the shed spans per priority class
+	MetricsFactory metrics.Factory
+}
+
+type shedderMetrics struct {
+	ShedLow      metrics.Counter `metric:"spans.shed" tags:"priority=low"`
+	ShedNormal   metrics.Counter `metric:"spans.shed" tags:"priority=normal"`
+	ShedHigh     metrics.Counter `metric:"spans.shed" tags:"priority=high"`
+	ShedCritical metrics.Counter `metric:"spans.shed" tags:"priority=critical"`
+}
+
+// Shedder decides which spans are admitted to the queue based on their priority and the queue occupancy.
+type Shedder struct {
+	retryAfter time.Duration
+	// limits holds the fraction of the queue capacity usable by each priority class
+	limits [numPriorities]float64
+	shed   [numPriorities]metrics.Counter
+}
+
+// NewShedder creates a Shedder, it returns an error if the reserved fractions are invalid.
+func NewShedder(options Options) (*Shedder, error) {
+	reserved := []float64{options.ReservedNormal, options.ReservedHigh, options.ReservedCritical}
+	total := 0.0
+	for _, r := range reserved {
+		if r < 0 || r > 1 {
+			return nil, fmt.Errorf("reserved queue fraction %v must be between 0 and 1", r)
+		}
+		total += r
+	}
+	if total >= 1 {
+		return nil, fmt.Errorf("the reserved queue fractions must add up to less than 1, got %v", total)
+	}
+	if options.RetryAfter <= 0 {
+		options.RetryAfter = DefaultRetryAfter
+	}
+	if options.MetricsFactory == nil {
+		options.MetricsFactory = metrics.NullFactory
+	}
+	var m shedderMetrics
+	metrics.MustInit(&m, options.MetricsFactory, nil)
+	return &Shedder{
+		retryAfter: options.RetryAfter,
+		limits: [numPriorities]float64{
+			1 - options.ReservedCritical - options.ReservedHigh - options.ReservedNormal,
+			1 - options.ReservedCritical - options.ReservedHigh,
+			1 - options.ReservedCritical,
+			1,
+		},
+		shed: [numPriorities]metrics.Counter{m.ShedLow, m.ShedNormal, m.ShedHigh, m.ShedCritical},
+	}, nil
+}
+
+// Admit returns true if a span of the priority class may be added to a queue with the given
+// size and capacity, otherwise the span is counted as shed.
+func (s *Shedder) Admit(priority Priority, size, capacity int) bool {
+	if s.fits(priority, size, capacity) {
+		return true
+	}
+	s.shed[priority].Inc(1)
+	return false
+}
+
+// AdmitBatch returns true if a batch of spans may be added to a queue with the given size
+// and capacity, otherwise all the spans of the batch are counted as shed. Batches are admitted
+// or shed as a whole, so that clients retrying a shed batch do not duplicate spans that were
+// accepted. A batch has the priority of its most important span.
+func (s *Shedder) AdmitBatch(spans []*model.Span, size, capacity int) bool {
+	priorities := make([]Priority, len(spans))
+	batchPriority := PriorityLow
+	for i, span := range spans {
+		priorities[i] = Classify(span)
+		if priorities[i] > batchPriority {
+			batchPriority = priorities[i]
+		}
+	}
+	if s.fits(batchPriority, size, capacity) {
+		return true
+	}
+	for _, priority := range priorities {
+		s.shed[priority].Inc(1)
+	}
+	return false
+}
+
+func (s *Shedder) fits(priority Priority, size, capacity int) bool {
+	return float64(size) < s.limits[priority]*float64(capacity)
+}
+
+// RetryAfter returns the delay after which clients should retry the shed spans.
+func (s *Shedder) RetryAfter() time.Duration {
+	return s.retryAfter
+}
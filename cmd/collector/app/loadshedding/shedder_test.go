@@ -0,0 +1,128 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadshedding
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name     string
+		span     *model.Span
+		expected Priority
+	}{
+		{name: "plain", span: &model.Span{}, expected: PriorityNormal},
+		{name: "firehose", span: &model.Span{Flags: model.FirehoseFlag}, expected: PriorityLow},
+		{name: "debug", span: &model.Span{Flags: model.DebugFlag | model.FirehoseFlag}, expected: PriorityCritical},
+		{name: "error bool", span: &model.Span{Tags: model.KeyValues{model.Bool("error", true)}}, expected: PriorityHigh},
+		{name: "error string", span: &model.Span{Tags: model.KeyValues{model.String("error", "true")}}, expected: PriorityHigh},
+		{name: "error false", span: &model.Span{Tags: model.KeyValues{model.Bool("error", false)}}, expected: PriorityNormal},
+		{name: "error int", span: &model.Span{Tags: model.KeyValues{model.Int64("error", 1)}}, expected: PriorityNormal},
+		{name: "lower bound", span: &model.Span{Tags: model.KeyValues{model.String("sampler.type", "lowerbound")}}, expected: PriorityHigh},
+		{name: "firehose error", span: &model.Span{Flags: model.FirehoseFlag, Tags: model.KeyValues{model.Bool("error", true)}}, expected: PriorityHigh},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Classify(test.span))
+		})
+	}
+}
+
+func TestPriorityString(t *testing.T) {
+	assert.Equal(t, "low", PriorityLow.String())
+	assert.Equal(t, "normal", PriorityNormal.String())
+	assert.Equal(t, "high", PriorityHigh.String())
+	assert.Equal(t, "critical", PriorityCritical.String())
+}
+
+func TestNewShedderErrors(t *testing.T) {
+	_, err := NewShedder(Options{ReservedCritical: -0.1})
+	assert.EqualError(t, err, "reserved queue fraction -0.1 must be between 0 and 1")
+	_, err = NewShedder(Options{ReservedHigh: 1.5})
+	assert.EqualError(t, err, "reserved queue fraction 1.5 must be between 0 and 1")
+	_, err = NewShedder(Options{ReservedCritical: 0.5, ReservedHigh: 0.3, ReservedNormal: 0.2})
+	assert.EqualError(t, err, "the reserved queue fractions must add up to less than 1, got 1")
+}
+
+func TestShedderAdmit(t *testing.T) {
+	metricsFactory := metricstest.NewFactory(time.Hour)
+	s, err := NewShedder(Options{
+		ReservedCritical: 0.1,
+		ReservedHigh:     0.2,
+		ReservedNormal:   0.3,
+		MetricsFactory:   metricsFactory,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultRetryAfter, s.RetryAfter())
+
+	// limits with a capacity of 100 are 40, 70, 90 and 100 spans
+	tests := []struct {
+		size     int
+		admitted []bool
+	}{
+		{size: 0, admitted: []bool{true, true, true, true}},
+		{size: 39, admitted: []bool{true, true, true, true}},
+		{size: 40, admitted: []bool{false, true, true, true}},
+		{size: 70, admitted: []bool{false, false, true, true}},
+		{size: 90, admitted: []bool{false, false, false, true}},
+		{size: 100, admitted: []bool{false, false, false, false}},
+	}
+	for _, test := range tests {
+		for p, admitted := range test.admitted {
+			assert.Equal(t, admitted, s.Admit(Priority(p), test.size, 100), "priority %v with size %d", Priority(p), test.size)
+		}
+	}
+
+	metricsFactory.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "spans.shed", Tags: map[string]string{"priority": "low"}, Value: 4},
+		metricstest.ExpectedMetric{Name: "spans.shed", Tags: map[string]string{"priority": "normal"}, Value: 3},
+		metricstest.ExpectedMetric{Name: "spans.shed", Tags: map[string]string{"priority": "high"}, Value: 2},
+		metricstest.ExpectedMetric{Name: "spans.shed", Tags: map[string]string{"priority": "critical"}, Value: 1},
+	)
+}
+
+func TestShedderAdmitBatch(t *testing.T) {
+	metricsFactory := metricstest.NewFactory(time.Hour)
+	s, err := NewShedder(Options{ReservedNormal: 0.5, MetricsFactory: metricsFactory})
+	require.NoError(t, err)
+
+	firehose := &model.Span{Flags: model.FirehoseFlag}
+	debug := &model.Span{Flags: model.DebugFlag}
+	assert.True(t, s.AdmitBatch([]*model.Span{firehose, firehose}, 49, 100))
+	assert.False(t, s.AdmitBatch([]*model.Span{firehose, firehose}, 50, 100))
+	// the debug span raises the priority of the whole batch
+	assert.True(t, s.AdmitBatch([]*model.Span{firehose, debug}, 50, 100))
+	assert.False(t, s.AdmitBatch([]*model.Span{firehose, debug}, 100, 100))
+
+	metricsFactory.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "spans.shed", Tags: map[string]string{"priority": "low"}, Value: 3},
+		metricstest.ExpectedMetric{Name: "spans.shed", Tags: map[string]string{"priority": "critical"}, Value: 1},
+	)
+}
+
+func TestShedderRetryAfter(t *testing.T) {
+	s, err := NewShedder(Options{RetryAfter: 5 * time.Second})
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, s.RetryAfter())
+	assert.True(t, s.Admit(PriorityLow, 99, 100))
+}
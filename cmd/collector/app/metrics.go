@@ -118,6 +118,7 @@ func NewSpanProcessorMetrics(serviceMetrics metrics.Factory, hostMetrics metrics
 		processor.ZipkinSpanFormat:  newCountsByTransport(serviceMetrics, processor.ZipkinSpanFormat),
 		processor.JaegerSpanFormat:  newCountsByTransport(serviceMetrics, processor.JaegerSpanFormat),
 		processor.ProtoSpanFormat:   newCountsByTransport(serviceMetrics, processor.ProtoSpanFormat),
+		processor.JSONSpanFormat:    newCountsByTransport(serviceMetrics, processor.JSONSpanFormat),
 		processor.UnknownSpanFormat: newCountsByTransport(serviceMetrics, processor.UnknownSpanFormat),
 	}
 	for _, otherFormatType := range otherFormatTypes {
@@ -196,6 +197,7 @@ func newCountsByTransport(factory metrics.Factory, format processor.SpanFormat)
 	return SpanCountsByTransport{
 		processor.HTTPTransport:    newCounts(factory, processor.HTTPTransport),
 		processor.GRPCTransport:    newCounts(factory, processor.GRPCTransport),
+		processor.FileTransport:    newCounts(factory, processor.FileTransport),
 		processor.UnknownTransport: newCounts(factory, processor.UnknownTransport),
 	}
 }
@@ -19,8 +19,10 @@ import (
 	"github.com/uber/jaeger-lib/metrics"
 	"go.uber.org/zap"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/loadshedding"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/throughput"
 	"github.com/jaegertracing/jaeger/model"
 )
 
@@ -47,6 +49,8 @@ type options struct {
 	reportBusy         bool
 	extraFormatTypes   []processor.SpanFormat
 	collectorTags      map[string]string
+	shedder            *loadshedding.Shedder
+	throughputTracker  *throughput.Tracker
 }
 
 // Option is a function that sets some option on StorageBuilder.
@@ -160,6 +164,20 @@ func (options) CollectorTags(extraTags map[string]string) Option {
 	}
 }
 
+// LoadShedder creates an Option that enables the priority-aware load shedding of the queue
+func (options) LoadShedder(shedder *loadshedding.Shedder) Option {
+	return func(b *options) {
+		b.shedder = shedder
+	}
+}
+
+// ThroughputTracker creates an Option that counts the received and dropped spans in the throughput tracker
+func (options) ThroughputTracker(tracker *throughput.Tracker) Option {
+	return func(b *options) {
+		b.throughputTracker = tracker
+	}
+}
+
 func (o options) apply(opts ...Option) options {
 	ret := options{}
 	for _, opt := range opts {
@@ -181,7 +199,7 @@ func (o options) apply(opts ...Option) options {
 		ret.sanitizer = func(span *model.Span) *model.Span { return span }
 	}
 	if ret.preSave == nil {
-		ret.preSave = func(span *model.Span) {}
+		ret.preSave = func(span *model.Span, tenant string) {}
 	}
 	if ret.spanFilter == nil {
 		ret.spanFilter = func(span *model.Span) bool { return true }
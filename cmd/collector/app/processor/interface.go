@@ -16,18 +16,37 @@ package processor
 
 import (
 	"errors"
+	"fmt"
 	"io"
+	"time"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/auth"
 	"github.com/jaegertracing/jaeger/model"
 )
 
 // ErrBusy signalizes that processor cannot process incoming data
 var ErrBusy = errors.New("server busy")
 
+// OverloadedError signalizes that a batch was shed because the processor is overloaded,
+// none of its spans was processed.
+type OverloadedError struct {
+	// Shed is the number of spans of the shed batch
+	Shed int
+	// RetryAfter is the delay after which the client should retry the shed spans
+	RetryAfter time.Duration
+}
+
+func (e *OverloadedError) Error() string {
+	return fmt.Sprintf("server overloaded, %d spans were shed, retry after %v", e.Shed, e.RetryAfter)
+}
+
 // SpansOptions additional options passed to processor along with the spans.
 type SpansOptions struct {
 	SpanFormat       SpanFormat
 	InboundTransport InboundTransport
+	Tenant           string
+	// Identity is the authenticated client that submitted the spans, nil if authentication is disabled
+	Identity *auth.Identity
 }
 
 // SpanProcessor handles model spans
@@ -45,6 +64,8 @@ const (
 	GRPCTransport InboundTransport = "grpc"
 	// HTTPTransport indicates spans received over HTTP.
 	HTTPTransport InboundTransport = "http"
+	// FileTransport indicates spans read from files.
+	FileTransport InboundTransport = "file"
 	// UnknownTransport is the fallback/catch-all category.
 	UnknownTransport InboundTransport = "unknown"
 )
@@ -59,6 +80,8 @@ const (
 	ZipkinSpanFormat SpanFormat = "zipkin"
 	// ProtoSpanFormat is for Jaeger protobuf Spans.
 	ProtoSpanFormat SpanFormat = "proto"
+	// JSONSpanFormat is for Jaeger protobuf-JSON Spans.
+	JSONSpanFormat SpanFormat = "json"
 	// UnknownSpanFormat is the fallback/catch-all category.
 	UnknownSpanFormat SpanFormat = "unknown"
 )
@@ -34,8 +34,9 @@ func NewGRPCHandler(store strategystore.StrategyStore) GRPCHandler {
 	}
 }
 
-// GetSamplingStrategy returns sampling decision from store.
+// GetSamplingStrategy returns sampling decision from store, for the client attributes of the request metadata.
 func (s GRPCHandler) GetSamplingStrategy(ctx context.Context, param *api_v2.SamplingStrategyParameters) (*api_v2.SamplingStrategyResponse, error) {
+	ctx = strategystore.ContextWithClientAttributes(ctx, strategystore.ClientAttributesFromIncomingContext(ctx))
 	r, err := s.store.GetSamplingStrategy(ctx, param.GetServiceName())
 	if err != nil {
 		return nil, err
@@ -21,7 +21,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
 	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
 )
@@ -37,6 +39,16 @@ func (s mockSamplingStore) GetSamplingStrategy(ctx context.Context, serviceName
 	return &sampling.SamplingStrategyResponse{StrategyType: sampling.SamplingStrategyType_PROBABILISTIC}, nil
 }
 
+// attributesSamplingStore records the client attributes of the last request.
+type attributesSamplingStore struct {
+	attributes *strategystore.ClientAttributes
+}
+
+func (s *attributesSamplingStore) GetSamplingStrategy(ctx context.Context, serviceName string) (*sampling.SamplingStrategyResponse, error) {
+	s.attributes = strategystore.GetClientAttributes(ctx)
+	return &sampling.SamplingStrategyResponse{StrategyType: sampling.SamplingStrategyType_PROBABILISTIC}, nil
+}
+
 func TestNewGRPCHandler(t *testing.T) {
 	tests := []struct {
 		req  *api_v2.SamplingStrategyParameters
@@ -59,3 +71,24 @@ func TestNewGRPCHandler(t *testing.T) {
 		}
 	}
 }
+
+func TestGRPCHandlerClientAttributes(t *testing.T) {
+	store := &attributesSamplingStore{}
+	h := NewGRPCHandler(store)
+	req := &api_v2.SamplingStrategyParameters{ServiceName: "foo"}
+
+	_, err := h.GetSamplingStrategy(context.Background(), req)
+	require.NoError(t, err)
+	assert.Nil(t, store.attributes)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		strategystore.EnvironmentMetadataKey, "canary",
+		strategystore.TagMetadataKey, "region:eu",
+	))
+	_, err = h.GetSamplingStrategy(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, &strategystore.ClientAttributes{
+		Environment: "canary",
+		Tags:        map[string]string{"region": "eu"},
+	}, store.attributes)
+}
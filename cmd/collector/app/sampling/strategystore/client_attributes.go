@@ -0,0 +1,144 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package strategystore
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// The gRPC metadata keys carrying the client attributes of the sampling strategy requests to
+// api_v2.SamplingManager, which are not part of the SamplingStrategyParameters of the IDL.
+// All the keys are optional, the requests without them get the strategy of the service:
+//   - jaeger-client-environment is the deployment environment of the client, e.g. "production";
+//   - jaeger-client-hostname is the hostname of the client;
+//   - jaeger-client-sdk-version is the name and version of the client library, e.g. "Go-2.29.1";
+//   - jaeger-client-tag is a tag of the client, a key:value pair split at the first ':', repeated for each tag.
+//
+// Only the first value of the other keys is used. The agent forwarding the requests to the collector
+// adds the tags of its --agent.tags flag with this key as well.
+const (
+	EnvironmentMetadataKey = "jaeger-client-environment"
+	HostnameMetadataKey    = "jaeger-client-hostname"
+	SDKVersionMetadataKey  = "jaeger-client-sdk-version"
+	TagMetadataKey         = "jaeger-client-tag"
+)
+
+type clientAttributesContextKey struct{}
+
+// ClientAttributes describe the client requesting a sampling strategy, the strategy stores may serve
+// different strategies to the clients of a service depending on them. Empty attributes are unknown.
+type ClientAttributes struct {
+	Environment string
+	Hostname    string
+	SDKVersion  string
+	Tags        map[string]string
+}
+
+// IsEmpty returns true if no attribute is known.
+func (a *ClientAttributes) IsEmpty() bool {
+	return a == nil || (a.Environment == "" && a.Hostname == "" && a.SDKVersion == "" && len(a.Tags) == 0)
+}
+
+// WithTags returns the attributes with the tags added, the tags of the attributes take precedence.
+func (a *ClientAttributes) WithTags(tags map[string]string) *ClientAttributes {
+	if len(tags) == 0 {
+		return a
+	}
+	merged := &ClientAttributes{Tags: make(map[string]string, len(tags))}
+	if a != nil {
+		merged.Environment, merged.Hostname, merged.SDKVersion = a.Environment, a.Hostname, a.SDKVersion
+		for k, v := range a.Tags {
+			merged.Tags[k] = v
+		}
+	}
+	for k, v := range tags {
+		if _, ok := merged.Tags[k]; !ok {
+			merged.Tags[k] = v
+		}
+	}
+	return merged
+}
+
+// ContextWithClientAttributes sets the client attributes in the context.
+func ContextWithClientAttributes(ctx context.Context, attributes *ClientAttributes) context.Context {
+	if attributes.IsEmpty() {
+		return ctx
+	}
+	return context.WithValue(ctx, clientAttributesContextKey{}, attributes)
+}
+
+// GetClientAttributes returns the client attributes of the context, or nil if there are none.
+func GetClientAttributes(ctx context.Context) *ClientAttributes {
+	attributes, _ := ctx.Value(clientAttributesContextKey{}).(*ClientAttributes)
+	return attributes
+}
+
+// OutgoingContextWithClientAttributes adds the client attributes to the metadata of the outgoing gRPC requests.
+func OutgoingContextWithClientAttributes(ctx context.Context, attributes *ClientAttributes) context.Context {
+	if attributes.IsEmpty() {
+		return ctx
+	}
+	var kv []string
+	if attributes.Environment != "" {
+		kv = append(kv, EnvironmentMetadataKey, attributes.Environment)
+	}
+	if attributes.Hostname != "" {
+		kv = append(kv, HostnameMetadataKey, attributes.Hostname)
+	}
+	if attributes.SDKVersion != "" {
+		kv = append(kv, SDKVersionMetadataKey, attributes.SDKVersion)
+	}
+	for k, v := range attributes.Tags {
+		kv = append(kv, TagMetadataKey, k+":"+v)
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
+
+// ClientAttributesFromIncomingContext returns the client attributes of the metadata of an incoming gRPC request,
+// or nil if there are none. The tags which are not key:value pairs are ignored.
+func ClientAttributesFromIncomingContext(ctx context.Context) *ClientAttributes {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil
+	}
+	first := func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+	attributes := &ClientAttributes{
+		Environment: first(EnvironmentMetadataKey),
+		Hostname:    first(HostnameMetadataKey),
+		SDKVersion:  first(SDKVersionMetadataKey),
+	}
+	for _, tag := range md.Get(TagMetadataKey) {
+		kv := strings.SplitN(tag, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		if attributes.Tags == nil {
+			attributes.Tags = make(map[string]string)
+		}
+		attributes.Tags[kv[0]] = kv[1]
+	}
+	if attributes.IsEmpty() {
+		return nil
+	}
+	return attributes
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package strategystore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestClientAttributesContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Nil(t, GetClientAttributes(ctx))
+	assert.Equal(t, ctx, ContextWithClientAttributes(ctx, &ClientAttributes{}))
+
+	attributes := &ClientAttributes{Environment: "canary"}
+	assert.Equal(t, attributes, GetClientAttributes(ContextWithClientAttributes(ctx, attributes)))
+}
+
+func TestClientAttributesWithTags(t *testing.T) {
+	var attributes *ClientAttributes
+	assert.Nil(t, attributes.WithTags(nil))
+	assert.Equal(t, &ClientAttributes{Tags: map[string]string{"region": "eu"}},
+		attributes.WithTags(map[string]string{"region": "eu"}))
+
+	attributes = &ClientAttributes{Hostname: "host-1", Tags: map[string]string{"region": "us"}}
+	assert.Equal(t, &ClientAttributes{
+		Hostname: "host-1",
+		Tags:     map[string]string{"region": "us", "zone": "a"},
+	}, attributes.WithTags(map[string]string{"region": "eu", "zone": "a"}))
+	assert.Equal(t, map[string]string{"region": "us"}, attributes.Tags, "the attributes are not modified")
+}
+
+func TestClientAttributesMetadata(t *testing.T) {
+	ctx := context.Background()
+	assert.Nil(t, ClientAttributesFromIncomingContext(ctx))
+	assert.Equal(t, ctx, OutgoingContextWithClientAttributes(ctx, nil))
+
+	attributes := &ClientAttributes{
+		Environment: "canary",
+		Hostname:    "host-1",
+		SDKVersion:  "Go-2.29.1",
+		Tags:        map[string]string{"region": "eu", "url": "http://localhost:8080"},
+	}
+	md, ok := metadata.FromOutgoingContext(OutgoingContextWithClientAttributes(ctx, attributes))
+	assert.True(t, ok)
+	assert.Equal(t, attributes, ClientAttributesFromIncomingContext(metadata.NewIncomingContext(ctx, md)))
+
+	md = metadata.Pairs(TagMetadataKey, "invalid", "authorization", "Bearer token")
+	assert.Nil(t, ClientAttributesFromIncomingContext(metadata.NewIncomingContext(ctx, md)))
+}
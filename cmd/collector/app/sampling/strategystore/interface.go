@@ -17,6 +17,7 @@ package strategystore
 import (
 	"context"
 
+	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
 )
 
@@ -25,3 +26,26 @@ type StrategyStore interface {
 	// GetSamplingStrategy retrieves the sampling strategy for the specified service.
 	GetSamplingStrategy(ctx context.Context, serviceName string) (*sampling.SamplingStrategyResponse, error)
 }
+
+// Aggregator is implemented by the strategy stores which aggregate the throughput of the spans
+// received by the collector, as adaptive sampling does.
+type Aggregator interface {
+	// HandleRootSpan records the throughput of a span, if it is a root span.
+	HandleRootSpan(span *model.Span, tenant string)
+}
+
+// StrategySources tells which source of strategies decided the default strategy and each operation
+// strategy of a sampling strategy response, for debugging.
+type StrategySources struct {
+	// Default is the source of the strategy type and of the default operation sampling
+	Default string `json:"default"`
+	// Operations maps the operations of the per-operation strategies to their source
+	Operations map[string]string `json:"operations,omitempty"`
+}
+
+// StrategySourcesStore is implemented by the strategy stores combining several sources of strategies.
+type StrategySourcesStore interface {
+	// GetSamplingStrategyWithSources retrieves the sampling strategy for the specified service,
+	// and the sources that decided it.
+	GetSamplingStrategyWithSources(ctx context.Context, serviceName string) (*sampling.SamplingStrategyResponse, *StrategySources, error)
+}
@@ -0,0 +1,269 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sanitizer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/uber/jaeger-lib/metrics"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/cache"
+)
+
+const (
+	// OtherOperations is the operation name assigned to spans of a service
+	// once the service exceeds the maximum number of distinct operations.
+	OtherOperations = "other-operations"
+
+	// OriginalOperationNameTag is the span tag holding the operation name reported
+	// by the client whenever the operation name sanitizer rewrites it.
+	OriginalOperationNameTag = "original.operation.name"
+
+	// DefaultMaxServices is the default number of services whose operations are tracked.
+	DefaultMaxServices = 10000
+
+	// anyService is the service name of rules that apply to all services.
+	anyService = "*"
+)
+
+// OperationNameRule describes how to normalize the operation names of a service.
+//
+// A rule is either a Template, in which every "{placeholder}" matches a non-empty
+// sequence of characters other than '/', e.g. "GET /users/{id}/orders", or a Regex
+// together with its Replacement, which may refer to capture groups as $1 or ${name}.
+// Rules with an empty Service or "*" apply to every service.
+type OperationNameRule struct {
+	Service     string `json:"service"`
+	Template    string `json:"template,omitempty"`
+	Regex       string `json:"regex,omitempty"`
+	Replacement string `json:"replacement,omitempty"`
+}
+
+type operationNameRules struct {
+	Rules []OperationNameRule `json:"rules"`
+}
+
+// OperationNameSanitizerOptions holds the configuration of the operation name sanitizer.
+type OperationNameSanitizerOptions struct {
+	// Rules is the ordered list of normalization rules; the first matching rule wins,
+	// with the rules of the span's service taking precedence over rules for all services.
+	Rules []OperationNameRule
+	// MaxOperationsPerService caps the number of distinct operation names per service,
+	// any further operation is renamed to OtherOperations. Zero means no limit.
+	MaxOperationsPerService int
+	// MaxServices caps the number of services whose operations are tracked for MaxOperationsPerService,
+	// the operations of the least recently seen service are forgotten beyond it. Zero means DefaultMaxServices.
+	MaxServices int
+	// MetricsFactory is used to report how many operation names were rewritten.
+	MetricsFactory metrics.Factory
+}
+
+type operationNameSanitizerMetrics struct {
+	// Number of operation names rewritten by one of the rules
+	Normalized metrics.Counter `metric:"operation-names.normalized"`
+	// Number of operation names collapsed into OtherOperations because of the per-service limit
+	Collapsed metrics.Counter `metric:"operation-names.collapsed"`
+	// Number of distinct operation names currently tracked across all services
+	Tracked metrics.Gauge `metric:"operation-names.tracked"`
+}
+
+type compiledRule struct {
+	regex       *regexp.Regexp
+	replacement string
+	template    bool
+}
+
+type operationNameSanitizer struct {
+	serviceRules  map[string][]compiledRule
+	defaultRules  []compiledRule
+	maxOperations int
+	metrics       operationNameSanitizerMetrics
+
+	lock sync.Mutex
+	// operations holds the set of operation names, map[string]struct{}, of each service
+	operations *cache.LRU
+	tracked    int64
+}
+
+// LoadOperationNameRules reads operation name rules from a JSON file of the form
+// {"rules": [{"service": "users", "template": "GET /users/{id}/orders"}]}.
+func LoadOperationNameRules(path string) ([]OperationNameRule, error) {
+	bytes, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read operation name rules file: %w", err)
+	}
+	var rules operationNameRules
+	if err := json.Unmarshal(bytes, &rules); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal operation name rules: %w", err)
+	}
+	return rules.Rules, nil
+}
+
+// NewOperationNameSanitizer creates a sanitizer that normalizes operation names
+// according to the given rules and caps the number of operations per service.
+func NewOperationNameSanitizer(opts OperationNameSanitizerOptions) (SanitizeSpan, error) {
+	s := &operationNameSanitizer{
+		serviceRules:  make(map[string][]compiledRule),
+		maxOperations: opts.MaxOperationsPerService,
+	}
+	maxServices := opts.MaxServices
+	if maxServices <= 0 {
+		maxServices = DefaultMaxServices
+	}
+	s.operations = cache.NewLRUWithOptions(maxServices, &cache.Options{
+		// called by track, which holds the lock
+		OnEvict: func(_ string, operations interface{}) {
+			s.tracked -= int64(len(operations.(map[string]struct{})))
+			s.metrics.Tracked.Update(s.tracked)
+		},
+	})
+	for i, rule := range opts.Rules {
+		compiled, err := compileRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid operation name rule #%d: %w", i, err)
+		}
+		if rule.Service == "" || rule.Service == anyService {
+			s.defaultRules = append(s.defaultRules, compiled)
+		} else {
+			s.serviceRules[rule.Service] = append(s.serviceRules[rule.Service], compiled)
+		}
+	}
+	metricsFactory := opts.MetricsFactory
+	if metricsFactory == nil {
+		metricsFactory = metrics.NullFactory
+	}
+	metrics.MustInit(&s.metrics, metricsFactory, nil)
+	return s.Sanitize, nil
+}
+
+func compileRule(rule OperationNameRule) (compiledRule, error) {
+	switch {
+	case rule.Template != "" && rule.Regex != "":
+		return compiledRule{}, errors.New("template and regex are mutually exclusive")
+	case rule.Template != "":
+		re, err := templateToRegex(rule.Template)
+		if err != nil {
+			return compiledRule{}, err
+		}
+		return compiledRule{regex: re, replacement: rule.Template, template: true}, nil
+	case rule.Regex != "":
+		if rule.Replacement == "" {
+			return compiledRule{}, errors.New("regex requires a replacement")
+		}
+		re, err := regexp.Compile(rule.Regex)
+		if err != nil {
+			return compiledRule{}, err
+		}
+		return compiledRule{regex: re, replacement: rule.Replacement}, nil
+	default:
+		return compiledRule{}, errors.New("either template or regex must be specified")
+	}
+}
+
+// templateToRegex converts a template like "GET /users/{id}" into the anchored regex "^GET /users/[^/]+$".
+func templateToRegex(template string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	rest := template
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			sb.WriteString(regexp.QuoteMeta(rest))
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated placeholder in template %q", template)
+		}
+		sb.WriteString(regexp.QuoteMeta(rest[:start]))
+		sb.WriteString("[^/]+")
+		rest = rest[start+end+1:]
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+// Sanitize normalizes the operation name of the span and enforces the per-service operations limit.
+func (s *operationNameSanitizer) Sanitize(span *model.Span) *model.Span {
+	if span.Process == nil {
+		return span
+	}
+	serviceName := span.Process.ServiceName
+	original := span.OperationName
+
+	name, ok := s.applyRules(s.serviceRules[serviceName], original)
+	if !ok {
+		name, ok = s.applyRules(s.defaultRules, original)
+	}
+	if ok && name != original {
+		s.metrics.Normalized.Inc(1)
+	}
+	if !s.track(serviceName, name) {
+		s.metrics.Collapsed.Inc(1)
+		name = OtherOperations
+	}
+
+	if name != original {
+		span.Tags = append(span.Tags, model.String(OriginalOperationNameTag, original))
+		span.OperationName = name
+	}
+	return span
+}
+
+func (s *operationNameSanitizer) applyRules(rules []compiledRule, operationName string) (string, bool) {
+	for _, rule := range rules {
+		if !rule.regex.MatchString(operationName) {
+			continue
+		}
+		if rule.template {
+			return rule.replacement, true
+		}
+		return rule.regex.ReplaceAllString(operationName, rule.replacement), true
+	}
+	return operationName, false
+}
+
+// track records the operation of the service and returns false if the service
+// already reached the maximum number of distinct operations.
+func (s *operationNameSanitizer) track(serviceName, operationName string) bool {
+	if s.maxOperations <= 0 {
+		return true
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	operations, ok := s.operations.Get(serviceName).(map[string]struct{})
+	if !ok {
+		operations = make(map[string]struct{})
+		s.operations.Put(serviceName, operations)
+	}
+	if _, ok := operations[operationName]; ok {
+		return true
+	}
+	if len(operations) >= s.maxOperations {
+		return false
+	}
+	operations[operationName] = struct{}{}
+	s.tracked++
+	s.metrics.Tracked.Update(s.tracked)
+	return true
+}
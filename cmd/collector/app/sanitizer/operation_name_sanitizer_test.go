@@ -0,0 +1,183 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sanitizer
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+func newSpan(service, operation string) *model.Span {
+	return &model.Span{
+		OperationName: operation,
+		Process:       &model.Process{ServiceName: service},
+	}
+}
+
+func TestOperationNameSanitizerRules(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	s, err := NewOperationNameSanitizer(OperationNameSanitizerOptions{
+		Rules: []OperationNameRule{
+			{Service: "users", Template: "GET /users/{id}/orders"},
+			{Service: "users", Regex: `^GET /accounts/\d+$`, Replacement: "GET /accounts/{id}"},
+			{Service: "*", Regex: `/[0-9a-f]{32}`, Replacement: "/{hash}"},
+		},
+		MetricsFactory: mf,
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		service   string
+		operation string
+		expected  string
+	}{
+		{"users", "GET /users/8812/orders", "GET /users/{id}/orders"},
+		{"users", "GET /users/8812/orders/1", "GET /users/8812/orders/1"},
+		{"users", "GET /accounts/42", "GET /accounts/{id}"},
+		{"users", "GET /accounts/abc", "GET /accounts/abc"},
+		{"orders", "GET /users/8812/orders", "GET /users/8812/orders"},
+		{"orders", "GET /blob/0123456789abcdef0123456789abcdef", "GET /blob/{hash}"},
+	}
+	normalized := 0
+	for _, test := range tests {
+		t.Run(test.operation, func(t *testing.T) {
+			span := s(newSpan(test.service, test.operation))
+			assert.Equal(t, test.expected, span.OperationName)
+			if test.expected == test.operation {
+				assert.Empty(t, span.Tags)
+			} else {
+				normalized++
+				assert.Equal(t, []model.KeyValue{model.String(OriginalOperationNameTag, test.operation)}, span.Tags)
+			}
+		})
+	}
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "operation-names.normalized", Value: normalized})
+}
+
+func TestOperationNameSanitizerLimit(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	s, err := NewOperationNameSanitizer(OperationNameSanitizerOptions{
+		MaxOperationsPerService: 2,
+		MetricsFactory:          mf,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "op1", s(newSpan("svc", "op1")).OperationName)
+	assert.Equal(t, "op2", s(newSpan("svc", "op2")).OperationName)
+	assert.Equal(t, "op1", s(newSpan("svc", "op1")).OperationName)
+
+	span := s(newSpan("svc", "op3"))
+	assert.Equal(t, OtherOperations, span.OperationName)
+	assert.Equal(t, []model.KeyValue{model.String(OriginalOperationNameTag, "op3")}, span.Tags)
+
+	// the limit is per service
+	assert.Equal(t, "op3", s(newSpan("another-svc", "op3")).OperationName)
+
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "operation-names.collapsed", Value: 1})
+	mf.AssertGaugeMetrics(t, metricstest.ExpectedMetric{Name: "operation-names.tracked", Value: 3})
+}
+
+func TestOperationNameSanitizerMaxServices(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	s, err := NewOperationNameSanitizer(OperationNameSanitizerOptions{
+		MaxOperationsPerService: 1,
+		MaxServices:             2,
+		MetricsFactory:          mf,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "op1", s(newSpan("svc1", "op1")).OperationName)
+	assert.Equal(t, "op1", s(newSpan("svc2", "op1")).OperationName)
+	assert.Equal(t, OtherOperations, s(newSpan("svc1", "op2")).OperationName)
+	mf.AssertGaugeMetrics(t, metricstest.ExpectedMetric{Name: "operation-names.tracked", Value: 2})
+
+	// svc2 is the least recently seen service, its operations are forgotten
+	assert.Equal(t, "op1", s(newSpan("svc3", "op1")).OperationName)
+	mf.AssertGaugeMetrics(t, metricstest.ExpectedMetric{Name: "operation-names.tracked", Value: 2})
+	assert.Equal(t, "op2", s(newSpan("svc2", "op2")).OperationName)
+	assert.Equal(t, "op2", s(newSpan("svc1", "op2")).OperationName, "svc1 was evicted by svc2")
+}
+
+func TestOperationNameSanitizerNoProcess(t *testing.T) {
+	s, err := NewOperationNameSanitizer(OperationNameSanitizerOptions{MaxOperationsPerService: 1})
+	require.NoError(t, err)
+	span := &model.Span{OperationName: "op"}
+	assert.Equal(t, span, s(span))
+}
+
+func TestOperationNameSanitizerInvalidRules(t *testing.T) {
+	rules := []OperationNameRule{
+		{Service: "svc"},
+		{Template: "a", Regex: "b", Replacement: "c"},
+		{Regex: "b"},
+		{Regex: "(", Replacement: "c"},
+		{Template: "GET /{id"},
+	}
+	for _, rule := range rules {
+		_, err := NewOperationNameSanitizer(OperationNameSanitizerOptions{Rules: []OperationNameRule{rule}})
+		assert.Error(t, err, "%+v", rule)
+	}
+}
+
+func TestLoadOperationNameRules(t *testing.T) {
+	f, err := ioutil.TempFile("", "operation-names")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(`{"rules": [{"service": "users", "template": "GET /users/{id}"}]}`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	rules, err := LoadOperationNameRules(f.Name())
+	require.NoError(t, err)
+	assert.Equal(t, []OperationNameRule{{Service: "users", Template: "GET /users/{id}"}}, rules)
+
+	_, err = LoadOperationNameRules("invalid-file-name")
+	assert.Error(t, err)
+
+	require.NoError(t, ioutil.WriteFile(f.Name(), []byte("bad json"), 0600))
+	_, err = LoadOperationNameRules(f.Name())
+	assert.Error(t, err)
+}
+
+func TestOperationNameSanitizerWithSpanLimits(t *testing.T) {
+	operationNameSanitizer, err := NewOperationNameSanitizer(OperationNameSanitizerOptions{
+		Rules: []OperationNameRule{{Service: "users", Template: "GET /users/{id}"}},
+	})
+	require.NoError(t, err)
+	s := NewChainedSanitizer(operationNameSanitizer, NewSpanLimitsSanitizer(SpanLimitsOptions{MaxTags: 4}))
+
+	span := newSpan("users", "GET /users/42")
+	span.Tags = model.KeyValues{
+		model.String("a", "a"),
+		model.String("b", "b"),
+		model.String("c", "c"),
+		model.String("internal.span.format", "proto"),
+	}
+	span = s(span)
+	assert.Equal(t, "GET /users/{id}", span.OperationName)
+	assert.Equal(t, model.KeyValues{
+		model.String("a", "a"),
+		model.String("internal.span.format", "proto"),
+		model.String(OriginalOperationNameTag, "GET /users/42"),
+		model.Bool(TruncatedTag, true),
+	}, model.KeyValues(span.Tags))
+}
@@ -20,15 +20,18 @@ import (
 )
 
 // SanitizeSpan sanitizes/normalizes spans. Any business logic that needs to be applied to normalize the contents of a
-// span should implement this interface.
+// span should implement this interface. A sanitizer may return nil to indicate that the span must be dropped.
 type SanitizeSpan func(span *model.Span) *model.Span
 
-// NewChainedSanitizer creates a Sanitizer from the variadic list of passed Sanitizers
+// NewChainedSanitizer creates a Sanitizer from the variadic list of passed Sanitizers.
+// The chain stops as soon as one of the sanitizers drops the span.
 func NewChainedSanitizer(sanitizers ...SanitizeSpan) SanitizeSpan {
 	return func(span *model.Span) *model.Span {
 		for _, s := range sanitizers {
 			span = s(span)
-
+			if span == nil {
+				return nil
+			}
 		}
 		return span
 	}
@@ -0,0 +1,148 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sanitizer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+const (
+	// DefaultDedupWindow is the default period during which a span is remembered to detect its duplicates.
+	DefaultDedupWindow = time.Minute
+	// DefaultDedupMaxSpans is the default max number of spans remembered, each one takes about 64 bytes.
+	DefaultDedupMaxSpans = 100000
+)
+
+// SpanDeduperOptions holds the configuration of the span de-duplication.
+type SpanDeduperOptions struct {
+	// Window is the period during which a span is remembered to detect its duplicates
+	Window time.Duration
+	// MaxSpans is the max number of spans remembered, it bounds the memory used by the deduper.
+	// When it is reached the oldest spans are forgotten before the end of their window.
+	MaxSpans int
+	// MetricsFactory is used to report the dropped duplicates
+	MetricsFactory metrics.Factory
+}
+
+type spanDeduperMetrics struct {
+	// Number of duplicate spans dropped
+	DuplicatesDropped metrics.Counter `metric:"dedup.spans-dropped"`
+	// Number of spans forgotten before the end of the window because of MaxSpans
+	Evicted metrics.Counter `metric:"dedup.evicted"`
+}
+
+// spanKey identifies a span, the hash of its content distinguishes the client and server
+// spans of Zipkin, which share the same span ID.
+type spanKey struct {
+	traceID   model.TraceID
+	spanID    model.SpanID
+	startTime int64
+	hash      uint64
+}
+
+type dedupEntry struct {
+	key      spanKey
+	received time.Time
+}
+
+type spanDeduper struct {
+	options SpanDeduperOptions
+	metrics spanDeduperMetrics
+	now     func() time.Time
+
+	lock sync.Mutex
+	seen map[spanKey]struct{}
+	// ring holds the remembered spans in the order they were received
+	ring  []dedupEntry
+	head  int
+	count int
+}
+
+// NewSpanDeduper creates a sanitizer that drops the spans identical to a span received within the window,
+// typically delivered twice because of retries. Dropped spans are returned as nil.
+func NewSpanDeduper(options SpanDeduperOptions) SanitizeSpan {
+	return newSpanDeduper(options).Sanitize
+}
+
+func newSpanDeduper(options SpanDeduperOptions) *spanDeduper {
+	if options.Window <= 0 {
+		options.Window = DefaultDedupWindow
+	}
+	if options.MaxSpans <= 0 {
+		options.MaxSpans = DefaultDedupMaxSpans
+	}
+	if options.MetricsFactory == nil {
+		options.MetricsFactory = metrics.NullFactory
+	}
+	d := &spanDeduper{
+		options: options,
+		now:     time.Now,
+		seen:    make(map[spanKey]struct{}),
+		ring:    make([]dedupEntry, options.MaxSpans),
+	}
+	metrics.MustInit(&d.metrics, options.MetricsFactory, nil)
+	return d
+}
+
+// Sanitize returns nil if the span is a duplicate of a span received within the window.
+func (d *spanDeduper) Sanitize(span *model.Span) *model.Span {
+	hash, err := model.HashCode(span)
+	if err != nil {
+		// cannot tell duplicates apart, let the span through
+		return span
+	}
+	key := spanKey{
+		traceID:   span.TraceID,
+		spanID:    span.SpanID,
+		startTime: span.StartTime.UnixNano(),
+		hash:      hash,
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	now := d.now()
+	d.expire(now)
+	if _, ok := d.seen[key]; ok {
+		d.metrics.DuplicatesDropped.Inc(1)
+		return nil
+	}
+	if d.count == len(d.ring) {
+		d.removeOldest()
+		d.metrics.Evicted.Inc(1)
+	}
+	d.ring[(d.head+d.count)%len(d.ring)] = dedupEntry{key: key, received: now}
+	d.count++
+	d.seen[key] = struct{}{}
+	return span
+}
+
+// expire forgets the spans received before the window.
+func (d *spanDeduper) expire(now time.Time) {
+	for d.count > 0 && now.Sub(d.ring[d.head].received) >= d.options.Window {
+		d.removeOldest()
+	}
+}
+
+func (d *spanDeduper) removeOldest() {
+	delete(d.seen, d.ring[d.head].key)
+	d.ring[d.head] = dedupEntry{}
+	d.head = (d.head + 1) % len(d.ring)
+	d.count--
+}
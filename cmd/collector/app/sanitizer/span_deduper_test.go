@@ -0,0 +1,96 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sanitizer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+func makeDedupSpan(spanID uint64, operationName string) *model.Span {
+	return &model.Span{
+		TraceID:       model.NewTraceID(1, 2),
+		SpanID:        model.NewSpanID(spanID),
+		OperationName: operationName,
+		StartTime:     time.Unix(100, 0),
+		Process:       &model.Process{ServiceName: "svc"},
+	}
+}
+
+func TestSpanDeduperDefaults(t *testing.T) {
+	d := newSpanDeduper(SpanDeduperOptions{})
+	assert.Equal(t, DefaultDedupWindow, d.options.Window)
+	assert.Len(t, d.ring, DefaultDedupMaxSpans)
+}
+
+func TestSpanDeduperDropsDuplicates(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	s := NewSpanDeduper(SpanDeduperOptions{MetricsFactory: mf})
+
+	assert.NotNil(t, s(makeDedupSpan(1, "op")))
+	assert.Nil(t, s(makeDedupSpan(1, "op")))
+	// different span ID
+	assert.NotNil(t, s(makeDedupSpan(2, "op")))
+	// same IDs but different content, like Zipkin client and server spans
+	assert.NotNil(t, s(makeDedupSpan(1, "other-op")))
+	// different start time
+	span := makeDedupSpan(1, "op")
+	span.StartTime = span.StartTime.Add(time.Millisecond)
+	assert.NotNil(t, s(span))
+	assert.Nil(t, s(makeDedupSpan(2, "op")))
+
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "dedup.spans-dropped", Value: 2})
+}
+
+func TestSpanDeduperWindow(t *testing.T) {
+	d := newSpanDeduper(SpanDeduperOptions{Window: time.Minute})
+	now := time.Unix(0, 0)
+	d.now = func() time.Time { return now }
+
+	assert.NotNil(t, d.Sanitize(makeDedupSpan(1, "op")))
+	now = now.Add(30 * time.Second)
+	assert.NotNil(t, d.Sanitize(makeDedupSpan(2, "op")))
+	assert.Nil(t, d.Sanitize(makeDedupSpan(1, "op")))
+
+	now = now.Add(30 * time.Second)
+	// the first span is forgotten, the second one is still remembered
+	assert.NotNil(t, d.Sanitize(makeDedupSpan(1, "op")))
+	assert.Nil(t, d.Sanitize(makeDedupSpan(2, "op")))
+	assert.Equal(t, 2, d.count)
+	assert.Len(t, d.seen, 2)
+}
+
+func TestSpanDeduperMaxSpans(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	d := newSpanDeduper(SpanDeduperOptions{MaxSpans: 2, MetricsFactory: mf})
+
+	for i := uint64(1); i <= 3; i++ {
+		assert.NotNil(t, d.Sanitize(makeDedupSpan(i, "op")))
+	}
+	// the first span was evicted to make room for the third one
+	assert.NotNil(t, d.Sanitize(makeDedupSpan(1, "op")))
+	assert.Nil(t, d.Sanitize(makeDedupSpan(3, "op")))
+	assert.Len(t, d.seen, 2)
+
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "dedup.evicted", Value: 2},
+		metricstest.ExpectedMetric{Name: "dedup.spans-dropped", Value: 1},
+	)
+}
@@ -0,0 +1,269 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sanitizer
+
+import (
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/uber/jaeger-lib/metrics"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+const (
+	// TruncatedTag is the span tag added to spans whose tags or logs were truncated or dropped.
+	TruncatedTag = "span.truncated"
+
+	// DefaultTraceWindow is the default period over which spans per trace are counted.
+	DefaultTraceWindow = time.Minute
+)
+
+// SpanLimitsOptions holds the limits enforced by the span limits sanitizer.
+// A zero value disables the respective limit.
+type SpanLimitsOptions struct {
+	// MaxTagValueLength is the max length in bytes of string and binary tag and log field values
+	MaxTagValueLength int
+	// MaxTags is the max number of tags per span, including the tags added by the collector
+	// which are kept when the tags of the client are dropped
+	MaxTags int
+	// MaxLogs is the max number of logs per span
+	MaxLogs int
+	// MaxSpanSize is the max size in bytes of a span after truncation, larger spans are dropped
+	MaxSpanSize int
+	// MaxSpansPerTrace is the max number of spans of a trace accepted within TraceWindow
+	MaxSpansPerTrace int
+	// TraceWindow is the period after which spans per trace are counted from zero again
+	TraceWindow time.Duration
+	// MetricsFactory is used to report truncated and dropped data
+	MetricsFactory metrics.Factory
+}
+
+// collectorTagKeys are the keys of the span tags added by the collector before the span limits
+// sanitizer, the span processor adds the span format. The original operation name is the only
+// record of the operation names rewritten by the operation name sanitizer.
+var collectorTagKeys = map[string]bool{
+	"internal.span.format":   true,
+	OriginalOperationNameTag: true,
+}
+
+// Enabled returns true if at least one of the limits is set.
+func (o SpanLimitsOptions) Enabled() bool {
+	return o.MaxTagValueLength > 0 || o.MaxTags > 0 || o.MaxLogs > 0 || o.MaxSpanSize > 0 || o.MaxSpansPerTrace > 0
+}
+
+type spanLimitsMetrics struct {
+	// Number of tag and log field values truncated to MaxTagValueLength
+	ValuesTruncated metrics.Counter `metric:"span-limits.values-truncated"`
+	// Number of tags dropped because of MaxTags
+	TagsDropped metrics.Counter `metric:"span-limits.tags-dropped"`
+	// Number of logs dropped because of MaxLogs
+	LogsDropped metrics.Counter `metric:"span-limits.logs-dropped"`
+	// Number of spans dropped because of MaxSpanSize
+	SpanSizeDropped metrics.Counter `metric:"span-limits.spans-dropped" tags:"reason=span-size"`
+	// Number of spans dropped because of MaxSpansPerTrace
+	TraceSizeDropped metrics.Counter `metric:"span-limits.spans-dropped" tags:"reason=spans-per-trace"`
+}
+
+type spanLimitsSanitizer struct {
+	options SpanLimitsOptions
+	metrics spanLimitsMetrics
+	now     func() time.Time
+
+	lock        sync.Mutex
+	windowStart time.Time
+	spanCounts  map[model.TraceID]int
+}
+
+// NewSpanLimitsSanitizer creates a sanitizer that truncates tag values, tags and logs of spans
+// exceeding the configured limits, and drops spans that are too large or belong to traces
+// with too many spans. Dropped spans are returned as nil.
+func NewSpanLimitsSanitizer(options SpanLimitsOptions) SanitizeSpan {
+	return newSpanLimitsSanitizer(options).Sanitize
+}
+
+func newSpanLimitsSanitizer(options SpanLimitsOptions) *spanLimitsSanitizer {
+	if options.TraceWindow <= 0 {
+		options.TraceWindow = DefaultTraceWindow
+	}
+	if options.MetricsFactory == nil {
+		options.MetricsFactory = metrics.NullFactory
+	}
+	s := &spanLimitsSanitizer{
+		options:    options,
+		now:        time.Now,
+		spanCounts: make(map[model.TraceID]int),
+	}
+	metrics.MustInit(&s.metrics, options.MetricsFactory, nil)
+	return s
+}
+
+// Sanitize enforces the limits on the span.
+func (s *spanLimitsSanitizer) Sanitize(span *model.Span) *model.Span {
+	truncated := s.truncateValues(span)
+	truncated = s.limitLogs(span) || truncated
+	truncated = s.limitTags(span, truncated) || truncated
+	if truncated {
+		span.Tags = append(span.Tags, model.Bool(TruncatedTag, true))
+	}
+
+	if s.options.MaxSpanSize > 0 && span.Size() > s.options.MaxSpanSize {
+		s.metrics.SpanSizeDropped.Inc(1)
+		return nil
+	}
+	if !s.countSpanOfTrace(span.TraceID) {
+		s.metrics.TraceSizeDropped.Inc(1)
+		return nil
+	}
+	return span
+}
+
+func (s *spanLimitsSanitizer) truncateValues(span *model.Span) bool {
+	if s.options.MaxTagValueLength <= 0 {
+		return false
+	}
+	truncated := s.truncateKV(span.Tags)
+	for _, log := range span.Logs {
+		truncated = s.truncateKV(log.Fields) || truncated
+	}
+	if span.Process != nil && s.exceedMaxLength(span.Process.Tags) {
+		// the spans of a batch share their process, which is truncated on a copy
+		// rather than in place as the queue workers sanitize the spans concurrently
+		process := *span.Process
+		process.Tags = append(model.KeyValues(nil), span.Process.Tags...)
+		truncated = s.truncateKV(process.Tags) || truncated
+		span.Process = &process
+	}
+	return truncated
+}
+
+func (s *spanLimitsSanitizer) exceedMaxLength(keyValues model.KeyValues) bool {
+	for _, kv := range keyValues {
+		if (kv.VType == model.StringType && len(kv.VStr) > s.options.MaxTagValueLength) ||
+			(kv.VType == model.BinaryType && len(kv.VBinary) > s.options.MaxTagValueLength) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *spanLimitsSanitizer) truncateKV(keyValues model.KeyValues) bool {
+	maxLength := s.options.MaxTagValueLength
+	truncated := false
+	for i := range keyValues {
+		kv := &keyValues[i]
+		switch {
+		case kv.VType == model.StringType && len(kv.VStr) > maxLength:
+			kv.VStr = truncateString(kv.VStr, maxLength)
+		case kv.VType == model.BinaryType && len(kv.VBinary) > maxLength:
+			kv.VBinary = kv.VBinary[:maxLength]
+		default:
+			continue
+		}
+		s.metrics.ValuesTruncated.Inc(1)
+		truncated = true
+	}
+	return truncated
+}
+
+// truncateString cuts the string to at most maxLength bytes without splitting a multi-byte rune.
+func truncateString(str string, maxLength int) string {
+	str = str[:maxLength]
+	for len(str) > 0 {
+		r, size := utf8.DecodeLastRuneInString(str)
+		if r != utf8.RuneError || size != 1 {
+			break
+		}
+		str = str[:len(str)-1]
+	}
+	return str
+}
+
+// limitTags drops the tags of the client beyond the room left by the tags of the collector, and by
+// TruncatedTag which is added if the span is truncated, so that the span has at most MaxTags tags.
+func (s *spanLimitsSanitizer) limitTags(span *model.Span, truncated bool) bool {
+	maxTags := s.options.MaxTags
+	if maxTags <= 0 {
+		return false
+	}
+	if truncated {
+		maxTags--
+	}
+	if len(span.Tags) <= maxTags {
+		return false
+	}
+	// The collector appends its tags, so only the last tag with each of their keys is the collector's,
+	// the clients cannot get past the limit by sending tags with the same keys.
+	collectorTags := make(map[int]bool)
+	seen := make(map[string]bool)
+	for i := len(span.Tags) - 1; i >= 0; i-- {
+		if key := span.Tags[i].Key; collectorTagKeys[key] && !seen[key] {
+			seen[key] = true
+			collectorTags[i] = true
+		}
+	}
+	room := s.options.MaxTags - 1 - len(collectorTags)
+	kept, dropped := 0, 0
+	tags := span.Tags[:0]
+	for i, tag := range span.Tags {
+		if collectorTags[i] {
+			tags = append(tags, tag)
+			continue
+		}
+		if kept < room {
+			tags = append(tags, tag)
+			kept++
+			continue
+		}
+		dropped++
+	}
+	span.Tags = tags
+	if dropped == 0 {
+		return false
+	}
+	s.metrics.TagsDropped.Inc(int64(dropped))
+	return true
+}
+
+func (s *spanLimitsSanitizer) limitLogs(span *model.Span) bool {
+	if s.options.MaxLogs <= 0 || len(span.Logs) <= s.options.MaxLogs {
+		return false
+	}
+	s.metrics.LogsDropped.Inc(int64(len(span.Logs) - s.options.MaxLogs))
+	span.Logs = span.Logs[:s.options.MaxLogs]
+	return true
+}
+
+// countSpanOfTrace counts the span towards its trace and returns false if the trace
+// already reached MaxSpansPerTrace in the current window. The counts are reset at the
+// start of every window, which keeps the memory bounded by the traces seen in one window.
+func (s *spanLimitsSanitizer) countSpanOfTrace(traceID model.TraceID) bool {
+	if s.options.MaxSpansPerTrace <= 0 {
+		return true
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if now := s.now(); now.Sub(s.windowStart) >= s.options.TraceWindow {
+		s.windowStart = now
+		s.spanCounts = make(map[model.TraceID]int)
+	}
+	count := s.spanCounts[traceID]
+	if count >= s.options.MaxSpansPerTrace {
+		return false
+	}
+	s.spanCounts[traceID] = count + 1
+	return true
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sanitizer
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+func TestSpanLimitsOptionsEnabled(t *testing.T) {
+	assert.False(t, SpanLimitsOptions{TraceWindow: time.Second}.Enabled())
+	assert.True(t, SpanLimitsOptions{MaxLogs: 1}.Enabled())
+}
+
+func TestSpanLimitsTruncateValues(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	s := NewSpanLimitsSanitizer(SpanLimitsOptions{MaxTagValueLength: 4, MetricsFactory: mf})
+
+	span := s(&model.Span{
+		Tags: model.KeyValues{
+			model.String("db.statement", "SELECT * FROM users"),
+			model.String("short", "abc"),
+			model.String("utf8", "abcé"),
+			model.Binary("payload", []byte("0123456789")),
+			model.Int64("int", 123456789),
+		},
+		Logs: []model.Log{{Fields: model.KeyValues{model.String("event", "exception")}}},
+		Process: &model.Process{
+			Tags: model.KeyValues{model.String("hostname", "localhost")},
+		},
+	})
+
+	assert.Equal(t, model.KeyValues{
+		model.String("db.statement", "SELE"),
+		model.String("short", "abc"),
+		model.String("utf8", "abc"),
+		model.Binary("payload", []byte("0123")),
+		model.Int64("int", 123456789),
+		model.Bool(TruncatedTag, true),
+	}, model.KeyValues(span.Tags))
+	assert.Equal(t, "exce", span.Logs[0].Fields[0].VStr)
+	assert.Equal(t, "loca", span.Process.Tags[0].VStr)
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "span-limits.values-truncated", Value: 5})
+}
+
+func TestSpanLimitsTagsAndLogs(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	s := NewSpanLimitsSanitizer(SpanLimitsOptions{MaxTags: 4, MaxLogs: 1, MetricsFactory: mf})
+
+	span := s(&model.Span{
+		Tags: model.KeyValues{
+			model.String("a", "a"),
+			model.String("b", "b"),
+			model.String("c", "c"),
+			model.String("d", "d"),
+			model.String("internal.span.format", "proto"),
+		},
+		Logs: []model.Log{{}, {}, {}},
+	})
+	assert.Equal(t, model.KeyValues{
+		model.String("a", "a"),
+		model.String("b", "b"),
+		model.String("internal.span.format", "proto"),
+		model.Bool(TruncatedTag, true),
+	}, model.KeyValues(span.Tags))
+	assert.Len(t, span.Logs, 1)
+
+	// within limits
+	span = s(&model.Span{
+		Tags: model.KeyValues{model.String("a", "a")},
+		Logs: []model.Log{{}},
+	})
+	assert.Len(t, span.Tags, 1)
+
+	// room for TruncatedTag when the logs are dropped
+	span = s(&model.Span{
+		Tags: model.KeyValues{model.String("a", "a"), model.String("b", "b"), model.String("c", "c"), model.String("d", "d")},
+		Logs: []model.Log{{}, {}},
+	})
+	assert.Equal(t, model.KeyValues{
+		model.String("a", "a"),
+		model.String("b", "b"),
+		model.String("c", "c"),
+		model.Bool(TruncatedTag, true),
+	}, model.KeyValues(span.Tags))
+
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "span-limits.tags-dropped", Value: 3},
+		metricstest.ExpectedMetric{Name: "span-limits.logs-dropped", Value: 3},
+	)
+}
+
+func TestSpanLimitsClientTagsWithCollectorKeys(t *testing.T) {
+	s := NewSpanLimitsSanitizer(SpanLimitsOptions{MaxTags: 3})
+
+	tags := model.KeyValues{model.String("a", "a")}
+	for i := 0; i < 10; i++ {
+		tags = append(tags, model.String("internal.span.format", "spoofed"), model.String("internal.foo", "bar"))
+	}
+	tags = append(tags, model.String("internal.span.format", "proto"))
+	span := s(&model.Span{Tags: tags})
+	assert.Equal(t, model.KeyValues{
+		model.String("a", "a"),
+		model.String("internal.span.format", "proto"),
+		model.Bool(TruncatedTag, true),
+	}, model.KeyValues(span.Tags))
+}
+
+func TestSpanLimitsSharedProcess(t *testing.T) {
+	s := NewSpanLimitsSanitizer(SpanLimitsOptions{MaxTagValueLength: 4})
+	process := &model.Process{
+		ServiceName: "foo",
+		Tags:        model.KeyValues{model.String("hostname", "localhost"), model.String("ip", "10.0.0.1")},
+	}
+	spans := make([]*model.Span, 20)
+	for i := range spans {
+		spans[i] = &model.Span{SpanID: model.NewSpanID(uint64(i)), Process: process}
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(spans); i += 8 {
+				s(spans[i])
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for _, span := range spans {
+		assert.Equal(t, []model.KeyValue{model.String("hostname", "loca"), model.String("ip", "10.0")}, span.Process.Tags)
+		assert.Equal(t, "foo", span.Process.ServiceName)
+	}
+	assert.Equal(t, []model.KeyValue{model.String("hostname", "localhost"), model.String("ip", "10.0.0.1")}, process.Tags)
+
+	// the process is not copied within limits
+	span := s(&model.Span{Process: &model.Process{Tags: model.KeyValues{model.String("ip", "::1")}}})
+	span2 := s(&model.Span{Process: span.Process})
+	assert.Same(t, span.Process, span2.Process)
+}
+
+func TestSpanLimitsSpanSize(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	s := NewSpanLimitsSanitizer(SpanLimitsOptions{MaxSpanSize: 100, MetricsFactory: mf})
+
+	assert.NotNil(t, s(&model.Span{OperationName: "small"}))
+	assert.Nil(t, s(&model.Span{OperationName: strings.Repeat("x", 200)}))
+
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "span-limits.spans-dropped|reason=span-size", Value: 1})
+}
+
+func TestSpanLimitsSpansPerTrace(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	s := newSpanLimitsSanitizer(SpanLimitsOptions{MaxSpansPerTrace: 2, MetricsFactory: mf})
+	now := time.Unix(0, 0)
+	s.now = func() time.Time { return now }
+
+	trace1 := model.NewTraceID(0, 1)
+	trace2 := model.NewTraceID(0, 2)
+	assert.NotNil(t, s.Sanitize(&model.Span{TraceID: trace1}))
+	assert.NotNil(t, s.Sanitize(&model.Span{TraceID: trace1}))
+	assert.Nil(t, s.Sanitize(&model.Span{TraceID: trace1}))
+	assert.NotNil(t, s.Sanitize(&model.Span{TraceID: trace2}))
+
+	// the counts are reset in the next window
+	now = now.Add(DefaultTraceWindow)
+	assert.NotNil(t, s.Sanitize(&model.Span{TraceID: trace1}))
+
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "span-limits.spans-dropped|reason=spans-per-trace", Value: 1})
+}
+
+func TestChainedSanitizerDropsSpan(t *testing.T) {
+	called := false
+	s := NewChainedSanitizer(
+		func(span *model.Span) *model.Span { return nil },
+		func(span *model.Span) *model.Span {
+			called = true
+			return span
+		},
+	)
+	assert.Nil(t, s(&model.Span{}))
+	assert.False(t, called)
+}
@@ -22,26 +22,40 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/auth"
+	collectorBaggage "github.com/jaegertracing/jaeger/cmd/collector/app/baggage"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
 	"github.com/jaegertracing/jaeger/pkg/config/tlscfg"
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
+	baggageProto "github.com/jaegertracing/jaeger/proto-gen/api_v2/baggage"
+	"github.com/jaegertracing/jaeger/proto-gen/api_v2/introspection"
+	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
 )
 
+// collectorServiceName is the name of the gRPC service receiving spans
+const collectorServiceName = "jaeger.api_v2.CollectorService"
+
 // GRPCServerParams to construct a new Jaeger Collector gRPC Server
 type GRPCServerParams struct {
 	TLSConfig     tlscfg.Options
 	HostPort      string
 	Handler       *handler.GRPCHandler
 	SamplingStore strategystore.StrategyStore
-	Logger        *zap.Logger
-	OnError       func(error)
+	// BaggageManager serves the baggage restrictions, the baggage service is not registered if nil
+	BaggageManager baggage.BaggageRestrictionManager
+	// SamplingIntrospection explains the adaptive sampling probabilities, the introspection service is not registered if nil
+	SamplingIntrospection introspection.SamplingIntrospectionServer
+	Logger                *zap.Logger
+	OnError               func(error)
+	// AuthMgr authenticates the span submissions, the sampling service remains open
+	AuthMgr *auth.Manager
 }
 
 // StartGRPCServer based on the given parameters
 func StartGRPCServer(params *GRPCServerParams) (*grpc.Server, error) {
-	var server *grpc.Server
+	var grpcOpts []grpc.ServerOption
 
 	if params.TLSConfig.Enabled {
 		// user requested a server with TLS, setup creds
@@ -51,11 +65,12 @@ func StartGRPCServer(params *GRPCServerParams) (*grpc.Server, error) {
 		}
 
 		creds := credentials.NewTLS(tlsCfg)
-		server = grpc.NewServer(grpc.Creds(creds))
-	} else {
-		// server without TLS
-		server = grpc.NewServer()
+		grpcOpts = append(grpcOpts, grpc.Creds(creds))
+	}
+	if params.AuthMgr != nil {
+		grpcOpts = append(grpcOpts, grpc.UnaryInterceptor(auth.NewUnaryServerInterceptor(params.AuthMgr, collectorServiceName)))
 	}
+	server := grpc.NewServer(grpcOpts...)
 
 	listener, err := net.Listen("tcp", params.HostPort)
 	if err != nil {
@@ -72,6 +87,12 @@ func StartGRPCServer(params *GRPCServerParams) (*grpc.Server, error) {
 func serveGRPC(server *grpc.Server, listener net.Listener, params *GRPCServerParams) error {
 	api_v2.RegisterCollectorServiceServer(server, params.Handler)
 	api_v2.RegisterSamplingManagerServer(server, sampling.NewGRPCHandler(params.SamplingStore))
+	if params.BaggageManager != nil {
+		baggageProto.RegisterBaggageRestrictionManagerServer(server, collectorBaggage.NewGRPCHandler(params.BaggageManager))
+	}
+	if params.SamplingIntrospection != nil {
+		introspection.RegisterSamplingIntrospectionServer(server, params.SamplingIntrospection)
+	}
 
 	params.Logger.Info("Starting jaeger-collector gRPC server", zap.String("grpc.host-port", params.HostPort))
 	go func() {
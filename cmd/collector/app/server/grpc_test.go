@@ -29,7 +29,10 @@ import (
 	"google.golang.org/grpc/test/bufconn"
 
 	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
+	baggageProto "github.com/jaegertracing/jaeger/proto-gen/api_v2/baggage"
+	"github.com/jaegertracing/jaeger/proto-gen/api_v2/introspection"
 )
 
 // test wrong port number
@@ -37,7 +40,7 @@ func TestFailToListen(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
 	server, err := StartGRPCServer(&GRPCServerParams{
 		HostPort:      ":-1",
-		Handler:       handler.NewGRPCHandler(logger, &mockSpanProcessor{}),
+		Handler:       handler.NewGRPCHandler(logger, &mockSpanProcessor{}, &tenancy.Manager{}),
 		SamplingStore: &mockSamplingStore{},
 		Logger:        logger,
 	})
@@ -54,7 +57,7 @@ func TestFailServe(t *testing.T) {
 
 	logger := zap.New(core)
 	serveGRPC(grpc.NewServer(), lis, &GRPCServerParams{
-		Handler:       handler.NewGRPCHandler(logger, &mockSpanProcessor{}),
+		Handler:       handler.NewGRPCHandler(logger, &mockSpanProcessor{}, &tenancy.Manager{}),
 		SamplingStore: &mockSamplingStore{},
 		Logger:        logger,
 		OnError: func(e error) {
@@ -69,7 +72,7 @@ func TestFailServe(t *testing.T) {
 func TestSpanCollector(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
 	params := &GRPCServerParams{
-		Handler:       handler.NewGRPCHandler(logger, &mockSpanProcessor{}),
+		Handler:       handler.NewGRPCHandler(logger, &mockSpanProcessor{}, &tenancy.Manager{}),
 		SamplingStore: &mockSamplingStore{},
 		Logger:        logger,
 	}
@@ -92,3 +95,69 @@ func TestSpanCollector(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, response)
 }
+
+func TestBaggageRestrictions(t *testing.T) {
+	logger := zap.NewNop()
+	params := &GRPCServerParams{
+		Handler:        handler.NewGRPCHandler(logger, &mockSpanProcessor{}, &tenancy.Manager{}),
+		SamplingStore:  &mockSamplingStore{},
+		BaggageManager: &mockBaggageManager{},
+		Logger:         logger,
+	}
+
+	server := grpc.NewServer()
+	defer server.Stop()
+
+	listener, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	serveGRPC(server, listener, params)
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	c := baggageProto.NewBaggageRestrictionManagerClient(conn)
+	response, err := c.GetBaggageRestrictions(context.Background(), &baggageProto.BaggageRestrictionsParameters{ServiceName: "foo"})
+	require.NoError(t, err)
+	assert.Equal(t, []*baggageProto.BaggageRestriction{{BaggageKey: "foo-key", MaxValueLength: 10}}, response.BaggageRestrictions)
+}
+
+type mockSamplingIntrospection struct{}
+
+func (mockSamplingIntrospection) Introspect(_ context.Context, r *introspection.IntrospectionRequest) (*introspection.IntrospectionResponse, error) {
+	return &introspection.IntrospectionResponse{
+		Instance: "host1",
+		Services: []*introspection.ServiceIntrospection{{Service: r.Service}},
+	}, nil
+}
+
+func TestSamplingIntrospection(t *testing.T) {
+	logger := zap.NewNop()
+	params := &GRPCServerParams{
+		Handler:               handler.NewGRPCHandler(logger, &mockSpanProcessor{}, &tenancy.Manager{}),
+		SamplingStore:         &mockSamplingStore{},
+		SamplingIntrospection: mockSamplingIntrospection{},
+		Logger:                logger,
+	}
+
+	server := grpc.NewServer()
+	defer server.Stop()
+
+	listener, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	serveGRPC(server, listener, params)
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	c := introspection.NewSamplingIntrospectionClient(conn)
+	response, err := c.Introspect(context.Background(), &introspection.IntrospectionRequest{Service: "foo"})
+	require.NoError(t, err)
+	assert.Equal(t, "host1", response.Instance)
+	assert.Equal(t, "foo", response.Services[0].Service)
+}
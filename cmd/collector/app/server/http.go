@@ -23,24 +23,33 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/auth"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
 	clientcfgHandler "github.com/jaegertracing/jaeger/pkg/clientcfg/clientcfghttp"
 	"github.com/jaegertracing/jaeger/pkg/config/tlscfg"
 	"github.com/jaegertracing/jaeger/pkg/healthcheck"
 	"github.com/jaegertracing/jaeger/pkg/httpmetrics"
 	"github.com/jaegertracing/jaeger/pkg/recoveryhandler"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
+	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
 )
 
 // HTTPServerParams to construct a new Jaeger Collector HTTP Server
 type HTTPServerParams struct {
 	TLSConfig      tlscfg.Options
 	HostPort       string
+	MaxRequestSize int64
 	Handler        handler.JaegerBatchesHandler
+	SpanProcessor  processor.SpanProcessor
 	SamplingStore  strategystore.StrategyStore
+	BaggageManager baggage.BaggageRestrictionManager
 	MetricsFactory metrics.Factory
 	HealthCheck    *healthcheck.HealthCheck
 	Logger         *zap.Logger
+	TenancyMgr     *tenancy.Manager
+	AuthMgr        *auth.Manager
 }
 
 // StartHTTPServer based on the given parameters
@@ -72,13 +81,16 @@ func StartHTTPServer(params *HTTPServerParams) (*http.Server, error) {
 
 func serveHTTP(server *http.Server, listener net.Listener, params *HTTPServerParams) {
 	r := mux.NewRouter()
-	apiHandler := handler.NewAPIHandler(params.Handler)
-	apiHandler.RegisterRoutes(r)
+	apiHandler := handler.NewAPIHandler(params.Handler, params.SpanProcessor, params.MaxRequestSize)
+	// only the span submission endpoint requires credentials and a tenant, sampling requests come from the SDKs
+	traceRouter := mux.NewRouter()
+	apiHandler.RegisterRoutes(traceRouter)
+	r.Handle("/api/traces", auth.NewHTTPHandler(params.AuthMgr, tenancy.ExtractTenantHTTPHandler(params.TenancyMgr, traceRouter)))
 
 	cfgHandler := clientcfgHandler.NewHTTPHandler(clientcfgHandler.HTTPHandlerParams{
 		ConfigManager: &clientcfgHandler.ConfigManager{
 			SamplingStrategyStore: params.SamplingStore,
-			// TODO provide baggage manager
+			BaggageManager:        params.BaggageManager,
 		},
 		MetricsFactory:         params.MetricsFactory,
 		BasePath:               "/api",
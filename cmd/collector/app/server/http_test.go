@@ -17,9 +17,11 @@ package server
 import (
 	"crypto/tls"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -28,9 +30,11 @@ import (
 	"github.com/uber/jaeger-lib/metrics/metricstest"
 	"go.uber.org/zap"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/auth"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
 	"github.com/jaegertracing/jaeger/pkg/config/tlscfg"
 	"github.com/jaegertracing/jaeger/pkg/healthcheck"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/ports"
 )
 
@@ -86,6 +90,85 @@ func TestSpanCollectorHTTP(t *testing.T) {
 	assert.NotNil(t, response)
 }
 
+func TestSpanCollectorHTTPWithTenancy(t *testing.T) {
+	logger := zap.NewNop()
+	params := &HTTPServerParams{
+		Handler:        handler.NewJaegerSpanHandler(logger, &mockSpanProcessor{}),
+		SamplingStore:  &mockSamplingStore{},
+		MetricsFactory: metricstest.NewFactory(time.Hour),
+		HealthCheck:    healthcheck.New(),
+		Logger:         logger,
+		TenancyMgr:     tenancy.NewManager(&tenancy.Options{Enabled: true}),
+	}
+
+	server := httptest.NewServer(nil)
+	defer server.Close()
+
+	serveHTTP(server.Config, server.Listener, params)
+
+	response, err := http.Post(server.URL+"/api/traces", "application/x-thrift", nil)
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	request, err := http.NewRequest(http.MethodPost, server.URL+"/api/traces", nil)
+	require.NoError(t, err)
+	request.Header.Set("Content-Type", "application/x-thrift")
+	request.Header.Set(tenancy.DefaultHeader, "acme")
+	response, err = http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.NotEqual(t, http.StatusUnauthorized, response.StatusCode)
+
+	// sampling endpoints do not require a tenant
+	response, err = http.Get(server.URL + "/api/sampling?service=foo")
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.NotEqual(t, http.StatusUnauthorized, response.StatusCode)
+}
+
+func TestSpanCollectorHTTPWithAuth(t *testing.T) {
+	keysFile := filepath.Join(t.TempDir(), "keys.json")
+	require.NoError(t, ioutil.WriteFile(keysFile, []byte(`{"keys": [{"key": "secret", "subject": "team"}]}`), 0600))
+	authMgr, err := auth.NewManager(auth.Options{APIKeysFile: keysFile})
+	require.NoError(t, err)
+
+	logger := zap.NewNop()
+	params := &HTTPServerParams{
+		Handler:        handler.NewJaegerSpanHandler(logger, &mockSpanProcessor{}),
+		SamplingStore:  &mockSamplingStore{},
+		MetricsFactory: metricstest.NewFactory(time.Hour),
+		HealthCheck:    healthcheck.New(),
+		Logger:         logger,
+		AuthMgr:        authMgr,
+	}
+
+	server := httptest.NewServer(nil)
+	defer server.Close()
+
+	serveHTTP(server.Config, server.Listener, params)
+
+	response, err := http.Post(server.URL+"/api/traces", "application/x-thrift", nil)
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	request, err := http.NewRequest(http.MethodPost, server.URL+"/api/traces", nil)
+	require.NoError(t, err)
+	request.Header.Set("Content-Type", "application/x-thrift")
+	request.Header.Set("Authorization", "Bearer secret")
+	response, err = http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.NotEqual(t, http.StatusUnauthorized, response.StatusCode)
+
+	// sampling endpoints do not require credentials
+	response, err = http.Get(server.URL + "/api/sampling?service=foo")
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.NotEqual(t, http.StatusUnauthorized, response.StatusCode)
+}
+
 func TestSpanCollectorHTTPS(t *testing.T) {
 
 	testCases := []struct {
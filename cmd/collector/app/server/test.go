@@ -19,6 +19,7 @@ import (
 
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
 	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
 	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
 )
 
@@ -28,6 +29,12 @@ func (s mockSamplingStore) GetSamplingStrategy(_ context.Context, serviceName st
 	return nil, nil
 }
 
+type mockBaggageManager struct{}
+
+func (m mockBaggageManager) GetBaggageRestrictions(_ context.Context, serviceName string) ([]*baggage.BaggageRestriction, error) {
+	return []*baggage.BaggageRestriction{{BaggageKey: serviceName + "-key", MaxValueLength: 10}}, nil
+}
+
 type mockSpanProcessor struct {
 }
 
@@ -25,11 +25,13 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/auth"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/zipkin"
 	"github.com/jaegertracing/jaeger/pkg/healthcheck"
 	"github.com/jaegertracing/jaeger/pkg/httpmetrics"
 	"github.com/jaegertracing/jaeger/pkg/recoveryhandler"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 )
 
 // ZipkinServerParams to construct a new Jaeger Collector Zipkin Server
@@ -41,6 +43,8 @@ type ZipkinServerParams struct {
 	HealthCheck    *healthcheck.HealthCheck
 	Logger         *zap.Logger
 	MetricsFactory metrics.Factory
+	TenancyMgr     *tenancy.Manager
+	AuthMgr        *auth.Manager
 }
 
 // StartZipkinServer based on the given parameters
@@ -82,7 +86,9 @@ func serveZipkin(server *http.Server, listener net.Listener, params *ZipkinServe
 	})
 
 	recoveryHandler := recoveryhandler.NewRecoveryHandler(params.Logger, true)
-	server.Handler = cors.Handler(httpmetrics.Wrap(recoveryHandler(r), params.MetricsFactory))
+	var handler http.Handler = tenancy.ExtractTenantHTTPHandler(params.TenancyMgr, r)
+	handler = auth.NewHTTPHandler(params.AuthMgr, handler)
+	server.Handler = cors.Handler(httpmetrics.Wrap(recoveryHandler(handler), params.MetricsFactory))
 	go func(listener net.Listener, server *http.Server) {
 		if err := server.Serve(listener); err != nil {
 			if err != http.ErrServerClosed {
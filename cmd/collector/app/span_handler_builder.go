@@ -21,10 +21,16 @@ import (
 	"github.com/uber/jaeger-lib/metrics"
 	"go.uber.org/zap"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/enrichment"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/loadshedding"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer"
 	zs "github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer/zipkin"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/throughput"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/validation"
 	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 )
 
@@ -34,6 +40,18 @@ type SpanHandlerBuilder struct {
 	CollectorOpts  CollectorOptions
 	Logger         *zap.Logger
 	MetricsFactory metrics.Factory
+	// PreSave is an optional function called for every span before it is saved
+	PreSave ProcessSpan
+	// TenancyMgr validates the tenant of the spans received over gRPC, it may be nil
+	TenancyMgr *tenancy.Manager
+	// Validator repairs, rejects or quarantines malformed spans, it may be nil
+	Validator *validation.Validator
+	// Enricher adds metadata process tags to the spans, it may be nil
+	Enricher *enrichment.Enricher
+	// SpanFilter decides which spans are processed, all spans are accepted if nil
+	SpanFilter FilterSpan
+	// ThroughputTracker counts the received and dropped spans per service and operation, it may be nil
+	ThroughputTracker *throughput.Tracker
 }
 
 // SpanHandlers holds instances to the span handlers built by the SpanHandlerBuilder
@@ -44,24 +62,100 @@ type SpanHandlers struct {
 }
 
 // BuildSpanProcessor builds the span processor to be used with the handlers
-func (b *SpanHandlerBuilder) BuildSpanProcessor() processor.SpanProcessor {
+func (b *SpanHandlerBuilder) BuildSpanProcessor() (processor.SpanProcessor, error) {
 	hostname, _ := os.Hostname()
 	svcMetrics := b.metricsFactory()
 	hostMetrics := svcMetrics.Namespace(metrics.NSOptions{Tags: map[string]string{"host": hostname}})
 
+	sanitizers, err := b.buildSanitizers(svcMetrics)
+	if err != nil {
+		return nil, err
+	}
+
+	var shedder *loadshedding.Shedder
+	if b.CollectorOpts.LoadShedding.Enabled {
+		loadSheddingOpts := b.CollectorOpts.LoadShedding
+		loadSheddingOpts.MetricsFactory = svcMetrics
+		if shedder, err = loadshedding.NewShedder(loadSheddingOpts); err != nil {
+			return nil, err
+		}
+	}
+
+	spanFilter := b.SpanFilter
+	if spanFilter == nil {
+		spanFilter = defaultSpanFilter
+	}
+
 	return NewSpanProcessor(
 		b.SpanWriter,
 		Options.ServiceMetrics(svcMetrics),
 		Options.HostMetrics(hostMetrics),
 		Options.Logger(b.logger()),
-		Options.SpanFilter(defaultSpanFilter),
+		Options.SpanFilter(spanFilter),
+		Options.PreProcessSpans(b.buildPreProcessSpans()),
+		Options.Sanitizer(sanitizer.NewChainedSanitizer(sanitizers...)),
+		Options.PreSave(b.PreSave),
 		Options.NumWorkers(b.CollectorOpts.NumWorkers),
 		Options.QueueSize(b.CollectorOpts.QueueSize),
 		Options.CollectorTags(b.CollectorOpts.CollectorTags),
 		Options.DynQueueSizeWarmup(uint(b.CollectorOpts.QueueSize)), // same as queue size for now
 		Options.DynQueueSizeMemory(b.CollectorOpts.DynQueueSizeMemory),
-	)
+		Options.LoadShedder(shedder),
+		Options.ThroughputTracker(b.ThroughputTracker),
+	), nil
+}
 
+// buildSanitizers builds the list of sanitizers enabled by the collector options
+func (b *SpanHandlerBuilder) buildSanitizers(metricsFactory metrics.Factory) ([]sanitizer.SanitizeSpan, error) {
+	var sanitizers []sanitizer.SanitizeSpan
+	if b.Validator != nil {
+		sanitizers = append(sanitizers, b.Validator.Sanitize)
+	}
+	if b.CollectorOpts.DedupEnabled {
+		dedup := b.CollectorOpts.Dedup
+		dedup.MetricsFactory = metricsFactory
+		sanitizers = append(sanitizers, sanitizer.NewSpanDeduper(dedup))
+	}
+	if b.CollectorOpts.OperationNameRulesFile != "" || b.CollectorOpts.MaxOperationsPerService > 0 {
+		var rules []sanitizer.OperationNameRule
+		if b.CollectorOpts.OperationNameRulesFile != "" {
+			var err error
+			if rules, err = sanitizer.LoadOperationNameRules(b.CollectorOpts.OperationNameRulesFile); err != nil {
+				return nil, err
+			}
+		}
+		operationNameSanitizer, err := sanitizer.NewOperationNameSanitizer(sanitizer.OperationNameSanitizerOptions{
+			Rules:                   rules,
+			MaxOperationsPerService: b.CollectorOpts.MaxOperationsPerService,
+			MaxServices:             b.CollectorOpts.MaxOperationServices,
+			MetricsFactory:          metricsFactory,
+		})
+		if err != nil {
+			return nil, err
+		}
+		sanitizers = append(sanitizers, operationNameSanitizer)
+	}
+	if b.CollectorOpts.SpanLimits.Enabled() {
+		spanLimits := b.CollectorOpts.SpanLimits
+		spanLimits.MetricsFactory = metricsFactory
+		sanitizers = append(sanitizers, sanitizer.NewSpanLimitsSanitizer(spanLimits))
+	}
+	return sanitizers, nil
+}
+
+// buildPreProcessSpans builds the function called on each batch before its spans are queued
+func (b *SpanHandlerBuilder) buildPreProcessSpans() ProcessSpans {
+	if b.Enricher == nil {
+		return nil
+	}
+	enricher := b.Enricher
+	// the spans of a batch share their process, so they are enriched before being queued
+	// rather than by the queue workers which process the spans of a batch concurrently
+	return func(spans []*model.Span) {
+		for _, span := range spans {
+			enricher.Enrich(span)
+		}
+	}
 }
 
 // BuildHandlers builds span handlers (Zipkin, Jaeger)
@@ -69,7 +163,7 @@ func (b *SpanHandlerBuilder) BuildHandlers(spanProcessor processor.SpanProcessor
 	return &SpanHandlers{
 		handler.NewZipkinSpanHandler(b.Logger, spanProcessor, zs.NewChainedSanitizer(zs.StandardSanitizers...)),
 		handler.NewJaegerSpanHandler(b.Logger, spanProcessor),
-		handler.NewGRPCHandler(b.Logger, spanProcessor),
+		handler.NewGRPCHandler(b.Logger, spanProcessor, b.TenancyMgr),
 	}
 }
 
@@ -16,14 +16,26 @@
 package app
 
 import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/uber/jaeger-lib/metrics"
 	"go.uber.org/zap"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/enrichment"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/loadshedding"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/validation"
 	"github.com/jaegertracing/jaeger/cmd/flags"
+	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/pkg/config"
 	"github.com/jaegertracing/jaeger/plugin/storage/memory"
 )
@@ -32,7 +44,8 @@ func TestNewSpanHandlerBuilder(t *testing.T) {
 	v, command := config.Viperize(flags.AddFlags, AddFlags)
 
 	require.NoError(t, command.ParseFlags([]string{}))
-	cOpts := new(CollectorOptions).InitFromViper(v)
+	cOpts, err := new(CollectorOptions).InitFromViper(v)
+	require.NoError(t, err)
 
 	spanWriter := memory.NewStore()
 
@@ -50,7 +63,8 @@ func TestNewSpanHandlerBuilder(t *testing.T) {
 		MetricsFactory: metrics.NullFactory,
 	}
 
-	spanProcessor := builder.BuildSpanProcessor()
+	spanProcessor, err := builder.BuildSpanProcessor()
+	require.NoError(t, err)
 	spanHandlers := builder.BuildHandlers(spanProcessor)
 	assert.NotNil(t, spanHandlers.ZipkinSpansHandler)
 	assert.NotNil(t, spanHandlers.JaegerBatchesHandler)
@@ -58,6 +72,122 @@ func TestNewSpanHandlerBuilder(t *testing.T) {
 	assert.NotNil(t, spanProcessor)
 }
 
+func TestSpanHandlerBuilderSanitizers(t *testing.T) {
+	builder := &SpanHandlerBuilder{
+		SpanWriter: memory.NewStore(),
+		CollectorOpts: CollectorOptions{
+			MaxOperationsPerService: 10,
+			SpanLimits:              sanitizer.SpanLimitsOptions{MaxTags: 10},
+			DedupEnabled:            true,
+		},
+	}
+	sanitizers, err := builder.buildSanitizers(metrics.NullFactory)
+	require.NoError(t, err)
+	assert.Len(t, sanitizers, 3)
+
+	builder.CollectorOpts.OperationNameRulesFile = "invalid-file-name"
+	_, err = builder.BuildSpanProcessor()
+	assert.Error(t, err)
+}
+
+func TestSpanHandlerBuilderEnricher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapping.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte("rules: [{service: foo, tags: {team: a}}]"), 0600))
+	enricher, err := enrichment.NewEnricher(enrichment.Options{MappingFile: path}, zap.NewNop())
+	require.NoError(t, err)
+	defer enricher.Close()
+
+	assert.Nil(t, (&SpanHandlerBuilder{}).buildPreProcessSpans())
+
+	spanWriter := newConcurrentSpanWriter(8)
+	builder := &SpanHandlerBuilder{
+		SpanWriter:    spanWriter,
+		CollectorOpts: CollectorOptions{NumWorkers: 8, QueueSize: 100},
+		Enricher:      enricher,
+	}
+	sanitizers, err := builder.buildSanitizers(metrics.NullFactory)
+	require.NoError(t, err)
+	assert.Empty(t, sanitizers)
+
+	sp, err := builder.BuildSpanProcessor()
+	require.NoError(t, err)
+
+	// the spans of a batch share their process, which must not be enriched by the concurrent queue workers
+	process := &model.Process{ServiceName: "foo"}
+	spans := make([]*model.Span, 20)
+	for i := range spans {
+		spans[i] = &model.Span{TraceID: model.NewTraceID(0, 1), SpanID: model.NewSpanID(uint64(i + 1)), Process: process}
+	}
+	_, err = sp.ProcessSpans(spans, processor.SpansOptions{SpanFormat: processor.JaegerSpanFormat})
+	require.NoError(t, err)
+	var trace *model.Trace
+	for i := 0; i < 100 && (trace == nil || len(trace.Spans) < len(spans)); i++ {
+		time.Sleep(time.Millisecond)
+		trace, _ = spanWriter.GetTrace(context.Background(), model.NewTraceID(0, 1))
+	}
+	require.NoError(t, sp.Close())
+
+	assert.Equal(t, []model.KeyValue{model.String("team", "a")}, process.Tags)
+	require.NotNil(t, trace)
+	assert.Len(t, trace.Spans, len(spans))
+}
+
+// concurrentSpanWriter holds the first writes until as many are in progress,
+// so that the spans are processed concurrently by the queue workers
+type concurrentSpanWriter struct {
+	*memory.Store
+	concurrency int32
+	writes      int32
+	inProgress  sync.WaitGroup
+}
+
+func newConcurrentSpanWriter(concurrency int) *concurrentSpanWriter {
+	w := &concurrentSpanWriter{Store: memory.NewStore(), concurrency: int32(concurrency)}
+	w.inProgress.Add(concurrency)
+	return w
+}
+
+func (w *concurrentSpanWriter) WriteSpan(ctx context.Context, span *model.Span) error {
+	if atomic.AddInt32(&w.writes, 1) <= w.concurrency {
+		w.inProgress.Done()
+		w.inProgress.Wait()
+	}
+	return w.Store.WriteSpan(ctx, span)
+}
+
+func TestSpanHandlerBuilderValidator(t *testing.T) {
+	validator, err := validation.NewValidator(validation.Options{}, zap.NewNop())
+	require.NoError(t, err)
+	defer validator.Close()
+
+	builder := &SpanHandlerBuilder{
+		CollectorOpts: CollectorOptions{DedupEnabled: true},
+		Validator:     validator,
+	}
+	sanitizers, err := builder.buildSanitizers(metrics.NullFactory)
+	require.NoError(t, err)
+	require.Len(t, sanitizers, 2)
+	span := sanitizers[0](&model.Span{TraceID: model.NewTraceID(0, 1), SpanID: 1, Duration: -1})
+	assert.Equal(t, time.Duration(0), span.Duration)
+}
+
+func TestSpanHandlerBuilderLoadShedding(t *testing.T) {
+	builder := &SpanHandlerBuilder{
+		SpanWriter: memory.NewStore(),
+		CollectorOpts: CollectorOptions{
+			LoadShedding: loadshedding.Options{Enabled: true, ReservedNormal: 0.5},
+		},
+	}
+	sp, err := builder.BuildSpanProcessor()
+	require.NoError(t, err)
+	assert.NotNil(t, sp.(*spanProcessor).shedder)
+	assert.NoError(t, sp.Close())
+
+	builder.CollectorOpts.LoadShedding.ReservedNormal = 1
+	_, err = builder.BuildSpanProcessor()
+	assert.EqualError(t, err, "the reserved queue fractions must add up to less than 1, got 1")
+}
+
 func TestDefaultSpanFilter(t *testing.T) {
 	assert.True(t, defaultSpanFilter(nil))
 }
@@ -23,10 +23,13 @@ import (
 	"go.uber.org/atomic"
 	"go.uber.org/zap"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/loadshedding"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/throughput"
 	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/pkg/queue"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 )
 
@@ -44,13 +47,15 @@ type spanProcessor struct {
 	metrics            *SpanProcessorMetrics
 	preProcessSpans    ProcessSpans
 	filterSpan         FilterSpan             // filter is called before the sanitizer but after preProcessSpans
-	sanitizer          sanitizer.SanitizeSpan // sanitizer is called before processSpan
+	sanitizer          sanitizer.SanitizeSpan // sanitizer is called before processSpan and may drop the span
 	processSpan        ProcessSpan
 	logger             *zap.Logger
 	spanWriter         spanstore.Writer
 	reportBusy         bool
 	numWorkers         int
 	collectorTags      map[string]string
+	shedder            *loadshedding.Shedder // shedder is nil unless load shedding is enabled
+	throughputTracker  *throughput.Tracker   // throughputTracker is nil unless throughput tracking is enabled
 	dynQueueSizeWarmup uint
 	dynQueueSizeMemory uint
 	bytesProcessed     *atomic.Uint64
@@ -61,6 +66,7 @@ type spanProcessor struct {
 type queueItem struct {
 	queuedTime time.Time
 	span       *model.Span
+	tenant     string
 }
 
 // NewSpanProcessor returns a SpanProcessor that preProcesses, filters, queues, sanitizes, and processes spans
@@ -92,6 +98,9 @@ func newSpanProcessor(spanWriter spanstore.Writer, opts ...Option) *spanProcesso
 		options.extraFormatTypes)
 	droppedItemHandler := func(item interface{}) {
 		handlerMetrics.SpansDropped.Inc(1)
+		if options.throughputTracker != nil {
+			options.throughputTracker.RecordDropped(item.(*queueItem).span)
+		}
 	}
 	boundedQueue := queue.NewBoundedQueue(options.queueSize, droppedItemHandler)
 
@@ -106,6 +115,8 @@ func newSpanProcessor(spanWriter spanstore.Writer, opts ...Option) *spanProcesso
 		numWorkers:         options.numWorkers,
 		spanWriter:         spanWriter,
 		collectorTags:      options.collectorTags,
+		shedder:            options.shedder,
+		throughputTracker:  options.throughputTracker,
 		stopCh:             make(chan struct{}),
 		dynQueueSizeMemory: options.dynQueueSizeMemory,
 		dynQueueSizeWarmup: options.dynQueueSizeWarmup,
@@ -133,7 +144,7 @@ func (sp *spanProcessor) Close() error {
 	return nil
 }
 
-func (sp *spanProcessor) saveSpan(span *model.Span) {
+func (sp *spanProcessor) saveSpan(span *model.Span, tenant string) {
 	if nil == span.Process {
 		sp.logger.Error("process is empty for the span")
 		sp.metrics.SavedErrBySvc.ReportServiceNameForSpan(span)
@@ -142,7 +153,8 @@ func (sp *spanProcessor) saveSpan(span *model.Span) {
 
 	startTime := time.Now()
 	// TODO context should be propagated from upstream components
-	if err := sp.spanWriter.WriteSpan(context.TODO(), span); err != nil {
+	ctx := tenancy.WithTenant(context.TODO(), tenant)
+	if err := sp.spanWriter.WriteSpan(ctx, span); err != nil {
 		sp.logger.Error("Failed to save span", zap.Error(err))
 		sp.metrics.SavedErrBySvc.ReportServiceNameForSpan(span)
 	} else {
@@ -153,17 +165,23 @@ func (sp *spanProcessor) saveSpan(span *model.Span) {
 	sp.metrics.SaveLatency.Record(time.Since(startTime))
 }
 
-func (sp *spanProcessor) countSpan(span *model.Span) {
+func (sp *spanProcessor) countSpan(span *model.Span, tenant string) {
 	sp.bytesProcessed.Add(uint64(span.Size()))
 	sp.spansProcessed.Inc()
 }
 
 func (sp *spanProcessor) ProcessSpans(mSpans []*model.Span, options processor.SpansOptions) ([]bool, error) {
 	sp.preProcessSpans(mSpans)
+	if sp.throughputTracker != nil {
+		sp.throughputTracker.RecordSpans(mSpans)
+	}
 	sp.metrics.BatchSize.Update(int64(len(mSpans)))
+	if sp.shedder != nil && !sp.shedder.AdmitBatch(mSpans, sp.queue.Size(), sp.queue.Capacity()) {
+		return nil, sp.shedBatch(mSpans, options)
+	}
 	retMe := make([]bool, len(mSpans))
 	for i, mSpan := range mSpans {
-		ok := sp.enqueueSpan(mSpan, options.SpanFormat, options.InboundTransport)
+		ok := sp.enqueueSpan(mSpan, options)
 		if !ok && sp.reportBusy {
 			return nil, processor.ErrBusy
 		}
@@ -172,8 +190,22 @@ func (sp *spanProcessor) ProcessSpans(mSpans []*model.Span, options processor.Sp
 	return retMe, nil
 }
 
+// shedBatch accounts for a batch rejected by the load shedding, none of its spans is enqueued.
+func (sp *spanProcessor) shedBatch(mSpans []*model.Span, options processor.SpansOptions) error {
+	spanCounts := sp.metrics.GetCountsForFormat(options.SpanFormat, options.InboundTransport)
+	for _, mSpan := range mSpans {
+		spanCounts.ReceivedBySvc.ReportServiceNameForSpan(mSpan)
+		if sp.throughputTracker != nil {
+			sp.throughputTracker.RecordDropped(mSpan)
+		}
+	}
+	return &processor.OverloadedError{Shed: len(mSpans), RetryAfter: sp.shedder.RetryAfter()}
+}
+
 func (sp *spanProcessor) processItemFromQueue(item *queueItem) {
-	sp.processSpan(sp.sanitizer(item.span))
+	if span := sp.sanitizer(item.span); span != nil {
+		sp.processSpan(span, item.tenant)
+	}
 	sp.metrics.InQueueLatency.Record(time.Since(item.queuedTime))
 }
 
@@ -198,10 +230,18 @@ func (sp *spanProcessor) addCollectorTags(span *model.Span) {
 	typedTags.Sort()
 }
 
-func (sp *spanProcessor) enqueueSpan(span *model.Span, originalFormat processor.SpanFormat, transport processor.InboundTransport) bool {
-	spanCounts := sp.metrics.GetCountsForFormat(originalFormat, transport)
+func (sp *spanProcessor) enqueueSpan(span *model.Span, options processor.SpansOptions) bool {
+	originalFormat := options.SpanFormat
+	spanCounts := sp.metrics.GetCountsForFormat(originalFormat, options.InboundTransport)
 	spanCounts.ReceivedBySvc.ReportServiceNameForSpan(span)
 
+	if span.Process != nil && !options.Identity.AllowsService(span.Process.ServiceName) {
+		sp.logger.Debug("Span rejected, the client is not allowed to report the service",
+			zap.String("client", options.Identity.Subject), zap.String("service", span.Process.ServiceName))
+		spanCounts.RejectedBySvc.ReportServiceNameForSpan(span)
+		return true
+	}
+
 	if !sp.filterSpan(span) {
 		spanCounts.RejectedBySvc.ReportServiceNameForSpan(span)
 		return true // as in "not dropped", because it's actively rejected
@@ -216,6 +256,7 @@ func (sp *spanProcessor) enqueueSpan(span *model.Span, originalFormat processor.
 	item := &queueItem{
 		queuedTime: time.Now(),
 		span:       span,
+		tenant:     options.Tenant,
 	}
 	return sp.queue.Produce(item)
 }
@@ -283,3 +324,13 @@ func (sp *spanProcessor) updateGauges() {
 	sp.metrics.QueueLength.Update(int64(sp.queue.Size()))
 	sp.metrics.QueueCapacity.Update(int64(sp.queue.Capacity()))
 }
+
+// QueueLength returns the number of spans in the queue.
+func (sp *spanProcessor) QueueLength() int {
+	return sp.queue.Size()
+}
+
+// QueueCapacity returns the capacity of the queue.
+func (sp *spanProcessor) QueueCapacity() int {
+	return sp.queue.Capacity()
+}
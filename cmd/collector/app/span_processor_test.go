@@ -24,15 +24,20 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/uber/jaeger-lib/metrics"
 	"github.com/uber/jaeger-lib/metrics/metricstest"
 	"go.uber.org/atomic"
 	"go.uber.org/zap"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/auth"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/loadshedding"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
 	zipkinSanitizer "github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer/zipkin"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/throughput"
 	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/pkg/testutils"
 	"github.com/jaegertracing/jaeger/thrift-gen/jaeger"
 	zc "github.com/jaegertracing/jaeger/thrift-gen/zipkincore"
@@ -316,6 +321,57 @@ func TestSpanProcessorBusy(t *testing.T) {
 	assert.Nil(t, res)
 }
 
+func TestSpanProcessorLoadShedding(t *testing.T) {
+	shedder, err := loadshedding.NewShedder(loadshedding.Options{ReservedNormal: 0.5, RetryAfter: 3 * time.Second})
+	require.NoError(t, err)
+	// consumers are not started, so that the spans stay in the queue
+	p := newSpanProcessor(&fakeSpanWriter{}, Options.QueueSize(4), Options.LoadShedder(shedder))
+	defer func() { assert.NoError(t, p.Close()) }()
+
+	firehoseSpan := func() *model.Span {
+		return &model.Span{Flags: model.FirehoseFlag, Process: &model.Process{ServiceName: "x"}}
+	}
+	res, err := p.ProcessSpans([]*model.Span{firehoseSpan(), firehoseSpan()}, processor.SpansOptions{SpanFormat: processor.JaegerSpanFormat})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, true}, res)
+
+	// low priority spans can only use half of the queue, the batch is shed as a whole
+	res, err = p.ProcessSpans([]*model.Span{firehoseSpan(), firehoseSpan()}, processor.SpansOptions{SpanFormat: processor.JaegerSpanFormat})
+	assert.Nil(t, res)
+	require.IsType(t, &processor.OverloadedError{}, err)
+	assert.Equal(t, &processor.OverloadedError{Shed: 2, RetryAfter: 3 * time.Second}, err)
+	assert.EqualError(t, err, "server overloaded, 2 spans were shed, retry after 3s")
+	assert.Equal(t, 2, p.queue.Size())
+
+	// a debug span makes the whole batch critical
+	res, err = p.ProcessSpans([]*model.Span{
+		firehoseSpan(),
+		{Flags: model.DebugFlag, Process: &model.Process{ServiceName: "x"}},
+	}, processor.SpansOptions{SpanFormat: processor.JaegerSpanFormat})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, true}, res)
+	assert.Equal(t, 4, p.queue.Size())
+}
+
+func TestSpanProcessorThroughputTracker(t *testing.T) {
+	tracker := throughput.NewTracker(throughput.Options{Enabled: true})
+	// consumers are not started, so that the spans stay in the queue
+	p := newSpanProcessor(&fakeSpanWriter{}, Options.QueueSize(1), Options.ThroughputTracker(tracker))
+	defer func() { assert.NoError(t, p.Close()) }()
+
+	_, err := p.ProcessSpans([]*model.Span{
+		{OperationName: "op", Process: &model.Process{ServiceName: "x"}},
+		{OperationName: "op", Process: &model.Process{ServiceName: "x"}},
+	}, processor.SpansOptions{SpanFormat: processor.JaegerSpanFormat})
+	require.NoError(t, err)
+
+	stats := tracker.Stats(10, throughput.SortBySpans)["10s"]
+	assert.Equal(t, int64(2), stats.Total.Spans)
+	assert.Equal(t, int64(1), stats.Total.Dropped)
+	assert.Equal(t, 1, p.QueueLength())
+	assert.Equal(t, 1, p.QueueCapacity())
+}
+
 func TestSpanProcessorWithNilProcess(t *testing.T) {
 	mb := metricstest.NewFactory(time.Hour)
 	serviceMetrics := mb.Namespace(metrics.NSOptions{Name: "service", Tags: nil})
@@ -324,7 +380,7 @@ func TestSpanProcessorWithNilProcess(t *testing.T) {
 	p := NewSpanProcessor(w, Options.ServiceMetrics(serviceMetrics)).(*spanProcessor)
 	defer assert.NoError(t, p.Close())
 
-	p.saveSpan(&model.Span{})
+	p.saveSpan(&model.Span{}, "")
 
 	expected := []metricstest.ExpectedMetric{{
 		Name: "service.spans.saved-by-svc|debug=false|result=err|svc=__unknown", Value: 1,
@@ -380,6 +436,87 @@ func TestSpanProcessorWithCollectorTags(t *testing.T) {
 	assert.Equal(t, expected.Process, span.Process)
 }
 
+func TestSpanProcessorSanitizerDropsSpan(t *testing.T) {
+	mb := metricstest.NewFactory(time.Hour)
+	serviceMetrics := mb.Namespace(metrics.NSOptions{Name: "service", Tags: nil})
+
+	w := &fakeSpanWriter{}
+	p := NewSpanProcessor(w,
+		Options.ServiceMetrics(serviceMetrics),
+		Options.Sanitizer(func(span *model.Span) *model.Span { return nil }),
+	).(*spanProcessor)
+	defer assert.NoError(t, p.Close())
+
+	p.processItemFromQueue(&queueItem{
+		queuedTime: time.Now(),
+		span:       &model.Span{Process: &model.Process{ServiceName: "x"}},
+	})
+
+	counters, _ := mb.Snapshot()
+	assert.NotContains(t, counters, "service.spans.saved-by-svc|debug=false|result=ok|svc=x")
+}
+
+type tenantRecordingWriter struct {
+	lock    sync.Mutex
+	tenants []string
+}
+
+func (w *tenantRecordingWriter) WriteSpan(ctx context.Context, span *model.Span) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.tenants = append(w.tenants, tenancy.GetTenant(ctx))
+	return nil
+}
+
+func (w *tenantRecordingWriter) getTenants() []string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.tenants
+}
+
+func TestSpanProcessorWithTenant(t *testing.T) {
+	w := &tenantRecordingWriter{}
+	p := NewSpanProcessor(w, Options.NumWorkers(1), Options.QueueSize(1)).(*spanProcessor)
+
+	_, err := p.ProcessSpans([]*model.Span{{Process: &model.Process{ServiceName: "x"}}}, processor.SpansOptions{
+		SpanFormat: processor.ProtoSpanFormat,
+		Tenant:     "acme",
+	})
+	require.NoError(t, err)
+	defer p.Close()
+
+	for i := 0; i < 100 && len(w.getTenants()) == 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, []string{"acme"}, w.getTenants())
+}
+
+func TestSpanProcessorRejectsUnauthorizedService(t *testing.T) {
+	mb := metricstest.NewFactory(time.Hour)
+	serviceMetrics := mb.Namespace(metrics.NSOptions{Name: "service", Tags: nil})
+	w := &tenantRecordingWriter{}
+	p := NewSpanProcessor(w, Options.ServiceMetrics(serviceMetrics), Options.NumWorkers(1), Options.QueueSize(2)).(*spanProcessor)
+	defer p.Close()
+
+	res, err := p.ProcessSpans([]*model.Span{
+		{Process: &model.Process{ServiceName: "frontend"}},
+		{Process: &model.Process{ServiceName: "db"}},
+	}, processor.SpansOptions{
+		SpanFormat: processor.ProtoSpanFormat,
+		Identity:   &auth.Identity{Subject: "team", Services: []string{"frontend"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, true}, res)
+
+	for i := 0; i < 100 && len(w.getTenants()) == 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Len(t, w.getTenants(), 1)
+	mb.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name: "service.spans.rejected|debug=false|format=proto|svc=db|transport=unknown", Value: 1,
+	})
+}
+
 func TestSpanProcessorCountSpan(t *testing.T) {
 	mb := metricstest.NewFactory(time.Hour)
 	m := mb.Namespace(metrics.NSOptions{})
@@ -388,7 +525,7 @@ func TestSpanProcessorCountSpan(t *testing.T) {
 	p := NewSpanProcessor(w, Options.HostMetrics(m), Options.DynQueueSizeMemory(1000)).(*spanProcessor)
 	p.background(10*time.Millisecond, p.updateGauges)
 
-	p.processSpan(&model.Span{})
+	p.processSpan(&model.Span{}, "")
 	assert.NotEqual(t, uint64(0), p.bytesProcessed)
 
 	for i := 0; i < 15; i++ {
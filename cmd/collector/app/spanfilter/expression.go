@@ -0,0 +1,475 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanfilter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+// Expression is a compiled boolean expression over the fields of a span, e.g.
+//
+//	service == "frontend" && (operation =~ "^GET /health" || duration < 1ms)
+//
+// The operands are:
+//   - service, operation: strings
+//   - duration: a duration, compared with duration literals such as 500us, 10ms or 1.5s
+//   - flags.debug, flags.sampled, flags.firehose: booleans
+//   - tags["key"], process.tags["key"]: the value of a span or process tag, missing if there is no such tag
+//   - string literals in double quotes, numbers, durations, true and false
+//
+// The operators are, by increasing precedence, ||, &&, the unary !, and the comparisons
+// ==, !=, <, <=, >, >=, =~ and !~, the latter two matching a regular expression given as a string literal.
+// Comparisons involving a missing tag or values of incompatible types are false.
+type Expression struct {
+	source string
+	root   node
+}
+
+// Compile parses the expression.
+func Compile(source string) (*Expression, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %s at position %d", tok, tok.pos)
+	}
+	return &Expression{source: source, root: root}, nil
+}
+
+// Matches evaluates the expression for the span.
+func (e *Expression) Matches(span *model.Span) bool {
+	return e.root.eval(span).isTrue()
+}
+
+// String returns the source of the expression.
+func (e *Expression) String() string {
+	return e.source
+}
+
+// --- values ---
+
+type valueKind int
+
+const (
+	kindMissing valueKind = iota
+	kindString
+	kindNumber
+	kindDuration
+	kindBool
+)
+
+type value struct {
+	kind     valueKind
+	str      string
+	num      float64
+	duration time.Duration
+	b        bool
+}
+
+func (v value) isTrue() bool {
+	return v.kind == kindBool && v.b
+}
+
+// toNumber returns the value as a number, converting strings if possible.
+func (v value) toNumber() (float64, bool) {
+	switch v.kind {
+	case kindNumber:
+		return v.num, true
+	case kindString:
+		n, err := strconv.ParseFloat(v.str, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// toString returns the value as a string, as it would be shown for a tag.
+func (v value) toString() string {
+	switch v.kind {
+	case kindString:
+		return v.str
+	case kindNumber:
+		return strconv.FormatFloat(v.num, 'f', -1, 64)
+	case kindDuration:
+		return v.duration.String()
+	case kindBool:
+		return strconv.FormatBool(v.b)
+	}
+	return ""
+}
+
+func tagValue(kv model.KeyValue) value {
+	switch kv.VType {
+	case model.BoolType:
+		return value{kind: kindBool, b: kv.Bool()}
+	case model.Int64Type:
+		return value{kind: kindNumber, num: float64(kv.Int64())}
+	case model.Float64Type:
+		return value{kind: kindNumber, num: kv.Float64()}
+	}
+	return value{kind: kindString, str: kv.AsString()}
+}
+
+// compare returns -1, 0 or 1, and false if the values cannot be ordered.
+func compare(a, b value) (int, bool) {
+	if a.kind == kindDuration || b.kind == kindDuration {
+		if a.kind != b.kind {
+			return 0, false
+		}
+		return compareOrdered(float64(a.duration), float64(b.duration)), true
+	}
+	if a.kind == kindString && b.kind == kindString {
+		switch {
+		case a.str < b.str:
+			return -1, true
+		case a.str > b.str:
+			return 1, true
+		}
+		return 0, true
+	}
+	x, okA := a.toNumber()
+	y, okB := b.toNumber()
+	if !okA || !okB {
+		return 0, false
+	}
+	return compareOrdered(x, y), true
+}
+
+func compareOrdered(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+func equal(a, b value) bool {
+	if a.kind == b.kind {
+		return a == b
+	}
+	if a.kind == kindDuration || b.kind == kindDuration {
+		return false
+	}
+	// values of different types are equal if they are shown the same way, e.g. the tag 200 and "200"
+	return a.toString() == b.toString()
+}
+
+// --- AST ---
+
+type node interface {
+	eval(span *model.Span) value
+}
+
+type literal struct {
+	value value
+}
+
+func (n literal) eval(*model.Span) value {
+	return n.value
+}
+
+type fieldFunc func(span *model.Span) value
+
+func (f fieldFunc) eval(span *model.Span) value {
+	return f(span)
+}
+
+var fields = map[string]fieldFunc{
+	"service": func(span *model.Span) value {
+		if span.Process == nil {
+			return value{}
+		}
+		return value{kind: kindString, str: span.Process.ServiceName}
+	},
+	"operation": func(span *model.Span) value {
+		return value{kind: kindString, str: span.OperationName}
+	},
+	"duration": func(span *model.Span) value {
+		return value{kind: kindDuration, duration: span.Duration}
+	},
+	"flags.debug": func(span *model.Span) value {
+		return value{kind: kindBool, b: span.Flags.IsDebug()}
+	},
+	"flags.sampled": func(span *model.Span) value {
+		return value{kind: kindBool, b: span.Flags.IsSampled()}
+	},
+	"flags.firehose": func(span *model.Span) value {
+		return value{kind: kindBool, b: span.Flags.IsFirehoseEnabled()}
+	},
+}
+
+type tagNode struct {
+	key     string
+	process bool
+}
+
+func (n tagNode) eval(span *model.Span) value {
+	tags := span.Tags
+	if n.process {
+		if span.Process == nil {
+			return value{}
+		}
+		tags = span.Process.Tags
+	}
+	if kv, ok := model.KeyValues(tags).FindByKey(n.key); ok {
+		return tagValue(kv)
+	}
+	return value{}
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n notNode) eval(span *model.Span) value {
+	return value{kind: kindBool, b: !n.operand.eval(span).isTrue()}
+}
+
+type logicalNode struct {
+	and         bool
+	left, right node
+}
+
+func (n logicalNode) eval(span *model.Span) value {
+	left := n.left.eval(span).isTrue()
+	if n.and && !left || !n.and && left {
+		return value{kind: kindBool, b: left}
+	}
+	return value{kind: kindBool, b: n.right.eval(span).isTrue()}
+}
+
+type comparisonNode struct {
+	op          string
+	left, right node
+}
+
+func (n comparisonNode) eval(span *model.Span) value {
+	left, right := n.left.eval(span), n.right.eval(span)
+	if left.kind == kindMissing || right.kind == kindMissing {
+		return value{kind: kindBool}
+	}
+	var result bool
+	switch n.op {
+	case "==":
+		result = equal(left, right)
+	case "!=":
+		result = !equal(left, right)
+	default:
+		cmp, ok := compare(left, right)
+		if !ok {
+			return value{kind: kindBool}
+		}
+		switch n.op {
+		case "<":
+			result = cmp < 0
+		case "<=":
+			result = cmp <= 0
+		case ">":
+			result = cmp > 0
+		case ">=":
+			result = cmp >= 0
+		}
+	}
+	return value{kind: kindBool, b: result}
+}
+
+type regexNode struct {
+	negate  bool
+	operand node
+	regex   *regexp.Regexp
+}
+
+func (n regexNode) eval(span *model.Span) value {
+	operand := n.operand.eval(span)
+	if operand.kind == kindMissing {
+		return value{kind: kindBool}
+	}
+	return value{kind: kindBool, b: n.regex.MatchString(operand.toString()) != n.negate}
+}
+
+// --- parser ---
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) expect(kind tokenKind, text string) error {
+	if tok := p.next(); tok.kind != kind || tok.text != text {
+		return fmt.Errorf("expected %q but found %s at position %d", text, tok, tok.pos)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is(tokenOperator, "||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is(tokenOperator, "&&") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalNode{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.peek().is(tokenOperator, "!") {
+		p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+var comparisonOperators = map[string]bool{"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	tok := p.peek()
+	if tok.kind != tokenOperator {
+		return left, nil
+	}
+	switch {
+	case comparisonOperators[tok.text]:
+		p.next()
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return comparisonNode{op: tok.text, left: left, right: right}, nil
+	case tok.text == "=~" || tok.text == "!~":
+		p.next()
+		pattern := p.next()
+		if pattern.kind != tokenString {
+			return nil, fmt.Errorf("expected a regular expression string after %s at position %d", tok.text, pattern.pos)
+		}
+		regex, err := regexp.Compile(pattern.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression at position %d: %w", pattern.pos, err)
+		}
+		return regexNode{negate: tok.text == "!~", operand: left, regex: regex}, nil
+	}
+	return left, nil
+}
+
+func (p *parser) parseOperand() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenString:
+		return literal{value: value{kind: kindString, str: tok.text}}, nil
+	case tokenNumber:
+		return parseNumber(tok)
+	case tokenPunctuation:
+		if tok.text != "(" {
+			break
+		}
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenPunctuation, ")"); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case tokenIdentifier:
+		return p.parseIdentifier(tok)
+	}
+	return nil, fmt.Errorf("unexpected %s at position %d", tok, tok.pos)
+}
+
+func parseNumber(tok token) (node, error) {
+	if n, err := strconv.ParseFloat(tok.text, 64); err == nil {
+		return literal{value: value{kind: kindNumber, num: n}}, nil
+	}
+	d, err := time.ParseDuration(tok.text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number or duration %s at position %d", tok.text, tok.pos)
+	}
+	return literal{value: value{kind: kindDuration, duration: d}}, nil
+}
+
+func (p *parser) parseIdentifier(tok token) (node, error) {
+	switch tok.text {
+	case "true", "false":
+		return literal{value: value{kind: kindBool, b: tok.text == "true"}}, nil
+	case "tags", "process.tags":
+		if err := p.expect(tokenPunctuation, "["); err != nil {
+			return nil, err
+		}
+		key := p.next()
+		if key.kind != tokenString {
+			return nil, fmt.Errorf("expected a tag key string but found %s at position %d", key, key.pos)
+		}
+		if err := p.expect(tokenPunctuation, "]"); err != nil {
+			return nil, err
+		}
+		return tagNode{key: key.text, process: tok.text == "process.tags"}, nil
+	}
+	if field, ok := fields[tok.text]; ok {
+		return field, nil
+	}
+	return nil, fmt.Errorf("unknown identifier %s at position %d", tok.text, tok.pos)
+}
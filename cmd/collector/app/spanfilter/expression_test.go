@@ -0,0 +1,137 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanfilter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+func testSpan() *model.Span {
+	return &model.Span{
+		OperationName: "GET /health",
+		Duration:      2 * time.Millisecond,
+		Flags:         model.SampledFlag,
+		Tags: model.KeyValues{
+			model.Int64("http.status_code", 200),
+			model.String("http.method", "GET"),
+			model.Bool("error", false),
+			model.Float64("ratio", 0.5),
+		},
+		Process: &model.Process{
+			ServiceName: "frontend",
+			Tags:        model.KeyValues{model.String("hostname", "host-1")},
+		},
+	}
+}
+
+func TestExpressionMatches(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   bool
+	}{
+		{`service == "frontend"`, true},
+		{`service != "frontend"`, false},
+		{`operation == "GET /health"`, true},
+		{`operation =~ "^GET /(health|ready)$"`, true},
+		{`operation !~ "health"`, false},
+		{`duration < 5ms`, true},
+		{`duration >= 2ms`, true},
+		{`duration > 2ms`, false},
+		{`duration <= 1.5ms`, false},
+		{`duration == 2000us`, true},
+		{`duration < 5`, false},
+		{`flags.sampled`, true},
+		{`flags.debug`, false},
+		{`flags.firehose == false`, true},
+		{`!flags.debug`, true},
+		{`tags["http.status_code"] == 200`, true},
+		{`tags["http.status_code"] == "200"`, true},
+		{`tags["http.status_code"] >= 500`, false},
+		{`tags["http.status_code"] =~ "^2"`, true},
+		{`tags["http.method"] == "GET"`, true},
+		{`tags["http.method"] > "DELETE"`, true},
+		{`tags["http.method"] < 10`, false},
+		{`tags["error"]`, false},
+		{`tags["error"] == false`, true},
+		{`tags["ratio"] < 1`, true},
+		{`tags["missing"] == "x"`, false},
+		{`tags["missing"] != "x"`, false},
+		{`tags["missing"] =~ ".*"`, false},
+		{`!(tags["missing"] == "x")`, true},
+		{`process.tags["hostname"] == "host-1"`, true},
+		{`process.tags["http.method"] == "GET"`, false},
+		{`service == "frontend" && operation == "GET /health"`, true},
+		{`service == "backend" || operation == "GET /health"`, true},
+		{`service == "backend" || operation == "POST /" && true`, false},
+		{`(service == "backend" || operation == "GET /health") && duration < 1ms`, false},
+		{`!service == "backend"`, true},
+		{`service`, false},
+		{`true`, true},
+		{`1 < 2`, true},
+		{`"a\"b" == "a\"b"`, true},
+	}
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			e, err := Compile(test.expression)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, e.Matches(testSpan()))
+			assert.Equal(t, test.expression, e.String())
+		})
+	}
+}
+
+func TestExpressionWithoutProcess(t *testing.T) {
+	for _, expression := range []string{`service == ""`, `process.tags["hostname"] == "host-1"`} {
+		e, err := Compile(expression)
+		require.NoError(t, err)
+		assert.False(t, e.Matches(&model.Span{}), expression)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		expression string
+		err        string
+	}{
+		{``, "unexpected end of expression at position 0"},
+		{`service ==`, "unexpected end of expression at position 10"},
+		{`service == "a" service`, `unexpected "service" at position 15`},
+		{`unknown == "a"`, "unknown identifier unknown at position 0"},
+		{`service == "a`, "unterminated string at position 11"},
+		{`service == "\q"`, "invalid string at position 11"},
+		{`duration < 5xs`, "invalid number or duration 5xs at position 11"},
+		{`service # "a"`, `unexpected character '#' at position 8`},
+		{`(service == "a"`, `expected ")" but found end of expression at position 15`},
+		{`tags("a")`, `expected "[" but found "(" at position 4`},
+		{`tags[a]`, `expected a tag key string but found "a" at position 5`},
+		{`tags["a"`, `expected "]" but found end of expression at position 8`},
+		{`service =~ operation`, "expected a regular expression string after =~ at position 11"},
+		{`service =~ "("`, "invalid regular expression at position 11"},
+		{`]`, `unexpected "]" at position 0`},
+	}
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			_, err := Compile(test.expression)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.err)
+		})
+	}
+}
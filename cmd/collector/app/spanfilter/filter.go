@@ -0,0 +1,176 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanfilter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/fswatcher"
+)
+
+// Options holds the configuration of the span filter.
+type Options struct {
+	// RulesFile is the path to the YAML or JSON file with the filter rules, spans are not filtered if empty
+	RulesFile string
+	// MetricsFactory is used to report the spans dropped by each rule
+	MetricsFactory metrics.Factory
+	// NewWatcher creates the watcher of the rules file, fswatcher.NewWatcher is used if nil
+	NewWatcher func() (fswatcher.Watcher, error)
+}
+
+// rulesFile is the content of the rules file, e.g.
+//
+//	rules:
+//	  - name: health-checks
+//	    drop: 'operation =~ "^GET /(health|ready)$"'
+//	  - name: fast-cron
+//	    drop: 'service == "cron" && duration < 1ms'
+type rulesFile struct {
+	Rules []ruleJSON `json:"rules" yaml:"rules"`
+}
+
+type ruleJSON struct {
+	// Name identifies the rule in the metrics
+	Name string `json:"name" yaml:"name"`
+	// Drop is the expression matching the spans to drop
+	Drop string `json:"drop" yaml:"drop"`
+}
+
+type rule struct {
+	name    string
+	drop    *Expression
+	dropped metrics.Counter
+}
+
+type filterMetrics struct {
+	// Number of successful reloads of the rules file
+	Reloads metrics.Counter `metric:"span-filter.reloads" tags:"result=ok"`
+	// Number of failed reloads of the rules file, the last valid rules are kept
+	ReloadErrors metrics.Counter `metric:"span-filter.reloads" tags:"result=err"`
+}
+
+// Filter drops the spans matching the expression of any of its rules. The rules are
+// compiled once when the rules file is loaded, and reloaded when the file changes.
+type Filter struct {
+	options Options
+	logger  *zap.Logger
+	metrics filterMetrics
+	rules   atomic.Value // []rule
+	watcher *fswatcher.FileWatcher
+}
+
+// NewFilter loads the rules file and starts watching it for changes.
+func NewFilter(options Options, logger *zap.Logger) (*Filter, error) {
+	if options.MetricsFactory == nil {
+		options.MetricsFactory = metrics.NullFactory
+	}
+	f := &Filter{
+		options: options,
+		logger:  logger,
+	}
+	metrics.MustInit(&f.metrics, options.MetricsFactory, nil)
+	rules, err := f.loadRules()
+	if err != nil {
+		return nil, err
+	}
+	f.rules.Store(rules)
+	if f.watcher, err = fswatcher.NewFileWatcher(options.RulesFile, f.reload, logger, options.NewWatcher); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Accept returns false if the span matches a rule, it can be used as the span filter of the span processor.
+func (f *Filter) Accept(span *model.Span) bool {
+	for _, r := range f.rules.Load().([]rule) {
+		if r.drop.Matches(span) {
+			r.dropped.Inc(1)
+			return false
+		}
+	}
+	return true
+}
+
+func (f *Filter) loadRules() ([]rule, error) {
+	file := f.options.RulesFile
+	bytes, err := ioutil.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the span filter rules file: %w", err)
+	}
+	if len(bytes) == 0 {
+		// most likely the file is being written
+		return nil, fmt.Errorf("the span filter rules file %s is empty", file)
+	}
+	var content rulesFile
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		err = json.Unmarshal(bytes, &content)
+	} else {
+		err = yaml.UnmarshalStrict(bytes, &content)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the span filter rules file: %w", err)
+	}
+	names := make(map[string]struct{})
+	rules := make([]rule, 0, len(content.Rules))
+	for i, r := range content.Rules {
+		if r.Name == "" {
+			return nil, fmt.Errorf("span filter rule %d has no name", i)
+		}
+		if _, ok := names[r.Name]; ok {
+			return nil, fmt.Errorf("duplicate span filter rule %s", r.Name)
+		}
+		names[r.Name] = struct{}{}
+		expression, err := Compile(r.Drop)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression of span filter rule %s: %w", r.Name, err)
+		}
+		rules = append(rules, rule{
+			name: r.Name,
+			drop: expression,
+			dropped: f.options.MetricsFactory.Counter(metrics.Options{
+				Name: "span-filter.spans-dropped",
+				Tags: map[string]string{"rule": r.Name},
+			}),
+		})
+	}
+	return rules, nil
+}
+
+func (f *Filter) reload() {
+	rules, err := f.loadRules()
+	if err != nil {
+		f.metrics.ReloadErrors.Inc(1)
+		f.logger.Error("failed to reload the span filter rules file, keeping the previous rules", zap.Error(err))
+		return
+	}
+	f.rules.Store(rules)
+	f.metrics.Reloads.Inc(1)
+	f.logger.Info("reloaded the span filter rules file", zap.String("file", f.options.RulesFile), zap.Int("rules", len(rules)))
+}
+
+// Close stops watching the rules file.
+func (f *Filter) Close() error {
+	return f.watcher.Close()
+}
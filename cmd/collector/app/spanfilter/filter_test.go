@@ -0,0 +1,130 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanfilter
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/fswatcher"
+)
+
+func writeRules(t *testing.T, dir, name, content string) string {
+	file := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(file, []byte(content), 0600))
+	return file
+}
+
+const testRules = `
+rules:
+  - name: health-checks
+    drop: 'operation =~ "^GET /(health|ready)$"'
+  - name: fast-cron
+    drop: 'service == "cron" && duration < 1ms'
+`
+
+func TestFilterAccept(t *testing.T) {
+	dir := t.TempDir()
+	mf := metricstest.NewFactory(0)
+	yamlFilter, err := NewFilter(Options{RulesFile: writeRules(t, dir, "rules.yaml", testRules), MetricsFactory: mf}, zap.NewNop())
+	require.NoError(t, err)
+	defer yamlFilter.Close()
+	jsonFilter, err := NewFilter(Options{RulesFile: writeRules(t, dir, "rules.json", `{"rules": [
+		{"name": "health-checks", "drop": "operation =~ \"^GET /(health|ready)$\""},
+		{"name": "fast-cron", "drop": "service == \"cron\" && duration < 1ms"}
+	]}`)}, zap.NewNop())
+	require.NoError(t, err)
+	defer jsonFilter.Close()
+
+	spans := []struct {
+		span     *model.Span
+		accepted bool
+	}{
+		{span: &model.Span{OperationName: "GET /ready", Process: &model.Process{ServiceName: "frontend"}}, accepted: false},
+		{span: &model.Span{OperationName: "GET /users", Process: &model.Process{ServiceName: "frontend"}}, accepted: true},
+		{span: &model.Span{OperationName: "tick", Duration: time.Microsecond, Process: &model.Process{ServiceName: "cron"}}, accepted: false},
+		{span: &model.Span{OperationName: "tick", Duration: time.Second, Process: &model.Process{ServiceName: "cron"}}, accepted: true},
+	}
+	for i, s := range spans {
+		assert.Equal(t, s.accepted, yamlFilter.Accept(s.span), "span %d", i)
+		assert.Equal(t, s.accepted, jsonFilter.Accept(s.span), "span %d", i)
+	}
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "span-filter.spans-dropped", Tags: map[string]string{"rule": "health-checks"}, Value: 1},
+		metricstest.ExpectedMetric{Name: "span-filter.spans-dropped", Tags: map[string]string{"rule": "fast-cron"}, Value: 1},
+	)
+}
+
+func TestFilterReload(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	file := writeRules(t, t.TempDir(), "rules.yaml", testRules)
+	f, err := NewFilter(Options{RulesFile: file, MetricsFactory: mf}, zap.NewNop())
+	require.NoError(t, err)
+	defer f.Close()
+
+	span := &model.Span{OperationName: "GET /users", Process: &model.Process{ServiceName: "frontend"}}
+	require.True(t, f.Accept(span))
+	require.NoError(t, ioutil.WriteFile(file, []byte(`rules: [{name: users, drop: 'operation == "GET /users"'}]`), 0600))
+	assert.Eventually(t, func() bool { return !f.Accept(span) }, 5*time.Second, 10*time.Millisecond)
+
+	// an invalid file does not replace the rules
+	require.NoError(t, ioutil.WriteFile(file, []byte(`rules: [{name: users, drop: 'operation =='}]`), 0600))
+	assert.Eventually(t, func() bool {
+		counters, _ := mf.Snapshot()
+		return counters["span-filter.reloads|result=err"] > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.False(t, f.Accept(span))
+}
+
+func TestNewFilterErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{name: "empty.yaml", content: "", err: "is empty"},
+		{name: "invalid.json", content: `{"rules": [`, err: "failed to parse the span filter rules file"},
+		{name: "unknown-field.yaml", content: "rules: [{name: a, keep: 'true'}]", err: "failed to parse the span filter rules file"},
+		{name: "no-name.yaml", content: "rules: [{drop: 'true'}]", err: "span filter rule 0 has no name"},
+		{name: "duplicate.yaml", content: "rules: [{name: a, drop: 'true'}, {name: a, drop: 'false'}]", err: "duplicate span filter rule a"},
+		{name: "expression.yaml", content: "rules: [{name: a, drop: 'service =='}]", err: "invalid expression of span filter rule a: unexpected end of expression"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewFilter(Options{RulesFile: writeRules(t, dir, test.name, test.content)}, zap.NewNop())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.err)
+		})
+	}
+
+	_, err := NewFilter(Options{RulesFile: filepath.Join(dir, "missing.yaml")}, zap.NewNop())
+	assert.Contains(t, err.Error(), "failed to read the span filter rules file")
+
+	_, err = NewFilter(Options{
+		RulesFile:  writeRules(t, dir, "rules.yaml", testRules),
+		NewWatcher: func() (fswatcher.Watcher, error) { return nil, errors.New("no watcher") },
+	}, zap.NewNop())
+	assert.EqualError(t, err, "no watcher")
+}
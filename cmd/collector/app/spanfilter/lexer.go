@@ -0,0 +1,127 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanfilter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdentifier
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenPunctuation
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) is(kind tokenKind, text string) bool {
+	return t.kind == kind && t.text == text
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of expression"
+	case tokenString:
+		return strconv.Quote(t.text)
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// operators are sorted so that the longest operators are tried first.
+var operators = []string{"==", "!=", "<=", ">=", "=~", "!~", "&&", "||", "<", ">", "!"}
+
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	for pos := 0; pos < len(source); {
+		c := rune(source[pos])
+		switch {
+		case unicode.IsSpace(c):
+			pos++
+		case c == '(' || c == ')' || c == '[' || c == ']':
+			tokens = append(tokens, token{kind: tokenPunctuation, text: string(c), pos: pos})
+			pos++
+		case c == '"':
+			end := stringEnd(source, pos)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at position %d", pos)
+			}
+			str, err := strconv.Unquote(source[pos:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at position %d: %w", pos, err)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: str, pos: pos})
+			pos = end
+		case c >= '0' && c <= '9':
+			end := scan(source, pos, func(r rune) bool { return r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r) })
+			tokens = append(tokens, token{kind: tokenNumber, text: source[pos:end], pos: pos})
+			pos = end
+		case c == '_' || unicode.IsLetter(c):
+			end := scan(source, pos, func(r rune) bool { return r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r) })
+			tokens = append(tokens, token{kind: tokenIdentifier, text: source[pos:end], pos: pos})
+			pos = end
+		default:
+			op := matchOperator(source[pos:])
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, pos)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: op, pos: pos})
+			pos += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(source)}), nil
+}
+
+// stringEnd returns the position after the closing quote of the string starting at start, or -1.
+func stringEnd(source string, start int) int {
+	for i := start + 1; i < len(source); i++ {
+		switch source[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+func scan(source string, start int, accept func(rune) bool) int {
+	end := start
+	for end < len(source) && accept(rune(source[end])) {
+		end++
+	}
+	return end
+}
+
+func matchOperator(source string) string {
+	for _, op := range operators {
+		if strings.HasPrefix(source, op) {
+			return op
+		}
+	}
+	return ""
+}
@@ -0,0 +1,242 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanmetrics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/proto-gen/api_v2/metrics"
+)
+
+const (
+	// The metric and label names match the ones produced by the OpenTelemetry Collector's
+	// spanmetrics processor, which are queried by plugin/metrics/prometheus.
+	callsMetricName   = "calls_total"
+	latencyMetricName = "latency"
+
+	serviceNameLabel = "service_name"
+	operationLabel   = "operation"
+	spanKindLabel    = "span_kind"
+	statusCodeLabel  = "status_code"
+	tenantLabel      = "tenant"
+
+	statusCodeError = "STATUS_CODE_ERROR"
+	statusCodeUnset = "STATUS_CODE_UNSET"
+)
+
+// DefaultLatencyBuckets are the default histogram buckets for span latencies, in milliseconds.
+var DefaultLatencyBuckets = []float64{2, 4, 6, 8, 10, 50, 100, 200, 400, 800, 1000, 1400, 2000, 5000, 10000, 15000}
+
+const (
+	// DefaultResolution is the default interval between two data points kept for the metrics reader.
+	DefaultResolution = 5 * time.Second
+	// DefaultRetention is the default period of data points kept for the metrics reader.
+	DefaultRetention = time.Hour
+)
+
+// Options holds the configuration of the Aggregator.
+type Options struct {
+	// LatencyBuckets are the upper bounds in milliseconds of the latency histogram buckets
+	LatencyBuckets []float64
+	// Resolution is the interval between two data points kept for the metrics reader
+	Resolution time.Duration
+	// Retention is the period of data points kept for the metrics reader
+	Retention time.Duration
+	// Registerer is where the Prometheus metrics are registered, e.g. prometheus.DefaultRegisterer,
+	// they are unregistered when the Aggregator is closed
+	Registerer prometheus.Registerer
+}
+
+type seriesKey struct {
+	tenant    string
+	service   string
+	operation string
+	spanKind  string
+}
+
+type seriesData struct {
+	calls  float64
+	errors float64
+	// buckets holds the number of spans per latency bucket, with the last one being +Inf
+	buckets []float64
+}
+
+type snapshot struct {
+	timestamp time.Time
+	series    map[seriesKey]seriesData
+}
+
+// Aggregator computes request, error and duration (RED) metrics from spans, grouped by
+// tenant, service, operation and span kind. The metrics are exposed in Prometheus format and are
+// also retained in memory so that the Aggregator can serve as a metricsstore.Reader.
+type Aggregator struct {
+	options Options
+	calls   *prometheus.CounterVec
+	latency *prometheus.HistogramVec
+
+	lock      sync.Mutex
+	series    map[seriesKey]*seriesData
+	snapshots []snapshot
+
+	now    func() time.Time
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewAggregator creates an Aggregator and starts recording data points for the metrics reader.
+func NewAggregator(options Options) (*Aggregator, error) {
+	if len(options.LatencyBuckets) == 0 {
+		options.LatencyBuckets = DefaultLatencyBuckets
+	}
+	sort.Float64s(options.LatencyBuckets)
+	if options.Resolution <= 0 {
+		options.Resolution = DefaultResolution
+	}
+	if options.Retention <= 0 {
+		options.Retention = DefaultRetention
+	}
+	labels := []string{serviceNameLabel, operationLabel, spanKindLabel, statusCodeLabel, tenantLabel}
+	a := &Aggregator{
+		options: options,
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: callsMetricName,
+			Help: "Number of spans, grouped by service, operation, span kind, status code and tenant",
+		}, labels),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    latencyMetricName,
+			Help:    "Span duration in milliseconds, grouped by service, operation, span kind, status code and tenant",
+			Buckets: options.LatencyBuckets,
+		}, labels),
+		series: make(map[seriesKey]*seriesData),
+		now:    time.Now,
+		stopCh: make(chan struct{}),
+	}
+	if options.Registerer != nil {
+		if err := options.Registerer.Register(a.calls); err != nil {
+			return nil, err
+		}
+		if err := options.Registerer.Register(a.latency); err != nil {
+			options.Registerer.Unregister(a.calls)
+			return nil, err
+		}
+	}
+	a.wg.Add(1)
+	go a.recordSnapshots()
+	return a, nil
+}
+
+// ProcessSpan records the span of the tenant in the metrics, it has the signature of app.ProcessSpan.
+func (a *Aggregator) ProcessSpan(span *model.Span, tenant string) {
+	if span.Process == nil {
+		return
+	}
+	key := seriesKey{
+		tenant:    tenant,
+		service:   span.Process.ServiceName,
+		operation: span.OperationName,
+		spanKind:  spanKind(span),
+	}
+	statusCode := statusCodeUnset
+	isError := span.HasErrorTag()
+	if isError {
+		statusCode = statusCodeError
+	}
+	latency := float64(span.Duration) / float64(time.Millisecond)
+
+	labels := prometheus.Labels{
+		serviceNameLabel: key.service,
+		operationLabel:   key.operation,
+		spanKindLabel:    key.spanKind,
+		statusCodeLabel:  statusCode,
+		tenantLabel:      key.tenant,
+	}
+	a.calls.With(labels).Inc()
+	a.latency.With(labels).Observe(latency)
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	data, ok := a.series[key]
+	if !ok {
+		data = &seriesData{buckets: make([]float64, len(a.options.LatencyBuckets)+1)}
+		a.series[key] = data
+	}
+	data.calls++
+	if isError {
+		data.errors++
+	}
+	data.buckets[sort.SearchFloat64s(a.options.LatencyBuckets, latency)]++
+}
+
+// Close stops recording data points and unregisters the Prometheus metrics.
+func (a *Aggregator) Close() error {
+	close(a.stopCh)
+	a.wg.Wait()
+	if a.options.Registerer != nil {
+		a.options.Registerer.Unregister(a.calls)
+		a.options.Registerer.Unregister(a.latency)
+	}
+	return nil
+}
+
+func (a *Aggregator) recordSnapshots() {
+	defer a.wg.Done()
+	ticker := time.NewTicker(a.options.Resolution)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			a.recordSnapshot()
+		case <-a.stopCh:
+			return
+		}
+	}
+}
+
+// recordSnapshot copies the current cumulative counts into the list of data points
+// and evicts the data points older than the retention period.
+func (a *Aggregator) recordSnapshot() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	now := a.now()
+	series := make(map[seriesKey]seriesData, len(a.series))
+	for k, v := range a.series {
+		buckets := make([]float64, len(v.buckets))
+		copy(buckets, v.buckets)
+		series[k] = seriesData{calls: v.calls, errors: v.errors, buckets: buckets}
+	}
+	a.snapshots = append(a.snapshots, snapshot{timestamp: now, series: series})
+
+	expired := 0
+	for expired < len(a.snapshots) && now.Sub(a.snapshots[expired].timestamp) > a.options.Retention {
+		expired++
+	}
+	a.snapshots = a.snapshots[expired:]
+}
+
+// spanKind returns the span kind in the format of the metrics API, e.g. SPAN_KIND_SERVER.
+func spanKind(span *model.Span) string {
+	kind, _ := span.GetSpanKind()
+	name := "SPAN_KIND_" + strings.ToUpper(kind)
+	if _, ok := metrics.SpanKind_value[name]; !ok {
+		return metrics.SpanKind_SPAN_KIND_UNSPECIFIED.String()
+	}
+	return name
+}
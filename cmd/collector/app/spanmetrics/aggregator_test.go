@@ -0,0 +1,116 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanmetrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+func makeSpan(service, operation, kind string, duration time.Duration, isError bool) *model.Span {
+	tags := model.KeyValues{model.String("span.kind", kind)}
+	if isError {
+		tags = append(tags, model.Bool("error", true))
+	}
+	return &model.Span{
+		OperationName: operation,
+		Duration:      duration,
+		Tags:          tags,
+		Process:       &model.Process{ServiceName: service},
+	}
+}
+
+func TestAggregatorPrometheusMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	a, err := NewAggregator(Options{Registerer: registry, LatencyBuckets: []float64{10, 100}})
+	require.NoError(t, err)
+
+	a.ProcessSpan(makeSpan("frontend", "GET /", "server", 5*time.Millisecond, false), "")
+	a.ProcessSpan(makeSpan("frontend", "GET /", "server", 50*time.Millisecond, true), "acme")
+	a.ProcessSpan(&model.Span{OperationName: "no-process"}, "")
+
+	expected := `
+# HELP calls_total Number of spans, grouped by service, operation, span kind, status code and tenant
+# TYPE calls_total counter
+calls_total{operation="GET /",service_name="frontend",span_kind="SPAN_KIND_SERVER",status_code="STATUS_CODE_ERROR",tenant="acme"} 1
+calls_total{operation="GET /",service_name="frontend",span_kind="SPAN_KIND_SERVER",status_code="STATUS_CODE_UNSET",tenant=""} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "calls_total"))
+	assert.Equal(t, 2, testutil.CollectAndCount(a.latency))
+
+	_, err = NewAggregator(Options{Registerer: registry})
+	assert.Error(t, err, "metrics are already registered")
+
+	// the metrics are unregistered on close, e.g. when the collector is restarted
+	require.NoError(t, a.Close())
+	a, err = NewAggregator(Options{Registerer: registry})
+	require.NoError(t, err)
+	require.NoError(t, a.Close())
+}
+
+func TestAggregatorSeries(t *testing.T) {
+	a, err := NewAggregator(Options{LatencyBuckets: []float64{100, 10}})
+	require.NoError(t, err)
+	defer a.Close()
+
+	a.ProcessSpan(makeSpan("frontend", "GET /", "server", 5*time.Millisecond, false), "")
+	a.ProcessSpan(makeSpan("frontend", "GET /", "server", 500*time.Millisecond, true), "")
+	a.ProcessSpan(makeSpan("frontend", "GET /", "unknown", 50*time.Millisecond, false), "")
+	a.ProcessSpan(makeSpan("frontend", "GET /", "unknown", 50*time.Millisecond, false), "acme")
+
+	assert.Equal(t, []float64{10, 100}, a.options.LatencyBuckets)
+	assert.Equal(t, map[seriesKey]*seriesData{
+		{service: "frontend", operation: "GET /", spanKind: "SPAN_KIND_SERVER"}: {
+			calls: 2, errors: 1, buckets: []float64{1, 0, 1},
+		},
+		{service: "frontend", operation: "GET /", spanKind: "SPAN_KIND_UNSPECIFIED"}: {
+			calls: 1, buckets: []float64{0, 1, 0},
+		},
+		{tenant: "acme", service: "frontend", operation: "GET /", spanKind: "SPAN_KIND_UNSPECIFIED"}: {
+			calls: 1, buckets: []float64{0, 1, 0},
+		},
+	}, a.series)
+}
+
+func TestAggregatorSnapshots(t *testing.T) {
+	a, err := NewAggregator(Options{Resolution: time.Millisecond, Retention: time.Minute})
+	require.NoError(t, err)
+	a.ProcessSpan(makeSpan("frontend", "GET /", "server", time.Millisecond, false), "")
+	for i := 0; i < 100; i++ {
+		a.lock.Lock()
+		n := len(a.snapshots)
+		a.lock.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	require.NoError(t, a.Close())
+	require.NotEmpty(t, a.snapshots)
+
+	// old snapshots are evicted
+	now := time.Now()
+	a.now = func() time.Time { return now.Add(time.Hour) }
+	a.recordSnapshot()
+	assert.Len(t, a.snapshots, 1)
+}
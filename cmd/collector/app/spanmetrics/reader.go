@@ -0,0 +1,283 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanmetrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
+	"github.com/jaegertracing/jaeger/proto-gen/api_v2/metrics"
+	"github.com/jaegertracing/jaeger/storage/metricsstore"
+)
+
+const (
+	latenciesMetricName = "service_latencies"
+	latenciesMetricDesc = "%.2fth quantile latency, grouped by service"
+
+	callsRateMetricName = "service_call_rate"
+	callsRateMetricDesc = "calls/sec, grouped by service"
+
+	errorsRateMetricName = "service_error_rate"
+	errorsRateMetricDesc = "error rate, computed as a fraction of errors/sec over calls/sec, grouped by service"
+
+	operationMetricName = "service_operation"
+	operationMetricDesc = " & operation"
+)
+
+var _ metricsstore.Reader = (*Aggregator)(nil)
+
+// errMissingParameters is returned when a query lacks the time range parameters.
+var errMissingParameters = errors.New("end time, lookback and step are required")
+
+type groupKey struct {
+	service   string
+	operation string
+}
+
+// pointValue computes the value of a data point from the snapshot at the point's timestamp and,
+// for rates, the snapshot at the start of the rate window. It returns false if there is no value.
+type pointValue func(current, previous map[seriesKey]seriesData, filter func(seriesKey) bool, group groupKey, groupByOperation bool) (float64, bool)
+
+// GetLatencies implements metricsstore.Reader.
+func (a *Aggregator) GetLatencies(ctx context.Context, params *metricsstore.LatenciesQueryParameters) (*metrics.MetricFamily, error) {
+	bounds := a.options.LatencyBuckets
+	return a.query(ctx, params.BaseQueryParameters, latenciesMetricName, fmt.Sprintf(latenciesMetricDesc, params.Quantile), false,
+		func(current, _ map[seriesKey]seriesData, filter func(seriesKey) bool, group groupKey, groupByOperation bool) (float64, bool) {
+			buckets := make([]float64, len(bounds)+1)
+			for k, v := range current {
+				if !filter(k) || groupOf(k, groupByOperation) != group {
+					continue
+				}
+				for i, count := range v.buckets {
+					buckets[i] += count
+				}
+			}
+			return bucketQuantile(params.Quantile, bounds, buckets)
+		})
+}
+
+// GetCallRates implements metricsstore.Reader.
+func (a *Aggregator) GetCallRates(ctx context.Context, params *metricsstore.CallRateQueryParameters) (*metrics.MetricFamily, error) {
+	return a.query(ctx, params.BaseQueryParameters, callsRateMetricName, callsRateMetricDesc, true,
+		func(current, previous map[seriesKey]seriesData, filter func(seriesKey) bool, group groupKey, groupByOperation bool) (float64, bool) {
+			calls, _ := delta(current, previous, filter, group, groupByOperation)
+			return calls / params.RatePer.Seconds(), true
+		})
+}
+
+// GetErrorRates implements metricsstore.Reader.
+func (a *Aggregator) GetErrorRates(ctx context.Context, params *metricsstore.ErrorRateQueryParameters) (*metrics.MetricFamily, error) {
+	return a.query(ctx, params.BaseQueryParameters, errorsRateMetricName, errorsRateMetricDesc, true,
+		func(current, previous map[seriesKey]seriesData, filter func(seriesKey) bool, group groupKey, groupByOperation bool) (float64, bool) {
+			calls, errs := delta(current, previous, filter, group, groupByOperation)
+			if calls == 0 {
+				return 0, false
+			}
+			return errs / calls, true
+		})
+}
+
+// GetMinStepDuration implements metricsstore.Reader.
+func (a *Aggregator) GetMinStepDuration(_ context.Context, _ *metricsstore.MinStepDurationQueryParameters) (time.Duration, error) {
+	return a.options.Resolution, nil
+}
+
+// query only reads the metrics of the tenant of the context.
+func (a *Aggregator) query(ctx context.Context, params metricsstore.BaseQueryParameters, name, desc string, isRate bool, value pointValue) (*metrics.MetricFamily, error) {
+	if params.EndTime == nil || params.Lookback == nil || params.Step == nil || *params.Step <= 0 {
+		return nil, errMissingParameters
+	}
+	if isRate && (params.RatePer == nil || *params.RatePer <= 0) {
+		return nil, errors.New("rate per is required")
+	}
+	if params.GroupByOperation {
+		name = operationMetricName + name[len("service"):]
+		desc += operationMetricDesc
+	}
+	filter := newFilter(params, tenancy.GetTenant(ctx))
+
+	a.lock.Lock()
+	snapshots := a.snapshots
+	a.lock.Unlock()
+
+	series := make(map[groupKey]*metrics.Metric)
+	start := params.EndTime.Add(-*params.Lookback)
+	for ts := start; !ts.After(*params.EndTime); ts = ts.Add(*params.Step) {
+		current := snapshotAt(snapshots, ts)
+		if current == nil {
+			continue
+		}
+		var previous map[seriesKey]seriesData
+		if isRate {
+			if previous = snapshotAt(snapshots, ts.Add(-*params.RatePer)); previous == nil {
+				continue
+			}
+		}
+		for _, group := range groupsOf(current, filter, params.GroupByOperation) {
+			v, ok := value(current, previous, filter, group, params.GroupByOperation)
+			if !ok {
+				continue
+			}
+			metric, ok := series[group]
+			if !ok {
+				metric = &metrics.Metric{Labels: groupLabels(group, params.GroupByOperation)}
+				series[group] = metric
+			}
+			metric.MetricPoints = append(metric.MetricPoints, metricPoint(ts, v))
+		}
+	}
+	return &metrics.MetricFamily{
+		Name:    name,
+		Type:    metrics.MetricType_GAUGE,
+		Help:    desc,
+		Metrics: sortedMetrics(series),
+	}, nil
+}
+
+func newFilter(params metricsstore.BaseQueryParameters, tenant string) func(seriesKey) bool {
+	services := make(map[string]bool, len(params.ServiceNames))
+	for _, s := range params.ServiceNames {
+		services[s] = true
+	}
+	kinds := make(map[string]bool, len(params.SpanKinds))
+	for _, k := range params.SpanKinds {
+		kinds[k] = true
+	}
+	return func(k seriesKey) bool {
+		if k.tenant != tenant {
+			return false
+		}
+		if len(services) > 0 && !services[k.service] {
+			return false
+		}
+		return len(kinds) == 0 || kinds[k.spanKind]
+	}
+}
+
+// snapshotAt returns the most recent snapshot taken at or before the given time.
+func snapshotAt(snapshots []snapshot, ts time.Time) map[seriesKey]seriesData {
+	i := sort.Search(len(snapshots), func(i int) bool {
+		return snapshots[i].timestamp.After(ts)
+	})
+	if i == 0 {
+		return nil
+	}
+	return snapshots[i-1].series
+}
+
+func groupOf(k seriesKey, groupByOperation bool) groupKey {
+	if groupByOperation {
+		return groupKey{service: k.service, operation: k.operation}
+	}
+	return groupKey{service: k.service}
+}
+
+func groupsOf(series map[seriesKey]seriesData, filter func(seriesKey) bool, groupByOperation bool) []groupKey {
+	unique := make(map[groupKey]struct{})
+	for k := range series {
+		if filter(k) {
+			unique[groupOf(k, groupByOperation)] = struct{}{}
+		}
+	}
+	groups := make([]groupKey, 0, len(unique))
+	for g := range unique {
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+func delta(current, previous map[seriesKey]seriesData, filter func(seriesKey) bool, group groupKey, groupByOperation bool) (calls, errs float64) {
+	for k, v := range current {
+		if !filter(k) || groupOf(k, groupByOperation) != group {
+			continue
+		}
+		calls += v.calls - previous[k].calls
+		errs += v.errors - previous[k].errors
+	}
+	return calls, errs
+}
+
+// bucketQuantile estimates the quantile from the histogram buckets the same way as
+// Prometheus' histogram_quantile, i.e. by linear interpolation within the bucket.
+func bucketQuantile(q float64, bounds []float64, buckets []float64) (float64, bool) {
+	var total float64
+	for _, count := range buckets {
+		total += count
+	}
+	if total == 0 || q < 0 || q > 1 {
+		return 0, false
+	}
+	rank := q * total
+	var cumulative float64
+	for i, count := range buckets {
+		if cumulative+count < rank || count == 0 {
+			cumulative += count
+			continue
+		}
+		if i == len(bounds) {
+			// the quantile falls into the +Inf bucket
+			return bounds[len(bounds)-1], true
+		}
+		lower := 0.0
+		if i > 0 {
+			lower = bounds[i-1]
+		}
+		return lower + (bounds[i]-lower)*(rank-cumulative)/count, true
+	}
+	return bounds[len(bounds)-1], true
+}
+
+func groupLabels(group groupKey, groupByOperation bool) []*metrics.Label {
+	labels := []*metrics.Label{{Name: serviceNameLabel, Value: group.service}}
+	if groupByOperation {
+		labels = append(labels, &metrics.Label{Name: operationLabel, Value: group.operation})
+	}
+	return labels
+}
+
+func metricPoint(ts time.Time, value float64) *metrics.MetricPoint {
+	return &metrics.MetricPoint{
+		Timestamp: &types.Timestamp{Seconds: ts.Unix(), Nanos: int32(ts.Nanosecond())},
+		Value: &metrics.MetricPoint_GaugeValue{
+			GaugeValue: &metrics.GaugeValue{
+				Value: &metrics.GaugeValue_DoubleValue{DoubleValue: value},
+			},
+		},
+	}
+}
+
+func sortedMetrics(series map[groupKey]*metrics.Metric) []*metrics.Metric {
+	groups := make([]groupKey, 0, len(series))
+	for g := range series {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].service != groups[j].service {
+			return groups[i].service < groups[j].service
+		}
+		return groups[i].operation < groups[j].operation
+	})
+	result := make([]*metrics.Metric, len(groups))
+	for i, g := range groups {
+		result[i] = series[g]
+	}
+	return result
+}
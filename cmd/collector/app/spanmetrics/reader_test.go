@@ -0,0 +1,183 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanmetrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
+	"github.com/jaegertracing/jaeger/proto-gen/api_v2/metrics"
+	"github.com/jaegertracing/jaeger/storage/metricsstore"
+)
+
+// newTestAggregator returns an aggregator with two snapshots taken at start and start+10s.
+func newTestAggregator(t *testing.T, start time.Time) *Aggregator {
+	a, err := NewAggregator(Options{LatencyBuckets: []float64{10, 100}, Resolution: time.Hour})
+	require.NoError(t, err)
+	t.Cleanup(func() { a.Close() })
+
+	now := start
+	a.now = func() time.Time { return now }
+	a.recordSnapshot()
+
+	for i := 0; i < 10; i++ {
+		a.ProcessSpan(makeSpan("frontend", "GET /", "server", 5*time.Millisecond, i < 2), "")
+		a.ProcessSpan(makeSpan("frontend", "GET /api", "server", 50*time.Millisecond, false), "")
+		a.ProcessSpan(makeSpan("frontend", "HTTP GET", "client", 50*time.Millisecond, false), "")
+		a.ProcessSpan(makeSpan("backend", "query", "server", 50*time.Millisecond, true), "")
+		if i < 5 {
+			a.ProcessSpan(makeSpan("frontend", "GET /", "server", 5*time.Millisecond, false), "acme")
+		}
+	}
+	now = start.Add(10 * time.Second)
+	a.recordSnapshot()
+	return a
+}
+
+func baseParams(end time.Time, groupByOperation bool) metricsstore.BaseQueryParameters {
+	lookback := 10 * time.Second
+	step := 10 * time.Second
+	ratePer := 10 * time.Second
+	return metricsstore.BaseQueryParameters{
+		ServiceNames:     []string{"frontend"},
+		GroupByOperation: groupByOperation,
+		EndTime:          &end,
+		Lookback:         &lookback,
+		Step:             &step,
+		RatePer:          &ratePer,
+		SpanKinds:        []string{metrics.SpanKind_SPAN_KIND_SERVER.String()},
+	}
+}
+
+func pointValues(m *metrics.Metric) []float64 {
+	values := make([]float64, len(m.MetricPoints))
+	for i, p := range m.MetricPoints {
+		values[i] = p.GetGaugeValue().GetDoubleValue()
+	}
+	return values
+}
+
+func TestGetCallRates(t *testing.T) {
+	start := time.Unix(1000, 0)
+	a := newTestAggregator(t, start)
+
+	mf, err := a.GetCallRates(context.Background(), &metricsstore.CallRateQueryParameters{
+		BaseQueryParameters: baseParams(start.Add(10*time.Second), false),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "service_call_rate", mf.Name)
+	require.Len(t, mf.Metrics, 1)
+	assert.Equal(t, []*metrics.Label{{Name: "service_name", Value: "frontend"}}, mf.Metrics[0].Labels)
+	// the first data point has no previous snapshot to compute the rate from
+	assert.Equal(t, []float64{2}, pointValues(mf.Metrics[0]))
+	assert.Equal(t, int64(1010), mf.Metrics[0].MetricPoints[0].Timestamp.Seconds)
+
+	mf, err = a.GetCallRates(context.Background(), &metricsstore.CallRateQueryParameters{
+		BaseQueryParameters: baseParams(start.Add(10*time.Second), true),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "service_operation_call_rate", mf.Name)
+	require.Len(t, mf.Metrics, 2)
+	assert.Equal(t, "GET /", mf.Metrics[0].Labels[1].Value)
+	assert.Equal(t, []float64{1}, pointValues(mf.Metrics[0]))
+	assert.Equal(t, "GET /api", mf.Metrics[1].Labels[1].Value)
+
+	// the metrics of a tenant are only read on behalf of the tenant
+	mf, err = a.GetCallRates(tenancy.WithTenant(context.Background(), "acme"), &metricsstore.CallRateQueryParameters{
+		BaseQueryParameters: baseParams(start.Add(10*time.Second), false),
+	})
+	require.NoError(t, err)
+	require.Len(t, mf.Metrics, 1)
+	assert.Equal(t, []float64{0.5}, pointValues(mf.Metrics[0]))
+}
+
+func TestGetErrorRates(t *testing.T) {
+	start := time.Unix(1000, 0)
+	a := newTestAggregator(t, start)
+
+	mf, err := a.GetErrorRates(context.Background(), &metricsstore.ErrorRateQueryParameters{
+		BaseQueryParameters: baseParams(start.Add(10*time.Second), false),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "service_error_rate", mf.Name)
+	require.Len(t, mf.Metrics, 1)
+	assert.Equal(t, []float64{0.1}, pointValues(mf.Metrics[0]))
+
+	// no calls within the window
+	mf, err = a.GetErrorRates(context.Background(), &metricsstore.ErrorRateQueryParameters{
+		BaseQueryParameters: baseParams(start.Add(time.Minute), false),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, mf.Metrics)
+}
+
+func TestGetLatencies(t *testing.T) {
+	start := time.Unix(1000, 0)
+	a := newTestAggregator(t, start)
+
+	mf, err := a.GetLatencies(context.Background(), &metricsstore.LatenciesQueryParameters{
+		BaseQueryParameters: baseParams(start.Add(10*time.Second), false),
+		Quantile:            0.95,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "service_latencies", mf.Name)
+	require.Len(t, mf.Metrics, 1)
+	// 10 spans in (0,10] and 10 spans in (10,100], the 95th percentile is at rank 19
+	assert.Equal(t, []float64{91}, pointValues(mf.Metrics[0]))
+}
+
+func TestQueryInvalidParameters(t *testing.T) {
+	a, err := NewAggregator(Options{})
+	require.NoError(t, err)
+	defer a.Close()
+
+	_, err = a.GetLatencies(context.Background(), &metricsstore.LatenciesQueryParameters{})
+	assert.Equal(t, errMissingParameters, err)
+
+	params := baseParams(time.Now(), false)
+	params.RatePer = nil
+	_, err = a.GetCallRates(context.Background(), &metricsstore.CallRateQueryParameters{BaseQueryParameters: params})
+	assert.Error(t, err)
+
+	step, err := a.GetMinStepDuration(context.Background(), &metricsstore.MinStepDurationQueryParameters{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultResolution, step)
+}
+
+func TestBucketQuantile(t *testing.T) {
+	bounds := []float64{10, 100}
+	tests := []struct {
+		q        float64
+		buckets  []float64
+		expected float64
+		ok       bool
+	}{
+		{q: 0.5, buckets: []float64{0, 0, 0}},
+		{q: 1.5, buckets: []float64{1, 0, 0}},
+		{q: 0.5, buckets: []float64{2, 0, 0}, expected: 5, ok: true},
+		{q: 0.5, buckets: []float64{0, 2, 0}, expected: 55, ok: true},
+		{q: 0.99, buckets: []float64{1, 0, 1}, expected: 100, ok: true},
+	}
+	for _, test := range tests {
+		v, ok := bucketQuantile(test.q, bounds, test.buckets)
+		assert.Equal(t, test.ok, ok)
+		assert.InDelta(t, test.expected, v, 0.0001)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package throughput
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// Route is the route of the throughput endpoint on the admin server.
+	Route = "/throughput"
+
+	defaultTop = 10
+	maxTop     = 1000
+)
+
+// QueueReporter reports the state of the queue of the span processor.
+type QueueReporter interface {
+	QueueLength() int
+	QueueCapacity() int
+}
+
+// QueueStats is the state of the queue of the span processor.
+type QueueStats struct {
+	Length   int `json:"length"`
+	Capacity int `json:"capacity"`
+}
+
+// Response is the body of the throughput endpoint.
+type Response struct {
+	Windows map[string]WindowStats `json:"windows"`
+	Queue   *QueueStats            `json:"queue,omitempty"`
+}
+
+// NewHTTPHandler creates the handler of the throughput endpoint. It accepts the query parameters
+// top, the number of services and operations returned for each window, 10 by default, and sort,
+// one of spans (the default), bytes or dropped.
+func NewHTTPHandler(tracker *Tracker, queue QueueReporter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		n := defaultTop
+		if param := r.URL.Query().Get("top"); param != "" {
+			var err error
+			if n, err = strconv.Atoi(param); err != nil || n <= 0 || n > maxTop {
+				http.Error(w, fmt.Sprintf("top must be an integer between 1 and %d", maxTop), http.StatusBadRequest)
+				return
+			}
+		}
+		sortBy := SortBy(r.URL.Query().Get("sort"))
+		switch sortBy {
+		case "":
+			sortBy = SortBySpans
+		case SortBySpans, SortByBytes, SortByDropped:
+		default:
+			http.Error(w, fmt.Sprintf("sort must be one of %s, %s or %s", SortBySpans, SortByBytes, SortByDropped), http.StatusBadRequest)
+			return
+		}
+		response := Response{Windows: tracker.Stats(n, sortBy)}
+		if queue != nil {
+			response.Queue = &QueueStats{Length: queue.QueueLength(), Capacity: queue.QueueCapacity()}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	})
+}
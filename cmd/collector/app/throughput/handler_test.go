@@ -0,0 +1,93 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package throughput
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+type fakeQueue struct{}
+
+func (fakeQueue) QueueLength() int {
+	return 3
+}
+
+func (fakeQueue) QueueCapacity() int {
+	return 100
+}
+
+func TestHTTPHandler(t *testing.T) {
+	tracker, _ := newTestTracker(Options{})
+	tracker.RecordSpans([]*model.Span{makeSpan("a", "op"), makeSpan("b", "op"), makeSpan("b", "op")})
+	tracker.RecordDropped(makeSpan("a", "op"))
+	server := httptest.NewServer(NewHTTPHandler(tracker, fakeQueue{}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		query    string
+		status   int
+		services []string
+	}{
+		{name: "default", query: "", status: http.StatusOK, services: []string{"b", "a"}},
+		{name: "top", query: "?top=1", status: http.StatusOK, services: []string{"b"}},
+		{name: "sort by dropped", query: "?sort=dropped", status: http.StatusOK, services: []string{"a"}},
+		{name: "invalid top", query: "?top=x", status: http.StatusBadRequest},
+		{name: "top too large", query: "?top=1001", status: http.StatusBadRequest},
+		{name: "invalid sort", query: "?sort=latency", status: http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := http.Get(server.URL + test.query)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, test.status, resp.StatusCode)
+			if test.status != http.StatusOK {
+				return
+			}
+			assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+			var response Response
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+			assert.Equal(t, &QueueStats{Length: 3, Capacity: 100}, response.Queue)
+			require.Len(t, response.Windows, len(Windows))
+			assert.Equal(t, test.services, serviceNames(response.Windows["1m"].Services))
+		})
+	}
+}
+
+func TestHTTPHandlerMethodNotAllowed(t *testing.T) {
+	tracker, _ := newTestTracker(Options{})
+	w := httptest.NewRecorder()
+	NewHTTPHandler(tracker, nil).ServeHTTP(w, httptest.NewRequest(http.MethodPost, Route, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestHTTPHandlerWithoutQueue(t *testing.T) {
+	tracker, _ := newTestTracker(Options{})
+	w := httptest.NewRecorder()
+	NewHTTPHandler(tracker, nil).ServeHTTP(w, httptest.NewRequest(http.MethodGet, Route, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var response Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Nil(t, response.Queue)
+}
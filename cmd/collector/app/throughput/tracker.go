@@ -0,0 +1,339 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package throughput
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+const (
+	// DefaultMaxServices is the default max number of services tracked.
+	DefaultMaxServices = 1000
+	// DefaultMaxOperations is the default max number of operations tracked, over all services.
+	DefaultMaxOperations = 10000
+
+	// OtherServices is the service the spans are counted for when the max number of services is reached.
+	OtherServices = "other-services"
+	// OtherOperations is the operation the spans are counted for when the max number of operations is reached.
+	OtherOperations = "other-operations"
+
+	// 1s buckets cover the 10s window, 10s buckets cover the 1m and 5m windows.
+	fineBucketSize     = 1
+	fineBuckets        = 10
+	coarseBucketSize   = 10
+	coarseBuckets      = 31
+	maxWindow          = 300
+	evictionPeriodSecs = 10
+)
+
+// Windows are the sliding windows over which the rates are computed.
+var Windows = []struct {
+	Name    string
+	Seconds int64
+}{
+	{Name: "10s", Seconds: 10},
+	{Name: "1m", Seconds: 60},
+	{Name: "5m", Seconds: 300},
+}
+
+// Options holds the configuration of the throughput tracker.
+type Options struct {
+	// Enabled determines if the throughput of the collector is tracked
+	Enabled bool
+	// MaxServices caps the number of services tracked, the spans of further services are counted as OtherServices
+	MaxServices int
+	// MaxOperations caps the number of operations tracked, the spans of further operations are counted as OtherOperations
+	MaxOperations int
+}
+
+type counts struct {
+	spans   int64
+	bytes   int64
+	dropped int64
+}
+
+func (c *counts) add(o counts) {
+	c.spans += o.spans
+	c.bytes += o.bytes
+	c.dropped += o.dropped
+}
+
+type bucket struct {
+	index int64
+	counts
+}
+
+// series holds the counts of a service or operation in rings of buckets.
+type series struct {
+	fine   [fineBuckets]bucket
+	coarse [coarseBuckets]bucket
+	last   int64 // the last second with counts
+}
+
+func addToRing(ring []bucket, size, now int64, c counts) {
+	index := now / size
+	b := &ring[index%int64(len(ring))]
+	if b.index != index {
+		*b = bucket{index: index}
+	}
+	b.add(c)
+}
+
+func (s *series) add(now int64, c counts) {
+	addToRing(s.fine[:], fineBucketSize, now, c)
+	addToRing(s.coarse[:], coarseBucketSize, now, c)
+	s.last = now
+}
+
+// sum returns the counts over the window ending at now, and the number of seconds they cover.
+func (s *series) sum(now, window int64) (counts, int64) {
+	ring, size := s.coarse[:], int64(coarseBucketSize)
+	if window <= fineBuckets*fineBucketSize {
+		ring, size = s.fine[:], fineBucketSize
+	}
+	first := (now - window + 1) / size
+	var total counts
+	for _, b := range ring {
+		if b.index >= first && b.index <= now/size {
+			total.add(b.counts)
+		}
+	}
+	return total, now - first*size + 1
+}
+
+type operationKey struct {
+	service   string
+	operation string
+}
+
+// Tracker counts the spans, bytes and dropped spans per service and operation over sliding windows,
+// with bounded memory: about 1.3KB per service and operation.
+type Tracker struct {
+	options Options
+	now     func() time.Time
+	start   int64
+
+	lock       sync.Mutex
+	total      series
+	services   map[string]*series
+	operations map[operationKey]*series
+	lastEvict  int64
+}
+
+// NewTracker creates a Tracker.
+func NewTracker(options Options) *Tracker {
+	if options.MaxServices <= 0 {
+		options.MaxServices = DefaultMaxServices
+	}
+	if options.MaxOperations <= 0 {
+		options.MaxOperations = DefaultMaxOperations
+	}
+	t := &Tracker{
+		options:    options,
+		now:        time.Now,
+		services:   make(map[string]*series),
+		operations: make(map[operationKey]*series),
+	}
+	t.start = t.now().Unix()
+	return t
+}
+
+// RecordSpans counts the received spans.
+func (t *Tracker) RecordSpans(spans []*model.Span) {
+	now := t.now().Unix()
+	// the sizes are computed before taking the lock, as they walk the whole span
+	// and would otherwise serialize the handlers of concurrent batches
+	sizes := make([]int64, len(spans))
+	for i, span := range spans {
+		sizes[i] = int64(span.Size())
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for i, span := range spans {
+		t.record(now, span, counts{spans: 1, bytes: sizes[i]})
+	}
+}
+
+// RecordDropped counts a span dropped because the queue was full or the span was shed.
+func (t *Tracker) RecordDropped(span *model.Span) {
+	now := t.now().Unix()
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.record(now, span, counts{dropped: 1})
+}
+
+func (t *Tracker) record(now int64, span *model.Span, c counts) {
+	service := ""
+	if span.Process != nil {
+		service = span.Process.ServiceName
+	}
+	t.total.add(now, c)
+	svc, ok := t.services[service]
+	if !ok {
+		if len(t.services) >= t.options.MaxServices {
+			t.evictStale(now)
+		}
+		if len(t.services) >= t.options.MaxServices {
+			service = OtherServices
+		}
+		if svc, ok = t.services[service]; !ok {
+			svc = &series{}
+			t.services[service] = svc
+		}
+	}
+	svc.add(now, c)
+	key := operationKey{service: service, operation: span.OperationName}
+	op, ok := t.operations[key]
+	if !ok {
+		if len(t.operations) >= t.options.MaxOperations {
+			t.evictStale(now)
+		}
+		if len(t.operations) >= t.options.MaxOperations {
+			key.operation = OtherOperations
+		}
+		if op, ok = t.operations[key]; !ok {
+			op = &series{}
+			t.operations[key] = op
+		}
+	}
+	op.add(now, c)
+}
+
+// evictStale removes the series without counts in the largest window, at most once every few seconds.
+func (t *Tracker) evictStale(now int64) {
+	if now-t.lastEvict < evictionPeriodSecs {
+		return
+	}
+	t.lastEvict = now
+	for service, s := range t.services {
+		if now-s.last >= maxWindow+coarseBucketSize {
+			delete(t.services, service)
+		}
+	}
+	for key, s := range t.operations {
+		if now-s.last >= maxWindow+coarseBucketSize {
+			delete(t.operations, key)
+		}
+	}
+}
+
+// Rate is the throughput of a service, an operation or of the collector over a window.
+type Rate struct {
+	Service       string  `json:"service,omitempty"`
+	Operation     string  `json:"operation,omitempty"`
+	Spans         int64   `json:"spans"`
+	Bytes         int64   `json:"bytes"`
+	Dropped       int64   `json:"dropped"`
+	SpansPerSec   float64 `json:"spans_per_sec"`
+	BytesPerSec   float64 `json:"bytes_per_sec"`
+	DroppedPerSec float64 `json:"dropped_per_sec"`
+}
+
+// WindowStats is the throughput over a window, with the top services and operations.
+type WindowStats struct {
+	Total      Rate   `json:"total"`
+	Services   []Rate `json:"services"`
+	Operations []Rate `json:"operations"`
+}
+
+// SortBy is the criteria of the top services and operations.
+type SortBy string
+
+const (
+	// SortBySpans ranks the services and operations by spans per second.
+	SortBySpans SortBy = "spans"
+	// SortByBytes ranks the services and operations by bytes per second.
+	SortByBytes SortBy = "bytes"
+	// SortByDropped ranks the services and operations by dropped spans per second.
+	SortByDropped SortBy = "dropped"
+)
+
+// Stats returns the throughput over each window, with the top n services and operations.
+func (t *Tracker) Stats(n int, sortBy SortBy) map[string]WindowStats {
+	now := t.now().Unix()
+	uptime := now - t.start + 1
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	stats := make(map[string]WindowStats, len(Windows))
+	for _, w := range Windows {
+		rate := func(s *series) Rate {
+			c, seconds := s.sum(now, w.Seconds)
+			if seconds > uptime {
+				seconds = uptime
+			}
+			return Rate{
+				Spans:         c.spans,
+				Bytes:         c.bytes,
+				Dropped:       c.dropped,
+				SpansPerSec:   float64(c.spans) / float64(seconds),
+				BytesPerSec:   float64(c.bytes) / float64(seconds),
+				DroppedPerSec: float64(c.dropped) / float64(seconds),
+			}
+		}
+		services := make([]Rate, 0, len(t.services))
+		for service, s := range t.services {
+			r := rate(s)
+			r.Service = service
+			services = append(services, r)
+		}
+		operations := make([]Rate, 0, len(t.operations))
+		for key, s := range t.operations {
+			r := rate(s)
+			r.Service, r.Operation = key.service, key.operation
+			operations = append(operations, r)
+		}
+		stats[w.Name] = WindowStats{
+			Total:      rate(&t.total),
+			Services:   top(services, n, sortBy),
+			Operations: top(operations, n, sortBy),
+		}
+	}
+	return stats
+}
+
+// top returns the n highest rates that are not zero.
+func top(rates []Rate, n int, sortBy SortBy) []Rate {
+	value := func(r Rate) float64 {
+		switch sortBy {
+		case SortByBytes:
+			return r.BytesPerSec
+		case SortByDropped:
+			return r.DroppedPerSec
+		}
+		return r.SpansPerSec
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		vi, vj := value(rates[i]), value(rates[j])
+		if vi != vj {
+			return vi > vj
+		}
+		if rates[i].Service != rates[j].Service {
+			return rates[i].Service < rates[j].Service
+		}
+		return rates[i].Operation < rates[j].Operation
+	})
+	result := make([]Rate, 0, n)
+	for _, r := range rates {
+		if len(result) == n || value(r) == 0 {
+			break
+		}
+		result = append(result, r)
+	}
+	return result
+}
@@ -0,0 +1,209 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package throughput
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestTracker(options Options) (*Tracker, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1000000, 0)}
+	t := NewTracker(options)
+	t.now = clock.Now
+	t.start = clock.now.Unix()
+	return t, clock
+}
+
+func makeSpan(service, operation string) *model.Span {
+	return &model.Span{
+		OperationName: operation,
+		Process:       &model.Process{ServiceName: service},
+	}
+}
+
+func TestTrackerRates(t *testing.T) {
+	tracker, clock := newTestTracker(Options{})
+	// 2 spans per second for 60s for frontend, then 10 spans per second for 10s for backend
+	for i := 0; i < 60; i++ {
+		tracker.RecordSpans([]*model.Span{makeSpan("frontend", "GET"), makeSpan("frontend", "POST")})
+		clock.Advance(time.Second)
+	}
+	for i := 0; i < 10; i++ {
+		spans := make([]*model.Span, 10)
+		for j := range spans {
+			spans[j] = makeSpan("backend", "query")
+		}
+		tracker.RecordSpans(spans)
+		clock.Advance(time.Second)
+	}
+	clock.Advance(-time.Second)
+
+	stats := tracker.Stats(10, SortBySpans)
+	require.Len(t, stats, len(Windows))
+
+	last10s := stats["10s"]
+	assert.Equal(t, int64(100), last10s.Total.Spans)
+	assert.Equal(t, 10.0, last10s.Total.SpansPerSec)
+	require.Len(t, last10s.Services, 1)
+	assert.Equal(t, "backend", last10s.Services[0].Service)
+	require.Len(t, last10s.Operations, 1)
+	assert.Equal(t, "query", last10s.Operations[0].Operation)
+
+	last1m := stats["1m"]
+	assert.Equal(t, int64(200), last1m.Total.Spans)
+	require.Len(t, last1m.Services, 2)
+	assert.Equal(t, "backend", last1m.Services[0].Service)
+	assert.Equal(t, int64(100), last1m.Services[0].Spans)
+	assert.Equal(t, "frontend", last1m.Services[1].Service)
+	assert.Equal(t, int64(100), last1m.Services[1].Spans)
+	require.Len(t, last1m.Operations, 3)
+	assert.Equal(t, "frontend", last1m.Operations[1].Service)
+	assert.Equal(t, "GET", last1m.Operations[1].Operation)
+	assert.Equal(t, "POST", last1m.Operations[2].Operation)
+
+	// the 5m window is limited to the uptime of the tracker
+	last5m := stats["5m"]
+	assert.Equal(t, int64(220), last5m.Total.Spans)
+	assert.InDelta(t, 220.0/70.0, last5m.Total.SpansPerSec, 0.001)
+}
+
+func TestTrackerBytesAndDropped(t *testing.T) {
+	tracker, _ := newTestTracker(Options{})
+	small, large := makeSpan("small", "op"), makeSpan("large", "op")
+	large.Tags = model.KeyValues{model.String("payload", strings.Repeat("x", 1000))}
+	tracker.RecordSpans([]*model.Span{small, small, large})
+	tracker.RecordDropped(small)
+	tracker.RecordDropped(small)
+	tracker.RecordDropped(large)
+
+	stats := tracker.Stats(10, SortByBytes)["10s"]
+	assert.Equal(t, int64(3), stats.Total.Dropped)
+	assert.Equal(t, int64(2*small.Size()+large.Size()), stats.Total.Bytes)
+	require.Len(t, stats.Services, 2)
+	assert.Equal(t, "large", stats.Services[0].Service)
+
+	stats = tracker.Stats(10, SortByDropped)["10s"]
+	require.Len(t, stats.Services, 2)
+	assert.Equal(t, "small", stats.Services[0].Service)
+	assert.Equal(t, int64(2), stats.Services[0].Dropped)
+}
+
+func TestTrackerConcurrentRecord(t *testing.T) {
+	tracker, _ := newTestTracker(Options{})
+	span := makeSpan("frontend", "GET")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				tracker.RecordSpans([]*model.Span{span, span})
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := tracker.Stats(10, SortBySpans)["10s"]
+	assert.Equal(t, int64(2000), stats.Total.Spans)
+	assert.Equal(t, int64(2000*span.Size()), stats.Total.Bytes)
+}
+
+func TestTrackerTopN(t *testing.T) {
+	tracker, _ := newTestTracker(Options{})
+	for i, service := range []string{"a", "b", "c"} {
+		for j := 0; j <= i; j++ {
+			tracker.RecordSpans([]*model.Span{makeSpan(service, "op")})
+		}
+	}
+	stats := tracker.Stats(2, SortBySpans)["10s"]
+	require.Len(t, stats.Services, 2)
+	assert.Equal(t, "c", stats.Services[0].Service)
+	assert.Equal(t, "b", stats.Services[1].Service)
+	assert.Equal(t, int64(6), stats.Total.Spans)
+}
+
+func TestTrackerSlidingWindow(t *testing.T) {
+	tracker, clock := newTestTracker(Options{})
+	tracker.RecordSpans([]*model.Span{makeSpan("svc", "op")})
+
+	clock.Advance(10 * time.Second)
+	stats := tracker.Stats(10, SortBySpans)
+	assert.Equal(t, int64(0), stats["10s"].Total.Spans)
+	assert.Empty(t, stats["10s"].Services)
+	assert.Equal(t, int64(1), stats["1m"].Total.Spans)
+
+	clock.Advance(5 * time.Minute)
+	stats = tracker.Stats(10, SortBySpans)
+	assert.Equal(t, int64(0), stats["5m"].Total.Spans)
+}
+
+func TestTrackerLimits(t *testing.T) {
+	tracker, clock := newTestTracker(Options{MaxServices: 2, MaxOperations: 2})
+	tracker.RecordSpans([]*model.Span{
+		makeSpan("a", "op1"),
+		makeSpan("a", "op2"),
+		makeSpan("b", "op1"),
+		makeSpan("c", "op1"),
+		{OperationName: "no-process"},
+	})
+	stats := tracker.Stats(10, SortBySpans)["10s"]
+	assert.Equal(t, int64(5), stats.Total.Spans)
+	assert.ElementsMatch(t, []string{"a", "b", OtherServices}, serviceNames(stats.Services))
+	assert.Len(t, tracker.services, 3)
+	assert.ElementsMatch(t, []string{"op1", "op2", OtherOperations, OtherOperations}, operationNames(stats.Operations))
+
+	// once stale, the services and operations are evicted to make room for new ones
+	clock.Advance(maxWindow*time.Second + coarseBucketSize*time.Second)
+	tracker.RecordSpans([]*model.Span{makeSpan("d", "op3")})
+	stats = tracker.Stats(10, SortBySpans)["10s"]
+	assert.Equal(t, []string{"d"}, serviceNames(stats.Services))
+	assert.Equal(t, []string{"op3"}, operationNames(stats.Operations))
+}
+
+func serviceNames(rates []Rate) []string {
+	var names []string
+	for _, r := range rates {
+		names = append(names, r.Service)
+	}
+	return names
+}
+
+func operationNames(rates []Rate) []string {
+	var names []string
+	for _, r := range rates {
+		names = append(names, r.Operation)
+	}
+	return names
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/uber/jaeger-lib/metrics"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+// quarantinedSpan is a line of the quarantine file, the span is in the same JSON format as the spans in Kafka.
+type quarantinedSpan struct {
+	Defects []Defect        `json:"defects"`
+	Span    json.RawMessage `json:"span"`
+}
+
+// rotatedQuarantineSuffix is appended to the name of the quarantine file when it is rotated.
+const rotatedQuarantineSuffix = ".1"
+
+type quarantineMetrics struct {
+	// Number of malformed spans dropped instead of being quarantined, because they are larger
+	// than the max size of the quarantine file or the file could not be rotated
+	Dropped metrics.Counter `metric:"validation.quarantine.dropped"`
+	// Number of rotations of the quarantine file
+	Rotations metrics.Counter `metric:"validation.quarantine.rotations"`
+}
+
+// quarantineFile appends malformed spans to a file for later inspection. When the file would
+// grow past its max size, it is rotated: it replaces the previous rotated file, so that the
+// quarantine never uses more than twice the max size on disk.
+type quarantineFile struct {
+	marshaler *jsonpb.Marshaler
+	path      string
+	maxSize   int64
+	metrics   quarantineMetrics
+	lock      sync.Mutex
+	file      *os.File
+	size      int64
+}
+
+func openQuarantineFile(path string, maxSize int64, metricsFactory metrics.Factory) (*quarantineFile, error) {
+	path = filepath.Clean(path)
+	file, err := openAppendOnly(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the quarantine file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to open the quarantine file: %w", err)
+	}
+	q := &quarantineFile{
+		marshaler: &jsonpb.Marshaler{},
+		path:      path,
+		maxSize:   maxSize,
+		file:      file,
+		size:      info.Size(),
+	}
+	metrics.MustInit(&q.metrics, metricsFactory, nil)
+	return q, nil
+}
+
+func openAppendOnly(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+}
+
+func (q *quarantineFile) write(span *model.Span, defects []Defect) error {
+	var spanJSON bytes.Buffer
+	if err := q.marshaler.Marshal(&spanJSON, span); err != nil {
+		return err
+	}
+	line, err := json.Marshal(quarantinedSpan{Defects: defects, Span: spanJSON.Bytes()})
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if int64(len(line)) > q.maxSize {
+		q.metrics.Dropped.Inc(1)
+		return fmt.Errorf("the malformed span takes %d bytes, more than the max size of the quarantine file", len(line))
+	}
+	if q.size+int64(len(line)) > q.maxSize {
+		if err := q.rotate(); err != nil {
+			q.metrics.Dropped.Inc(1)
+			return fmt.Errorf("failed to rotate the quarantine file: %w", err)
+		}
+	}
+	n, err := q.file.Write(line)
+	q.size += int64(n)
+	return err
+}
+
+func (q *quarantineFile) rotate() error {
+	if err := q.file.Close(); err != nil {
+		return err
+	}
+	renameErr := os.Rename(q.path, q.path+rotatedQuarantineSuffix)
+	// the file is reopened even if it could not be renamed, the next write retries the rotation
+	file, err := openAppendOnly(q.path)
+	if err != nil {
+		return err
+	}
+	q.file = file
+	if renameErr != nil {
+		return renameErr
+	}
+	q.size = 0
+	q.metrics.Rotations.Inc(1)
+	return nil
+}
+func (q *quarantineFile) close() error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.file.Close()
+}
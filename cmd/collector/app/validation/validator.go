@@ -0,0 +1,266 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+// Defect is a kind of malformed span.
+type Defect string
+
+const (
+	// DefectZeroTraceID is reported for spans without a trace ID, it cannot be repaired.
+	DefectZeroTraceID Defect = "zero-trace-id"
+	// DefectZeroSpanID is reported for spans without a span ID, it cannot be repaired.
+	DefectZeroSpanID Defect = "zero-span-id"
+	// DefectNegativeDuration is reported for spans with a negative duration, repaired by setting it to zero.
+	DefectNegativeDuration Defect = "negative-duration"
+	// DefectFutureStartTime is reported for spans starting later than now plus the max clock skew,
+	// repaired by setting the start time to now.
+	DefectFutureStartTime Defect = "future-start-time"
+	// DefectSelfReference is reported for spans referencing themselves, repaired by removing the reference.
+	DefectSelfReference Defect = "self-reference"
+)
+
+// Action is what the validator does with malformed spans.
+type Action string
+
+const (
+	// ActionRepair repairs the defects that can be repaired. The spans with a defect that
+	// cannot be repaired are quarantined if a quarantine file is configured, and rejected otherwise.
+	ActionRepair Action = "repair"
+	// ActionReject drops the malformed spans.
+	ActionReject Action = "reject"
+	// ActionQuarantine writes the malformed spans to the quarantine file instead of the storage.
+	ActionQuarantine Action = "quarantine"
+)
+
+const (
+	// DefaultAction is the default action for malformed spans.
+	DefaultAction = ActionRepair
+	// DefaultMaxClockSkew is the default max time a span may start in the future.
+	DefaultMaxClockSkew = time.Hour
+	// DefaultQuarantineMaxSize is the default size in bytes after which the quarantine file is rotated.
+	DefaultQuarantineMaxSize = 100 << 20
+
+	outcomeRepaired    = "repaired"
+	outcomeRejected    = "rejected"
+	outcomeQuarantined = "quarantined"
+
+	// maxServiceNames caps the number of services in the metrics, like the span processor metrics
+	maxServiceNames = 4000
+	otherServices   = "other-services"
+)
+
+var repairable = map[Defect]bool{
+	DefectNegativeDuration: true,
+	DefectFutureStartTime:  true,
+	DefectSelfReference:    true,
+}
+
+// Options holds the configuration of the span validation.
+type Options struct {
+	// Enabled turns on the validation of spans
+	Enabled bool
+	// Action is what is done with malformed spans
+	Action Action
+	// MaxClockSkew is the max time a span may start in the future
+	MaxClockSkew time.Duration
+	// QuarantineFile is the path to the file where quarantined spans are appended as JSON lines
+	QuarantineFile string
+	// QuarantineMaxSize is the size in bytes after which the quarantine file is rotated
+	QuarantineMaxSize int64
+	// MetricsFactory is used to report the defects per type, service and outcome
+	MetricsFactory metrics.Factory
+}
+
+type defectKey struct {
+	defect  Defect
+	service string
+	outcome string
+}
+
+// Validator detects malformed spans, and repairs, rejects or quarantines them.
+type Validator struct {
+	options    Options
+	logger     *zap.Logger
+	now        func() time.Time
+	quarantine *quarantineFile
+
+	lock     sync.Mutex
+	counters map[defectKey]metrics.Counter
+	services map[string]struct{}
+}
+
+// NewValidator creates a Validator, opening the quarantine file if configured.
+func NewValidator(options Options, logger *zap.Logger) (*Validator, error) {
+	if options.Action == "" {
+		options.Action = DefaultAction
+	}
+	if options.MaxClockSkew <= 0 {
+		options.MaxClockSkew = DefaultMaxClockSkew
+	}
+	if options.QuarantineMaxSize <= 0 {
+		options.QuarantineMaxSize = DefaultQuarantineMaxSize
+	}
+	if options.MetricsFactory == nil {
+		options.MetricsFactory = metrics.NullFactory
+	}
+	switch options.Action {
+	case ActionRepair, ActionReject:
+	case ActionQuarantine:
+		if options.QuarantineFile == "" {
+			return nil, fmt.Errorf("the quarantine action requires a quarantine file")
+		}
+	default:
+		return nil, fmt.Errorf("unknown action for malformed spans %q, expected one of %s, %s or %s", options.Action, ActionRepair, ActionReject, ActionQuarantine)
+	}
+	v := &Validator{
+		options:  options,
+		logger:   logger,
+		now:      time.Now,
+		counters: make(map[defectKey]metrics.Counter),
+		services: make(map[string]struct{}),
+	}
+	if options.QuarantineFile != "" {
+		q, err := openQuarantineFile(options.QuarantineFile, options.QuarantineMaxSize, options.MetricsFactory)
+		if err != nil {
+			return nil, err
+		}
+		v.quarantine = q
+	}
+	return v, nil
+}
+
+// Defects returns the defects of the span.
+func (v *Validator) Defects(span *model.Span) []Defect {
+	var defects []Defect
+	if span.TraceID == (model.TraceID{}) {
+		defects = append(defects, DefectZeroTraceID)
+	}
+	if span.SpanID == 0 {
+		defects = append(defects, DefectZeroSpanID)
+	}
+	if span.Duration < 0 {
+		defects = append(defects, DefectNegativeDuration)
+	}
+	if span.StartTime.After(v.now().Add(v.options.MaxClockSkew)) {
+		defects = append(defects, DefectFutureStartTime)
+	}
+	for _, ref := range span.References {
+		if ref.TraceID == span.TraceID && ref.SpanID == span.SpanID {
+			defects = append(defects, DefectSelfReference)
+			break
+		}
+	}
+	return defects
+}
+
+// Sanitize validates the span, it returns the span, possibly repaired, or nil if the span
+// is rejected or quarantined. It can be used as a sanitizer of the span processor.
+func (v *Validator) Sanitize(span *model.Span) *model.Span {
+	defects := v.Defects(span)
+	if len(defects) == 0 {
+		return span
+	}
+	switch {
+	case v.options.Action == ActionRepair && allRepairable(defects):
+		v.repair(span, defects)
+		v.count(span, defects, outcomeRepaired)
+		return span
+	case v.options.Action == ActionReject || v.quarantine == nil:
+		v.count(span, defects, outcomeRejected)
+		return nil
+	}
+	if err := v.quarantine.write(span, defects); err != nil {
+		v.logger.Error("failed to quarantine malformed span", zap.Error(err))
+		v.count(span, defects, outcomeRejected)
+		return nil
+	}
+	v.count(span, defects, outcomeQuarantined)
+	return nil
+}
+
+func allRepairable(defects []Defect) bool {
+	for _, d := range defects {
+		if !repairable[d] {
+			return false
+		}
+	}
+	return true
+}
+
+func (v *Validator) repair(span *model.Span, defects []Defect) {
+	for _, d := range defects {
+		switch d {
+		case DefectNegativeDuration:
+			span.Duration = 0
+		case DefectFutureStartTime:
+			span.StartTime = v.now()
+		case DefectSelfReference:
+			refs := span.References[:0]
+			for _, ref := range span.References {
+				if ref.TraceID != span.TraceID || ref.SpanID != span.SpanID {
+					refs = append(refs, ref)
+				}
+			}
+			span.References = refs
+		}
+	}
+}
+
+func (v *Validator) count(span *model.Span, defects []Defect, outcome string) {
+	service := ""
+	if span.Process != nil {
+		service = span.Process.ServiceName
+	}
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if _, ok := v.services[service]; !ok {
+		if len(v.services) >= maxServiceNames {
+			service = otherServices
+		} else {
+			v.services[service] = struct{}{}
+		}
+	}
+	for _, d := range defects {
+		key := defectKey{defect: d, service: service, outcome: outcome}
+		counter, ok := v.counters[key]
+		if !ok {
+			counter = v.options.MetricsFactory.Counter(metrics.Options{
+				Name: "validation.defects",
+				Tags: map[string]string{"defect": string(d), "service": service, "outcome": outcome},
+			})
+			v.counters[key] = counter
+		}
+		counter.Inc(1)
+	}
+}
+
+// Close closes the quarantine file.
+func (v *Validator) Close() error {
+	if v.quarantine == nil {
+		return nil
+	}
+	return v.quarantine.close()
+}
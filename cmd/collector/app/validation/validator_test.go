@@ -0,0 +1,320 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+var now = time.Unix(1000, 0).UTC()
+
+func makeSpan() *model.Span {
+	return &model.Span{
+		TraceID:       model.NewTraceID(1, 2),
+		SpanID:        model.NewSpanID(3),
+		OperationName: "op",
+		StartTime:     now.Add(-time.Second),
+		Duration:      time.Millisecond,
+		References: []model.SpanRef{
+			model.NewChildOfRef(model.NewTraceID(1, 2), model.NewSpanID(1)),
+		},
+		Process: &model.Process{ServiceName: "svc"},
+	}
+}
+
+func newTestValidator(t *testing.T, options Options) *Validator {
+	v, err := NewValidator(options, zap.NewNop())
+	require.NoError(t, err)
+	v.now = func() time.Time { return now }
+	t.Cleanup(func() { assert.NoError(t, v.Close()) })
+	return v
+}
+
+func TestNewValidator(t *testing.T) {
+	v, err := NewValidator(Options{}, zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, ActionRepair, v.options.Action)
+	assert.Equal(t, DefaultMaxClockSkew, v.options.MaxClockSkew)
+	assert.NoError(t, v.Close())
+
+	_, err = NewValidator(Options{Action: "ignore"}, zap.NewNop())
+	assert.EqualError(t, err, `unknown action for malformed spans "ignore", expected one of repair, reject or quarantine`)
+
+	_, err = NewValidator(Options{Action: ActionQuarantine}, zap.NewNop())
+	assert.EqualError(t, err, "the quarantine action requires a quarantine file")
+
+	_, err = NewValidator(Options{Action: ActionQuarantine, QuarantineFile: filepath.Join(t.TempDir(), "missing", "file")}, zap.NewNop())
+	assert.Contains(t, err.Error(), "failed to open the quarantine file")
+}
+
+func TestValidatorDefects(t *testing.T) {
+	tests := []struct {
+		name    string
+		malform func(span *model.Span)
+		defects []Defect
+	}{
+		{name: "valid", malform: func(*model.Span) {}},
+		{
+			name:    "zero trace ID",
+			malform: func(span *model.Span) { span.TraceID = model.TraceID{} },
+			defects: []Defect{DefectZeroTraceID},
+		},
+		{
+			name:    "zero span ID",
+			malform: func(span *model.Span) { span.SpanID = 0 },
+			defects: []Defect{DefectZeroSpanID},
+		},
+		{
+			name:    "negative duration",
+			malform: func(span *model.Span) { span.Duration = -time.Second },
+			defects: []Defect{DefectNegativeDuration},
+		},
+		{
+			name:    "start time within the clock skew",
+			malform: func(span *model.Span) { span.StartTime = now.Add(time.Minute) },
+		},
+		{
+			name:    "future start time",
+			malform: func(span *model.Span) { span.StartTime = now.Add(2 * time.Hour) },
+			defects: []Defect{DefectFutureStartTime},
+		},
+		{
+			name: "self reference",
+			malform: func(span *model.Span) {
+				span.References = append(span.References, model.NewFollowsFromRef(span.TraceID, span.SpanID))
+			},
+			defects: []Defect{DefectSelfReference},
+		},
+		{
+			name: "several defects",
+			malform: func(span *model.Span) {
+				span.SpanID = 0
+				span.Duration = -1
+			},
+			defects: []Defect{DefectZeroSpanID, DefectNegativeDuration},
+		},
+	}
+	v := newTestValidator(t, Options{})
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			span := makeSpan()
+			test.malform(span)
+			assert.Equal(t, test.defects, v.Defects(span))
+		})
+	}
+}
+
+func TestValidatorRepair(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	v := newTestValidator(t, Options{MetricsFactory: mf})
+
+	valid := makeSpan()
+	assert.Same(t, valid, v.Sanitize(valid))
+
+	span := makeSpan()
+	span.Duration = -time.Second
+	span.StartTime = now.Add(2 * time.Hour)
+	span.References = append(span.References, model.NewFollowsFromRef(span.TraceID, span.SpanID))
+	repaired := v.Sanitize(span)
+	require.NotNil(t, repaired)
+	assert.Equal(t, time.Duration(0), repaired.Duration)
+	assert.Equal(t, now, repaired.StartTime)
+	assert.Equal(t, makeSpan().References, repaired.References)
+	assert.Empty(t, v.Defects(repaired))
+
+	// zero IDs cannot be repaired and no quarantine file is configured
+	span = makeSpan()
+	span.TraceID = model.TraceID{}
+	span.Duration = -time.Second
+	assert.Nil(t, v.Sanitize(span))
+
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "validation.defects", Tags: map[string]string{"defect": "negative-duration", "service": "svc", "outcome": "repaired"}, Value: 1},
+		metricstest.ExpectedMetric{Name: "validation.defects", Tags: map[string]string{"defect": "future-start-time", "service": "svc", "outcome": "repaired"}, Value: 1},
+		metricstest.ExpectedMetric{Name: "validation.defects", Tags: map[string]string{"defect": "self-reference", "service": "svc", "outcome": "repaired"}, Value: 1},
+		metricstest.ExpectedMetric{Name: "validation.defects", Tags: map[string]string{"defect": "zero-trace-id", "service": "svc", "outcome": "rejected"}, Value: 1},
+		metricstest.ExpectedMetric{Name: "validation.defects", Tags: map[string]string{"defect": "negative-duration", "service": "svc", "outcome": "rejected"}, Value: 1},
+	)
+}
+
+func TestValidatorReject(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	v := newTestValidator(t, Options{Action: ActionReject, MetricsFactory: mf})
+
+	assert.NotNil(t, v.Sanitize(makeSpan()))
+	span := makeSpan()
+	span.Duration = -time.Second
+	assert.Nil(t, v.Sanitize(span))
+	span = makeSpan()
+	span.Process = nil
+	span.Duration = -time.Second
+	assert.Nil(t, v.Sanitize(span))
+
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "validation.defects", Tags: map[string]string{"defect": "negative-duration", "service": "svc", "outcome": "rejected"}, Value: 1},
+		metricstest.ExpectedMetric{Name: "validation.defects", Tags: map[string]string{"defect": "negative-duration", "service": "", "outcome": "rejected"}, Value: 1},
+	)
+}
+
+func readQuarantine(t *testing.T, file string) []quarantinedSpan {
+	f, err := os.Open(file)
+	require.NoError(t, err)
+	defer f.Close()
+	var lines []quarantinedSpan
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line quarantinedSpan
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	require.NoError(t, scanner.Err())
+	return lines
+}
+
+func TestValidatorQuarantine(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "quarantine.json")
+	mf := metricstest.NewFactory(0)
+	v := newTestValidator(t, Options{Action: ActionQuarantine, QuarantineFile: file, MetricsFactory: mf})
+
+	assert.NotNil(t, v.Sanitize(makeSpan()))
+	malformed := makeSpan()
+	malformed.Duration = -time.Second
+	assert.Nil(t, v.Sanitize(malformed))
+
+	lines := readQuarantine(t, file)
+	require.Len(t, lines, 1)
+	assert.Equal(t, []Defect{DefectNegativeDuration}, lines[0].Defects)
+	var span model.Span
+	require.NoError(t, jsonpb.Unmarshal(strings.NewReader(string(lines[0].Span)), &span))
+	assert.Equal(t, malformed, &span)
+
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "validation.defects", Tags: map[string]string{"defect": "negative-duration", "service": "svc", "outcome": "quarantined"}, Value: 1},
+	)
+}
+
+func TestValidatorRepairQuarantinesUnrepairable(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "quarantine.json")
+	v := newTestValidator(t, Options{QuarantineFile: file})
+
+	span := makeSpan()
+	span.Duration = -time.Second
+	assert.NotNil(t, v.Sanitize(span))
+	span = makeSpan()
+	span.SpanID = 0
+	assert.Nil(t, v.Sanitize(span))
+
+	lines := readQuarantine(t, file)
+	require.Len(t, lines, 1)
+	assert.Equal(t, []Defect{DefectZeroSpanID}, lines[0].Defects)
+}
+
+func TestValidatorQuarantineWriteError(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "quarantine.json")
+	mf := metricstest.NewFactory(0)
+	v, err := NewValidator(Options{Action: ActionQuarantine, QuarantineFile: file, MetricsFactory: mf}, zap.NewNop())
+	require.NoError(t, err)
+	require.NoError(t, v.quarantine.file.Close())
+
+	span := makeSpan()
+	span.SpanID = 0
+	assert.Nil(t, v.Sanitize(span))
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "validation.defects", Tags: map[string]string{"defect": "zero-span-id", "service": "svc", "outcome": "rejected"}, Value: 1},
+	)
+}
+
+func TestValidatorQuarantineRotation(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "quarantine.json")
+	mf := metricstest.NewFactory(0)
+	malformed := makeSpan()
+	malformed.SpanID = 0
+
+	// find out the size of a quarantined span to fit exactly two of them in the file
+	v, err := NewValidator(Options{Action: ActionQuarantine, QuarantineFile: file}, zap.NewNop())
+	require.NoError(t, err)
+	assert.Nil(t, v.Sanitize(malformed))
+	info, err := os.Stat(file)
+	require.NoError(t, err)
+	require.NoError(t, v.Close())
+
+	v = newTestValidator(t, Options{Action: ActionQuarantine, QuarantineFile: file, QuarantineMaxSize: 2 * info.Size(), MetricsFactory: mf})
+	assert.Nil(t, v.Sanitize(malformed))
+	assert.Len(t, readQuarantine(t, file), 2)
+	assert.Nil(t, v.Sanitize(malformed))
+	assert.Len(t, readQuarantine(t, file), 1)
+	assert.Len(t, readQuarantine(t, file+rotatedQuarantineSuffix), 2)
+
+	// spans larger than the max size are dropped
+	tooLarge := makeSpan()
+	tooLarge.SpanID = 0
+	tooLarge.OperationName = strings.Repeat("x", int(2*info.Size()))
+	assert.Nil(t, v.Sanitize(tooLarge))
+	assert.Len(t, readQuarantine(t, file), 1)
+
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "validation.quarantine.rotations", Value: 1},
+		metricstest.ExpectedMetric{Name: "validation.quarantine.dropped", Value: 1},
+		metricstest.ExpectedMetric{Name: "validation.defects", Tags: map[string]string{"defect": "zero-span-id", "service": "svc", "outcome": "quarantined"}, Value: 2},
+		metricstest.ExpectedMetric{Name: "validation.defects", Tags: map[string]string{"defect": "zero-span-id", "service": "svc", "outcome": "rejected"}, Value: 1},
+	)
+}
+
+func TestValidatorQuarantineRotationError(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "quarantine.json")
+	mf := metricstest.NewFactory(0)
+	v := newTestValidator(t, Options{Action: ActionQuarantine, QuarantineFile: file, QuarantineMaxSize: 1000, MetricsFactory: mf})
+	malformed := makeSpan()
+	malformed.SpanID = 0
+	assert.Nil(t, v.Sanitize(malformed))
+	// the rotated file cannot replace a directory
+	require.NoError(t, os.Mkdir(file+rotatedQuarantineSuffix, 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(file+rotatedQuarantineSuffix, "f"), nil, 0600))
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, v.Sanitize(malformed))
+	}
+	dropped, _ := mf.Snapshot()
+	assert.Greater(t, dropped["validation.quarantine.dropped"], int64(0))
+	assert.Equal(t, int64(0), dropped["validation.quarantine.rotations"])
+}
+
+func TestValidatorMaxServiceNames(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	v := newTestValidator(t, Options{Action: ActionReject, MetricsFactory: mf})
+	for i := 0; i < maxServiceNames; i++ {
+		v.services[string(rune(i))] = struct{}{}
+	}
+	span := makeSpan()
+	span.SpanID = 0
+	v.Sanitize(span)
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "validation.defects", Tags: map[string]string{"defect": "zero-span-id", "service": "other-services", "outcome": "rejected"}, Value: 1},
+	)
+}
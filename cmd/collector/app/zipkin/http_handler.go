@@ -17,6 +17,7 @@ package zipkin
 
 import (
 	"compress/gzip"
+	"context"
 	"fmt"
 	"html"
 	"io"
@@ -31,9 +32,11 @@ import (
 	"github.com/gogo/protobuf/proto"
 	"github.com/gorilla/mux"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/auth"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
 	"github.com/jaegertracing/jaeger/model/converter/thrift/zipkin"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	zipkinProto "github.com/jaegertracing/jaeger/proto-gen/zipkin"
 	"github.com/jaegertracing/jaeger/swagger-gen/models"
 	"github.com/jaegertracing/jaeger/swagger-gen/restapi"
@@ -106,7 +109,10 @@ func (aH *APIHandler) saveSpans(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := aH.saveThriftSpans(tSpans); err != nil {
+	if err := aH.saveThriftSpans(r.Context(), tSpans); err != nil {
+		if handler.WriteOverloadedError(w, err) {
+			return
+		}
 		http.Error(w, fmt.Sprintf("Cannot submit Zipkin batch: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -156,7 +162,10 @@ func (aH *APIHandler) saveSpansV2(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err = aH.saveThriftSpans(tSpans); err != nil {
+	if err = aH.saveThriftSpans(r.Context(), tSpans); err != nil {
+		if handler.WriteOverloadedError(w, err) {
+			return
+		}
 		http.Error(w, fmt.Sprintf("Cannot submit Zipkin batch: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -164,6 +173,11 @@ func (aH *APIHandler) saveSpansV2(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(operations.PostSpansAcceptedCode)
 }
 
+// DeserializeJSONV2 decodes a list of spans in the Zipkin v2 JSON format and converts them to Zipkin Thrift.
+func DeserializeJSONV2(body []byte) ([]*zipkincore.Span, error) {
+	return jsonToThriftSpansV2(body, strfmt.Default)
+}
+
 func jsonToThriftSpansV2(bodyBytes []byte, zipkinV2Formats strfmt.Registry) ([]*zipkincore.Span, error) {
 	var spans models.ListOfSpans
 	if err := swag.ReadJSON(bodyBytes, &spans); err != nil {
@@ -201,9 +215,13 @@ func gunzip(r io.ReadCloser) (*gzip.Reader, error) {
 	return gz, nil
 }
 
-func (aH *APIHandler) saveThriftSpans(tSpans []*zipkincore.Span) error {
+func (aH *APIHandler) saveThriftSpans(ctx context.Context, tSpans []*zipkincore.Span) error {
 	if len(tSpans) > 0 {
-		opts := handler.SubmitBatchOptions{InboundTransport: processor.HTTPTransport}
+		opts := handler.SubmitBatchOptions{
+			InboundTransport: processor.HTTPTransport,
+			Tenant:           tenancy.GetTenant(ctx),
+			Identity:         auth.GetIdentity(ctx),
+		}
 		if _, err := aH.zipkinSpansHandler.SubmitZipkinBatch(tSpans, opts); err != nil {
 			return err
 		}
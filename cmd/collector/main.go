@@ -30,15 +30,20 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/jaegertracing/jaeger/cmd/collector/app"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/throughput"
 	"github.com/jaegertracing/jaeger/cmd/docs"
 	"github.com/jaegertracing/jaeger/cmd/env"
 	"github.com/jaegertracing/jaeger/cmd/flags"
 	"github.com/jaegertracing/jaeger/cmd/status"
 	"github.com/jaegertracing/jaeger/pkg/config"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/pkg/version"
 	ss "github.com/jaegertracing/jaeger/plugin/sampling/strategystore"
+	"github.com/jaegertracing/jaeger/plugin/sampling/strategystore/adaptive"
+	"github.com/jaegertracing/jaeger/plugin/sampling/strategystore/static"
 	"github.com/jaegertracing/jaeger/plugin/storage"
 	"github.com/jaegertracing/jaeger/ports"
+	"github.com/jaegertracing/jaeger/proto-gen/api_v2/introspection"
 )
 
 const serviceName = "jaeger-collector"
@@ -81,7 +86,7 @@ func main() {
 			}
 
 			strategyStoreFactory.InitFromViper(v, logger)
-			if err := strategyStoreFactory.Initialize(metricsFactory, logger); err != nil {
+			if err := strategyStoreFactory.Initialize(metricsFactory, storageFactory, logger); err != nil {
 				logger.Fatal("Failed to init sampling strategy store factory", zap.Error(err))
 			}
 			strategyStore, err := strategyStoreFactory.CreateStrategyStore()
@@ -89,23 +94,61 @@ func main() {
 				logger.Fatal("Failed to create sampling strategy store", zap.Error(err))
 			}
 
+			var samplingIntrospection introspection.SamplingIntrospectionServer
+			introspector, isAdaptive := strategyStore.(adaptive.Introspector)
+			if isAdaptive {
+				samplingIntrospection = adaptive.NewGRPCIntrospectionHandler(introspector)
+			}
+
 			c := app.New(&app.CollectorParams{
-				ServiceName:    serviceName,
-				Logger:         logger,
-				MetricsFactory: metricsFactory,
-				SpanWriter:     spanWriter,
-				StrategyStore:  strategyStore,
-				HealthCheck:    svc.HC(),
+				ServiceName:           serviceName,
+				Logger:                logger,
+				MetricsFactory:        metricsFactory,
+				SpanWriter:            spanWriter,
+				StrategyStore:         strategyStore,
+				HealthCheck:           svc.HC(),
+				SamplingIntrospection: samplingIntrospection,
 			})
-			collectorOpts := new(app.CollectorOptions).InitFromViper(v)
+			collectorOpts, err := new(app.CollectorOptions).InitFromViper(v)
+			if err != nil {
+				logger.Fatal("Failed to initialize collector", zap.Error(err))
+			}
+			if err := storageFactory.ValidateTenancy(collectorOpts.Tenancy); err != nil {
+				logger.Fatal("Invalid multi-tenancy configuration", zap.Error(err))
+			}
 			if err := c.Start(collectorOpts); err != nil {
 				logger.Fatal("Failed to start collector", zap.Error(err))
 			}
+			if throughputHandler := c.ThroughputHandler(); throughputHandler != nil {
+				svc.Admin.Handle(throughput.Route, throughputHandler)
+			}
+			if isAdaptive {
+				svc.Admin.Handle(adaptive.IntrospectionRoute, adaptive.NewIntrospectionHandler(introspector))
+			}
+			if manager, ok := strategyStore.(static.Manager); ok && manager.ManagementEnabled() {
+				strategiesStore, err := storageFactory.CreateSamplingStrategiesStore()
+				if err != nil {
+					logger.Fatal("Failed to create sampling strategies store", zap.Error(err))
+				}
+				managementHandler, err := manager.Manage(strategiesStore)
+				if err != nil {
+					logger.Fatal("Failed to manage sampling strategies", zap.Error(err))
+				}
+				if managementHandler != nil {
+					svc.Admin.Handle(static.StrategiesRoute, managementHandler)
+					svc.Admin.Handle(static.StrategyChangesRoute, managementHandler)
+				}
+			}
 
 			svc.RunAndThen(func() {
 				if err := c.Close(); err != nil {
 					logger.Error("failed to cleanly close the collector", zap.Error(err))
 				}
+				if closer, ok := strategyStore.(io.Closer); ok {
+					if err := closer.Close(); err != nil {
+						logger.Error("failed to close sampling strategy store", zap.Error(err))
+					}
+				}
 				if closer, ok := spanWriter.(io.Closer); ok {
 					err := closer.Close()
 					if err != nil {
@@ -131,6 +174,7 @@ func main() {
 		command,
 		svc.AddFlags,
 		app.AddFlags,
+		tenancy.AddFlags,
 		storageFactory.AddPipelineFlags,
 		strategyStoreFactory.AddFlags,
 	)
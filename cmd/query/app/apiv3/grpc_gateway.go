@@ -23,17 +23,26 @@ import (
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
 
 	"github.com/jaegertracing/jaeger/pkg/config/tlscfg"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/proto-gen/api_v3"
 )
 
 // RegisterGRPCGateway registers api_v3 endpoints into provided mux.
-func RegisterGRPCGateway(ctx context.Context, logger *zap.Logger, r *mux.Router, basePath string, grpcEndpoint string, grpcTLS tlscfg.Options) error {
+func RegisterGRPCGateway(ctx context.Context, logger *zap.Logger, r *mux.Router, basePath string, grpcEndpoint string, grpcTLS tlscfg.Options, tenancyMgr *tenancy.Manager) error {
 	jsonpb := &runtime.JSONPb{}
-	grpcGatewayMux := runtime.NewServeMux(
+	muxOpts := []runtime.ServeMuxOption{
 		runtime.WithMarshalerOption(runtime.MIMEWildcard, jsonpb),
-	)
+	}
+	if tenancyMgr != nil && tenancyMgr.Enabled {
+		// forward the tenant extracted by the HTTP server to the gRPC server
+		muxOpts = append(muxOpts, runtime.WithMetadata(func(ctx context.Context, r *http.Request) metadata.MD {
+			return metadata.Pairs(tenancyMgr.Header, tenancy.GetTenant(r.Context()))
+		}))
+	}
+	grpcGatewayMux := runtime.NewServeMux(muxOpts...)
 	var handler http.Handler = grpcGatewayMux
 	if basePath != "/" {
 		handler = http.StripPrefix(basePath, grpcGatewayMux)
@@ -86,7 +86,7 @@ func testGRPCGateway(t *testing.T, basePath string, serverTLS tlscfg.Options, cl
 	router = router.PathPrefix(basePath).Subrouter()
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	err := RegisterGRPCGateway(ctx, zap.NewNop(), router, basePath, lis.Addr().String(), clientTLS)
+	err := RegisterGRPCGateway(ctx, zap.NewNop(), router, basePath, lis.Addr().String(), clientTLS, nil)
 	require.NoError(t, err)
 
 	httpLis, err := net.Listen("tcp", ":0")
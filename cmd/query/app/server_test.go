@@ -31,13 +31,17 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest/observer"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"github.com/jaegertracing/jaeger/cmd/flags"
 	"github.com/jaegertracing/jaeger/cmd/query/app/querysvc"
 	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/pkg/config/tlscfg"
 	"github.com/jaegertracing/jaeger/pkg/healthcheck"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/ports"
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
 	depsmocks "github.com/jaegertracing/jaeger/storage/dependencystore/mocks"
@@ -648,6 +652,51 @@ func TestServerSinglePort(t *testing.T) {
 	assert.Equal(t, healthcheck.Unavailable, flagsSvc.HC().Get())
 }
 
+func TestServerWithTenancy(t *testing.T) {
+	hostPort := ports.GetAddressFromCLIOptions(ports.QueryHTTP, "")
+	spanReader := &spanstoremocks.Reader{}
+	spanReader.On("GetServices", mock.MatchedBy(func(ctx context.Context) bool {
+		return tenancy.GetTenant(ctx) == "acme"
+	})).Return([]string{"test"}, nil)
+
+	querySvc := querysvc.NewQueryService(spanReader, &depsmocks.Reader{}, querysvc.QueryServiceOptions{})
+	server, err := NewServer(zap.NewNop(), querySvc, nil,
+		&QueryOptions{
+			GRPCHostPort: hostPort,
+			HTTPHostPort: hostPort,
+			Tenancy:      tenancy.Options{Enabled: true, Tenants: []string{"acme"}},
+		},
+		opentracing.NoopTracer{})
+	require.NoError(t, err)
+	require.NoError(t, server.Start())
+	defer server.Close()
+
+	client := newGRPCClient(t, hostPort)
+	defer client.conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	_, err = client.GetServices(ctx, &api_v2.GetServicesRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	res, err := client.GetServices(metadata.AppendToOutgoingContext(ctx, tenancy.DefaultHeader, "acme"), &api_v2.GetServicesRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test"}, res.Services)
+
+	response, err := http.Get("http://" + hostPort + "/api/services")
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	request, err := http.NewRequest(http.MethodGet, "http://"+hostPort+"/api/services", nil)
+	require.NoError(t, err)
+	request.Header.Set(tenancy.DefaultHeader, "acme")
+	response, err = http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
 func TestServerGracefulExit(t *testing.T) {
 	flagsSvc := flags.NewService(ports.QueryAdminHTTP)
 
@@ -0,0 +1,146 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/plugin/sampling/strategystore/adaptive"
+	"github.com/jaegertracing/jaeger/plugin/sampling/strategystore/static"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+const (
+	// TrafficSourceThroughput reads the traffic from the throughput of the adaptive sampling.
+	TrafficSourceThroughput = "throughput"
+	// TrafficSourceSpans reads the traffic from the traces in span storage.
+	TrafficSourceSpans = "spans"
+	// StrategiesStatic proposes the strategies of a static strategies file.
+	StrategiesStatic = "static"
+	// StrategiesAdaptive proposes the strategies of the adaptive sampling.
+	StrategiesAdaptive = "adaptive"
+
+	trafficSourceFlag    = "simulator.traffic-source"
+	strategiesFlag       = "simulator.strategies"
+	lookbackFlag         = "simulator.lookback"
+	maxTracesFlag        = "simulator.max-traces"
+	serviceInstancesFlag = "simulator.service-instances"
+	traceSizeFlag        = "simulator.trace-size"
+
+	defaultLookback         = time.Hour
+	defaultMaxTraces        = 10000
+	defaultServiceInstances = 1
+	defaultTraceSize        = 10 * 1024
+)
+
+// Options represent configurable parameters for jaeger-sampling-simulator
+type Options struct {
+	// TrafficSource is where the traffic observed with the current configuration is read from.
+	TrafficSource string
+	// Strategies is the kind of the proposed strategies, configured by the flags of their strategy store.
+	Strategies string
+	// Lookback is the duration of the observed traffic, until now.
+	Lookback time.Duration
+	// MaxTraces is the maximum number of traces read per service from span storage.
+	MaxTraces int
+	// ServiceInstances is the number of instances of every service, each applying the rate limits of the strategies.
+	ServiceInstances int
+	// TraceSize is the mean size of the traces in bytes, when the traffic source has no spans.
+	TraceSize float64
+}
+
+// StorageFactory creates the readers of the traffic sources.
+type StorageFactory interface {
+	CreateSpanReader() (spanstore.Reader, error)
+	CreateSamplingStore() (samplingstore.Store, error)
+}
+
+// AddFlags adds flags for Options
+func AddFlags(flagSet *flag.FlagSet) {
+	flagSet.String(trafficSourceFlag, TrafficSourceThroughput,
+		fmt.Sprintf("Where the traffic observed with the current sampling configuration is read from, %q for the throughput of the adaptive sampling or %q for the traces in span storage.", TrafficSourceThroughput, TrafficSourceSpans))
+	flagSet.String(strategiesFlag, StrategiesStatic,
+		fmt.Sprintf("The proposed sampling strategies, %q for a strategies file or %q for the adaptive sampling, configured by the sampling flags.", StrategiesStatic, StrategiesAdaptive))
+	flagSet.Duration(lookbackFlag, defaultLookback,
+		"The duration of the observed traffic, until now.")
+	flagSet.Int(maxTracesFlag, defaultMaxTraces,
+		"The maximum number of traces read per service from span storage.")
+	flagSet.Int(serviceInstancesFlag, defaultServiceInstances,
+		"The number of instances of every service, the rate limits and lower bounds of the sampling strategies apply to every instance.")
+	flagSet.Float64(traceSizeFlag, defaultTraceSize,
+		"The mean size of the traces in bytes, used for the storage volume when the traffic is read from the throughput.")
+}
+
+// InitFromViper initializes Options with properties from viper
+func (o *Options) InitFromViper(v *viper.Viper) *Options {
+	o.TrafficSource = v.GetString(trafficSourceFlag)
+	o.Strategies = v.GetString(strategiesFlag)
+	o.Lookback = v.GetDuration(lookbackFlag)
+	o.MaxTraces = v.GetInt(maxTracesFlag)
+	o.ServiceInstances = v.GetInt(serviceInstancesFlag)
+	o.TraceSize = v.GetFloat64(traceSizeFlag)
+	return o
+}
+
+// LoadTraffic reads the traffic of the lookback until now from the traffic source.
+func (o *Options) LoadTraffic(ctx context.Context, factory StorageFactory, now time.Time) (*Traffic, error) {
+	if o.Lookback <= 0 {
+		return nil, fmt.Errorf("the lookback must be positive, got %v", o.Lookback)
+	}
+	start := now.Add(-o.Lookback)
+	switch o.TrafficSource {
+	case TrafficSourceThroughput:
+		store, err := factory.CreateSamplingStore()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the sampling store: %w", err)
+		}
+		return LoadThroughput(store, start, now)
+	case TrafficSourceSpans:
+		reader, err := factory.CreateSpanReader()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the span reader: %w", err)
+		}
+		return LoadSpans(ctx, reader, start, now, o.MaxTraces)
+	default:
+		return nil, fmt.Errorf("unknown traffic source %q", o.TrafficSource)
+	}
+}
+
+// NewStrategyProvider creates the provider of the proposed strategies, configured by the flags of their strategy store.
+func (o *Options) NewStrategyProvider(v *viper.Viper, logger *zap.Logger) (StrategyProvider, error) {
+	switch o.Strategies {
+	case StrategiesStatic:
+		store, err := static.NewStrategyStore(*new(static.Options).InitFromViper(v), logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the static strategy store: %w", err)
+		}
+		return StoreStrategies(store), nil
+	case StrategiesAdaptive:
+		projection, err := adaptive.NewProjection(adaptive.Options{}.InitFromViper(v))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the adaptive sampling projection: %w", err)
+		}
+		return AdaptiveStrategies(projection), nil
+	default:
+		return nil, fmt.Errorf("unknown sampling strategies %q", o.Strategies)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/model"
+	"github.com/jaegertracing/jaeger/pkg/config"
+	"github.com/jaegertracing/jaeger/plugin/sampling/strategystore/adaptive"
+	"github.com/jaegertracing/jaeger/plugin/sampling/strategystore/static"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
+	samplingStoreMocks "github.com/jaegertracing/jaeger/storage/samplingstore/mocks"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+	spanStoreMocks "github.com/jaegertracing/jaeger/storage/spanstore/mocks"
+	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
+)
+
+type storageFactory struct {
+	reader spanstore.Reader
+	store  samplingstore.Store
+	err    error
+}
+
+func (f *storageFactory) CreateSpanReader() (spanstore.Reader, error) {
+	return f.reader, f.err
+}
+
+func (f *storageFactory) CreateSamplingStore() (samplingstore.Store, error) {
+	return f.store, f.err
+}
+
+func TestOptionsFlags(t *testing.T) {
+	v, command := config.Viperize(AddFlags)
+	options := new(Options).InitFromViper(v)
+	assert.Equal(t, &Options{
+		TrafficSource:    TrafficSourceThroughput,
+		Strategies:       StrategiesStatic,
+		Lookback:         time.Hour,
+		MaxTraces:        10000,
+		ServiceInstances: 1,
+		TraceSize:        10240,
+	}, options)
+
+	require.NoError(t, command.ParseFlags([]string{
+		"--simulator.traffic-source=spans",
+		"--simulator.strategies=adaptive",
+		"--simulator.lookback=24h",
+		"--simulator.max-traces=100",
+		"--simulator.service-instances=3",
+		"--simulator.trace-size=2048",
+	}))
+	options = new(Options).InitFromViper(v)
+	assert.Equal(t, &Options{
+		TrafficSource:    TrafficSourceSpans,
+		Strategies:       StrategiesAdaptive,
+		Lookback:         24 * time.Hour,
+		MaxTraces:        100,
+		ServiceInstances: 3,
+		TraceSize:        2048,
+	}, options)
+}
+
+func TestLoadTraffic(t *testing.T) {
+	now := time.Unix(3600, 0)
+	store := &samplingStoreMocks.Store{}
+	store.On("GetThroughput", time.Unix(0, 0), now).Return([]*model.Throughput{
+		{Service: "svcA", Operation: "GET", Count: 36},
+	}, nil)
+	reader := &spanStoreMocks.Reader{}
+	reader.On("GetServices", mock.Anything).Return([]string{}, nil)
+	factory := &storageFactory{reader: reader, store: store}
+
+	options := &Options{TrafficSource: TrafficSourceThroughput, Lookback: time.Hour}
+	traffic, err := options.LoadTraffic(context.Background(), factory, now)
+	require.NoError(t, err)
+	assert.Len(t, traffic.Operations, 1)
+
+	options.TrafficSource = TrafficSourceSpans
+	traffic, err = options.LoadTraffic(context.Background(), factory, now)
+	require.NoError(t, err)
+	assert.Empty(t, traffic.Operations)
+
+	factory.err = errors.New("storage error")
+	_, err = options.LoadTraffic(context.Background(), factory, now)
+	assert.EqualError(t, err, "failed to create the span reader: storage error")
+	options.TrafficSource = TrafficSourceThroughput
+	_, err = options.LoadTraffic(context.Background(), factory, now)
+	assert.EqualError(t, err, "failed to create the sampling store: storage error")
+
+	options.TrafficSource = "metrics"
+	_, err = options.LoadTraffic(context.Background(), factory, now)
+	assert.EqualError(t, err, `unknown traffic source "metrics"`)
+
+	options.Lookback = 0
+	_, err = options.LoadTraffic(context.Background(), factory, now)
+	assert.EqualError(t, err, "the lookback must be positive, got 0s")
+}
+
+func TestNewStrategyProvider(t *testing.T) {
+	v, command := config.Viperize(AddFlags, static.AddFlags, adaptive.AddFlags)
+	require.NoError(t, command.ParseFlags([]string{
+		"--sampling.strategies-file=fixtures/strategies.json",
+		"--sampling.target-samples-per-second=2",
+	}))
+
+	options := &Options{Strategies: StrategiesStatic}
+	strategies, err := options.NewStrategyProvider(v, zap.NewNop())
+	require.NoError(t, err)
+	strategy, err := strategies("checkout", nil)
+	require.NoError(t, err)
+	assert.Equal(t, sampling.SamplingStrategyType_RATE_LIMITING, strategy.StrategyType)
+
+	options.Strategies = StrategiesAdaptive
+	strategies, err = options.NewStrategyProvider(v, zap.NewNop())
+	require.NoError(t, err)
+	strategy, err = strategies("checkout", map[string]float64{"GET": 10})
+	require.NoError(t, err)
+	assert.Equal(t, 0.2, strategy.OperationSampling.PerOperationStrategies[0].ProbabilisticSampling.SamplingRate)
+
+	options.Strategies = "remote"
+	_, err = options.NewStrategyProvider(v, zap.NewNop())
+	assert.EqualError(t, err, `unknown sampling strategies "remote"`)
+
+	require.NoError(t, command.ParseFlags([]string{"--sampling.overrides-file=fixtures/missing.json"}))
+	options.Strategies = StrategiesAdaptive
+	_, err = options.NewStrategyProvider(v, zap.NewNop())
+	assert.Contains(t, err.Error(), "failed to create the adaptive sampling projection")
+
+	require.NoError(t, command.ParseFlags([]string{"--sampling.strategies-file=fixtures/missing.json"}))
+	options.Strategies = StrategiesStatic
+	_, err = options.NewStrategyProvider(v, zap.NewNop())
+	assert.Contains(t, err.Error(), "failed to create the static strategy store")
+}
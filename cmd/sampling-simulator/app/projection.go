@@ -0,0 +1,97 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"math"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
+	"github.com/jaegertracing/jaeger/plugin/sampling/strategystore/adaptive"
+	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
+)
+
+// StrategyProvider returns the proposed sampling strategy of a service, given the QPS before sampling
+// of its operations.
+type StrategyProvider func(service string, qps map[string]float64) (*sampling.SamplingStrategyResponse, error)
+
+// StoreStrategies returns the strategies of a strategy store, such as the static strategy store.
+func StoreStrategies(store strategystore.StrategyStore) StrategyProvider {
+	return func(service string, _ map[string]float64) (*sampling.SamplingStrategyResponse, error) {
+		return store.GetSamplingStrategy(context.Background(), service)
+	}
+}
+
+// AdaptiveStrategies returns the strategies the adaptive sampling converges to.
+func AdaptiveStrategies(projection *adaptive.Projection) StrategyProvider {
+	return func(service string, qps map[string]float64) (*sampling.SamplingStrategyResponse, error) {
+		return projection.Strategy(service, qps), nil
+	}
+}
+
+// projectTracesPerSecond returns the traces per second sampled by the SDKs of the service instances with the
+// strategy, given the QPS before sampling of the operations, ie [operation] = traces per second.
+// The rate limits and lower bounds of the strategy apply to every instance.
+func projectTracesPerSecond(strategy *sampling.SamplingStrategyResponse, qps map[string]float64, instances int) map[string]float64 {
+	projected := make(map[string]float64, len(qps))
+	n := float64(instances)
+	if opS := strategy.OperationSampling; opS != nil {
+		operations := make(map[string]*sampling.OperationSamplingStrategy, len(opS.PerOperationStrategies))
+		for _, op := range opS.PerOperationStrategies {
+			operations[op.Operation] = op
+		}
+		for operation, q := range qps {
+			probability := opS.DefaultSamplingProbability
+			op, ok := operations[operation]
+			if ok && op.RateLimitingSampling != nil {
+				projected[operation] = math.Min(q, float64(op.RateLimitingSampling.MaxTracesPerSecond)*n)
+				continue
+			}
+			if ok && op.ProbabilisticSampling != nil {
+				probability = op.ProbabilisticSampling.SamplingRate
+			}
+			// the lower bound sampler samples the operations under-sampled by the probabilistic sampler
+			traces := math.Max(q*probability, math.Min(q, opS.DefaultLowerBoundTracesPerSecond*n))
+			if opS.DefaultUpperBoundTracesPerSecond != nil {
+				traces = math.Min(traces, *opS.DefaultUpperBoundTracesPerSecond*n)
+			}
+			projected[operation] = traces
+		}
+		return projected
+	}
+	if strategy.RateLimitingSampling != nil {
+		// the rate limit is shared by the operations in proportion of their QPS
+		var total float64
+		for _, q := range qps {
+			total += q
+		}
+		limit := float64(strategy.RateLimitingSampling.MaxTracesPerSecond) * n
+		for operation, q := range qps {
+			projected[operation] = q
+			if total > limit {
+				projected[operation] = q * limit / total
+			}
+		}
+		return projected
+	}
+	var probability float64
+	if strategy.ProbabilisticSampling != nil {
+		probability = strategy.ProbabilisticSampling.SamplingRate
+	}
+	for operation, q := range qps {
+		projected[operation] = q * probability
+	}
+	return projected
+}
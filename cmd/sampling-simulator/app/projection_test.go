@@ -0,0 +1,117 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/plugin/sampling/strategystore/adaptive"
+	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
+)
+
+type staticStore map[string]*sampling.SamplingStrategyResponse
+
+func (s staticStore) GetSamplingStrategy(_ context.Context, service string) (*sampling.SamplingStrategyResponse, error) {
+	return s[service], nil
+}
+
+func TestProjectTracesPerSecond(t *testing.T) {
+	upperBound := 30.0
+	qps := map[string]float64{"GET": 1000, "PUT": 10, "HEAD": 0.5, "DELETE": 499.5}
+	tests := []struct {
+		name      string
+		strategy  *sampling.SamplingStrategyResponse
+		instances int
+		expected  map[string]float64
+	}{
+		{
+			name: "probabilistic",
+			strategy: &sampling.SamplingStrategyResponse{
+				StrategyType:          sampling.SamplingStrategyType_PROBABILISTIC,
+				ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: 0.1},
+			},
+			instances: 1,
+			expected:  map[string]float64{"GET": 100, "PUT": 1, "HEAD": 0.05, "DELETE": 49.95},
+		},
+		{
+			name: "rate limiting",
+			strategy: &sampling.SamplingStrategyResponse{
+				StrategyType:         sampling.SamplingStrategyType_RATE_LIMITING,
+				RateLimitingSampling: &sampling.RateLimitingSamplingStrategy{MaxTracesPerSecond: 151},
+			},
+			instances: 2,
+			expected:  map[string]float64{"GET": 200, "PUT": 2, "HEAD": 0.1, "DELETE": 99.9},
+		},
+		{
+			name: "rate limiting under the limit",
+			strategy: &sampling.SamplingStrategyResponse{
+				StrategyType:         sampling.SamplingStrategyType_RATE_LIMITING,
+				RateLimitingSampling: &sampling.RateLimitingSamplingStrategy{MaxTracesPerSecond: 2000},
+			},
+			instances: 1,
+			expected:  qps,
+		},
+		{
+			name: "per operation",
+			strategy: &sampling.SamplingStrategyResponse{
+				StrategyType: sampling.SamplingStrategyType_PROBABILISTIC,
+				OperationSampling: &sampling.PerOperationSamplingStrategies{
+					DefaultSamplingProbability:       0.01,
+					DefaultLowerBoundTracesPerSecond: 1,
+					DefaultUpperBoundTracesPerSecond: &upperBound,
+					PerOperationStrategies: []*sampling.OperationSamplingStrategy{
+						{Operation: "GET", ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: 0.5}},
+						{
+							Operation:             "DELETE",
+							ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: 0.01},
+							RateLimitingSampling:  &sampling.RateLimitingSamplingStrategy{MaxTracesPerSecond: 4},
+						},
+					},
+				},
+			},
+			instances: 2,
+			expected:  map[string]float64{"GET": 60, "PUT": 2, "HEAD": 0.5, "DELETE": 8},
+		},
+		{
+			name:      "no strategy",
+			strategy:  &sampling.SamplingStrategyResponse{},
+			instances: 1,
+			expected:  map[string]float64{"GET": 0, "PUT": 0, "HEAD": 0, "DELETE": 0},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			projected := projectTracesPerSecond(test.strategy, qps, test.instances)
+			assert.InDeltaMapValues(t, test.expected, projected, 1e-9)
+		})
+	}
+}
+
+func TestStrategyProviders(t *testing.T) {
+	strategy := &sampling.SamplingStrategyResponse{StrategyType: sampling.SamplingStrategyType_RATE_LIMITING}
+	s, err := StoreStrategies(staticStore{"svcA": strategy})("svcA", nil)
+	require.NoError(t, err)
+	assert.Equal(t, strategy, s)
+
+	projection, err := adaptive.NewProjection(adaptive.Options{TargetSamplesPerSecond: 1, MinSamplingProbability: 0.001})
+	require.NoError(t, err)
+	s, err = AdaptiveStrategies(projection)("svcA", map[string]float64{"GET": 100})
+	require.NoError(t, err)
+	assert.Equal(t, 0.01, s.OperationSampling.PerOperationStrategies[0].ProbabilisticSampling.SamplingRate)
+}
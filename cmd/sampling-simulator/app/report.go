@@ -0,0 +1,148 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	allOperations = "*"
+	allServices   = "*"
+)
+
+// OperationProjection compares the sampling of an operation with the current and the proposed configurations.
+type OperationProjection struct {
+	Service   string
+	Operation string
+	// QPS is the estimated QPS of the operation before sampling.
+	QPS                      float64
+	CurrentTracesPerSecond   float64
+	ProjectedTracesPerSecond float64
+	// CurrentBytesPerDay and ProjectedBytesPerDay are the storage volumes of the sampled traces.
+	CurrentBytesPerDay   float64
+	ProjectedBytesPerDay float64
+}
+
+// Report is the projection of the proposed configuration for all operations.
+type Report struct {
+	Operations []*OperationProjection
+	// Truncated are the services whose traffic is underestimated, see Traffic.
+	Truncated []string
+}
+
+// Project projects the sampled traces and their storage volume with the proposed strategies, for the traffic
+// observed with the current configuration. The traces of unknown size have the default trace size in bytes.
+func Project(traffic *Traffic, strategies StrategyProvider, instances int, defaultTraceSize float64) (*Report, error) {
+	projected := make(map[string]map[string]float64)
+	for service, qps := range traffic.Services() {
+		strategy, err := strategies(service, qps)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the sampling strategy of service %s: %w", service, err)
+		}
+		projected[service] = projectTracesPerSecond(strategy, qps, instances)
+	}
+	secondsPerDay := (24 * time.Hour).Seconds()
+	report := &Report{Truncated: traffic.Truncated}
+	for _, op := range traffic.Operations {
+		traceSize := op.TraceSize
+		if traceSize == 0 {
+			traceSize = defaultTraceSize
+		}
+		tracesPerSecond := projected[op.Service][op.Operation]
+		report.Operations = append(report.Operations, &OperationProjection{
+			Service:                  op.Service,
+			Operation:                op.Operation,
+			QPS:                      op.QPS,
+			CurrentTracesPerSecond:   op.TracesPerSecond,
+			ProjectedTracesPerSecond: tracesPerSecond,
+			CurrentBytesPerDay:       op.TracesPerSecond * traceSize * secondsPerDay,
+			ProjectedBytesPerDay:     tracesPerSecond * traceSize * secondsPerDay,
+		})
+	}
+	return report, nil
+}
+
+// Write writes the report as a table of the operations, of the totals of every service, and of the total
+// of all services.
+func (r *Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SERVICE\tOPERATION\tQPS\tCURRENT TRACES/S\tPROJECTED TRACES/S\tCHANGE\tCURRENT STORAGE/DAY\tPROJECTED STORAGE/DAY")
+	total := &OperationProjection{Service: allServices, Operation: allOperations}
+	var service *OperationProjection
+	for i, op := range r.Operations {
+		writeProjection(tw, op)
+		if service == nil {
+			service = &OperationProjection{Service: op.Service, Operation: allOperations}
+		}
+		service.add(op)
+		total.add(op)
+		if i == len(r.Operations)-1 || r.Operations[i+1].Service != op.Service {
+			writeProjection(tw, service)
+			service = nil
+		}
+	}
+	writeProjection(tw, total)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, service := range r.Truncated {
+		if _, err := fmt.Fprintf(w, "warning: the traffic of service %s is underestimated, it has more traces than the maximum read\n", service); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *OperationProjection) add(op *OperationProjection) {
+	p.QPS += op.QPS
+	p.CurrentTracesPerSecond += op.CurrentTracesPerSecond
+	p.ProjectedTracesPerSecond += op.ProjectedTracesPerSecond
+	p.CurrentBytesPerDay += op.CurrentBytesPerDay
+	p.ProjectedBytesPerDay += op.ProjectedBytesPerDay
+}
+
+func writeProjection(w io.Writer, p *OperationProjection) {
+	fmt.Fprintf(w, "%s\t%s\t%.3f\t%.3f\t%.3f\t%s\t%s\t%s\n", p.Service, p.Operation, p.QPS,
+		p.CurrentTracesPerSecond, p.ProjectedTracesPerSecond,
+		formatChange(p.CurrentTracesPerSecond, p.ProjectedTracesPerSecond),
+		formatBytes(p.CurrentBytesPerDay), formatBytes(p.ProjectedBytesPerDay))
+}
+
+func formatChange(current, projected float64) string {
+	if current == 0 {
+		if projected == 0 {
+			return "0%"
+		}
+		return "new"
+	}
+	return fmt.Sprintf("%+.0f%%", 100*(projected-current)/current)
+}
+
+func formatBytes(bytes float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	i := 0
+	for bytes >= 1024 && i < len(units)-1 {
+		bytes /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f %s", bytes, units[i])
+	}
+	return fmt.Sprintf("%.1f %s", bytes, units[i])
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
+)
+
+func TestProject(t *testing.T) {
+	traffic := &Traffic{
+		Operations: []*OperationTraffic{
+			{Service: "svcA", Operation: "GET", QPS: 100, TracesPerSecond: 1, TraceSize: 2048},
+			{Service: "svcA", Operation: "PUT", QPS: 10, TracesPerSecond: 0.1},
+			{Service: "svcB", Operation: "GET", QPS: 1, TracesPerSecond: 0},
+		},
+		Truncated: []string{"svcA"},
+	}
+	strategies := func(service string, qps map[string]float64) (*sampling.SamplingStrategyResponse, error) {
+		return &sampling.SamplingStrategyResponse{
+			StrategyType:          sampling.SamplingStrategyType_PROBABILISTIC,
+			ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: 0.1},
+		}, nil
+	}
+	report, err := Project(traffic, strategies, 1, 1024)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"svcA"}, report.Truncated)
+	require.Len(t, report.Operations, 3)
+	get := report.Operations[0]
+	assert.Equal(t, &OperationProjection{
+		Service: "svcA", Operation: "GET", QPS: 100,
+		CurrentTracesPerSecond: 1, ProjectedTracesPerSecond: 10,
+		CurrentBytesPerDay: 2048 * 86400, ProjectedBytesPerDay: 10 * 2048 * 86400,
+	}, get)
+	assert.InDelta(t, 0.1*1024*86400, report.Operations[1].CurrentBytesPerDay, 1e-6)
+
+	var buf bytes.Buffer
+	require.NoError(t, report.Write(&buf))
+	assert.Equal(t, ""+
+		"SERVICE  OPERATION  QPS      CURRENT TRACES/S  PROJECTED TRACES/S  CHANGE  CURRENT STORAGE/DAY  PROJECTED STORAGE/DAY\n"+
+		"svcA     GET        100.000  1.000             10.000              +900%   168.8 MiB            1.6 GiB\n"+
+		"svcA     PUT        10.000   0.100             1.000               +900%   8.4 MiB              84.4 MiB\n"+
+		"svcA     *          110.000  1.100             11.000              +900%   177.2 MiB            1.7 GiB\n"+
+		"svcB     GET        1.000    0.000             0.100               new     0 B                  8.4 MiB\n"+
+		"svcB     *          1.000    0.000             0.100               new     0 B                  8.4 MiB\n"+
+		"*        *          111.000  1.100             11.100              +909%   177.2 MiB            1.7 GiB\n"+
+		"warning: the traffic of service svcA is underestimated, it has more traces than the maximum read\n",
+		buf.String())
+
+	_, err = Project(traffic, func(string, map[string]float64) (*sampling.SamplingStrategyResponse, error) {
+		return nil, errors.New("strategy error")
+	}, 1, 1024)
+	assert.Contains(t, err.Error(), "strategy error")
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "0%", formatChange(0, 0))
+	assert.Equal(t, "-50%", formatChange(2, 1))
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.5 KiB", formatBytes(1536))
+	assert.Equal(t, "2048.0 TiB", formatBytes(2*1024*1024*1024*1024*1024))
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+const (
+	samplerTypeTagKey  = "sampler.type"
+	samplerParamTagKey = "sampler.param"
+	samplerTypeProb    = "probabilistic"
+)
+
+// OperationTraffic is the traffic of the root spans of an operation observed over the lookback.
+type OperationTraffic struct {
+	Service   string
+	Operation string
+	// QPS is the estimated QPS of the operation before sampling.
+	QPS float64
+	// TracesPerSecond is the rate of the traces sampled with the current sampling configuration.
+	TracesPerSecond float64
+	// TraceSize is the mean size of the traces in bytes, 0 if it is unknown.
+	TraceSize float64
+}
+
+// Traffic is the traffic of all operations observed over the lookback.
+type Traffic struct {
+	Operations []*OperationTraffic
+	// Truncated are the services with more traces than the maximum read from span storage,
+	// whose traffic is underestimated.
+	Truncated []string
+}
+
+// Services returns the QPS before sampling of the operations of every service, ie [service][operation] = QPS.
+func (t *Traffic) Services() map[string]map[string]float64 {
+	services := make(map[string]map[string]float64)
+	for _, op := range t.Operations {
+		if _, ok := services[op.Service]; !ok {
+			services[op.Service] = make(map[string]float64)
+		}
+		services[op.Service][op.Operation] = op.QPS
+	}
+	return services
+}
+
+// LoadThroughput reads the traffic from the throughput aggregated by the adaptive sampling between start and end.
+// The throughput of an operation counts its sampled traces at the sampling probabilities of the throughput,
+// which are assumed to be used equally. The traces only sampled by the lower bound sampler count once.
+func LoadThroughput(store samplingstore.Store, start, end time.Time) (*Traffic, error) {
+	throughput, err := store.GetThroughput(start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the throughput: %w", err)
+	}
+	seconds := end.Sub(start).Seconds()
+	operations := make(map[string]map[string]*OperationTraffic)
+	traffic := &Traffic{}
+	for _, t := range throughput {
+		op := traffic.operation(operations, t.Service, t.Operation)
+		op.TracesPerSecond += float64(t.Count) / seconds
+		op.QPS += float64(t.Count) / seconds / meanProbability(t.Probabilities)
+	}
+	traffic.sort()
+	return traffic, nil
+}
+
+// LoadSpans reads the traffic from the traces started between start and end in span storage, reading at most
+// maxTraces traces per service. The traces sampled probabilistically count as the inverse of their sampling
+// probability, recorded in the tags of their root span, the other traces count once.
+func LoadSpans(ctx context.Context, reader spanstore.Reader, start, end time.Time, maxTraces int) (*Traffic, error) {
+	services, err := reader.GetServices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the services: %w", err)
+	}
+	sort.Strings(services)
+	seconds := end.Sub(start).Seconds()
+	operations := make(map[string]map[string]*OperationTraffic)
+	traffic := &Traffic{}
+	for _, service := range services {
+		traces, err := reader.FindTraces(ctx, &spanstore.TraceQueryParameters{
+			ServiceName:  service,
+			StartTimeMin: start,
+			StartTimeMax: end,
+			NumTraces:    maxTraces,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the traces of service %s: %w", service, err)
+		}
+		if len(traces) >= maxTraces {
+			traffic.Truncated = append(traffic.Truncated, service)
+		}
+		for _, trace := range traces {
+			root := rootSpan(trace)
+			// the traces started by other services are counted with their services
+			if root == nil || root.Process == nil || root.Process.ServiceName != service {
+				continue
+			}
+			op := traffic.operation(operations, service, root.OperationName)
+			size := 0
+			for _, span := range trace.Spans {
+				size += span.Size()
+			}
+			// the mean size is accumulated as the total size until all traces are counted
+			op.TraceSize += float64(size)
+			op.TracesPerSecond++
+			op.QPS += 1 / samplingProbability(root)
+		}
+	}
+	for _, op := range traffic.Operations {
+		op.TraceSize /= op.TracesPerSecond
+		op.TracesPerSecond /= seconds
+		op.QPS /= seconds
+	}
+	traffic.sort()
+	return traffic, nil
+}
+
+func (t *Traffic) operation(operations map[string]map[string]*OperationTraffic, service, operation string) *OperationTraffic {
+	if _, ok := operations[service]; !ok {
+		operations[service] = make(map[string]*OperationTraffic)
+	}
+	op, ok := operations[service][operation]
+	if !ok {
+		op = &OperationTraffic{Service: service, Operation: operation}
+		operations[service][operation] = op
+		t.Operations = append(t.Operations, op)
+	}
+	return op
+}
+
+func (t *Traffic) sort() {
+	sort.Slice(t.Operations, func(i, j int) bool {
+		if t.Operations[i].Service != t.Operations[j].Service {
+			return t.Operations[i].Service < t.Operations[j].Service
+		}
+		return t.Operations[i].Operation < t.Operations[j].Operation
+	})
+}
+
+// meanProbability returns the mean of the sampling probabilities of a throughput, or 1 if there are none.
+func meanProbability(probabilities map[string]struct{}) float64 {
+	var sum float64
+	var count int
+	for p := range probabilities {
+		probability, err := strconv.ParseFloat(p, 64)
+		if err != nil || probability <= 0 || probability > 1 {
+			continue
+		}
+		sum += probability
+		count++
+	}
+	if count == 0 {
+		return 1
+	}
+	return sum / float64(count)
+}
+
+// rootSpan returns the span without parent of a trace, or nil if there is none.
+func rootSpan(trace *model.Trace) *model.Span {
+	for _, span := range trace.Spans {
+		if span.ParentSpanID() == 0 {
+			return span
+		}
+	}
+	return nil
+}
+
+// samplingProbability returns the probability of the probabilistic sampler of a root span, or 1 for the other samplers.
+func samplingProbability(root *model.Span) float64 {
+	samplerType, ok := model.KeyValues(root.Tags).FindByKey(samplerTypeTagKey)
+	if !ok || samplerType.AsString() != samplerTypeProb {
+		return 1
+	}
+	samplerParam, ok := model.KeyValues(root.Tags).FindByKey(samplerParamTagKey)
+	if !ok {
+		return 1
+	}
+	var probability float64
+	if samplerParam.VType == model.Float64Type {
+		probability = samplerParam.Float64()
+	} else {
+		probability, _ = strconv.ParseFloat(samplerParam.AsString(), 64)
+	}
+	if probability <= 0 || probability > 1 {
+		return 1
+	}
+	return probability
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	samplingModel "github.com/jaegertracing/jaeger/cmd/collector/app/sampling/model"
+	"github.com/jaegertracing/jaeger/model"
+	samplingStoreMocks "github.com/jaegertracing/jaeger/storage/samplingstore/mocks"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+	spanStoreMocks "github.com/jaegertracing/jaeger/storage/spanstore/mocks"
+)
+
+func TestLoadThroughput(t *testing.T) {
+	start, end := time.Unix(0, 0), time.Unix(60, 0)
+	store := &samplingStoreMocks.Store{}
+	store.On("GetThroughput", start, end).Return([]*samplingModel.Throughput{
+		{Service: "svcB", Operation: "GET", Count: 60, Probabilities: map[string]struct{}{"0.100000": {}}},
+		{Service: "svcA", Operation: "PUT", Count: 6},
+		{Service: "svcA", Operation: "GET", Count: 30, Probabilities: map[string]struct{}{"0.010000": {}}},
+		{Service: "svcA", Operation: "GET", Count: 30, Probabilities: map[string]struct{}{"0.010000": {}, "0.030000": {}, "invalid": {}}},
+	}, nil)
+
+	traffic, err := LoadThroughput(store, start, end)
+	require.NoError(t, err)
+	require.Len(t, traffic.Operations, 3)
+	expected := []*OperationTraffic{
+		{Service: "svcA", Operation: "GET", QPS: 50 + 25, TracesPerSecond: 1},
+		{Service: "svcA", Operation: "PUT", QPS: 0.1, TracesPerSecond: 0.1},
+		{Service: "svcB", Operation: "GET", QPS: 10, TracesPerSecond: 1},
+	}
+	for i, op := range expected {
+		assert.Equal(t, op.Service, traffic.Operations[i].Service)
+		assert.Equal(t, op.Operation, traffic.Operations[i].Operation)
+		assert.InDelta(t, op.QPS, traffic.Operations[i].QPS, 1e-9)
+		assert.InDelta(t, op.TracesPerSecond, traffic.Operations[i].TracesPerSecond, 1e-9)
+		assert.Zero(t, traffic.Operations[i].TraceSize)
+	}
+	assert.Equal(t, map[string]map[string]float64{
+		"svcA": {"GET": traffic.Operations[0].QPS, "PUT": traffic.Operations[1].QPS},
+		"svcB": {"GET": traffic.Operations[2].QPS},
+	}, traffic.Services())
+
+	store = &samplingStoreMocks.Store{}
+	store.On("GetThroughput", start, end).Return(nil, errors.New("storage error"))
+	_, err = LoadThroughput(store, start, end)
+	assert.EqualError(t, err, "failed to read the throughput: storage error")
+}
+
+func makeTrace(service, operation string, tags ...model.KeyValue) *model.Trace {
+	root := &model.Span{
+		TraceID:       model.NewTraceID(0, 1),
+		SpanID:        model.NewSpanID(1),
+		OperationName: operation,
+		Process:       model.NewProcess(service, nil),
+		Tags:          tags,
+	}
+	child := &model.Span{
+		TraceID:       model.NewTraceID(0, 1),
+		SpanID:        model.NewSpanID(2),
+		OperationName: "query",
+		References:    []model.SpanRef{model.NewChildOfRef(model.NewTraceID(0, 1), model.NewSpanID(1))},
+		Process:       model.NewProcess("db", nil),
+	}
+	return &model.Trace{Spans: []*model.Span{root, child}}
+}
+
+func TestLoadSpans(t *testing.T) {
+	start, end := time.Unix(0, 0), time.Unix(10, 0)
+	probabilistic := func(param model.KeyValue) []model.KeyValue {
+		return []model.KeyValue{model.String(samplerTypeTagKey, samplerTypeProb), param}
+	}
+	frontendTraces := []*model.Trace{
+		makeTrace("frontend", "GET", probabilistic(model.Float64(samplerParamTagKey, 0.5))...),
+		makeTrace("frontend", "GET", probabilistic(model.String(samplerParamTagKey, "0.25"))...),
+		makeTrace("frontend", "PUT", model.String(samplerTypeTagKey, "ratelimiting"), model.Float64(samplerParamTagKey, 2)),
+		makeTrace("frontend", "PUT", probabilistic(model.Float64(samplerParamTagKey, 2))...),
+		makeTrace("frontend", "PUT", model.String(samplerTypeTagKey, samplerTypeProb)),
+		{Spans: makeTrace("frontend", "HEAD").Spans[1:]},
+	}
+	reader := &spanStoreMocks.Reader{}
+	reader.On("GetServices", mock.Anything).Return([]string{"frontend", "db"}, nil)
+	reader.On("FindTraces", mock.Anything, &spanstore.TraceQueryParameters{
+		ServiceName: "frontend", StartTimeMin: start, StartTimeMax: end, NumTraces: 6,
+	}).Return(frontendTraces, nil)
+	reader.On("FindTraces", mock.Anything, &spanstore.TraceQueryParameters{
+		ServiceName: "db", StartTimeMin: start, StartTimeMax: end, NumTraces: 6,
+	}).Return(frontendTraces[:2], nil)
+
+	traffic, err := LoadSpans(context.Background(), reader, start, end, 6)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"frontend"}, traffic.Truncated)
+	require.Len(t, traffic.Operations, 2)
+	get, put := traffic.Operations[0], traffic.Operations[1]
+	assert.Equal(t, "GET", get.Operation)
+	assert.InDelta(t, 0.6, get.QPS, 1e-9)
+	assert.InDelta(t, 0.2, get.TracesPerSecond, 1e-9)
+	assert.InDelta(t, float64(frontendTraces[0].Spans[0].Size()+frontendTraces[0].Spans[1].Size()+
+		frontendTraces[1].Spans[0].Size()+frontendTraces[1].Spans[1].Size())/2, get.TraceSize, 1e-9)
+	assert.Equal(t, "PUT", put.Operation)
+	assert.InDelta(t, 0.3, put.QPS, 1e-9)
+	assert.InDelta(t, 0.3, put.TracesPerSecond, 1e-9)
+
+	reader = &spanStoreMocks.Reader{}
+	reader.On("GetServices", mock.Anything).Return(nil, errors.New("storage error"))
+	_, err = LoadSpans(context.Background(), reader, start, end, 6)
+	assert.EqualError(t, err, "failed to read the services: storage error")
+
+	reader = &spanStoreMocks.Reader{}
+	reader.On("GetServices", mock.Anything).Return([]string{"frontend"}, nil)
+	reader.On("FindTraces", mock.Anything, mock.Anything).Return(nil, errors.New("storage error"))
+	_, err = LoadSpans(context.Background(), reader, start, end, 6)
+	assert.EqualError(t, err, "failed to read the traces of service frontend: storage error")
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/cmd/docs"
+	"github.com/jaegertracing/jaeger/cmd/env"
+	"github.com/jaegertracing/jaeger/cmd/sampling-simulator/app"
+	"github.com/jaegertracing/jaeger/pkg/config"
+	"github.com/jaegertracing/jaeger/pkg/version"
+	"github.com/jaegertracing/jaeger/plugin/sampling/strategystore/adaptive"
+	"github.com/jaegertracing/jaeger/plugin/sampling/strategystore/static"
+	"github.com/jaegertracing/jaeger/plugin/storage"
+)
+
+var logger, _ = zap.NewDevelopment()
+
+func main() {
+	storageFactory, err := storage.NewFactory(storage.FactoryConfigFromEnvAndCLI(os.Args, os.Stderr))
+	if err != nil {
+		log.Fatalf("Cannot initialize storage factory: %v", err)
+	}
+
+	v := viper.New()
+	command := &cobra.Command{
+		Use:   "jaeger-sampling-simulator",
+		Short: "Jaeger sampling simulator projects the traces sampled with proposed sampling strategies",
+		Long: `Jaeger sampling simulator reads the traffic observed with the current sampling configuration from storage, ` +
+			`and reports the sampled traces per second and storage volume of every service and operation with the proposed ` +
+			`static strategies file or adaptive sampling options, compared to the current configuration.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options := new(app.Options).InitFromViper(v)
+			strategies, err := options.NewStrategyProvider(v, logger)
+			if err != nil {
+				return err
+			}
+
+			storageFactory.InitFromViper(v, logger)
+			if err := storageFactory.Initialize(metrics.NullFactory, logger); err != nil {
+				return fmt.Errorf("failed to init storage factory: %w", err)
+			}
+			defer storageFactory.Close()
+
+			traffic, err := options.LoadTraffic(context.Background(), storageFactory, time.Now())
+			if err != nil {
+				return err
+			}
+			report, err := app.Project(traffic, strategies, options.ServiceInstances, options.TraceSize)
+			if err != nil {
+				return err
+			}
+			return report.Write(os.Stdout)
+		},
+	}
+
+	command.AddCommand(version.Command())
+	command.AddCommand(env.Command())
+	command.AddCommand(docs.Command(v))
+
+	config.AddFlags(
+		v,
+		command,
+		app.AddFlags,
+		storageFactory.AddFlags,
+		static.AddFlags,
+		adaptive.AddFlags,
+	)
+
+	if err := command.Execute(); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
@@ -32,10 +32,14 @@ func ConvertSamplingResponseFromDomain(r *api_v2.SamplingStrategyResponse) (*sam
 	if err != nil {
 		return nil, err
 	}
+	ops, err := convertPerOperationFromDomain(r.GetOperationSampling())
+	if err != nil {
+		return nil, err
+	}
 	thriftResp := &sampling.SamplingStrategyResponse{StrategyType: typ,
 		ProbabilisticSampling: convertProbabilisticFromDomain(r.GetProbabilisticSampling()),
 		RateLimitingSampling:  rl,
-		OperationSampling:     convertPerOperationFromDomain(r.GetOperationSampling()),
+		OperationSampling:     ops,
 	}
 	return thriftResp, nil
 }
@@ -57,9 +61,9 @@ func convertRateLimitingFromDomain(s *api_v2.RateLimitingSamplingStrategy) (*sam
 	return &sampling.RateLimitingSamplingStrategy{MaxTracesPerSecond: int16(s.GetMaxTracesPerSecond())}, nil
 }
 
-func convertPerOperationFromDomain(s *api_v2.PerOperationSamplingStrategies) *sampling.PerOperationSamplingStrategies {
+func convertPerOperationFromDomain(s *api_v2.PerOperationSamplingStrategies) (*sampling.PerOperationSamplingStrategies, error) {
 	if s == nil {
-		return nil
+		return nil, nil
 	}
 	r := &sampling.PerOperationSamplingStrategies{
 		DefaultSamplingProbability:       s.GetDefaultSamplingProbability(),
@@ -69,20 +73,29 @@ func convertPerOperationFromDomain(s *api_v2.PerOperationSamplingStrategies) *sa
 	if s.GetPerOperationStrategies() != nil {
 		r.PerOperationStrategies = make([]*sampling.OperationSamplingStrategy, len(s.GetPerOperationStrategies()))
 		for i, k := range s.PerOperationStrategies {
-			r.PerOperationStrategies[i] = convertOperationFromDomain(k)
+			op, err := convertOperationFromDomain(k)
+			if err != nil {
+				return nil, err
+			}
+			r.PerOperationStrategies[i] = op
 		}
 	}
-	return r
+	return r, nil
 }
 
-func convertOperationFromDomain(s *api_v2.OperationSamplingStrategy) *sampling.OperationSamplingStrategy {
+func convertOperationFromDomain(s *api_v2.OperationSamplingStrategy) (*sampling.OperationSamplingStrategy, error) {
 	if s == nil {
-		return nil
+		return nil, nil
+	}
+	rl, err := convertRateLimitingFromDomain(s.GetRateLimitingSampling())
+	if err != nil {
+		return nil, err
 	}
 	return &sampling.OperationSamplingStrategy{
 		Operation:             s.GetOperation(),
 		ProbabilisticSampling: convertProbabilisticFromDomain(s.GetProbabilisticSampling()),
-	}
+		RateLimitingSampling:  rl,
+	}, nil
 }
 
 func convertStrategyTypeFromDomain(s api_v2.SamplingStrategyType) (sampling.SamplingStrategyType, error) {
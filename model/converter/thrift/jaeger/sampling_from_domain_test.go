@@ -86,15 +86,28 @@ func TestConvertOperationStrategyFromDomain(t *testing.T) {
 	tests := []struct {
 		in       *api_v2.OperationSamplingStrategy
 		expected *sampling.OperationSamplingStrategy
+		err      string
 	}{
 		{in: &api_v2.OperationSamplingStrategy{Operation: "foo"}, expected: &sampling.OperationSamplingStrategy{Operation: "foo"}},
 		{in: &api_v2.OperationSamplingStrategy{Operation: "foo", ProbabilisticSampling: &api_v2.ProbabilisticSamplingStrategy{SamplingRate: 2}},
 			expected: &sampling.OperationSamplingStrategy{Operation: "foo", ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: 2}}},
+		{in: &api_v2.OperationSamplingStrategy{Operation: "foo", ProbabilisticSampling: &api_v2.ProbabilisticSamplingStrategy{SamplingRate: 2},
+			RateLimitingSampling: &api_v2.RateLimitingSamplingStrategy{MaxTracesPerSecond: 5}},
+			expected: &sampling.OperationSamplingStrategy{Operation: "foo", ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: 2},
+				RateLimitingSampling: &sampling.RateLimitingSamplingStrategy{MaxTracesPerSecond: 5}}},
+		{in: &api_v2.OperationSamplingStrategy{Operation: "foo", RateLimitingSampling: &api_v2.RateLimitingSamplingStrategy{MaxTracesPerSecond: math.MaxInt32}},
+			err: "maxTracesPerSecond is higher than int16"},
 		{},
 	}
 	for _, test := range tests {
-		o := convertOperationFromDomain(test.in)
-		assert.Equal(t, test.expected, o)
+		o, err := convertOperationFromDomain(test.in)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err)
+			require.Nil(t, o)
+		} else {
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, o)
+		}
 	}
 }
 
@@ -103,16 +116,26 @@ func TestConvertPerOperationStrategyFromDomain(t *testing.T) {
 	tests := []struct {
 		in       *api_v2.PerOperationSamplingStrategies
 		expected *sampling.PerOperationSamplingStrategies
+		err      string
 	}{
 		{in: &api_v2.PerOperationSamplingStrategies{DefaultSamplingProbability: 15.2, DefaultUpperBoundTracesPerSecond: a, DefaultLowerBoundTracesPerSecond: 2,
 			PerOperationStrategies: []*api_v2.OperationSamplingStrategy{{Operation: "fao"}}},
 			expected: &sampling.PerOperationSamplingStrategies{DefaultSamplingProbability: 15.2, DefaultUpperBoundTracesPerSecond: &a, DefaultLowerBoundTracesPerSecond: 2,
 				PerOperationStrategies: []*sampling.OperationSamplingStrategy{{Operation: "fao"}}}},
+		{in: &api_v2.PerOperationSamplingStrategies{
+			PerOperationStrategies: []*api_v2.OperationSamplingStrategy{{Operation: "fao", RateLimitingSampling: &api_v2.RateLimitingSamplingStrategy{MaxTracesPerSecond: math.MaxInt32}}}},
+			err: "maxTracesPerSecond is higher than int16"},
 		{},
 	}
 	for _, test := range tests {
-		o := convertPerOperationFromDomain(test.in)
-		assert.Equal(t, test.expected, o)
+		o, err := convertPerOperationFromDomain(test.in)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err)
+			require.Nil(t, o)
+		} else {
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, o)
+		}
 	}
 }
 
@@ -62,6 +62,7 @@ func convertPerOperationToDomain(s *sampling.PerOperationSamplingStrategies) *ap
 		poss[i] = &api_v2.OperationSamplingStrategy{
 			Operation:             pos.Operation,
 			ProbabilisticSampling: convertProbabilisticToDomain(pos.GetProbabilisticSampling()),
+			RateLimitingSampling:  convertRateLimitingToDomain(pos.GetRateLimitingSampling()),
 		}
 	}
 	return &api_v2.PerOperationSamplingStrategies{
@@ -84,6 +84,12 @@ func TestConvertPerOperationStrategyToDomain(t *testing.T) {
 			PerOperationStrategies: []*api_v2.OperationSamplingStrategy{{Operation: "fao"}}},
 			in: &sampling.PerOperationSamplingStrategies{DefaultSamplingProbability: 15.2, DefaultUpperBoundTracesPerSecond: &a, DefaultLowerBoundTracesPerSecond: 2,
 				PerOperationStrategies: []*sampling.OperationSamplingStrategy{{Operation: "fao"}}}},
+		{expected: &api_v2.PerOperationSamplingStrategies{
+			PerOperationStrategies: []*api_v2.OperationSamplingStrategy{{Operation: "fao", ProbabilisticSampling: &api_v2.ProbabilisticSamplingStrategy{SamplingRate: 0.1},
+				RateLimitingSampling: &api_v2.RateLimitingSamplingStrategy{MaxTracesPerSecond: 5}}}},
+			in: &sampling.PerOperationSamplingStrategies{
+				PerOperationStrategies: []*sampling.OperationSamplingStrategy{{Operation: "fao", ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: 0.1},
+					RateLimitingSampling: &sampling.RateLimitingSamplingStrategy{MaxTracesPerSecond: 5}}}}},
 		{},
 	}
 	for _, test := range tests {
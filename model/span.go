@@ -73,6 +73,21 @@ func (s *Span) GetSamplerType() string {
 	return samplerTypeUnknown
 }
 
+// HasErrorTag returns true if the span has an `error` tag set to true, as a bool or a string.
+func (s *Span) HasErrorTag() bool {
+	tag, ok := KeyValues(s.Tags).FindByKey(string(ext.Error))
+	if !ok {
+		return false
+	}
+	switch tag.VType {
+	case BoolType:
+		return tag.Bool()
+	case StringType:
+		return tag.VStr == "true"
+	}
+	return false
+}
+
 // IsRPCClient returns true if the span represents a client side of an RPC,
 // as indicated by the `span.kind` tag set to `client`.
 func (s *Span) IsRPCClient() bool {
@@ -242,6 +242,14 @@ func TestSamplerType(t *testing.T) {
 	assert.Equal(t, "unknown", span.GetSamplerType())
 }
 
+func TestHasErrorTag(t *testing.T) {
+	assert.True(t, makeSpan(model.Bool("error", true)).HasErrorTag())
+	assert.True(t, makeSpan(model.String("error", "true")).HasErrorTag())
+	assert.False(t, makeSpan(model.Bool("error", false)).HasErrorTag())
+	assert.False(t, makeSpan(model.Int64("error", 1)).HasErrorTag())
+	assert.False(t, makeSpan(model.KeyValue{}).HasErrorTag())
+}
+
 func TestIsSampled(t *testing.T) {
 	flags := model.Flags(0)
 	flags.SetSampled()
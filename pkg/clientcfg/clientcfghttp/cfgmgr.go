@@ -34,6 +34,16 @@ func (c *ConfigManager) GetSamplingStrategy(ctx context.Context, serviceName str
 	return c.SamplingStrategyStore.GetSamplingStrategy(ctx, serviceName)
 }
 
+// GetSamplingStrategyWithSources retrieves the sampling strategy and the sources that decided it,
+// the sources are nil unless the strategy store implements strategystore.StrategySourcesStore.
+func (c *ConfigManager) GetSamplingStrategyWithSources(ctx context.Context, serviceName string) (*sampling.SamplingStrategyResponse, *strategystore.StrategySources, error) {
+	if store, ok := c.SamplingStrategyStore.(strategystore.StrategySourcesStore); ok {
+		return store.GetSamplingStrategyWithSources(ctx, serviceName)
+	}
+	strategy, err := c.SamplingStrategyStore.GetSamplingStrategy(ctx, serviceName)
+	return strategy, nil, err
+}
+
 // GetBaggageRestrictions implements ClientConfigManager.GetBaggageRestrictions.
 func (c *ConfigManager) GetBaggageRestrictions(ctx context.Context, serviceName string) ([]*baggage.BaggageRestriction, error) {
 	if c.BaggageManager == nil {
@@ -22,21 +22,38 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
 	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
 	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
 )
 
 type mockSamplingStore struct {
 	samplingResponse *sampling.SamplingStrategyResponse
+	// attributes are the client attributes of the last request
+	attributes *strategystore.ClientAttributes
 }
 
-func (m *mockSamplingStore) GetSamplingStrategy(_ context.Context, serviceName string) (*sampling.SamplingStrategyResponse, error) {
+func (m *mockSamplingStore) GetSamplingStrategy(ctx context.Context, serviceName string) (*sampling.SamplingStrategyResponse, error) {
+	m.attributes = strategystore.GetClientAttributes(ctx)
 	if m.samplingResponse == nil {
 		return nil, errors.New("no mock response provided")
 	}
 	return m.samplingResponse, nil
 }
 
+type mockSourcesStore struct {
+	mockSamplingStore
+	sources *strategystore.StrategySources
+}
+
+func (m *mockSourcesStore) GetSamplingStrategyWithSources(ctx context.Context, serviceName string) (*sampling.SamplingStrategyResponse, *strategystore.StrategySources, error) {
+	strategy, err := m.GetSamplingStrategy(ctx, serviceName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return strategy, m.sources, nil
+}
+
 type mockBaggageMgr struct {
 	baggageResponse []*baggage.BaggageRestriction
 }
@@ -61,6 +78,21 @@ func TestConfigManager(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, sampling.SamplingStrategyResponse{}, *r)
 	})
+	t.Run("GetSamplingStrategyWithSources", func(t *testing.T) {
+		r, sources, err := mgr.GetSamplingStrategyWithSources(context.Background(), "foo")
+		require.NoError(t, err)
+		assert.Equal(t, sampling.SamplingStrategyResponse{}, *r)
+		assert.Nil(t, sources)
+
+		sourcesMgr := &ConfigManager{SamplingStrategyStore: &mockSourcesStore{
+			mockSamplingStore: mockSamplingStore{samplingResponse: &sampling.SamplingStrategyResponse{}},
+			sources:           &strategystore.StrategySources{Default: "static"},
+		}}
+		r, sources, err = sourcesMgr.GetSamplingStrategyWithSources(context.Background(), "foo")
+		require.NoError(t, err)
+		assert.Equal(t, sampling.SamplingStrategyResponse{}, *r)
+		assert.Equal(t, &strategystore.StrategySources{Default: "static"}, sources)
+	})
 	t.Run("GetBaggageRestrictions", func(t *testing.T) {
 		expResp := []*baggage.BaggageRestriction{}
 		bgm.baggageResponse = expResp
@@ -16,6 +16,7 @@
 package clientcfghttp
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -26,6 +27,7 @@ import (
 	"github.com/uber/jaeger-lib/metrics"
 
 	"github.com/jaegertracing/jaeger/cmd/agent/app/configmanager"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
 	tSampling "github.com/jaegertracing/jaeger/thrift-gen/sampling"
 )
 
@@ -35,6 +37,18 @@ var (
 	errBadRequest = errors.New("bad request")
 )
 
+// samplingSourcesManager is implemented by the config managers that can tell which sources
+// decided a sampling strategy.
+type samplingSourcesManager interface {
+	GetSamplingStrategyWithSources(ctx context.Context, serviceName string) (*tSampling.SamplingStrategyResponse, *strategystore.StrategySources, error)
+}
+
+// samplingStrategyWithSources is the response of the sampling endpoint with debug=true.
+type samplingStrategyWithSources struct {
+	*tSampling.SamplingStrategyResponse
+	Debug *strategystore.StrategySources `json:"debug,omitempty"`
+}
+
 // HTTPHandlerParams contains parameters that must be passed to NewHTTPHandler.
 type HTTPHandlerParams struct {
 	ConfigManager  configmanager.ClientConfigManager // required
@@ -112,6 +126,30 @@ func (h *HTTPHandler) serviceFromRequest(w http.ResponseWriter, r *http.Request)
 	return services[0], nil
 }
 
+// clientAttributesFromRequest returns the optional attributes of the client requesting a sampling strategy:
+// the 'environment', 'hostname' and 'sdk_version' parameters, and the 'tag' parameters formatted as key:value.
+func (h *HTTPHandler) clientAttributesFromRequest(w http.ResponseWriter, r *http.Request) (*strategystore.ClientAttributes, error) {
+	query := r.URL.Query()
+	attributes := &strategystore.ClientAttributes{
+		Environment: query.Get("environment"),
+		Hostname:    query.Get("hostname"),
+		SDKVersion:  query.Get("sdk_version"),
+	}
+	for _, tag := range query["tag"] {
+		kv := strings.SplitN(tag, ":", 2)
+		if len(kv) != 2 {
+			h.metrics.BadRequest.Inc(1)
+			http.Error(w, fmt.Sprintf("malformed 'tag' parameter %q, expected key:value", tag), http.StatusBadRequest)
+			return nil, errBadRequest
+		}
+		if attributes.Tags == nil {
+			attributes.Tags = make(map[string]string)
+		}
+		attributes.Tags[kv[0]] = kv[1]
+	}
+	return attributes, nil
+}
+
 func (h *HTTPHandler) writeJSON(w http.ResponseWriter, json []byte) error {
 	w.Header().Add("Content-Type", mimeTypeApplicationJSON)
 	if _, err := w.Write(json); err != nil {
@@ -126,12 +164,29 @@ func (h *HTTPHandler) serveSamplingHTTP(w http.ResponseWriter, r *http.Request,
 	if err != nil {
 		return
 	}
-	resp, err := h.params.ConfigManager.GetSamplingStrategy(r.Context(), service)
+	attributes, err := h.clientAttributesFromRequest(w, r)
 	if err != nil {
-		h.metrics.CollectorProxyFailures.Inc(1)
-		http.Error(w, fmt.Sprintf("collector error: %+v", err), http.StatusInternalServerError)
 		return
 	}
+	ctx := strategystore.ContextWithClientAttributes(r.Context(), attributes)
+	var resp interface{}
+	if sourcesManager, ok := h.params.ConfigManager.(samplingSourcesManager); ok && r.URL.Query().Get("debug") == "true" {
+		strategy, sources, err := sourcesManager.GetSamplingStrategyWithSources(ctx, service)
+		if err != nil {
+			h.metrics.CollectorProxyFailures.Inc(1)
+			http.Error(w, fmt.Sprintf("collector error: %+v", err), http.StatusInternalServerError)
+			return
+		}
+		resp = &samplingStrategyWithSources{SamplingStrategyResponse: strategy, Debug: sources}
+	} else {
+		strategy, err := h.params.ConfigManager.GetSamplingStrategy(ctx, service)
+		if err != nil {
+			h.metrics.CollectorProxyFailures.Inc(1)
+			http.Error(w, fmt.Sprintf("collector error: %+v", err), http.StatusInternalServerError)
+			return
+		}
+		resp = strategy
+	}
 	jsonBytes, err := json.Marshal(resp)
 	if err != nil {
 		h.metrics.BadThriftFailures.Inc(1)
@@ -29,6 +29,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/uber/jaeger-lib/metrics/metricstest"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
 	tSampling092 "github.com/jaegertracing/jaeger/pkg/clientcfg/clientcfghttp/thrift-0.9.2"
 	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
 	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
@@ -128,6 +129,123 @@ func testHTTPHandler(t *testing.T, basePath string) {
 	})
 }
 
+func TestHTTPHandlerOperationRateLimiting(t *testing.T) {
+	strategy := probabilistic(0.001)
+	strategy.OperationSampling = &sampling.PerOperationSamplingStrategies{
+		DefaultSamplingProbability: 0.001,
+		PerOperationStrategies: []*sampling.OperationSamplingStrategy{
+			{
+				Operation:             "GET",
+				ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: 0.5},
+			},
+			{
+				Operation:             "POST",
+				ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: 0.001},
+				RateLimitingSampling:  &sampling.RateLimitingSamplingStrategy{MaxTracesPerSecond: 10},
+			},
+		},
+	}
+	withServer("", strategy, nil, func(ts *testServer) {
+		expectedOperations := `[
+			{"operation": "GET", "probabilisticSampling": {"samplingRate": 0.5}},
+			{"operation": "POST", "probabilisticSampling": {"samplingRate": 0.001}, "rateLimitingSampling": {"maxTracesPerSecond": 10}}
+		]`
+		for _, endpoint := range []string{"/", "/sampling"} {
+			resp, err := http.Get(ts.server.URL + endpoint + "?service=Y")
+			require.NoError(t, err)
+			body, err := ioutil.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var raw struct {
+				OperationSampling struct {
+					PerOperationStrategies json.RawMessage `json:"perOperationStrategies"`
+				} `json:"operationSampling"`
+			}
+			require.NoError(t, json.Unmarshal(body, &raw))
+			assert.JSONEq(t, expectedOperations, string(raw.OperationSampling.PerOperationStrategies), endpoint)
+
+			if endpoint == "/" {
+				// clients generated with Thrift 0.9.2 ignore the rate limits of the operations
+				objResp := &tSampling092.SamplingStrategyResponse{}
+				require.NoError(t, json.Unmarshal(body, objResp))
+				require.Len(t, objResp.GetOperationSampling().GetPerOperationStrategies(), 2)
+				assert.Equal(t, 0.001, objResp.GetOperationSampling().GetPerOperationStrategies()[1].GetProbabilisticSampling().GetSamplingRate())
+			} else {
+				objResp := &sampling.SamplingStrategyResponse{}
+				require.NoError(t, json.Unmarshal(body, objResp))
+				assert.Equal(t, strategy, objResp)
+			}
+		}
+	})
+}
+
+func TestHTTPHandlerClientAttributes(t *testing.T) {
+	withServer("", probabilistic(0.001), nil, func(ts *testServer) {
+		get := func(query string) {
+			resp, err := http.Get(ts.server.URL + "/sampling?service=Y" + query)
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+		}
+
+		get("")
+		assert.Nil(t, ts.samplingStore.attributes)
+
+		get("&environment=canary&hostname=host-1&sdk_version=Go-2.29.1&tag=region:eu&tag=url:http://localhost")
+		assert.Equal(t, &strategystore.ClientAttributes{
+			Environment: "canary",
+			Hostname:    "host-1",
+			SDKVersion:  "Go-2.29.1",
+			Tags:        map[string]string{"region": "eu", "url": "http://localhost"},
+		}, ts.samplingStore.attributes)
+	})
+}
+
+func TestHTTPHandlerDebugSources(t *testing.T) {
+	metricsFactory := metricstest.NewFactory(0)
+	store := &mockSourcesStore{
+		mockSamplingStore: mockSamplingStore{samplingResponse: probabilistic(0.001)},
+		sources: &strategystore.StrategySources{
+			Default:    "adaptive",
+			Operations: map[string]string{"GET": "static"},
+		},
+	}
+	handler := NewHTTPHandler(HTTPHandlerParams{
+		ConfigManager:  &ConfigManager{SamplingStrategyStore: store},
+		MetricsFactory: metricsFactory,
+	})
+	r := mux.NewRouter()
+	handler.RegisterRoutes(r)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	get := func(query string) map[string]json.RawMessage {
+		resp, err := http.Get(server.URL + "/sampling?service=Y" + query)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var body map[string]json.RawMessage
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return body
+	}
+
+	body := get("")
+	assert.NotContains(t, body, "debug")
+	assert.Contains(t, body, "probabilisticSampling")
+
+	body = get("&debug=true")
+	assert.JSONEq(t, `{"default": "adaptive", "operations": {"GET": "static"}}`, string(body["debug"]))
+	assert.Contains(t, body, "probabilisticSampling")
+
+	store.samplingResponse = nil
+	resp, err := http.Get(server.URL + "/sampling?service=Y&debug=true")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+}
+
 func TestHTTPHandlerErrors(t *testing.T) {
 	testCases := []struct {
 		description          string
@@ -165,6 +283,15 @@ func TestHTTPHandlerErrors(t *testing.T) {
 				{Name: "http-server.errors", Tags: map[string]string{"source": "all", "status": "4xx"}, Value: 1},
 			},
 		},
+		{
+			description: "sampling endpoint malformed tag",
+			url:         "/sampling?service=Y&tag=region",
+			statusCode:  http.StatusBadRequest,
+			body:        "malformed 'tag' parameter \"region\", expected key:value\n",
+			metrics: []metricstest.ExpectedMetric{
+				{Name: "http-server.errors", Tags: map[string]string{"source": "all", "status": "4xx"}, Value: 1},
+			},
+		},
 		{
 			description: "sampler collector error",
 			url:         "?service=Y",
@@ -0,0 +1,102 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package locktest provides the conformance tests of the implementations of distributedlock.Lock.
+package locktest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/pkg/distributedlock"
+)
+
+const (
+	alice = "alice"
+	bob   = "bob"
+)
+
+// Suite tests that an implementation of distributedlock.Lock follows the contract of the interface:
+// a lease is held by a single owner until it expires, it is extended when its owner acquires it again,
+// and only its owner can forfeit it.
+type Suite struct {
+	// NewLock returns the lock of an owner. The locks of all the owners must share the same backend,
+	// which must hold no lease.
+	NewLock func(owner string) distributedlock.Lock
+	// TTL is the duration of the leases acquired by the tests, which wait for them to expire.
+	TTL time.Duration
+}
+
+// Run runs the conformance tests.
+func (s *Suite) Run(t *testing.T) {
+	t.Run("Acquire", s.testAcquire)
+	t.Run("Extend", s.testExtend)
+	t.Run("Forfeit", s.testForfeit)
+	t.Run("Expiry", s.testExpiry)
+}
+
+func (s *Suite) acquire(t *testing.T, owner, resource string, expected bool) {
+	acquired, err := s.NewLock(owner).Acquire(resource, s.TTL)
+	require.NoError(t, err)
+	assert.Equal(t, expected, acquired, "%s acquires %s", owner, resource)
+}
+
+func (s *Suite) forfeit(t *testing.T, owner, resource string, expected bool) {
+	forfeited, err := s.NewLock(owner).Forfeit(resource)
+	if expected {
+		require.NoError(t, err)
+		assert.True(t, forfeited, "%s forfeits %s", owner, resource)
+		return
+	}
+	assert.Error(t, err, "%s does not own %s", owner, resource)
+}
+
+func (s *Suite) testAcquire(t *testing.T) {
+	s.acquire(t, alice, "acquire", true)
+	s.acquire(t, bob, "acquire", false)
+	s.acquire(t, bob, "acquire-other", true)
+	s.acquire(t, alice, "acquire-other", false)
+}
+
+func (s *Suite) testExtend(t *testing.T) {
+	s.acquire(t, alice, "extend", true)
+	time.Sleep(s.TTL / 2)
+	s.acquire(t, alice, "extend", true)
+	// the first lease has expired, the extended one has not
+	time.Sleep(s.TTL * 3 / 4)
+	s.acquire(t, bob, "extend", false)
+}
+
+func (s *Suite) testForfeit(t *testing.T) {
+	s.forfeit(t, bob, "forfeit", false)
+	s.acquire(t, alice, "forfeit", true)
+	s.forfeit(t, bob, "forfeit", false)
+	s.acquire(t, bob, "forfeit", false)
+
+	s.forfeit(t, alice, "forfeit", true)
+	s.forfeit(t, alice, "forfeit", false)
+	s.acquire(t, bob, "forfeit", true)
+}
+
+func (s *Suite) testExpiry(t *testing.T) {
+	s.acquire(t, alice, "expiry", true)
+	s.acquire(t, bob, "expiry", false)
+	time.Sleep(s.TTL * 3 / 2)
+	s.forfeit(t, alice, "expiry", false)
+	s.acquire(t, bob, "expiry", true)
+	s.acquire(t, alice, "expiry", false)
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package es
+
+import (
+	"context"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
+)
+
+// tenantIndexSeparator separates the tenant from the rest of the index name.
+const tenantIndexSeparator = "-"
+
+// safeTenant matches the tenants that can be used verbatim at the start of an index name.
+var safeTenant = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// TenantIndexPrefix returns the prefix of the indices of the tenant in the context, if any.
+// The tenant is prepended to the configured index prefix so that the index templates,
+// which match any leading characters, are applied to the indices of every tenant.
+//
+// Tenants are validated when they arrive, but as they end up in index names which
+// also accept wildcards and comma separated lists in searches, any tenant that cannot
+// be used verbatim is hex encoded. The encoded form contains a '.', which no valid
+// tenant does, so it never collides with the indices of another tenant.
+func TenantIndexPrefix(ctx context.Context) string {
+	tenant := tenancy.GetTenant(ctx)
+	if tenant == "" {
+		return ""
+	}
+	if !safeTenant.MatchString(tenant) {
+		tenant = "tenant." + hex.EncodeToString([]byte(tenant))
+	}
+	return tenant + tenantIndexSeparator
+}
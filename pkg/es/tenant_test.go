@@ -0,0 +1,48 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package es
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
+)
+
+func TestTenantIndexPrefix(t *testing.T) {
+	tests := []struct {
+		tenant   string
+		expected string
+	}{
+		{tenant: "", expected: ""},
+		{tenant: "acme", expected: "acme-"},
+		{tenant: "acme_corp-2", expected: "acme_corp-2-"},
+		{tenant: "*", expected: "tenant.2a-"},
+		{tenant: "a,b", expected: "tenant.612c62-"},
+		{tenant: "Acme", expected: "tenant.41636d65-"},
+		{tenant: "_acme", expected: "tenant.5f61636d65-"},
+	}
+	for _, test := range tests {
+		t.Run(test.tenant, func(t *testing.T) {
+			ctx := context.Background()
+			if test.tenant != "" {
+				ctx = tenancy.WithTenant(ctx, test.tenant)
+			}
+			assert.Equal(t, test.expected, TenantIndexPrefix(ctx))
+		})
+	}
+}
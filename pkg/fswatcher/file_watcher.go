@@ -0,0 +1,88 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fswatcher
+
+import (
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// FileWatcher calls a function every time a file is written, created or replaced.
+type FileWatcher struct {
+	file     string
+	onChange func()
+	logger   *zap.Logger
+	watcher  Watcher
+	wg       sync.WaitGroup
+}
+
+// NewFileWatcher starts watching the file with a Watcher created by newWatcher, NewWatcher is used if nil.
+// The directory of the file is watched, since the file may be replaced rather than written to.
+func NewFileWatcher(file string, onChange func(), logger *zap.Logger, newWatcher func() (Watcher, error)) (*FileWatcher, error) {
+	if newWatcher == nil {
+		newWatcher = NewWatcher
+	}
+	watcher, err := newWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(filepath.Dir(file)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	w := &FileWatcher{
+		file:     file,
+		onChange: onChange,
+		logger:   logger,
+		watcher:  watcher,
+	}
+	w.wg.Add(1)
+	go w.watch()
+	return w, nil
+}
+
+func (w *FileWatcher) watch() {
+	defer w.wg.Done()
+	for {
+		select {
+		case event, ok := <-w.watcher.Events():
+			if !ok {
+				return
+			}
+			if filepath.Base(event.Name) != filepath.Base(w.file) {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			w.onChange()
+		case err, ok := <-w.watcher.Errors():
+			if !ok {
+				return
+			}
+			w.logger.Error("error watching file", zap.String("file", w.file), zap.Error(err))
+		}
+	}
+}
+
+// Close stops watching the file, onChange is not called after Close returns.
+func (w *FileWatcher) Close() error {
+	err := w.watcher.Close()
+	w.wg.Wait()
+	return err
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fswatcher
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+func TestFileWatcher(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.json")
+	require.NoError(t, ioutil.WriteFile(file, []byte("{}"), 0600))
+
+	changes := atomic.NewInt32(0)
+	w, err := NewFileWatcher(file, func() { changes.Inc() }, zap.NewNop(), nil)
+	require.NoError(t, err)
+
+	// other files in the directory are ignored
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "other.json"), []byte("{}"), 0600))
+	require.NoError(t, ioutil.WriteFile(file, []byte(`{"a": 1}`), 0600))
+	assert.Eventually(t, func() bool { return changes.Load() > 0 }, 5*time.Second, 10*time.Millisecond)
+
+	// the file is replaced
+	replacement := filepath.Join(dir, "replacement.json")
+	require.NoError(t, ioutil.WriteFile(replacement, []byte(`{"a": 2}`), 0600))
+	before := changes.Load()
+	require.NoError(t, os.Rename(replacement, file))
+	assert.Eventually(t, func() bool { return changes.Load() > before }, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, w.Close())
+}
+
+type fakeWatcher struct {
+	Watcher
+	addErr error
+	closed bool
+}
+
+func (w *fakeWatcher) Add(string) error {
+	return w.addErr
+}
+
+func (w *fakeWatcher) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestFileWatcherErrors(t *testing.T) {
+	_, err := NewFileWatcher("config.json", func() {}, zap.NewNop(), func() (Watcher, error) {
+		return nil, errors.New("no watcher")
+	})
+	assert.EqualError(t, err, "no watcher")
+
+	watcher := &fakeWatcher{addErr: os.ErrNotExist}
+	_, err = NewFileWatcher("config.json", func() {}, zap.NewNop(), func() (Watcher, error) {
+		return watcher, nil
+	})
+	assert.Equal(t, os.ErrNotExist, err)
+	assert.True(t, watcher.closed)
+}
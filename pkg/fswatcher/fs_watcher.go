@@ -22,6 +22,7 @@ type Watcher interface {
 	Add(name string) error
 	Events() chan fsnotify.Event
 	Errors() chan error
+	Close() error
 }
 
 // fsnotifyWatcherWrapper wraps the fsnotify.Watcher and implements Watcher.
@@ -44,6 +45,11 @@ func (f *fsnotifyWatcherWrapper) Errors() chan error {
 	return f.fsnotifyWatcher.Errors
 }
 
+// Close stops watching and closes the Events and Errors chans.
+func (f *fsnotifyWatcherWrapper) Close() error {
+	return f.fsnotifyWatcher.Close()
+}
+
 // NewWatcher creates a new fsnotifyWatcherWrapper, wrapping the fsnotify.Watcher.
 func NewWatcher() (Watcher, error) {
 	w, err := fsnotify.NewWatcher()
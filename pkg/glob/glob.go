@@ -0,0 +1,32 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package glob
+
+import (
+	"path"
+)
+
+// Validate returns an error if the pattern is malformed.
+func Validate(pattern string) error {
+	_, err := path.Match(pattern, "")
+	return err
+}
+
+// Match reports whether the value matches the shell pattern, as defined by path.Match.
+// The pattern must have been checked with Validate, malformed patterns match nothing.
+func Match(pattern, value string) bool {
+	ok, _ := path.Match(pattern, value)
+	return ok
+}
@@ -0,0 +1,33 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package glob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate("frontend-*"))
+	assert.Error(t, Validate("[frontend"))
+}
+
+func TestMatch(t *testing.T) {
+	assert.True(t, Match("frontend-*", "frontend-eu"))
+	assert.True(t, Match("frontend", "frontend"))
+	assert.False(t, Match("frontend-*", "backend"))
+	assert.False(t, Match("[frontend", "frontend"))
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tenancy
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TenantFromGRPC returns the validated tenant from the metadata of the incoming gRPC request.
+// It returns an empty tenant if multi-tenancy is disabled.
+func (m *Manager) TenantFromGRPC(ctx context.Context) (string, error) {
+	if m == nil || !m.Enabled {
+		return "", nil
+	}
+	var tenant string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(m.Header); len(values) > 0 {
+			tenant = values[0]
+		}
+	}
+	if err := m.Validate(tenant); err != nil {
+		return "", status.Error(codes.PermissionDenied, err.Error())
+	}
+	return tenant, nil
+}
+
+// NewGuardingUnaryInterceptor returns a gRPC interceptor that rejects requests without a valid
+// tenant and propagates the tenant to the handler through the context.
+func NewGuardingUnaryInterceptor(m *Manager) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		tenant, err := m.TenantFromGRPC(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(WithTenant(ctx, tenant), req)
+	}
+}
+
+// NewGuardingStreamInterceptor returns a gRPC interceptor that rejects streams without a valid
+// tenant and propagates the tenant to the handler through the stream context.
+func NewGuardingStreamInterceptor(m *Manager) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		tenant, err := m.TenantFromGRPC(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &tenantedServerStream{
+			ServerStream: ss,
+			ctx:          WithTenant(ss.Context(), tenant),
+		})
+	}
+}
+
+type tenantedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context carrying the tenant.
+func (s *tenantedServerStream) Context() context.Context {
+	return s.ctx
+}
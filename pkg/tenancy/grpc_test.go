@@ -0,0 +1,77 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tenancy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestGuardingUnaryInterceptor(t *testing.T) {
+	interceptor := NewGuardingUnaryInterceptor(NewManager(&Options{Enabled: true, Tenants: []string{"acme"}}))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return GetTenant(ctx), nil
+	}
+
+	_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, handler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-tenant", "megacorp"))
+	_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-tenant", "acme"))
+	tenant, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", tenant)
+}
+
+func TestGuardingStreamInterceptor(t *testing.T) {
+	interceptor := NewGuardingStreamInterceptor(NewManager(&Options{Enabled: true}))
+	var tenant string
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		tenant = GetTenant(stream.Context())
+		return nil
+	}
+
+	err := interceptor(nil, &testServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{}, handler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-tenant", "acme"))
+	require.NoError(t, interceptor(nil, &testServerStream{ctx: ctx}, &grpc.StreamServerInfo{}, handler))
+	assert.Equal(t, "acme", tenant)
+}
+
+func TestTenantFromGRPCDisabled(t *testing.T) {
+	tenant, err := NewManager(&Options{}).TenantFromGRPC(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, tenant)
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tenancy
+
+import (
+	"net/http"
+)
+
+// ExtractTenantHTTPHandler returns an HTTP handler that rejects requests without a valid
+// tenant and propagates the tenant to the wrapped handler through the request context.
+func ExtractTenantHTTPHandler(m *Manager, h http.Handler) http.Handler {
+	if m == nil || !m.Enabled {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant, err := m.TenantFromHTTP(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r.WithContext(WithTenant(r.Context(), tenant)))
+	})
+}
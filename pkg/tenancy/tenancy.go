@@ -0,0 +1,121 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tenancy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// DefaultHeader is the default header carrying the tenant of a request.
+const DefaultHeader = "x-tenant"
+
+var (
+	// ErrMissingTenant is returned when a request does not carry a tenant.
+	ErrMissingTenant = errors.New("missing tenant header")
+	// ErrUnknownTenant is returned when the tenant of a request is not in the list of allowed tenants.
+	ErrUnknownTenant = errors.New("unknown tenant")
+	// ErrInvalidTenant is returned when the tenant of a request contains characters other than [a-z0-9_-].
+	ErrInvalidTenant = errors.New("invalid tenant, must match [a-z0-9_-]+")
+
+	// tenants end up in index and table names of the storage backends, so they are restricted
+	// to characters that are safe there
+	validTenant = regexp.MustCompile(`^[a-z0-9_-]+$`)
+)
+
+type tenantKeyType string
+
+const tenantKey = tenantKeyType("tenant")
+
+// Options describes the configuration of multi-tenancy.
+type Options struct {
+	// Enabled determines if requests must carry a tenant
+	Enabled bool
+	// Header is the name of the header carrying the tenant
+	Header string
+	// Tenants is the list of allowed tenants, an empty list allows any tenant
+	Tenants []string
+}
+
+// Manager validates the tenants of incoming requests.
+type Manager struct {
+	Enabled bool
+	Header  string
+	tenants map[string]struct{}
+}
+
+// NewManager creates a Manager from the given options.
+func NewManager(options *Options) *Manager {
+	header := options.Header
+	if header == "" {
+		header = DefaultHeader
+	}
+	tenants := make(map[string]struct{}, len(options.Tenants))
+	for _, tenant := range options.Tenants {
+		tenants[tenant] = struct{}{}
+	}
+	return &Manager{
+		Enabled: options.Enabled,
+		Header:  strings.ToLower(header),
+		tenants: tenants,
+	}
+}
+
+// Validate checks that the tenant is present, well-formed and allowed. It always succeeds if multi-tenancy is disabled.
+func (m *Manager) Validate(tenant string) error {
+	if m == nil || !m.Enabled {
+		return nil
+	}
+	if tenant == "" {
+		return ErrMissingTenant
+	}
+	if !validTenant.MatchString(tenant) {
+		return ErrInvalidTenant
+	}
+	if len(m.tenants) == 0 {
+		return nil
+	}
+	if _, ok := m.tenants[tenant]; !ok {
+		return ErrUnknownTenant
+	}
+	return nil
+}
+
+// TenantFromHTTP returns the validated tenant from the headers of the HTTP request.
+// It returns an empty tenant if multi-tenancy is disabled.
+func (m *Manager) TenantFromHTTP(r *http.Request) (string, error) {
+	if m == nil || !m.Enabled {
+		return "", nil
+	}
+	tenant := r.Header.Get(m.Header)
+	if err := m.Validate(tenant); err != nil {
+		return "", err
+	}
+	return tenant, nil
+}
+
+// WithTenant returns a context carrying the tenant.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+// GetTenant returns the tenant carried by the context, or an empty string.
+func GetTenant(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey).(string)
+	return tenant
+}
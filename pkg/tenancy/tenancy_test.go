@@ -0,0 +1,78 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tenancy
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  Options
+		tenant   string
+		expected error
+	}{
+		{name: "disabled", options: Options{}, tenant: ""},
+		{name: "missing tenant", options: Options{Enabled: true}, tenant: "", expected: ErrMissingTenant},
+		{name: "any tenant", options: Options{Enabled: true}, tenant: "acme"},
+		{name: "allowed tenant", options: Options{Enabled: true, Tenants: []string{"acme"}}, tenant: "acme"},
+		{name: "unknown tenant", options: Options{Enabled: true, Tenants: []string{"acme"}}, tenant: "megacorp", expected: ErrUnknownTenant},
+		{name: "wildcard tenant", options: Options{Enabled: true}, tenant: "*", expected: ErrInvalidTenant},
+		{name: "tenant list", options: Options{Enabled: true}, tenant: "acme,wonka", expected: ErrInvalidTenant},
+		{name: "uppercase tenant", options: Options{Enabled: true}, tenant: "Acme", expected: ErrInvalidTenant},
+		{name: "valid characters", options: Options{Enabled: true}, tenant: "acme_corp-2"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, NewManager(&test.options).Validate(test.tenant))
+		})
+	}
+	var m *Manager
+	assert.NoError(t, m.Validate(""))
+}
+
+func TestNewManagerDefaultHeader(t *testing.T) {
+	assert.Equal(t, DefaultHeader, NewManager(&Options{}).Header)
+	assert.Equal(t, "x-team", NewManager(&Options{Header: "X-Team"}).Header)
+}
+
+func TestTenantFromHTTP(t *testing.T) {
+	m := NewManager(&Options{Enabled: true, Tenants: []string{"acme"}})
+	r, err := http.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(t, err)
+
+	_, err = m.TenantFromHTTP(r)
+	assert.Equal(t, ErrMissingTenant, err)
+
+	r.Header.Set("X-Tenant", "acme")
+	tenant, err := m.TenantFromHTTP(r)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", tenant)
+
+	tenant, err = NewManager(&Options{}).TenantFromHTTP(r)
+	require.NoError(t, err)
+	assert.Empty(t, tenant)
+}
+
+func TestContext(t *testing.T) {
+	assert.Empty(t, GetTenant(context.Background()))
+	assert.Equal(t, "acme", GetTenant(WithTenant(context.Background(), "acme")))
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/olivere/elastic"
+)
+
+const (
+	defaultTTL = 60 * time.Second
+
+	leaseType = "_doc"
+)
+
+var (
+	errLockOwnership = errors.New("this host does not own the resource lock")
+)
+
+// Lock is a distributed lock based off Elasticsearch. The lease of a resource is a document of the leases
+// index, which is only changed if it was not changed since it was read, as told by its sequence number and
+// primary term. This requires Elasticsearch 6.7 or later.
+type Lock struct {
+	client *elastic.Client
+	index  string
+	owner  string
+}
+
+// lease is the document of the lease of a resource.
+type lease struct {
+	Owner      string    `json:"owner"`
+	Expiration time.Time `json:"expiration"`
+}
+
+// storedLease is a lease read from the index.
+type storedLease struct {
+	lease
+	seqNo       int64
+	primaryTerm int64
+}
+
+// NewLock creates a new instance of a distributed locking mechanism based off Elasticsearch,
+// storing the leases in the given index.
+func NewLock(client *elastic.Client, index, owner string) *Lock {
+	return &Lock{
+		client: client,
+		index:  index,
+		owner:  owner,
+	}
+}
+
+// Acquire acquires a lease around a given resource, or extends it if this host already owns it.
+// The expiration of the leases is told by the clock of the hosts.
+func (l *Lock) Acquire(resource string, ttl time.Duration) (bool, error) {
+	if ttl == 0 {
+		ttl = defaultTTL
+	}
+	ctx := context.Background()
+	current, err := l.getLease(ctx, resource)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire resource lock due to elasticsearch error: %w", err)
+	}
+	now := time.Now()
+	index := l.client.Index().
+		Index(l.index).
+		Type(leaseType).
+		Id(resource).
+		BodyJson(&lease{Owner: l.owner, Expiration: now.Add(ttl)})
+	switch {
+	case current == nil:
+		// The lease is created, unless another host creates it first
+		index = index.OpType("create")
+	case current.Owner == l.owner || !now.Before(current.Expiration):
+		// This host already owns the lease or it has expired, the lease is replaced
+		// unless another host replaces it first
+		index = index.IfSeqNo(current.seqNo).IfPrimaryTerm(current.primaryTerm)
+	default:
+		return false, nil
+	}
+	if _, err := index.Do(ctx); err != nil {
+		if elastic.IsConflict(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to acquire resource lock due to elasticsearch error: %w", err)
+	}
+	return true, nil
+}
+
+// Forfeit forfeits an existing lease around a given resource.
+func (l *Lock) Forfeit(resource string) (bool, error) {
+	ctx := context.Background()
+	current, err := l.getLease(ctx, resource)
+	if err != nil {
+		return false, fmt.Errorf("failed to forfeit resource lock due to elasticsearch error: %w", err)
+	}
+	if current == nil || current.Owner != l.owner || !time.Now().Before(current.Expiration) {
+		return false, fmt.Errorf("failed to forfeit resource lock: %w", errLockOwnership)
+	}
+	_, err = l.client.Delete().
+		Index(l.index).
+		Type(leaseType).
+		Id(resource).
+		IfSeqNo(current.seqNo).
+		IfPrimaryTerm(current.primaryTerm).
+		Do(ctx)
+	if elastic.IsConflict(err) || elastic.IsNotFound(err) {
+		// Another host acquired the expired lease in the meantime
+		return false, fmt.Errorf("failed to forfeit resource lock: %w", errLockOwnership)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to forfeit resource lock due to elasticsearch error: %w", err)
+	}
+	return true, nil
+}
+
+// getLease returns the lease of a resource, or nil if there is none.
+func (l *Lock) getLease(ctx context.Context, resource string) (*storedLease, error) {
+	result, err := l.client.Get().
+		Index(l.index).
+		Type(leaseType).
+		Id(resource).
+		Do(ctx)
+	if elastic.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if result.SeqNo == nil || result.PrimaryTerm == nil {
+		return nil, errors.New("the lease has no sequence number, Elasticsearch 6.7 or later is required")
+	}
+	stored := &storedLease{
+		seqNo:       *result.SeqNo,
+		primaryTerm: *result.PrimaryTerm,
+	}
+	if result.Source == nil {
+		return nil, errors.New("the lease has no source")
+	}
+	if err := json.Unmarshal(*result.Source, &stored.lease); err != nil {
+		return nil, fmt.Errorf("invalid lease: %w", err)
+	}
+	return stored, nil
+}
@@ -0,0 +1,214 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elasticsearch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/olivere/elastic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/pkg/distributedlock"
+	"github.com/jaegertracing/jaeger/pkg/distributedlock/locktest"
+)
+
+const leasesIndex = "jaeger-leases"
+
+var _ distributedlock.Lock = (*Lock)(nil) // check API conformance
+
+type document struct {
+	seqNo  int64
+	source json.RawMessage
+}
+
+// fakeElasticsearch serves the document APIs of Elasticsearch used by the lock.
+type fakeElasticsearch struct {
+	sync.Mutex
+	documents map[string]*document
+	seqNo     int64
+	// omitSeqNo emulates the versions of Elasticsearch before 6.7
+	omitSeqNo bool
+}
+
+func (es *fakeElasticsearch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	es.Lock()
+	defer es.Unlock()
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) != 4 || parts[1] != leasesIndex || parts[2] != leaseType {
+		writeResponse(w, http.StatusBadRequest, map[string]interface{}{"error": "unexpected path " + r.URL.Path})
+		return
+	}
+	id := parts[3]
+	doc := es.documents[id]
+	conflict := false
+	if ifSeqNo := r.URL.Query().Get("if_seq_no"); ifSeqNo != "" {
+		conflict = doc == nil || ifSeqNo != strconv.FormatInt(doc.seqNo, 10) || r.URL.Query().Get("if_primary_term") != "1"
+	}
+	switch {
+	case r.Method == http.MethodGet && doc == nil:
+		writeResponse(w, http.StatusNotFound, map[string]interface{}{"_id": id, "found": false})
+	case r.Method == http.MethodGet:
+		response := map[string]interface{}{"_id": id, "found": true, "_source": doc.source}
+		if !es.omitSeqNo {
+			response["_seq_no"] = doc.seqNo
+			response["_primary_term"] = 1
+		}
+		writeResponse(w, http.StatusOK, response)
+	case r.Method == http.MethodPut && (conflict || (doc != nil && r.URL.Query().Get("op_type") == "create")):
+		writeConflict(w)
+	case r.Method == http.MethodPut:
+		body, _ := ioutil.ReadAll(r.Body)
+		es.seqNo++
+		es.documents[id] = &document{seqNo: es.seqNo, source: body}
+		writeResponse(w, http.StatusOK, map[string]interface{}{"_id": id, "_seq_no": es.seqNo, "_primary_term": 1})
+	case r.Method == http.MethodDelete && doc == nil:
+		writeResponse(w, http.StatusNotFound, map[string]interface{}{"_id": id, "result": "not_found"})
+	case r.Method == http.MethodDelete && conflict:
+		writeConflict(w)
+	case r.Method == http.MethodDelete:
+		delete(es.documents, id)
+		writeResponse(w, http.StatusOK, map[string]interface{}{"_id": id, "result": "deleted"})
+	default:
+		writeResponse(w, http.StatusMethodNotAllowed, map[string]interface{}{"error": "unexpected method " + r.Method})
+	}
+}
+
+func writeConflict(w http.ResponseWriter) {
+	writeResponse(w, http.StatusConflict, map[string]interface{}{
+		"error":  map[string]interface{}{"type": "version_conflict_engine_exception", "reason": "version conflict"},
+		"status": http.StatusConflict,
+	})
+}
+
+func writeResponse(w http.ResponseWriter, status int, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func withElasticsearch(t *testing.T, handler http.Handler, fn func(newLock func(owner string) *Lock)) {
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	client, err := elastic.NewClient(elastic.SetURL(server.URL), elastic.SetSniff(false), elastic.SetHealthcheck(false))
+	require.NoError(t, err)
+	fn(func(owner string) *Lock {
+		return NewLock(client, leasesIndex, owner)
+	})
+}
+
+func newFakeElasticsearch() *fakeElasticsearch {
+	return &fakeElasticsearch{documents: make(map[string]*document)}
+}
+
+func TestConformance(t *testing.T) {
+	withElasticsearch(t, newFakeElasticsearch(), func(newLock func(owner string) *Lock) {
+		suite := &locktest.Suite{
+			NewLock: func(owner string) distributedlock.Lock {
+				return newLock(owner)
+			},
+			TTL: 200 * time.Millisecond,
+		}
+		suite.Run(t)
+	})
+}
+
+func TestConcurrentAcquire(t *testing.T) {
+	withElasticsearch(t, newFakeElasticsearch(), func(newLock func(owner string) *Lock) {
+		acquireAll := func(ttl time.Duration) []string {
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			owners := []string{}
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func(owner string) {
+					defer wg.Done()
+					acquired, err := newLock(owner).Acquire("sampling_lock", ttl)
+					assert.NoError(t, err)
+					if acquired {
+						mu.Lock()
+						owners = append(owners, owner)
+						mu.Unlock()
+					}
+				}(fmt.Sprintf("host-%d", i))
+			}
+			wg.Wait()
+			return owners
+		}
+		// the lease is created by a single host
+		assert.Len(t, acquireAll(time.Millisecond), 1)
+		time.Sleep(10 * time.Millisecond)
+		// the expired lease is replaced by a single host
+		assert.Len(t, acquireAll(time.Minute), 1)
+	})
+}
+
+func TestElasticsearchErrors(t *testing.T) {
+	failing := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeResponse(w, http.StatusInternalServerError, map[string]interface{}{
+			"error":  map[string]interface{}{"type": "exception", "reason": "storage error"},
+			"status": http.StatusInternalServerError,
+		})
+	})
+	withElasticsearch(t, failing, func(newLock func(owner string) *Lock) {
+		_, err := newLock("localhost").Acquire("sampling_lock", time.Minute)
+		assert.Contains(t, err.Error(), "failed to acquire resource lock due to elasticsearch error")
+		_, err = newLock("localhost").Forfeit("sampling_lock")
+		assert.Contains(t, err.Error(), "failed to forfeit resource lock due to elasticsearch error")
+	})
+
+	es := newFakeElasticsearch()
+	es.documents["unversioned"] = &document{source: json.RawMessage(`{"owner": "localhost"}`)}
+	es.documents["invalid"] = &document{source: json.RawMessage(`{"owner": 1}`)}
+	withElasticsearch(t, es, func(newLock func(owner string) *Lock) {
+		_, err := newLock("localhost").Acquire("invalid", time.Minute)
+		assert.Contains(t, err.Error(), "invalid lease")
+
+		es.omitSeqNo = true
+		_, err = newLock("localhost").Acquire("unversioned", time.Minute)
+		assert.Contains(t, err.Error(), "Elasticsearch 6.7 or later is required")
+	})
+}
+
+func TestForfeitConflict(t *testing.T) {
+	es := newFakeElasticsearch()
+	// another host replaces the lease between its read and its deletion
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			es.Lock()
+			es.documents["sampling_lock"].seqNo++
+			es.Unlock()
+		}
+		es.ServeHTTP(w, r)
+	})
+	withElasticsearch(t, handler, func(newLock func(owner string) *Lock) {
+		lock := newLock("localhost")
+		acquired, err := lock.Acquire("sampling_lock", time.Minute)
+		require.NoError(t, err)
+		require.True(t, acquired)
+		forfeited, err := lock.Forfeit("sampling_lock")
+		assert.False(t, forfeited)
+		assert.EqualError(t, err, "failed to forfeit resource lock: this host does not own the resource lock")
+	})
+}
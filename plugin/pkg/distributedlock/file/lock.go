@@ -0,0 +1,140 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	defaultTTL = 60 * time.Second
+
+	leaseFileExtension = ".lease"
+)
+
+var (
+	errLockOwnership = errors.New("this host does not own the resource lock")
+)
+
+// Lock is a distributed lock between the processes of a single host, based off lease files.
+// The lease file of a resource is guarded by an exclusive file lock while it is read and written.
+type Lock struct {
+	directory string
+	owner     string
+}
+
+// lease is the content of a lease file, an empty file holds no lease.
+type lease struct {
+	Owner      string    `json:"owner"`
+	Expiration time.Time `json:"expiration"`
+}
+
+// NewLock creates a new instance of a distributed locking mechanism based off the lease files of a directory,
+// which is created if it does not exist.
+func NewLock(directory, owner string) (*Lock, error) {
+	if err := os.MkdirAll(directory, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create the directory of the lease files: %w", err)
+	}
+	return &Lock{
+		directory: directory,
+		owner:     owner,
+	}, nil
+}
+
+// Acquire acquires a lease around a given resource, or extends it if this host already owns it.
+func (l *Lock) Acquire(resource string, ttl time.Duration) (bool, error) {
+	if ttl == 0 {
+		ttl = defaultTTL
+	}
+	acquired := false
+	err := l.updateLease(resource, func(current *lease, now time.Time) *lease {
+		if current != nil && current.Owner != l.owner && now.Before(current.Expiration) {
+			return current
+		}
+		acquired = true
+		return &lease{Owner: l.owner, Expiration: now.Add(ttl)}
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire resource lock: %w", err)
+	}
+	return acquired, nil
+}
+
+// Forfeit forfeits an existing lease around a given resource.
+func (l *Lock) Forfeit(resource string) (bool, error) {
+	forfeited := false
+	err := l.updateLease(resource, func(current *lease, now time.Time) *lease {
+		if current == nil || current.Owner != l.owner || !now.Before(current.Expiration) {
+			return current
+		}
+		forfeited = true
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to forfeit resource lock: %w", err)
+	}
+	if !forfeited {
+		return false, fmt.Errorf("failed to forfeit resource lock: %w", errLockOwnership)
+	}
+	return true, nil
+}
+
+// updateLease replaces the lease of a resource by the one returned by update, which receives the current lease
+// or nil. The lease file is locked until it is written, the lock is released if the process dies.
+func (l *Lock) updateLease(resource string, update func(current *lease, now time.Time) *lease) error {
+	path := filepath.Join(l.directory, url.QueryEscape(resource)+leaseFileExtension)
+	file, err := os.OpenFile(filepath.Clean(path), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := lockFile(file); err != nil {
+		return fmt.Errorf("failed to lock the lease file: %w", err)
+	}
+	defer unlockFile(file)
+
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	var current *lease
+	if len(content) > 0 {
+		current = &lease{}
+		if err := json.Unmarshal(content, current); err != nil {
+			return fmt.Errorf("invalid lease file %s: %w", path, err)
+		}
+	}
+
+	content = nil
+	if next := update(current, time.Now()); next != nil {
+		if content, err = json.Marshal(next); err != nil {
+			return err
+		}
+	}
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := file.WriteAt(content, 0); err != nil {
+		return err
+	}
+	return file.Sync()
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/pkg/distributedlock"
+	"github.com/jaegertracing/jaeger/pkg/distributedlock/locktest"
+)
+
+var _ distributedlock.Lock = (*Lock)(nil) // check API conformance
+
+func withLeaseDirectory(t *testing.T, fn func(directory string)) {
+	directory, err := ioutil.TempDir("", "jaeger-leases")
+	require.NoError(t, err)
+	defer os.RemoveAll(directory)
+	fn(directory)
+}
+
+func TestConformance(t *testing.T) {
+	withLeaseDirectory(t, func(directory string) {
+		suite := &locktest.Suite{
+			NewLock: func(owner string) distributedlock.Lock {
+				lock, err := NewLock(directory, owner)
+				require.NoError(t, err)
+				return lock
+			},
+			TTL: 200 * time.Millisecond,
+		}
+		suite.Run(t)
+	})
+}
+
+func TestConcurrentAcquire(t *testing.T) {
+	withLeaseDirectory(t, func(directory string) {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		owners := []string{}
+		for i := 0; i < 10; i++ {
+			lock, err := NewLock(directory, fmt.Sprintf("host-%d", i))
+			require.NoError(t, err)
+			wg.Add(1)
+			go func(owner string) {
+				defer wg.Done()
+				acquired, err := lock.Acquire("sampling/lock", 0)
+				assert.NoError(t, err)
+				if acquired {
+					mu.Lock()
+					owners = append(owners, owner)
+					mu.Unlock()
+				}
+			}(lock.owner)
+		}
+		wg.Wait()
+		assert.Len(t, owners, 1)
+
+		content, err := ioutil.ReadFile(filepath.Join(directory, "sampling%2Flock.lease"))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), fmt.Sprintf(`"owner":%q`, owners[0]))
+	})
+}
+
+func TestNewLockError(t *testing.T) {
+	withLeaseDirectory(t, func(directory string) {
+		path := filepath.Join(directory, "file")
+		require.NoError(t, ioutil.WriteFile(path, nil, 0600))
+		_, err := NewLock(filepath.Join(path, "leases"), "localhost")
+		assert.Contains(t, err.Error(), "failed to create the directory of the lease files")
+	})
+}
+
+func TestInvalidLeaseFile(t *testing.T) {
+	withLeaseDirectory(t, func(directory string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(directory, "lock.lease"), []byte("{"), 0600))
+		lock, err := NewLock(directory, "localhost")
+		require.NoError(t, err)
+
+		_, err = lock.Acquire("lock", time.Minute)
+		assert.Contains(t, err.Error(), "failed to acquire resource lock: invalid lease file")
+		_, err = lock.Forfeit("lock")
+		assert.Contains(t, err.Error(), "failed to forfeit resource lock: invalid lease file")
+	})
+}
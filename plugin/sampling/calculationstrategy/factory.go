@@ -0,0 +1,64 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calculationstrategy
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// PercentageIncreaseCappedKind is the kind of the PercentageIncreaseCappedCalculator.
+	PercentageIncreaseCappedKind = "percentage-increase-capped"
+	// PIDKind is the kind of the PIDCalculator.
+	PIDKind = "pid"
+	// ProportionalKind is the kind of the ProportionalCalculator.
+	ProportionalKind = "proportional"
+)
+
+// Kinds are the kinds of the calculators created by NewCalculator.
+var Kinds = []string{PercentageIncreaseCappedKind, PIDKind, ProportionalKind}
+
+// Options are the options of the calculator created by NewCalculator.
+type Options struct {
+	// Kind is the kind of the calculator, one of Kinds. The default is PercentageIncreaseCappedKind.
+	Kind string
+	// PercentageIncreaseCap is the cap of the PercentageIncreaseCappedCalculator.
+	PercentageIncreaseCap float64
+	// PIDGains are the gains of the PIDCalculator.
+	PIDGains PIDGains
+	// SmoothingFactor is the smoothing factor of the ProportionalCalculator.
+	SmoothingFactor float64
+}
+
+// NewCalculator returns a new calculator of the kind of the options.
+func NewCalculator(opts Options) (ProbabilityCalculator, error) {
+	switch opts.Kind {
+	case PercentageIncreaseCappedKind, "":
+		return NewPercentageIncreaseCappedCalculator(opts.PercentageIncreaseCap), nil
+	case PIDKind:
+		if opts.PIDGains.Integral < 0 || opts.PIDGains.Proportional < 0 || opts.PIDGains.Derivative < 0 {
+			return nil, fmt.Errorf("the gains of the PID calculator cannot be negative, got %+v", opts.PIDGains)
+		}
+		return NewPIDCalculator(opts.PIDGains), nil
+	case ProportionalKind:
+		if opts.SmoothingFactor < 0 || opts.SmoothingFactor > 1 {
+			return nil, fmt.Errorf("the smoothing factor of the proportional calculator must be between 0 and 1, got %v", opts.SmoothingFactor)
+		}
+		return NewProportionalCalculator(opts.SmoothingFactor), nil
+	default:
+		return nil, fmt.Errorf("unknown probability calculator %q, expected one of %s", opts.Kind, strings.Join(Kinds, ", "))
+	}
+}
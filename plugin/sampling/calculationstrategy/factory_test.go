@@ -0,0 +1,67 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calculationstrategy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCalculator(t *testing.T) {
+	tests := []struct {
+		opts     Options
+		expected ProbabilityCalculator
+		err      string
+	}{
+		{
+			expected: NewPercentageIncreaseCappedCalculator(0),
+		},
+		{
+			opts:     Options{Kind: PercentageIncreaseCappedKind, PercentageIncreaseCap: 1},
+			expected: NewPercentageIncreaseCappedCalculator(1),
+		},
+		{
+			opts:     Options{Kind: PIDKind, PIDGains: PIDGains{Integral: 1}},
+			expected: NewPIDCalculator(PIDGains{Integral: 1}),
+		},
+		{
+			opts:     Options{Kind: ProportionalKind, SmoothingFactor: 0.3},
+			expected: NewProportionalCalculator(0.3),
+		},
+		{
+			opts: Options{Kind: PIDKind, PIDGains: PIDGains{Integral: 1, Derivative: -1}},
+			err:  "the gains of the PID calculator cannot be negative, got {Proportional:0 Integral:1 Derivative:-1}",
+		},
+		{
+			opts: Options{Kind: ProportionalKind, SmoothingFactor: 1.5},
+			err:  "the smoothing factor of the proportional calculator must be between 0 and 1, got 1.5",
+		},
+		{
+			opts: Options{Kind: "pd"},
+			err:  `unknown probability calculator "pd", expected one of percentage-increase-capped, pid, proportional`,
+		},
+	}
+	for _, test := range tests {
+		calculator, err := NewCalculator(test.opts)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, calculator, test.opts.Kind)
+	}
+}
@@ -26,3 +26,12 @@ type CalculateFunc func(targetQPS, curQPS, prevProbability float64) (newProbabil
 func (c CalculateFunc) Calculate(targetQPS, curQPS, prevProbability float64) float64 {
 	return c(targetQPS, curQPS, prevProbability)
 }
+
+// OperationCalculator is implemented by the calculators keeping state between the calculations
+// of the probability of an operation, such as the errors of the previous calculations.
+type OperationCalculator interface {
+	// ForOperation returns the calculator of the probability of an operation.
+	ForOperation(service, operation string) ProbabilityCalculator
+	// Retain forgets the state of the operations for which keep returns false.
+	Retain(keep func(service, operation string) bool)
+}
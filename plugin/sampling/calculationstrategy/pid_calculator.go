@@ -0,0 +1,137 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calculationstrategy
+
+import (
+	"math"
+	"sync"
+)
+
+const (
+	defaultProportionalGain = 0.2
+	defaultIntegralGain     = 0.6
+	defaultDerivativeGain   = 0.05
+)
+
+// PIDGains are the gains of the terms of a PID controller.
+type PIDGains struct {
+	Proportional float64
+	Integral     float64
+	Derivative   float64
+}
+
+// PIDCalculator is a probability calculator that controls the probability of every operation
+// with a PID controller, see PIDController. It keeps the state of the controller of every operation.
+type PIDCalculator struct {
+	gains PIDGains
+
+	sync.Mutex
+	// controllers is the controller of every operation, ie [service][operation] = controller
+	controllers map[string]map[string]*PIDController
+}
+
+// NewPIDCalculator returns a new PID calculator. The zero gains are replaced with the default gains.
+func NewPIDCalculator(gains PIDGains) *PIDCalculator {
+	if gains == (PIDGains{}) {
+		gains = PIDGains{
+			Proportional: defaultProportionalGain,
+			Integral:     defaultIntegralGain,
+			Derivative:   defaultDerivativeGain,
+		}
+	}
+	return &PIDCalculator{
+		gains:       gains,
+		controllers: make(map[string]map[string]*PIDController),
+	}
+}
+
+// Calculate calculates the new probability with a controller without state, the PID controller
+// of the operations are returned by ForOperation.
+func (c *PIDCalculator) Calculate(targetQPS, curQPS, prevProbability float64) float64 {
+	return NewPIDController(c.gains).Calculate(targetQPS, curQPS, prevProbability)
+}
+
+// ForOperation implements OperationCalculator.
+func (c *PIDCalculator) ForOperation(service, operation string) ProbabilityCalculator {
+	c.Lock()
+	defer c.Unlock()
+	operations, ok := c.controllers[service]
+	if !ok {
+		operations = make(map[string]*PIDController)
+		c.controllers[service] = operations
+	}
+	controller, ok := operations[operation]
+	if !ok {
+		controller = NewPIDController(c.gains)
+		operations[operation] = controller
+	}
+	return controller
+}
+
+// Retain implements OperationCalculator.
+func (c *PIDCalculator) Retain(keep func(service, operation string) bool) {
+	c.Lock()
+	defer c.Unlock()
+	for service, operations := range c.controllers {
+		for operation := range operations {
+			if !keep(service, operation) {
+				delete(operations, operation)
+			}
+		}
+		if len(operations) == 0 {
+			delete(c.controllers, service)
+		}
+	}
+}
+
+// PIDController is a probability calculator implementing the velocity form of a PID controller
+// in log scale: the error is log(targetQPS / curQPS), and the change of log(probability) is
+//
+//	Integral * error + Proportional * (error - prevError) + Derivative * (error - 2 * prevError + prevPrevError)
+//
+// The integral term accumulates in the probability itself, so a controller only keeps the errors
+// of its two previous calculations, and the limits applied to the probability cannot wind it up.
+// An integral gain of 1 without the other terms jumps directly to the probability giving the target QPS,
+// lower integral gains converge more slowly, and the proportional and derivative gains damp the bursts.
+type PIDController struct {
+	gains PIDGains
+
+	sync.Mutex
+	calculations  int
+	prevError     float64
+	prevPrevError float64
+}
+
+// NewPIDController returns a new PID controller.
+func NewPIDController(gains PIDGains) *PIDController {
+	return &PIDController{gains: gains}
+}
+
+// Calculate calculates the new probability.
+func (c *PIDController) Calculate(targetQPS, curQPS, prevProbability float64) float64 {
+	c.Lock()
+	defer c.Unlock()
+	err := math.Log(targetQPS / curQPS)
+	if c.calculations == 0 {
+		// no proportional and derivative kick on the first calculation
+		c.prevError, c.prevPrevError = err, err
+	}
+	change := c.gains.Integral*err +
+		c.gains.Proportional*(err-c.prevError) +
+		c.gains.Derivative*(err-2*c.prevError+c.prevPrevError)
+	c.calculations++
+	c.prevPrevError, c.prevError = c.prevError, err
+	return prevProbability * math.Exp(change)
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calculationstrategy
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var _ OperationCalculator = new(PIDCalculator)
+
+func TestPIDController(t *testing.T) {
+	controller := NewPIDController(PIDGains{Proportional: 0.5, Integral: 0.5, Derivative: 0.25})
+
+	// the first calculation only has the integral term: exp(0.5 * log(1/4)) = 1/2
+	assert.InDelta(t, 0.05, controller.Calculate(1.0, 4.0, 0.1), 0.0001)
+	// the error is unchanged, the derivative term is 0.25 * (e - 2e + e) = 0
+	assert.InDelta(t, 0.025, controller.Calculate(1.0, 4.0, 0.05), 0.0001)
+	// on target, the proportional and derivative terms brake the decrease:
+	// 0.5 * (0 - e) + 0.25 * (0 - 2e + e) = -0.75 * e with e = log(1/4)
+	assert.InDelta(t, 0.025*math.Pow(4, 0.75), controller.Calculate(1.0, 1.0, 0.025), 0.0001)
+
+	integral := NewPIDController(PIDGains{Integral: 1})
+	assert.InDelta(t, 0.025, integral.Calculate(1.0, 4.0, 0.1), 0.0001)
+	assert.InDelta(t, 0.4, integral.Calculate(1.0, 0.25, 0.1), 0.0001)
+}
+
+func TestPIDCalculator(t *testing.T) {
+	calculator := NewPIDCalculator(PIDGains{})
+	assert.Equal(t, PIDGains{
+		Proportional: defaultProportionalGain,
+		Integral:     defaultIntegralGain,
+		Derivative:   defaultDerivativeGain,
+	}, calculator.gains)
+	assert.InDelta(t, 0.1*math.Pow(0.25, defaultIntegralGain), calculator.Calculate(1.0, 4.0, 0.1), 0.0001)
+
+	foo := calculator.ForOperation("svcA", "foo")
+	assert.Same(t, foo, calculator.ForOperation("svcA", "foo"))
+	assert.NotSame(t, foo, calculator.ForOperation("svcA", "bar"))
+	calculator.ForOperation("svcB", "foo")
+
+	calculator.Retain(func(service, operation string) bool {
+		return operation == "foo"
+	})
+	assert.Len(t, calculator.controllers, 2)
+	assert.Len(t, calculator.controllers["svcA"], 1)
+	assert.Same(t, foo, calculator.ForOperation("svcA", "foo"))
+
+	calculator.Retain(func(service, operation string) bool {
+		return service == "svcB"
+	})
+	assert.Len(t, calculator.controllers, 1)
+	assert.NotSame(t, foo, calculator.ForOperation("svcA", "foo"))
+}
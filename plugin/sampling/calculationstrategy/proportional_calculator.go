@@ -0,0 +1,49 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calculationstrategy
+
+import "math"
+
+const (
+	defaultSmoothingFactor = 0.5
+)
+
+// ProportionalCalculator is a probability calculator that moves the probability towards the
+// probability which would give the target QPS, (targetQPS / curQPS) * prevProbability, by a
+// smoothing factor in log scale, so that increases and decreases are equally damped.
+//
+// Given prevProb = 0.1, targetQPS = 1, curQPS = 4, and a smoothing factor of 0.5:
+// the probability giving the target QPS is 0.025, a factor of 1/4, so the probability
+// decreases by a factor of (1/4)^0.5 = 1/2, to 0.05.
+//
+// A smoothing factor of 1 jumps directly to the probability giving the target QPS.
+type ProportionalCalculator struct {
+	smoothingFactor float64
+}
+
+// NewProportionalCalculator returns a new proportional calculator.
+func NewProportionalCalculator(smoothingFactor float64) ProportionalCalculator {
+	if smoothingFactor == 0 {
+		smoothingFactor = defaultSmoothingFactor
+	}
+	return ProportionalCalculator{
+		smoothingFactor: smoothingFactor,
+	}
+}
+
+// Calculate calculates the new probability.
+func (c ProportionalCalculator) Calculate(targetQPS, curQPS, prevProbability float64) float64 {
+	return prevProbability * math.Pow(targetQPS/curQPS, c.smoothingFactor)
+}
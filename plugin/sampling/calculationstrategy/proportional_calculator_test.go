@@ -0,0 +1,43 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calculationstrategy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProportionalCalculator(t *testing.T) {
+	calculator := NewProportionalCalculator(0)
+	tests := []struct {
+		targetQPS           float64
+		curQPS              float64
+		oldProbability      float64
+		expectedProbability float64
+		testName            string
+	}{
+		{1.0, 4.0, 0.1, 0.05, "decrease"},
+		{1.0, 0.25, 0.1, 0.2, "increase"},
+		{1.0, 1.0, 0.1, 0.1, "on target"},
+	}
+	for _, tt := range tests {
+		probability := calculator.Calculate(tt.targetQPS, tt.curQPS, tt.oldProbability)
+		assert.InDelta(t, tt.expectedProbability, probability, 0.0001, tt.testName)
+	}
+
+	direct := NewProportionalCalculator(1)
+	assert.InDelta(t, 0.025, direct.Calculate(1.0, 4.0, 0.1), 0.0001)
+}
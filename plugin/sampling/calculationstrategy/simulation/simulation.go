@@ -0,0 +1,258 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulation
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/model"
+	"github.com/jaegertracing/jaeger/plugin/sampling/calculationstrategy"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
+)
+
+// Options are the settings of the adaptive sampling replayed by the simulation,
+// see the options of the adaptive sampling strategy store.
+type Options struct {
+	TargetSamplesPerSecond     float64
+	DeltaTolerance             float64
+	InitialSamplingProbability float64
+	MinSamplingProbability     float64
+	// MaxSamplingProbability is the maximum sampling probability, 1 if it is 0.
+	MaxSamplingProbability float64
+	// CalculationInterval is the interval of the QPS of the series.
+	CalculationInterval time.Duration
+}
+
+// Series is the QPS of an operation before sampling, over consecutive calculation intervals.
+type Series struct {
+	Service   string
+	Operation string
+	QPS       []float64
+}
+
+// Result is the outcome of the simulation of the sampling of an operation.
+type Result struct {
+	Service   string
+	Operation string
+	// Converged is true if the sampled QPS is within the delta tolerance of the target at the end of the series.
+	Converged bool
+	// ConvergenceTime is the time after which the sampled QPS stays within the delta tolerance of the target.
+	ConvergenceTime time.Duration
+	// Overshoot is the largest excess of the sampled QPS over the target, as a ratio of the target,
+	// after the sampled QPS first reached the lower bound of the delta tolerance of the target.
+	Overshoot float64
+	// SampledQPS is the sampled QPS of every calculation interval.
+	SampledQPS []float64
+}
+
+// Report is the outcome of the simulation of a calculator.
+type Report struct {
+	Calculator string
+	Results    []*Result
+}
+
+// LoadSeries reads the throughput between start and end from storage, one calculation interval at a time,
+// and estimates the QPS before sampling of every operation: the throughput of an interval is the count of
+// the sampled traces, at the sampling probabilities of the throughput, which are assumed to be used equally.
+// The throughput without sampling probabilities, only sampled by the lower bound sampler, is ignored,
+// and an operation has no QPS in the intervals without throughput.
+func LoadSeries(store samplingstore.Store, start, end time.Time, interval time.Duration) ([]*Series, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("the calculation interval must be positive, got %v", interval)
+	}
+	steps := int(end.Sub(start) / interval)
+	series := make(map[string]map[string]*Series)
+	var ordered []*Series
+	for i := 0; i < steps; i++ {
+		intervalStart := start.Add(time.Duration(i) * interval)
+		throughput, err := store.GetThroughput(intervalStart, intervalStart.Add(interval))
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range throughput {
+			probability, ok := meanProbability(t)
+			if !ok {
+				continue
+			}
+			if _, ok := series[t.Service]; !ok {
+				series[t.Service] = make(map[string]*Series)
+			}
+			s, ok := series[t.Service][t.Operation]
+			if !ok {
+				s = &Series{Service: t.Service, Operation: t.Operation, QPS: make([]float64, steps)}
+				series[t.Service][t.Operation] = s
+				ordered = append(ordered, s)
+			}
+			s.QPS[i] += float64(t.Count) / interval.Seconds() / probability
+		}
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if ordered[i].Service != ordered[j].Service {
+			return ordered[i].Service < ordered[j].Service
+		}
+		return ordered[i].Operation < ordered[j].Operation
+	})
+	return ordered, nil
+}
+
+func meanProbability(throughput *model.Throughput) (float64, bool) {
+	var sum float64
+	var count int
+	for p := range throughput.Probabilities {
+		probability, err := strconv.ParseFloat(p, 64)
+		if err != nil || probability <= 0 {
+			continue
+		}
+		sum += probability
+		count++
+	}
+	if count == 0 {
+		return 0, false
+	}
+	return sum / float64(count), true
+}
+
+// Simulate replays the series with a new calculator, like the adaptive sampling processor: the sampling
+// probability of an operation starts at the initial sampling probability, and after every interval where the
+// sampled QPS is not within the delta tolerance of the target, the calculator calculates the probability of the
+// next interval. The traffic is assumed to be sampled exactly at the probability, without delay.
+func Simulate(calculatorOptions calculationstrategy.Options, series []*Series, opts Options) (*Report, error) {
+	calculator, err := calculationstrategy.NewCalculator(calculatorOptions)
+	if err != nil {
+		return nil, err
+	}
+	maxProbability := opts.MaxSamplingProbability
+	if maxProbability == 0 {
+		maxProbability = 1
+	}
+	report := &Report{Calculator: calculatorOptions.Kind}
+	if report.Calculator == "" {
+		report.Calculator = calculationstrategy.PercentageIncreaseCappedKind
+	}
+	for _, s := range series {
+		operationCalculator := calculator
+		if c, ok := calculator.(calculationstrategy.OperationCalculator); ok {
+			operationCalculator = c.ForOperation(s.Service, s.Operation)
+		}
+		result := &Result{Service: s.Service, Operation: s.Operation, SampledQPS: make([]float64, len(s.QPS))}
+		probability := opts.InitialSamplingProbability
+		for i, qps := range s.QPS {
+			sampledQPS := qps * probability
+			result.SampledQPS[i] = sampledQPS
+			if withinTolerance(sampledQPS, opts) {
+				continue
+			}
+			if sampledQPS == 0 {
+				probability *= 2
+			} else {
+				probability = operationCalculator.Calculate(opts.TargetSamplesPerSecond, sampledQPS, probability)
+			}
+			probability = math.Min(maxProbability, math.Max(opts.MinSamplingProbability, probability))
+		}
+		result.evaluate(opts)
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
+
+func withinTolerance(qps float64, opts Options) bool {
+	return math.Abs(qps-opts.TargetSamplesPerSecond)/opts.TargetSamplesPerSecond < opts.DeltaTolerance
+}
+
+func (r *Result) evaluate(opts Options) {
+	settled := len(r.SampledQPS)
+	for settled > 0 && withinTolerance(r.SampledQPS[settled-1], opts) {
+		settled--
+	}
+	if settled < len(r.SampledQPS) {
+		r.Converged = true
+		r.ConvergenceTime = time.Duration(settled) * opts.CalculationInterval
+	}
+	reached := false
+	for _, qps := range r.SampledQPS {
+		reached = reached || qps >= opts.TargetSamplesPerSecond*(1-opts.DeltaTolerance)
+		if reached {
+			r.Overshoot = math.Max(r.Overshoot, (qps-opts.TargetSamplesPerSecond)/opts.TargetSamplesPerSecond)
+		}
+	}
+}
+
+// Summary summarizes the results of a report.
+type Summary struct {
+	Operations      int
+	Converged       int
+	MeanConvergence time.Duration
+	MaxConvergence  time.Duration
+	MeanOvershoot   float64
+	MaxOvershoot    float64
+}
+
+// Summary returns the summary of the results, the convergence times are those of the converged operations.
+func (r *Report) Summary() Summary {
+	summary := Summary{Operations: len(r.Results)}
+	var totalConvergence time.Duration
+	for _, result := range r.Results {
+		summary.MeanOvershoot += result.Overshoot
+		summary.MaxOvershoot = math.Max(summary.MaxOvershoot, result.Overshoot)
+		if !result.Converged {
+			continue
+		}
+		summary.Converged++
+		totalConvergence += result.ConvergenceTime
+		if result.ConvergenceTime > summary.MaxConvergence {
+			summary.MaxConvergence = result.ConvergenceTime
+		}
+	}
+	if summary.Converged > 0 {
+		summary.MeanConvergence = totalConvergence / time.Duration(summary.Converged)
+	}
+	if summary.Operations > 0 {
+		summary.MeanOvershoot /= float64(summary.Operations)
+	}
+	return summary
+}
+
+// WriteReports writes the summary of the reports as a table, followed by the results of every
+// operation if verbose is true.
+func WriteReports(w io.Writer, reports []*Report, verbose bool) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CALCULATOR\tOPERATIONS\tCONVERGED\tMEAN CONVERGENCE\tMAX CONVERGENCE\tMEAN OVERSHOOT\tMAX OVERSHOOT")
+	for _, report := range reports {
+		summary := report.Summary()
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%v\t%v\t%.1f%%\t%.1f%%\n", report.Calculator, summary.Operations, summary.Converged,
+			summary.MeanConvergence, summary.MaxConvergence, 100*summary.MeanOvershoot, 100*summary.MaxOvershoot)
+	}
+	if verbose {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "CALCULATOR\tSERVICE\tOPERATION\tCONVERGENCE\tOVERSHOOT")
+		for _, report := range reports {
+			for _, result := range report.Results {
+				convergence := "-"
+				if result.Converged {
+					convergence = result.ConvergenceTime.String()
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%.1f%%\n", report.Calculator, result.Service, result.Operation,
+					convergence, 100*result.Overshoot)
+			}
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulation
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/model"
+	"github.com/jaegertracing/jaeger/plugin/sampling/calculationstrategy"
+	"github.com/jaegertracing/jaeger/storage/samplingstore/mocks"
+)
+
+var testOptions = Options{
+	TargetSamplesPerSecond:     1,
+	DeltaTolerance:             0.3,
+	InitialSamplingProbability: 0.0001,
+	MinSamplingProbability:     0.00001,
+	CalculationInterval:        time.Minute,
+}
+
+func TestLoadSeries(t *testing.T) {
+	start := time.Unix(0, 0)
+	store := &mocks.Store{}
+	store.On("GetThroughput", start, start.Add(time.Minute)).Return([]*model.Throughput{
+		{Service: "svcB", Operation: "GET", Count: 60, Probabilities: map[string]struct{}{"0.010000": {}}},
+		{Service: "svcA", Operation: "GET", Count: 30, Probabilities: map[string]struct{}{"0.001000": {}}},
+		{Service: "svcA", Operation: "GET", Count: 30, Probabilities: map[string]struct{}{"0.001000": {}, "0.003000": {}}},
+		{Service: "svcA", Operation: "PUT", Count: 5},
+	}, nil)
+	store.On("GetThroughput", start.Add(time.Minute), start.Add(2*time.Minute)).Return([]*model.Throughput{
+		{Service: "svcA", Operation: "PUT", Count: 6, Probabilities: map[string]struct{}{"0.100000": {}, "invalid": {}}},
+	}, nil)
+
+	series, err := LoadSeries(store, start, start.Add(2*time.Minute), time.Minute)
+	require.NoError(t, err)
+	require.Len(t, series, 3)
+	assert.Equal(t, "svcA", series[0].Service)
+	assert.Equal(t, "GET", series[0].Operation)
+	assert.InDeltaSlice(t, []float64{500 + 250, 0}, series[0].QPS, 1e-9)
+	assert.Equal(t, "PUT", series[1].Operation)
+	assert.InDeltaSlice(t, []float64{0, 1}, series[1].QPS, 1e-9)
+	assert.Equal(t, "svcB", series[2].Service)
+	assert.InDeltaSlice(t, []float64{100, 0}, series[2].QPS, 1e-9)
+
+	store = &mocks.Store{}
+	store.On("GetThroughput", start, start.Add(time.Minute)).Return(nil, errors.New("storage error"))
+	_, err = LoadSeries(store, start, start.Add(time.Minute), time.Minute)
+	assert.EqualError(t, err, "storage error")
+
+	_, err = LoadSeries(store, start, start.Add(time.Minute), 0)
+	assert.EqualError(t, err, "the calculation interval must be positive, got 0s")
+}
+
+func TestSimulate(t *testing.T) {
+	series := []*Series{
+		{Service: "svcA", Operation: "steady", QPS: []float64{1000, 1000, 1000, 1000, 1000, 1000}},
+		{Service: "svcA", Operation: "burst", QPS: []float64{1000, 1000, 1000, 4000, 4000, 4000}},
+		{Service: "svcA", Operation: "idle", QPS: []float64{0, 0, 0}},
+	}
+	tests := []struct {
+		calculator calculationstrategy.Options
+		expected   []*Result
+	}{
+		{
+			calculator: calculationstrategy.Options{PercentageIncreaseCap: 1},
+			expected: []*Result{
+				{
+					Service: "svcA", Operation: "steady", Converged: true, ConvergenceTime: 3 * time.Minute,
+					SampledQPS: []float64{0.1, 0.2, 0.4, 0.8, 0.8, 0.8},
+				},
+				{
+					Service: "svcA", Operation: "burst", Converged: true, ConvergenceTime: 4 * time.Minute, Overshoot: 2.2,
+					SampledQPS: []float64{0.1, 0.2, 0.4, 3.2, 1, 1},
+				},
+				{Service: "svcA", Operation: "idle", SampledQPS: []float64{0, 0, 0}},
+			},
+		},
+		{
+			calculator: calculationstrategy.Options{Kind: calculationstrategy.ProportionalKind, SmoothingFactor: 1},
+			expected: []*Result{
+				{
+					Service: "svcA", Operation: "steady", Converged: true, ConvergenceTime: time.Minute,
+					SampledQPS: []float64{0.1, 1, 1, 1, 1, 1},
+				},
+				{
+					Service: "svcA", Operation: "burst", Converged: true, ConvergenceTime: 4 * time.Minute, Overshoot: 3,
+					SampledQPS: []float64{0.1, 1, 1, 4, 1, 1},
+				},
+				{Service: "svcA", Operation: "idle", SampledQPS: []float64{0, 0, 0}},
+			},
+		},
+	}
+	for _, test := range tests {
+		report, err := Simulate(test.calculator, series, testOptions)
+		require.NoError(t, err)
+		require.Len(t, report.Results, len(test.expected))
+		for i, expected := range test.expected {
+			result := report.Results[i]
+			assert.InDeltaSlice(t, expected.SampledQPS, result.SampledQPS, 1e-9, expected.Operation)
+			assert.InDelta(t, expected.Overshoot, result.Overshoot, 1e-9, expected.Operation)
+			expected.SampledQPS, expected.Overshoot = result.SampledQPS, result.Overshoot
+			assert.Equal(t, expected, result, report.Calculator)
+		}
+	}
+
+	_, err := Simulate(calculationstrategy.Options{Kind: "pd"}, series, testOptions)
+	assert.Error(t, err)
+}
+
+func TestSimulatePIDCalculator(t *testing.T) {
+	series := []*Series{
+		{Service: "svcA", Operation: "GET", QPS: []float64{1000, 1000, 1000, 1000, 1000, 1000, 1000, 1000}},
+	}
+	report, err := Simulate(calculationstrategy.Options{Kind: calculationstrategy.PIDKind}, series, testOptions)
+	require.NoError(t, err)
+	assert.Equal(t, calculationstrategy.PIDKind, report.Calculator)
+	result := report.Results[0]
+	assert.True(t, result.Converged)
+	assert.Equal(t, 3*time.Minute, result.ConvergenceTime)
+	assert.Zero(t, result.Overshoot)
+}
+
+func TestSummary(t *testing.T) {
+	report := &Report{Results: []*Result{
+		{Converged: true, ConvergenceTime: time.Minute, Overshoot: 0.5},
+		{Converged: true, ConvergenceTime: 3 * time.Minute},
+		{Overshoot: 1},
+	}}
+	assert.Equal(t, Summary{
+		Operations:      3,
+		Converged:       2,
+		MeanConvergence: 2 * time.Minute,
+		MaxConvergence:  3 * time.Minute,
+		MeanOvershoot:   0.5,
+		MaxOvershoot:    1,
+	}, report.Summary())
+	assert.Equal(t, Summary{}, (&Report{}).Summary())
+}
+
+func TestWriteReports(t *testing.T) {
+	reports := []*Report{
+		{Calculator: "pid", Results: []*Result{
+			{Service: "svcA", Operation: "GET", Converged: true, ConvergenceTime: time.Minute, Overshoot: 0.25},
+			{Service: "svcA", Operation: "PUT"},
+		}},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, WriteReports(&buf, reports, false))
+	assert.Equal(t, ""+
+		"CALCULATOR  OPERATIONS  CONVERGED  MEAN CONVERGENCE  MAX CONVERGENCE  MEAN OVERSHOOT  MAX OVERSHOOT\n"+
+		"pid         2           1          1m0s              1m0s             12.5%           25.0%\n",
+		buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteReports(&buf, reports, true))
+	assert.Contains(t, buf.String(), "pid         svcA     GET        1m0s         25.0%\n")
+	assert.Contains(t, buf.String(), "pid         svcA     PUT        -            0.0%\n")
+}
@@ -15,20 +15,33 @@
 package adaptive
 
 import (
+	"errors"
 	"flag"
+	"os"
 
 	"github.com/spf13/viper"
 	"github.com/uber/jaeger-lib/metrics"
 	"go.uber.org/zap"
 
 	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
+	"github.com/jaegertracing/jaeger/pkg/distributedlock"
+	"github.com/jaegertracing/jaeger/plugin/sampling/leaderelection"
+	"github.com/jaegertracing/jaeger/storage"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
 )
 
+// samplingLock is the resource locked by the collector which calculates the probabilities.
+const samplingLock = "sampling_lock"
+
+var errNoSamplingStoreFactory = errors.New("the storage backend does not support adaptive sampling")
+
 // Factory implements strategystore.Factory for an adaptive strategy store.
 type Factory struct {
 	options        Options
 	logger         *zap.Logger
 	metricsFactory metrics.Factory
+	lock           distributedlock.Lock
+	store          samplingstore.Store
 }
 
 // NewFactory creates a new Factory.
@@ -50,14 +63,46 @@ func (f *Factory) InitFromViper(v *viper.Viper, logger *zap.Logger) {
 }
 
 // Initialize implements strategystore.Factory
-func (f *Factory) Initialize(metricsFactory metrics.Factory, logger *zap.Logger) error {
+func (f *Factory) Initialize(metricsFactory metrics.Factory, ssFactory storage.SamplingStoreFactory, logger *zap.Logger) error {
+	if ssFactory == nil {
+		return errNoSamplingStoreFactory
+	}
 	f.logger = logger
 	f.metricsFactory = metricsFactory
+	var err error
+	if f.lock, err = ssFactory.CreateLock(); err != nil {
+		return err
+	}
+	if f.store, err = ssFactory.CreateSamplingStore(); err != nil {
+		return err
+	}
 	return nil
 }
 
-// CreateStrategyStore implements strategystore.Factory
+// CreateStrategyStore implements strategystore.Factory, the strategy store is started
+// and calculates the probabilities when it is elected leader.
 func (f *Factory) CreateStrategyStore() (strategystore.StrategyStore, error) {
-	// TODO
-	return nil, nil
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	participant := leaderelection.NewElectionParticipant(f.lock, samplingLock, leaderelection.ElectionParticipantOptions{
+		LeaderLeaseRefreshInterval:   f.options.LeaderLeaseRefreshInterval,
+		FollowerLeaseRefreshInterval: f.options.FollowerLeaseRefreshInterval,
+		Logger:                       f.logger,
+	})
+	store, err := NewProcessor(f.options, hostname, f.store, participant, f.metricsFactory, f.logger)
+	if err != nil {
+		return nil, err
+	}
+	p := store.(*processor)
+	if err := participant.Start(); err != nil {
+		return nil, err
+	}
+	if err := p.Start(); err != nil {
+		return nil, err
+	}
+	aggregator := NewAggregator(f.metricsFactory, f.options.CalculationInterval, f.store)
+	aggregator.Start()
+	return newStrategyStore(p, aggregator, f.logger), nil
 }
@@ -15,20 +15,34 @@
 package adaptive
 
 import (
+	"context"
+	"errors"
+	"io"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/uber/jaeger-lib/metrics"
 	"go.uber.org/zap"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/model"
 	ss "github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
+	jaegermodel "github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/pkg/config"
+	"github.com/jaegertracing/jaeger/pkg/distributedlock"
+	lmocks "github.com/jaegertracing/jaeger/pkg/distributedlock/mocks"
 	"github.com/jaegertracing/jaeger/plugin"
+	"github.com/jaegertracing/jaeger/plugin/sampling/calculationstrategy"
+	"github.com/jaegertracing/jaeger/storage"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
+	smocks "github.com/jaegertracing/jaeger/storage/samplingstore/mocks"
 )
 
 var _ ss.Factory = new(Factory)
 var _ plugin.Configurable = new(Factory)
+var _ storage.SamplingStoreFactory = new(samplingStoreFactory)
 
 func TestFactory(t *testing.T) {
 	f := NewFactory()
@@ -45,6 +59,11 @@ func TestFactory(t *testing.T) {
 		"--sampling.min-samples-per-second=1",
 		"--sampling.leader-lease-refresh-interval=1s",
 		"--sampling.follower-lease-refresh-interval=2s",
+		"--sampling.overrides-file=overrides.json",
+		"--sampling.calculator=pid",
+		"--sampling.calculator.pid.proportional-gain=0.1",
+		"--sampling.calculator.pid.integral-gain=0.5",
+		"--sampling.calculator.pid.derivative-gain=0",
 	})
 
 	f.InitFromViper(v, zap.NewNop())
@@ -60,8 +79,82 @@ func TestFactory(t *testing.T) {
 	assert.Equal(t, 1.0, f.options.MinSamplesPerSecond)
 	assert.Equal(t, time.Second, f.options.LeaderLeaseRefreshInterval)
 	assert.Equal(t, time.Second*2, f.options.FollowerLeaseRefreshInterval)
+	assert.Equal(t, "overrides.json", f.options.OverridesFile)
+	assert.Equal(t, calculationstrategy.Options{
+		Kind:                  calculationstrategy.PIDKind,
+		PercentageIncreaseCap: 1,
+		PIDGains:              calculationstrategy.PIDGains{Proportional: 0.1, Integral: 0.5},
+		SmoothingFactor:       0.5,
+	}, f.options.Calculator)
+}
+
+type samplingStoreFactory struct {
+	store   samplingstore.Store
+	lock    distributedlock.Lock
+	lockErr error
+}
+
+func (f *samplingStoreFactory) CreateSamplingStore() (samplingstore.Store, error) {
+	return f.store, nil
+}
+
+func (f *samplingStoreFactory) CreateLock() (distributedlock.Lock, error) {
+	return f.lock, f.lockErr
+}
+
+func newSamplingStoreFactory() *samplingStoreFactory {
+	store := &smocks.Store{}
+	store.On("GetLatestProbabilities").Return(make(model.ServiceOperationProbabilities), nil)
+	store.On("GetThroughput", mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).
+		Return([]*model.Throughput{}, nil)
+	store.On("InsertThroughput", mock.Anything).Return(nil)
+	lock := &lmocks.Lock{}
+	lock.On("Acquire", mock.Anything, mock.Anything).Return(false, nil)
+	lock.On("Forfeit", mock.Anything).Return(true, nil)
+	return &samplingStoreFactory{store: store, lock: lock}
+}
+
+func TestFactoryCreateStrategyStore(t *testing.T) {
+	f := NewFactory()
+	v, command := config.Viperize(f.AddFlags)
+	command.ParseFlags([]string{"--sampling.initial-sampling-probability=0.02"})
+	f.InitFromViper(v, zap.NewNop())
+	require.NoError(t, f.Initialize(metrics.NullFactory, newSamplingStoreFactory(), zap.NewNop()))
+
+	store, err := f.CreateStrategyStore()
+	require.NoError(t, err)
+	defer store.(io.Closer).Close()
+
+	strategy, err := store.GetSamplingStrategy(context.Background(), "frontend")
+	require.NoError(t, err)
+	assert.Equal(t, 0.02, strategy.OperationSampling.DefaultSamplingProbability)
+	assert.Implements(t, (*ss.Aggregator)(nil), store)
+	assert.Implements(t, (*Introspector)(nil), store)
+
+	span := &jaegermodel.Span{
+		OperationName: "GET",
+		Process:       &jaegermodel.Process{ServiceName: "frontend"},
+		Tags: jaegermodel.KeyValues{
+			jaegermodel.String("sampler.type", "probabilistic"),
+			jaegermodel.Float64("sampler.param", 0.02),
+		},
+	}
+	store.(ss.Aggregator).HandleRootSpan(span, "")
+}
 
-	assert.NoError(t, f.Initialize(metrics.NullFactory, zap.NewNop()))
+func TestFactoryInitializeErrors(t *testing.T) {
+	f := NewFactory()
+	assert.Equal(t, errNoSamplingStoreFactory, f.Initialize(metrics.NullFactory, nil, zap.NewNop()))
+
+	ssFactory := newSamplingStoreFactory()
+	ssFactory.lockErr = errors.New("no lock")
+	assert.EqualError(t, f.Initialize(metrics.NullFactory, ssFactory, zap.NewNop()), "no lock")
+}
+
+func TestFactoryCreateStrategyStoreError(t *testing.T) {
+	f := NewFactory()
+	require.NoError(t, f.Initialize(metrics.NullFactory, newSamplingStoreFactory(), zap.NewNop()))
+	// the options are not initialized from viper, the calculation interval is zero
 	_, err := f.CreateStrategyStore()
-	assert.NoError(t, err)
+	assert.Equal(t, errNonZero, err)
 }
@@ -0,0 +1,269 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adaptive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	introspectionProto "github.com/jaegertracing/jaeger/proto-gen/api_v2/introspection"
+)
+
+// IntrospectionRoute is the route of the adaptive sampling introspection endpoint on the admin server.
+const IntrospectionRoute = "/sampling/adaptive"
+
+// ErrNoIntrospection is returned by the strategy stores wrapping a strategy store which is not adaptive.
+var ErrNoIntrospection = errors.New("the strategy store does not use adaptive sampling")
+
+// Introspector is implemented by the strategy stores using adaptive sampling.
+type Introspector interface {
+	// Introspect returns the state of adaptive sampling for a service, or for all services if service is empty.
+	Introspect(service string) (*Introspection, error)
+}
+
+// Introspection is the state of adaptive sampling, explaining how the probabilities are calculated.
+type Introspection struct {
+	// Instance is the hostname of the collector answering
+	Instance string `json:"instance"`
+	// IsLeader is true if the collector answering calculates the probabilities
+	IsLeader bool `json:"is_leader"`
+	// Leaders are the instances which saved probabilities within the last two calculation intervals,
+	// there is more than one if the leadership changed
+	Leaders []string `json:"leaders"`
+	// CalculatedAt is the time of the latest calculation by the collector answering, when it is leader
+	CalculatedAt *time.Time `json:"calculated_at,omitempty"`
+	// LoadedAt is the time of the latest load of the probabilities from storage, when it is follower
+	LoadedAt *time.Time `json:"loaded_at,omitempty"`
+	// CalculationInterval is the interval between two calculations of the probabilities
+	CalculationInterval string `json:"calculation_interval"`
+	// DeltaTolerance is the deviation of the QPS from the target under which the probability is not changed
+	DeltaTolerance float64                `json:"delta_tolerance"`
+	Services       []ServiceIntrospection `json:"services"`
+}
+
+// ServiceIntrospection is the state of adaptive sampling for the operations of a service.
+type ServiceIntrospection struct {
+	Service    string                   `json:"service"`
+	Operations []OperationIntrospection `json:"operations"`
+}
+
+// OperationIntrospection is the state of adaptive sampling for an operation.
+type OperationIntrospection struct {
+	Operation string `json:"operation"`
+	// Probability is the sampling probability currently served
+	Probability float64 `json:"probability"`
+	// QPS is the QPS of sampled traces measured in the latest throughput buckets, the latest first
+	QPS []float64 `json:"qps"`
+	// WeightedQPS is the weighted average of QPS, biased toward the latest buckets, compared to the target
+	WeightedQPS float64 `json:"weighted_qps"`
+	// TargetSamplesPerSecond is the target QPS, from the options or the overrides
+	TargetSamplesPerSecond float64 `json:"target_samples_per_second"`
+	// WithinTolerance is true if the weighted QPS is within DeltaTolerance of the target,
+	// in which case the probability is not changed
+	WithinTolerance bool `json:"within_tolerance"`
+	// Pinned is true if the probability is set by the overrides rather than calculated
+	Pinned bool `json:"pinned"`
+}
+
+// Introspect implements Introspector.
+func (p *processor) Introspect(service string) (*Introspection, error) {
+	leaders := p.leaders()
+	o := p.getOverrides()
+
+	p.RLock()
+	defer p.RUnlock()
+	introspection := &Introspection{
+		Instance:            p.hostname,
+		IsLeader:            p.isLeader(),
+		Leaders:             leaders,
+		CalculationInterval: p.CalculationInterval.String(),
+		DeltaTolerance:      p.DeltaTolerance,
+		Services:            []ServiceIntrospection{},
+	}
+	if !p.calculatedAt.IsZero() {
+		calculatedAt := p.calculatedAt
+		introspection.CalculatedAt = &calculatedAt
+	}
+	if !p.loadedAt.IsZero() {
+		loadedAt := p.loadedAt
+		introspection.LoadedAt = &loadedAt
+	}
+
+	svcOpQPS := p.throughputToQPS()
+	operations := make(map[string]map[string]struct{})
+	for svc, opProbabilities := range p.probabilities {
+		for op := range opProbabilities {
+			addOperation(operations, svc, op)
+		}
+	}
+	for svc, opQPS := range svcOpQPS {
+		for op := range opQPS {
+			addOperation(operations, svc, op)
+		}
+	}
+	services := make([]string, 0, len(operations))
+	for svc := range operations {
+		if service == "" || svc == service {
+			services = append(services, svc)
+		}
+	}
+	sort.Strings(services)
+	for _, svc := range services {
+		svcIntrospection := ServiceIntrospection{Service: svc}
+		ops := make([]string, 0, len(operations[svc]))
+		for op := range operations[svc] {
+			ops = append(ops, op)
+		}
+		sort.Strings(ops)
+		for _, op := range ops {
+			settings := o.settings(p.Options, svc, op)
+			probability := p.InitialSamplingProbability
+			if prob, ok := p.probabilities[svc][op]; ok {
+				probability = prob
+			}
+			if settings.pinned {
+				probability = settings.samplingProbability
+			}
+			qps := svcOpQPS[svc][op]
+			if qps == nil {
+				qps = []float64{}
+			}
+			weightedQPS := p.calculateWeightedQPS(qps)
+			svcIntrospection.Operations = append(svcIntrospection.Operations, OperationIntrospection{
+				Operation:              op,
+				Probability:            probability,
+				QPS:                    qps,
+				WeightedQPS:            weightedQPS,
+				TargetSamplesPerSecond: settings.targetSamplesPerSecond,
+				WithinTolerance:        p.withinTolerance(weightedQPS, settings.targetSamplesPerSecond),
+				Pinned:                 settings.pinned,
+			})
+		}
+		introspection.Services = append(introspection.Services, svcIntrospection)
+	}
+	return introspection, nil
+}
+
+// leaders returns the instances which saved probabilities within the last two calculation intervals.
+func (p *processor) leaders() []string {
+	if p.isLeader() {
+		return []string{p.hostname}
+	}
+	end := time.Now()
+	data, err := p.storage.GetProbabilitiesAndQPS(end.Add(-2*p.CalculationInterval), end)
+	if err != nil {
+		p.logger.Warn("failed to get the instances which saved probabilities", zap.Error(err))
+		return []string{}
+	}
+	leaders := make([]string, 0, len(data))
+	for host := range data {
+		leaders = append(leaders, host)
+	}
+	sort.Strings(leaders)
+	return leaders
+}
+
+func addOperation(operations map[string]map[string]struct{}, service, operation string) {
+	if _, ok := operations[service]; !ok {
+		operations[service] = make(map[string]struct{})
+	}
+	operations[service][operation] = struct{}{}
+}
+
+// NewIntrospectionHandler creates the handler of the adaptive sampling introspection endpoint.
+// It accepts an optional service query parameter to restrict the response to one service.
+func NewIntrospectionHandler(introspector Introspector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		introspection, err := introspector.Introspect(r.URL.Query().Get("service"))
+		if errors.Is(err, ErrNoIntrospection) {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(introspection)
+	})
+}
+
+// GRPCIntrospectionHandler serves the adaptive sampling introspection over gRPC.
+type GRPCIntrospectionHandler struct {
+	introspector Introspector
+}
+
+// NewGRPCIntrospectionHandler creates the handler of the adaptive sampling introspection gRPC service.
+func NewGRPCIntrospectionHandler(introspector Introspector) *GRPCIntrospectionHandler {
+	return &GRPCIntrospectionHandler{introspector: introspector}
+}
+
+// Introspect implements introspection.SamplingIntrospectionServer.
+func (h *GRPCIntrospectionHandler) Introspect(_ context.Context, r *introspectionProto.IntrospectionRequest) (*introspectionProto.IntrospectionResponse, error) {
+	introspection, err := h.introspector.Introspect(r.GetService())
+	if errors.Is(err, ErrNoIntrospection) {
+		return nil, status.Error(codes.Unimplemented, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	resp := &introspectionProto.IntrospectionResponse{
+		Instance:            introspection.Instance,
+		IsLeader:            introspection.IsLeader,
+		Leaders:             introspection.Leaders,
+		CalculationInterval: introspection.CalculationInterval,
+		DeltaTolerance:      introspection.DeltaTolerance,
+		Services:            make([]*introspectionProto.ServiceIntrospection, len(introspection.Services)),
+	}
+	if introspection.CalculatedAt != nil {
+		if resp.CalculatedAt, err = types.TimestampProto(*introspection.CalculatedAt); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	if introspection.LoadedAt != nil {
+		if resp.LoadedAt, err = types.TimestampProto(*introspection.LoadedAt); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	for i, svc := range introspection.Services {
+		operations := make([]*introspectionProto.OperationIntrospection, len(svc.Operations))
+		for j, op := range svc.Operations {
+			operations[j] = &introspectionProto.OperationIntrospection{
+				Operation:              op.Operation,
+				Probability:            op.Probability,
+				Qps:                    op.QPS,
+				WeightedQps:            op.WeightedQPS,
+				TargetSamplesPerSecond: op.TargetSamplesPerSecond,
+				WithinTolerance:        op.WithinTolerance,
+				Pinned:                 op.Pinned,
+			}
+		}
+		resp.Services[i] = &introspectionProto.ServiceIntrospection{Service: svc.Service, Operations: operations}
+	}
+	return resp, nil
+}
@@ -0,0 +1,261 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adaptive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/model"
+	epmocks "github.com/jaegertracing/jaeger/plugin/sampling/leaderelection/mocks"
+	introspectionProto "github.com/jaegertracing/jaeger/proto-gen/api_v2/introspection"
+	smocks "github.com/jaegertracing/jaeger/storage/samplingstore/mocks"
+)
+
+func newIntrospectionProcessor(t *testing.T, leader bool, storage *smocks.Store) *processor {
+	o, err := parseOverrides([]byte(testOverrides))
+	require.NoError(t, err)
+	mockEP := &epmocks.ElectionParticipant{}
+	mockEP.On("IsLeader").Return(leader)
+	p := &processor{
+		Options: Options{
+			TargetSamplesPerSecond:     1.0,
+			DeltaTolerance:             0.2,
+			InitialSamplingProbability: 0.001,
+			CalculationInterval:        time.Minute,
+			BucketsForCalculation:      1,
+		},
+		hostname:            "host1",
+		storage:             storage,
+		electionParticipant: mockEP,
+		logger:              zap.NewNop(),
+		weightVectorCache:   NewWeightVectorCache(),
+		probabilities: model.ServiceOperationProbabilities{
+			"svcA": {"GET": 0.5},
+			"svcB": {"GET": 0.1},
+		},
+		throughputs: []*throughputBucket{
+			{
+				throughput: serviceOperationThroughput{
+					"svcA": map[string]*model.Throughput{
+						"GET": {Count: 60},
+						"PUT": {Count: 600},
+					},
+					"checkout": map[string]*model.Throughput{
+						"charge": {Count: 60},
+					},
+				},
+				interval: time.Minute,
+			},
+		},
+	}
+	p.overrides.Store(o)
+	return p
+}
+
+func TestIntrospect(t *testing.T) {
+	p := newIntrospectionProcessor(t, true, &smocks.Store{})
+	calculatedAt := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	p.calculatedAt = calculatedAt
+
+	introspection, err := p.Introspect("")
+	require.NoError(t, err)
+	assert.Equal(t, &Introspection{
+		Instance:            "host1",
+		IsLeader:            true,
+		Leaders:             []string{"host1"},
+		CalculatedAt:        &calculatedAt,
+		CalculationInterval: "1m0s",
+		DeltaTolerance:      0.2,
+		Services: []ServiceIntrospection{
+			{
+				Service: "checkout",
+				Operations: []OperationIntrospection{
+					{
+						Operation:              "charge",
+						Probability:            1.0,
+						QPS:                    []float64{1.0},
+						WeightedQPS:            1.0,
+						TargetSamplesPerSecond: 10,
+						Pinned:                 true,
+					},
+				},
+			},
+			{
+				Service: "svcA",
+				Operations: []OperationIntrospection{
+					{
+						Operation:              "GET",
+						Probability:            0.5,
+						QPS:                    []float64{1.0},
+						WeightedQPS:            1.0,
+						TargetSamplesPerSecond: 1.0,
+						WithinTolerance:        true,
+					},
+					{
+						Operation:              "PUT",
+						Probability:            0.001,
+						QPS:                    []float64{10.0},
+						WeightedQPS:            10.0,
+						TargetSamplesPerSecond: 1.0,
+					},
+				},
+			},
+			{
+				Service: "svcB",
+				Operations: []OperationIntrospection{
+					{
+						Operation:              "GET",
+						Probability:            0.1,
+						QPS:                    []float64{},
+						TargetSamplesPerSecond: 1.0,
+					},
+				},
+			},
+		},
+	}, introspection)
+
+	introspection, err = p.Introspect("svcB")
+	require.NoError(t, err)
+	require.Len(t, introspection.Services, 1)
+	assert.Equal(t, "svcB", introspection.Services[0].Service)
+
+	introspection, err = p.Introspect("unknown")
+	require.NoError(t, err)
+	assert.Empty(t, introspection.Services)
+}
+
+func TestIntrospectFollower(t *testing.T) {
+	mockStorage := &smocks.Store{}
+	mockStorage.On("GetProbabilitiesAndQPS", mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).
+		Return(map[string][]model.ServiceOperationData{"host3": nil, "host2": nil}, nil).Once()
+	mockStorage.On("GetProbabilitiesAndQPS", mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).
+		Return(nil, errTestStorage)
+	p := newIntrospectionProcessor(t, false, mockStorage)
+	loadedAt := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	p.loadedAt = loadedAt
+
+	introspection, err := p.Introspect("svcA")
+	require.NoError(t, err)
+	assert.False(t, introspection.IsLeader)
+	assert.Equal(t, []string{"host2", "host3"}, introspection.Leaders)
+	assert.Nil(t, introspection.CalculatedAt)
+	assert.Equal(t, &loadedAt, introspection.LoadedAt)
+
+	introspection, err = p.Introspect("svcA")
+	require.NoError(t, err)
+	assert.Empty(t, introspection.Leaders)
+}
+
+type mockIntrospector struct {
+	service string
+	err     error
+}
+
+func (m *mockIntrospector) Introspect(service string) (*Introspection, error) {
+	m.service = service
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &Introspection{Instance: "host1", Services: []ServiceIntrospection{{Service: service}}}, nil
+}
+
+func TestIntrospectionHandler(t *testing.T) {
+	introspector := &mockIntrospector{}
+	server := httptest.NewServer(NewIntrospectionHandler(introspector))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?service=svcA")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	var introspection Introspection
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&introspection))
+	assert.Equal(t, "host1", introspection.Instance)
+	assert.Equal(t, "svcA", introspector.service)
+
+	tests := []struct {
+		name       string
+		method     string
+		err        error
+		statusCode int
+	}{
+		{name: "method not allowed", method: http.MethodPost, statusCode: http.StatusMethodNotAllowed},
+		{name: "not adaptive", method: http.MethodGet, err: ErrNoIntrospection, statusCode: http.StatusNotImplemented},
+		{name: "error", method: http.MethodGet, err: errors.New("introspection error"), statusCode: http.StatusInternalServerError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			introspector.err = test.err
+			req, err := http.NewRequest(test.method, server.URL, nil)
+			require.NoError(t, err)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, test.statusCode, resp.StatusCode)
+		})
+	}
+}
+
+func TestGRPCIntrospectionHandler(t *testing.T) {
+	p := newIntrospectionProcessor(t, true, &smocks.Store{})
+	calculatedAt := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	p.calculatedAt = calculatedAt
+
+	resp, err := NewGRPCIntrospectionHandler(p).Introspect(context.Background(), &introspectionProto.IntrospectionRequest{Service: "svcA"})
+	require.NoError(t, err)
+	assert.Equal(t, "host1", resp.Instance)
+	assert.True(t, resp.IsLeader)
+	assert.Equal(t, []string{"host1"}, resp.Leaders)
+	assert.Equal(t, "1m0s", resp.CalculationInterval)
+	assert.Equal(t, calculatedAt.Unix(), resp.CalculatedAt.Seconds)
+	assert.Nil(t, resp.LoadedAt)
+	require.Len(t, resp.Services, 1)
+	assert.Equal(t, "svcA", resp.Services[0].Service)
+	require.Len(t, resp.Services[0].Operations, 2)
+	assert.Equal(t, "GET", resp.Services[0].Operations[0].Operation)
+	assert.Equal(t, 0.5, resp.Services[0].Operations[0].Probability)
+	assert.Equal(t, []float64{1}, resp.Services[0].Operations[0].Qps)
+	assert.True(t, resp.Services[0].Operations[0].WithinTolerance)
+
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{name: "not adaptive", err: ErrNoIntrospection, code: codes.Unimplemented},
+		{name: "error", err: errors.New("introspection error"), code: codes.Internal},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewGRPCIntrospectionHandler(&mockIntrospector{err: test.err})
+			_, err := handler.Introspect(context.Background(), &introspectionProto.IntrospectionRequest{})
+			assert.Equal(t, test.code, status.Code(err))
+		})
+	}
+}
@@ -16,9 +16,13 @@ package adaptive
 
 import (
 	"flag"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
+
+	"github.com/jaegertracing/jaeger/plugin/sampling/calculationstrategy"
 )
 
 const (
@@ -33,6 +37,13 @@ const (
 	minSamplesPerSecond          = "sampling.min-samples-per-second"
 	leaderLeaseRefreshInterval   = "sampling.leader-lease-refresh-interval"
 	followerLeaseRefreshInterval = "sampling.follower-lease-refresh-interval"
+	overridesFile                = "sampling.overrides-file"
+	calculator                   = "sampling.calculator"
+	percentageIncreaseCap        = "sampling.calculator.percentage-increase-cap"
+	pidProportionalGain          = "sampling.calculator.pid.proportional-gain"
+	pidIntegralGain              = "sampling.calculator.pid.integral-gain"
+	pidDerivativeGain            = "sampling.calculator.pid.derivative-gain"
+	smoothingFactor              = "sampling.calculator.smoothing-factor"
 
 	defaultTargetSamplesPerSecond       = 1
 	defaultDeltaTolerance               = 0.3
@@ -45,6 +56,12 @@ const (
 	defaultMinSamplesPerSecond          = 1.0 / float64(time.Minute/time.Second) // once every 1 minute
 	defaultLeaderLeaseRefreshInterval   = 5 * time.Second
 	defaultFollowerLeaseRefreshInterval = 60 * time.Second
+	defaultCalculator                   = calculationstrategy.PercentageIncreaseCappedKind
+	defaultPercentageIncreaseCap        = 1.0
+	defaultPIDProportionalGain          = 0.2
+	defaultPIDIntegralGain              = 0.6
+	defaultPIDDerivativeGain            = 0.05
+	defaultSmoothingFactor              = 0.5
 )
 
 // Options holds configuration for the adaptive sampling strategy store.
@@ -52,7 +69,7 @@ const (
 // of the optimization/control implemented by the adaptive sampling.
 type Options struct {
 	// TargetSamplesPerSecond is the global target rate of samples per operation.
-	// TODO implement manual overrides per service/operation.
+	// It can be overridden per service and operation in the OverridesFile.
 	TargetSamplesPerSecond float64
 
 	// DeltaTolerance is the acceptable amount of deviation between the observed and the desired (target)
@@ -111,6 +128,19 @@ type Options struct {
 	// FollowerLeaseRefreshInterval is the duration to sleep if this processor is a follower
 	// (ie. failed to gain the leader lock).
 	FollowerLeaseRefreshInterval time.Duration
+
+	// OverridesFile is the path to a JSON file with per-service and per-operation overrides of
+	// TargetSamplesPerSecond, MinSamplingProbability and MinSamplesPerSecond, a max sampling probability,
+	// and pinned probabilities that are not calculated. Operations without an override use the settings
+	// of their service, and services without an override use these options. The file is reloaded when it changes.
+	OverridesFile string
+
+	// Calculator selects and configures the calculator of the new sampling probability of an operation
+	// from its previous probability and its QPS. The percentage increase capped calculator increases the
+	// probability slowly and decreases it at once, the PID calculator controls the probability of every
+	// operation with a PID controller, and the proportional calculator moves the probability towards the
+	// target by a smoothing factor.
+	Calculator calculationstrategy.Options
 }
 
 // AddFlags adds flags for Options
@@ -148,6 +178,27 @@ func AddFlags(flagSet *flag.FlagSet) {
 	flagSet.Duration(followerLeaseRefreshInterval, defaultFollowerLeaseRefreshInterval,
 		"The duration to sleep if this processor is a follower.",
 	)
+	flagSet.String(overridesFile, "",
+		`The path to a JSON file with per-service and per-operation overrides of the target samples per second, min and max sampling probabilities and min samples per second, or pinned sampling probabilities, e.g. {"services": [{"service": "checkout", "target_samples_per_second": 10, "operations": [{"operation": "charge", "sampling_probability": 1}]}]}. The file is reloaded when it changes, it should be replaced atomically.`,
+	)
+	flagSet.String(calculator, defaultCalculator,
+		fmt.Sprintf("The calculator of the sampling probabilities, one of %s.", strings.Join(calculationstrategy.Kinds, ", ")),
+	)
+	flagSet.Float64(percentageIncreaseCap, defaultPercentageIncreaseCap,
+		"The maximum increase of a sampling probability at each calculation of the percentage-increase-capped calculator, as a ratio of the previous probability.",
+	)
+	flagSet.Float64(pidProportionalGain, defaultPIDProportionalGain,
+		"The proportional gain of the pid calculator.",
+	)
+	flagSet.Float64(pidIntegralGain, defaultPIDIntegralGain,
+		"The integral gain of the pid calculator, 1 jumps directly to the sampling probability giving the target samples per second.",
+	)
+	flagSet.Float64(pidDerivativeGain, defaultPIDDerivativeGain,
+		"The derivative gain of the pid calculator.",
+	)
+	flagSet.Float64(smoothingFactor, defaultSmoothingFactor,
+		"The smoothing factor of the proportional calculator, between 0 and 1, 1 jumps directly to the sampling probability giving the target samples per second.",
+	)
 }
 
 // InitFromViper initializes Options with properties from viper
@@ -163,5 +214,16 @@ func (opts Options) InitFromViper(v *viper.Viper) Options {
 	opts.MinSamplesPerSecond = v.GetFloat64(minSamplesPerSecond)
 	opts.LeaderLeaseRefreshInterval = v.GetDuration(leaderLeaseRefreshInterval)
 	opts.FollowerLeaseRefreshInterval = v.GetDuration(followerLeaseRefreshInterval)
+	opts.OverridesFile = v.GetString(overridesFile)
+	opts.Calculator = calculationstrategy.Options{
+		Kind:                  v.GetString(calculator),
+		PercentageIncreaseCap: v.GetFloat64(percentageIncreaseCap),
+		PIDGains: calculationstrategy.PIDGains{
+			Proportional: v.GetFloat64(pidProportionalGain),
+			Integral:     v.GetFloat64(pidIntegralGain),
+			Derivative:   v.GetFloat64(pidDerivativeGain),
+		},
+		SmoothingFactor: v.GetFloat64(smoothingFactor),
+	}
 	return opts
 }
@@ -0,0 +1,178 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adaptive
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// overrideJSON holds the settings that can be overridden for a service or an operation.
+// Unset settings fall back to the service settings for an operation, and to the Options for a service.
+type overrideJSON struct {
+	// TargetSamplesPerSecond replaces Options.TargetSamplesPerSecond
+	TargetSamplesPerSecond *float64 `json:"target_samples_per_second,omitempty"`
+	// MinSamplingProbability replaces Options.MinSamplingProbability
+	MinSamplingProbability *float64 `json:"min_sampling_probability,omitempty"`
+	// MaxSamplingProbability caps the calculated probabilities, 1.0 by default
+	MaxSamplingProbability *float64 `json:"max_sampling_probability,omitempty"`
+	// SamplingProbability pins the probability, it is not calculated from the throughput
+	SamplingProbability *float64 `json:"sampling_probability,omitempty"`
+}
+
+type operationOverrideJSON struct {
+	Operation string `json:"operation"`
+	overrideJSON
+}
+
+type serviceOverrideJSON struct {
+	Service string `json:"service"`
+	overrideJSON
+	// MinSamplesPerSecond replaces Options.MinSamplesPerSecond, the lower bound rate of the service
+	MinSamplesPerSecond *float64                `json:"min_samples_per_second,omitempty"`
+	Operations          []operationOverrideJSON `json:"operations,omitempty"`
+}
+
+type overridesJSON struct {
+	Services []serviceOverrideJSON `json:"services"`
+}
+
+type serviceOverride struct {
+	overrideJSON
+	minSamplesPerSecond *float64
+	operations          map[string]overrideJSON
+}
+
+// overrides holds the per-service and per-operation settings, by service name.
+type overrides map[string]*serviceOverride
+
+// operationSettings are the settings of the probability calculation of an operation.
+type operationSettings struct {
+	targetSamplesPerSecond float64
+	minSamplingProbability float64
+	maxSamplingProbability float64
+	// pinned is true if the probability is samplingProbability rather than calculated
+	pinned              bool
+	samplingProbability float64
+}
+
+// loadOverrides reads and validates the overrides file.
+func loadOverrides(file string) (overrides, error) {
+	data, err := ioutil.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sampling overrides file: %w", err)
+	}
+	return parseOverrides(data)
+}
+
+func parseOverrides(data []byte) (overrides, error) {
+	var raw overridesJSON
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to parse sampling overrides: %w", err)
+	}
+	result := make(overrides, len(raw.Services))
+	for _, svc := range raw.Services {
+		if svc.Service == "" {
+			return nil, errors.New("sampling override without service name")
+		}
+		if _, ok := result[svc.Service]; ok {
+			return nil, fmt.Errorf("duplicate sampling override for service %q", svc.Service)
+		}
+		if err := svc.overrideJSON.validate(); err != nil {
+			return nil, fmt.Errorf("invalid sampling override for service %q: %w", svc.Service, err)
+		}
+		if svc.MinSamplesPerSecond != nil && *svc.MinSamplesPerSecond < 0 {
+			return nil, fmt.Errorf("invalid sampling override for service %q: min_samples_per_second must not be negative", svc.Service)
+		}
+		override := &serviceOverride{
+			overrideJSON:        svc.overrideJSON,
+			minSamplesPerSecond: svc.MinSamplesPerSecond,
+			operations:          make(map[string]overrideJSON, len(svc.Operations)),
+		}
+		for _, op := range svc.Operations {
+			if _, ok := override.operations[op.Operation]; ok {
+				return nil, fmt.Errorf("duplicate sampling override for operation %q of service %q", op.Operation, svc.Service)
+			}
+			if err := op.overrideJSON.validate(); err != nil {
+				return nil, fmt.Errorf("invalid sampling override for operation %q of service %q: %w", op.Operation, svc.Service, err)
+			}
+			override.operations[op.Operation] = op.overrideJSON
+		}
+		result[svc.Service] = override
+	}
+	return result, nil
+}
+
+func (o overrideJSON) validate() error {
+	if o.TargetSamplesPerSecond != nil && *o.TargetSamplesPerSecond <= 0 {
+		return errors.New("target_samples_per_second must be positive")
+	}
+	for _, p := range []struct {
+		name  string
+		value *float64
+	}{
+		{name: "min_sampling_probability", value: o.MinSamplingProbability},
+		{name: "max_sampling_probability", value: o.MaxSamplingProbability},
+		{name: "sampling_probability", value: o.SamplingProbability},
+	} {
+		if p.value != nil && (*p.value < 0 || *p.value > 1) {
+			return fmt.Errorf("%s must be between 0 and 1", p.name)
+		}
+	}
+	if o.MinSamplingProbability != nil && o.MaxSamplingProbability != nil && *o.MinSamplingProbability > *o.MaxSamplingProbability {
+		return errors.New("min_sampling_probability must not be greater than max_sampling_probability")
+	}
+	return nil
+}
+
+// settings returns the settings of an operation, falling back to the settings of its service and to the options.
+func (o overrides) settings(options Options, service, operation string) operationSettings {
+	settings := operationSettings{
+		targetSamplesPerSecond: options.TargetSamplesPerSecond,
+		minSamplingProbability: options.MinSamplingProbability,
+		maxSamplingProbability: maxSamplingProbability,
+	}
+	svc, ok := o[service]
+	if !ok {
+		return settings
+	}
+	settings.apply(svc.overrideJSON)
+	if op, ok := svc.operations[operation]; ok {
+		settings.apply(op)
+	}
+	return settings
+}
+
+func (s *operationSettings) apply(o overrideJSON) {
+	if o.TargetSamplesPerSecond != nil {
+		s.targetSamplesPerSecond = *o.TargetSamplesPerSecond
+	}
+	if o.MinSamplingProbability != nil {
+		s.minSamplingProbability = *o.MinSamplingProbability
+	}
+	if o.MaxSamplingProbability != nil {
+		s.maxSamplingProbability = *o.MaxSamplingProbability
+	}
+	if o.SamplingProbability != nil {
+		s.pinned = true
+		s.samplingProbability = *o.SamplingProbability
+	}
+}
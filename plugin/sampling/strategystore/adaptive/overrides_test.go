@@ -0,0 +1,152 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adaptive
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testOverrides = `{
+	"services": [
+		{
+			"service": "checkout",
+			"target_samples_per_second": 10,
+			"max_sampling_probability": 0.5,
+			"min_samples_per_second": 0.1,
+			"operations": [
+				{"operation": "charge", "sampling_probability": 1},
+				{"operation": "refund", "min_sampling_probability": 0.01}
+			]
+		},
+		{"service": "batch", "sampling_probability": 0.0001}
+	]
+}`
+
+func TestOverridesSettings(t *testing.T) {
+	o, err := parseOverrides([]byte(testOverrides))
+	require.NoError(t, err)
+	options := Options{TargetSamplesPerSecond: 1, MinSamplingProbability: 0.00001}
+
+	tests := []struct {
+		name      string
+		service   string
+		operation string
+		expected  operationSettings
+	}{
+		{
+			name: "no override", service: "frontend", operation: "GET",
+			expected: operationSettings{targetSamplesPerSecond: 1, minSamplingProbability: 0.00001, maxSamplingProbability: 1},
+		},
+		{
+			name: "service override", service: "checkout", operation: "GET",
+			expected: operationSettings{targetSamplesPerSecond: 10, minSamplingProbability: 0.00001, maxSamplingProbability: 0.5},
+		},
+		{
+			name: "operation override", service: "checkout", operation: "refund",
+			expected: operationSettings{targetSamplesPerSecond: 10, minSamplingProbability: 0.01, maxSamplingProbability: 0.5},
+		},
+		{
+			name: "pinned operation", service: "checkout", operation: "charge",
+			expected: operationSettings{
+				targetSamplesPerSecond: 10, minSamplingProbability: 0.00001, maxSamplingProbability: 0.5,
+				pinned: true, samplingProbability: 1,
+			},
+		},
+		{
+			name: "pinned service", service: "batch", operation: "run",
+			expected: operationSettings{
+				targetSamplesPerSecond: 1, minSamplingProbability: 0.00001, maxSamplingProbability: 1,
+				pinned: true, samplingProbability: 0.0001,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, o.settings(options, test.service, test.operation))
+		})
+	}
+
+	var none overrides
+	assert.Equal(t, operationSettings{targetSamplesPerSecond: 1, minSamplingProbability: 0.00001, maxSamplingProbability: 1},
+		none.settings(options, "checkout", "charge"))
+}
+
+func TestParseOverridesErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides string
+		err       string
+	}{
+		{
+			name:      "invalid json",
+			overrides: `{"services": [}`,
+			err:       "failed to parse sampling overrides: invalid character '}' looking for beginning of value",
+		},
+		{
+			name:      "unknown field",
+			overrides: `{"services": [{"service": "a", "target": 1}]}`,
+			err:       `failed to parse sampling overrides: json: unknown field "target"`,
+		},
+		{
+			name:      "no service name",
+			overrides: `{"services": [{"target_samples_per_second": 1}]}`,
+			err:       "sampling override without service name",
+		},
+		{
+			name:      "duplicate service",
+			overrides: `{"services": [{"service": "a"}, {"service": "a"}]}`,
+			err:       `duplicate sampling override for service "a"`,
+		},
+		{
+			name:      "duplicate operation",
+			overrides: `{"services": [{"service": "a", "operations": [{"operation": "op"}, {"operation": "op"}]}]}`,
+			err:       `duplicate sampling override for operation "op" of service "a"`,
+		},
+		{
+			name:      "invalid target",
+			overrides: `{"services": [{"service": "a", "target_samples_per_second": 0}]}`,
+			err:       `invalid sampling override for service "a": target_samples_per_second must be positive`,
+		},
+		{
+			name:      "invalid probability",
+			overrides: `{"services": [{"service": "a", "operations": [{"operation": "op", "sampling_probability": 1.5}]}]}`,
+			err:       `invalid sampling override for operation "op" of service "a": sampling_probability must be between 0 and 1`,
+		},
+		{
+			name:      "min greater than max",
+			overrides: `{"services": [{"service": "a", "min_sampling_probability": 0.5, "max_sampling_probability": 0.1}]}`,
+			err:       `invalid sampling override for service "a": min_sampling_probability must not be greater than max_sampling_probability`,
+		},
+		{
+			name:      "negative min samples per second",
+			overrides: `{"services": [{"service": "a", "min_samples_per_second": -1}]}`,
+			err:       `invalid sampling override for service "a": min_samples_per_second must not be negative`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parseOverrides([]byte(test.overrides))
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestLoadOverridesMissingFile(t *testing.T) {
+	_, err := loadOverrides("/does/not/exist.json")
+	assert.Contains(t, err.Error(), "failed to read sampling overrides file")
+}
@@ -20,7 +20,9 @@ import (
 	"io"
 	"math"
 	"math/rand"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/uber/jaeger-lib/metrics"
@@ -28,6 +30,7 @@ import (
 
 	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/model"
 	ss "github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
+	"github.com/jaegertracing/jaeger/pkg/fswatcher"
 	"github.com/jaegertracing/jaeger/plugin/sampling/calculationstrategy"
 	"github.com/jaegertracing/jaeger/plugin/sampling/leaderelection"
 	"github.com/jaegertracing/jaeger/storage/samplingstore"
@@ -95,12 +98,19 @@ type processor struct {
 	// The latest throughput is stored at the head of the slice.
 	throughputs []*throughputBucket
 
+	// calculatedAt is the time of the latest calculation of the probabilities by this processor as leader,
+	// and loadedAt the time of the latest load of the probabilities from storage as follower.
+	calculatedAt time.Time
+	loadedAt     time.Time
+
 	// strategyResponses is the cache of the sampling strategies for every service, in Thrift format.
 	// TODO change this to work with protobuf model instead, to support gRPC endpoint.
 	strategyResponses map[string]*sampling.SamplingStrategyResponse
 
 	weightVectorCache *WeightVectorCache
 
+	// probabilityCalculator calculates the new probabilities, the calculators implementing
+	// calculationstrategy.OperationCalculator keep a state for every operation.
 	probabilityCalculator calculationstrategy.ProbabilityCalculator
 
 	// followerRefreshInterval determines how often the follower processor updates its probabilities.
@@ -110,6 +120,10 @@ type processor struct {
 
 	serviceCache []SamplingCache
 
+	// overrides holds the per-service and per-operation settings loaded from the OverridesFile.
+	overrides        atomic.Value // overrides
+	overridesWatcher *fswatcher.FileWatcher
+
 	shutdown chan struct{}
 
 	operationsCalculatedGauge     metrics.Gauge
@@ -131,8 +145,16 @@ func NewProcessor(
 	if opts.BucketsForCalculation < 1 {
 		return nil, errBucketsForCalculation
 	}
+	calculatorOptions := opts.Calculator
+	if calculatorOptions.PercentageIncreaseCap == 0 {
+		calculatorOptions.PercentageIncreaseCap = defaultPercentageIncreaseCap
+	}
+	probabilityCalculator, err := calculationstrategy.NewCalculator(calculatorOptions)
+	if err != nil {
+		return nil, err
+	}
 	metricsFactory = metricsFactory.Namespace(metrics.NSOptions{Name: "adaptive_sampling_processor"})
-	return &processor{
+	p := &processor{
 		Options:             opts,
 		storage:             storage,
 		probabilities:       make(model.ServiceOperationProbabilities),
@@ -141,14 +163,25 @@ func NewProcessor(
 		strategyResponses:   make(map[string]*sampling.SamplingStrategyResponse),
 		logger:              logger,
 		electionParticipant: electionParticipant,
-		// TODO make weightsCache and probabilityCalculator configurable
+		// TODO make weightsCache configurable
 		weightVectorCache:             NewWeightVectorCache(),
-		probabilityCalculator:         calculationstrategy.NewPercentageIncreaseCappedCalculator(1.0),
+		probabilityCalculator:         probabilityCalculator,
 		followerRefreshInterval:       defaultFollowerProbabilityInterval,
 		serviceCache:                  []SamplingCache{},
 		operationsCalculatedGauge:     metricsFactory.Gauge(metrics.Options{Name: "operations_calculated"}),
 		calculateProbabilitiesLatency: metricsFactory.Timer(metrics.TimerOptions{Name: "calculate_probabilities"}),
-	}, nil
+	}
+	if opts.OverridesFile != "" {
+		o, err := loadOverrides(opts.OverridesFile)
+		if err != nil {
+			return nil, err
+		}
+		p.overrides.Store(o)
+		if p.overridesWatcher, err = fswatcher.NewFileWatcher(opts.OverridesFile, p.reloadOverrides, logger, nil); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
 }
 
 // GetSamplingStrategy implements Thrift endpoint for retrieving sampling strategy for a service.
@@ -178,10 +211,32 @@ func (p *processor) Close() error {
 	if closer, ok := p.electionParticipant.(io.Closer); ok {
 		closer.Close()
 	}
+	if p.overridesWatcher != nil {
+		p.overridesWatcher.Close()
+	}
 	close(p.shutdown)
 	return nil
 }
 
+// getOverrides returns the per-service and per-operation settings, nil if there is no overrides file.
+func (p *processor) getOverrides() overrides {
+	o, _ := p.overrides.Load().(overrides)
+	return o
+}
+
+// reloadOverrides loads the overrides file after it changed and regenerates the strategies,
+// so that pinned probabilities are served without waiting for the next calculation.
+func (p *processor) reloadOverrides() {
+	o, err := loadOverrides(p.OverridesFile)
+	if err != nil {
+		p.logger.Error("failed to reload the sampling overrides file, keeping the previous overrides", zap.Error(err))
+		return
+	}
+	p.overrides.Store(o)
+	p.logger.Info("reloaded the sampling overrides file", zap.String("file", p.OverridesFile), zap.Int("services", len(o)))
+	p.generateStrategyResponses()
+}
+
 func (p *processor) loadProbabilities() {
 	// TODO GetLatestProbabilities API can be changed to return the latest measured qps for initialization
 	probabilities, err := p.storage.GetLatestProbabilities()
@@ -192,6 +247,7 @@ func (p *processor) loadProbabilities() {
 	p.Lock()
 	defer p.Unlock()
 	p.probabilities = probabilities
+	p.loadedAt = time.Now()
 }
 
 // runUpdateProbabilitiesLoop is a loop that reads probabilities from storage.
@@ -260,6 +316,7 @@ func (p *processor) runCalculationLoop() {
 				p.Lock()
 				p.probabilities = probabilities
 				p.qps = qps
+				p.calculatedAt = time.Now()
 				p.Unlock()
 				// NB: This has the potential of running into a race condition if the CalculationInterval
 				// is set to an extremely low value. The worst case scenario is that probabilities is calculated
@@ -285,7 +342,11 @@ func (p *processor) saveProbabilitiesAndQPS() {
 	}
 }
 
+// prependThroughputBucket adds the latest throughput bucket. The throughputs are only written by the
+// calculation loop, the lock protects them from concurrent reads by the introspection.
 func (p *processor) prependThroughputBucket(bucket *throughputBucket) {
+	p.Lock()
+	defer p.Unlock()
 	p.throughputs = append([]*throughputBucket{bucket}, p.throughputs...)
 	if len(p.throughputs) > p.AggregationBuckets {
 		p.throughputs = p.throughputs[0:p.AggregationBuckets]
@@ -325,11 +386,13 @@ func (p *processor) initializeThroughput(endTime time.Time) {
 			return
 		}
 		aggregatedThroughput := p.aggregateThroughput(throughput)
+		p.Lock()
 		p.throughputs = append(p.throughputs, &throughputBucket{
 			throughput: aggregatedThroughput,
 			interval:   p.CalculationInterval,
 			endTime:    endTime,
 		})
+		p.Unlock()
 		endTime = startTime
 	}
 }
@@ -402,6 +465,13 @@ func (p *processor) calculateProbabilitiesAndQPS() (model.ServiceOperationProbab
 			retProbabilities[svc][op] = p.calculateProbability(svc, op, avgQPS)
 		}
 	}
+	if c, ok := p.probabilityCalculator.(calculationstrategy.OperationCalculator); ok {
+		// forget the state of the operations without throughput in the buckets for calculation
+		c.Retain(func(service, operation string) bool {
+			_, ok := svcOpQPS[service][operation]
+			return ok
+		})
+	}
 	p.operationsCalculatedGauge.Update(totalOperations)
 	return retProbabilities, retQPS
 }
@@ -424,9 +494,13 @@ func (p *processor) calculateProbability(service, operation string, qps float64)
 		UsingAdaptive: usingAdaptiveSampling,
 	})
 
+	settings := p.getOverrides().settings(p.Options, service, operation)
+	if settings.pinned {
+		return settings.samplingProbability
+	}
 	// Short circuit if the qps is close enough to targetQPS or if the service doesn't appear to be using
 	// adaptive sampling.
-	if p.withinTolerance(qps, p.TargetSamplesPerSecond) || !usingAdaptiveSampling {
+	if p.withinTolerance(qps, settings.targetSamplesPerSecond) || !usingAdaptiveSampling {
 		return oldProbability
 	}
 	var newProbability float64
@@ -435,9 +509,17 @@ func (p *processor) calculateProbability(service, operation string, qps float64)
 		// to at least sample one span probabilistically.
 		newProbability = oldProbability * 2.0
 	} else {
-		newProbability = p.probabilityCalculator.Calculate(p.TargetSamplesPerSecond, qps, oldProbability)
+		newProbability = p.calculatorFor(service, operation).Calculate(settings.targetSamplesPerSecond, qps, oldProbability)
 	}
-	return math.Min(maxSamplingProbability, math.Max(p.MinSamplingProbability, newProbability))
+	return math.Min(settings.maxSamplingProbability, math.Max(settings.minSamplingProbability, newProbability))
+}
+
+// calculatorFor returns the calculator of the probability of an operation.
+func (p *processor) calculatorFor(service, operation string) calculationstrategy.ProbabilityCalculator {
+	if c, ok := p.probabilityCalculator.(calculationstrategy.OperationCalculator); ok {
+		return c.ForOperation(service, operation)
+	}
+	return p.probabilityCalculator
 }
 
 // is actual value within p.DeltaTolerance percentage of expected value.
@@ -482,13 +564,18 @@ func (p *processor) isUsingAdaptiveSampling(
 }
 
 // generateStrategyResponses generates and caches SamplingStrategyResponse from the calculated sampling probabilities.
+// The pinned probabilities of the overrides replace the calculated ones, including for operations not seen yet.
 func (p *processor) generateStrategyResponses() {
+	o := p.getOverrides()
 	p.RLock()
 	strategies := make(map[string]*sampling.SamplingStrategyResponse)
 	for svc, opProbabilities := range p.probabilities {
 		opStrategies := make([]*sampling.OperationSamplingStrategy, len(opProbabilities))
 		var idx int
 		for op, probability := range opProbabilities {
+			if settings := o.settings(p.Options, svc, op); settings.pinned {
+				probability = settings.samplingProbability
+			}
 			opStrategies[idx] = &sampling.OperationSamplingStrategy{
 				Operation: op,
 				ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{
@@ -503,6 +590,40 @@ func (p *processor) generateStrategyResponses() {
 	}
 	p.RUnlock()
 
+	for svc, override := range o {
+		strategy, ok := strategies[svc]
+		if !ok {
+			strategy = p.generateDefaultSamplingStrategyResponse()
+			strategies[svc] = strategy
+		}
+		if override.SamplingProbability != nil {
+			strategy.OperationSampling.DefaultSamplingProbability = *override.SamplingProbability
+		}
+		if override.minSamplesPerSecond != nil {
+			strategy.OperationSampling.DefaultLowerBoundTracesPerSecond = *override.minSamplesPerSecond
+		}
+		calculated := make(map[string]struct{}, len(strategy.OperationSampling.PerOperationStrategies))
+		for _, opStrategy := range strategy.OperationSampling.PerOperationStrategies {
+			calculated[opStrategy.Operation] = struct{}{}
+		}
+		var pinned []string
+		for op, opOverride := range override.operations {
+			if _, ok := calculated[op]; !ok && opOverride.SamplingProbability != nil {
+				pinned = append(pinned, op)
+			}
+		}
+		sort.Strings(pinned)
+		for _, op := range pinned {
+			strategy.OperationSampling.PerOperationStrategies = append(strategy.OperationSampling.PerOperationStrategies,
+				&sampling.OperationSamplingStrategy{
+					Operation: op,
+					ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{
+						SamplingRate: *override.operations[op].SamplingProbability,
+					},
+				})
+		}
+	}
+
 	p.Lock()
 	defer p.Unlock()
 	p.strategyResponses = strategies
@@ -18,6 +18,9 @@ import (
 	"context"
 	"errors"
 	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -283,6 +286,43 @@ func TestCalculateProbability(t *testing.T) {
 	}
 }
 
+func TestCalculateProbabilityWithOverrides(t *testing.T) {
+	o, err := parseOverrides([]byte(testOverrides))
+	require.NoError(t, err)
+	p := &processor{
+		Options: Options{
+			TargetSamplesPerSecond:     1.0,
+			DeltaTolerance:             0.2,
+			InitialSamplingProbability: 0.001,
+			MinSamplingProbability:     0.00001,
+		},
+		probabilities:         model.ServiceOperationProbabilities{},
+		probabilityCalculator: testCalculator,
+		throughputs:           []*throughputBucket{{throughput: serviceOperationThroughput{}}},
+		serviceCache:          []SamplingCache{{}},
+	}
+	p.overrides.Store(o)
+	tests := []struct {
+		service             string
+		operation           string
+		qps                 float64
+		expectedProbability float64
+		errMsg              string
+	}{
+		{"checkout", "GET", 9.0, 0.001, "qps within tolerance of the service target"},
+		{"checkout", "GET", 5.0, 0.002, "service target"},
+		{"checkout", "GET", 0.001, 0.5, "service max probability"},
+		{"checkout", "refund", 1000000000, 0.01, "operation min probability"},
+		{"checkout", "charge", 1000, 1.0, "pinned operation probability"},
+		{"batch", "run", 0.0, 0.0001, "pinned service probability"},
+		{"frontend", "GET", 2.0, 0.0005, "no override"},
+	}
+	for _, test := range tests {
+		probability := p.calculateProbability(test.service, test.operation, test.qps)
+		assert.Equal(t, test.expectedProbability, probability, test.errMsg)
+	}
+}
+
 func TestCalculateProbabilitiesAndQPS(t *testing.T) {
 	prevProbabilities := model.ServiceOperationProbabilities{
 		"svcB": map[string]float64{
@@ -537,6 +577,68 @@ func TestConstructorFailure(t *testing.T) {
 	cfg.BucketsForCalculation = -1
 	_, err = NewProcessor(cfg, "host", nil, nil, metrics.NullFactory, logger)
 	assert.EqualError(t, err, "BucketsForCalculation cannot be less than 1")
+
+	cfg.BucketsForCalculation = 1
+	cfg.Calculator.Kind = "pd"
+	_, err = NewProcessor(cfg, "host", nil, nil, metrics.NullFactory, logger)
+	assert.EqualError(t, err, `unknown probability calculator "pd", expected one of percentage-increase-capped, pid, proportional`)
+}
+
+func TestNewProcessorCalculator(t *testing.T) {
+	cfg := Options{CalculationInterval: time.Minute, AggregationBuckets: 1, BucketsForCalculation: 1}
+	p, err := NewProcessor(cfg, "host", nil, nil, metrics.NullFactory, zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, calculationstrategy.NewPercentageIncreaseCappedCalculator(1.0), p.(*processor).probabilityCalculator)
+
+	cfg.Calculator = calculationstrategy.Options{Kind: calculationstrategy.PIDKind}
+	p, err = NewProcessor(cfg, "host", nil, nil, metrics.NullFactory, zap.NewNop())
+	require.NoError(t, err)
+	assert.IsType(t, &calculationstrategy.PIDCalculator{}, p.(*processor).probabilityCalculator)
+}
+
+func TestCalculateProbabilitiesWithOperationCalculator(t *testing.T) {
+	calculator := calculationstrategy.NewPIDCalculator(calculationstrategy.PIDGains{Integral: 1})
+	p := &processor{
+		Options: Options{
+			TargetSamplesPerSecond:     1.0,
+			DeltaTolerance:             0.1,
+			InitialSamplingProbability: 0.001,
+			MinSamplingProbability:     0.00001,
+			BucketsForCalculation:      1,
+			AggregationBuckets:         10,
+		},
+		probabilities:             make(model.ServiceOperationProbabilities),
+		qps:                       make(model.ServiceOperationQPS),
+		weightVectorCache:         NewWeightVectorCache(),
+		probabilityCalculator:     calculator,
+		operationsCalculatedGauge: metrics.NullFactory.Gauge(metrics.Options{}),
+	}
+	p.prependThroughputBucket(&throughputBucket{
+		throughput: serviceOperationThroughput{
+			"svcA": map[string]*model.Throughput{
+				"GET": {Count: 240, Probabilities: map[string]struct{}{"0.001000": {}}},
+				"PUT": {Count: 15, Probabilities: map[string]struct{}{"0.001000": {}}},
+			},
+		},
+		interval: 60 * time.Second,
+	})
+	probabilities, _ := p.calculateProbabilitiesAndQPS()
+	assert.InDelta(t, 0.00025, probabilities["svcA"]["GET"], 1e-9)
+	assert.InDelta(t, 0.004, probabilities["svcA"]["PUT"], 1e-9)
+	get := calculator.ForOperation("svcA", "GET")
+
+	p.probabilities = probabilities
+	p.prependThroughputBucket(&throughputBucket{
+		throughput: serviceOperationThroughput{
+			"svcA": map[string]*model.Throughput{
+				"GET": {Count: 30, Probabilities: map[string]struct{}{"0.000250": {}}},
+			},
+		},
+		interval: 60 * time.Second,
+	})
+	probabilities, _ = p.calculateProbabilitiesAndQPS()
+	assert.InDelta(t, 0.0005, probabilities["svcA"]["GET"], 1e-9)
+	assert.Same(t, get, calculator.ForOperation("svcA", "GET"), "the controller of GET is kept")
 }
 
 func TestGenerateStrategyResponses(t *testing.T) {
@@ -573,6 +675,97 @@ func TestGenerateStrategyResponses(t *testing.T) {
 	assert.Equal(t, expectedResponse, p.strategyResponses)
 }
 
+func TestGenerateStrategyResponsesWithOverrides(t *testing.T) {
+	o, err := parseOverrides([]byte(testOverrides))
+	require.NoError(t, err)
+	p := &processor{
+		probabilities: model.ServiceOperationProbabilities{
+			"checkout": map[string]float64{
+				"charge": 0.2,
+			},
+		},
+		Options: Options{
+			InitialSamplingProbability: 0.001,
+			MinSamplesPerSecond:        0.0001,
+		}}
+	p.overrides.Store(o)
+	p.generateStrategyResponses()
+
+	expectedResponse := map[string]*sampling.SamplingStrategyResponse{
+		"checkout": {
+			StrategyType: sampling.SamplingStrategyType_PROBABILISTIC,
+			OperationSampling: &sampling.PerOperationSamplingStrategies{
+				DefaultSamplingProbability:       0.001,
+				DefaultLowerBoundTracesPerSecond: 0.1,
+				PerOperationStrategies: []*sampling.OperationSamplingStrategy{
+					{
+						Operation: "charge",
+						ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{
+							SamplingRate: 1,
+						},
+					},
+				},
+			},
+		},
+		"batch": {
+			StrategyType: sampling.SamplingStrategyType_PROBABILISTIC,
+			OperationSampling: &sampling.PerOperationSamplingStrategies{
+				DefaultSamplingProbability:       0.0001,
+				DefaultLowerBoundTracesPerSecond: 0.0001,
+			},
+		},
+	}
+	assert.Equal(t, expectedResponse, p.strategyResponses)
+
+	// pinned operations are served before they are seen
+	delete(p.probabilities, "checkout")
+	p.generateStrategyResponses()
+	assert.Equal(t, expectedResponse, p.strategyResponses)
+}
+
+func TestProcessorOverridesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overrides.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"services": [{"service": "checkout", "sampling_probability": 0.5}]}`), 0600))
+	cfg := Options{
+		CalculationInterval:        time.Minute,
+		AggregationBuckets:         1,
+		BucketsForCalculation:      1,
+		InitialSamplingProbability: 0.001,
+		OverridesFile:              path,
+	}
+	mockStorage := &smocks.Store{}
+	mockStorage.On("GetLatestProbabilities").Return(make(model.ServiceOperationProbabilities), nil)
+	mockStorage.On("GetThroughput", mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).
+		Return([]*model.Throughput{}, nil)
+	mockEP := &epmocks.ElectionParticipant{}
+	mockEP.On("IsLeader").Return(false)
+	mockEP.On("Close").Return(nil)
+	s, err := NewProcessor(cfg, "host", mockStorage, mockEP, metrics.NullFactory, zap.NewNop())
+	require.NoError(t, err)
+	p := s.(*processor)
+	require.NoError(t, p.Start())
+	defer p.Close()
+
+	probability := func() float64 {
+		strategy, err := p.GetSamplingStrategy(context.Background(), "checkout")
+		require.NoError(t, err)
+		return strategy.OperationSampling.DefaultSamplingProbability
+	}
+	assert.Equal(t, 0.5, probability())
+
+	// invalid overrides are ignored
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"services": [{"service": "checkout", "sampling_probability": 5}]}`), 0600))
+	require.NoError(t, ioutil.WriteFile(path+".tmp", []byte(`{"services": [{"service": "checkout", "sampling_probability": 1}]}`), 0600))
+	require.NoError(t, os.Rename(path+".tmp", path))
+	assert.Eventually(t, func() bool {
+		return probability() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cfg.OverridesFile = filepath.Join(t.TempDir(), "missing.json")
+	_, err = NewProcessor(cfg, "host", mockStorage, mockEP, metrics.NullFactory, zap.NewNop())
+	assert.Contains(t, err.Error(), "failed to read sampling overrides file")
+}
+
 func TestUsingAdaptiveSampling(t *testing.T) {
 	p := &processor{}
 	throughput := serviceOperationThroughput{
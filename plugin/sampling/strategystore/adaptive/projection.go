@@ -0,0 +1,90 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adaptive
+
+import (
+	"math"
+	"sort"
+
+	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
+)
+
+// Projection calculates the sampling strategies the adaptive sampling converges to for given QPS,
+// to preview the options without running the adaptive sampling processor.
+type Projection struct {
+	options   Options
+	overrides overrides
+}
+
+// NewProjection creates a Projection of the options, and of the overrides of their OverridesFile.
+func NewProjection(opts Options) (*Projection, error) {
+	p := &Projection{options: opts}
+	if opts.OverridesFile != "" {
+		o, err := loadOverrides(opts.OverridesFile)
+		if err != nil {
+			return nil, err
+		}
+		p.overrides = o
+	}
+	return p, nil
+}
+
+// Strategy returns the sampling strategy of a service once the probabilities of its operations,
+// whose QPS before sampling are given, have converged to the target samples per second.
+// The operations without QPS keep the initial sampling probability.
+func (p *Projection) Strategy(service string, qps map[string]float64) *sampling.SamplingStrategyResponse {
+	strategy := &sampling.SamplingStrategyResponse{
+		StrategyType: sampling.SamplingStrategyType_PROBABILISTIC,
+		OperationSampling: &sampling.PerOperationSamplingStrategies{
+			DefaultSamplingProbability:       p.options.InitialSamplingProbability,
+			DefaultLowerBoundTracesPerSecond: p.options.MinSamplesPerSecond,
+		},
+	}
+	if override, ok := p.overrides[service]; ok {
+		if override.SamplingProbability != nil {
+			strategy.OperationSampling.DefaultSamplingProbability = *override.SamplingProbability
+		}
+		if override.minSamplesPerSecond != nil {
+			strategy.OperationSampling.DefaultLowerBoundTracesPerSecond = *override.minSamplesPerSecond
+		}
+	}
+	operations := make([]string, 0, len(qps))
+	for op := range qps {
+		operations = append(operations, op)
+	}
+	sort.Strings(operations)
+	for _, op := range operations {
+		strategy.OperationSampling.PerOperationStrategies = append(strategy.OperationSampling.PerOperationStrategies,
+			&sampling.OperationSamplingStrategy{
+				Operation: op,
+				ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{
+					SamplingRate: p.probability(service, op, qps[op]),
+				},
+			})
+	}
+	return strategy
+}
+
+func (p *Projection) probability(service, operation string, qps float64) float64 {
+	settings := p.overrides.settings(p.options, service, operation)
+	if settings.pinned {
+		return settings.samplingProbability
+	}
+	if qps <= 0 {
+		return p.options.InitialSamplingProbability
+	}
+	probability := settings.targetSamplesPerSecond / qps
+	return math.Min(settings.maxSamplingProbability, math.Max(settings.minSamplingProbability, probability))
+}
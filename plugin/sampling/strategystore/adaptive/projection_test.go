@@ -0,0 +1,75 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adaptive
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
+)
+
+func TestProjection(t *testing.T) {
+	dir, err := ioutil.TempDir("", "projection")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	overridesFile := filepath.Join(dir, "overrides.json")
+	require.NoError(t, ioutil.WriteFile(overridesFile, []byte(testOverrides), 0600))
+
+	p, err := NewProjection(Options{
+		TargetSamplesPerSecond:     1,
+		InitialSamplingProbability: 0.001,
+		MinSamplingProbability:     0.0001,
+		MinSamplesPerSecond:        0.5,
+		OverridesFile:              overridesFile,
+	})
+	require.NoError(t, err)
+
+	strategy := p.Strategy("frontend", map[string]float64{"GET": 100, "PUT": 1000000, "HEAD": 0.5, "DELETE": 0})
+	assert.Equal(t, &sampling.PerOperationSamplingStrategies{
+		DefaultSamplingProbability:       0.001,
+		DefaultLowerBoundTracesPerSecond: 0.5,
+		PerOperationStrategies: []*sampling.OperationSamplingStrategy{
+			{Operation: "DELETE", ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: 0.001}},
+			{Operation: "GET", ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: 0.01}},
+			{Operation: "HEAD", ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: 1}},
+			{Operation: "PUT", ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: 0.0001}},
+		},
+	}, strategy.OperationSampling)
+	assert.Equal(t, sampling.SamplingStrategyType_PROBABILISTIC, strategy.StrategyType)
+
+	strategy = p.Strategy("checkout", map[string]float64{"GET": 10, "charge": 100, "refund": 10000})
+	assert.Equal(t, &sampling.PerOperationSamplingStrategies{
+		DefaultSamplingProbability:       0.001,
+		DefaultLowerBoundTracesPerSecond: 0.1,
+		PerOperationStrategies: []*sampling.OperationSamplingStrategy{
+			{Operation: "GET", ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: 0.5}},
+			{Operation: "charge", ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: 1}},
+			{Operation: "refund", ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: 0.01}},
+		},
+	}, strategy.OperationSampling)
+
+	strategy = p.Strategy("batch", nil)
+	assert.Equal(t, 0.0001, strategy.OperationSampling.DefaultSamplingProbability)
+	assert.Empty(t, strategy.OperationSampling.PerOperationStrategies)
+
+	_, err = NewProjection(Options{OverridesFile: filepath.Join(dir, "missing.json")})
+	assert.Contains(t, err.Error(), "failed to read sampling overrides file")
+}
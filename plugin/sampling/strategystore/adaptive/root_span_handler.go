@@ -23,7 +23,7 @@ import (
 
 // HandleRootSpan returns a function that records throughput for root spans
 func HandleRootSpan(aggregator Aggregator, logger *zap.Logger) app.ProcessSpan {
-	return func(span *model.Span) {
+	return func(span *model.Span, tenant string) {
 		// TODO simply checking parentId to determine if a span is a root span is not sufficient. However,
 		// we can be sure that only a root span will have sampler tags.
 		if span.ParentSpanID() != model.NewSpanID(0) {
@@ -0,0 +1,49 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adaptive
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app"
+	"github.com/jaegertracing/jaeger/model"
+)
+
+// strategyStore is the adaptive strategy store created by the Factory, it serves the probabilities
+// calculated by the processor from the throughput of the root spans recorded by the aggregator.
+type strategyStore struct {
+	*processor
+	aggregator     Aggregator
+	handleRootSpan app.ProcessSpan
+}
+
+func newStrategyStore(p *processor, aggregator Aggregator, logger *zap.Logger) *strategyStore {
+	return &strategyStore{
+		processor:      p,
+		aggregator:     aggregator,
+		handleRootSpan: HandleRootSpan(aggregator, logger),
+	}
+}
+
+// HandleRootSpan implements strategystore.Aggregator.
+func (s *strategyStore) HandleRootSpan(span *model.Span, tenant string) {
+	s.handleRootSpan(span, tenant)
+}
+
+// Close stops aggregating the throughput and calculating the probabilities.
+func (s *strategyStore) Close() error {
+	s.aggregator.Stop()
+	return s.processor.Close()
+}
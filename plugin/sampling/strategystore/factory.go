@@ -24,14 +24,19 @@ import (
 
 	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
 	"github.com/jaegertracing/jaeger/plugin"
+	"github.com/jaegertracing/jaeger/plugin/sampling/strategystore/adaptive"
+	"github.com/jaegertracing/jaeger/plugin/sampling/strategystore/hybrid"
 	"github.com/jaegertracing/jaeger/plugin/sampling/strategystore/static"
+	"github.com/jaegertracing/jaeger/storage"
 )
 
 const (
-	staticStrategyStoreType = "static"
+	staticStrategyStoreType   = "static"
+	adaptiveStrategyStoreType = "adaptive"
+	hybridStrategyStoreType   = "hybrid"
 )
 
-var allSamplingTypes = []string{staticStrategyStoreType} // TODO support adaptive
+var allSamplingTypes = []string{staticStrategyStoreType, adaptiveStrategyStoreType, hybridStrategyStoreType}
 
 // Factory implements strategystore.Factory interface as a meta-factory for strategy storage components.
 type Factory struct {
@@ -61,6 +66,10 @@ func (f *Factory) getFactoryOfType(factoryType string) (strategystore.Factory, e
 	switch factoryType {
 	case staticStrategyStoreType:
 		return static.NewFactory(), nil
+	case adaptiveStrategyStoreType:
+		return adaptive.NewFactory(), nil
+	case hybridStrategyStoreType:
+		return hybrid.NewFactory(), nil
 	default:
 		return nil, fmt.Errorf("unknown sampling strategy store type %s. Valid types are %v", factoryType, allSamplingTypes)
 	}
@@ -85,9 +94,9 @@ func (f *Factory) InitFromViper(v *viper.Viper, logger *zap.Logger) {
 }
 
 // Initialize implements strategystore.Factory
-func (f *Factory) Initialize(metricsFactory metrics.Factory, logger *zap.Logger) error {
+func (f *Factory) Initialize(metricsFactory metrics.Factory, ssFactory storage.SamplingStoreFactory, logger *zap.Logger) error {
 	for _, factory := range f.factories {
-		if err := factory.Initialize(metricsFactory, logger); err != nil {
+		if err := factory.Initialize(metricsFactory, ssFactory, logger); err != nil {
 			return err
 		}
 	}
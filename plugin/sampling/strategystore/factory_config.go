@@ -30,7 +30,8 @@ type FactoryConfig struct {
 
 // FactoryConfigFromEnv reads the desired sampling type from the SAMPLING_TYPE environment variable. Allowed values:
 //   * `static` - built-in
-//   * `adaptive` - built-in // TODO
+//   * `adaptive` - built-in, requires a storage backend supporting adaptive sampling
+//   * `hybrid` - built-in, static strategies layered over adaptive sampling
 func FactoryConfigFromEnv() FactoryConfig {
 	strategyStoreType := os.Getenv(SamplingTypeEnvVar)
 	if strategyStoreType == "" {
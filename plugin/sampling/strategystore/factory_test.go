@@ -28,6 +28,7 @@ import (
 
 	ss "github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
 	"github.com/jaegertracing/jaeger/plugin"
+	"github.com/jaegertracing/jaeger/storage"
 )
 
 var _ ss.Factory = new(Factory)
@@ -43,13 +44,13 @@ func TestNewFactory(t *testing.T) {
 	mock := new(mockFactory)
 	f.factories[staticStrategyStoreType] = mock
 
-	assert.NoError(t, f.Initialize(metrics.NullFactory, zap.NewNop()))
+	assert.NoError(t, f.Initialize(metrics.NullFactory, nil, zap.NewNop()))
 	_, err = f.CreateStrategyStore()
 	assert.NoError(t, err)
 
 	// force the mock to return errors
 	mock.retError = true
-	assert.EqualError(t, f.Initialize(metrics.NullFactory, zap.NewNop()), "error initializing store")
+	assert.EqualError(t, f.Initialize(metrics.NullFactory, nil, zap.NewNop()), "error initializing store")
 	_, err = f.CreateStrategyStore()
 	assert.EqualError(t, err, "error creating store")
 
@@ -57,6 +58,12 @@ func TestNewFactory(t *testing.T) {
 	_, err = f.CreateStrategyStore()
 	assert.EqualError(t, err, "no nonsense strategy store registered")
 
+	for _, strategyStoreType := range []string{adaptiveStrategyStoreType, hybridStrategyStoreType} {
+		f, err := NewFactory(FactoryConfig{StrategyStoreType: strategyStoreType})
+		require.NoError(t, err)
+		assert.NotEmpty(t, f.factories[strategyStoreType])
+	}
+
 	_, err = NewFactory(FactoryConfig{StrategyStoreType: "nonsense"})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "unknown sampling strategy store type")
@@ -107,7 +114,7 @@ func (f *mockFactory) CreateStrategyStore() (ss.StrategyStore, error) {
 	return nil, nil
 }
 
-func (f *mockFactory) Initialize(metricsFactory metrics.Factory, logger *zap.Logger) error {
+func (f *mockFactory) Initialize(metricsFactory metrics.Factory, ssFactory storage.SamplingStoreFactory, logger *zap.Logger) error {
 	if f.retError {
 		return errors.New("error initializing store")
 	}
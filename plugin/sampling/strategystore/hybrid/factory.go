@@ -0,0 +1,76 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hybrid
+
+import (
+	"flag"
+
+	"github.com/spf13/viper"
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
+	"github.com/jaegertracing/jaeger/plugin/sampling/strategystore/adaptive"
+	"github.com/jaegertracing/jaeger/plugin/sampling/strategystore/static"
+	"github.com/jaegertracing/jaeger/storage"
+)
+
+// Factory implements strategystore.Factory for a hybrid strategy store, combining the
+// static and adaptive strategy stores created by their own factories.
+type Factory struct {
+	static   *static.Factory
+	adaptive *adaptive.Factory
+}
+
+// NewFactory creates a new Factory.
+func NewFactory() *Factory {
+	return &Factory{
+		static:   static.NewFactory(),
+		adaptive: adaptive.NewFactory(),
+	}
+}
+
+// AddFlags implements plugin.Configurable
+func (f *Factory) AddFlags(flagSet *flag.FlagSet) {
+	f.static.AddFlags(flagSet)
+	f.adaptive.AddFlags(flagSet)
+}
+
+// InitFromViper implements plugin.Configurable
+func (f *Factory) InitFromViper(v *viper.Viper, logger *zap.Logger) {
+	f.static.InitFromViper(v, logger)
+	f.adaptive.InitFromViper(v, logger)
+}
+
+// Initialize implements strategystore.Factory
+func (f *Factory) Initialize(metricsFactory metrics.Factory, ssFactory storage.SamplingStoreFactory, logger *zap.Logger) error {
+	if err := f.static.Initialize(metricsFactory, ssFactory, logger); err != nil {
+		return err
+	}
+	return f.adaptive.Initialize(metricsFactory, ssFactory, logger)
+}
+
+// CreateStrategyStore implements strategystore.Factory
+func (f *Factory) CreateStrategyStore() (strategystore.StrategyStore, error) {
+	staticStore, err := f.static.CreateStrategyStore()
+	if err != nil {
+		return nil, err
+	}
+	adaptiveStore, err := f.adaptive.CreateStrategyStore()
+	if err != nil {
+		return nil, err
+	}
+	return NewStrategyStore(staticStore, adaptiveStore)
+}
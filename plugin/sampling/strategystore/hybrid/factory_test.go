@@ -0,0 +1,108 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hybrid
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/model"
+	ss "github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
+	"github.com/jaegertracing/jaeger/pkg/config"
+	"github.com/jaegertracing/jaeger/pkg/distributedlock"
+	lmocks "github.com/jaegertracing/jaeger/pkg/distributedlock/mocks"
+	"github.com/jaegertracing/jaeger/plugin"
+	"github.com/jaegertracing/jaeger/plugin/sampling/strategystore/adaptive"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
+	smocks "github.com/jaegertracing/jaeger/storage/samplingstore/mocks"
+	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
+)
+
+var _ ss.Factory = new(Factory)
+var _ plugin.Configurable = new(Factory)
+
+func TestFactory(t *testing.T) {
+	f := NewFactory()
+	v, command := config.Viperize(f.AddFlags)
+	command.ParseFlags([]string{
+		"--sampling.strategies-file=fixtures/strategies.json",
+		"--sampling.target-samples-per-second=5",
+	})
+	f.InitFromViper(v, zap.NewNop())
+	require.NoError(t, f.Initialize(metrics.NullFactory, newSamplingStoreFactory(), zap.NewNop()))
+
+	store, err := f.CreateStrategyStore()
+	require.NoError(t, err)
+	defer store.(io.Closer).Close()
+
+	strategy, err := store.GetSamplingStrategy(context.Background(), "checkout")
+	require.NoError(t, err)
+	assert.Equal(t, 0.8, strategy.OperationSampling.DefaultSamplingProbability)
+	strategy, err = store.GetSamplingStrategy(context.Background(), "batch")
+	require.NoError(t, err)
+	assert.Equal(t, sampling.SamplingStrategyType_RATE_LIMITING, strategy.StrategyType)
+	assert.Implements(t, (*ss.Aggregator)(nil), store)
+	assert.Implements(t, (*adaptive.Introspector)(nil), store)
+}
+
+func TestFactoryNoSamplingStore(t *testing.T) {
+	f := NewFactory()
+	v, command := config.Viperize(f.AddFlags)
+	command.ParseFlags([]string{"--sampling.strategies-file=fixtures/strategies.json"})
+	f.InitFromViper(v, zap.NewNop())
+	assert.Error(t, f.Initialize(metrics.NullFactory, nil, zap.NewNop()))
+}
+
+type samplingStoreFactory struct {
+	store samplingstore.Store
+	lock  distributedlock.Lock
+}
+
+func (f *samplingStoreFactory) CreateSamplingStore() (samplingstore.Store, error) {
+	return f.store, nil
+}
+
+func (f *samplingStoreFactory) CreateLock() (distributedlock.Lock, error) {
+	return f.lock, nil
+}
+
+func newSamplingStoreFactory() *samplingStoreFactory {
+	store := &smocks.Store{}
+	store.On("GetLatestProbabilities").Return(make(model.ServiceOperationProbabilities), nil)
+	store.On("GetThroughput", mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).
+		Return([]*model.Throughput{}, nil)
+	store.On("InsertThroughput", mock.Anything).Return(nil)
+	lock := &lmocks.Lock{}
+	lock.On("Acquire", mock.Anything, mock.Anything).Return(false, nil)
+	lock.On("Forfeit", mock.Anything).Return(true, nil)
+	return &samplingStoreFactory{store: store, lock: lock}
+}
+
+func TestFactoryStaticError(t *testing.T) {
+	f := NewFactory()
+	v, command := config.Viperize(f.AddFlags)
+	command.ParseFlags([]string{"--sampling.strategies-file=fixtures/missing.json"})
+	f.InitFromViper(v, zap.NewNop())
+	assert.NoError(t, f.Initialize(metrics.NullFactory, newSamplingStoreFactory(), zap.NewNop()))
+	_, err := f.CreateStrategyStore()
+	assert.Contains(t, err.Error(), "failed to read strategies file")
+}
@@ -0,0 +1,193 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hybrid
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+
+	ss "github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/plugin/sampling/strategystore/adaptive"
+	"github.com/jaegertracing/jaeger/plugin/sampling/strategystore/static"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
+	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
+)
+
+const (
+	// SourceStatic is the source of the strategies listed in the static strategies file.
+	SourceStatic = "static"
+	// SourceAdaptive is the source of the strategies calculated by adaptive sampling.
+	SourceAdaptive = "adaptive"
+)
+
+var (
+	errNotStatic  = errors.New("the static strategy store does not support looking up listed services")
+	errNotManaged = errors.New("the static strategy store does not support managing strategies at runtime")
+)
+
+// staticLookup is implemented by the static strategy store.
+type staticLookup interface {
+	Lookup(ctx context.Context, serviceName string) (strategy *sampling.SamplingStrategyResponse, listed bool)
+	LookupOperation(ctx context.Context, serviceName, operation string) (*sampling.OperationSamplingStrategy, bool)
+}
+
+// strategyStore layers the strategies of a static strategy store over the strategies of
+// an adaptive strategy store. For every service:
+//   * a service listed in the static strategies file with a ratelimiting strategy is forced
+//     to this strategy, the adaptive strategy is ignored;
+//   * a service listed with a probabilistic strategy gets its default sampling probability
+//     from the static strategy;
+//   * the operations listed for the service, or in the default strategy of the static file,
+//     get their static strategy, as well as the operations matching their operation patterns;
+//   * everything else comes from adaptive sampling.
+type strategyStore struct {
+	static   staticLookup
+	adaptive ss.StrategyStore
+}
+
+// NewStrategyStore creates a strategy store combining a static strategy store, as created by
+// static.NewStrategyStore, with an adaptive strategy store.
+func NewStrategyStore(static, adaptive ss.StrategyStore) (ss.StrategyStore, error) {
+	lookup, ok := static.(staticLookup)
+	if !ok {
+		return nil, errNotStatic
+	}
+	return &strategyStore{static: lookup, adaptive: adaptive}, nil
+}
+
+// GetSamplingStrategy implements StrategyStore#GetSamplingStrategy.
+func (s *strategyStore) GetSamplingStrategy(ctx context.Context, serviceName string) (*sampling.SamplingStrategyResponse, error) {
+	strategy, _, err := s.GetSamplingStrategyWithSources(ctx, serviceName)
+	return strategy, err
+}
+
+// GetSamplingStrategyWithSources implements StrategySourcesStore#GetSamplingStrategyWithSources.
+func (s *strategyStore) GetSamplingStrategyWithSources(ctx context.Context, serviceName string) (*sampling.SamplingStrategyResponse, *ss.StrategySources, error) {
+	static, listed := s.static.Lookup(ctx, serviceName)
+	if listed && static.StrategyType == sampling.SamplingStrategyType_RATE_LIMITING {
+		return static, &ss.StrategySources{Default: SourceStatic}, nil
+	}
+	calculated, err := s.adaptive.GetSamplingStrategy(ctx, serviceName)
+	if err != nil {
+		return nil, nil, err
+	}
+	strategy, sources := s.merge(ctx, serviceName, static, listed, calculated)
+	return strategy, sources, nil
+}
+
+// Introspect implements adaptive.Introspector, the adaptive strategies are introspected.
+func (s *strategyStore) Introspect(service string) (*adaptive.Introspection, error) {
+	introspector, ok := s.adaptive.(adaptive.Introspector)
+	if !ok {
+		return nil, adaptive.ErrNoIntrospection
+	}
+	return introspector.Introspect(service)
+}
+
+// HandleRootSpan implements strategystore.Aggregator, the throughput is aggregated by the adaptive strategy store.
+func (s *strategyStore) HandleRootSpan(span *model.Span, tenant string) {
+	if aggregator, ok := s.adaptive.(ss.Aggregator); ok {
+		aggregator.HandleRootSpan(span, tenant)
+	}
+}
+
+// Close implements io.Closer, the adaptive strategy store stops calculating the probabilities.
+func (s *strategyStore) Close() error {
+	if closer, ok := s.adaptive.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// ManagementEnabled implements static.Manager.
+func (s *strategyStore) ManagementEnabled() bool {
+	manager, ok := s.static.(static.Manager)
+	return ok && manager.ManagementEnabled()
+}
+
+// Manage implements static.Manager, the static strategies are managed.
+func (s *strategyStore) Manage(store samplingstore.StrategiesStore) (http.Handler, error) {
+	manager, ok := s.static.(static.Manager)
+	if !ok {
+		return nil, errNotManaged
+	}
+	return manager.Manage(store)
+}
+
+// merge returns a new response rather than modifying the responses of the stores, which are shared.
+func (s *strategyStore) merge(
+	ctx context.Context,
+	serviceName string,
+	static *sampling.SamplingStrategyResponse,
+	listed bool,
+	calculated *sampling.SamplingStrategyResponse,
+) (*sampling.SamplingStrategyResponse, *ss.StrategySources) {
+	sources := &ss.StrategySources{Default: SourceAdaptive, Operations: make(map[string]string)}
+	operationSampling := &sampling.PerOperationSamplingStrategies{}
+	if calculated.OperationSampling != nil {
+		*operationSampling = *calculated.OperationSampling
+	} else if calculated.ProbabilisticSampling != nil {
+		operationSampling.DefaultSamplingProbability = calculated.ProbabilisticSampling.SamplingRate
+	}
+	if listed {
+		sources.Default = SourceStatic
+		if static.ProbabilisticSampling != nil {
+			operationSampling.DefaultSamplingProbability = static.ProbabilisticSampling.SamplingRate
+		} else if static.OperationSampling != nil {
+			operationSampling.DefaultSamplingProbability = static.OperationSampling.DefaultSamplingProbability
+		}
+	}
+
+	operations := make(map[string]*sampling.OperationSamplingStrategy)
+	if calculated.OperationSampling != nil {
+		for _, op := range calculated.OperationSampling.PerOperationStrategies {
+			if staticOp, ok := s.static.LookupOperation(ctx, serviceName, op.Operation); ok {
+				operations[op.Operation] = staticOp
+				sources.Operations[op.Operation] = SourceStatic
+				continue
+			}
+			operations[op.Operation] = op
+			sources.Operations[op.Operation] = SourceAdaptive
+		}
+	}
+	// the operations of a listed service already include the operations of the default strategy
+	if static.OperationSampling != nil {
+		for _, op := range static.OperationSampling.PerOperationStrategies {
+			operations[op.Operation] = op
+			sources.Operations[op.Operation] = SourceStatic
+		}
+	}
+	names := make([]string, 0, len(operations))
+	for name := range operations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	operationSampling.PerOperationStrategies = make([]*sampling.OperationSamplingStrategy, len(names))
+	for i, name := range names {
+		operationSampling.PerOperationStrategies[i] = operations[name]
+	}
+
+	return &sampling.SamplingStrategyResponse{
+		StrategyType: sampling.SamplingStrategyType_PROBABILISTIC,
+		ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{
+			SamplingRate: operationSampling.DefaultSamplingProbability,
+		},
+		OperationSampling: operationSampling,
+	}, sources
+}
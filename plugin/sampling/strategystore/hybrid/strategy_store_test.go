@@ -0,0 +1,241 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hybrid
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	ss "github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
+	"github.com/jaegertracing/jaeger/plugin/sampling/strategystore/adaptive"
+	"github.com/jaegertracing/jaeger/plugin/sampling/strategystore/static"
+	"github.com/jaegertracing/jaeger/plugin/storage/memory"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
+	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
+)
+
+type mockAdaptiveStore struct {
+	strategies map[string]*sampling.SamplingStrategyResponse
+	err        error
+}
+
+func (m *mockAdaptiveStore) GetSamplingStrategy(_ context.Context, serviceName string) (*sampling.SamplingStrategyResponse, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if strategy, ok := m.strategies[serviceName]; ok {
+		return strategy, nil
+	}
+	return adaptiveResponse(0.001), nil
+}
+
+func adaptiveResponse(defaultProbability float64, operations ...*sampling.OperationSamplingStrategy) *sampling.SamplingStrategyResponse {
+	return &sampling.SamplingStrategyResponse{
+		StrategyType: sampling.SamplingStrategyType_PROBABILISTIC,
+		OperationSampling: &sampling.PerOperationSamplingStrategies{
+			DefaultSamplingProbability:       defaultProbability,
+			DefaultLowerBoundTracesPerSecond: 0.0166,
+			PerOperationStrategies:           operations,
+		},
+	}
+}
+
+func operation(name string, probability float64) *sampling.OperationSamplingStrategy {
+	return &sampling.OperationSamplingStrategy{
+		Operation:             name,
+		ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: probability},
+	}
+}
+
+func probabilistic(defaultProbability float64, operations ...*sampling.OperationSamplingStrategy) *sampling.SamplingStrategyResponse {
+	return &sampling.SamplingStrategyResponse{
+		StrategyType:          sampling.SamplingStrategyType_PROBABILISTIC,
+		ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: defaultProbability},
+		OperationSampling: &sampling.PerOperationSamplingStrategies{
+			DefaultSamplingProbability:       defaultProbability,
+			DefaultLowerBoundTracesPerSecond: 0.0166,
+			PerOperationStrategies:           operations,
+		},
+	}
+}
+
+type mockIntrospectorStore struct {
+	mockAdaptiveStore
+}
+
+func (m *mockIntrospectorStore) Introspect(service string) (*adaptive.Introspection, error) {
+	return &adaptive.Introspection{Services: []adaptive.ServiceIntrospection{{Service: service}}}, nil
+}
+
+func newTestStore(t *testing.T, adaptive ss.StrategyStore) ss.StrategySourcesStore {
+	staticStore, err := static.NewStrategyStore(static.Options{StrategiesFile: "fixtures/strategies.json"}, zap.NewNop())
+	require.NoError(t, err)
+	store, err := NewStrategyStore(staticStore, adaptive)
+	require.NoError(t, err)
+	return store.(ss.StrategySourcesStore)
+}
+
+func TestGetSamplingStrategy(t *testing.T) {
+	store := newTestStore(t, &mockAdaptiveStore{strategies: map[string]*sampling.SamplingStrategyResponse{
+		"checkout": adaptiveResponse(0.001, operation("list", 0.01), operation("charge", 0.02)),
+		"frontend": adaptiveResponse(0.001, operation("GET", 0.1), operation("/health", 0.5)),
+		"batch":    adaptiveResponse(0.001, operation("run", 0.1)),
+		"payments-eu": adaptiveResponse(0.001,
+			operation("pay", 0.1), operation("refund-full", 0.01), operation("GET /health/live", 0.5)),
+	}})
+
+	tests := []struct {
+		service  string
+		strategy *sampling.SamplingStrategyResponse
+		sources  *ss.StrategySources
+	}{
+		{
+			service:  "frontend",
+			strategy: probabilistic(0.001, operation("/health", 0), operation("GET", 0.1)),
+			sources: &ss.StrategySources{
+				Default:    SourceAdaptive,
+				Operations: map[string]string{"/health": SourceStatic, "GET": SourceAdaptive},
+			},
+		},
+		{
+			service:  "checkout",
+			strategy: probabilistic(0.8, operation("/health", 0), operation("charge", 1), operation("list", 0.01)),
+			sources: &ss.StrategySources{
+				Default:    SourceStatic,
+				Operations: map[string]string{"/health": SourceStatic, "charge": SourceStatic, "list": SourceAdaptive},
+			},
+		},
+		{
+			service: "batch",
+			strategy: &sampling.SamplingStrategyResponse{
+				StrategyType:         sampling.SamplingStrategyType_RATE_LIMITING,
+				RateLimitingSampling: &sampling.RateLimitingSamplingStrategy{MaxTracesPerSecond: 5},
+			},
+			sources: &ss.StrategySources{Default: SourceStatic},
+		},
+		{
+			service: "payments-eu",
+			strategy: probabilistic(0.2,
+				operation("/health", 0), operation("GET /health/live", 0), operation("pay", 0.1), operation("refund-full", 1)),
+			sources: &ss.StrategySources{
+				Default: SourceStatic,
+				Operations: map[string]string{
+					"/health": SourceStatic, "GET /health/live": SourceStatic, "pay": SourceAdaptive, "refund-full": SourceStatic,
+				},
+			},
+		},
+		{
+			service:  "unknown",
+			strategy: probabilistic(0.001, operation("/health", 0)),
+			sources: &ss.StrategySources{
+				Default:    SourceAdaptive,
+				Operations: map[string]string{"/health": SourceStatic},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.service, func(t *testing.T) {
+			strategy, sources, err := store.GetSamplingStrategyWithSources(context.Background(), test.service)
+			require.NoError(t, err)
+			assert.Equal(t, test.strategy, strategy)
+			assert.Equal(t, test.sources, sources)
+
+			strategy, err = store.(ss.StrategyStore).GetSamplingStrategy(context.Background(), test.service)
+			require.NoError(t, err)
+			assert.Equal(t, test.strategy, strategy)
+		})
+	}
+}
+
+func TestGetSamplingStrategyClientAttributes(t *testing.T) {
+	store := newTestStore(t, &mockAdaptiveStore{})
+	ctx := ss.ContextWithClientAttributes(context.Background(), &ss.ClientAttributes{Environment: "canary"})
+	strategy, sources, err := store.GetSamplingStrategyWithSources(ctx, "checkout")
+	require.NoError(t, err)
+	assert.Equal(t, &sampling.SamplingStrategyResponse{
+		StrategyType:         sampling.SamplingStrategyType_RATE_LIMITING,
+		RateLimitingSampling: &sampling.RateLimitingSamplingStrategy{MaxTracesPerSecond: 2},
+	}, strategy)
+	assert.Equal(t, &ss.StrategySources{Default: SourceStatic}, sources)
+}
+
+func TestGetSamplingStrategyDoesNotModifyAdaptiveStrategies(t *testing.T) {
+	adaptive := adaptiveResponse(0.001, operation("GET", 0.1))
+	store := newTestStore(t, &mockAdaptiveStore{strategies: map[string]*sampling.SamplingStrategyResponse{"checkout": adaptive}})
+	_, err := store.(ss.StrategyStore).GetSamplingStrategy(context.Background(), "checkout")
+	require.NoError(t, err)
+	assert.Equal(t, adaptiveResponse(0.001, operation("GET", 0.1)), adaptive)
+}
+
+func TestGetSamplingStrategyAdaptiveError(t *testing.T) {
+	store := newTestStore(t, &mockAdaptiveStore{err: errors.New("adaptive error")})
+	_, err := store.(ss.StrategyStore).GetSamplingStrategy(context.Background(), "frontend")
+	assert.EqualError(t, err, "adaptive error")
+}
+
+func TestNewStrategyStoreNotStatic(t *testing.T) {
+	_, err := NewStrategyStore(&mockAdaptiveStore{}, &mockAdaptiveStore{})
+	assert.Equal(t, errNotStatic, err)
+}
+
+func TestIntrospect(t *testing.T) {
+	store := newTestStore(t, &mockIntrospectorStore{})
+	introspection, err := store.(adaptive.Introspector).Introspect("checkout")
+	require.NoError(t, err)
+	assert.Equal(t, []adaptive.ServiceIntrospection{{Service: "checkout"}}, introspection.Services)
+
+	store = newTestStore(t, &mockAdaptiveStore{})
+	_, err = store.(adaptive.Introspector).Introspect("checkout")
+	assert.Equal(t, adaptive.ErrNoIntrospection, err)
+}
+
+type lookupOnlyStore struct {
+	staticLookup
+}
+
+func TestManage(t *testing.T) {
+	staticStore, err := static.NewStrategyStore(static.Options{
+		StrategiesFile:    "fixtures/strategies.json",
+		ManagementEnabled: true,
+	}, zap.NewNop())
+	require.NoError(t, err)
+	store, err := NewStrategyStore(staticStore, &mockAdaptiveStore{})
+	require.NoError(t, err)
+	manager := store.(static.Manager)
+	assert.True(t, manager.ManagementEnabled())
+
+	strategies := memory.NewStrategiesStore()
+	require.NoError(t, strategies.PutStrategy(&samplingstore.StrategyChange{
+		Service: "checkout", Version: 1, After: []byte(`{"type": "ratelimiting", "param": 3}`),
+	}))
+	handler, err := manager.Manage(strategies)
+	require.NoError(t, err)
+	assert.Nil(t, handler)
+	strategy, sources, err := store.(ss.StrategySourcesStore).GetSamplingStrategyWithSources(context.Background(), "checkout")
+	require.NoError(t, err)
+	assert.Equal(t, sampling.SamplingStrategyType_RATE_LIMITING, strategy.StrategyType)
+	assert.Equal(t, SourceStatic, sources.Default)
+
+	store = &strategyStore{static: lookupOnlyStore{}}
+	manager = store.(static.Manager)
+	assert.False(t, manager.ManagementEnabled())
+	_, err = manager.Manage(strategies)
+	assert.Equal(t, errNotManaged, err)
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package static
+
+import (
+	"fmt"
+
+	ss "github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
+)
+
+// clientRule holds the strategy of the clients of a service, or of any service, matching client attributes.
+type clientRule struct {
+	matcher *clientMatcher
+	*serviceRule
+}
+
+// clientMatcher matches the client attributes against the conditions of a client strategy,
+// the conditions which are not set are nil.
+type clientMatcher struct {
+	environment *valueMatcher
+	hostname    *valueMatcher
+	sdkVersion  *valueMatcher
+	tags        map[string]*valueMatcher
+}
+
+// valueMatcher matches an attribute against a name, or a pattern.
+type valueMatcher struct {
+	value   string
+	pattern *pattern
+}
+
+// isEmpty returns true if no condition is set, the client strategy matches all the clients.
+func (a *clientAttributes) isEmpty() bool {
+	return a.Environment == "" && a.Hostname == "" && a.SDKVersion == "" && len(a.Tags) == 0
+}
+
+func parseClientMatcher(attributes clientAttributes) (*clientMatcher, error) {
+	var err error
+	m := &clientMatcher{}
+	if m.environment, err = parseValueMatcher(attributes.Environment); err != nil {
+		return nil, err
+	}
+	if m.hostname, err = parseValueMatcher(attributes.Hostname); err != nil {
+		return nil, err
+	}
+	if m.sdkVersion, err = parseValueMatcher(attributes.SDKVersion); err != nil {
+		return nil, err
+	}
+	for key, value := range attributes.Tags {
+		// unlike the other attributes, a tag listed in the conditions is set
+		if value == "" {
+			return nil, fmt.Errorf("tag %q: the condition must not be empty", key)
+		}
+		tag, err := parseValueMatcher(value)
+		if err != nil {
+			return nil, fmt.Errorf("tag %q: %w", key, err)
+		}
+		if m.tags == nil {
+			m.tags = make(map[string]*valueMatcher)
+		}
+		m.tags[key] = tag
+	}
+	return m, nil
+}
+
+func parseValueMatcher(value string) (*valueMatcher, error) {
+	if value == "" {
+		return nil, nil
+	}
+	p, err := parsePattern(value, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &valueMatcher{value: value, pattern: p}, nil
+}
+
+func (m *clientMatcher) match(attributes *ss.ClientAttributes) bool {
+	if attributes == nil {
+		attributes = &ss.ClientAttributes{}
+	}
+	if !m.environment.match(attributes.Environment) ||
+		!m.hostname.match(attributes.Hostname) ||
+		!m.sdkVersion.match(attributes.SDKVersion) {
+		return false
+	}
+	for key, tag := range m.tags {
+		if !tag.match(attributes.Tags[key]) {
+			return false
+		}
+	}
+	return true
+}
+
+// match returns true if there is no condition, or if the attribute is known and matches it.
+func (m *valueMatcher) match(value string) bool {
+	if m == nil {
+		return true
+	}
+	if value == "" {
+		return false
+	}
+	if m.pattern != nil {
+		return m.pattern.match(value)
+	}
+	return value == m.value
+}
@@ -45,8 +45,8 @@ func defaultStrategyResponse() *sampling.SamplingStrategyResponse {
 
 func defaultStrategies() *storedStrategies {
 	s := &storedStrategies{
-		serviceStrategies: make(map[string]*sampling.SamplingStrategyResponse),
+		serviceStrategies: make(map[string]*serviceRule),
 	}
-	s.defaultStrategy = defaultStrategyResponse()
+	s.defaultStrategy = &serviceRule{strategy: defaultStrategyResponse()}
 	return s
 }
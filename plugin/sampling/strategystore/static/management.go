@@ -0,0 +1,221 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package static
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app/auth"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
+)
+
+// Manager is implemented by the strategy stores serving the sampling strategies managed at runtime.
+type Manager interface {
+	// ManagementEnabled returns true if the strategies managed at runtime are served.
+	ManagementEnabled() bool
+	// Manage loads the strategies managed at runtime from the store, and reloads them periodically.
+	// It returns the handler of the management API, or nil if the API is not served.
+	Manage(store samplingstore.StrategiesStore) (http.Handler, error)
+}
+
+// ManagementEnabled implements Manager.
+func (h *strategyStore) ManagementEnabled() bool {
+	return h.options.ManagementEnabled
+}
+
+// Manage implements Manager. The API is authenticated with the API keys of the options.
+func (h *strategyStore) Manage(store samplingstore.StrategiesStore) (http.Handler, error) {
+	if err := h.reloadManagedStrategies(store); err != nil {
+		return nil, err
+	}
+	if h.options.ManagementRefreshInterval > 0 {
+		go h.autoReloadManagedStrategies(store, h.options.ManagementRefreshInterval)
+	}
+	if h.options.ManagementAPIKeysFile == "" {
+		return nil, nil
+	}
+	authManager, err := auth.NewManager(auth.Options{APIKeysFile: h.options.ManagementAPIKeysFile})
+	if err != nil {
+		return nil, fmt.Errorf("cannot create the authenticator of the sampling strategies management API: %w", err)
+	}
+	return auth.NewHTTPHandler(authManager, &managementHandler{
+		logger: h.logger,
+		store:  store,
+		reload: func() error {
+			return h.reloadManagedStrategies(store)
+		},
+	}), nil
+}
+
+func (h *strategyStore) autoReloadManagedStrategies(store samplingstore.StrategiesStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := h.reloadManagedStrategies(store); err != nil {
+				h.logger.Error("failed to reload the managed sampling strategies", zap.Error(err))
+			}
+		case <-h.ctx.Done():
+			return
+		}
+	}
+}
+
+// reloadManagedStrategies loads the managed strategies from the store and, if they changed,
+// parses them with the strategies of the file. Invalid managed strategies are ignored.
+func (h *strategyStore) reloadManagedStrategies(store samplingstore.StrategiesStore) error {
+	stored, err := store.GetStrategies()
+	if err != nil {
+		return fmt.Errorf("failed to load the managed sampling strategies: %w", err)
+	}
+	// all collectors must list the strategies in the same order, which gives the precedence
+	// of the service patterns of the same length
+	sort.Slice(stored, func(i, j int) bool {
+		return stored[i].Service < stored[j].Service
+	})
+	var versions strings.Builder
+	managed := make([]*serviceStrategy, 0, len(stored))
+	for _, s := range stored {
+		fmt.Fprintf(&versions, "%q:%d,", s.Service, s.Version)
+		strategy, _, err := parseManagedStrategy(s.Service, s.Document)
+		if err != nil {
+			h.logger.Error("ignoring invalid managed sampling strategy", zap.String("service", s.Service), zap.Error(err))
+			continue
+		}
+		managed = append(managed, strategy)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if versions.String() == h.managedVersions {
+		return nil
+	}
+	if err := h.parseStrategies(combineStrategies(h.fileStrategies, managed)); err != nil {
+		return err
+	}
+	h.managedStrategies = managed
+	h.managedVersions = versions.String()
+	h.logger.Info("Updated managed sampling strategies", zap.Int("services", len(managed)))
+	return nil
+}
+
+// combineStrategies layers the managed strategies over the strategies of the file: a managed strategy
+// replaces the strategy of the file for the same service name or pattern, and is listed after the
+// strategies of the file.
+func combineStrategies(file *strategies, managed []*serviceStrategy) *strategies {
+	if len(managed) == 0 {
+		return file
+	}
+	replaced := make(map[string]bool, len(managed))
+	for _, s := range managed {
+		replaced[s.Service] = true
+	}
+	combined := &strategies{}
+	if file != nil {
+		combined.DefaultStrategy = file.DefaultStrategy
+		for _, s := range file.ServiceStrategies {
+			if !replaced[s.Service] {
+				combined.ServiceStrategies = append(combined.ServiceStrategies, s)
+			}
+		}
+	}
+	combined.ServiceStrategies = append(combined.ServiceStrategies, managed...)
+	return combined
+}
+
+// parseManagedStrategy validates a managed strategy against the schema of the service strategies of
+// the strategies file, where the service may be omitted. It also returns the operation rules which are
+// never used or ambiguous, which are accepted like in the strategies file.
+func parseManagedStrategy(service string, document []byte) (*serviceStrategy, []string, error) {
+	var strategy serviceStrategy
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&strategy); err != nil {
+		return nil, nil, fmt.Errorf("invalid sampling strategy: %w", err)
+	}
+	if strategy.Service == "" {
+		strategy.Service = service
+	}
+	if strategy.Service != service {
+		return nil, nil, fmt.Errorf("the sampling strategy is for service %q rather than %q", strategy.Service, service)
+	}
+	if _, err := parsePattern(service, 0); err != nil {
+		return nil, nil, err
+	}
+	if err := validateStrategy(&strategy.strategy); err != nil {
+		return nil, nil, err
+	}
+	if err := validateOperationStrategies(strategy.OperationStrategies); err != nil {
+		return nil, nil, err
+	}
+	for i, client := range strategy.ClientStrategies {
+		if client == nil {
+			return nil, nil, errors.New("client strategies must not be null")
+		}
+		if _, err := parseClientMatcher(client.Match); err != nil {
+			return nil, nil, fmt.Errorf("client strategy %d: %w", i+1, err)
+		}
+		if err := validateStrategy(&client.strategy); err != nil {
+			return nil, nil, fmt.Errorf("client strategy %d: %w", i+1, err)
+		}
+		if err := validateOperationStrategies(client.OperationStrategies); err != nil {
+			return nil, nil, fmt.Errorf("client strategy %d: %w", i+1, err)
+		}
+	}
+	return &strategy, validateServiceStrategy(&strategy, fmt.Sprintf(" of service %q", service)), nil
+}
+
+func validateOperationStrategies(operations []*operationStrategy) error {
+	for _, op := range operations {
+		if op == nil || op.Operation == "" {
+			return errors.New("operation strategies must have an operation")
+		}
+		if _, err := parsePattern(op.Operation, 0); err != nil {
+			return err
+		}
+		if err := validateStrategy(&op.strategy); err != nil {
+			return fmt.Errorf("operation %q: %w", op.Operation, err)
+		}
+	}
+	return nil
+}
+
+// validateStrategy rejects the strategies which the strategies file accepts with a warning.
+func validateStrategy(strategy *strategy) error {
+	switch strategy.Type {
+	case samplerTypeProbabilistic:
+		if strategy.Param < 0 || strategy.Param > 1 {
+			return fmt.Errorf("sampling probability must be between 0 and 1, got %v", strategy.Param)
+		}
+	case samplerTypeRateLimiting:
+		if _, err := parseRateLimit(strategy.Param); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("sampling strategy type must be %q or %q, got %q",
+			samplerTypeProbabilistic, samplerTypeRateLimiting, strategy.Type)
+	}
+	return nil
+}
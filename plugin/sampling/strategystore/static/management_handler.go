@@ -0,0 +1,318 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package static
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app/auth"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
+)
+
+const (
+	// StrategiesRoute is the route of the sampling strategies management API on the admin server.
+	// GET lists the managed strategies, or returns the strategy of the service query parameter.
+	// PUT replaces the strategy of the service with the body, a service strategy of the strategies file.
+	// PATCH applies the body, a JSON merge patch (RFC 7386), to the strategy of the service; like any
+	// array, the operation strategies are replaced as a whole.
+	// The version of a strategy is returned as its ETag, PUT and PATCH accept it in If-Match.
+	StrategiesRoute = "/sampling/strategies"
+	// StrategyChangesRoute is the route of the audit trail of the strategy of the service query parameter,
+	// the latest changes first, up to the limit query parameter.
+	StrategyChangesRoute = "/sampling/strategies/changes"
+
+	actionPut   = "put"
+	actionPatch = "patch"
+
+	defaultChangesLimit = 100
+	maxDocumentSize     = 1 << 20
+)
+
+// managedStrategy is the representation of a managed strategy in the management API.
+type managedStrategy struct {
+	Service   string          `json:"service"`
+	Strategy  json.RawMessage `json:"strategy"`
+	Version   int64           `json:"version"`
+	UpdatedBy string          `json:"updated_by"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	// Warnings are the operation rules of the strategy which are never used or ambiguous
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// strategyChange is the representation of an entry of the audit trail in the management API.
+type strategyChange struct {
+	Service string          `json:"service"`
+	Version int64           `json:"version"`
+	Action  string          `json:"action"`
+	Subject string          `json:"subject"`
+	Time    time.Time       `json:"time"`
+	Before  json.RawMessage `json:"before,omitempty"`
+	After   json.RawMessage `json:"after"`
+}
+
+type managementHandler struct {
+	logger *zap.Logger
+	store  samplingstore.StrategiesStore
+	// reload applies the changes to the strategies served by this collector
+	// without waiting for the next reload
+	reload func() error
+}
+
+func (m *managementHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == StrategyChangesRoute {
+		m.serveChanges(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		m.getStrategies(w, r)
+	case http.MethodPut:
+		m.putStrategy(w, r, actionPut)
+	case http.MethodPatch:
+		m.putStrategy(w, r, actionPatch)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (m *managementHandler) getStrategies(w http.ResponseWriter, r *http.Request) {
+	service := r.URL.Query().Get("service")
+	if service != "" {
+		strategy, err := m.store.GetStrategy(service)
+		if errors.Is(err, samplingstore.ErrStrategyNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("ETag", etag(strategy.Version))
+		writeJSON(w, http.StatusOK, toManagedStrategy(strategy, nil))
+		return
+	}
+	strategies, err := m.store.GetStrategies()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	response := struct {
+		Strategies []*managedStrategy `json:"strategies"`
+	}{Strategies: make([]*managedStrategy, len(strategies))}
+	for i, strategy := range strategies {
+		response.Strategies[i] = toManagedStrategy(strategy, nil)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (m *managementHandler) putStrategy(w http.ResponseWriter, r *http.Request, action string) {
+	service := r.URL.Query().Get("service")
+	if service == "" {
+		http.Error(w, "the service query parameter is required", http.StatusBadRequest)
+		return
+	}
+	identity := auth.GetIdentity(r.Context())
+	if !identity.AllowsService(service) {
+		http.Error(w, fmt.Sprintf("not allowed to change the sampling strategy of service %q", service), http.StatusForbidden)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxDocumentSize))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("cannot read the request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	current, err := m.store.GetStrategy(service)
+	if errors.Is(err, samplingstore.ErrStrategyNotFound) {
+		current = nil
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !matchesVersion(r.Header.Get("If-Match"), current) {
+		http.Error(w, "the sampling strategy does not match If-Match", http.StatusPreconditionFailed)
+		return
+	}
+	document := body
+	if action == actionPatch {
+		if current == nil {
+			http.Error(w, samplingstore.ErrStrategyNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		if document, err = mergePatch(current.Document, body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	strategy, warnings, err := parseManagedStrategy(service, document)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if document, err = json.Marshal(strategy); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	change := &samplingstore.StrategyChange{
+		Service: service,
+		Version: 1,
+		Action:  action,
+		Time:    time.Now().UTC(),
+		After:   document,
+	}
+	if identity != nil {
+		change.Subject = identity.Subject
+	}
+	if current != nil {
+		change.Version = current.Version + 1
+		change.Before = current.Document
+	}
+	if err := m.store.PutStrategy(change); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, samplingstore.ErrVersionConflict) {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	m.logger.Info("Sampling strategy changed",
+		zap.String("service", service),
+		zap.Int64("version", change.Version),
+		zap.String("action", action),
+		zap.String("subject", change.Subject))
+	if err := m.reload(); err != nil {
+		m.logger.Error("failed to reload the managed sampling strategies", zap.Error(err))
+	}
+
+	status := http.StatusOK
+	if current == nil {
+		status = http.StatusCreated
+	}
+	w.Header().Set("ETag", etag(change.Version))
+	writeJSON(w, status, toManagedStrategy(change.Strategy(), warnings))
+}
+
+func (m *managementHandler) serveChanges(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	service := r.URL.Query().Get("service")
+	if service == "" {
+		http.Error(w, "the service query parameter is required", http.StatusBadRequest)
+		return
+	}
+	limit := defaultChangesLimit
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitParam); err != nil || limit <= 0 {
+			http.Error(w, fmt.Sprintf("invalid limit %q", limitParam), http.StatusBadRequest)
+			return
+		}
+	}
+	changes, err := m.store.GetStrategyChanges(service, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	response := struct {
+		Changes []*strategyChange `json:"changes"`
+	}{Changes: make([]*strategyChange, len(changes))}
+	for i, change := range changes {
+		response.Changes[i] = &strategyChange{
+			Service: change.Service,
+			Version: change.Version,
+			Action:  change.Action,
+			Subject: change.Subject,
+			Time:    change.Time,
+			Before:  change.Before,
+			After:   change.After,
+		}
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func toManagedStrategy(strategy *samplingstore.Strategy, warnings []string) *managedStrategy {
+	return &managedStrategy{
+		Service:   strategy.Service,
+		Strategy:  strategy.Document,
+		Version:   strategy.Version,
+		UpdatedBy: strategy.UpdatedBy,
+		UpdatedAt: strategy.UpdatedAt,
+		Warnings:  warnings,
+	}
+}
+
+func etag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// matchesVersion returns true if there is no If-Match header, or if it holds the ETag of the current strategy.
+func matchesVersion(ifMatch string, current *samplingstore.Strategy) bool {
+	if ifMatch == "" {
+		return true
+	}
+	if current == nil {
+		return false
+	}
+	return ifMatch == "*" || strings.TrimSpace(ifMatch) == etag(current.Version)
+}
+
+// mergePatch applies a JSON merge patch (RFC 7386) to a JSON document.
+func mergePatch(document, patch []byte) ([]byte, error) {
+	var target, changes interface{}
+	if err := json.Unmarshal(document, &target); err != nil {
+		return nil, fmt.Errorf("invalid stored sampling strategy: %w", err)
+	}
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, fmt.Errorf("invalid merge patch: %w", err)
+	}
+	return json.Marshal(mergeValue(target, changes))
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergeValue(targetObject[key], value)
+	}
+	return targetObject
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,282 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package static
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/plugin/storage/memory"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
+	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
+)
+
+type managementTest struct {
+	t          *testing.T
+	store      *strategyStore
+	strategies *memory.StrategiesStore
+	server     *httptest.Server
+}
+
+func withManagementAPI(t *testing.T, fn func(m *managementTest)) {
+	store, err := NewStrategyStore(Options{
+		StrategiesFile:        "fixtures/strategies.json",
+		ManagementEnabled:     true,
+		ManagementAPIKeysFile: "fixtures/management_api_keys.json",
+	}, zap.NewNop())
+	require.NoError(t, err)
+	defer store.(*strategyStore).Close()
+	strategies := memory.NewStrategiesStore()
+	handler, err := store.(Manager).Manage(strategies)
+	require.NoError(t, err)
+	require.NotNil(t, handler)
+	mux := http.NewServeMux()
+	mux.Handle(StrategiesRoute, handler)
+	mux.Handle(StrategyChangesRoute, handler)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	fn(&managementTest{t: t, store: store.(*strategyStore), strategies: strategies, server: server})
+}
+
+// do sends a request with the API key and the headers, given as name and value pairs,
+// and returns the response with its body.
+func (m *managementTest) do(method, path, apiKey, body string, headers ...string) (*http.Response, string) {
+	req, err := http.NewRequest(method, m.server.URL+path, strings.NewReader(body))
+	require.NoError(m.t, err)
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(m.t, err)
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	require.NoError(m.t, err)
+	return resp, string(respBody)
+}
+
+func TestManagementAPI(t *testing.T) {
+	withManagementAPI(t, func(m *managementTest) {
+		resp, _ := m.do(http.MethodGet, StrategiesRoute, "", "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		resp, _ = m.do(http.MethodGet, StrategiesRoute, "wrong-secret", "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp, body := m.do(http.MethodPut, StrategiesRoute+"?service=foo", "sre-secret", `{
+			"type": "probabilistic",
+			"param": 0.2,
+			"operation_strategies": [
+				{"operation": "op1", "type": "probabilistic", "param": 0.3},
+				{"operation": "op1", "type": "probabilistic", "param": 0.4}
+			]
+		}`)
+		require.Equal(t, http.StatusCreated, resp.StatusCode, body)
+		assert.Equal(t, `"1"`, resp.Header.Get("ETag"))
+		var created managedStrategy
+		require.NoError(t, json.Unmarshal([]byte(body), &created))
+		assert.Equal(t, "foo", created.Service)
+		assert.Equal(t, int64(1), created.Version)
+		assert.Equal(t, "sre-team", created.UpdatedBy)
+		assert.Len(t, created.Warnings, 1)
+		assert.JSONEq(t, `{
+			"service": "foo",
+			"type": "probabilistic",
+			"param": 0.2,
+			"operation_strategies": [
+				{"operation": "op1", "type": "probabilistic", "param": 0.3},
+				{"operation": "op1", "type": "probabilistic", "param": 0.4}
+			]
+		}`, string(created.Strategy))
+
+		// the change is served at once by this collector
+		s, err := m.store.GetSamplingStrategy(context.Background(), "foo")
+		require.NoError(t, err)
+		assert.Equal(t, 0.2, s.ProbabilisticSampling.SamplingRate)
+
+		resp, body = m.do(http.MethodPatch, StrategiesRoute+"?service=foo", "sre-secret",
+			`{"param": 0.1, "operation_strategies": null}`, "If-Match", `"1"`)
+		require.Equal(t, http.StatusOK, resp.StatusCode, body)
+		assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+		s, err = m.store.GetSamplingStrategy(context.Background(), "foo")
+		require.NoError(t, err)
+		assert.EqualValues(t, makeResponse(sampling.SamplingStrategyType_PROBABILISTIC, 0.1), *s)
+
+		resp, body = m.do(http.MethodGet, StrategiesRoute+"?service=foo", "frontend-secret", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode, body)
+		assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+		var patched managedStrategy
+		require.NoError(t, json.Unmarshal([]byte(body), &patched))
+		assert.JSONEq(t, `{"service": "foo", "type": "probabilistic", "param": 0.1}`, string(patched.Strategy))
+
+		resp, body = m.do(http.MethodPut, StrategiesRoute+"?service=frontend", "frontend-secret",
+			`{"type": "ratelimiting", "param": 3}`)
+		require.Equal(t, http.StatusCreated, resp.StatusCode, body)
+
+		resp, body = m.do(http.MethodGet, StrategiesRoute, "sre-secret", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode, body)
+		var list struct {
+			Strategies []managedStrategy `json:"strategies"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(body), &list))
+		require.Len(t, list.Strategies, 2)
+		assert.Equal(t, "foo", list.Strategies[0].Service)
+		assert.Equal(t, "frontend-team", list.Strategies[1].UpdatedBy)
+
+		resp, body = m.do(http.MethodGet, StrategyChangesRoute+"?service=foo", "sre-secret", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode, body)
+		var changes struct {
+			Changes []strategyChange `json:"changes"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(body), &changes))
+		require.Len(t, changes.Changes, 2)
+		assert.Equal(t, int64(2), changes.Changes[0].Version)
+		assert.Equal(t, actionPatch, changes.Changes[0].Action)
+		assert.Equal(t, "sre-team", changes.Changes[0].Subject)
+		assert.JSONEq(t, string(created.Strategy), string(changes.Changes[0].Before))
+		assert.JSONEq(t, string(patched.Strategy), string(changes.Changes[0].After))
+		assert.Equal(t, actionPut, changes.Changes[1].Action)
+		assert.Nil(t, changes.Changes[1].Before)
+
+		resp, body = m.do(http.MethodGet, StrategyChangesRoute+"?service=foo&limit=1", "sre-secret", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode, body)
+		require.NoError(t, json.Unmarshal([]byte(body), &changes))
+		assert.Len(t, changes.Changes, 1)
+	})
+}
+
+func TestManagementAPIErrors(t *testing.T) {
+	withManagementAPI(t, func(m *managementTest) {
+		putManagedStrategy(t, m.strategies, "foo", `{"service": "foo", "type": "probabilistic", "param": 0.5}`)
+		tests := []struct {
+			method   string
+			path     string
+			apiKey   string
+			body     string
+			headers  []string
+			status   int
+			contains string
+		}{
+			{method: http.MethodDelete, path: StrategiesRoute, status: http.StatusMethodNotAllowed},
+			{method: http.MethodPost, path: StrategyChangesRoute, status: http.StatusMethodNotAllowed},
+			{method: http.MethodGet, path: StrategiesRoute + "?service=bar", status: http.StatusNotFound},
+			{method: http.MethodPut, path: StrategiesRoute, body: `{}`, status: http.StatusBadRequest, contains: "service query parameter is required"},
+			{
+				method: http.MethodPut, path: StrategiesRoute + "?service=foo", apiKey: "frontend-secret",
+				body: `{"type": "probabilistic", "param": 0.1}`, status: http.StatusForbidden,
+			},
+			{
+				method: http.MethodPut, path: StrategiesRoute + "?service=foo", body: `{"type": "probabilistic", "param": 1.1}`,
+				status: http.StatusBadRequest, contains: "sampling probability must be between 0 and 1",
+			},
+			{
+				method: http.MethodPut, path: StrategiesRoute + "?service=foo", body: `{"type": "probabilistic", "param": 0.1}`,
+				headers: []string{"If-Match", `"2"`}, status: http.StatusPreconditionFailed,
+			},
+			{
+				method: http.MethodPut, path: StrategiesRoute + "?service=bar", body: `{"type": "probabilistic", "param": 0.1}`,
+				headers: []string{"If-Match", "*"}, status: http.StatusPreconditionFailed,
+			},
+			{method: http.MethodPatch, path: StrategiesRoute + "?service=bar", body: `{"param": 0.1}`, status: http.StatusNotFound},
+			{
+				method: http.MethodPatch, path: StrategiesRoute + "?service=foo", body: `{"param": `,
+				status: http.StatusBadRequest, contains: "invalid merge patch",
+			},
+			{
+				method: http.MethodPatch, path: StrategiesRoute + "?service=foo", body: `{"type": null}`,
+				status: http.StatusBadRequest, contains: "sampling strategy type must be",
+			},
+			{method: http.MethodGet, path: StrategyChangesRoute, status: http.StatusBadRequest},
+			{method: http.MethodGet, path: StrategyChangesRoute + "?service=foo&limit=0", status: http.StatusBadRequest},
+		}
+		for _, test := range tests {
+			apiKey := test.apiKey
+			if apiKey == "" {
+				apiKey = "sre-secret"
+			}
+			resp, body := m.do(test.method, test.path, apiKey, test.body, test.headers...)
+			assert.Equal(t, test.status, resp.StatusCode, "%s %s: %s", test.method, test.path, body)
+			assert.Contains(t, body, test.contains)
+		}
+		// the failed requests did not change the strategy
+		strategy, err := m.strategies.GetStrategy("foo")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), strategy.Version)
+	})
+}
+
+type conflictingStrategiesStore struct {
+	*memory.StrategiesStore
+	err error
+}
+
+func (s conflictingStrategiesStore) PutStrategy(*samplingstore.StrategyChange) error {
+	return s.err
+}
+
+func TestManagementHandlerStoreErrors(t *testing.T) {
+	for _, test := range []struct {
+		err    error
+		status int
+	}{
+		{err: samplingstore.ErrVersionConflict, status: http.StatusConflict},
+		{err: errors.New("storage error"), status: http.StatusInternalServerError},
+	} {
+		handler := &managementHandler{
+			logger: zap.NewNop(),
+			store:  conflictingStrategiesStore{StrategiesStore: memory.NewStrategiesStore(), err: test.err},
+			reload: func() error { return nil },
+		}
+		req := httptest.NewRequest(http.MethodPut, StrategiesRoute+"?service=foo",
+			strings.NewReader(`{"type": "probabilistic", "param": 0.1}`))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, test.status, w.Code)
+		assert.Contains(t, w.Body.String(), test.err.Error())
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		document string
+		patch    string
+		expected string
+	}{
+		{document: `{"a": 1, "b": 2}`, patch: `{"a": 3}`, expected: `{"a": 3, "b": 2}`},
+		{document: `{"a": 1, "b": 2}`, patch: `{"a": null}`, expected: `{"b": 2}`},
+		{document: `{"a": {"b": 1, "c": 2}}`, patch: `{"a": {"c": null, "d": 3}}`, expected: `{"a": {"b": 1, "d": 3}}`},
+		{document: `{"a": [1, 2]}`, patch: `{"a": [3]}`, expected: `{"a": [3]}`},
+		{document: `{"a": 1}`, patch: `{"b": {"c": 2}}`, expected: `{"a": 1, "b": {"c": 2}}`},
+		{document: `{"a": 1}`, patch: `[1]`, expected: `[1]`},
+	}
+	for _, test := range tests {
+		patched, err := mergePatch([]byte(test.document), []byte(test.patch))
+		require.NoError(t, err)
+		assert.JSONEq(t, test.expected, string(patched), test.patch)
+	}
+	_, err := mergePatch([]byte(`{`), []byte(`{}`))
+	assert.Contains(t, err.Error(), "invalid stored sampling strategy")
+}
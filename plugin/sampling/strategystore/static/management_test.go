@@ -0,0 +1,261 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package static
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/pkg/testutils"
+	"github.com/jaegertracing/jaeger/plugin/storage/memory"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
+	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
+)
+
+var _ Manager = new(strategyStore)
+
+// putManagedStrategy stores the next version of the strategy of a service.
+func putManagedStrategy(t *testing.T, store samplingstore.StrategiesStore, service, document string) {
+	change := &samplingstore.StrategyChange{Service: service, Version: 1, Action: actionPut, After: []byte(document)}
+	if current, err := store.GetStrategy(service); err == nil {
+		change.Version = current.Version + 1
+	}
+	require.NoError(t, store.PutStrategy(change))
+}
+
+type failingStrategiesStore struct {
+	samplingstore.StrategiesStore
+}
+
+func (failingStrategiesStore) GetStrategies() ([]*samplingstore.Strategy, error) {
+	return nil, errors.New("storage error")
+}
+
+func TestManagedStrategies(t *testing.T) {
+	logger, buf := testutils.NewLogger()
+	store, err := NewStrategyStore(Options{
+		StrategiesFile:    "fixtures/strategies.json",
+		ManagementEnabled: true,
+	}, logger)
+	require.NoError(t, err)
+	defer store.(*strategyStore).Close()
+	manager := store.(Manager)
+	assert.True(t, manager.ManagementEnabled())
+
+	strategies := memory.NewStrategiesStore()
+	putManagedStrategy(t, strategies, "foo", `{"type": "probabilistic", "param": 0.1}`)
+	putManagedStrategy(t, strategies, "baz", `{"type": "ratelimiting", "param": 7}`)
+	putManagedStrategy(t, strategies, "invalid", `{"type": "probabilistic", "param": 2}`)
+	handler, err := manager.Manage(strategies)
+	require.NoError(t, err)
+	assert.Nil(t, handler, "the API is not served without API keys")
+	assert.Contains(t, buf.String(), "ignoring invalid managed sampling strategy")
+
+	expectStrategy := func(service string, expected sampling.SamplingStrategyResponse) {
+		s, err := store.GetSamplingStrategy(context.Background(), service)
+		require.NoError(t, err)
+		assert.EqualValues(t, expected, *s, service)
+	}
+	expectStrategy("foo", makeResponse(sampling.SamplingStrategyType_PROBABILISTIC, 0.1))
+	expectStrategy("bar", makeResponse(sampling.SamplingStrategyType_RATE_LIMITING, 5))
+	expectStrategy("baz", makeResponse(sampling.SamplingStrategyType_RATE_LIMITING, 7))
+	expectStrategy("invalid", makeResponse(sampling.SamplingStrategyType_PROBABILISTIC, 0.5))
+
+	// the managed strategies are kept when the file is reloaded
+	require.NoError(t, store.(*strategyStore).updateSamplingStrategy([]byte(strategiesJSON(0.9))))
+	expectStrategy("foo", makeResponse(sampling.SamplingStrategyType_PROBABILISTIC, 0.1))
+
+	putManagedStrategy(t, strategies, "foo", `{"type": "probabilistic", "param": 0.2}`)
+	require.NoError(t, store.(*strategyStore).reloadManagedStrategies(strategies))
+	expectStrategy("foo", makeResponse(sampling.SamplingStrategyType_PROBABILISTIC, 0.2))
+
+	assert.EqualError(t, store.(*strategyStore).reloadManagedStrategies(failingStrategiesStore{}),
+		"failed to load the managed sampling strategies: storage error")
+	_, err = manager.Manage(failingStrategiesStore{})
+	assert.Error(t, err)
+}
+
+func TestAutoReloadManagedStrategies(t *testing.T) {
+	store, err := NewStrategyStore(Options{
+		ManagementEnabled:         true,
+		ManagementRefreshInterval: 10 * time.Millisecond,
+	}, zap.NewNop())
+	require.NoError(t, err)
+	defer store.(*strategyStore).Close()
+
+	strategies := memory.NewStrategiesStore()
+	_, err = store.(Manager).Manage(strategies)
+	require.NoError(t, err)
+	putManagedStrategy(t, strategies, "foo", `{"type": "probabilistic", "param": 0.3}`)
+	for i := 0; i < 100; i++ {
+		s, err := store.GetSamplingStrategy(context.Background(), "foo")
+		require.NoError(t, err)
+		if s.ProbabilisticSampling.SamplingRate == 0.3 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	s, err := store.GetSamplingStrategy(context.Background(), "foo")
+	require.NoError(t, err)
+	assert.EqualValues(t, makeResponse(sampling.SamplingStrategyType_PROBABILISTIC, 0.3), *s)
+}
+
+func TestManageAPIKeysFileError(t *testing.T) {
+	store, err := NewStrategyStore(Options{
+		ManagementEnabled:     true,
+		ManagementAPIKeysFile: "fixtures/missing.json",
+	}, zap.NewNop())
+	require.NoError(t, err)
+	_, err = store.(Manager).Manage(memory.NewStrategiesStore())
+	assert.Contains(t, err.Error(), "cannot create the authenticator of the sampling strategies management API")
+}
+
+func TestCombineStrategies(t *testing.T) {
+	file := &strategies{
+		DefaultStrategy: &serviceStrategy{strategy: strategy{Type: samplerTypeProbabilistic, Param: 0.5}},
+		ServiceStrategies: []*serviceStrategy{
+			{Service: "foo", strategy: strategy{Type: samplerTypeProbabilistic, Param: 0.8}},
+			{Service: "bar", strategy: strategy{Type: samplerTypeRateLimiting, Param: 5}},
+		},
+	}
+	assert.Equal(t, file, combineStrategies(file, nil))
+
+	managed := []*serviceStrategy{
+		{Service: "baz", strategy: strategy{Type: samplerTypeProbabilistic, Param: 0.3}},
+		{Service: "foo", strategy: strategy{Type: samplerTypeProbabilistic, Param: 0.1}},
+	}
+	assert.Equal(t, &strategies{
+		DefaultStrategy:   file.DefaultStrategy,
+		ServiceStrategies: []*serviceStrategy{file.ServiceStrategies[1], managed[0], managed[1]},
+	}, combineStrategies(file, managed))
+	assert.Equal(t, &strategies{ServiceStrategies: managed}, combineStrategies(nil, managed))
+}
+
+func TestParseManagedStrategy(t *testing.T) {
+	s, warnings, err := parseManagedStrategy("foo", []byte(`{
+		"type": "probabilistic",
+		"param": 0.5,
+		"operation_strategies": [
+			{"operation": "op1", "type": "ratelimiting", "param": 10},
+			{"operation": "op1", "type": "probabilistic", "param": 0.1},
+			{"operation": "glob:GET /*", "type": "probabilistic", "param": 0.2}
+		]
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, "foo", s.Service)
+	assert.Len(t, s.OperationStrategies, 3)
+	assert.Equal(t, []string{
+		`sampling strategy for operation "op1" of service "foo" is listed more than once, the last one is used`,
+	}, warnings)
+
+	_, warnings, err = parseManagedStrategy("foo", []byte(`{
+		"type": "probabilistic",
+		"param": 0.5,
+		"client_strategies": [
+			{"match": {"sdk_version": "glob:Go-*"}, "type": "probabilistic", "param": 1},
+			{"type": "ratelimiting", "param": 10},
+			{"match": {"environment": "canary"}, "type": "probabilistic", "param": 1}
+		]
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`client strategy 2 of service "foo" matches all the clients, the following client strategies are unreachable`,
+	}, warnings)
+
+	_, _, err = parseManagedStrategy("regex:foo-.*", []byte(`{"service": "regex:foo-.*", "type": "ratelimiting", "param": 5}`))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		service  string
+		document string
+		err      string
+	}{
+		{
+			document: `{"type": "probabilistic", "param": 0.5`,
+			err:      "invalid sampling strategy: unexpected EOF",
+		},
+		{
+			document: `{"type": "probabilistic", "probability": 0.5}`,
+			err:      `invalid sampling strategy: json: unknown field "probability"`,
+		},
+		{
+			document: `{"service": "bar", "type": "probabilistic", "param": 0.5}`,
+			err:      `the sampling strategy is for service "bar" rather than "foo"`,
+		},
+		{
+			service:  "regex:(",
+			document: `{"type": "probabilistic", "param": 0.5}`,
+			err:      "invalid regular expression \"regex:(\": error parsing regexp: missing closing ): `^(?:()$`",
+		},
+		{
+			document: `{"type": "adaptive", "param": 0.5}`,
+			err:      `sampling strategy type must be "probabilistic" or "ratelimiting", got "adaptive"`,
+		},
+		{
+			document: `{"type": "probabilistic", "param": 1.5}`,
+			err:      "sampling probability must be between 0 and 1, got 1.5",
+		},
+		{
+			document: `{"type": "ratelimiting", "param": 40000}`,
+			err:      "rate limit must be a whole number between 0 and 32767 traces per second, got 40000",
+		},
+		{
+			document: `{"type": "ratelimiting", "param": 2.5}`,
+			err:      "rate limit must be a whole number between 0 and 32767 traces per second, got 2.5",
+		},
+		{
+			document: `{"type": "probabilistic", "param": 0.5, "operation_strategies": [{"type": "probabilistic", "param": 0.5}]}`,
+			err:      "operation strategies must have an operation",
+		},
+		{
+			document: `{"type": "probabilistic", "param": 0.5, "operation_strategies": [{"operation": "op", "type": "ratelimiting", "param": -1}]}`,
+			err:      `operation "op": rate limit must be a whole number between 0 and 32767 traces per second, got -1`,
+		},
+		{
+			document: `{"type": "probabilistic", "param": 0.5, "client_strategies": [null]}`,
+			err:      "client strategies must not be null",
+		},
+		{
+			document: `{"type": "probabilistic", "param": 0.5, "client_strategies": [{"match": {"hostname": "regex:("}, "type": "probabilistic", "param": 1}]}`,
+			err:      "client strategy 1: invalid regular expression \"regex:(\": error parsing regexp: missing closing ): `^(?:()$`",
+		},
+		{
+			document: `{"type": "probabilistic", "param": 0.5, "client_strategies": [{"match": {"tags": {"canary": ""}}, "type": "probabilistic", "param": 1}]}`,
+			err:      `client strategy 1: tag "canary": the condition must not be empty`,
+		},
+		{
+			document: `{"type": "probabilistic", "param": 0.5, "client_strategies": [{"match": {"environment": "canary"}, "type": "probabilistic", "param": 2}]}`,
+			err:      "client strategy 1: sampling probability must be between 0 and 1, got 2",
+		},
+		{
+			document: `{"type": "probabilistic", "param": 0.5, "client_strategies": [{"type": "probabilistic", "param": 1, "operation_strategies": [{"type": "probabilistic", "param": 1}]}]}`,
+			err:      "client strategy 1: operation strategies must have an operation",
+		},
+	}
+	for _, test := range tests {
+		service := test.service
+		if service == "" {
+			service = "foo"
+		}
+		_, _, err := parseManagedStrategy(service, []byte(test.document))
+		assert.EqualError(t, err, test.err, test.document)
+	}
+}
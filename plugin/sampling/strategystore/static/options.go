@@ -25,6 +25,12 @@ const (
 	// samplingStrategiesFile contains the name of CLI option for config file.
 	samplingStrategiesFile           = "sampling.strategies-file"
 	samplingStrategiesReloadInterval = "sampling.strategies-reload-interval"
+
+	samplingManagementEnabled         = "sampling.management.enabled"
+	samplingManagementRefreshInterval = "sampling.management.refresh-interval"
+	samplingManagementAPIKeysFile     = "sampling.management.api-keys-file"
+
+	defaultManagementRefreshInterval = 10 * time.Second
 )
 
 // Options holds configuration for the static sampling strategy store.
@@ -33,17 +39,31 @@ type Options struct {
 	StrategiesFile string
 	// ReloadInterval is the time interval to check and reload sampling strategies file
 	ReloadInterval time.Duration
+	// ManagementEnabled enables the strategies managed at runtime in the storage backend,
+	// which replace the strategies of the file for the same services
+	ManagementEnabled bool
+	// ManagementRefreshInterval is the time interval to reload the strategies managed at runtime from storage
+	ManagementRefreshInterval time.Duration
+	// ManagementAPIKeysFile is the path to a JSON file with the API keys of the strategies management API,
+	// the API is not served if it is empty
+	ManagementAPIKeysFile string
 }
 
 // AddFlags adds flags for Options
 func AddFlags(flagSet *flag.FlagSet) {
 	flagSet.Duration(samplingStrategiesReloadInterval, 0, "Reload interval to check and reload sampling strategies file. Zero value means no reloading")
 	flagSet.String(samplingStrategiesFile, "", "The path for the sampling strategies file in JSON format. See sampling documentation to see format of the file")
+	flagSet.Bool(samplingManagementEnabled, false, "Whether to serve the sampling strategies managed at runtime in the span storage backend, which replace the strategies of the file for the same services. Supported by the memory, badger and cassandra storage types")
+	flagSet.Duration(samplingManagementRefreshInterval, defaultManagementRefreshInterval, "Interval to reload the sampling strategies managed at runtime from storage, so that all collectors converge. Zero value means no reloading")
+	flagSet.String(samplingManagementAPIKeysFile, "", `The path to a JSON file with the API keys accepted as bearer tokens by the sampling strategies management API on the admin server, e.g. {"keys": [{"key": "secret", "subject": "sre-team", "services": ["frontend"]}]}; the keys may only change the strategies of their services, any service if empty. The API is not served if no file is given`)
 }
 
 // InitFromViper initializes Options with properties from viper
 func (opts *Options) InitFromViper(v *viper.Viper) *Options {
 	opts.StrategiesFile = v.GetString(samplingStrategiesFile)
 	opts.ReloadInterval = v.GetDuration(samplingStrategiesReloadInterval)
+	opts.ManagementEnabled = v.GetBool(samplingManagementEnabled)
+	opts.ManagementRefreshInterval = v.GetDuration(samplingManagementRefreshInterval)
+	opts.ManagementAPIKeysFile = v.GetString(samplingManagementAPIKeysFile)
 	return opts
 }
@@ -0,0 +1,260 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package static
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// regexPrefix marks the service and operation names of the strategies file which are regular expressions.
+	regexPrefix = "regex:"
+	// globPrefix marks the service and operation names of the strategies file which are globs.
+	globPrefix = "glob:"
+)
+
+// pattern matches the names of services or operations against a glob or a regular expression.
+type pattern struct {
+	// key is the name of the rule in the strategies file
+	key string
+	// expr is the glob or the regular expression, its length gives the precedence of the pattern
+	expr   string
+	glob   bool
+	regexp *regexp.Regexp
+	// order is the position of the rule in the strategies file
+	order int
+}
+
+// parsePattern returns the pattern of a rule of the strategies file, or nil if the rule matches a name exactly.
+// Names prefixed with "glob:" are globs where '*' matches any sequence of characters and '?' any character,
+// names prefixed with "regex:" are regular expressions matching whole names. Other names are never patterns,
+// even if they contain '*' or '?'.
+func parsePattern(key string, order int) (*pattern, error) {
+	if strings.HasPrefix(key, regexPrefix) {
+		expr := strings.TrimPrefix(key, regexPrefix)
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %w", key, err)
+		}
+		return &pattern{key: key, expr: expr, regexp: re, order: order}, nil
+	}
+	if !strings.HasPrefix(key, globPrefix) {
+		return nil, nil
+	}
+	expr := strings.TrimPrefix(key, globPrefix)
+	var re strings.Builder
+	re.WriteString("^(?s:")
+	for _, r := range expr {
+		switch r {
+		case '*':
+			re.WriteString(".*")
+		case '?':
+			re.WriteString(".")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	re.WriteString(")$")
+	return &pattern{key: key, expr: expr, glob: true, regexp: regexp.MustCompile(re.String()), order: order}, nil
+}
+
+func (p *pattern) match(name string) bool {
+	return p.regexp.MatchString(name)
+}
+
+// precedes returns true if p is used rather than o for the names matched by both patterns:
+// the longest pattern wins, then the last one in the strategies file.
+func (p *pattern) precedes(o *pattern) bool {
+	if len(p.expr) != len(o.expr) {
+		return len(p.expr) > len(o.expr)
+	}
+	return p.order > o.order
+}
+
+// sortPatterns sorts the patterns by precedence.
+func sortPatterns(patterns []*pattern) {
+	sort.Slice(patterns, func(i, j int) bool {
+		return patterns[i].precedes(patterns[j])
+	})
+}
+
+// contains returns true if every name matched by o is matched by p. It may return false for
+// globs like "?*" and "*?" which match the same names, and for all regular expressions but identical ones.
+func (p *pattern) contains(o *pattern) bool {
+	if p.glob != o.glob {
+		return false
+	}
+	if !p.glob {
+		return p.expr == o.expr
+	}
+	return globContains([]rune(p.expr), []rune(o.expr))
+}
+
+// overlaps returns true if some name is matched by both p and o. It returns false for regular expressions,
+// whose intersection is not computed, unless they are identical.
+func (p *pattern) overlaps(o *pattern) bool {
+	if p.glob != o.glob {
+		return false
+	}
+	if !p.glob {
+		return p.expr == o.expr
+	}
+	return globsIntersect([]rune(p.expr), []rune(o.expr))
+}
+
+// globContains matches the glob p against the glob o, where a '*' of p matches any sequence of
+// characters and wildcards of o, and a '?' of p matches any character or '?' of o.
+func globContains(p, o []rune) bool {
+	memo := make(map[[2]int]bool)
+	var contains func(i, j int) bool
+	contains = func(i, j int) bool {
+		key := [2]int{i, j}
+		if result, ok := memo[key]; ok {
+			return result
+		}
+		var result bool
+		switch {
+		case i == len(p):
+			result = j == len(o)
+		case p[i] == '*':
+			result = contains(i+1, j) || (j < len(o) && contains(i, j+1))
+		case j == len(o):
+			result = false
+		case p[i] == '?':
+			result = o[j] != '*' && contains(i+1, j+1)
+		default:
+			result = o[j] == p[i] && contains(i+1, j+1)
+		}
+		memo[key] = result
+		return result
+	}
+	return contains(0, 0)
+}
+
+// globsIntersect returns true if some name is matched by both globs a and b.
+func globsIntersect(a, b []rune) bool {
+	memo := make(map[[2]int]bool)
+	var intersect func(i, j int) bool
+	intersect = func(i, j int) bool {
+		key := [2]int{i, j}
+		if result, ok := memo[key]; ok {
+			return result
+		}
+		var result bool
+		switch {
+		case i == len(a) && j == len(b):
+			result = true
+		case i < len(a) && a[i] == '*':
+			// the '*' of a matches nothing more, or the next character of b
+			result = intersect(i+1, j) || (j < len(b) && intersect(i, j+1))
+		case j < len(b) && b[j] == '*':
+			result = intersect(i, j+1) || (i < len(a) && intersect(i+1, j))
+		case i == len(a) || j == len(b):
+			result = false
+		default:
+			result = (a[i] == '?' || b[j] == '?' || a[i] == b[j]) && intersect(i+1, j+1)
+		}
+		memo[key] = result
+		return result
+	}
+	return intersect(0, 0)
+}
+
+// validateRules reports the rules of the strategies file which are never used, and the patterns which
+// match the same names with the same precedence. what is "service" or "operation", and scope describes
+// where the rules are listed. The keys must be in the order of the strategies file, with valid patterns.
+func validateRules(keys []string, what, scope string) []string {
+	var issues []string
+	var patterns []*pattern
+	exact := make(map[string]int)
+	for i, key := range keys {
+		p, _ := parsePattern(key, i)
+		if p != nil {
+			patterns = append(patterns, p)
+			continue
+		}
+		exact[key]++
+		if exact[key] == 2 {
+			issues = append(issues, fmt.Sprintf(
+				"sampling strategy for %s %q%s is listed more than once, the last one is used", what, key, scope))
+		}
+	}
+	sortPatterns(patterns)
+	unreachable := make(map[*pattern]bool)
+	for i, p := range patterns {
+		for _, q := range patterns[:i] {
+			if q.contains(p) {
+				unreachable[p] = true
+				issues = append(issues, fmt.Sprintf(
+					"sampling strategy for %s %q%s is unreachable, the names it matches are matched by %q",
+					what, p.key, scope, q.key))
+				break
+			}
+		}
+	}
+	for i, p := range patterns {
+		for _, q := range patterns[i+1:] {
+			if len(q.expr) != len(p.expr) || unreachable[p] || unreachable[q] || !p.overlaps(q) {
+				continue
+			}
+			issues = append(issues, fmt.Sprintf(
+				"sampling strategies for %s %q and %q%s are ambiguous, %q is used for the names matched by both",
+				what, q.key, p.key, scope, p.key))
+		}
+	}
+	return issues
+}
+
+// validateStrategies reports the rules of the strategies file which are never used or ambiguous.
+func validateStrategies(strategies *strategies) []string {
+	services := make([]string, len(strategies.ServiceStrategies))
+	for i, s := range strategies.ServiceStrategies {
+		services[i] = s.Service
+	}
+	issues := validateRules(services, "service", "")
+	if strategies.DefaultStrategy != nil {
+		issues = append(issues, validateServiceStrategy(strategies.DefaultStrategy, " of the default strategy")...)
+	}
+	for _, s := range strategies.ServiceStrategies {
+		issues = append(issues, validateServiceStrategy(s, fmt.Sprintf(" of service %q", s.Service))...)
+	}
+	return issues
+}
+
+// validateServiceStrategy reports the operation rules of a service strategy and of its client strategies
+// which are never used or ambiguous, and the client strategies following one without conditions.
+func validateServiceStrategy(strategy *serviceStrategy, scope string) []string {
+	issues := validateRules(operationNames(strategy.OperationStrategies), "operation", scope)
+	for i, client := range strategy.ClientStrategies {
+		clientScope := fmt.Sprintf(" of client strategy %d%s", i+1, scope)
+		issues = append(issues, validateRules(operationNames(client.OperationStrategies), "operation", clientScope)...)
+		if client.Match.isEmpty() && i < len(strategy.ClientStrategies)-1 {
+			issues = append(issues, fmt.Sprintf(
+				"client strategy %d%s matches all the clients, the following client strategies are unreachable", i+1, scope))
+		}
+	}
+	return issues
+}
+
+func operationNames(strategies []*operationStrategy) []string {
+	operations := make([]string, len(strategies))
+	for i, op := range strategies {
+		operations[i] = op.Operation
+	}
+	return operations
+}
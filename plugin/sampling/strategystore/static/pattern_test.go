@@ -0,0 +1,138 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package static
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePattern(t *testing.T) {
+	for _, key := range []string{"payments", "GET /users/*", "what?"} {
+		p, err := parsePattern(key, 0)
+		require.NoError(t, err)
+		assert.Nil(t, p, key)
+	}
+
+	_, err := parsePattern("regex:[", 0)
+	assert.Error(t, err)
+
+	tests := []struct {
+		key        string
+		matches    []string
+		notMatches []string
+	}{
+		{key: "glob:payments-*", matches: []string{"payments-", "payments-eu", "payments-eu/west"}, notMatches: []string{"payments", "old-payments-eu"}},
+		{key: "glob:GET /health*", matches: []string{"GET /health", "GET /health/live"}, notMatches: []string{"POST /health"}},
+		{key: "glob:v?.*", matches: []string{"v1.2", "v2."}, notMatches: []string{"v12", "v.2"}},
+		{key: "regex:(billing|invoicing)-v[0-9]+", matches: []string{"billing-v1", "invoicing-v22"}, notMatches: []string{"billing-v", "billing-v1-beta"}},
+	}
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			p, err := parsePattern(test.key, 0)
+			require.NoError(t, err)
+			require.NotNil(t, p)
+			for _, name := range test.matches {
+				assert.True(t, p.match(name), name)
+			}
+			for _, name := range test.notMatches {
+				assert.False(t, p.match(name), name)
+			}
+		})
+	}
+}
+
+func TestPatternPrecedence(t *testing.T) {
+	short, _ := parsePattern("glob:payments-*", 0)
+	long, _ := parsePattern("glob:payments-eu-*", 1)
+	regex, _ := parsePattern("regex:payments-.*", 2)
+	same, _ := parsePattern("glob:*-payments", 3)
+	patterns := []*pattern{short, regex, same, long}
+	sortPatterns(patterns)
+	assert.Equal(t, []*pattern{long, regex, same, short}, patterns)
+}
+
+func TestGlobContains(t *testing.T) {
+	tests := []struct {
+		p, o     string
+		expected bool
+	}{
+		{p: "foo-*", o: "foo-*", expected: true},
+		{p: "foo-*", o: "foo-bar-*", expected: true},
+		{p: "foo-*", o: "foo-?", expected: true},
+		{p: "*", o: "?a*b", expected: true},
+		{p: "*-*", o: "foo-*", expected: true},
+		{p: "foo-?*", o: "foo-*"},
+		{p: "foo-?", o: "foo-*"},
+		{p: "foo-*", o: "bar-*"},
+		{p: "a*b", o: "a*"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, globContains([]rune(test.p), []rune(test.o)), "%s contains %s", test.p, test.o)
+	}
+}
+
+func TestGlobsIntersect(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{a: "foo-*", b: "*-bar", expected: true},
+		{a: "foo-*", b: "foo-?", expected: true},
+		{a: "a*b*c", b: "*bc", expected: true},
+		{a: "?", b: "*", expected: true},
+		{a: "foo-*", b: "bar-*"},
+		{a: "a?", b: "b*"},
+		{a: "*a", b: "*b"},
+		{a: "??", b: "?"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, globsIntersect([]rune(test.a), []rune(test.b)), "%s and %s", test.a, test.b)
+		assert.Equal(t, test.expected, globsIntersect([]rune(test.b), []rune(test.a)), "%s and %s", test.b, test.a)
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	tests := []struct {
+		keys     []string
+		expected []string
+	}{
+		{
+			keys: []string{"GET", "glob:GET /health*", "glob:GET /*", "regex:GET /.*", "POST"},
+		},
+		{
+			keys:     []string{"GET", "POST", "GET"},
+			expected: []string{`sampling strategy for operation "GET" of service "foo" is listed more than once, the last one is used`},
+		},
+		{
+			keys: []string{"glob:GET *", "glob:GET /v?", "glob:GET /health/*", "glob:GET /*?", "regex:GET .*", "regex:GET .*"},
+			expected: []string{
+				`sampling strategy for operation "glob:GET /v?" of service "foo" is unreachable, the names it matches are matched by "glob:GET /*?"`,
+				`sampling strategy for operation "regex:GET .*" of service "foo" is unreachable, the names it matches are matched by "regex:GET .*"`,
+			},
+		},
+		{
+			keys: []string{"glob:GET /*", "glob:* /api", "regex:GET /.", "regex:. /api"},
+			expected: []string{
+				`sampling strategies for operation "glob:GET /*" and "glob:* /api" of service "foo" are ambiguous, "glob:* /api" is used for the names matched by both`,
+			},
+		},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, validateRules(test.keys, "operation", ` of service "foo"`), "%v", test.keys)
+	}
+}
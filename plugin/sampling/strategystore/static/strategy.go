@@ -15,25 +15,65 @@
 package static
 
 // strategy defines a sampling strategy. Type can be "probabilistic" or "ratelimiting"
-// and Param will represent "sampling probability" and "max traces per second" respectively.
+// and Param will represent "sampling probability" and "max traces per second" respectively,
+// where the max traces per second must be a whole number between 0 and 32767.
 type strategy struct {
 	Type  string  `json:"type"`
 	Param float64 `json:"param"`
 }
 
 // operationStrategy defines an operation specific sampling strategy.
+// A "ratelimiting" operation strategy is served with both a rate limit and a sampling probability,
+// the probability of the service: clients supporting per-operation rate limiting sample up to
+// "max traces per second" for the operation, older clients fall back to the probability.
 type operationStrategy struct {
 	Operation string `json:"operation"`
 	strategy
 }
 
 // serviceStrategy defines a service specific sampling strategy.
+//
+// Service, as well as Operation in the operation strategies, is a name, a glob prefixed with "glob:"
+// where '*' matches any sequence of characters and '?' any character, or a regular expression matching
+// whole names prefixed with "regex:". Names without these prefixes are matched exactly, even if they
+// contain '*' or '?'. A name takes precedence over the patterns, and the longest pattern over shorter
+// ones, the last one in the file winning between patterns of the same length.
+// The operation patterns are never sent to the clients, which only know operation names: they apply to
+// the operations listed by name in the default strategy and, with the hybrid strategy store only, to the
+// operations seen by adaptive sampling. With the static strategy store, an operation pattern like
+// "glob:GET /health*" has no effect on the operations which are not listed by name.
+// The rules that are never used and the globs of the same length matching the same names are reported
+// when the file is loaded, regular expressions are only checked for duplicates.
+// The service strategies managed at runtime, see StrategiesRoute, have the same format and replace
+// the strategies of the file for the same Service.
+// The clients of the service matching a client strategy get this strategy instead, the first one in
+// the list wins.
 type serviceStrategy struct {
 	Service             string               `json:"service"`
-	OperationStrategies []*operationStrategy `json:"operation_strategies"`
+	OperationStrategies []*operationStrategy `json:"operation_strategies,omitempty"`
+	ClientStrategies    []*clientStrategy    `json:"client_strategies,omitempty"`
+	strategy
+}
+
+// clientStrategy defines the sampling strategy of the clients of a service matching client attributes.
+// It replaces the strategy of the service as a whole, and is merged with the operation strategies
+// of the default strategy like a service strategy.
+type clientStrategy struct {
+	Match               clientAttributes     `json:"match"`
+	OperationStrategies []*operationStrategy `json:"operation_strategies,omitempty"`
 	strategy
 }
 
+// clientAttributes are the conditions of a client strategy on the attributes reported by the clients,
+// all the conditions which are set must match. The tags listed must have a condition, an empty one is rejected. The values are names, globs or regular expressions like
+// the service names, and never match the attributes the clients do not report.
+type clientAttributes struct {
+	Environment string            `json:"environment,omitempty"`
+	Hostname    string            `json:"hostname,omitempty"`
+	SDKVersion  string            `json:"sdk_version,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+}
+
 // strategies holds a default sampling strategy and service specific sampling strategies.
 type strategies struct {
 	DefaultStrategy   *serviceStrategy   `json:"default_strategy"`
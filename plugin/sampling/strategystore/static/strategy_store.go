@@ -21,9 +21,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"sort"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -38,16 +41,83 @@ import (
 var nullJSON = []byte("null")
 
 type strategyStore struct {
-	logger *zap.Logger
+	logger  *zap.Logger
+	options Options
 
 	storedStrategies atomic.Value // holds *storedStrategies
 
+	// mu serializes the updates of the strategies of the file and of the managed strategies,
+	// which are parsed together
+	mu                sync.Mutex
+	fileStrategies    *strategies
+	managedStrategies []*serviceStrategy
+	// managedVersions identifies the versions of the managed strategies, to parse them only when they change
+	managedVersions string
+
+	ctx        context.Context
 	cancelFunc context.CancelFunc
 }
 
 type storedStrategies struct {
-	defaultStrategy   *sampling.SamplingStrategyResponse
-	serviceStrategies map[string]*sampling.SamplingStrategyResponse
+	defaultStrategy   *serviceRule
+	serviceStrategies map[string]*serviceRule
+	// servicePatterns are the strategies of the services matched by patterns, by precedence
+	servicePatterns []*serviceRule
+}
+
+// serviceRule holds the strategy of a service, or the default strategy, with its operation patterns.
+type serviceRule struct {
+	// pattern is nil unless the service is matched by a pattern
+	pattern  *pattern
+	strategy *sampling.SamplingStrategyResponse
+	// operationPatterns are the strategies of the operations matched by patterns, by precedence
+	operationPatterns []*operationRule
+	// clientRules are the strategies of the clients matching client attributes, in the order of the file
+	clientRules []*clientRule
+}
+
+type operationRule struct {
+	pattern  *pattern
+	strategy *sampling.OperationSamplingStrategy
+}
+
+// lookup returns the strategy of a service, listed by name or matching a pattern, or the default strategy.
+// The services listed by name take precedence over the patterns. The strategy of the first client rule
+// of the service matching the client attributes is returned instead, as a listed strategy.
+func (s *storedStrategies) lookup(serviceName string, attributes *ss.ClientAttributes) (service *serviceRule, listed bool) {
+	service, listed = s.lookupService(serviceName)
+	for _, client := range service.clientRules {
+		if client.matcher.match(attributes) {
+			return client.serviceRule, true
+		}
+	}
+	return service, listed
+}
+
+func (s *storedStrategies) lookupService(serviceName string) (service *serviceRule, listed bool) {
+	if service, ok := s.serviceStrategies[serviceName]; ok {
+		return service, true
+	}
+	for _, service := range s.servicePatterns {
+		if service.pattern.match(serviceName) {
+			return service, true
+		}
+	}
+	return s.defaultStrategy, false
+}
+
+// lookupOperation returns the strategy of the first operation pattern matching the operation.
+func (r *serviceRule) lookupOperation(operation string) (*sampling.OperationSamplingStrategy, bool) {
+	for _, op := range r.operationPatterns {
+		if op.pattern.match(operation) {
+			return &sampling.OperationSamplingStrategy{
+				Operation:             operation,
+				ProbabilisticSampling: op.strategy.ProbabilisticSampling,
+				RateLimitingSampling:  op.strategy.RateLimitingSampling,
+			}, true
+		}
+	}
+	return nil, false
 }
 
 type strategyLoader func() ([]byte, error)
@@ -57,12 +127,14 @@ func NewStrategyStore(options Options, logger *zap.Logger) (ss.StrategyStore, er
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	h := &strategyStore{
 		logger:     logger,
+		options:    options,
+		ctx:        ctx,
 		cancelFunc: cancelFunc,
 	}
 	h.storedStrategies.Store(defaultStrategies())
 
 	if options.StrategiesFile == "" {
-		h.parseStrategies(nil)
+		_ = h.setFileStrategies(nil)
 		return h, nil
 	}
 
@@ -71,7 +143,9 @@ func NewStrategyStore(options Options, logger *zap.Logger) (ss.StrategyStore, er
 	if err != nil {
 		return nil, err
 	}
-	h.parseStrategies(strategies)
+	if err := h.setFileStrategies(strategies); err != nil {
+		return nil, err
+	}
 
 	if options.ReloadInterval > 0 {
 		go h.autoUpdateStrategies(ctx, options.ReloadInterval, loadFn)
@@ -80,14 +154,37 @@ func NewStrategyStore(options Options, logger *zap.Logger) (ss.StrategyStore, er
 }
 
 // GetSamplingStrategy implements StrategyStore#GetSamplingStrategy.
-func (h *strategyStore) GetSamplingStrategy(_ context.Context, serviceName string) (*sampling.SamplingStrategyResponse, error) {
-	ss := h.storedStrategies.Load().(*storedStrategies)
-	serviceStrategies := ss.serviceStrategies
-	if strategy, ok := serviceStrategies[serviceName]; ok {
-		return strategy, nil
-	}
-	h.logger.Debug("sampling strategy not found, using default", zap.String("service", serviceName))
-	return ss.defaultStrategy, nil
+func (h *strategyStore) GetSamplingStrategy(ctx context.Context, serviceName string) (*sampling.SamplingStrategyResponse, error) {
+	stored := h.storedStrategies.Load().(*storedStrategies)
+	service, listed := stored.lookup(serviceName, ss.GetClientAttributes(ctx))
+	if !listed {
+		h.logger.Debug("sampling strategy not found, using default", zap.String("service", serviceName))
+	}
+	return service.strategy, nil
+}
+
+// Lookup returns the strategy of the service if it is listed in the strategies file, with listed set
+// to true, or the default strategy. The client strategies are matched against the client attributes
+// of the context. It lets other stores layer the static strategies over their own.
+func (h *strategyStore) Lookup(ctx context.Context, serviceName string) (strategy *sampling.SamplingStrategyResponse, listed bool) {
+	stored := h.storedStrategies.Load().(*storedStrategies)
+	service, listed := stored.lookup(serviceName, ss.GetClientAttributes(ctx))
+	return service.strategy, listed
+}
+
+// LookupOperation returns the strategy of an operation which is not listed by name in the strategy
+// of the service but matches an operation pattern of the service, or of the default strategy.
+// It lets other stores apply the static operation patterns to the operations they know.
+func (h *strategyStore) LookupOperation(ctx context.Context, serviceName, operation string) (*sampling.OperationSamplingStrategy, bool) {
+	stored := h.storedStrategies.Load().(*storedStrategies)
+	service, listed := stored.lookup(serviceName, ss.GetClientAttributes(ctx))
+	if strategy, ok := service.lookupOperation(operation); ok {
+		return strategy, true
+	}
+	if listed {
+		return stored.defaultStrategy.lookupOperation(operation)
+	}
+	return nil, false
 }
 
 // Close stops updating the strategies
@@ -177,11 +274,24 @@ func (h *strategyStore) updateSamplingStrategy(bytes []byte) error {
 	if err := json.Unmarshal(bytes, &strategies); err != nil {
 		return fmt.Errorf("failed to unmarshal sampling strategies: %w", err)
 	}
-	h.parseStrategies(&strategies)
+	if err := h.setFileStrategies(&strategies); err != nil {
+		return err
+	}
 	h.logger.Info("Updated sampling strategies:" + string(bytes))
 	return nil
 }
 
+// setFileStrategies replaces the strategies of the file, and parses them with the managed strategies.
+func (h *strategyStore) setFileStrategies(strategies *strategies) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := h.parseStrategies(combineStrategies(strategies, h.managedStrategies)); err != nil {
+		return err
+	}
+	h.fileStrategies = strategies
+	return nil
+}
+
 // TODO good candidate for a global util function
 func loadStrategies(loadFn strategyLoader) (*strategies, error) {
 	strategyBytes, err := loadFn()
@@ -196,52 +306,78 @@ func loadStrategies(loadFn strategyLoader) (*strategies, error) {
 	return strategies, nil
 }
 
-func (h *strategyStore) parseStrategies(strategies *strategies) {
+func (h *strategyStore) parseStrategies(strategies *strategies) error {
 	if strategies == nil {
 		h.logger.Info("No sampling strategies provided or URL is unavailable, using defaults")
-		return
+		return nil
 	}
 	newStore := defaultStrategies()
 	if strategies.DefaultStrategy != nil {
-		newStore.defaultStrategy = h.parseServiceStrategies(strategies.DefaultStrategy)
-	}
-
-	merge := true
-	if newStore.defaultStrategy.OperationSampling == nil ||
-		newStore.defaultStrategy.OperationSampling.PerOperationStrategies == nil {
-		merge = false
-	}
-
-	for _, s := range strategies.ServiceStrategies {
-		newStore.serviceStrategies[s.Service] = h.parseServiceStrategies(s)
-
-		// Merge with the default operation strategies, because only merging with
-		// the default strategy has no effect on service strategies (the default strategy
-		// is not merged with and only used as a fallback).
-		opS := newStore.serviceStrategies[s.Service].OperationSampling
-		if opS == nil {
-			if newStore.defaultStrategy.OperationSampling == nil ||
-				newStore.serviceStrategies[s.Service].ProbabilisticSampling == nil {
-				continue
-			}
-			// Service has no per-operation strategies, so just reference the default settings and change default samplingRate.
-			newOpS := *newStore.defaultStrategy.OperationSampling
-			newOpS.DefaultSamplingProbability = newStore.serviceStrategies[s.Service].ProbabilisticSampling.SamplingRate
-			newStore.serviceStrategies[s.Service].OperationSampling = &newOpS
-			continue
+		defaultStrategy, err := h.parseServiceStrategies(strategies.DefaultStrategy)
+		if err != nil {
+			return err
+		}
+		newStore.defaultStrategy = defaultStrategy
+	}
+	defaultOpS := newStore.defaultStrategy.strategy.OperationSampling
+
+	for i, s := range strategies.ServiceStrategies {
+		service, err := h.parseServiceStrategies(s)
+		if err != nil {
+			return err
+		}
+		if service.pattern, err = parsePattern(s.Service, i); err != nil {
+			return err
 		}
-		if merge {
-			opS.PerOperationStrategies = mergePerOperationSamplingStrategies(
-				opS.PerOperationStrategies,
-				newStore.defaultStrategy.OperationSampling.PerOperationStrategies)
+		if service.pattern == nil {
+			newStore.serviceStrategies[s.Service] = service
+		} else {
+			newStore.servicePatterns = append(newStore.servicePatterns, service)
 		}
+
+		mergeDefaultOperationStrategies(service, defaultOpS)
+		for _, client := range service.clientRules {
+			mergeDefaultOperationStrategies(client.serviceRule, defaultOpS)
+		}
+	}
+	sort.SliceStable(newStore.servicePatterns, func(i, j int) bool {
+		return newStore.servicePatterns[i].pattern.precedes(newStore.servicePatterns[j].pattern)
+	})
+	for _, issue := range validateStrategies(strategies) {
+		h.logger.Warn(issue)
 	}
 	h.storedStrategies.Store(newStore)
+	return nil
+}
+
+// mergeDefaultOperationStrategies merges the strategy of a service with the default operation strategies,
+// because only merging with the default strategy has no effect on service strategies (the default strategy
+// is not merged with and only used as a fallback).
+func mergeDefaultOperationStrategies(service *serviceRule, defaultOpS *sampling.PerOperationSamplingStrategies) {
+	opS := service.strategy.OperationSampling
+	if opS == nil {
+		if defaultOpS == nil || service.strategy.ProbabilisticSampling == nil {
+			return
+		}
+		// Service has no per-operation strategies, so just reference the default settings and change default samplingRate.
+		newOpS := *defaultOpS
+		newOpS.DefaultSamplingProbability = service.strategy.ProbabilisticSampling.SamplingRate
+		service.strategy.OperationSampling = &newOpS
+		return
+	}
+	if defaultOpS != nil && defaultOpS.PerOperationStrategies != nil {
+		opS.PerOperationStrategies = mergePerOperationSamplingStrategies(
+			opS.PerOperationStrategies,
+			defaultOpS.PerOperationStrategies,
+			service)
+	}
 }
 
 // mergePerOperationStrategies merges two operation strategies a and b, where a takes precedence over b.
+// The operations of b matching an operation pattern of the service get the strategy of the pattern.
 func mergePerOperationSamplingStrategies(
 	a, b []*sampling.OperationSamplingStrategy,
+	service *serviceRule,
 ) []*sampling.OperationSamplingStrategy {
 	m := make(map[string]bool)
 	for _, aOp := range a {
@@ -251,15 +387,36 @@ func mergePerOperationSamplingStrategies(
 		if m[bOp.Operation] {
 			continue
 		}
+		if s, ok := service.lookupOperation(bOp.Operation); ok {
+			bOp = s
+		}
 		a = append(a, bOp)
 	}
 	return a
 }
 
-func (h *strategyStore) parseServiceStrategies(strategy *serviceStrategy) *sampling.SamplingStrategyResponse {
-	resp := h.parseStrategy(&strategy.strategy)
+func (h *strategyStore) parseServiceStrategies(strategy *serviceStrategy) (*serviceRule, error) {
+	resp, err := h.parseStrategy(&strategy.strategy)
+	if err != nil {
+		return nil, err
+	}
+	service := &serviceRule{strategy: resp}
+	for _, clientStrategy := range strategy.ClientStrategies {
+		matcher, err := parseClientMatcher(clientStrategy.Match)
+		if err != nil {
+			return nil, err
+		}
+		client, err := h.parseServiceStrategies(&serviceStrategy{
+			OperationStrategies: clientStrategy.OperationStrategies,
+			strategy:            clientStrategy.strategy,
+		})
+		if err != nil {
+			return nil, err
+		}
+		service.clientRules = append(service.clientRules, &clientRule{matcher: matcher, serviceRule: client})
+	}
 	if len(strategy.OperationStrategies) == 0 {
-		return resp
+		return service, nil
 	}
 	opS := &sampling.PerOperationSamplingStrategies{
 		DefaultSamplingProbability: defaultSamplingProbability,
@@ -267,41 +424,55 @@ func (h *strategyStore) parseServiceStrategies(strategy *serviceStrategy) *sampl
 	if resp.StrategyType == sampling.SamplingStrategyType_PROBABILISTIC {
 		opS.DefaultSamplingProbability = resp.ProbabilisticSampling.SamplingRate
 	}
-	for _, operationStrategy := range strategy.OperationStrategies {
-		s, ok := h.parseOperationStrategy(operationStrategy, opS)
-		if !ok {
-			continue
+	for i, operationStrategy := range strategy.OperationStrategies {
+		operationPattern, err := parsePattern(operationStrategy.Operation, i)
+		if err != nil {
+			return nil, err
+		}
+		s, err := h.parseOperationStrategy(operationStrategy, opS)
+		if err != nil {
+			return nil, fmt.Errorf("operation %q: %w", operationStrategy.Operation, err)
 		}
 
-		opS.PerOperationStrategies = append(opS.PerOperationStrategies,
-			&sampling.OperationSamplingStrategy{
-				Operation:             operationStrategy.Operation,
-				ProbabilisticSampling: s.ProbabilisticSampling,
+		opStrategy := &sampling.OperationSamplingStrategy{
+			Operation:             operationStrategy.Operation,
+			ProbabilisticSampling: s.ProbabilisticSampling,
+			RateLimitingSampling:  s.RateLimitingSampling,
+		}
+		if operationPattern != nil {
+			service.operationPatterns = append(service.operationPatterns, &operationRule{
+				pattern:  operationPattern,
+				strategy: opStrategy,
 			})
+			continue
+		}
+		opS.PerOperationStrategies = append(opS.PerOperationStrategies, opStrategy)
 	}
+	sort.SliceStable(service.operationPatterns, func(i, j int) bool {
+		return service.operationPatterns[i].pattern.precedes(service.operationPatterns[j].pattern)
+	})
 	resp.OperationSampling = opS
-	return resp
+	return service, nil
 }
 
 func (h *strategyStore) parseOperationStrategy(
 	strategy *operationStrategy,
 	parent *sampling.PerOperationSamplingStrategies,
-) (s *sampling.SamplingStrategyResponse, ok bool) {
-	s = h.parseStrategy(&strategy.strategy)
+) (*sampling.SamplingStrategyResponse, error) {
+	s, err := h.parseStrategy(&strategy.strategy)
+	if err != nil {
+		return nil, err
+	}
 	if s.StrategyType == sampling.SamplingStrategyType_RATE_LIMITING {
-		// TODO OperationSamplingStrategy only supports probabilistic sampling
-		h.logger.Warn(
-			fmt.Sprintf(
-				"Operation strategies only supports probabilistic sampling at the moment,"+
-					"'%s' defaulting to probabilistic sampling with probability %f",
-				strategy.Operation, parent.DefaultSamplingProbability),
-			zap.Any("strategy", strategy))
-		return nil, false
-	}
-	return s, true
+		// clients not supporting per-operation rate limiting fall back to the probability of the service
+		s.ProbabilisticSampling = &sampling.ProbabilisticSamplingStrategy{
+			SamplingRate: parent.DefaultSamplingProbability,
+		}
+	}
+	return s, nil
 }
 
-func (h *strategyStore) parseStrategy(strategy *strategy) *sampling.SamplingStrategyResponse {
+func (h *strategyStore) parseStrategy(strategy *strategy) (*sampling.SamplingStrategyResponse, error) {
 	switch strategy.Type {
 	case samplerTypeProbabilistic:
 		return &sampling.SamplingStrategyResponse{
@@ -309,18 +480,32 @@ func (h *strategyStore) parseStrategy(strategy *strategy) *sampling.SamplingStra
 			ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{
 				SamplingRate: strategy.Param,
 			},
-		}
+		}, nil
 	case samplerTypeRateLimiting:
+		maxTracesPerSecond, err := parseRateLimit(strategy.Param)
+		if err != nil {
+			return nil, err
+		}
 		return &sampling.SamplingStrategyResponse{
 			StrategyType: sampling.SamplingStrategyType_RATE_LIMITING,
 			RateLimitingSampling: &sampling.RateLimitingSamplingStrategy{
-				MaxTracesPerSecond: int16(strategy.Param),
+				MaxTracesPerSecond: maxTracesPerSecond,
 			},
-		}
+		}, nil
 	default:
 		h.logger.Warn("Failed to parse sampling strategy", zap.Any("strategy", strategy))
-		return defaultStrategyResponse()
+		return defaultStrategyResponse(), nil
+	}
+}
+
+// parseRateLimit converts the param of a rate limiting strategy to the int16 of the sampling API,
+// rejecting the fractional rates it would truncate and the rates it would wrap around.
+func parseRateLimit(param float64) (int16, error) {
+	if param < 0 || param > math.MaxInt16 || param != math.Trunc(param) {
+		return 0, fmt.Errorf("rate limit must be a whole number between 0 and %d traces per second, got %v",
+			math.MaxInt16, param)
 	}
+	return int16(param), nil
 }
 
 func deepCopy(s *sampling.SamplingStrategyResponse) *sampling.SamplingStrategyResponse {
@@ -31,6 +31,7 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest/observer"
 
+	ss "github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
 	"github.com/jaegertracing/jaeger/pkg/testutils"
 	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
 )
@@ -150,11 +151,8 @@ func TestStrategyStoreWithURL(t *testing.T) {
 func TestPerOperationSamplingStrategies(t *testing.T) {
 	logger, buf := testutils.NewLogger()
 	store, err := NewStrategyStore(Options{StrategiesFile: "fixtures/operation_strategies.json"}, logger)
-	assert.Contains(t, buf.String(), "Operation strategies only supports probabilistic sampling at the moment,"+
-		"'op2' defaulting to probabilistic sampling with probability 0.8")
-	assert.Contains(t, buf.String(), "Operation strategies only supports probabilistic sampling at the moment,"+
-		"'op4' defaulting to probabilistic sampling with probability 0.001")
 	require.NoError(t, err)
+	assert.Empty(t, buf.String())
 
 	expected := makeResponse(sampling.SamplingStrategyType_PROBABILISTIC, 0.8)
 
@@ -166,16 +164,14 @@ func TestPerOperationSamplingStrategies(t *testing.T) {
 	require.NotNil(t, s.OperationSampling)
 	os := s.OperationSampling
 	assert.EqualValues(t, os.DefaultSamplingProbability, 0.8)
-	require.Len(t, os.PerOperationStrategies, 4)
-
-	assert.Equal(t, "op6", os.PerOperationStrategies[0].Operation)
-	assert.EqualValues(t, 0.5, os.PerOperationStrategies[0].ProbabilisticSampling.SamplingRate)
-	assert.Equal(t, "op1", os.PerOperationStrategies[1].Operation)
-	assert.EqualValues(t, 0.2, os.PerOperationStrategies[1].ProbabilisticSampling.SamplingRate)
-	assert.Equal(t, "op0", os.PerOperationStrategies[2].Operation)
-	assert.EqualValues(t, 0.2, os.PerOperationStrategies[2].ProbabilisticSampling.SamplingRate)
-	assert.Equal(t, "op7", os.PerOperationStrategies[3].Operation)
-	assert.EqualValues(t, 1, os.PerOperationStrategies[3].ProbabilisticSampling.SamplingRate)
+	assert.Equal(t, []*sampling.OperationSamplingStrategy{
+		probabilisticOperation("op6", 0.5),
+		probabilisticOperation("op1", 0.2),
+		rateLimitingOperation("op2", 0.8, 10),
+		probabilisticOperation("op0", 0.2),
+		rateLimitingOperation("spam", 0.5, 1),
+		probabilisticOperation("op7", 1),
+	}, os.PerOperationStrategies)
 
 	expected = makeResponse(sampling.SamplingStrategyType_RATE_LIMITING, 5)
 
@@ -187,17 +183,15 @@ func TestPerOperationSamplingStrategies(t *testing.T) {
 	require.NotNil(t, s.OperationSampling)
 	os = s.OperationSampling
 	assert.EqualValues(t, os.DefaultSamplingProbability, 0.001)
-	require.Len(t, os.PerOperationStrategies, 5)
-	assert.Equal(t, "op3", os.PerOperationStrategies[0].Operation)
-	assert.EqualValues(t, 0.3, os.PerOperationStrategies[0].ProbabilisticSampling.SamplingRate)
-	assert.Equal(t, "op5", os.PerOperationStrategies[1].Operation)
-	assert.EqualValues(t, 0.4, os.PerOperationStrategies[1].ProbabilisticSampling.SamplingRate)
-	assert.Equal(t, "op0", os.PerOperationStrategies[2].Operation)
-	assert.EqualValues(t, 0.2, os.PerOperationStrategies[2].ProbabilisticSampling.SamplingRate)
-	assert.Equal(t, "op6", os.PerOperationStrategies[3].Operation)
-	assert.EqualValues(t, 0, os.PerOperationStrategies[3].ProbabilisticSampling.SamplingRate)
-	assert.Equal(t, "op7", os.PerOperationStrategies[4].Operation)
-	assert.EqualValues(t, 1, os.PerOperationStrategies[4].ProbabilisticSampling.SamplingRate)
+	assert.Equal(t, []*sampling.OperationSamplingStrategy{
+		probabilisticOperation("op3", 0.3),
+		rateLimitingOperation("op4", 0.001, 100),
+		probabilisticOperation("op5", 0.4),
+		probabilisticOperation("op0", 0.2),
+		probabilisticOperation("op6", 0),
+		rateLimitingOperation("spam", 0.5, 1),
+		probabilisticOperation("op7", 1),
+	}, os.PerOperationStrategies)
 
 	s, err = store.GetSamplingStrategy(context.Background(), "default")
 	require.NoError(t, err)
@@ -205,29 +199,359 @@ func TestPerOperationSamplingStrategies(t *testing.T) {
 	expectedRsp.OperationSampling = &sampling.PerOperationSamplingStrategies{
 		DefaultSamplingProbability: 0.5,
 		PerOperationStrategies: []*sampling.OperationSamplingStrategy{
-			{
-				Operation: "op0",
-				ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{
-					SamplingRate: 0.2,
-				},
-			},
-			{
-				Operation: "op6",
-				ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{
-					SamplingRate: 0,
-				},
-			},
-			{
-				Operation: "op7",
-				ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{
-					SamplingRate: 1,
-				},
-			},
+			probabilisticOperation("op0", 0.2),
+			probabilisticOperation("op6", 0),
+			rateLimitingOperation("spam", 0.5, 1),
+			probabilisticOperation("op7", 1),
 		},
 	}
 	assert.EqualValues(t, expectedRsp, *s)
 }
 
+func probabilisticOperation(operation string, probability float64) *sampling.OperationSamplingStrategy {
+	return &sampling.OperationSamplingStrategy{
+		Operation: operation,
+		ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{
+			SamplingRate: probability,
+		},
+	}
+}
+
+func rateLimitingOperation(operation string, probability float64, maxTracesPerSecond int16) *sampling.OperationSamplingStrategy {
+	s := probabilisticOperation(operation, probability)
+	s.RateLimitingSampling = &sampling.RateLimitingSamplingStrategy{
+		MaxTracesPerSecond: maxTracesPerSecond,
+	}
+	return s
+}
+
+func TestRateLimitingSamplingStrategiesErrors(t *testing.T) {
+	s, err := NewStrategyStore(Options{}, zap.NewNop())
+	require.NoError(t, err)
+	store := s.(*strategyStore)
+
+	tests := []struct {
+		strategies string
+		err        string
+	}{
+		{
+			strategies: `{"default_strategy": {"type": "ratelimiting", "param": 2.5}}`,
+			err:        "rate limit must be a whole number between 0 and 32767 traces per second, got 2.5",
+		},
+		{
+			strategies: `{"service_strategies": [{"service": "foo", "type": "ratelimiting", "param": 40000}]}`,
+			err:        "rate limit must be a whole number between 0 and 32767 traces per second, got 40000",
+		},
+		{
+			strategies: `{"service_strategies": [{"service": "foo", "type": "probabilistic", "param": 0.5,
+				"operation_strategies": [{"operation": "op", "type": "ratelimiting", "param": 2.5}]}]}`,
+			err: `operation "op": rate limit must be a whole number between 0 and 32767 traces per second, got 2.5`,
+		},
+		{
+			strategies: `{"service_strategies": [{"service": "foo", "type": "probabilistic", "param": 0.5,
+				"operation_strategies": [{"operation": "op", "type": "ratelimiting", "param": 40000}]}]}`,
+			err: `operation "op": rate limit must be a whole number between 0 and 32767 traces per second, got 40000`,
+		},
+	}
+	for _, test := range tests {
+		assert.EqualError(t, store.updateSamplingStrategy([]byte(test.strategies)), test.err, test.strategies)
+	}
+	strategy, err := store.GetSamplingStrategy(context.Background(), "foo")
+	require.NoError(t, err)
+	assert.EqualValues(t, makeResponse(sampling.SamplingStrategyType_PROBABILISTIC, defaultSamplingProbability), *strategy)
+}
+
+func TestPatternSamplingStrategies(t *testing.T) {
+	logger, buf := testutils.NewLogger()
+	s, err := NewStrategyStore(Options{StrategiesFile: "fixtures/pattern_strategies.json"}, logger)
+	require.NoError(t, err)
+	assert.NotContains(t, buf.String(), "sampling strateg")
+	store := s.(*strategyStore)
+
+	tests := []struct {
+		service  string
+		expected *sampling.SamplingStrategyResponse
+		listed   bool
+	}{
+		{
+			service: "payments-us",
+			expected: withOperations(makeResponse(sampling.SamplingStrategyType_PROBABILISTIC, 0.2), 0.2,
+				probabilisticOperation("GET /health", 0.1)),
+			listed: true,
+		},
+		{
+			service: "payments-eu-central",
+			expected: withOperations(makeResponse(sampling.SamplingStrategyType_PROBABILISTIC, 0.3), 0.3,
+				probabilisticOperation("refund", 1), probabilisticOperation("GET /health", 0.05)),
+			listed: true,
+		},
+		{
+			service:  "payments-eu-west",
+			expected: withOperations(makeResponse(sampling.SamplingStrategyType_RATE_LIMITING, 10), 0),
+			listed:   true,
+		},
+		{
+			service: "invoicing-v2",
+			expected: withOperations(makeResponse(sampling.SamplingStrategyType_PROBABILISTIC, 0.4), 0.4,
+				probabilisticOperation("GET /health", 0.1)),
+			listed: true,
+		},
+		{
+			service: "invoicing-v2-beta",
+			expected: withOperations(makeResponse(sampling.SamplingStrategyType_PROBABILISTIC, 0.5), 0.5,
+				probabilisticOperation("GET /health", 0.1)),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.service, func(t *testing.T) {
+			strategy, err := store.GetSamplingStrategy(context.Background(), test.service)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, strategy)
+
+			strategy, listed := store.Lookup(context.Background(), test.service)
+			assert.Equal(t, test.expected, strategy)
+			assert.Equal(t, test.listed, listed)
+		})
+	}
+
+	operationTests := []struct {
+		service   string
+		operation string
+		expected  *sampling.OperationSamplingStrategy
+	}{
+		{service: "payments-eu-central", operation: "GET /health/ready", expected: probabilisticOperation("GET /health/ready", 0.05)},
+		{service: "payments-us", operation: "GET /health/ready", expected: probabilisticOperation("GET /health/ready", 0)},
+		{service: "unknown", operation: "GET /healthz", expected: probabilisticOperation("GET /healthz", 0)},
+		{service: "payments-us", operation: "POST /pay"},
+	}
+	for _, test := range operationTests {
+		t.Run(test.service+" "+test.operation, func(t *testing.T) {
+			strategy, ok := store.LookupOperation(context.Background(), test.service, test.operation)
+			assert.Equal(t, test.expected != nil, ok)
+			assert.Equal(t, test.expected, strategy)
+		})
+	}
+}
+
+func TestOperationPatternsNotSentToClients(t *testing.T) {
+	s, err := NewStrategyStore(Options{}, zap.NewNop())
+	require.NoError(t, err)
+	store := s.(*strategyStore)
+	require.NoError(t, store.updateSamplingStrategy([]byte(`{"service_strategies": [
+		{"service": "foo", "type": "probabilistic", "param": 0.5, "operation_strategies": [
+			{"operation": "glob:GET /health*", "type": "probabilistic", "param": 0},
+			{"operation": "POST /pay", "type": "probabilistic", "param": 1}
+		]}
+	]}`)))
+
+	// the clients only get the operations listed by name, the pattern is only used by LookupOperation
+	strategy, err := store.GetSamplingStrategy(context.Background(), "foo")
+	require.NoError(t, err)
+	assert.Equal(t, withOperations(makeResponse(sampling.SamplingStrategyType_PROBABILISTIC, 0.5), 0.5,
+		probabilisticOperation("POST /pay", 1)), strategy)
+	operation, ok := store.LookupOperation(context.Background(), "foo", "GET /health/live")
+	assert.True(t, ok)
+	assert.Equal(t, probabilisticOperation("GET /health/live", 0), operation)
+}
+
+func TestNamesWithWildcardsAreNotPatterns(t *testing.T) {
+	s, err := NewStrategyStore(Options{}, zap.NewNop())
+	require.NoError(t, err)
+	store := s.(*strategyStore)
+	require.NoError(t, store.updateSamplingStrategy([]byte(`{"service_strategies": [
+		{"service": "foo-*", "type": "probabilistic", "param": 0.2},
+		{"service": "bar?", "type": "probabilistic", "param": 0.3}
+	]}`)))
+
+	for service, probability := range map[string]float64{"foo-*": 0.2, "foo-bar": defaultSamplingProbability, "bar?": 0.3, "bar1": defaultSamplingProbability} {
+		strategy, err := store.GetSamplingStrategy(context.Background(), service)
+		require.NoError(t, err)
+		assert.EqualValues(t, makeResponse(sampling.SamplingStrategyType_PROBABILISTIC, probability), *strategy, service)
+	}
+}
+
+func TestPatternSamplingStrategiesErrors(t *testing.T) {
+	zapCore, logs := observer.New(zap.InfoLevel)
+	s, err := NewStrategyStore(Options{}, zap.New(zapCore))
+	require.NoError(t, err)
+	store := s.(*strategyStore)
+
+	err = store.updateSamplingStrategy([]byte(`{"service_strategies": [{"service": "regex:(", "type": "probabilistic", "param": 0.1}]}`))
+	assert.EqualError(t, err, "invalid regular expression \"regex:(\": error parsing regexp: missing closing ): `^(?:()$`")
+	err = store.updateSamplingStrategy([]byte(`{"default_strategy": {"type": "probabilistic", "param": 0.1,
+		"operation_strategies": [{"operation": "regex:[", "type": "probabilistic", "param": 0.1}]}}`))
+	assert.Error(t, err)
+
+	require.NoError(t, store.updateSamplingStrategy([]byte(`{"service_strategies": [
+		{"service": "foo", "type": "probabilistic", "param": 0.1},
+		{"service": "glob:foo-*", "type": "probabilistic", "param": 0.2},
+		{"service": "glob:*-bar", "type": "probabilistic", "param": 0.3},
+		{"service": "foo", "type": "probabilistic", "param": 0.4},
+		{"service": "glob:foo-*", "type": "probabilistic", "param": 0.5}
+	]}`)))
+	assert.Equal(t, []string{
+		`sampling strategy for service "foo" is listed more than once, the last one is used`,
+		`sampling strategy for service "glob:foo-*" is unreachable, the names it matches are matched by "glob:foo-*"`,
+		`sampling strategies for service "glob:*-bar" and "glob:foo-*" are ambiguous, "glob:foo-*" is used for the names matched by both`,
+	}, warnings(logs))
+	for service, probability := range map[string]float64{"foo": 0.4, "foo-bar": 0.5, "baz-bar": 0.3} {
+		strategy, err := store.GetSamplingStrategy(context.Background(), service)
+		require.NoError(t, err)
+		assert.EqualValues(t, makeResponse(sampling.SamplingStrategyType_PROBABILISTIC, probability), *strategy, service)
+	}
+}
+
+func TestClientSamplingStrategies(t *testing.T) {
+	logger, buf := testutils.NewLogger()
+	s, err := NewStrategyStore(Options{StrategiesFile: "fixtures/client_strategies.json"}, logger)
+	require.NoError(t, err)
+	assert.NotContains(t, buf.String(), "sampling strateg")
+	store := s.(*strategyStore)
+
+	health := probabilisticOperation("GET /health", 0.1)
+	tests := []struct {
+		name       string
+		service    string
+		attributes *ss.ClientAttributes
+		expected   *sampling.SamplingStrategyResponse
+		listed     bool
+	}{
+		{
+			name:     "no attributes",
+			service:  "checkout",
+			expected: withOperations(makeResponse(sampling.SamplingStrategyType_PROBABILISTIC, 0.2), 0.2, health),
+			listed:   true,
+		},
+		{
+			name:       "environment and tag",
+			service:    "checkout",
+			attributes: &ss.ClientAttributes{Environment: "canary", Tags: map[string]string{"region": "eu-west"}},
+			expected: withOperations(makeResponse(sampling.SamplingStrategyType_PROBABILISTIC, 1), 1,
+				rateLimitingOperation("pay", 1, 5), health),
+			listed: true,
+		},
+		{
+			name:       "missing tag",
+			service:    "checkout",
+			attributes: &ss.ClientAttributes{Environment: "canary"},
+			expected:   withOperations(makeResponse(sampling.SamplingStrategyType_PROBABILISTIC, 0.2), 0.2, health),
+			listed:     true,
+		},
+		{
+			name:    "first match",
+			service: "checkout",
+			attributes: &ss.ClientAttributes{
+				Environment: "canary",
+				Hostname:    "checkout-debug-1",
+				SDKVersion:  "Go-2.29.1",
+				Tags:        map[string]string{"region": "us-east"},
+			},
+			expected: withOperations(makeResponse(sampling.SamplingStrategyType_RATE_LIMITING, 10), 0),
+			listed:   true,
+		},
+		{
+			name:       "hostname",
+			service:    "checkout",
+			attributes: &ss.ClientAttributes{Hostname: "checkout-debug-1"},
+			expected:   withOperations(makeResponse(sampling.SamplingStrategyType_PROBABILISTIC, 0.9), 0.9, health),
+			listed:     true,
+		},
+		{
+			name:       "default client strategy",
+			service:    "unknown",
+			attributes: &ss.ClientAttributes{Environment: "dev"},
+			expected:   withOperations(makeResponse(sampling.SamplingStrategyType_PROBABILISTIC, 1), 0),
+			listed:     true,
+		},
+		{
+			name:       "default strategy",
+			service:    "unknown",
+			attributes: &ss.ClientAttributes{Environment: "prod"},
+			expected:   withOperations(makeResponse(sampling.SamplingStrategyType_PROBABILISTIC, 0.5), 0.5, health),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := ss.ContextWithClientAttributes(context.Background(), test.attributes)
+			strategy, err := store.GetSamplingStrategy(ctx, test.service)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, strategy)
+
+			strategy, listed := store.Lookup(ctx, test.service)
+			assert.Equal(t, test.expected, strategy)
+			assert.Equal(t, test.listed, listed)
+		})
+	}
+}
+
+func TestClientSamplingStrategiesErrors(t *testing.T) {
+	zapCore, logs := observer.New(zap.InfoLevel)
+	s, err := NewStrategyStore(Options{}, zap.New(zapCore))
+	require.NoError(t, err)
+	store := s.(*strategyStore)
+
+	err = store.updateSamplingStrategy([]byte(`{"service_strategies": [{"service": "foo", "type": "probabilistic", "param": 0.1,
+		"client_strategies": [{"match": {"tags": {"region": "regex:("}}, "type": "probabilistic", "param": 1}]}]}`))
+	assert.EqualError(t, err, "tag \"region\": invalid regular expression \"regex:(\": error parsing regexp: missing closing ): `^(?:()$`")
+	err = store.updateSamplingStrategy([]byte(`{"service_strategies": [{"service": "foo", "type": "probabilistic", "param": 0.1,
+		"client_strategies": [{"match": {"tags": {"canary": ""}}, "type": "probabilistic", "param": 1}]}]}`))
+	assert.EqualError(t, err, `tag "canary": the condition must not be empty`)
+
+	require.NoError(t, store.updateSamplingStrategy([]byte(`{"service_strategies": [{"service": "foo", "type": "probabilistic", "param": 0.1,
+		"client_strategies": [
+			{"match": {}, "type": "probabilistic", "param": 0.2,
+				"operation_strategies": [
+					{"operation": "op", "type": "probabilistic", "param": 0.3},
+					{"operation": "op", "type": "probabilistic", "param": 0.4}
+				]},
+			{"match": {"environment": "canary"}, "type": "probabilistic", "param": 1}
+		]}]}`)))
+	assert.Equal(t, []string{
+		`sampling strategy for operation "op" of client strategy 1 of service "foo" is listed more than once, the last one is used`,
+		`client strategy 1 of service "foo" matches all the clients, the following client strategies are unreachable`,
+	}, warnings(logs))
+	ctx := ss.ContextWithClientAttributes(context.Background(), &ss.ClientAttributes{Environment: "canary"})
+	strategy, err := store.GetSamplingStrategy(ctx, "foo")
+	require.NoError(t, err)
+	assert.EqualValues(t, 0.2, strategy.ProbabilisticSampling.SamplingRate)
+}
+
+func warnings(logs *observer.ObservedLogs) []string {
+	var messages []string
+	for _, entry := range logs.FilterLevelExact(zap.WarnLevel).All() {
+		messages = append(messages, entry.Message)
+	}
+	return messages
+}
+
+func withOperations(
+	strategy sampling.SamplingStrategyResponse,
+	defaultProbability float64,
+	operations ...*sampling.OperationSamplingStrategy,
+) *sampling.SamplingStrategyResponse {
+	if len(operations) > 0 {
+		strategy.OperationSampling = &sampling.PerOperationSamplingStrategies{
+			DefaultSamplingProbability: defaultProbability,
+			PerOperationStrategies:     operations,
+		}
+	}
+	return &strategy
+}
+
+func TestLookup(t *testing.T) {
+	store, err := NewStrategyStore(Options{StrategiesFile: "fixtures/operation_strategies.json"}, zap.NewNop())
+	require.NoError(t, err)
+	lookup := store.(*strategyStore)
+
+	strategy, listed := lookup.Lookup(context.Background(), "foo")
+	assert.True(t, listed)
+	assert.EqualValues(t, 0.8, strategy.ProbabilisticSampling.SamplingRate)
+
+	strategy, listed = lookup.Lookup(context.Background(), "unknown")
+	assert.False(t, listed)
+	assert.EqualValues(t, 0.5, strategy.ProbabilisticSampling.SamplingRate)
+}
+
 func TestMissingServiceSamplingStrategyTypes(t *testing.T) {
 	logger, buf := testutils.NewLogger()
 	store, err := NewStrategyStore(Options{StrategiesFile: "fixtures/missing-service-types.json"}, logger)
@@ -284,7 +608,7 @@ func TestParseStrategy(t *testing.T) {
 		{
 			strategy: serviceStrategy{
 				Service:  "svc",
-				strategy: strategy{Type: "ratelimiting", Param: 3.5},
+				strategy: strategy{Type: "ratelimiting", Param: 3},
 			},
 			expected: makeResponse(sampling.SamplingStrategyType_RATE_LIMITING, 3),
 		},
@@ -294,15 +618,18 @@ func TestParseStrategy(t *testing.T) {
 	for _, test := range tests {
 		tt := test
 		t.Run("", func(t *testing.T) {
-			assert.EqualValues(t, tt.expected, *store.parseStrategy(&tt.strategy.strategy))
+			actual, err := store.parseStrategy(&tt.strategy.strategy)
+			require.NoError(t, err)
+			assert.EqualValues(t, tt.expected, *actual)
 		})
 	}
 	assert.Empty(t, buf.String())
 
 	// Test nonexistent strategy type
-	actual := *store.parseStrategy(&strategy{Type: "blah", Param: 3.5})
+	actual, err := store.parseStrategy(&strategy{Type: "blah", Param: 3.5})
+	require.NoError(t, err)
 	expected := makeResponse(sampling.SamplingStrategyType_PROBABILISTIC, defaultSamplingProbability)
-	assert.EqualValues(t, expected, actual)
+	assert.EqualValues(t, expected, *actual)
 	assert.Contains(t, buf.String(), "Failed to parse sampling strategy")
 }
 
@@ -28,8 +28,10 @@ import (
 	"go.uber.org/zap"
 
 	depStore "github.com/jaegertracing/jaeger/plugin/storage/badger/dependencystore"
+	badgerSamplingStore "github.com/jaegertracing/jaeger/plugin/storage/badger/samplingstore"
 	badgerStore "github.com/jaegertracing/jaeger/plugin/storage/badger/spanstore"
 	"github.com/jaegertracing/jaeger/storage/dependencystore"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 )
 
@@ -164,6 +166,11 @@ func (f *Factory) CreateDependencyReader() (dependencystore.Reader, error) {
 	return depStore.NewDependencyStore(sr), nil
 }
 
+// CreateSamplingStrategiesStore implements storage.SamplingStrategiesFactory
+func (f *Factory) CreateSamplingStrategiesStore() (samplingstore.StrategiesStore, error) {
+	return badgerSamplingStore.NewStrategiesStore(f.store), nil
+}
+
 // Close Implements io.Closer and closes the underlying storage
 func (f *Factory) Close() error {
 	close(f.maintenanceDone)
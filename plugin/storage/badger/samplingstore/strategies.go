@@ -0,0 +1,184 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package samplingstore
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/dgraph-io/badger/v3"
+
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
+)
+
+const (
+	// The first bit of the keys is not set, unlike the span keys
+	strategyKeyPrefix byte = 0x01
+	changeKeyPrefix   byte = 0x02
+	// changeKeySeparator ends the service name in the keys of the changes,
+	// so that the changes of a service are not listed with the changes of the services it prefixes
+	changeKeySeparator byte = 0x00
+)
+
+// StrategiesStore handles all insertions and queries of the sampling strategies managed at runtime to and from Badger
+type StrategiesStore struct {
+	store *badger.DB
+}
+
+// NewStrategiesStore returns a StrategiesStore
+func NewStrategiesStore(db *badger.DB) *StrategiesStore {
+	return &StrategiesStore{
+		store: db,
+	}
+}
+
+// GetStrategies implements samplingstore.StrategiesStore#GetStrategies
+func (s *StrategiesStore) GetStrategies() ([]*samplingstore.Strategy, error) {
+	strategies := []*samplingstore.Strategy{}
+	err := s.store.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		prefix := []byte{strategyKeyPrefix}
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			var strategy samplingstore.Strategy
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &strategy)
+			}); err != nil {
+				return err
+			}
+			strategies = append(strategies, &strategy)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sampling strategies: %w", err)
+	}
+	return strategies, nil
+}
+
+// GetStrategy implements samplingstore.StrategiesStore#GetStrategy
+func (s *StrategiesStore) GetStrategy(service string) (*samplingstore.Strategy, error) {
+	var strategy *samplingstore.Strategy
+	err := s.store.View(func(txn *badger.Txn) error {
+		var err error
+		strategy, err = getStrategy(txn, service)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return strategy, nil
+}
+
+func getStrategy(txn *badger.Txn, service string) (*samplingstore.Strategy, error) {
+	item, err := txn.Get(strategyKey(service))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, samplingstore.ErrStrategyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the sampling strategy of %s: %w", service, err)
+	}
+	var strategy samplingstore.Strategy
+	if err := item.Value(func(val []byte) error {
+		return json.Unmarshal(val, &strategy)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to read the sampling strategy of %s: %w", service, err)
+	}
+	return &strategy, nil
+}
+
+// PutStrategy implements samplingstore.StrategiesStore#PutStrategy
+func (s *StrategiesStore) PutStrategy(change *samplingstore.StrategyChange) error {
+	strategy, err := json.Marshal(change.Strategy())
+	if err != nil {
+		return err
+	}
+	entry, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	err = s.store.Update(func(txn *badger.Txn) error {
+		var version int64
+		current, err := getStrategy(txn, change.Service)
+		switch {
+		case err == nil:
+			version = current.Version
+		case !errors.Is(err, samplingstore.ErrStrategyNotFound):
+			return err
+		}
+		if change.Version != version+1 {
+			return samplingstore.ErrVersionConflict
+		}
+		if err := txn.Set(strategyKey(change.Service), strategy); err != nil {
+			return err
+		}
+		return txn.Set(changeKey(change.Service, change.Version), entry)
+	})
+	// a concurrent transaction changed the strategy after it was read
+	if errors.Is(err, badger.ErrConflict) {
+		return samplingstore.ErrVersionConflict
+	}
+	return err
+}
+
+// GetStrategyChanges implements samplingstore.StrategiesStore#GetStrategyChanges
+func (s *StrategiesStore) GetStrategyChanges(service string, limit int) ([]*samplingstore.StrategyChange, error) {
+	changes := []*samplingstore.StrategyChange{}
+	err := s.store.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Reverse = true
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		prefix := changeKeyServicePrefix(service)
+		// in reverse, seek to the key following the last version
+		seek := append(append([]byte{}, prefix...), 0xFF)
+		for it.Seek(seek); it.ValidForPrefix(prefix) && (limit <= 0 || len(changes) < limit); it.Next() {
+			var change samplingstore.StrategyChange
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &change)
+			}); err != nil {
+				return err
+			}
+			changes = append(changes, &change)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the sampling strategy changes of %s: %w", service, err)
+	}
+	return changes, nil
+}
+
+// KEY: s<service>
+func strategyKey(service string) []byte {
+	return append([]byte{strategyKeyPrefix}, service...)
+}
+
+// KEY: c<service>0<version>
+func changeKey(service string, version int64) []byte {
+	key := changeKeyServicePrefix(service)
+	versionBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(versionBytes, uint64(version))
+	return append(key, versionBytes...)
+}
+
+func changeKeyServicePrefix(service string) []byte {
+	key := make([]byte, 0, len(service)+10)
+	key = append(key, changeKeyPrefix)
+	key = append(key, service...)
+	return append(key, changeKeySeparator)
+}
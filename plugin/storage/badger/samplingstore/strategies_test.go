@@ -0,0 +1,113 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package samplingstore
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
+)
+
+var _ samplingstore.StrategiesStore = new(StrategiesStore)
+
+func TestStrategiesStore(t *testing.T) {
+	runWithBadger(t, func(store *StrategiesStore, t *testing.T) {
+		now := time.Now().UTC()
+
+		_, err := store.GetStrategy("foo")
+		assert.Equal(t, samplingstore.ErrStrategyNotFound, err)
+
+		assert.Equal(t, samplingstore.ErrVersionConflict, store.PutStrategy(&samplingstore.StrategyChange{
+			Service: "foo", Version: 2, After: []byte(`{}`),
+		}))
+		first := &samplingstore.StrategyChange{
+			Service: "foo", Version: 1, Action: "put", Subject: "alice", Time: now,
+			After: []byte(`{"type": "probabilistic", "param": 0.5}`),
+		}
+		require.NoError(t, store.PutStrategy(first))
+		second := &samplingstore.StrategyChange{
+			Service: "foo", Version: 2, Action: "patch", Subject: "bob", Time: now.Add(time.Second),
+			Before: first.After,
+			After:  []byte(`{"type": "probabilistic", "param": 0.1}`),
+		}
+		require.NoError(t, store.PutStrategy(second))
+		assert.Equal(t, samplingstore.ErrVersionConflict, store.PutStrategy(second))
+		// foo prefixes foobar, their changes must not be mixed
+		foobar := &samplingstore.StrategyChange{
+			Service: "foobar", Version: 1, Action: "put", Subject: "alice", Time: now,
+			After: []byte(`{"type": "ratelimiting", "param": 5}`),
+		}
+		require.NoError(t, store.PutStrategy(foobar))
+
+		strategy, err := store.GetStrategy("foo")
+		require.NoError(t, err)
+		assert.Equal(t, second.Strategy(), strategy)
+
+		strategies, err := store.GetStrategies()
+		require.NoError(t, err)
+		assert.Equal(t, []*samplingstore.Strategy{second.Strategy(), foobar.Strategy()}, strategies)
+
+		changes, err := store.GetStrategyChanges("foo", 0)
+		require.NoError(t, err)
+		assert.Equal(t, []*samplingstore.StrategyChange{second, first}, changes)
+		changes, err = store.GetStrategyChanges("foo", 1)
+		require.NoError(t, err)
+		assert.Equal(t, []*samplingstore.StrategyChange{second}, changes)
+		changes, err = store.GetStrategyChanges("foobar", 0)
+		require.NoError(t, err)
+		assert.Equal(t, []*samplingstore.StrategyChange{foobar}, changes)
+		changes, err = store.GetStrategyChanges("fo", 0)
+		require.NoError(t, err)
+		assert.Empty(t, changes)
+	})
+}
+
+func TestStrategiesStoreClosed(t *testing.T) {
+	runWithBadger(t, func(store *StrategiesStore, t *testing.T) {
+		require.NoError(t, store.store.Close())
+		_, err := store.GetStrategies()
+		assert.Error(t, err)
+		_, err = store.GetStrategy("foo")
+		assert.Error(t, err)
+		assert.Error(t, store.PutStrategy(&samplingstore.StrategyChange{Service: "foo", Version: 1}))
+		_, err = store.GetStrategyChanges("foo", 0)
+		assert.Error(t, err)
+	})
+}
+
+func runWithBadger(t *testing.T, test func(store *StrategiesStore, t *testing.T)) {
+	opts := badger.DefaultOptions("")
+
+	opts.SyncWrites = false
+	dir, _ := ioutil.TempDir("", "badger")
+	opts.Dir = dir
+	opts.ValueDir = dir
+
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}()
+
+	test(NewStrategiesStore(db), t)
+}
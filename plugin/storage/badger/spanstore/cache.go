@@ -31,17 +31,30 @@ type CacheStore struct {
 	services   map[string]uint64
 	operations map[string]map[string]uint64
 
-	store *badger.DB
-	ttl   time.Duration
+	store   *badger.DB
+	ttl     time.Duration
+	prefill bool
+
+	// prefix is the key prefix of the tenant of the cache, the caches of the tenants are kept by the cache without a tenant
+	prefix      []byte
+	tenantsLock sync.Mutex
+	tenants     map[string]*CacheStore
 }
 
 // NewCacheStore returns initialized CacheStore for badger use
 func NewCacheStore(db *badger.DB, ttl time.Duration, prefill bool) *CacheStore {
+	return newCacheStore(db, ttl, prefill, nil)
+}
+
+func newCacheStore(db *badger.DB, ttl time.Duration, prefill bool, prefix []byte) *CacheStore {
 	cs := &CacheStore{
 		services:   make(map[string]uint64),
 		operations: make(map[string]map[string]uint64),
 		ttl:        ttl,
 		store:      db,
+		prefill:    prefill,
+		prefix:     prefix,
+		tenants:    make(map[string]*CacheStore),
 	}
 
 	if prefill {
@@ -50,6 +63,22 @@ func NewCacheStore(db *badger.DB, ttl time.Duration, prefill bool) *CacheStore {
 	return cs
 }
 
+// forTenant returns the cache of the services and operations of the tenant, which is
+// created, and prefilled from the store, on the first use of the tenant
+func (c *CacheStore) forTenant(tenant string) *CacheStore {
+	if tenant == "" {
+		return c
+	}
+	c.tenantsLock.Lock()
+	defer c.tenantsLock.Unlock()
+	cs, ok := c.tenants[tenant]
+	if !ok {
+		cs = newCacheStore(c.store, c.ttl, c.prefill, tenantPrefix(tenant))
+		c.tenants[tenant] = cs
+	}
+	return cs
+}
+
 func (c *CacheStore) populateCaches() {
 	c.cacheLock.Lock()
 	defer c.cacheLock.Unlock()
@@ -67,7 +96,9 @@ func (c *CacheStore) loadServices() {
 		it := txn.NewIterator(opts)
 		defer it.Close()
 
-		serviceKey := []byte{serviceNameIndexKey}
+		serviceKey := make([]byte, 0, len(c.prefix)+1)
+		serviceKey = append(serviceKey, c.prefix...)
+		serviceKey = append(serviceKey, serviceNameIndexKey)
 
 		// Seek all the services first
 		for it.Seek(serviceKey); it.ValidForPrefix(serviceKey); it.Next() {
@@ -91,9 +122,10 @@ func (c *CacheStore) loadOperations(service string) {
 		it := txn.NewIterator(opts)
 		defer it.Close()
 
-		serviceKey := make([]byte, len(service)+1)
-		serviceKey[0] = operationNameIndexKey
-		copy(serviceKey[1:], service)
+		serviceKey := make([]byte, 0, len(c.prefix)+len(service)+1)
+		serviceKey = append(serviceKey, c.prefix...)
+		serviceKey = append(serviceKey, operationNameIndexKey)
+		serviceKey = append(serviceKey, service...)
 
 		// Seek all the services first
 		for it.Seek(serviceKey); it.ValidForPrefix(serviceKey); it.Next() {
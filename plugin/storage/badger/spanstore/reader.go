@@ -27,6 +27,7 @@ import (
 	"github.com/dgraph-io/badger/v3"
 
 	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 )
 
@@ -72,6 +73,9 @@ type TraceReader struct {
 
 // executionPlan is internal structure to track the index filtering
 type executionPlan struct {
+	// prefix is the key prefix of the tenant of the query
+	prefix []byte
+
 	startTimeMin []byte
 	startTimeMax []byte
 
@@ -110,13 +114,13 @@ func decodeValue(val []byte, encodeType byte) (*model.Span, error) {
 }
 
 // getTraces enriches TraceIDs to Traces
-func (r *TraceReader) getTraces(traceIDs []model.TraceID) ([]*model.Trace, error) {
+func (r *TraceReader) getTraces(tenantPrefix []byte, traceIDs []model.TraceID) ([]*model.Trace, error) {
 	// Get by PK
 	traces := make([]*model.Trace, 0, len(traceIDs))
 	prefixes := make([][]byte, 0, len(traceIDs))
 
 	for _, traceID := range traceIDs {
-		prefixes = append(prefixes, createPrimaryKeySeekPrefix(traceID))
+		prefixes = append(prefixes, createPrimaryKeySeekPrefix(tenantPrefix, traceID))
 	}
 
 	err := r.store.View(func(txn *badger.Txn) error {
@@ -158,7 +162,7 @@ func (r *TraceReader) getTraces(traceIDs []model.TraceID) ([]*model.Trace, error
 
 // GetTrace takes a traceID and returns a Trace associated with that traceID
 func (r *TraceReader) GetTrace(ctx context.Context, traceID model.TraceID) (*model.Trace, error) {
-	traces, err := r.getTraces([]model.TraceID{traceID})
+	traces, err := r.getTraces(tenantPrefix(tenancy.GetTenant(ctx)), []model.TraceID{traceID})
 	if err != nil {
 		return nil, err
 	}
@@ -182,7 +186,9 @@ func (r *TraceReader) scanTimeRange(plan *executionPlan) ([]model.TraceID, error
 		it := txn.NewIterator(opts)
 		defer it.Close()
 
-		startIndex := []byte{spanKeyPrefix}
+		startIndex := make([]byte, 0, len(plan.prefix)+1)
+		startIndex = append(startIndex, plan.prefix...)
+		startIndex = append(startIndex, spanKeyPrefix)
 		prevTraceID := []byte{}
 		for it.Seek(startIndex); it.ValidForPrefix(startIndex); it.Next() {
 			item := it.Item()
@@ -190,8 +196,8 @@ func (r *TraceReader) scanTimeRange(plan *executionPlan) ([]model.TraceID, error
 			key := []byte{}
 			key = item.KeyCopy(key)
 
-			timestamp := key[sizeOfTraceID+1 : sizeOfTraceID+1+8]
-			traceID := key[1 : sizeOfTraceID+1]
+			timestamp := key[len(startIndex)+sizeOfTraceID : len(startIndex)+sizeOfTraceID+8]
+			traceID := key[len(startIndex) : len(startIndex)+sizeOfTraceID]
 
 			if bytes.Compare(timestamp, plan.startTimeMin) >= 0 && bytes.Compare(timestamp, plan.startTimeMax) <= 0 {
 				if !bytes.Equal(traceID, prevTraceID) {
@@ -211,9 +217,10 @@ func (r *TraceReader) scanTimeRange(plan *executionPlan) ([]model.TraceID, error
 		return nil
 	})
 
+	traceIDStart := len(plan.prefix) + 1
 	sort.Slice(traceKeys, func(k, h int) bool {
 		// This sorts by timestamp to descending order
-		return bytes.Compare(traceKeys[k][sizeOfTraceID+1:sizeOfTraceID+1+8], traceKeys[h][sizeOfTraceID+1:sizeOfTraceID+1+8]) > 0
+		return bytes.Compare(traceKeys[k][traceIDStart+sizeOfTraceID:traceIDStart+sizeOfTraceID+8], traceKeys[h][traceIDStart+sizeOfTraceID:traceIDStart+sizeOfTraceID+8]) > 0
 	})
 
 	sizeCount := len(traceKeys)
@@ -223,16 +230,17 @@ func (r *TraceReader) scanTimeRange(plan *executionPlan) ([]model.TraceID, error
 	traceIDs := make([]model.TraceID, sizeCount)
 
 	for i := 0; i < sizeCount; i++ {
-		traceIDs[i] = bytesToTraceID(traceKeys[i][1 : sizeOfTraceID+1])
+		traceIDs[i] = bytesToTraceID(traceKeys[i][traceIDStart : traceIDStart+sizeOfTraceID])
 	}
 
 	return traceIDs, err
 }
 
-func createPrimaryKeySeekPrefix(traceID model.TraceID) []byte {
-	key := make([]byte, 1+sizeOfTraceID)
-	key[0] = spanKeyPrefix
-	pos := 1
+func createPrimaryKeySeekPrefix(tenantPrefix []byte, traceID model.TraceID) []byte {
+	key := make([]byte, len(tenantPrefix)+1+sizeOfTraceID)
+	copy(key, tenantPrefix)
+	key[len(tenantPrefix)] = spanKeyPrefix
+	pos := len(tenantPrefix) + 1
 	binary.BigEndian.PutUint64(key[pos:], traceID.High)
 	pos += 8
 	binary.BigEndian.PutUint64(key[pos:], traceID.Low)
@@ -242,7 +250,7 @@ func createPrimaryKeySeekPrefix(traceID model.TraceID) []byte {
 
 // GetServices fetches the sorted service list that have not expired
 func (r *TraceReader) GetServices(ctx context.Context) ([]string, error) {
-	return r.cache.GetServices()
+	return r.cache.forTenant(tenancy.GetTenant(ctx)).GetServices()
 }
 
 // GetOperations fetches operations in the service and empty slice if service does not exists
@@ -250,7 +258,7 @@ func (r *TraceReader) GetOperations(
 	ctx context.Context,
 	query spanstore.OperationQueryParameters,
 ) ([]spanstore.Operation, error) {
-	return r.cache.GetOperations(query.ServiceName)
+	return r.cache.forTenant(tenancy.GetTenant(ctx)).GetOperations(query.ServiceName)
 }
 
 // setQueryDefaults alters the query with defaults if certain parameters are not set
@@ -261,13 +269,14 @@ func setQueryDefaults(query *spanstore.TraceQueryParameters) {
 }
 
 // serviceQueries parses the query to index seeks which are unique index seeks
-func serviceQueries(query *spanstore.TraceQueryParameters, indexSeeks [][]byte) [][]byte {
+func serviceQueries(tenantPrefix []byte, query *spanstore.TraceQueryParameters, indexSeeks [][]byte) [][]byte {
 	if query.ServiceName != "" {
 		indexSearchKey := make([]byte, 0, 64) // 64 is a magic guess
 		tagQueryUsed := false
 		for k, v := range query.Tags {
 			tagSearch := []byte(query.ServiceName + k + v)
-			tagSearchKey := make([]byte, 0, len(tagSearch)+1)
+			tagSearchKey := make([]byte, 0, len(tenantPrefix)+len(tagSearch)+1)
+			tagSearchKey = append(tagSearchKey, tenantPrefix...)
 			tagSearchKey = append(tagSearchKey, tagIndexKey)
 			tagSearchKey = append(tagSearchKey, tagSearch...)
 			indexSeeks = append(indexSeeks, tagSearchKey)
@@ -275,10 +284,12 @@ func serviceQueries(query *spanstore.TraceQueryParameters, indexSeeks [][]byte)
 		}
 
 		if query.OperationName != "" {
+			indexSearchKey = append(indexSearchKey, tenantPrefix...)
 			indexSearchKey = append(indexSearchKey, operationNameIndexKey)
 			indexSearchKey = append(indexSearchKey, []byte(query.ServiceName+query.OperationName)...)
 		} else {
 			if !tagQueryUsed { // Tag query already reduces the search set with a serviceName
+				indexSearchKey = append(indexSearchKey, tenantPrefix...)
 				indexSearchKey = append(indexSearchKey, serviceNameIndexKey)
 				indexSearchKey = append(indexSearchKey, []byte(query.ServiceName)...)
 			}
@@ -395,18 +406,21 @@ func (r *TraceReader) durationQueries(plan *executionPlan, query *spanstore.Trac
 	durMax := uint64(model.DurationAsMicroseconds(query.DurationMax))
 	durMin := uint64(model.DurationAsMicroseconds(query.DurationMin))
 
-	startKey := make([]byte, 1+8)
-	endKey := make([]byte, 1+8)
+	pos := len(plan.prefix) + 1
+	startKey := make([]byte, pos+8)
+	endKey := make([]byte, pos+8)
 
-	startKey[0] = durationIndexKey
-	endKey[0] = durationIndexKey
+	copy(startKey, plan.prefix)
+	copy(endKey, plan.prefix)
+	startKey[pos-1] = durationIndexKey
+	endKey[pos-1] = durationIndexKey
 
 	if query.DurationMax == 0 {
 		// Set MAX to infinite, if Min is missing, 0 is a fine search result for us
 		durMax = math.MaxUint64
 	}
-	binary.BigEndian.PutUint64(endKey[1:], durMax)
-	binary.BigEndian.PutUint64(startKey[1:], durMin)
+	binary.BigEndian.PutUint64(endKey[pos:], durMax)
+	binary.BigEndian.PutUint64(startKey[pos:], durMin)
 
 	// This is not unique index result - same TraceID can be matched from multiple spans
 	indexResults, _ := r.scanRangeIndex(plan, startKey, endKey)
@@ -463,7 +477,7 @@ func (r *TraceReader) FindTraces(ctx context.Context, query *spanstore.TraceQuer
 		return nil, err
 	}
 
-	return r.getTraces(keys)
+	return r.getTraces(tenantPrefix(tenancy.GetTenant(ctx)), keys)
 }
 
 // FindTraceIDs retrieves only the TraceIDs that match the traceQuery, but not the trace data
@@ -474,10 +488,11 @@ func (r *TraceReader) FindTraceIDs(ctx context.Context, query *spanstore.TraceQu
 	}
 
 	setQueryDefaults(query)
+	prefix := tenantPrefix(tenancy.GetTenant(ctx))
 
 	// Find matches using indexes that are using service as part of the key
 	indexSeeks := make([][]byte, 0, 1)
-	indexSeeks = serviceQueries(query, indexSeeks)
+	indexSeeks = serviceQueries(prefix, query, indexSeeks)
 
 	startStampBytes := make([]byte, 8)
 	binary.BigEndian.PutUint64(startStampBytes, model.TimeAsEpochMicroseconds(query.StartTimeMin))
@@ -486,6 +501,7 @@ func (r *TraceReader) FindTraceIDs(ctx context.Context, query *spanstore.TraceQu
 	binary.BigEndian.PutUint64(endStampBytes, model.TimeAsEpochMicroseconds(query.StartTimeMax))
 
 	plan := &executionPlan{
+		prefix:       prefix,
 		startTimeMin: startStampBytes,
 		startTimeMax: endStampBytes,
 		limit:        query.NumTraces,
@@ -570,16 +586,16 @@ func (r *TraceReader) scanIndexKeys(indexKeyValue []byte, plan *executionPlan) (
 // scanFunction compares the index name as well as the time range in the index key
 func scanFunction(it *badger.Iterator, indexPrefix []byte, timeBytesEnd []byte) bool {
 	if it.Valid() {
-		// We can't use the indexPrefix length, because we might have the same prefixValue for non-matching cases also
-		timestampStartIndex := len(it.Item().Key()) - (sizeOfTraceID + 8) // timestamp is stored with 8 bytes
-		timestamp := it.Item().Key()[timestampStartIndex : timestampStartIndex+8]
-		timestampInRange := bytes.Compare(timeBytesEnd, timestamp) <= 0
-
 		// Check length as well to prevent theoretical case where timestamp might match with wrong index key
 		if len(it.Item().Key()) != len(indexPrefix)+24 {
 			return false
 		}
 
+		// We can't use the indexPrefix length, because we might have the same prefixValue for non-matching cases also
+		timestampStartIndex := len(it.Item().Key()) - (sizeOfTraceID + 8) // timestamp is stored with 8 bytes
+		timestamp := it.Item().Key()[timestampStartIndex : timestampStartIndex+8]
+		timestampInRange := bytes.Compare(timeBytesEnd, timestamp) <= 0
+
 		return bytes.HasPrefix(it.Item().Key()[:timestampStartIndex], indexPrefix) && timestampInRange
 	}
 	return false
@@ -621,7 +637,11 @@ func (r *TraceReader) scanRangeIndex(plan *executionPlan, indexStartValue []byte
 // scanRangeFunction seeks until the index end has been reached
 func scanRangeFunction(it *badger.Iterator, indexEndValue []byte) bool {
 	if it.Valid() {
-		compareSlice := it.Item().Key()[:len(indexEndValue)]
+		compareSlice := it.Item().Key()
+		if len(compareSlice) > len(indexEndValue) {
+			// the keys of other tenants can be shorter than the index end
+			compareSlice = compareSlice[:len(indexEndValue)]
+		}
 		return bytes.Compare(indexEndValue, compareSlice) >= 0
 	}
 	return false
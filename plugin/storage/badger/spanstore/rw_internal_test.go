@@ -24,6 +24,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 )
 
@@ -71,7 +72,7 @@ func TestEncodingTypes(t *testing.T) {
 
 		startTime := model.TimeAsEpochMicroseconds(testSpan.StartTime)
 
-		key, _, _ := createTraceKV(&testSpan, protoEncoding, startTime)
+		key, _, _ := createTraceKV(nil, &testSpan, protoEncoding, startTime)
 		e := &badger.Entry{
 			Key:       key,
 			ExpiresAt: uint64(time.Now().Add(1 * time.Hour).Unix()),
@@ -129,6 +130,61 @@ func TestDuplicateTraceIDDetection(t *testing.T) {
 	})
 }
 
+func TestTenantPartitioning(t *testing.T) {
+	runWithBadger(t, func(store *badger.DB, t *testing.T) {
+		cache := NewCacheStore(store, time.Duration(1*time.Hour), true)
+		sw := NewSpanWriter(store, cache, time.Duration(1*time.Hour))
+		rw := NewTraceReader(store, cache)
+
+		tenants := []string{"", "acme", "acme-corp", "a"}
+		for i, tenant := range tenants {
+			testSpan := createDummySpan()
+			testSpan.TraceID.Low = uint64(i)
+			testSpan.Process.ServiceName = "service-" + tenant
+			err := sw.WriteSpan(tenancy.WithTenant(context.Background(), tenant), &testSpan)
+			assert.NoError(t, err)
+		}
+
+		for i, tenant := range tenants {
+			ctx := tenancy.WithTenant(context.Background(), tenant)
+			services, err := rw.GetServices(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"service-" + tenant}, services)
+
+			tr, err := rw.GetTrace(ctx, model.TraceID{Low: uint64(i), High: 1})
+			assert.NoError(t, err)
+			assert.Equal(t, 1, len(tr.Spans))
+			_, err = rw.GetTrace(ctx, model.TraceID{Low: uint64((i + 1) % len(tenants)), High: 1})
+			assert.Equal(t, spanstore.ErrTraceNotFound, err)
+
+			for _, query := range []*spanstore.TraceQueryParameters{
+				{},
+				{ServiceName: "service-" + tenant, OperationName: "operation"},
+				{ServiceName: "service-" + tenant, Tags: map[string]string{"key": "value"}},
+				{DurationMin: time.Millisecond},
+			} {
+				query.StartTimeMin = time.Now().Add(-1 * time.Hour)
+				query.StartTimeMax = time.Now().Add(time.Hour)
+				traces, err := rw.FindTraces(ctx, query)
+				assert.NoError(t, err)
+				if assert.Len(t, traces, 1) {
+					assert.Equal(t, model.TraceID{Low: uint64(i), High: 1}, traces[0].Spans[0].TraceID)
+				}
+			}
+		}
+
+		// the services of the tenants are loaded from the store by a new cache
+		cache = NewCacheStore(store, time.Duration(1*time.Hour), true)
+		rw = NewTraceReader(store, cache)
+		services, err := rw.GetServices(tenancy.WithTenant(context.Background(), "acme"))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"service-acme"}, services)
+		services, err = rw.GetServices(tenancy.WithTenant(context.Background(), "unknown"))
+		assert.NoError(t, err)
+		assert.Empty(t, services)
+	})
+}
+
 func createDummySpan() model.Span {
 	tid := time.Now()
 
@@ -25,6 +25,7 @@ import (
 	"github.com/gogo/protobuf/proto"
 
 	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 )
 
 /*
@@ -32,6 +33,9 @@ import (
 	That includes RocksDB also (this key structure should work as-is with RocksDB)
 
 	Keys are written in BigEndian order to allow lexicographic sorting of keys
+
+	The keys of the spans of a tenant are prefixed by tenantKeyPrefix<tenant>tenantKeySeparator, the keys
+	of the spans written without a tenant have no prefix.
 */
 
 const (
@@ -44,6 +48,8 @@ const (
 	jsonEncoding          byte = 0x01 // Last 4 bits of the meta byte are for encoding type
 	protoEncoding         byte = 0x02 // Last 4 bits of the meta byte are for encoding type
 	defaultEncoding       byte = protoEncoding
+	tenantKeyPrefix       byte = 0x70 // Below spanKeyPrefix, tenant keys are never scanned with the keys without a tenant
+	tenantKeySeparator    byte = 0x00 // Tenants do not contain this byte
 )
 
 // SpanWriter for writing spans to badger
@@ -68,37 +74,39 @@ func NewSpanWriter(db *badger.DB, c *CacheStore, ttl time.Duration) *SpanWriter
 func (w *SpanWriter) WriteSpan(ctx context.Context, span *model.Span) error {
 	expireTime := uint64(time.Now().Add(w.ttl).Unix())
 	startTime := model.TimeAsEpochMicroseconds(span.StartTime)
+	cache := w.cache.forTenant(tenancy.GetTenant(ctx))
+	prefix := cache.prefix
 
 	// Avoid doing as much as possible inside the transaction boundary, create entries here
 	entriesToStore := make([]*badger.Entry, 0, len(span.Tags)+4+len(span.Process.Tags)+len(span.Logs)*4)
 
-	trace, err := w.createTraceEntry(span, startTime, expireTime)
+	trace, err := w.createTraceEntry(prefix, span, startTime, expireTime)
 	if err != nil {
 		return err
 	}
 
 	entriesToStore = append(entriesToStore, trace)
-	entriesToStore = append(entriesToStore, w.createBadgerEntry(createIndexKey(serviceNameIndexKey, []byte(span.Process.ServiceName), startTime, span.TraceID), nil, expireTime))
-	entriesToStore = append(entriesToStore, w.createBadgerEntry(createIndexKey(operationNameIndexKey, []byte(span.Process.ServiceName+span.OperationName), startTime, span.TraceID), nil, expireTime))
+	entriesToStore = append(entriesToStore, w.createBadgerEntry(createIndexKey(prefix, serviceNameIndexKey, []byte(span.Process.ServiceName), startTime, span.TraceID), nil, expireTime))
+	entriesToStore = append(entriesToStore, w.createBadgerEntry(createIndexKey(prefix, operationNameIndexKey, []byte(span.Process.ServiceName+span.OperationName), startTime, span.TraceID), nil, expireTime))
 
 	// It doesn't matter if we overwrite Duration index keys, everything is read at Trace level in any case
 	durationValue := make([]byte, 8)
 	binary.BigEndian.PutUint64(durationValue, uint64(model.DurationAsMicroseconds(span.Duration)))
-	entriesToStore = append(entriesToStore, w.createBadgerEntry(createIndexKey(durationIndexKey, durationValue, startTime, span.TraceID), nil, expireTime))
+	entriesToStore = append(entriesToStore, w.createBadgerEntry(createIndexKey(prefix, durationIndexKey, durationValue, startTime, span.TraceID), nil, expireTime))
 
 	for _, kv := range span.Tags {
 		// Convert everything to string since queries are done that way also
 		// KEY: it<serviceName><tagsKey><traceId> VALUE: <tagsValue>
-		entriesToStore = append(entriesToStore, w.createBadgerEntry(createIndexKey(tagIndexKey, []byte(span.Process.ServiceName+kv.Key+kv.AsString()), startTime, span.TraceID), nil, expireTime))
+		entriesToStore = append(entriesToStore, w.createBadgerEntry(createIndexKey(prefix, tagIndexKey, []byte(span.Process.ServiceName+kv.Key+kv.AsString()), startTime, span.TraceID), nil, expireTime))
 	}
 
 	for _, kv := range span.Process.Tags {
-		entriesToStore = append(entriesToStore, w.createBadgerEntry(createIndexKey(tagIndexKey, []byte(span.Process.ServiceName+kv.Key+kv.AsString()), startTime, span.TraceID), nil, expireTime))
+		entriesToStore = append(entriesToStore, w.createBadgerEntry(createIndexKey(prefix, tagIndexKey, []byte(span.Process.ServiceName+kv.Key+kv.AsString()), startTime, span.TraceID), nil, expireTime))
 	}
 
 	for _, log := range span.Logs {
 		for _, kv := range log.Fields {
-			entriesToStore = append(entriesToStore, w.createBadgerEntry(createIndexKey(tagIndexKey, []byte(span.Process.ServiceName+kv.Key+kv.AsString()), startTime, span.TraceID), nil, expireTime))
+			entriesToStore = append(entriesToStore, w.createBadgerEntry(createIndexKey(prefix, tagIndexKey, []byte(span.Process.ServiceName+kv.Key+kv.AsString()), startTime, span.TraceID), nil, expireTime))
 		}
 	}
 
@@ -119,17 +127,29 @@ func (w *SpanWriter) WriteSpan(ctx context.Context, span *model.Span) error {
 	})
 
 	// Do cache refresh here to release the transaction earlier
-	w.cache.Update(span.Process.ServiceName, span.OperationName, expireTime)
+	cache.Update(span.Process.ServiceName, span.OperationName, expireTime)
 
 	return err
 }
 
-func createIndexKey(indexPrefixKey byte, value []byte, startTime uint64, traceID model.TraceID) []byte {
-	// KEY: indexKey<indexValue><startTime><traceId> (traceId is last 16 bytes of the key)
-	key := make([]byte, 1+len(value)+8+sizeOfTraceID)
-	key[0] = (indexPrefixKey & indexKeyRange) | spanKeyPrefix
-	pos := len(value) + 1
-	copy(key[1:pos], value)
+// tenantPrefix returns the prefix of the keys of the spans of the tenant
+func tenantPrefix(tenant string) []byte {
+	if tenant == "" {
+		return nil
+	}
+	prefix := make([]byte, 0, len(tenant)+2)
+	prefix = append(prefix, tenantKeyPrefix)
+	prefix = append(prefix, tenant...)
+	return append(prefix, tenantKeySeparator)
+}
+
+func createIndexKey(prefix []byte, indexPrefixKey byte, value []byte, startTime uint64, traceID model.TraceID) []byte {
+	// KEY: <prefix>indexKey<indexValue><startTime><traceId> (traceId is last 16 bytes of the key)
+	key := make([]byte, len(prefix)+1+len(value)+8+sizeOfTraceID)
+	copy(key, prefix)
+	key[len(prefix)] = (indexPrefixKey & indexKeyRange) | spanKeyPrefix
+	pos := len(prefix) + 1 + len(value)
+	copy(key[len(prefix)+1:pos], value)
 	binary.BigEndian.PutUint64(key[pos:], startTime)
 	pos += 8 // sizeOfTraceID / 2
 	binary.BigEndian.PutUint64(key[pos:], traceID.High)
@@ -146,8 +166,8 @@ func (w *SpanWriter) createBadgerEntry(key []byte, value []byte, expireTime uint
 	}
 }
 
-func (w *SpanWriter) createTraceEntry(span *model.Span, startTime, expireTime uint64) (*badger.Entry, error) {
-	pK, pV, err := createTraceKV(span, w.encodingType, startTime)
+func (w *SpanWriter) createTraceEntry(prefix []byte, span *model.Span, startTime, expireTime uint64) (*badger.Entry, error) {
+	pK, pV, err := createTraceKV(prefix, span, w.encodingType, startTime)
 	if err != nil {
 		return nil, err
 	}
@@ -158,15 +178,16 @@ func (w *SpanWriter) createTraceEntry(span *model.Span, startTime, expireTime ui
 	return e, nil
 }
 
-func createTraceKV(span *model.Span, encodingType byte, startTime uint64) ([]byte, []byte, error) {
+func createTraceKV(prefix []byte, span *model.Span, encodingType byte, startTime uint64) ([]byte, []byte, error) {
 	// TODO Add Hash for Zipkin compatibility?
 
 	// Note, KEY must include startTime for proper sorting order for span-ids
-	// KEY: ti<trace-id><startTime><span-id> VALUE: All the details (json for now) METADATA: Encoding
+	// KEY: <prefix>ti<trace-id><startTime><span-id> VALUE: All the details (json for now) METADATA: Encoding
 
-	key := make([]byte, 1+sizeOfTraceID+8+8)
-	key[0] = spanKeyPrefix
-	pos := 1
+	key := make([]byte, len(prefix)+1+sizeOfTraceID+8+8)
+	copy(key, prefix)
+	key[len(prefix)] = spanKeyPrefix
+	pos := len(prefix) + 1
 	binary.BigEndian.PutUint64(key[pos:], span.TraceID.High)
 	pos += 8
 	binary.BigEndian.PutUint64(key[pos:], span.TraceID.Low)
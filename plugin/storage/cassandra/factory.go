@@ -19,6 +19,7 @@ import (
 	"errors"
 	"flag"
 	"io"
+	"os"
 
 	"github.com/spf13/viper"
 	"github.com/uber/jaeger-lib/metrics"
@@ -26,11 +27,15 @@ import (
 
 	"github.com/jaegertracing/jaeger/pkg/cassandra"
 	"github.com/jaegertracing/jaeger/pkg/cassandra/config"
+	"github.com/jaegertracing/jaeger/pkg/distributedlock"
+	cLock "github.com/jaegertracing/jaeger/plugin/pkg/distributedlock/cassandra"
 	cDepStore "github.com/jaegertracing/jaeger/plugin/storage/cassandra/dependencystore"
+	cSamplingStore "github.com/jaegertracing/jaeger/plugin/storage/cassandra/samplingstore"
 	cSpanStore "github.com/jaegertracing/jaeger/plugin/storage/cassandra/spanstore"
 	"github.com/jaegertracing/jaeger/plugin/storage/cassandra/spanstore/dbmodel"
 	"github.com/jaegertracing/jaeger/storage"
 	"github.com/jaegertracing/jaeger/storage/dependencystore"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 )
 
@@ -51,6 +56,10 @@ type Factory struct {
 	primarySession cassandra.Session
 	archiveConfig  config.SessionBuilder
 	archiveSession cassandra.Session
+
+	// the spans and dependencies of each tenant are kept in their own keyspace
+	primaryTenants *tenantSessions
+	archiveTenants *tenantSessions
 }
 
 // NewFactory creates a new Factory.
@@ -94,10 +103,12 @@ func (f *Factory) Initialize(metricsFactory metrics.Factory, logger *zap.Logger)
 		return err
 	}
 	f.primarySession = primarySession
+	f.primaryTenants = newTenantSessions(primarySession, f.newTenantSession(f.Options.GetPrimary()))
 
 	if f.archiveConfig != nil {
 		if archiveSession, err := f.archiveConfig.NewSession(logger); err == nil {
 			f.archiveSession = archiveSession
+			f.archiveTenants = newTenantSessions(archiveSession, f.newTenantSession(f.Options.Get(archiveStorageConfig)))
 		} else {
 			return err
 		}
@@ -107,9 +118,20 @@ func (f *Factory) Initialize(metricsFactory metrics.Factory, logger *zap.Logger)
 	return nil
 }
 
+// newTenantSession returns the function creating the sessions of the keyspaces of the tenants
+func (f *Factory) newTenantSession(cfg *config.Configuration) func(tenant string) (cassandra.Session, error) {
+	return func(tenant string) (cassandra.Session, error) {
+		tenantConfig := *cfg
+		tenantConfig.Keyspace = TenantKeyspace(cfg.Keyspace, tenant)
+		return tenantConfig.NewSession(f.logger)
+	}
+}
+
 // CreateSpanReader implements storage.Factory
 func (f *Factory) CreateSpanReader() (spanstore.Reader, error) {
-	return cSpanStore.NewSpanReader(f.primarySession, f.primaryMetricsFactory, f.logger), nil
+	return newTenantSpanReader(f.primaryTenants, func(session cassandra.Session) spanstore.Reader {
+		return cSpanStore.NewSpanReader(session, f.primaryMetricsFactory, f.logger)
+	}), nil
 }
 
 // CreateSpanWriter implements storage.Factory
@@ -118,13 +140,43 @@ func (f *Factory) CreateSpanWriter() (spanstore.Writer, error) {
 	if err != nil {
 		return nil, err
 	}
-	return cSpanStore.NewSpanWriter(f.primarySession, f.Options.SpanStoreWriteCacheTTL, f.primaryMetricsFactory, f.logger, options...), nil
+	return newTenantSpanWriter(f.primaryTenants, func(session cassandra.Session) spanstore.Writer {
+		return cSpanStore.NewSpanWriter(session, f.Options.SpanStoreWriteCacheTTL, f.primaryMetricsFactory, f.logger, options...)
+	}), nil
 }
 
 // CreateDependencyReader implements storage.Factory
 func (f *Factory) CreateDependencyReader() (dependencystore.Reader, error) {
 	version := cDepStore.GetDependencyVersion(f.primarySession)
-	return cDepStore.NewDependencyStore(f.primarySession, f.primaryMetricsFactory, f.logger, version)
+	reader, err := cDepStore.NewDependencyStore(f.primarySession, f.primaryMetricsFactory, f.logger, version)
+	if err != nil {
+		return nil, err
+	}
+	return newTenantDependencyReader(f.primaryTenants, func(session cassandra.Session) (dependencystore.Reader, error) {
+		if session == f.primarySession {
+			return reader, nil
+		}
+		return cDepStore.NewDependencyStore(session, f.primaryMetricsFactory, f.logger, cDepStore.GetDependencyVersion(session))
+	}), nil
+}
+
+// CreateSamplingStrategiesStore implements storage.SamplingStrategiesFactory
+func (f *Factory) CreateSamplingStrategiesStore() (samplingstore.StrategiesStore, error) {
+	return cSamplingStore.NewStrategiesStore(f.primarySession, f.primaryMetricsFactory, f.logger), nil
+}
+
+// CreateSamplingStore implements storage.SamplingStoreFactory
+func (f *Factory) CreateSamplingStore() (samplingstore.Store, error) {
+	return cSamplingStore.New(f.primarySession, f.primaryMetricsFactory, f.logger), nil
+}
+
+// CreateLock implements storage.SamplingStoreFactory, the lock is owned by the hostname.
+func (f *Factory) CreateLock() (distributedlock.Lock, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	return cLock.NewLock(f.primarySession, hostname), nil
 }
 
 // CreateArchiveSpanReader implements storage.ArchiveFactory
@@ -132,7 +184,9 @@ func (f *Factory) CreateArchiveSpanReader() (spanstore.Reader, error) {
 	if f.archiveSession == nil {
 		return nil, storage.ErrArchiveStorageNotConfigured
 	}
-	return cSpanStore.NewSpanReader(f.archiveSession, f.archiveMetricsFactory, f.logger), nil
+	return newTenantSpanReader(f.archiveTenants, func(session cassandra.Session) spanstore.Reader {
+		return cSpanStore.NewSpanReader(session, f.archiveMetricsFactory, f.logger)
+	}), nil
 }
 
 // CreateArchiveSpanWriter implements storage.ArchiveFactory
@@ -144,7 +198,9 @@ func (f *Factory) CreateArchiveSpanWriter() (spanstore.Writer, error) {
 	if err != nil {
 		return nil, err
 	}
-	return cSpanStore.NewSpanWriter(f.archiveSession, f.Options.SpanStoreWriteCacheTTL, f.archiveMetricsFactory, f.logger, options...), nil
+	return newTenantSpanWriter(f.archiveTenants, func(session cassandra.Session) spanstore.Writer {
+		return cSpanStore.NewSpanWriter(session, f.Options.SpanStoreWriteCacheTTL, f.archiveMetricsFactory, f.logger, options...)
+	}), nil
 }
 
 func writerOptions(opts *Options) ([]cSpanStore.Option, error) {
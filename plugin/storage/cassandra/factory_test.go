@@ -33,6 +33,8 @@ import (
 
 var _ storage.Factory = new(Factory)
 var _ storage.ArchiveFactory = new(Factory)
+var _ storage.SamplingStrategiesFactory = new(Factory)
+var _ storage.SamplingStoreFactory = new(Factory)
 
 type mockSessionBuilder struct {
 	session *mocks.Session
@@ -85,6 +87,15 @@ func TestCassandraFactory(t *testing.T) {
 	_, err = f.CreateDependencyReader()
 	assert.NoError(t, err)
 
+	_, err = f.CreateSamplingStrategiesStore()
+	assert.NoError(t, err)
+
+	_, err = f.CreateSamplingStore()
+	assert.NoError(t, err)
+
+	_, err = f.CreateLock()
+	assert.NoError(t, err)
+
 	_, err = f.CreateArchiveSpanReader()
 	assert.EqualError(t, err, "archive storage not configured")
 
@@ -0,0 +1,158 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package samplingstore
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/pkg/cassandra"
+	casMetrics "github.com/jaegertracing/jaeger/pkg/cassandra/metrics"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
+)
+
+const (
+	strategyColumns = `service, strategy, updated_at, updated_by, version`
+	getStrategies   = `SELECT ` + strategyColumns + ` FROM sampling_strategies`
+	getStrategy     = `SELECT ` + strategyColumns + ` FROM sampling_strategies WHERE service = ?`
+	insertStrategy  = `INSERT INTO sampling_strategies(` + strategyColumns + `) VALUES (?, ?, ?, ?, ?) IF NOT EXISTS`
+	updateStrategy  = `UPDATE sampling_strategies SET strategy = ?, updated_at = ?, updated_by = ?, version = ?
+		WHERE service = ? IF version = ?`
+	insertChange = `INSERT INTO sampling_strategies_audit(service, version, action, subject, ts, before, after)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+	getChanges = `SELECT service, version, action, subject, ts, before, after FROM sampling_strategies_audit
+		WHERE service = ?`
+)
+
+// StrategiesStore handles all insertions and queries of the sampling strategies managed at runtime to and from Cassandra
+// The tables are created by the v004 schema, or by migration/V003toV004.sh for the keyspaces created with v003.
+type StrategiesStore struct {
+	session cassandra.Session
+	changes *casMetrics.Table
+	logger  *zap.Logger
+}
+
+// NewStrategiesStore creates a new cassandra store of sampling strategies.
+func NewStrategiesStore(session cassandra.Session, factory metrics.Factory, logger *zap.Logger) *StrategiesStore {
+	return &StrategiesStore{
+		session: session,
+		changes: casMetrics.NewTable(factory, "sampling_strategies_audit"),
+		logger:  logger,
+	}
+}
+
+// GetStrategies implements samplingstore.StrategiesStore#GetStrategies.
+func (s *StrategiesStore) GetStrategies() ([]*samplingstore.Strategy, error) {
+	iter := s.session.Query(getStrategies).Iter()
+	strategies := []*samplingstore.Strategy{}
+	for {
+		strategy := &samplingstore.Strategy{}
+		if !scanStrategy(iter, strategy) {
+			break
+		}
+		strategies = append(strategies, strategy)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("error reading sampling strategies from storage: %w", err)
+	}
+	return strategies, nil
+}
+
+// GetStrategy implements samplingstore.StrategiesStore#GetStrategy.
+func (s *StrategiesStore) GetStrategy(service string) (*samplingstore.Strategy, error) {
+	iter := s.session.Query(getStrategy, service).Iter()
+	strategy := &samplingstore.Strategy{}
+	found := scanStrategy(iter, strategy)
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("error reading the sampling strategy of %s from storage: %w", service, err)
+	}
+	if !found {
+		return nil, samplingstore.ErrStrategyNotFound
+	}
+	return strategy, nil
+}
+
+func scanStrategy(iter cassandra.Iterator, strategy *samplingstore.Strategy) bool {
+	var document string
+	if !iter.Scan(&strategy.Service, &document, &strategy.UpdatedAt, &strategy.UpdatedBy, &strategy.Version) {
+		return false
+	}
+	strategy.Document = []byte(document)
+	return true
+}
+
+// PutStrategy implements samplingstore.StrategiesStore#PutStrategy.
+// The strategy is written with a lightweight transaction, the change is written afterwards.
+func (s *StrategiesStore) PutStrategy(change *samplingstore.StrategyChange) error {
+	var applied bool
+	var err error
+	if change.Version == 1 {
+		var service, document, updatedBy string
+		var updatedAt time.Time
+		var version int64
+		applied, err = s.session.Query(insertStrategy,
+			change.Service, string(change.After), change.Time, change.Subject, change.Version,
+		).ScanCAS(&service, &document, &updatedAt, &updatedBy, &version)
+	} else {
+		var version int64
+		applied, err = s.session.Query(updateStrategy,
+			string(change.After), change.Time, change.Subject, change.Version, change.Service, change.Version-1,
+		).ScanCAS(&version)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write the sampling strategy of %s: %w", change.Service, err)
+	}
+	if !applied {
+		return samplingstore.ErrVersionConflict
+	}
+	var before interface{}
+	if change.Before != nil {
+		before = string(change.Before)
+	}
+	query := s.session.Query(insertChange,
+		change.Service, change.Version, change.Action, change.Subject, change.Time, before, string(change.After))
+	return s.changes.Exec(query, s.logger)
+}
+
+// GetStrategyChanges implements samplingstore.StrategiesStore#GetStrategyChanges.
+func (s *StrategiesStore) GetStrategyChanges(service string, limit int) ([]*samplingstore.StrategyChange, error) {
+	query := getChanges
+	args := []interface{}{service}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	iter := s.session.Query(query, args...).Iter()
+	changes := []*samplingstore.StrategyChange{}
+	for {
+		change := &samplingstore.StrategyChange{}
+		var before, after string
+		if !iter.Scan(&change.Service, &change.Version, &change.Action, &change.Subject, &change.Time, &before, &after) {
+			break
+		}
+		if before != "" {
+			change.Before = []byte(before)
+		}
+		change.After = []byte(after)
+		changes = append(changes, change)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("error reading the sampling strategy changes of %s from storage: %w", service, err)
+	}
+	return changes, nil
+}
@@ -0,0 +1,231 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package samplingstore
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+
+	"github.com/jaegertracing/jaeger/pkg/cassandra/mocks"
+	"github.com/jaegertracing/jaeger/pkg/testutils"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
+)
+
+var _ samplingstore.StrategiesStore = &StrategiesStore{} // check API conformance
+
+func withStrategiesStore(fn func(session *mocks.Session, store *StrategiesStore)) {
+	session := &mocks.Session{}
+	logger, _ := testutils.NewLogger()
+	fn(session, NewStrategiesStore(session, metricstest.NewFactory(0), logger))
+}
+
+// scanRows returns a matcher of Iterator#Scan filling the destinations with the values of the rows,
+// it matches once per row.
+func scanRows(rows ...[]interface{}) interface{} {
+	return mock.MatchedBy(func(args []interface{}) bool {
+		if len(rows) == 0 {
+			return false
+		}
+		for i, arg := range args {
+			switch ptr := arg.(type) {
+			case *string:
+				*ptr = rows[0][i].(string)
+			case *int64:
+				*ptr = rows[0][i].(int64)
+			case *time.Time:
+				*ptr = rows[0][i].(time.Time)
+			}
+		}
+		rows = rows[1:]
+		return true
+	})
+}
+
+func withIterator(session *mocks.Session, stmt string, closeErr error, rows ...[]interface{}) {
+	iter := &mocks.Iterator{}
+	iter.On("Scan", scanRows(rows...)).Return(true)
+	iter.On("Scan", matchEverything()).Return(false)
+	iter.On("Close").Return(closeErr)
+	query := &mocks.Query{}
+	query.On("Iter").Return(iter)
+	session.On("Query", stmt, matchEverything()).Return(query)
+}
+
+func TestGetStrategies(t *testing.T) {
+	now := time.Now()
+	withStrategiesStore(func(session *mocks.Session, store *StrategiesStore) {
+		withIterator(session, getStrategies, nil,
+			[]interface{}{"foo", `{"param": 0.5}`, now, "alice", int64(2)},
+			[]interface{}{"bar", `{"param": 0.1}`, now, "bob", int64(1)},
+		)
+		strategies, err := store.GetStrategies()
+		require.NoError(t, err)
+		assert.Equal(t, []*samplingstore.Strategy{
+			{Service: "foo", Document: []byte(`{"param": 0.5}`), Version: 2, UpdatedBy: "alice", UpdatedAt: now},
+			{Service: "bar", Document: []byte(`{"param": 0.1}`), Version: 1, UpdatedBy: "bob", UpdatedAt: now},
+		}, strategies)
+	})
+	withStrategiesStore(func(session *mocks.Session, store *StrategiesStore) {
+		withIterator(session, getStrategies, errors.New("query error"))
+		_, err := store.GetStrategies()
+		assert.EqualError(t, err, "error reading sampling strategies from storage: query error")
+	})
+}
+
+func TestGetStrategy(t *testing.T) {
+	now := time.Now()
+	withStrategiesStore(func(session *mocks.Session, store *StrategiesStore) {
+		withIterator(session, getStrategy, nil, []interface{}{"foo", `{"param": 0.5}`, now, "alice", int64(2)})
+		strategy, err := store.GetStrategy("foo")
+		require.NoError(t, err)
+		assert.Equal(t, &samplingstore.Strategy{
+			Service: "foo", Document: []byte(`{"param": 0.5}`), Version: 2, UpdatedBy: "alice", UpdatedAt: now,
+		}, strategy)
+	})
+	withStrategiesStore(func(session *mocks.Session, store *StrategiesStore) {
+		withIterator(session, getStrategy, nil)
+		_, err := store.GetStrategy("foo")
+		assert.Equal(t, samplingstore.ErrStrategyNotFound, err)
+	})
+	withStrategiesStore(func(session *mocks.Session, store *StrategiesStore) {
+		withIterator(session, getStrategy, errors.New("query error"))
+		_, err := store.GetStrategy("foo")
+		assert.EqualError(t, err, "error reading the sampling strategy of foo from storage: query error")
+	})
+}
+
+func TestPutStrategy(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		caption       string
+		change        *samplingstore.StrategyChange
+		stmt          string
+		args          []interface{}
+		applied       bool
+		scanError     error
+		execError     error
+		expectedError string
+	}{
+		{
+			caption: "create",
+			change: &samplingstore.StrategyChange{
+				Service: "foo", Version: 1, Action: "put", Subject: "alice", Time: now, After: []byte(`{"param": 0.5}`),
+			},
+			stmt:    insertStrategy,
+			args:    []interface{}{"foo", `{"param": 0.5}`, now, "alice", int64(1)},
+			applied: true,
+		},
+		{
+			caption: "update",
+			change: &samplingstore.StrategyChange{
+				Service: "foo", Version: 3, Action: "patch", Subject: "bob", Time: now,
+				Before: []byte(`{"param": 0.5}`), After: []byte(`{"param": 0.1}`),
+			},
+			stmt:    updateStrategy,
+			args:    []interface{}{`{"param": 0.1}`, now, "bob", int64(3), "foo", int64(2)},
+			applied: true,
+		},
+		{
+			caption: "conflict",
+			change: &samplingstore.StrategyChange{
+				Service: "foo", Version: 3, Action: "patch", Subject: "bob", Time: now, After: []byte(`{}`),
+			},
+			stmt:          updateStrategy,
+			args:          []interface{}{`{}`, now, "bob", int64(3), "foo", int64(2)},
+			expectedError: samplingstore.ErrVersionConflict.Error(),
+		},
+		{
+			caption: "strategy error",
+			change: &samplingstore.StrategyChange{
+				Service: "foo", Version: 1, Action: "put", Subject: "alice", Time: now, After: []byte(`{}`),
+			},
+			stmt:          insertStrategy,
+			args:          []interface{}{"foo", `{}`, now, "alice", int64(1)},
+			scanError:     errors.New("query error"),
+			expectedError: "failed to write the sampling strategy of foo: query error",
+		},
+		{
+			caption: "change error",
+			change: &samplingstore.StrategyChange{
+				Service: "foo", Version: 1, Action: "put", Subject: "alice", Time: now, After: []byte(`{}`),
+			},
+			stmt:          insertStrategy,
+			args:          []interface{}{"foo", `{}`, now, "alice", int64(1)},
+			applied:       true,
+			execError:     errors.New("query error"),
+			expectedError: "failed to Exec query 'insert change': query error",
+		},
+	}
+	for _, tc := range testCases {
+		testCase := tc // capture loop var
+		t.Run(testCase.caption, func(t *testing.T) {
+			withStrategiesStore(func(session *mocks.Session, store *StrategiesStore) {
+				strategyQuery := &mocks.Query{}
+				strategyQuery.On("ScanCAS", matchEverything()).Return(testCase.applied, testCase.scanError)
+				session.On("Query", testCase.stmt, testCase.args).Return(strategyQuery)
+
+				var before interface{}
+				if testCase.change.Before != nil {
+					before = string(testCase.change.Before)
+				}
+				changeQuery := &mocks.Query{}
+				changeQuery.On("Exec").Return(testCase.execError)
+				changeQuery.On("String").Return("insert change")
+				session.On("Query", insertChange, []interface{}{
+					testCase.change.Service, testCase.change.Version, testCase.change.Action, testCase.change.Subject,
+					testCase.change.Time, before, string(testCase.change.After),
+				}).Return(changeQuery)
+
+				err := store.PutStrategy(testCase.change)
+				if testCase.expectedError != "" {
+					assert.EqualError(t, err, testCase.expectedError)
+					return
+				}
+				require.NoError(t, err)
+				changeQuery.AssertCalled(t, "Exec")
+			})
+		})
+	}
+}
+
+func TestGetStrategyChanges(t *testing.T) {
+	now := time.Now()
+	withStrategiesStore(func(session *mocks.Session, store *StrategiesStore) {
+		withIterator(session, getChanges+` LIMIT ?`, nil,
+			[]interface{}{"foo", int64(2), "patch", "bob", now, `{"param": 0.5}`, `{"param": 0.1}`},
+			[]interface{}{"foo", int64(1), "put", "alice", now, "", `{"param": 0.5}`},
+		)
+		changes, err := store.GetStrategyChanges("foo", 2)
+		require.NoError(t, err)
+		assert.Equal(t, []*samplingstore.StrategyChange{
+			{
+				Service: "foo", Version: 2, Action: "patch", Subject: "bob", Time: now,
+				Before: []byte(`{"param": 0.5}`), After: []byte(`{"param": 0.1}`),
+			},
+			{Service: "foo", Version: 1, Action: "put", Subject: "alice", Time: now, After: []byte(`{"param": 0.5}`)},
+		}, changes)
+	})
+	withStrategiesStore(func(session *mocks.Session, store *StrategiesStore) {
+		withIterator(session, getChanges, errors.New("query error"))
+		_, err := store.GetStrategyChanges("foo", 0)
+		assert.EqualError(t, err, "error reading the sampling strategy changes of foo from storage: query error")
+	})
+}
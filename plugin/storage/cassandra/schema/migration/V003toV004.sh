@@ -0,0 +1,87 @@
+#!/usr/bin/env bash
+
+# Create the sampling_strategies and sampling_strategies_audit tables of the v004 schema,
+# which hold the sampling strategies managed through the collector admin API.
+# The existing tables are not modified and no data is copied.
+# Sample usage: KEYSPACE=jaeger_v1 CQL_CMD='cqlsh host 9042 -u test_user -p test_password --request-timeout=3000' bash
+# ./V003toV004.sh
+
+set -euo pipefail
+
+function usage {
+    >&2 echo "Error: $1"
+    >&2 echo ""
+    >&2 echo "Usage: KEYSPACE={keyspace} CQL_CMD={cql_cmd} $0"
+    >&2 echo ""
+    >&2 echo "The following parameters can be set via environment:"
+    >&2 echo "  KEYSPACE           - keyspace"
+    >&2 echo "  CQL_CMD            - cqlsh host port -u user -p password"
+    >&2 echo ""
+    exit 1
+}
+
+confirm() {
+    read -r -p "${1:-Continue? [y/N]} " response
+    case "$response" in
+        [yY][eE][sS]|[yY])
+            true
+            ;;
+        *)
+            exit 1
+            ;;
+    esac
+}
+
+if [[ ${KEYSPACE:-} == "" ]]; then
+   usage "missing KEYSPACE parameter"
+fi
+
+if [[ ${KEYSPACE} =~ [^a-zA-Z0-9_] ]]; then
+    usage "invalid characters in KEYSPACE=$KEYSPACE parameter, please use letters, digits or underscores"
+fi
+
+keyspace=${KEYSPACE}
+cqlsh_cmd=${CQL_CMD:-}
+
+if [[ ${cqlsh_cmd} == "" ]]; then
+   cqlsh_cmd=cqlsh
+fi
+
+echo "Using cql command: $cqlsh_cmd"
+
+echo "About to create tables $keyspace.sampling_strategies and $keyspace.sampling_strategies_audit..."
+
+confirm
+
+${cqlsh_cmd} -e "CREATE TABLE IF NOT EXISTS $keyspace.sampling_strategies (
+    service     text,
+    strategy    text,
+    updated_at  timestamp,
+    updated_by  text,
+    version     bigint,
+    PRIMARY KEY (service)
+) WITH compaction = {
+        'min_threshold': '4',
+        'max_threshold': '32',
+        'class': 'org.apache.cassandra.db.compaction.SizeTieredCompactionStrategy'
+    }
+    AND default_time_to_live = 0;"
+
+${cqlsh_cmd} -e "CREATE TABLE IF NOT EXISTS $keyspace.sampling_strategies_audit (
+    service     text,
+    version     bigint,
+    action      text,
+    subject     text,
+    ts          timestamp,
+    before      text,
+    after       text,
+    PRIMARY KEY (service, version)
+) WITH CLUSTERING ORDER BY (version DESC)
+    AND compaction = {
+        'min_threshold': '4',
+        'max_threshold': '32',
+        'class': 'org.apache.cassandra.db.compaction.SizeTieredCompactionStrategy'
+    }
+    AND default_time_to_live = 0;"
+
+echo "The tables of the v004 schema are successfully created!"
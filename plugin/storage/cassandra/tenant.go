@@ -0,0 +1,251 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandra
+
+import (
+	"context"
+	"encoding/hex"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/cassandra"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
+	"github.com/jaegertracing/jaeger/storage/dependencystore"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+// plainTenant matches the tenants that can be used verbatim in a keyspace name.
+var plainTenant = regexp.MustCompile(`^[a-z0-9]+$`)
+
+// TenantKeyspace returns the keyspace of the spans and dependencies of the tenant, which is created
+// with the schema script like the keyspace of the spans without a tenant. Tenants that cannot be used
+// verbatim in a keyspace name are hex encoded after a double underscore, which no plain tenant starts with.
+func TenantKeyspace(keyspace, tenant string) string {
+	if tenant == "" {
+		return keyspace
+	}
+	if !plainTenant.MatchString(tenant) {
+		return keyspace + "__" + hex.EncodeToString([]byte(tenant))
+	}
+	return keyspace + "_" + tenant
+}
+
+// tenantSessions creates the session of the keyspace of each tenant on the first use of the tenant.
+type tenantSessions struct {
+	newSession func(tenant string) (cassandra.Session, error)
+
+	lock     sync.Mutex
+	sessions map[string]cassandra.Session
+}
+
+func newTenantSessions(session cassandra.Session, newSession func(tenant string) (cassandra.Session, error)) *tenantSessions {
+	return &tenantSessions{
+		newSession: newSession,
+		sessions:   map[string]cassandra.Session{"": session},
+	}
+}
+
+func (s *tenantSessions) get(tenant string) (cassandra.Session, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if session, ok := s.sessions[tenant]; ok {
+		return session, nil
+	}
+	session, err := s.newSession(tenant)
+	if err != nil {
+		return nil, err
+	}
+	s.sessions[tenant] = session
+	return session, nil
+}
+
+// tenantSpanWriter writes the spans to the keyspace of their tenant.
+type tenantSpanWriter struct {
+	sessions  *tenantSessions
+	newWriter func(session cassandra.Session) spanstore.Writer
+
+	lock    sync.Mutex
+	writers map[string]spanstore.Writer
+}
+
+func newTenantSpanWriter(sessions *tenantSessions, newWriter func(session cassandra.Session) spanstore.Writer) *tenantSpanWriter {
+	return &tenantSpanWriter{
+		sessions:  sessions,
+		newWriter: newWriter,
+		writers:   make(map[string]spanstore.Writer),
+	}
+}
+
+func (w *tenantSpanWriter) writer(ctx context.Context) (spanstore.Writer, error) {
+	tenant := tenancy.GetTenant(ctx)
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if writer, ok := w.writers[tenant]; ok {
+		return writer, nil
+	}
+	session, err := w.sessions.get(tenant)
+	if err != nil {
+		return nil, err
+	}
+	writer := w.newWriter(session)
+	w.writers[tenant] = writer
+	return writer, nil
+}
+
+// WriteSpan implements spanstore.Writer
+func (w *tenantSpanWriter) WriteSpan(ctx context.Context, span *model.Span) error {
+	writer, err := w.writer(ctx)
+	if err != nil {
+		return err
+	}
+	return writer.WriteSpan(ctx, span)
+}
+
+// Close closes the writers of the tenants, and so their sessions
+func (w *tenantSpanWriter) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	for _, writer := range w.writers {
+		if closer, ok := writer.(interface{ Close() error }); ok {
+			closer.Close()
+		}
+	}
+	return nil
+}
+
+// tenantSpanReader reads the spans from the keyspace of the tenant of the query.
+type tenantSpanReader struct {
+	sessions  *tenantSessions
+	newReader func(session cassandra.Session) spanstore.Reader
+
+	lock    sync.Mutex
+	readers map[string]spanstore.Reader
+}
+
+func newTenantSpanReader(sessions *tenantSessions, newReader func(session cassandra.Session) spanstore.Reader) *tenantSpanReader {
+	return &tenantSpanReader{
+		sessions:  sessions,
+		newReader: newReader,
+		readers:   make(map[string]spanstore.Reader),
+	}
+}
+
+func (r *tenantSpanReader) reader(ctx context.Context) (spanstore.Reader, error) {
+	tenant := tenancy.GetTenant(ctx)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if reader, ok := r.readers[tenant]; ok {
+		return reader, nil
+	}
+	session, err := r.sessions.get(tenant)
+	if err != nil {
+		return nil, err
+	}
+	reader := r.newReader(session)
+	r.readers[tenant] = reader
+	return reader, nil
+}
+
+// GetTrace implements spanstore.Reader
+func (r *tenantSpanReader) GetTrace(ctx context.Context, traceID model.TraceID) (*model.Trace, error) {
+	reader, err := r.reader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return reader.GetTrace(ctx, traceID)
+}
+
+// GetServices implements spanstore.Reader
+func (r *tenantSpanReader) GetServices(ctx context.Context) ([]string, error) {
+	reader, err := r.reader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return reader.GetServices(ctx)
+}
+
+// GetOperations implements spanstore.Reader
+func (r *tenantSpanReader) GetOperations(ctx context.Context, query spanstore.OperationQueryParameters) ([]spanstore.Operation, error) {
+	reader, err := r.reader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return reader.GetOperations(ctx, query)
+}
+
+// FindTraces implements spanstore.Reader
+func (r *tenantSpanReader) FindTraces(ctx context.Context, query *spanstore.TraceQueryParameters) ([]*model.Trace, error) {
+	reader, err := r.reader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return reader.FindTraces(ctx, query)
+}
+
+// FindTraceIDs implements spanstore.Reader
+func (r *tenantSpanReader) FindTraceIDs(ctx context.Context, query *spanstore.TraceQueryParameters) ([]model.TraceID, error) {
+	reader, err := r.reader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return reader.FindTraceIDs(ctx, query)
+}
+
+// tenantDependencyReader reads the dependencies from the keyspace of the tenant of the query.
+type tenantDependencyReader struct {
+	sessions  *tenantSessions
+	newReader func(session cassandra.Session) (dependencystore.Reader, error)
+
+	lock    sync.Mutex
+	readers map[string]dependencystore.Reader
+}
+
+func newTenantDependencyReader(sessions *tenantSessions, newReader func(session cassandra.Session) (dependencystore.Reader, error)) *tenantDependencyReader {
+	return &tenantDependencyReader{
+		sessions:  sessions,
+		newReader: newReader,
+		readers:   make(map[string]dependencystore.Reader),
+	}
+}
+
+func (r *tenantDependencyReader) reader(ctx context.Context) (dependencystore.Reader, error) {
+	tenant := tenancy.GetTenant(ctx)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if reader, ok := r.readers[tenant]; ok {
+		return reader, nil
+	}
+	session, err := r.sessions.get(tenant)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := r.newReader(session)
+	if err != nil {
+		return nil, err
+	}
+	r.readers[tenant] = reader
+	return reader, nil
+}
+
+// GetDependencies implements dependencystore.Reader
+func (r *tenantDependencyReader) GetDependencies(ctx context.Context, endTs time.Time, lookback time.Duration) ([]model.DependencyLink, error) {
+	reader, err := r.reader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return reader.GetDependencies(ctx, endTs, lookback)
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandra
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/cassandra"
+	"github.com/jaegertracing/jaeger/pkg/cassandra/mocks"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
+	"github.com/jaegertracing/jaeger/storage/dependencystore"
+	depMocks "github.com/jaegertracing/jaeger/storage/dependencystore/mocks"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+	spanMocks "github.com/jaegertracing/jaeger/storage/spanstore/mocks"
+)
+
+func TestTenantKeyspace(t *testing.T) {
+	assert.Equal(t, "jaeger_v1", TenantKeyspace("jaeger_v1", ""))
+	assert.Equal(t, "jaeger_v1_acme", TenantKeyspace("jaeger_v1", "acme"))
+	assert.Equal(t, "jaeger_v1__61636d652d636f7270", TenantKeyspace("jaeger_v1", "acme-corp"))
+	assert.Equal(t, "jaeger_v1__615f62", TenantKeyspace("jaeger_v1", "a_b"))
+}
+
+func newTestTenantSessions(t *testing.T) (*tenantSessions, map[string]cassandra.Session) {
+	sessions := map[string]cassandra.Session{"": &mocks.Session{}}
+	return newTenantSessions(sessions[""], func(tenant string) (cassandra.Session, error) {
+		if tenant == "unknown" {
+			return nil, errors.New("keyspace does not exist")
+		}
+		_, ok := sessions[tenant]
+		require.False(t, ok, "the session of a tenant is created once")
+		sessions[tenant] = &mocks.Session{}
+		return sessions[tenant], nil
+	}), sessions
+}
+
+func TestTenantSpanWriter(t *testing.T) {
+	tenantSessions, sessions := newTestTenantSessions(t)
+	writers := map[cassandra.Session]*spanMocks.Writer{}
+	writer := newTenantSpanWriter(tenantSessions, func(session cassandra.Session) spanstore.Writer {
+		writers[session] = &spanMocks.Writer{}
+		writers[session].On("WriteSpan", mock.Anything, mock.Anything).Return(nil)
+		return writers[session]
+	})
+
+	span := &model.Span{}
+	for _, tenant := range []string{"", "acme", "acme", ""} {
+		assert.NoError(t, writer.WriteSpan(tenancy.WithTenant(context.Background(), tenant), span))
+	}
+	assert.Len(t, writers, 2)
+	writers[sessions[""]].AssertNumberOfCalls(t, "WriteSpan", 2)
+	writers[sessions["acme"]].AssertNumberOfCalls(t, "WriteSpan", 2)
+
+	assert.EqualError(t, writer.WriteSpan(tenancy.WithTenant(context.Background(), "unknown"), span), "keyspace does not exist")
+	assert.NoError(t, writer.Close())
+}
+
+func TestTenantSpanReader(t *testing.T) {
+	tenantSessions, sessions := newTestTenantSessions(t)
+	query := &spanstore.TraceQueryParameters{ServiceName: "service"}
+	readers := map[cassandra.Session]*spanMocks.Reader{}
+	reader := newTenantSpanReader(tenantSessions, func(session cassandra.Session) spanstore.Reader {
+		r := &spanMocks.Reader{}
+		r.On("GetTrace", mock.Anything, model.TraceID{Low: 1}).Return(&model.Trace{}, nil)
+		r.On("GetServices", mock.Anything).Return([]string{"service"}, nil)
+		r.On("GetOperations", mock.Anything, spanstore.OperationQueryParameters{ServiceName: "service"}).Return([]spanstore.Operation{{Name: "op"}}, nil)
+		r.On("FindTraces", mock.Anything, query).Return([]*model.Trace{{}}, nil)
+		r.On("FindTraceIDs", mock.Anything, query).Return([]model.TraceID{{Low: 1}}, nil)
+		readers[session] = r
+		return r
+	})
+
+	ctx := tenancy.WithTenant(context.Background(), "acme")
+	trace, err := reader.GetTrace(ctx, model.TraceID{Low: 1})
+	assert.NoError(t, err)
+	assert.NotNil(t, trace)
+	services, err := reader.GetServices(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"service"}, services)
+	operations, err := reader.GetOperations(ctx, spanstore.OperationQueryParameters{ServiceName: "service"})
+	assert.NoError(t, err)
+	assert.Equal(t, []spanstore.Operation{{Name: "op"}}, operations)
+	traces, err := reader.FindTraces(ctx, query)
+	assert.NoError(t, err)
+	assert.Len(t, traces, 1)
+	traceIDs, err := reader.FindTraceIDs(ctx, query)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.TraceID{{Low: 1}}, traceIDs)
+	require.Len(t, readers, 1)
+	readers[sessions["acme"]].AssertNumberOfCalls(t, "GetTrace", 1)
+
+	ctx = tenancy.WithTenant(context.Background(), "unknown")
+	_, err = reader.GetTrace(ctx, model.TraceID{Low: 1})
+	assert.EqualError(t, err, "keyspace does not exist")
+	_, err = reader.GetServices(ctx)
+	assert.EqualError(t, err, "keyspace does not exist")
+	_, err = reader.GetOperations(ctx, spanstore.OperationQueryParameters{})
+	assert.EqualError(t, err, "keyspace does not exist")
+	_, err = reader.FindTraces(ctx, query)
+	assert.EqualError(t, err, "keyspace does not exist")
+	_, err = reader.FindTraceIDs(ctx, query)
+	assert.EqualError(t, err, "keyspace does not exist")
+}
+
+func TestTenantDependencyReader(t *testing.T) {
+	tenantSessions, sessions := newTestTenantSessions(t)
+	endTs := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	readers := map[cassandra.Session]*depMocks.Reader{}
+	reader := newTenantDependencyReader(tenantSessions, func(session cassandra.Session) (dependencystore.Reader, error) {
+		if session == sessions[""] {
+			return nil, errors.New("made-up error")
+		}
+		r := &depMocks.Reader{}
+		r.On("GetDependencies", endTs, time.Hour).Return([]model.DependencyLink{{Parent: "a", Child: "b"}}, nil)
+		readers[session] = r
+		return r, nil
+	})
+
+	links, err := reader.GetDependencies(tenancy.WithTenant(context.Background(), "acme"), endTs, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.DependencyLink{{Parent: "a", Child: "b"}}, links)
+	assert.Len(t, readers, 1)
+
+	_, err = reader.GetDependencies(context.Background(), endTs, time.Hour)
+	assert.EqualError(t, err, "made-up error")
+	_, err = reader.GetDependencies(tenancy.WithTenant(context.Background(), "unknown"), endTs, time.Hour)
+	assert.EqualError(t, err, "keyspace does not exist")
+}
@@ -83,9 +83,9 @@ func (s *DependencyStore) writeDependencies(indexName string, ts time.Time, depe
 		}).Add()
 }
 
-// GetDependencies returns all interservice dependencies
+// GetDependencies returns all interservice dependencies of the tenant in the context
 func (s *DependencyStore) GetDependencies(ctx context.Context, endTs time.Time, lookback time.Duration) ([]model.DependencyLink, error) {
-	indices := getIndices(s.indexPrefix, s.indexDateLayout, endTs, lookback)
+	indices := getIndices(es.TenantIndexPrefix(ctx)+s.indexPrefix, s.indexDateLayout, endTs, lookback)
 	searchResult, err := s.client.Search(indices...).
 		Size(s.maxDocCount).
 		Query(buildTSQuery(endTs, lookback)).
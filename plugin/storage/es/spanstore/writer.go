@@ -147,8 +147,14 @@ func getSpanAndServiceIndexFn(archive, useReadWriteAliases bool, prefix, spanDat
 }
 
 // WriteSpan writes a span and its corresponding service:operation in ElasticSearch
-func (s *SpanWriter) WriteSpan(_ context.Context, span *model.Span) error {
+func (s *SpanWriter) WriteSpan(ctx context.Context, span *model.Span) error {
 	spanIndexName, serviceIndexName := s.spanServiceIndex(span.StartTime)
+	if tenantPrefix := es.TenantIndexPrefix(ctx); tenantPrefix != "" {
+		spanIndexName = tenantPrefix + spanIndexName
+		if serviceIndexName != "" {
+			serviceIndexName = tenantPrefix + serviceIndexName
+		}
+	}
 	jsonSpan := s.spanConverter.FromDomainEmbedProcess(span)
 	if serviceIndexName != "" {
 		s.writeService(serviceIndexName, jsonSpan)
@@ -30,6 +30,7 @@ import (
 
 	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/pkg/es/mocks"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/pkg/testutils"
 	"github.com/jaegertracing/jaeger/plugin/storage/es/spanstore/dbmodel"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
@@ -112,6 +113,7 @@ func TestClientClose(t *testing.T) {
 func TestSpanWriter_WriteSpan(t *testing.T) {
 	testCases := []struct {
 		caption            string
+		tenant             string
 		serviceIndexExists bool
 		expectedError      string
 		expectedLogs       []string
@@ -121,6 +123,13 @@ func TestSpanWriter_WriteSpan(t *testing.T) {
 
 			serviceIndexExists: false,
 
+			expectedError: "",
+			expectedLogs:  []string{},
+		},
+		{
+			caption: "span insertion for tenant",
+			tenant:  "acme",
+
 			expectedError: "",
 			expectedLogs:  []string{},
 		},
@@ -142,8 +151,12 @@ func TestSpanWriter_WriteSpan(t *testing.T) {
 					StartTime: date,
 				}
 
-				spanIndexName := "jaeger-span-1995-04-21"
-				serviceIndexName := "jaeger-service-1995-04-21"
+				tenantPrefix := ""
+				if testCase.tenant != "" {
+					tenantPrefix = testCase.tenant + "-"
+				}
+				spanIndexName := tenantPrefix + "jaeger-span-1995-04-21"
+				serviceIndexName := tenantPrefix + "jaeger-service-1995-04-21"
 				serviceHash := "de3b5a8f1a79989d"
 
 				indexService := &mocks.IndexService{}
@@ -166,7 +179,7 @@ func TestSpanWriter_WriteSpan(t *testing.T) {
 
 				w.client.On("Index").Return(indexService)
 
-				err = w.writer.WriteSpan(context.Background(), span)
+				err = w.writer.WriteSpan(tenancy.WithTenant(context.Background(), testCase.tenant), span)
 
 				if testCase.expectedError == "" {
 					require.NoError(t, err)
@@ -19,12 +19,16 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"sort"
 
 	"github.com/spf13/viper"
 	"github.com/uber/jaeger-lib/metrics"
 	"go.uber.org/zap"
 
+	"github.com/jaegertracing/jaeger/pkg/distributedlock"
+	"github.com/jaegertracing/jaeger/pkg/fswatcher"
 	"github.com/jaegertracing/jaeger/pkg/multierror"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/plugin"
 	"github.com/jaegertracing/jaeger/plugin/storage/badger"
 	"github.com/jaegertracing/jaeger/plugin/storage/cassandra"
@@ -34,6 +38,7 @@ import (
 	"github.com/jaegertracing/jaeger/plugin/storage/memory"
 	"github.com/jaegertracing/jaeger/storage"
 	"github.com/jaegertracing/jaeger/storage/dependencystore"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 )
 
@@ -46,6 +51,7 @@ const (
 	badgerStorageType        = "badger"
 	downsamplingRatio        = "downsampling.ratio"
 	downsamplingHashSalt     = "downsampling.hashsalt"
+	spanRoutingRulesFile     = "span-storage.routing-rules-file"
 	spanStorageType          = "span-storage-type"
 
 	// defaultDownsamplingRatio is the default downsampling ratio.
@@ -57,12 +63,23 @@ const (
 // AllStorageTypes defines all available storage backends
 var AllStorageTypes = []string{cassandraStorageType, elasticsearchStorageType, memoryStorageType, kafkaStorageType, badgerStorageType, grpcPluginStorageType}
 
+// tenantAwareStorageTypes are the storage backends that partition their data by tenant
+var tenantAwareStorageTypes = map[string]struct{}{
+	cassandraStorageType:     {},
+	elasticsearchStorageType: {},
+	memoryStorageType:        {},
+	badgerStorageType:        {},
+}
+
 // Factory implements storage.Factory interface as a meta-factory for storage components.
 type Factory struct {
 	FactoryConfig
 	metricsFactory         metrics.Factory
+	logger                 *zap.Logger
 	factories              map[string]storage.Factory
+	routingWatcher         *fswatcher.FileWatcher
 	downsamplingFlagsAdded bool
+	routingFlagsAdded      bool
 }
 
 // NewFactory creates the meta-factory.
@@ -105,9 +122,29 @@ func (f *Factory) getFactoryOfType(factoryType string) (storage.Factory, error)
 	}
 }
 
+// ValidateTenancy returns an error if multi-tenancy is enabled while one of the configured
+// storage backends does not partition its data by tenant, as the tenants would then share their traces.
+func (f *Factory) ValidateTenancy(options tenancy.Options) error {
+	if !options.Enabled {
+		return nil
+	}
+	storageTypes := make([]string, 0, len(f.factories))
+	for storageType := range f.factories {
+		storageTypes = append(storageTypes, storageType)
+	}
+	sort.Strings(storageTypes)
+	for _, storageType := range storageTypes {
+		if _, ok := tenantAwareStorageTypes[storageType]; !ok {
+			return fmt.Errorf("multi-tenancy is not supported by the %s storage", storageType)
+		}
+	}
+	return nil
+}
+
 // Initialize implements storage.Factory.
 func (f *Factory) Initialize(metricsFactory metrics.Factory, logger *zap.Logger) error {
 	f.metricsFactory = metricsFactory
+	f.logger = logger
 	for _, factory := range f.factories {
 		if err := factory.Initialize(metricsFactory, logger); err != nil {
 			return err
@@ -120,6 +157,9 @@ func (f *Factory) Initialize(metricsFactory metrics.Factory, logger *zap.Logger)
 
 // CreateSpanReader implements storage.Factory.
 func (f *Factory) CreateSpanReader() (spanstore.Reader, error) {
+	if f.SpanRoutingRulesFile != "" {
+		return f.createFanOutReader()
+	}
 	factory, ok := f.factories[f.SpanReaderType]
 	if !ok {
 		return nil, fmt.Errorf("no %s backend registered for span store", f.SpanReaderType)
@@ -130,6 +170,7 @@ func (f *Factory) CreateSpanReader() (spanstore.Reader, error) {
 // CreateSpanWriter implements storage.Factory.
 func (f *Factory) CreateSpanWriter() (spanstore.Writer, error) {
 	var writers []spanstore.Writer
+	writersByType := make(map[string]spanstore.Writer)
 	for _, storageType := range f.SpanWriterTypes {
 		factory, ok := f.factories[storageType]
 		if !ok {
@@ -140,11 +181,19 @@ func (f *Factory) CreateSpanWriter() (spanstore.Writer, error) {
 			return nil, err
 		}
 		writers = append(writers, writer)
+		writersByType[storageType] = writer
 	}
 	var spanWriter spanstore.Writer
-	if len(f.SpanWriterTypes) == 1 {
+	switch {
+	case f.SpanRoutingRulesFile != "":
+		routingWriter, err := f.createRoutingWriter(writersByType)
+		if err != nil {
+			return nil, err
+		}
+		spanWriter = routingWriter
+	case len(f.SpanWriterTypes) == 1:
 		spanWriter = writers[0]
-	} else {
+	default:
 		spanWriter = spanstore.NewCompositeWriter(writers...)
 	}
 	// Turn off DownsamplingWriter entirely if ratio == defaultDownsamplingRatio.
@@ -174,6 +223,7 @@ func (f *Factory) AddFlags(flagSet *flag.FlagSet) {
 			conf.AddFlags(flagSet)
 		}
 	}
+	f.addRoutingFlags(flagSet)
 }
 
 // AddPipelineFlags adds all the standard flags as well as the downsampling
@@ -184,6 +234,16 @@ func (f *Factory) AddPipelineFlags(flagSet *flag.FlagSet) {
 	f.addDownsamplingFlags(flagSet)
 }
 
+// addRoutingFlags adds flags for the routing of spans to the span storage types
+func (f *Factory) addRoutingFlags(flagSet *flag.FlagSet) {
+	f.routingFlagsAdded = true
+	flagSet.String(
+		spanRoutingRulesFile,
+		"",
+		"The path to a YAML or JSON file with rules routing the spans to one of the span storage types by service, process tag or tenant, instead of writing them to all span storage types. The file is reloaded when it changes. Spans are read from all the span storage types the rules route to.",
+	)
+}
+
 // addDownsamplingFlags add flags for Downsampling params
 func (f *Factory) addDownsamplingFlags(flagSet *flag.FlagSet) {
 	f.downsamplingFlagsAdded = true
@@ -207,6 +267,9 @@ func (f *Factory) InitFromViper(v *viper.Viper, logger *zap.Logger) {
 		}
 	}
 	f.initDownsamplingFromViper(v)
+	if f.routingFlagsAdded {
+		f.FactoryConfig.SpanRoutingRulesFile = v.GetString(spanRoutingRulesFile)
+	}
 }
 
 func (f *Factory) initDownsamplingFromViper(v *viper.Viper) {
@@ -252,11 +315,57 @@ func (f *Factory) CreateArchiveSpanWriter() (spanstore.Writer, error) {
 	return archive.CreateArchiveSpanWriter()
 }
 
+// CreateSamplingStrategiesStore implements storage.SamplingStrategiesFactory, the strategies are stored
+// in the first span storage type.
+func (f *Factory) CreateSamplingStrategiesStore() (samplingstore.StrategiesStore, error) {
+	factory, ok := f.factories[f.SpanWriterTypes[0]]
+	if !ok {
+		return nil, fmt.Errorf("no %s backend registered for span store", f.SpanWriterTypes[0])
+	}
+	strategies, ok := factory.(storage.SamplingStrategiesFactory)
+	if !ok {
+		return nil, storage.ErrSamplingStrategiesNotSupported
+	}
+	return strategies.CreateSamplingStrategiesStore()
+}
+
+// CreateSamplingStore implements storage.SamplingStoreFactory, the adaptive sampling data is stored
+// in the first span storage type.
+func (f *Factory) CreateSamplingStore() (samplingstore.Store, error) {
+	factory, ok := f.factories[f.SpanWriterTypes[0]]
+	if !ok {
+		return nil, fmt.Errorf("no %s backend registered for span store", f.SpanWriterTypes[0])
+	}
+	sampling, ok := factory.(storage.SamplingStoreFactory)
+	if !ok {
+		return nil, storage.ErrSamplingStoreNotSupported
+	}
+	return sampling.CreateSamplingStore()
+}
+
+// CreateLock implements storage.SamplingStoreFactory, the lock is stored in the first span storage type.
+func (f *Factory) CreateLock() (distributedlock.Lock, error) {
+	factory, ok := f.factories[f.SpanWriterTypes[0]]
+	if !ok {
+		return nil, fmt.Errorf("no %s backend registered for span store", f.SpanWriterTypes[0])
+	}
+	sampling, ok := factory.(storage.SamplingStoreFactory)
+	if !ok {
+		return nil, storage.ErrSamplingStoreNotSupported
+	}
+	return sampling.CreateLock()
+}
+
 var _ io.Closer = (*Factory)(nil)
 
 // Close closes the resources held by the factory
 func (f *Factory) Close() error {
 	var errs []error
+	if f.routingWatcher != nil {
+		if err := f.routingWatcher.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
 	for _, storageType := range f.SpanWriterTypes {
 		if factory, ok := f.factories[storageType]; ok {
 			if closer, ok := factory.(io.Closer); ok {
@@ -16,10 +16,13 @@
 package storage
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -27,23 +30,33 @@ import (
 
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/uber/jaeger-lib/metrics"
 	"github.com/uber/jaeger-lib/metrics/fork"
 	"github.com/uber/jaeger-lib/metrics/metricstest"
 	"go.uber.org/zap"
 
+	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/pkg/config"
+	"github.com/jaegertracing/jaeger/pkg/distributedlock"
+	distributedLockMocks "github.com/jaegertracing/jaeger/pkg/distributedlock/mocks"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
+	"github.com/jaegertracing/jaeger/plugin/storage/memory"
 	"github.com/jaegertracing/jaeger/storage"
 	"github.com/jaegertracing/jaeger/storage/dependencystore"
 	depStoreMocks "github.com/jaegertracing/jaeger/storage/dependencystore/mocks"
 	"github.com/jaegertracing/jaeger/storage/mocks"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
+	samplingStoreMocks "github.com/jaegertracing/jaeger/storage/samplingstore/mocks"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 	spanStoreMocks "github.com/jaegertracing/jaeger/storage/spanstore/mocks"
 )
 
 var _ storage.Factory = new(Factory)
 var _ storage.ArchiveFactory = new(Factory)
+var _ storage.SamplingStrategiesFactory = new(Factory)
+var _ storage.SamplingStoreFactory = new(Factory)
 
 func defaultCfg() FactoryConfig {
 	return FactoryConfig{
@@ -87,6 +100,33 @@ func TestNewFactory(t *testing.T) {
 	assert.NoError(t, f.Close())
 }
 
+func TestValidateTenancy(t *testing.T) {
+	f, err := NewFactory(FactoryConfig{
+		SpanWriterTypes:         []string{elasticsearchStorageType},
+		SpanReaderType:          elasticsearchStorageType,
+		DependenciesStorageType: memoryStorageType,
+	})
+	require.NoError(t, err)
+	assert.NoError(t, f.ValidateTenancy(tenancy.Options{Enabled: true}))
+
+	f, err = NewFactory(FactoryConfig{
+		SpanWriterTypes:         []string{cassandraStorageType, badgerStorageType},
+		SpanReaderType:          cassandraStorageType,
+		DependenciesStorageType: cassandraStorageType,
+	})
+	require.NoError(t, err)
+	assert.NoError(t, f.ValidateTenancy(tenancy.Options{Enabled: true}))
+
+	f, err = NewFactory(FactoryConfig{
+		SpanWriterTypes:         []string{elasticsearchStorageType, kafkaStorageType},
+		SpanReaderType:          elasticsearchStorageType,
+		DependenciesStorageType: cassandraStorageType,
+	})
+	require.NoError(t, err)
+	assert.NoError(t, f.ValidateTenancy(tenancy.Options{}))
+	assert.EqualError(t, f.ValidateTenancy(tenancy.Options{Enabled: true}), "multi-tenancy is not supported by the kafka storage")
+}
+
 func TestClose(t *testing.T) {
 	storageType := "foo"
 	err := fmt.Errorf("some error")
@@ -198,6 +238,183 @@ func TestCreateDownsamplingWriter(t *testing.T) {
 	}
 }
 
+func TestCreateRoutingWriter(t *testing.T) {
+	f, err := NewFactory(FactoryConfig{
+		SpanWriterTypes:         []string{cassandraStorageType, elasticsearchStorageType},
+		SpanReaderType:          cassandraStorageType,
+		DependenciesStorageType: cassandraStorageType,
+		DownsamplingRatio:       1.0,
+	})
+	require.NoError(t, err)
+	cassandraFactory, esFactory := new(mocks.Factory), new(mocks.Factory)
+	f.factories[cassandraStorageType] = cassandraFactory
+	f.factories[elasticsearchStorageType] = esFactory
+	cassandraWriter, esWriter := new(spanStoreMocks.Writer), new(spanStoreMocks.Writer)
+	cassandraFactory.On("CreateSpanWriter").Return(cassandraWriter, nil)
+	esFactory.On("CreateSpanWriter").Return(esWriter, nil)
+	f.metricsFactory = metrics.NullFactory
+	f.logger = zap.NewNop()
+	defer func() { require.NoError(t, f.routingWatcher.Close()) }()
+
+	dir := t.TempDir()
+	writeRules := func(content string) string {
+		file := filepath.Join(dir, "routing.json")
+		require.NoError(t, ioutil.WriteFile(file, []byte(content), 0600))
+		return file
+	}
+
+	f.SpanRoutingRulesFile = writeRules(`{"routes": [{"name": "pci", "services": ["payment-*"], "storage": "elasticsearch"}]}`)
+	w, err := f.CreateSpanWriter()
+	require.NoError(t, err)
+	require.IsType(t, &spanstore.RoutingWriter{}, w)
+
+	pciSpan := &model.Span{Process: &model.Process{ServiceName: "payment-api"}}
+	otherSpan := &model.Span{Process: &model.Process{ServiceName: "frontend"}}
+	esWriter.On("WriteSpan", mock.Anything, pciSpan).Return(nil).Once()
+	cassandraWriter.On("WriteSpan", mock.Anything, otherSpan).Return(nil).Once()
+	require.NoError(t, w.WriteSpan(context.Background(), pciSpan))
+	require.NoError(t, w.WriteSpan(context.Background(), otherSpan))
+	esWriter.AssertExpectations(t)
+	cassandraWriter.AssertExpectations(t)
+
+	tests := []struct {
+		rules string
+		err   string
+	}{
+		{rules: `{"routes": [{"name": "pci", "services": ["a"], "storage": "memory"}]}`, err: `span routing uses storage type "memory" which is not one of the span storage types [cassandra elasticsearch]`},
+		{rules: `{"default_storage": "kafka"}`, err: `span routing uses storage type "kafka" which is not one of the span storage types [cassandra elasticsearch]`},
+		{rules: `{"routes": [{"name": "pci", "storage": "cassandra"}]}`, err: "invalid span route 0: route pci has no services, tags or tenants to match"},
+		{rules: `{"routes": `, err: "failed to parse the span routing rules file"},
+	}
+	for _, test := range tests {
+		f.SpanRoutingRulesFile = writeRules(test.rules)
+		_, err := f.CreateSpanWriter()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), test.err)
+	}
+
+	f.SpanRoutingRulesFile = filepath.Join(dir, "missing.json")
+	_, err = f.CreateSpanWriter()
+	assert.Contains(t, err.Error(), "failed to read the span routing rules file")
+
+	f.SpanRoutingRulesFile = writeRules(``)
+	_, err = f.CreateSpanWriter()
+	assert.Contains(t, err.Error(), "is empty")
+}
+
+func TestRoutingWriterReload(t *testing.T) {
+	f, err := NewFactory(FactoryConfig{
+		SpanWriterTypes:         []string{cassandraStorageType, elasticsearchStorageType},
+		SpanReaderType:          cassandraStorageType,
+		DependenciesStorageType: cassandraStorageType,
+		DownsamplingRatio:       1.0,
+	})
+	require.NoError(t, err)
+	cassandraFactory, esFactory := new(mocks.Factory), new(mocks.Factory)
+	f.factories[cassandraStorageType] = cassandraFactory
+	f.factories[elasticsearchStorageType] = esFactory
+	cassandraWriter, esWriter := new(spanStoreMocks.Writer), new(spanStoreMocks.Writer)
+	cassandraFactory.On("CreateSpanWriter").Return(cassandraWriter, nil)
+	esFactory.On("CreateSpanWriter").Return(esWriter, nil)
+	cassandraWriter.On("WriteSpan", mock.Anything, mock.Anything).Return(nil)
+	esWriter.On("WriteSpan", mock.Anything, mock.Anything).Return(nil)
+	metricsFactory := metricstest.NewFactory(time.Hour)
+	f.metricsFactory = metricsFactory
+	f.logger = zap.NewNop()
+
+	f.SpanRoutingRulesFile = filepath.Join(t.TempDir(), "routing.yaml")
+	require.NoError(t, ioutil.WriteFile(f.SpanRoutingRulesFile, []byte(`
+routes:
+  - name: pci
+    tags:
+      compliance: pci
+    storage: elasticsearch
+`), 0600))
+	w, err := f.CreateSpanWriter()
+	require.NoError(t, err)
+	defer func() { require.NoError(t, f.Close()) }()
+
+	span := &model.Span{Process: &model.Process{ServiceName: "vault", Tags: model.KeyValues{model.String("compliance", "pci")}}}
+	require.NoError(t, w.WriteSpan(context.Background(), span))
+	esWriter.AssertNumberOfCalls(t, "WriteSpan", 1)
+
+	require.NoError(t, ioutil.WriteFile(f.SpanRoutingRulesFile, []byte("default_storage: elasticsearch\n"), 0600))
+	assert.Eventually(t, func() bool {
+		require.NoError(t, w.WriteSpan(context.Background(), &model.Span{Process: &model.Process{ServiceName: "frontend"}}))
+		return len(esWriter.Calls) > 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, ioutil.WriteFile(f.SpanRoutingRulesFile, []byte("routes: ["), 0600))
+	assert.Eventually(t, func() bool {
+		counters, _ := metricsFactory.Snapshot()
+		return counters["routing_writer.reloads|result=err"] > 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestCreateFanOutReader(t *testing.T) {
+	f, err := NewFactory(FactoryConfig{
+		SpanWriterTypes:         []string{cassandraStorageType, elasticsearchStorageType},
+		SpanReaderType:          cassandraStorageType,
+		DependenciesStorageType: cassandraStorageType,
+	})
+	require.NoError(t, err)
+	cassandraFactory, esFactory := new(mocks.Factory), new(mocks.Factory)
+	f.factories[cassandraStorageType] = cassandraFactory
+	f.factories[elasticsearchStorageType] = esFactory
+	cassandraReader, esReader := new(spanStoreMocks.Reader), new(spanStoreMocks.Reader)
+	cassandraFactory.On("CreateSpanReader").Return(cassandraReader, nil)
+	esFactory.On("CreateSpanReader").Return(esReader, nil)
+
+	dir := t.TempDir()
+	writeRules := func(content string) string {
+		file := filepath.Join(dir, "routing.json")
+		require.NoError(t, ioutil.WriteFile(file, []byte(content), 0600))
+		return file
+	}
+
+	// kafka is not a span storage type of this component, e.g. in the query service
+	f.SpanRoutingRulesFile = writeRules(`{"routes": [{"name": "pci", "services": ["payment-*"], "storage": "elasticsearch"}, {"name": "raw", "services": ["raw"], "storage": "kafka"}]}`)
+	r, err := f.CreateSpanReader()
+	require.NoError(t, err)
+	assert.Equal(t, spanstore.NewFanOutReader(cassandraReader, esReader), r)
+
+	f.SpanRoutingRulesFile = writeRules(`{"routes": [{"name": "pci", "services": ["payment-*"], "storage": "cassandra"}]}`)
+	r, err = f.CreateSpanReader()
+	require.NoError(t, err)
+	assert.Equal(t, cassandraReader, r)
+
+	f.SpanRoutingRulesFile = writeRules(`{"routes": `)
+	_, err = f.CreateSpanReader()
+	assert.Contains(t, err.Error(), "failed to parse the span routing rules file")
+
+	f.SpanRoutingRulesFile = writeRules(`{"default_storage": "elasticsearch"}`)
+	failingFactory := new(mocks.Factory)
+	failingFactory.On("CreateSpanReader").Return(nil, errors.New("reader error"))
+	f.factories[elasticsearchStorageType] = failingFactory
+	_, err = f.CreateSpanReader()
+	assert.EqualError(t, err, "reader error")
+
+	f.SpanReaderType = "memory"
+	f.SpanRoutingRulesFile = writeRules(`{"default_storage": "kafka"}`)
+	_, err = f.CreateSpanReader()
+	assert.EqualError(t, err, "no memory backend registered for span store")
+}
+
+func TestParsingSpanRoutingRulesFile(t *testing.T) {
+	f := Factory{}
+	v, command := config.Viperize(f.AddPipelineFlags)
+	require.NoError(t, command.ParseFlags([]string{"--span-storage.routing-rules-file=routing.json"}))
+	f.InitFromViper(v, zap.NewNop())
+	assert.Equal(t, "routing.json", f.SpanRoutingRulesFile)
+
+	// the query service reads from all the storage types of the rules
+	f = Factory{}
+	v, command = config.Viperize(f.AddFlags)
+	require.NoError(t, command.ParseFlags([]string{"--span-storage.routing-rules-file=routing.yaml"}))
+	f.InitFromViper(v, zap.NewNop())
+	assert.Equal(t, "routing.yaml", f.SpanRoutingRulesFile)
+}
+
 func TestCreateMulti(t *testing.T) {
 	cfg := defaultCfg()
 	cfg.SpanWriterTypes = append(cfg.SpanWriterTypes, elasticsearchStorageType)
@@ -256,6 +473,75 @@ func TestCreateArchive(t *testing.T) {
 	assert.EqualError(t, err, "archive-span-writer-error")
 }
 
+func TestCreateSamplingStrategiesStore(t *testing.T) {
+	f, err := NewFactory(defaultCfg())
+	require.NoError(t, err)
+	f.factories[cassandraStorageType] = &mocks.Factory{}
+	_, err = f.CreateSamplingStrategiesStore()
+	assert.Equal(t, storage.ErrSamplingStrategiesNotSupported, err)
+
+	memoryFactory := memory.NewFactory()
+	require.NoError(t, memoryFactory.Initialize(metrics.NullFactory, zap.NewNop()))
+	f.factories[cassandraStorageType] = memoryFactory
+	store, err := f.CreateSamplingStrategiesStore()
+	require.NoError(t, err)
+	assert.NotNil(t, store)
+
+	delete(f.factories, cassandraStorageType)
+	_, err = f.CreateSamplingStrategiesStore()
+	assert.EqualError(t, err, "no cassandra backend registered for span store")
+}
+
+type samplingStoreFactory struct {
+	mocks.Factory
+	store samplingstore.Store
+	lock  distributedlock.Lock
+}
+
+func (f *samplingStoreFactory) CreateSamplingStore() (samplingstore.Store, error) {
+	return f.store, nil
+}
+
+func (f *samplingStoreFactory) CreateLock() (distributedlock.Lock, error) {
+	return f.lock, nil
+}
+
+func TestCreateSamplingStore(t *testing.T) {
+	f, err := NewFactory(defaultCfg())
+	require.NoError(t, err)
+	f.factories[cassandraStorageType] = &mocks.Factory{}
+	_, err = f.CreateSamplingStore()
+	assert.Equal(t, storage.ErrSamplingStoreNotSupported, err)
+
+	samplingStore := &samplingStoreMocks.Store{}
+	f.factories[cassandraStorageType] = &samplingStoreFactory{store: samplingStore}
+	store, err := f.CreateSamplingStore()
+	require.NoError(t, err)
+	assert.Equal(t, samplingStore, store)
+
+	delete(f.factories, cassandraStorageType)
+	_, err = f.CreateSamplingStore()
+	assert.EqualError(t, err, "no cassandra backend registered for span store")
+}
+
+func TestCreateLock(t *testing.T) {
+	f, err := NewFactory(defaultCfg())
+	require.NoError(t, err)
+	f.factories[cassandraStorageType] = &mocks.Factory{}
+	_, err = f.CreateLock()
+	assert.Equal(t, storage.ErrSamplingStoreNotSupported, err)
+
+	distributedLock := &distributedLockMocks.Lock{}
+	f.factories[cassandraStorageType] = &samplingStoreFactory{lock: distributedLock}
+	lock, err := f.CreateLock()
+	require.NoError(t, err)
+	assert.Equal(t, distributedLock, lock)
+
+	delete(f.factories, cassandraStorageType)
+	_, err = f.CreateLock()
+	assert.EqualError(t, err, "no cassandra backend registered for span store")
+}
+
 func TestCreateError(t *testing.T) {
 	f, err := NewFactory(defaultCfg())
 	require.NoError(t, err)
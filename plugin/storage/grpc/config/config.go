@@ -92,6 +92,11 @@ func (c *Configuration) Build() (*ClientPluginServices, error) {
 		return nil, fmt.Errorf("unable to cast %T to shared.ArchiveStoragePlugin for plugin \"%s\"",
 			raw, shared.StoragePluginIdentifier)
 	}
+	lockPlugin, ok := raw.(shared.DistributedLockPlugin)
+	if !ok {
+		return nil, fmt.Errorf("unable to cast %T to shared.DistributedLockPlugin for plugin \"%s\"",
+			raw, shared.StoragePluginIdentifier)
+	}
 	capabilities, ok := raw.(shared.PluginCapabilities)
 	if !ok {
 		return nil, fmt.Errorf("unable to cast %T to shared.PluginCapabilities for plugin \"%s\"",
@@ -102,6 +107,7 @@ func (c *Configuration) Build() (*ClientPluginServices, error) {
 		PluginServices: shared.PluginServices{
 			Store:        storagePlugin,
 			ArchiveStore: archiveStoragePlugin,
+			LockStore:    lockPlugin,
 		},
 		Capabilities: capabilities,
 	}, nil
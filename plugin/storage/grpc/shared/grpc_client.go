@@ -25,15 +25,17 @@ import (
 	"google.golang.org/grpc/status"
 
 	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/distributedlock"
 	"github.com/jaegertracing/jaeger/proto-gen/storage_v1"
 	"github.com/jaegertracing/jaeger/storage/dependencystore"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 )
 
 var (
-	_ StoragePlugin        = (*grpcClient)(nil)
-	_ ArchiveStoragePlugin = (*grpcClient)(nil)
-	_ PluginCapabilities   = (*grpcClient)(nil)
+	_ StoragePlugin         = (*grpcClient)(nil)
+	_ ArchiveStoragePlugin  = (*grpcClient)(nil)
+	_ PluginCapabilities    = (*grpcClient)(nil)
+	_ DistributedLockPlugin = (*grpcClient)(nil)
 
 	// upgradeContext composites several steps of upgrading context
 	upgradeContext = composeContextUpgradeFuncs(upgradeContextWithBearerToken)
@@ -47,6 +49,7 @@ type grpcClient struct {
 	archiveWriterClient storage_v1.ArchiveSpanWriterPluginClient
 	capabilitiesClient  storage_v1.PluginCapabilitiesClient
 	depsReaderClient    storage_v1.DependenciesReaderPluginClient
+	lockClient          storage_v1.DistributedLockPluginClient
 }
 
 // ContextUpgradeFunc is a functional type that can be composed to upgrade context
@@ -102,6 +105,11 @@ func (c *grpcClient) ArchiveSpanWriter() spanstore.Writer {
 	return &archiveWriter{client: c.archiveWriterClient}
 }
 
+// DistributedLock implements shared.DistributedLockPlugin.
+func (c *grpcClient) DistributedLock(owner string) distributedlock.Lock {
+	return &lock{client: c.lockClient, owner: owner}
+}
+
 // GetTrace takes a traceID and returns a Trace associated with that traceID
 func (c *grpcClient) GetTrace(ctx context.Context, traceID model.TraceID) (*model.Trace, error) {
 	stream, err := c.readerClient.GetTrace(upgradeContext(ctx), &storage_v1.GetTraceRequest{
@@ -254,6 +262,7 @@ func (c *grpcClient) Capabilities() (*Capabilities, error) {
 	return &Capabilities{
 		ArchiveSpanReader: capabilities.ArchiveSpanReader,
 		ArchiveSpanWriter: capabilities.ArchiveSpanWriter,
+		DistributedLock:   capabilities.DistributedLock,
 	}, nil
 }
 
@@ -408,13 +408,14 @@ func TestGrpcClientGetArchiveTrace_StreamErrorTraceNotFound(t *testing.T) {
 func TestGrpcClientCapabilities(t *testing.T) {
 	withGRPCClient(func(r *grpcClientTest) {
 		r.capabilities.On("Capabilities", mock.Anything, &storage_v1.CapabilitiesRequest{}).
-			Return(&storage_v1.CapabilitiesResponse{ArchiveSpanReader: true, ArchiveSpanWriter: true}, nil)
+			Return(&storage_v1.CapabilitiesResponse{ArchiveSpanReader: true, ArchiveSpanWriter: true, DistributedLock: true}, nil)
 
 		capabilities, err := r.client.Capabilities()
 		assert.NoError(t, err)
 		assert.Equal(t, &Capabilities{
 			ArchiveSpanReader: true,
 			ArchiveSpanWriter: true,
+			DistributedLock:   true,
 		}, capabilities)
 	})
 }
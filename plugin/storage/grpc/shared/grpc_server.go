@@ -32,6 +32,7 @@ const spanBatchSize = 1000
 type grpcServer struct {
 	Impl        StoragePlugin
 	ArchiveImpl ArchiveStoragePlugin
+	LockImpl    DistributedLockPlugin
 }
 
 // GetDependencies returns all interservice dependencies
@@ -172,6 +173,7 @@ func (s *grpcServer) Capabilities(ctx context.Context, request *storage_v1.Capab
 	return &storage_v1.CapabilitiesResponse{
 		ArchiveSpanReader: s.ArchiveImpl != nil,
 		ArchiveSpanWriter: s.ArchiveImpl != nil,
+		DistributedLock:   s.LockImpl != nil,
 	}, nil
 }
 
@@ -205,3 +207,31 @@ func (s *grpcServer) WriteArchiveSpan(ctx context.Context, r *storage_v1.WriteSp
 	}
 	return &storage_v1.WriteSpanResponse{}, nil
 }
+
+// AcquireLock acquires a lease around a resource on behalf of the owner of the request
+func (s *grpcServer) AcquireLock(ctx context.Context, r *storage_v1.AcquireLockRequest) (*storage_v1.AcquireLockResponse, error) {
+	if s.LockImpl == nil {
+		return nil, status.Error(codes.Unimplemented, "not implemented")
+	}
+	acquired, err := s.LockImpl.DistributedLock(r.Owner).Acquire(r.Resource, r.Ttl)
+	if err != nil {
+		return nil, err
+	}
+	return &storage_v1.AcquireLockResponse{
+		Acquired: acquired,
+	}, nil
+}
+
+// ForfeitLock forfeits a lease around a resource on behalf of the owner of the request
+func (s *grpcServer) ForfeitLock(ctx context.Context, r *storage_v1.ForfeitLockRequest) (*storage_v1.ForfeitLockResponse, error) {
+	if s.LockImpl == nil {
+		return nil, status.Error(codes.Unimplemented, "not implemented")
+	}
+	forfeited, err := s.LockImpl.DistributedLock(r.Owner).Forfeit(r.Resource)
+	if err != nil {
+		return nil, err
+	}
+	return &storage_v1.ForfeitLockResponse{
+		Forfeited: forfeited,
+	}, nil
+}
@@ -17,6 +17,7 @@ package shared
 import (
 	"github.com/hashicorp/go-plugin"
 
+	"github.com/jaegertracing/jaeger/pkg/distributedlock"
 	"github.com/jaegertracing/jaeger/storage/dependencystore"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 )
@@ -48,6 +49,12 @@ type ArchiveStoragePlugin interface {
 	ArchiveSpanWriter() spanstore.Writer
 }
 
+// DistributedLockPlugin is the interface we're exposing as a plugin.
+type DistributedLockPlugin interface {
+	// DistributedLock returns the lock acquiring and forfeiting leases on behalf of owner.
+	DistributedLock(owner string) distributedlock.Lock
+}
+
 // PluginCapabilities allow expose plugin its capabilities.
 type PluginCapabilities interface {
 	Capabilities() (*Capabilities, error)
@@ -57,10 +64,12 @@ type PluginCapabilities interface {
 type Capabilities struct {
 	ArchiveSpanReader bool
 	ArchiveSpanWriter bool
+	DistributedLock   bool
 }
 
 // PluginServices defines services plugin can expose
 type PluginServices struct {
 	Store        StoragePlugin
 	ArchiveStore ArchiveStoragePlugin
+	LockStore    DistributedLockPlugin
 }
@@ -0,0 +1,57 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shared
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jaegertracing/jaeger/pkg/distributedlock"
+	"github.com/jaegertracing/jaeger/proto-gen/storage_v1"
+)
+
+var _ distributedlock.Lock = (*lock)(nil)
+
+// lock wraps storage_v1.DistributedLockPluginClient into distributedlock.Lock
+type lock struct {
+	client storage_v1.DistributedLockPluginClient
+	owner  string
+}
+
+// Acquire acquires a lease around a given resource in the plugin storage
+func (l *lock) Acquire(resource string, ttl time.Duration) (bool, error) {
+	resp, err := l.client.AcquireLock(context.Background(), &storage_v1.AcquireLockRequest{
+		Resource: resource,
+		Owner:    l.owner,
+		Ttl:      ttl,
+	})
+	if err != nil {
+		return false, fmt.Errorf("plugin error: %w", err)
+	}
+	return resp.Acquired, nil
+}
+
+// Forfeit forfeits a lease around a given resource in the plugin storage
+func (l *lock) Forfeit(resource string) (bool, error) {
+	resp, err := l.client.ForfeitLock(context.Background(), &storage_v1.ForfeitLockRequest{
+		Resource: resource,
+		Owner:    l.owner,
+	})
+	if err != nil {
+		return false, fmt.Errorf("plugin error: %w", err)
+	}
+	return resp.Forfeited, nil
+}
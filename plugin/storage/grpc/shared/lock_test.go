@@ -0,0 +1,157 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shared
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/jaegertracing/jaeger/pkg/distributedlock"
+	"github.com/jaegertracing/jaeger/pkg/distributedlock/locktest"
+	lockMocks "github.com/jaegertracing/jaeger/pkg/distributedlock/mocks"
+	"github.com/jaegertracing/jaeger/plugin/pkg/distributedlock/file"
+	"github.com/jaegertracing/jaeger/proto-gen/storage_v1"
+	"github.com/jaegertracing/jaeger/proto-gen/storage_v1/mocks"
+)
+
+// fileLockPlugin serves the file locks of a directory.
+type fileLockPlugin struct {
+	directory string
+}
+
+func (p *fileLockPlugin) DistributedLock(owner string) distributedlock.Lock {
+	lock, err := file.NewLock(p.directory, owner)
+	if err != nil {
+		panic(err)
+	}
+	return lock
+}
+
+// mockLockPlugin serves the same mock lock to all the owners.
+type mockLockPlugin struct {
+	lock   *lockMocks.Lock
+	owners []string
+}
+
+func (p *mockLockPlugin) DistributedLock(owner string) distributedlock.Lock {
+	p.owners = append(p.owners, owner)
+	return p.lock
+}
+
+func TestLockConformance(t *testing.T) {
+	directory, err := ioutil.TempDir("", "jaeger-leases")
+	require.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	storage_v1.RegisterDistributedLockPluginServer(server, &grpcServer{LockImpl: &fileLockPlugin{directory: directory}})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.Dial()
+	}))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := &grpcClient{lockClient: storage_v1.NewDistributedLockPluginClient(conn)}
+
+	suite := &locktest.Suite{
+		NewLock: client.DistributedLock,
+		TTL:     200 * time.Millisecond,
+	}
+	suite.Run(t)
+}
+
+func TestLockPluginError(t *testing.T) {
+	lockClient := new(mocks.DistributedLockPluginClient)
+	lockClient.On("AcquireLock", mock.Anything, &storage_v1.AcquireLockRequest{Resource: "sampling_lock", Owner: "localhost", Ttl: time.Minute}).
+		Return(nil, status.Error(codes.Internal, "internal error"))
+	lockClient.On("ForfeitLock", mock.Anything, &storage_v1.ForfeitLockRequest{Resource: "sampling_lock", Owner: "localhost"}).
+		Return(nil, status.Error(codes.Internal, "internal error"))
+	client := &grpcClient{lockClient: lockClient}
+
+	_, err := client.DistributedLock("localhost").Acquire("sampling_lock", time.Minute)
+	assert.EqualError(t, err, "plugin error: rpc error: code = Internal desc = internal error")
+	_, err = client.DistributedLock("localhost").Forfeit("sampling_lock")
+	assert.EqualError(t, err, "plugin error: rpc error: code = Internal desc = internal error")
+}
+
+func TestGRPCServerAcquireLock(t *testing.T) {
+	lock := new(lockMocks.Lock)
+	lock.On("Acquire", "sampling_lock", time.Minute).Return(true, nil).Once()
+	lock.On("Acquire", "sampling_lock", time.Minute).Return(false, errors.New("storage error"))
+	plugin := &mockLockPlugin{lock: lock}
+	server := &grpcServer{LockImpl: plugin}
+
+	resp, err := server.AcquireLock(context.Background(), &storage_v1.AcquireLockRequest{
+		Resource: "sampling_lock",
+		Owner:    "localhost",
+		Ttl:      time.Minute,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &storage_v1.AcquireLockResponse{Acquired: true}, resp)
+	assert.Equal(t, []string{"localhost"}, plugin.owners)
+
+	_, err = server.AcquireLock(context.Background(), &storage_v1.AcquireLockRequest{
+		Resource: "sampling_lock",
+		Owner:    "localhost",
+		Ttl:      time.Minute,
+	})
+	assert.EqualError(t, err, "storage error")
+}
+
+func TestGRPCServerForfeitLock(t *testing.T) {
+	lock := new(lockMocks.Lock)
+	lock.On("Forfeit", "sampling_lock").Return(true, nil).Once()
+	lock.On("Forfeit", "sampling_lock").Return(false, errors.New("this host does not own the resource lock"))
+	server := &grpcServer{LockImpl: &mockLockPlugin{lock: lock}}
+
+	resp, err := server.ForfeitLock(context.Background(), &storage_v1.ForfeitLockRequest{Resource: "sampling_lock", Owner: "localhost"})
+	require.NoError(t, err)
+	assert.Equal(t, &storage_v1.ForfeitLockResponse{Forfeited: true}, resp)
+
+	_, err = server.ForfeitLock(context.Background(), &storage_v1.ForfeitLockRequest{Resource: "sampling_lock", Owner: "localhost"})
+	assert.EqualError(t, err, "this host does not own the resource lock")
+}
+
+func TestGRPCServerLock_NoImpl(t *testing.T) {
+	server := &grpcServer{}
+	_, err := server.AcquireLock(context.Background(), &storage_v1.AcquireLockRequest{Resource: "sampling_lock"})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+	_, err = server.ForfeitLock(context.Background(), &storage_v1.ForfeitLockRequest{Resource: "sampling_lock"})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+	capabilities, err := server.Capabilities(context.Background(), &storage_v1.CapabilitiesRequest{})
+	require.NoError(t, err)
+	assert.False(t, capabilities.DistributedLock)
+	server.LockImpl = &mockLockPlugin{}
+	capabilities, err = server.Capabilities(context.Background(), &storage_v1.CapabilitiesRequest{})
+	require.NoError(t, err)
+	assert.True(t, capabilities.DistributedLock)
+}
@@ -34,6 +34,7 @@ type StorageGRPCPlugin struct {
 	// Concrete implementation, This is only used for plugins that are written in Go.
 	Impl        StoragePlugin
 	ArchiveImpl ArchiveStoragePlugin
+	LockImpl    DistributedLockPlugin
 }
 
 // GRPCServer implements plugin.GRPCPlugin. It is used by go-plugin to create a grpc plugin server.
@@ -41,6 +42,7 @@ func (p *StorageGRPCPlugin) GRPCServer(broker *plugin.GRPCBroker, s *grpc.Server
 	server := &grpcServer{
 		Impl:        p.Impl,
 		ArchiveImpl: p.ArchiveImpl,
+		LockImpl:    p.LockImpl,
 	}
 	storage_v1.RegisterSpanReaderPluginServer(s, server)
 	storage_v1.RegisterSpanWriterPluginServer(s, server)
@@ -48,6 +50,7 @@ func (p *StorageGRPCPlugin) GRPCServer(broker *plugin.GRPCBroker, s *grpc.Server
 	storage_v1.RegisterArchiveSpanWriterPluginServer(s, server)
 	storage_v1.RegisterPluginCapabilitiesServer(s, server)
 	storage_v1.RegisterDependenciesReaderPluginServer(s, server)
+	storage_v1.RegisterDistributedLockPluginServer(s, server)
 	return nil
 }
 
@@ -60,5 +63,6 @@ func (*StorageGRPCPlugin) GRPCClient(ctx context.Context, broker *plugin.GRPCBro
 		archiveWriterClient: storage_v1.NewArchiveSpanWriterPluginClient(c),
 		capabilitiesClient:  storage_v1.NewPluginCapabilitiesClient(c),
 		depsReaderClient:    storage_v1.NewDependenciesReaderPluginClient(c),
+		lockClient:          storage_v1.NewDistributedLockPluginClient(c),
 	}, nil
 }
@@ -23,6 +23,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/jaegertracing/jaeger/storage/dependencystore"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 )
 
@@ -32,6 +33,7 @@ type Factory struct {
 	metricsFactory metrics.Factory
 	logger         *zap.Logger
 	store          *Store
+	strategies     *StrategiesStore
 }
 
 // NewFactory creates a new Factory.
@@ -58,7 +60,8 @@ func (f *Factory) InitFromOptions(opts Options) {
 func (f *Factory) Initialize(metricsFactory metrics.Factory, logger *zap.Logger) error {
 	f.metricsFactory, f.logger = metricsFactory, logger
 	f.store = WithConfiguration(f.options.Configuration)
-	logger.Info("Memory storage initialized", zap.Any("configuration", f.store.config))
+	f.strategies = NewStrategiesStore()
+	logger.Info("Memory storage initialized", zap.Any("configuration", f.store.defaultConfig))
 	f.publishOpts()
 
 	return nil
@@ -79,6 +82,11 @@ func (f *Factory) CreateDependencyReader() (dependencystore.Reader, error) {
 	return f.store, nil
 }
 
+// CreateSamplingStrategiesStore implements storage.SamplingStrategiesFactory
+func (f *Factory) CreateSamplingStrategiesStore() (samplingstore.StrategiesStore, error) {
+	return f.strategies, nil
+}
+
 func (f *Factory) publishOpts() {
 	internalFactory := f.metricsFactory.Namespace(metrics.NSOptions{Name: "internal"})
 	internalFactory.Gauge(metrics.Options{Name: limit}).
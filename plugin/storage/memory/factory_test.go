@@ -30,6 +30,7 @@ import (
 )
 
 var _ storage.Factory = new(Factory)
+var _ storage.SamplingStrategiesFactory = new(Factory)
 
 func TestMemoryStorageFactory(t *testing.T) {
 	f := NewFactory()
@@ -44,6 +45,9 @@ func TestMemoryStorageFactory(t *testing.T) {
 	depReader, err := f.CreateDependencyReader()
 	assert.NoError(t, err)
 	assert.Equal(t, f.store, depReader)
+	strategiesStore, err := f.CreateSamplingStrategiesStore()
+	assert.NoError(t, err)
+	assert.Equal(t, f.strategies, strategiesStore)
 }
 
 func TestWithConfiguration(t *testing.T) {
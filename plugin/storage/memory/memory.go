@@ -27,11 +27,21 @@ import (
 	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/model/adjuster"
 	"github.com/jaegertracing/jaeger/pkg/memory/config"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 )
 
-// Store is an in-memory store of traces
+// Store is an in-memory store of traces, partitioned by tenant
 type Store struct {
+	sync.RWMutex
+	// Each tenant gets a copy of default config.
+	// In the future this can be extended to contain per-tenant configuration.
+	defaultConfig config.Configuration
+	perTenant     map[string]*Tenant
+}
+
+// Tenant is an in-memory store of traces for a single tenant
+type Tenant struct {
 	sync.RWMutex
 	ids        []*model.TraceID
 	traces     map[model.TraceID]*model.Trace
@@ -50,17 +60,58 @@ func NewStore() *Store {
 // WithConfiguration creates a new in memory storage based on the given configuration
 func WithConfiguration(configuration config.Configuration) *Store {
 	return &Store{
-		ids:        make([]*model.TraceID, configuration.MaxTraces),
+		defaultConfig: configuration,
+		perTenant:     make(map[string]*Tenant),
+	}
+}
+
+func newTenant(cfg config.Configuration) *Tenant {
+	return &Tenant{
+		ids:        make([]*model.TraceID, cfg.MaxTraces),
 		traces:     map[model.TraceID]*model.Trace{},
 		services:   map[string]struct{}{},
 		operations: map[string]map[spanstore.Operation]struct{}{},
 		deduper:    adjuster.SpanIDDeduper(),
-		config:     configuration,
+		config:     cfg,
 	}
 }
 
+// getTenant returns the per-tenant storage, creating it on first use.
+// Tenants are not validated here, only the collector and query validate them.
+func (m *Store) getTenant(tenantID string) *Tenant {
+	m.RLock()
+	tenant, ok := m.perTenant[tenantID]
+	m.RUnlock()
+	if !ok {
+		m.Lock()
+		defer m.Unlock()
+		tenant, ok = m.perTenant[tenantID]
+		if !ok {
+			tenant = newTenant(m.defaultConfig)
+			m.perTenant[tenantID] = tenant
+		}
+	}
+	return tenant
+}
+
+// findTenant returns the per-tenant storage, or nil if nothing was written for the tenant yet.
+// Reads use it so that querying unknown tenants does not allocate storage for them.
+func (m *Store) findTenant(tenantID string) *Tenant {
+	m.RLock()
+	defer m.RUnlock()
+	return m.perTenant[tenantID]
+}
+
 // GetDependencies returns dependencies between services
 func (m *Store) GetDependencies(ctx context.Context, endTs time.Time, lookback time.Duration) ([]model.DependencyLink, error) {
+	tenant := m.findTenant(tenancy.GetTenant(ctx))
+	if tenant == nil {
+		return []model.DependencyLink{}, nil
+	}
+	return tenant.getDependencies(endTs, lookback)
+}
+
+func (m *Tenant) getDependencies(endTs time.Time, lookback time.Duration) ([]model.DependencyLink, error) {
 	// deduper used below can modify the spans, so we take an exclusive lock
 	m.Lock()
 	defer m.Unlock()
@@ -97,7 +148,7 @@ func (m *Store) GetDependencies(ctx context.Context, endTs time.Time, lookback t
 	return retMe, nil
 }
 
-func (m *Store) findSpan(trace *model.Trace, spanID model.SpanID) *model.Span {
+func (m *Tenant) findSpan(trace *model.Trace, spanID model.SpanID) *model.Span {
 	for _, s := range trace.Spans {
 		if s.SpanID == spanID {
 			return s
@@ -106,7 +157,7 @@ func (m *Store) findSpan(trace *model.Trace, spanID model.SpanID) *model.Span {
 	return nil
 }
 
-func (m *Store) traceIsBetweenStartAndEnd(startTs, endTs time.Time, trace *model.Trace) bool {
+func (m *Tenant) traceIsBetweenStartAndEnd(startTs, endTs time.Time, trace *model.Trace) bool {
 	for _, s := range trace.Spans {
 		if s.StartTime.After(startTs) && endTs.After(s.StartTime) {
 			return true
@@ -117,6 +168,10 @@ func (m *Store) traceIsBetweenStartAndEnd(startTs, endTs time.Time, trace *model
 
 // WriteSpan writes the given span
 func (m *Store) WriteSpan(ctx context.Context, span *model.Span) error {
+	return m.getTenant(tenancy.GetTenant(ctx)).writeSpan(span)
+}
+
+func (m *Tenant) writeSpan(span *model.Span) error {
 	m.Lock()
 	defer m.Unlock()
 	if _, ok := m.operations[span.Process.ServiceName]; !ok {
@@ -160,6 +215,14 @@ func (m *Store) WriteSpan(ctx context.Context, span *model.Span) error {
 
 // GetTrace gets a trace
 func (m *Store) GetTrace(ctx context.Context, traceID model.TraceID) (*model.Trace, error) {
+	tenant := m.findTenant(tenancy.GetTenant(ctx))
+	if tenant == nil {
+		return nil, spanstore.ErrTraceNotFound
+	}
+	return tenant.getTrace(traceID)
+}
+
+func (m *Tenant) getTrace(traceID model.TraceID) (*model.Trace, error) {
 	m.RLock()
 	defer m.RUnlock()
 	trace, ok := m.traces[traceID]
@@ -170,7 +233,7 @@ func (m *Store) GetTrace(ctx context.Context, traceID model.TraceID) (*model.Tra
 }
 
 // Spans may still be added to traces after they are returned to user code, so make copies.
-func (m *Store) copyTrace(trace *model.Trace) (*model.Trace, error) {
+func (m *Tenant) copyTrace(trace *model.Trace) (*model.Trace, error) {
 	bytes, err := proto.Marshal(trace)
 	if err != nil {
 		return nil, err
@@ -183,6 +246,14 @@ func (m *Store) copyTrace(trace *model.Trace) (*model.Trace, error) {
 
 // GetServices returns a list of all known services
 func (m *Store) GetServices(ctx context.Context) ([]string, error) {
+	tenant := m.findTenant(tenancy.GetTenant(ctx))
+	if tenant == nil {
+		return nil, nil
+	}
+	return tenant.getServices()
+}
+
+func (m *Tenant) getServices() ([]string, error) {
 	m.RLock()
 	defer m.RUnlock()
 	var retMe []string
@@ -197,6 +268,14 @@ func (m *Store) GetOperations(
 	ctx context.Context,
 	query spanstore.OperationQueryParameters,
 ) ([]spanstore.Operation, error) {
+	tenant := m.findTenant(tenancy.GetTenant(ctx))
+	if tenant == nil {
+		return nil, nil
+	}
+	return tenant.getOperations(query)
+}
+
+func (m *Tenant) getOperations(query spanstore.OperationQueryParameters) ([]spanstore.Operation, error) {
 	m.RLock()
 	defer m.RUnlock()
 	var retMe []spanstore.Operation
@@ -212,6 +291,14 @@ func (m *Store) GetOperations(
 
 // FindTraces returns all traces in the query parameters are satisfied by a trace's span
 func (m *Store) FindTraces(ctx context.Context, query *spanstore.TraceQueryParameters) ([]*model.Trace, error) {
+	tenant := m.findTenant(tenancy.GetTenant(ctx))
+	if tenant == nil {
+		return nil, nil
+	}
+	return tenant.findTraces(query)
+}
+
+func (m *Tenant) findTraces(query *spanstore.TraceQueryParameters) ([]*model.Trace, error) {
 	m.RLock()
 	defer m.RUnlock()
 	var retMe []*model.Trace
@@ -243,7 +330,7 @@ func (m *Store) FindTraceIDs(ctx context.Context, query *spanstore.TraceQueryPar
 	return nil, errors.New("not implemented")
 }
 
-func (m *Store) validTrace(trace *model.Trace, query *spanstore.TraceQueryParameters) bool {
+func (m *Tenant) validTrace(trace *model.Trace, query *spanstore.TraceQueryParameters) bool {
 	for _, span := range trace.Spans {
 		if m.validSpan(span, query) {
 			return true
@@ -261,7 +348,7 @@ func findKeyValueMatch(kvs model.KeyValues, key, value string) (model.KeyValue,
 	return model.KeyValue{}, false
 }
 
-func (m *Store) validSpan(span *model.Span, query *spanstore.TraceQueryParameters) bool {
+func (m *Tenant) validSpan(span *model.Span, query *spanstore.TraceQueryParameters) bool {
 	if query.ServiceName != span.Process.ServiceName {
 		return false
 	}
@@ -290,7 +377,7 @@ func (m *Store) validSpan(span *model.Span, query *spanstore.TraceQueryParameter
 	return true
 }
 
-func (m *Store) flattenTags(span *model.Span) model.KeyValues {
+func (m *Tenant) flattenTags(span *model.Span) model.KeyValues {
 	retMe := span.Tags
 	retMe = append(retMe, span.Process.Tags...)
 	for _, l := range span.Logs {
@@ -24,6 +24,7 @@ import (
 
 	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/pkg/memory/config"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 )
 
@@ -205,8 +206,8 @@ func TestStoreWithLimit(t *testing.T) {
 		assert.NoError(t, err)
 	}
 
-	assert.Equal(t, maxTraces, len(store.traces))
-	assert.Equal(t, maxTraces, len(store.ids))
+	assert.Equal(t, maxTraces, len(store.getTenant("").traces))
+	assert.Equal(t, maxTraces, len(store.getTenant("").ids))
 }
 
 func TestStoreGetTraceSuccess(t *testing.T) {
@@ -238,7 +239,7 @@ func TestStoreGetAndMutateTrace(t *testing.T) {
 
 func TestStoreGetTraceError(t *testing.T) {
 	withPopulatedMemoryStore(func(store *Store) {
-		store.traces[testingSpan.TraceID] = &model.Trace{
+		store.getTenant("").traces[testingSpan.TraceID] = &model.Trace{
 			Spans: []*model.Span{nonSerializableSpan},
 		}
 		_, err := store.GetTrace(context.Background(), testingSpan.TraceID)
@@ -462,3 +463,51 @@ func TestStore_FindTraceIDs(t *testing.T) {
 		assert.EqualError(t, err, "not implemented")
 	})
 }
+
+func TestTenantStore(t *testing.T) {
+	store := NewStore()
+	ctxAcme := tenancy.WithTenant(context.Background(), "acme")
+	ctxWonka := tenancy.WithTenant(context.Background(), "wonka")
+
+	assert.NoError(t, store.WriteSpan(ctxAcme, testingSpan))
+	wonkaSpan := &model.Span{
+		TraceID: model.NewTraceID(2, 3),
+		SpanID:  model.NewSpanID(1),
+		Process: &model.Process{ServiceName: "wonka-service"},
+	}
+	assert.NoError(t, store.WriteSpan(ctxWonka, wonkaSpan))
+
+	trace, err := store.GetTrace(ctxAcme, testingSpan.TraceID)
+	assert.NoError(t, err)
+	assert.Len(t, trace.Spans, 1)
+	_, err = store.GetTrace(ctxWonka, testingSpan.TraceID)
+	assert.Equal(t, spanstore.ErrTraceNotFound, err)
+	_, err = store.GetTrace(context.Background(), testingSpan.TraceID)
+	assert.Equal(t, spanstore.ErrTraceNotFound, err)
+
+	services, err := store.GetServices(ctxWonka)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"wonka-service"}, services)
+}
+
+func TestTenantStoreReadUnknownTenant(t *testing.T) {
+	store := NewStore()
+	ctx := tenancy.WithTenant(context.Background(), "unknown")
+
+	_, err := store.GetTrace(ctx, testingSpan.TraceID)
+	assert.Equal(t, spanstore.ErrTraceNotFound, err)
+	services, err := store.GetServices(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, services)
+	operations, err := store.GetOperations(ctx, spanstore.OperationQueryParameters{ServiceName: "svc"})
+	assert.NoError(t, err)
+	assert.Empty(t, operations)
+	traces, err := store.FindTraces(ctx, &spanstore.TraceQueryParameters{ServiceName: "svc"})
+	assert.NoError(t, err)
+	assert.Empty(t, traces)
+	links, err := store.GetDependencies(ctx, time.Now(), time.Hour)
+	assert.NoError(t, err)
+	assert.Empty(t, links)
+
+	assert.Empty(t, store.perTenant)
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
+)
+
+// StrategiesStore is an in-memory store of the sampling strategies managed at runtime
+type StrategiesStore struct {
+	sync.RWMutex
+	strategies map[string]*samplingstore.Strategy
+	// changes are the changes of each service, the oldest first
+	changes map[string][]*samplingstore.StrategyChange
+}
+
+// NewStrategiesStore creates an empty in-memory store of sampling strategies
+func NewStrategiesStore() *StrategiesStore {
+	return &StrategiesStore{
+		strategies: make(map[string]*samplingstore.Strategy),
+		changes:    make(map[string][]*samplingstore.StrategyChange),
+	}
+}
+
+// GetStrategies implements samplingstore.StrategiesStore#GetStrategies
+func (s *StrategiesStore) GetStrategies() ([]*samplingstore.Strategy, error) {
+	s.RLock()
+	defer s.RUnlock()
+	strategies := make([]*samplingstore.Strategy, 0, len(s.strategies))
+	for _, strategy := range s.strategies {
+		strategies = append(strategies, strategy)
+	}
+	sort.Slice(strategies, func(i, j int) bool {
+		return strategies[i].Service < strategies[j].Service
+	})
+	return strategies, nil
+}
+
+// GetStrategy implements samplingstore.StrategiesStore#GetStrategy
+func (s *StrategiesStore) GetStrategy(service string) (*samplingstore.Strategy, error) {
+	s.RLock()
+	defer s.RUnlock()
+	strategy, ok := s.strategies[service]
+	if !ok {
+		return nil, samplingstore.ErrStrategyNotFound
+	}
+	return strategy, nil
+}
+
+// PutStrategy implements samplingstore.StrategiesStore#PutStrategy
+func (s *StrategiesStore) PutStrategy(change *samplingstore.StrategyChange) error {
+	s.Lock()
+	defer s.Unlock()
+	var version int64
+	if current, ok := s.strategies[change.Service]; ok {
+		version = current.Version
+	}
+	if change.Version != version+1 {
+		return samplingstore.ErrVersionConflict
+	}
+	s.strategies[change.Service] = change.Strategy()
+	s.changes[change.Service] = append(s.changes[change.Service], change)
+	return nil
+}
+
+// GetStrategyChanges implements samplingstore.StrategiesStore#GetStrategyChanges
+func (s *StrategiesStore) GetStrategyChanges(service string, limit int) ([]*samplingstore.StrategyChange, error) {
+	s.RLock()
+	defer s.RUnlock()
+	changes := s.changes[service]
+	if limit <= 0 || limit > len(changes) {
+		limit = len(changes)
+	}
+	latest := make([]*samplingstore.StrategyChange, limit)
+	for i := range latest {
+		latest[i] = changes[len(changes)-1-i]
+	}
+	return latest, nil
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
+)
+
+var _ samplingstore.StrategiesStore = new(StrategiesStore)
+
+func TestStrategiesStore(t *testing.T) {
+	store := NewStrategiesStore()
+	now := time.Now()
+
+	_, err := store.GetStrategy("foo")
+	assert.Equal(t, samplingstore.ErrStrategyNotFound, err)
+
+	assert.Equal(t, samplingstore.ErrVersionConflict, store.PutStrategy(&samplingstore.StrategyChange{
+		Service: "foo", Version: 2, After: []byte(`{}`),
+	}))
+	first := &samplingstore.StrategyChange{
+		Service: "foo", Version: 1, Action: "put", Subject: "alice", Time: now,
+		After: []byte(`{"type": "probabilistic", "param": 0.5}`),
+	}
+	require.NoError(t, store.PutStrategy(first))
+	second := &samplingstore.StrategyChange{
+		Service: "foo", Version: 2, Action: "patch", Subject: "bob", Time: now.Add(time.Second),
+		Before: first.After,
+		After:  []byte(`{"type": "probabilistic", "param": 0.1}`),
+	}
+	require.NoError(t, store.PutStrategy(second))
+	assert.Equal(t, samplingstore.ErrVersionConflict, store.PutStrategy(second))
+	require.NoError(t, store.PutStrategy(&samplingstore.StrategyChange{
+		Service: "bar", Version: 1, Action: "put", Subject: "alice", Time: now,
+		After: []byte(`{"type": "ratelimiting", "param": 5}`),
+	}))
+
+	strategy, err := store.GetStrategy("foo")
+	require.NoError(t, err)
+	assert.Equal(t, second.Strategy(), strategy)
+	assert.Equal(t, "bob", strategy.UpdatedBy)
+
+	strategies, err := store.GetStrategies()
+	require.NoError(t, err)
+	require.Len(t, strategies, 2)
+	assert.Equal(t, "bar", strategies[0].Service)
+	assert.Equal(t, "foo", strategies[1].Service)
+
+	changes, err := store.GetStrategyChanges("foo", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []*samplingstore.StrategyChange{second, first}, changes)
+	changes, err = store.GetStrategyChanges("foo", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []*samplingstore.StrategyChange{second}, changes)
+	changes, err = store.GetStrategyChanges("baz", 10)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
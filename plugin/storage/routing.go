@@ -0,0 +1,212 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+
+	"github.com/jaegertracing/jaeger/pkg/fswatcher"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+// routingConfig is the content of the span routing rules file, in YAML or, with a .json extension, JSON, e.g.
+//
+//	routes:
+//	  - name: pci
+//	    services: ["payment-*"]
+//	    tags:
+//	      compliance: pci
+//	    storage: elasticsearch
+//	default_storage: cassandra
+//
+// Routes match the service name, the process tags or the tenant of the spans.
+type routingConfig struct {
+	Routes []routeConfig `json:"routes" yaml:"routes"`
+	// DefaultStorage is the storage type of the spans not matching any route, the first span storage type if empty
+	DefaultStorage string `json:"default_storage" yaml:"default_storage"`
+}
+
+type routeConfig struct {
+	Name     string            `json:"name" yaml:"name"`
+	Services []string          `json:"services" yaml:"services"`
+	Tags     map[string]string `json:"tags" yaml:"tags"`
+	Tenants  []string          `json:"tenants" yaml:"tenants"`
+	Storage  string            `json:"storage" yaml:"storage"`
+}
+
+type routingMetrics struct {
+	// Number of successful reloads of the routing rules file
+	Reloads metrics.Counter `metric:"reloads" tags:"result=ok"`
+	// Number of failed reloads of the routing rules file, the last valid routes are kept
+	ReloadErrors metrics.Counter `metric:"reloads" tags:"result=err"`
+}
+
+func loadRoutingConfig(file string) (*routingConfig, error) {
+	bytes, err := ioutil.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the span routing rules file: %w", err)
+	}
+	if len(bytes) == 0 {
+		// most likely the file is being written
+		return nil, fmt.Errorf("the span routing rules file %s is empty", file)
+	}
+	var cfg routingConfig
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		err = json.Unmarshal(bytes, &cfg)
+	} else {
+		err = yaml.UnmarshalStrict(bytes, &cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the span routing rules file: %w", err)
+	}
+	return &cfg, nil
+}
+
+// createRoutingWriter creates a span writer routing the spans to the writers of the
+// span storage types according to the routing rules file, which is reloaded when it changes.
+func (f *Factory) createRoutingWriter(writers map[string]spanstore.Writer) (spanstore.Writer, error) {
+	cfg, err := loadRoutingConfig(f.SpanRoutingRulesFile)
+	if err != nil {
+		return nil, err
+	}
+	routes, defaultWriter, err := f.routes(cfg, writers)
+	if err != nil {
+		return nil, err
+	}
+	metricsFactory := f.metricsFactory.Namespace(metrics.NSOptions{Name: "routing_writer"})
+	routingWriter, err := spanstore.NewRoutingWriter(spanstore.RoutingOptions{
+		Routes:         routes,
+		DefaultWriter:  defaultWriter,
+		MetricsFactory: metricsFactory,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var m routingMetrics
+	metrics.MustInit(&m, metricsFactory, nil)
+	reload := func() {
+		if err := f.reloadRoutes(routingWriter, writers); err != nil {
+			m.ReloadErrors.Inc(1)
+			f.logger.Error("failed to reload the span routing rules file, keeping the previous routes", zap.Error(err))
+			return
+		}
+		m.Reloads.Inc(1)
+		f.logger.Info("reloaded the span routing rules file", zap.String("file", f.SpanRoutingRulesFile))
+	}
+	if f.routingWatcher != nil {
+		f.routingWatcher.Close()
+	}
+	if f.routingWatcher, err = fswatcher.NewFileWatcher(f.SpanRoutingRulesFile, reload, f.logger, nil); err != nil {
+		return nil, err
+	}
+	return routingWriter, nil
+}
+
+func (f *Factory) reloadRoutes(routingWriter *spanstore.RoutingWriter, writers map[string]spanstore.Writer) error {
+	cfg, err := loadRoutingConfig(f.SpanRoutingRulesFile)
+	if err != nil {
+		return err
+	}
+	routes, defaultWriter, err := f.routes(cfg, writers)
+	if err != nil {
+		return err
+	}
+	return routingWriter.UpdateRoutes(routes, defaultWriter)
+}
+
+// routes returns the routes and the default writer of the routing rules.
+func (f *Factory) routes(cfg *routingConfig, writers map[string]spanstore.Writer) ([]spanstore.SpanRoute, spanstore.Writer, error) {
+	writerOf := func(storageType string) (spanstore.Writer, error) {
+		writer, ok := writers[storageType]
+		if !ok {
+			return nil, fmt.Errorf("span routing uses storage type %q which is not one of the span storage types %v", storageType, f.SpanWriterTypes)
+		}
+		return writer, nil
+	}
+	defaultWriter, err := writerOf(f.defaultRoutingStorage(cfg))
+	if err != nil {
+		return nil, nil, err
+	}
+	routes := make([]spanstore.SpanRoute, 0, len(cfg.Routes))
+	for _, r := range cfg.Routes {
+		writer, err := writerOf(r.Storage)
+		if err != nil {
+			return nil, nil, err
+		}
+		routes = append(routes, spanstore.SpanRoute{
+			Name:     r.Name,
+			Services: r.Services,
+			Tags:     r.Tags,
+			Tenants:  r.Tenants,
+			Writer:   writer,
+		})
+	}
+	return routes, defaultWriter, nil
+}
+
+func (f *Factory) defaultRoutingStorage(cfg *routingConfig) string {
+	if cfg.DefaultStorage == "" {
+		return f.SpanWriterTypes[0]
+	}
+	return cfg.DefaultStorage
+}
+
+// createFanOutReader creates a span reader reading from the span reader storage type and from
+// the storage types the routing rules route spans to, as the spans of a trace reported by
+// several services may be split between them. Storage types which are not span storage types
+// of this component are skipped, e.g. kafka in the query service, and storage types added
+// to the rules file after startup are only read after a restart.
+func (f *Factory) createFanOutReader() (spanstore.Reader, error) {
+	cfg, err := loadRoutingConfig(f.SpanRoutingRulesFile)
+	if err != nil {
+		return nil, err
+	}
+	storageTypes := []string{f.SpanReaderType, f.defaultRoutingStorage(cfg)}
+	for _, r := range cfg.Routes {
+		storageTypes = append(storageTypes, r.Storage)
+	}
+	seen := make(map[string]struct{})
+	var readers []spanstore.Reader
+	for _, storageType := range storageTypes {
+		if _, ok := seen[storageType]; ok {
+			continue
+		}
+		seen[storageType] = struct{}{}
+		factory, ok := f.factories[storageType]
+		if !ok {
+			continue
+		}
+		reader, err := factory.CreateSpanReader()
+		if err != nil {
+			return nil, err
+		}
+		readers = append(readers, reader)
+	}
+	if len(readers) == 0 {
+		return nil, fmt.Errorf("no %s backend registered for span store", f.SpanReaderType)
+	}
+	if len(readers) == 1 {
+		return readers[0], nil
+	}
+	return spanstore.NewFanOutReader(readers...), nil
+}
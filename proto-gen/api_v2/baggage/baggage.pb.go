@@ -0,0 +1,264 @@
+// Code generated by protoc-gen-gogo. DO NOT EDIT.
+// source: baggage.proto
+
+package baggage
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/gogo/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
+
+// BaggageRestriction restricts the baggage a service may set.
+type BaggageRestriction struct {
+	// baggage_key is the baggage key the service is allowed to set.
+	BaggageKey string `protobuf:"bytes,1,opt,name=baggage_key,json=baggageKey,proto3" json:"baggage_key,omitempty"`
+	// max_value_length is the maximum length of the baggage value.
+	MaxValueLength       int32    `protobuf:"varint,2,opt,name=max_value_length,json=maxValueLength,proto3" json:"max_value_length,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaggageRestriction) Reset()         { *m = BaggageRestriction{} }
+func (m *BaggageRestriction) String() string { return proto.CompactTextString(m) }
+func (*BaggageRestriction) ProtoMessage()    {}
+func (*BaggageRestriction) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b9e101d0014c1cc3, []int{0}
+}
+func (m *BaggageRestriction) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaggageRestriction.Unmarshal(m, b)
+}
+func (m *BaggageRestriction) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaggageRestriction.Marshal(b, m, deterministic)
+}
+func (m *BaggageRestriction) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaggageRestriction.Merge(m, src)
+}
+func (m *BaggageRestriction) XXX_Size() int {
+	return xxx_messageInfo_BaggageRestriction.Size(m)
+}
+func (m *BaggageRestriction) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaggageRestriction.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaggageRestriction proto.InternalMessageInfo
+
+func (m *BaggageRestriction) GetBaggageKey() string {
+	if m != nil {
+		return m.BaggageKey
+	}
+	return ""
+}
+
+func (m *BaggageRestriction) GetMaxValueLength() int32 {
+	if m != nil {
+		return m.MaxValueLength
+	}
+	return 0
+}
+
+// BaggageRestrictionsParameters is the request of the BaggageRestrictionManager.GetBaggageRestrictions RPC.
+type BaggageRestrictionsParameters struct {
+	ServiceName          string   `protobuf:"bytes,1,opt,name=service_name,json=serviceName,proto3" json:"service_name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaggageRestrictionsParameters) Reset()         { *m = BaggageRestrictionsParameters{} }
+func (m *BaggageRestrictionsParameters) String() string { return proto.CompactTextString(m) }
+func (*BaggageRestrictionsParameters) ProtoMessage()    {}
+func (*BaggageRestrictionsParameters) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b9e101d0014c1cc3, []int{1}
+}
+func (m *BaggageRestrictionsParameters) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaggageRestrictionsParameters.Unmarshal(m, b)
+}
+func (m *BaggageRestrictionsParameters) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaggageRestrictionsParameters.Marshal(b, m, deterministic)
+}
+func (m *BaggageRestrictionsParameters) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaggageRestrictionsParameters.Merge(m, src)
+}
+func (m *BaggageRestrictionsParameters) XXX_Size() int {
+	return xxx_messageInfo_BaggageRestrictionsParameters.Size(m)
+}
+func (m *BaggageRestrictionsParameters) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaggageRestrictionsParameters.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaggageRestrictionsParameters proto.InternalMessageInfo
+
+func (m *BaggageRestrictionsParameters) GetServiceName() string {
+	if m != nil {
+		return m.ServiceName
+	}
+	return ""
+}
+
+// BaggageRestrictionsResponse is the response of the BaggageRestrictionManager.GetBaggageRestrictions RPC.
+type BaggageRestrictionsResponse struct {
+	// baggage_restrictions lists the baggage keys the service is allowed to set,
+	// keys that are not listed must be dropped by the clients.
+	BaggageRestrictions  []*BaggageRestriction `protobuf:"bytes,1,rep,name=baggage_restrictions,json=baggageRestrictions,proto3" json:"baggage_restrictions,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *BaggageRestrictionsResponse) Reset()         { *m = BaggageRestrictionsResponse{} }
+func (m *BaggageRestrictionsResponse) String() string { return proto.CompactTextString(m) }
+func (*BaggageRestrictionsResponse) ProtoMessage()    {}
+func (*BaggageRestrictionsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b9e101d0014c1cc3, []int{2}
+}
+func (m *BaggageRestrictionsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaggageRestrictionsResponse.Unmarshal(m, b)
+}
+func (m *BaggageRestrictionsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaggageRestrictionsResponse.Marshal(b, m, deterministic)
+}
+func (m *BaggageRestrictionsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaggageRestrictionsResponse.Merge(m, src)
+}
+func (m *BaggageRestrictionsResponse) XXX_Size() int {
+	return xxx_messageInfo_BaggageRestrictionsResponse.Size(m)
+}
+func (m *BaggageRestrictionsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaggageRestrictionsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaggageRestrictionsResponse proto.InternalMessageInfo
+
+func (m *BaggageRestrictionsResponse) GetBaggageRestrictions() []*BaggageRestriction {
+	if m != nil {
+		return m.BaggageRestrictions
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*BaggageRestriction)(nil), "jaeger.api_v2.baggage.BaggageRestriction")
+	proto.RegisterType((*BaggageRestrictionsParameters)(nil), "jaeger.api_v2.baggage.BaggageRestrictionsParameters")
+	proto.RegisterType((*BaggageRestrictionsResponse)(nil), "jaeger.api_v2.baggage.BaggageRestrictionsResponse")
+}
+
+func init() { proto.RegisterFile("baggage.proto", fileDescriptor_b9e101d0014c1cc3) }
+
+var fileDescriptor_b9e101d0014c1cc3 = []byte{
+	// 278 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x92, 0xcf, 0x4a, 0xc4, 0x30,
+	0x10, 0xc6, 0x8d, 0xa2, 0xe2, 0x54, 0x45, 0xe2, 0x1f, 0xaa, 0x22, 0x5b, 0x7b, 0xaa, 0x1e, 0x7a,
+	0xa8, 0x3e, 0x41, 0x2f, 0x1e, 0xfc, 0x83, 0xf4, 0xe0, 0x41, 0x84, 0x30, 0x2d, 0x43, 0x8c, 0x6e,
+	0xd3, 0x92, 0xc4, 0xb2, 0x8b, 0x57, 0x9f, 0xc4, 0x27, 0x15, 0xd7, 0x2c, 0x2c, 0xb6, 0x87, 0xbd,
+	0x0d, 0xdf, 0xcc, 0x7c, 0xbf, 0xc9, 0x47, 0x60, 0xa7, 0x44, 0x29, 0x51, 0x52, 0xda, 0x9a, 0xc6,
+	0x35, 0xfc, 0xf0, 0x0d, 0x49, 0x92, 0x49, 0xb1, 0x55, 0xa2, 0xcb, 0x52, 0xdf, 0x8c, 0x05, 0xf0,
+	0xfc, 0xaf, 0x2c, 0xc8, 0x3a, 0xa3, 0x2a, 0xa7, 0x1a, 0xcd, 0x47, 0x10, 0xf8, 0x01, 0xf1, 0x4e,
+	0xd3, 0x90, 0x45, 0x2c, 0xd9, 0x2a, 0xc0, 0x4b, 0xb7, 0x34, 0xe5, 0x09, 0xec, 0xd5, 0x38, 0x11,
+	0x1d, 0x8e, 0x3f, 0x48, 0x8c, 0x49, 0x4b, 0xf7, 0x1a, 0xae, 0x46, 0x2c, 0x59, 0x2f, 0x76, 0x6b,
+	0x9c, 0x3c, 0xfd, 0xca, 0x77, 0x33, 0x35, 0xce, 0xe1, 0xac, 0x0f, 0xb0, 0x8f, 0x68, 0xb0, 0x26,
+	0x47, 0xc6, 0xf2, 0x73, 0xd8, 0xb6, 0x64, 0x3a, 0x55, 0x91, 0xd0, 0x58, 0x93, 0x87, 0x05, 0x5e,
+	0x7b, 0xc0, 0x9a, 0xe2, 0x4f, 0x38, 0x1d, 0xf0, 0x28, 0xc8, 0xb6, 0x8d, 0xb6, 0xc4, 0x5f, 0xe0,
+	0x60, 0x7e, 0xad, 0x59, 0xe8, 0x87, 0x2c, 0x5a, 0x4b, 0x82, 0xec, 0x22, 0x1d, 0x7c, 0x79, 0xda,
+	0x77, 0x2c, 0xf6, 0xcb, 0x3e, 0x25, 0xfb, 0x66, 0x70, 0xdc, 0x9f, 0xbd, 0x47, 0x8d, 0x92, 0x0c,
+	0xff, 0x62, 0x70, 0x74, 0x43, 0x6e, 0xe0, 0x3c, 0x7e, 0xbd, 0x34, 0x78, 0x21, 0x8e, 0x93, 0x6c,
+	0xf9, 0xad, 0x79, 0x00, 0xf1, 0x4a, 0x7e, 0x09, 0x23, 0xd5, 0xf8, 0x4d, 0x67, 0xb0, 0x52, 0x5a,
+	0xfe, 0x33, 0x78, 0xde, 0xf4, 0x45, 0xb9, 0x31, 0xfb, 0x10, 0x57, 0x3f, 0x03, 0x00, 0x59, 0xfb,
+	0x0d, 0x3e, 0x21, 0x02, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// BaggageRestrictionManagerClient is the client API for BaggageRestrictionManager service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type BaggageRestrictionManagerClient interface {
+	GetBaggageRestrictions(ctx context.Context, in *BaggageRestrictionsParameters, opts ...grpc.CallOption) (*BaggageRestrictionsResponse, error)
+}
+
+type baggageRestrictionManagerClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewBaggageRestrictionManagerClient(cc *grpc.ClientConn) BaggageRestrictionManagerClient {
+	return &baggageRestrictionManagerClient{cc}
+}
+
+func (c *baggageRestrictionManagerClient) GetBaggageRestrictions(ctx context.Context, in *BaggageRestrictionsParameters, opts ...grpc.CallOption) (*BaggageRestrictionsResponse, error) {
+	out := new(BaggageRestrictionsResponse)
+	err := c.cc.Invoke(ctx, "/jaeger.api_v2.baggage.BaggageRestrictionManager/GetBaggageRestrictions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BaggageRestrictionManagerServer is the server API for BaggageRestrictionManager service.
+type BaggageRestrictionManagerServer interface {
+	GetBaggageRestrictions(context.Context, *BaggageRestrictionsParameters) (*BaggageRestrictionsResponse, error)
+}
+
+// UnimplementedBaggageRestrictionManagerServer can be embedded to have forward compatible implementations.
+type UnimplementedBaggageRestrictionManagerServer struct {
+}
+
+func (*UnimplementedBaggageRestrictionManagerServer) GetBaggageRestrictions(ctx context.Context, req *BaggageRestrictionsParameters) (*BaggageRestrictionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBaggageRestrictions not implemented")
+}
+
+func RegisterBaggageRestrictionManagerServer(s *grpc.Server, srv BaggageRestrictionManagerServer) {
+	s.RegisterService(&_BaggageRestrictionManager_serviceDesc, srv)
+}
+
+func _BaggageRestrictionManager_GetBaggageRestrictions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BaggageRestrictionsParameters)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BaggageRestrictionManagerServer).GetBaggageRestrictions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/jaeger.api_v2.baggage.BaggageRestrictionManager/GetBaggageRestrictions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BaggageRestrictionManagerServer).GetBaggageRestrictions(ctx, req.(*BaggageRestrictionsParameters))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _BaggageRestrictionManager_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "jaeger.api_v2.baggage.BaggageRestrictionManager",
+	HandlerType: (*BaggageRestrictionManagerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetBaggageRestrictions",
+			Handler:    _BaggageRestrictionManager_GetBaggageRestrictions_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "baggage.proto",
+}
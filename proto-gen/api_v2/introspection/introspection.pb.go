@@ -0,0 +1,436 @@
+// Code generated by protoc-gen-gogo. DO NOT EDIT.
+// source: introspection.proto
+
+package introspection
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/gogo/protobuf/proto"
+	types "github.com/gogo/protobuf/types"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
+
+// IntrospectionRequest is the request of the SamplingIntrospection.Introspect RPC.
+type IntrospectionRequest struct {
+	// service restricts the response to one service, all services are returned if empty.
+	Service              string   `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *IntrospectionRequest) Reset()         { *m = IntrospectionRequest{} }
+func (m *IntrospectionRequest) String() string { return proto.CompactTextString(m) }
+func (*IntrospectionRequest) ProtoMessage()    {}
+func (*IntrospectionRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_53a8bedf9a75e10a, []int{0}
+}
+func (m *IntrospectionRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_IntrospectionRequest.Unmarshal(m, b)
+}
+func (m *IntrospectionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_IntrospectionRequest.Marshal(b, m, deterministic)
+}
+func (m *IntrospectionRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_IntrospectionRequest.Merge(m, src)
+}
+func (m *IntrospectionRequest) XXX_Size() int {
+	return xxx_messageInfo_IntrospectionRequest.Size(m)
+}
+func (m *IntrospectionRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_IntrospectionRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_IntrospectionRequest proto.InternalMessageInfo
+
+func (m *IntrospectionRequest) GetService() string {
+	if m != nil {
+		return m.Service
+	}
+	return ""
+}
+
+// OperationIntrospection is the state of adaptive sampling for an operation.
+type OperationIntrospection struct {
+	Operation string `protobuf:"bytes,1,opt,name=operation,proto3" json:"operation,omitempty"`
+	// probability is the sampling probability currently served.
+	Probability float64 `protobuf:"fixed64,2,opt,name=probability,proto3" json:"probability,omitempty"`
+	// qps is the QPS of sampled traces measured in the latest throughput buckets, the latest first.
+	Qps []float64 `protobuf:"fixed64,3,rep,packed,name=qps,proto3" json:"qps,omitempty"`
+	// weighted_qps is the weighted average of qps, biased toward the latest buckets, compared to the target.
+	WeightedQps float64 `protobuf:"fixed64,4,opt,name=weighted_qps,json=weightedQps,proto3" json:"weighted_qps,omitempty"`
+	// target_samples_per_second is the target QPS, from the options or the overrides.
+	TargetSamplesPerSecond float64 `protobuf:"fixed64,5,opt,name=target_samples_per_second,json=targetSamplesPerSecond,proto3" json:"target_samples_per_second,omitempty"`
+	// within_tolerance is true if the weighted QPS is within delta_tolerance of the target,
+	// in which case the probability is not changed.
+	WithinTolerance bool `protobuf:"varint,6,opt,name=within_tolerance,json=withinTolerance,proto3" json:"within_tolerance,omitempty"`
+	// pinned is true if the probability is set by the overrides rather than calculated.
+	Pinned               bool     `protobuf:"varint,7,opt,name=pinned,proto3" json:"pinned,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *OperationIntrospection) Reset()         { *m = OperationIntrospection{} }
+func (m *OperationIntrospection) String() string { return proto.CompactTextString(m) }
+func (*OperationIntrospection) ProtoMessage()    {}
+func (*OperationIntrospection) Descriptor() ([]byte, []int) {
+	return fileDescriptor_53a8bedf9a75e10a, []int{1}
+}
+func (m *OperationIntrospection) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OperationIntrospection.Unmarshal(m, b)
+}
+func (m *OperationIntrospection) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OperationIntrospection.Marshal(b, m, deterministic)
+}
+func (m *OperationIntrospection) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OperationIntrospection.Merge(m, src)
+}
+func (m *OperationIntrospection) XXX_Size() int {
+	return xxx_messageInfo_OperationIntrospection.Size(m)
+}
+func (m *OperationIntrospection) XXX_DiscardUnknown() {
+	xxx_messageInfo_OperationIntrospection.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OperationIntrospection proto.InternalMessageInfo
+
+func (m *OperationIntrospection) GetOperation() string {
+	if m != nil {
+		return m.Operation
+	}
+	return ""
+}
+
+func (m *OperationIntrospection) GetProbability() float64 {
+	if m != nil {
+		return m.Probability
+	}
+	return 0
+}
+
+func (m *OperationIntrospection) GetQps() []float64 {
+	if m != nil {
+		return m.Qps
+	}
+	return nil
+}
+
+func (m *OperationIntrospection) GetWeightedQps() float64 {
+	if m != nil {
+		return m.WeightedQps
+	}
+	return 0
+}
+
+func (m *OperationIntrospection) GetTargetSamplesPerSecond() float64 {
+	if m != nil {
+		return m.TargetSamplesPerSecond
+	}
+	return 0
+}
+
+func (m *OperationIntrospection) GetWithinTolerance() bool {
+	if m != nil {
+		return m.WithinTolerance
+	}
+	return false
+}
+
+func (m *OperationIntrospection) GetPinned() bool {
+	if m != nil {
+		return m.Pinned
+	}
+	return false
+}
+
+// ServiceIntrospection is the state of adaptive sampling for the operations of a service.
+type ServiceIntrospection struct {
+	Service              string                    `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
+	Operations           []*OperationIntrospection `protobuf:"bytes,2,rep,name=operations,proto3" json:"operations,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                  `json:"-"`
+	XXX_unrecognized     []byte                    `json:"-"`
+	XXX_sizecache        int32                     `json:"-"`
+}
+
+func (m *ServiceIntrospection) Reset()         { *m = ServiceIntrospection{} }
+func (m *ServiceIntrospection) String() string { return proto.CompactTextString(m) }
+func (*ServiceIntrospection) ProtoMessage()    {}
+func (*ServiceIntrospection) Descriptor() ([]byte, []int) {
+	return fileDescriptor_53a8bedf9a75e10a, []int{2}
+}
+func (m *ServiceIntrospection) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ServiceIntrospection.Unmarshal(m, b)
+}
+func (m *ServiceIntrospection) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ServiceIntrospection.Marshal(b, m, deterministic)
+}
+func (m *ServiceIntrospection) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ServiceIntrospection.Merge(m, src)
+}
+func (m *ServiceIntrospection) XXX_Size() int {
+	return xxx_messageInfo_ServiceIntrospection.Size(m)
+}
+func (m *ServiceIntrospection) XXX_DiscardUnknown() {
+	xxx_messageInfo_ServiceIntrospection.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ServiceIntrospection proto.InternalMessageInfo
+
+func (m *ServiceIntrospection) GetService() string {
+	if m != nil {
+		return m.Service
+	}
+	return ""
+}
+
+func (m *ServiceIntrospection) GetOperations() []*OperationIntrospection {
+	if m != nil {
+		return m.Operations
+	}
+	return nil
+}
+
+// IntrospectionResponse is the state of adaptive sampling, explaining how the probabilities are calculated.
+type IntrospectionResponse struct {
+	// instance is the hostname of the collector answering.
+	Instance string `protobuf:"bytes,1,opt,name=instance,proto3" json:"instance,omitempty"`
+	// is_leader is true if the collector answering calculates the probabilities.
+	IsLeader bool `protobuf:"varint,2,opt,name=is_leader,json=isLeader,proto3" json:"is_leader,omitempty"`
+	// leaders are the instances which saved probabilities within the last two calculation intervals,
+	// there is more than one if the leadership changed.
+	Leaders []string `protobuf:"bytes,3,rep,name=leaders,proto3" json:"leaders,omitempty"`
+	// calculated_at is the time of the latest calculation by the collector answering, when it is leader.
+	CalculatedAt *types.Timestamp `protobuf:"bytes,4,opt,name=calculated_at,json=calculatedAt,proto3" json:"calculated_at,omitempty"`
+	// loaded_at is the time of the latest load of the probabilities from storage, when it is follower.
+	LoadedAt *types.Timestamp `protobuf:"bytes,5,opt,name=loaded_at,json=loadedAt,proto3" json:"loaded_at,omitempty"`
+	// calculation_interval is the interval between two calculations of the probabilities.
+	CalculationInterval string `protobuf:"bytes,6,opt,name=calculation_interval,json=calculationInterval,proto3" json:"calculation_interval,omitempty"`
+	// delta_tolerance is the deviation of the QPS from the target under which the probability is not changed.
+	DeltaTolerance       float64                 `protobuf:"fixed64,7,opt,name=delta_tolerance,json=deltaTolerance,proto3" json:"delta_tolerance,omitempty"`
+	Services             []*ServiceIntrospection `protobuf:"bytes,8,rep,name=services,proto3" json:"services,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                `json:"-"`
+	XXX_unrecognized     []byte                  `json:"-"`
+	XXX_sizecache        int32                   `json:"-"`
+}
+
+func (m *IntrospectionResponse) Reset()         { *m = IntrospectionResponse{} }
+func (m *IntrospectionResponse) String() string { return proto.CompactTextString(m) }
+func (*IntrospectionResponse) ProtoMessage()    {}
+func (*IntrospectionResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_53a8bedf9a75e10a, []int{3}
+}
+func (m *IntrospectionResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_IntrospectionResponse.Unmarshal(m, b)
+}
+func (m *IntrospectionResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_IntrospectionResponse.Marshal(b, m, deterministic)
+}
+func (m *IntrospectionResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_IntrospectionResponse.Merge(m, src)
+}
+func (m *IntrospectionResponse) XXX_Size() int {
+	return xxx_messageInfo_IntrospectionResponse.Size(m)
+}
+func (m *IntrospectionResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_IntrospectionResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_IntrospectionResponse proto.InternalMessageInfo
+
+func (m *IntrospectionResponse) GetInstance() string {
+	if m != nil {
+		return m.Instance
+	}
+	return ""
+}
+
+func (m *IntrospectionResponse) GetIsLeader() bool {
+	if m != nil {
+		return m.IsLeader
+	}
+	return false
+}
+
+func (m *IntrospectionResponse) GetLeaders() []string {
+	if m != nil {
+		return m.Leaders
+	}
+	return nil
+}
+
+func (m *IntrospectionResponse) GetCalculatedAt() *types.Timestamp {
+	if m != nil {
+		return m.CalculatedAt
+	}
+	return nil
+}
+
+func (m *IntrospectionResponse) GetLoadedAt() *types.Timestamp {
+	if m != nil {
+		return m.LoadedAt
+	}
+	return nil
+}
+
+func (m *IntrospectionResponse) GetCalculationInterval() string {
+	if m != nil {
+		return m.CalculationInterval
+	}
+	return ""
+}
+
+func (m *IntrospectionResponse) GetDeltaTolerance() float64 {
+	if m != nil {
+		return m.DeltaTolerance
+	}
+	return 0
+}
+
+func (m *IntrospectionResponse) GetServices() []*ServiceIntrospection {
+	if m != nil {
+		return m.Services
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*IntrospectionRequest)(nil), "jaeger.api_v2.introspection.IntrospectionRequest")
+	proto.RegisterType((*OperationIntrospection)(nil), "jaeger.api_v2.introspection.OperationIntrospection")
+	proto.RegisterType((*ServiceIntrospection)(nil), "jaeger.api_v2.introspection.ServiceIntrospection")
+	proto.RegisterType((*IntrospectionResponse)(nil), "jaeger.api_v2.introspection.IntrospectionResponse")
+}
+
+func init() { proto.RegisterFile("introspection.proto", fileDescriptor_53a8bedf9a75e10a) }
+
+var fileDescriptor_53a8bedf9a75e10a = []byte{
+	// 525 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x53, 0xcf, 0x6f, 0xd3, 0x30,
+	0x14, 0x26, 0x2d, 0x6b, 0x93, 0xd7, 0x8d, 0x4d, 0x5e, 0x57, 0x85, 0x0e, 0x89, 0x50, 0x09, 0x51,
+	0x2e, 0x19, 0xeb, 0x24, 0x10, 0x27, 0x34, 0x6e, 0x93, 0x40, 0x40, 0xba, 0x13, 0x97, 0xc8, 0x4d,
+	0x1e, 0x99, 0x51, 0x6a, 0x7b, 0xb6, 0xdb, 0x89, 0x3f, 0x80, 0x13, 0x12, 0xfc, 0xcb, 0xa8, 0x76,
+	0xd3, 0x36, 0x52, 0x29, 0xda, 0xad, 0xef, 0xfb, 0xf1, 0x9a, 0xbc, 0xef, 0x0b, 0x1c, 0x33, 0x6e,
+	0x94, 0xd0, 0x12, 0x33, 0xc3, 0x04, 0x8f, 0xa5, 0x12, 0x46, 0x90, 0xd3, 0xef, 0x14, 0x0b, 0x54,
+	0x31, 0x95, 0x2c, 0x9d, 0x8f, 0xe2, 0x9a, 0xa4, 0xff, 0xb4, 0x10, 0xa2, 0x28, 0xf1, 0xcc, 0x4a,
+	0x27, 0xb3, 0x6f, 0x67, 0x86, 0x4d, 0x51, 0x1b, 0x3a, 0x95, 0xce, 0x3d, 0x78, 0x05, 0xdd, 0xab,
+	0x4d, 0x47, 0x82, 0xb7, 0x33, 0xd4, 0x86, 0x84, 0xd0, 0xd6, 0xa8, 0xe6, 0x2c, 0xc3, 0xd0, 0x8b,
+	0xbc, 0x61, 0x90, 0x54, 0xe3, 0xe0, 0x57, 0x03, 0x7a, 0x9f, 0x24, 0x2a, 0xba, 0x90, 0xd7, 0xbc,
+	0xe4, 0x09, 0x04, 0xa2, 0x62, 0x96, 0xb6, 0x35, 0x40, 0x22, 0xe8, 0x48, 0x25, 0x26, 0x74, 0xc2,
+	0x4a, 0x66, 0x7e, 0x84, 0x8d, 0xc8, 0x1b, 0x7a, 0xc9, 0x26, 0x44, 0x8e, 0xa0, 0x79, 0x2b, 0x75,
+	0xd8, 0x8c, 0x9a, 0x43, 0x2f, 0x59, 0xfc, 0x24, 0xcf, 0x60, 0xff, 0x0e, 0x59, 0x71, 0x63, 0x30,
+	0x4f, 0x17, 0xd4, 0x43, 0x67, 0xaa, 0xb0, 0x2f, 0x52, 0x93, 0xb7, 0xf0, 0xd8, 0x50, 0x55, 0xa0,
+	0x49, 0x35, 0x9d, 0xca, 0x12, 0x75, 0x2a, 0x51, 0xa5, 0x1a, 0x33, 0xc1, 0xf3, 0x70, 0xcf, 0xea,
+	0x7b, 0x4e, 0x30, 0x76, 0xfc, 0x67, 0x54, 0x63, 0xcb, 0x92, 0x97, 0x70, 0x74, 0xc7, 0xcc, 0x0d,
+	0xe3, 0xa9, 0x11, 0x25, 0x2a, 0xca, 0x33, 0x0c, 0x5b, 0x91, 0x37, 0xf4, 0x93, 0x43, 0x87, 0x5f,
+	0x57, 0x30, 0xe9, 0x41, 0x4b, 0x32, 0xce, 0x31, 0x0f, 0xdb, 0x56, 0xb0, 0x9c, 0x06, 0x3f, 0x3d,
+	0xe8, 0x8e, 0xdd, 0x65, 0xea, 0xb7, 0xf8, 0xe7, 0x01, 0xc9, 0x18, 0x60, 0x75, 0x14, 0x1d, 0x36,
+	0xa2, 0xe6, 0xb0, 0x33, 0xba, 0x88, 0x77, 0xa4, 0x18, 0x6f, 0x3f, 0x77, 0xb2, 0xb1, 0x66, 0xf0,
+	0xa7, 0x09, 0x27, 0x75, 0x16, 0xb5, 0x14, 0x5c, 0x23, 0xe9, 0x83, 0xcf, 0xb8, 0x36, 0x94, 0xaf,
+	0x9e, 0x64, 0x35, 0x93, 0x53, 0x08, 0x98, 0x4e, 0x4b, 0xa4, 0x39, 0x2a, 0x1b, 0x88, 0x9f, 0xf8,
+	0x4c, 0x7f, 0xb0, 0xf3, 0xe2, 0x0d, 0x1c, 0xe3, 0x12, 0x09, 0x92, 0x6a, 0x24, 0xef, 0xe0, 0x20,
+	0xa3, 0x65, 0x36, 0x2b, 0xe9, 0x22, 0x17, 0x6a, 0x6c, 0x2c, 0x9d, 0x51, 0x3f, 0x76, 0x6d, 0x8b,
+	0xab, 0xb6, 0xc5, 0xd7, 0x55, 0xdb, 0x92, 0xfd, 0xb5, 0xe1, 0xd2, 0x90, 0x37, 0x10, 0x94, 0x82,
+	0xe6, 0xce, 0xbc, 0xf7, 0x5f, 0xb3, 0xef, 0xc4, 0x97, 0x86, 0x9c, 0x43, 0xb7, 0x5a, 0xc4, 0x04,
+	0x4f, 0x19, 0x37, 0xa8, 0xe6, 0xb4, 0xb4, 0xa9, 0x05, 0xc9, 0xf1, 0x06, 0x77, 0xb5, 0xa4, 0xc8,
+	0x0b, 0x38, 0xcc, 0xb1, 0x34, 0x74, 0x23, 0xe3, 0xb6, 0x6d, 0xc5, 0x23, 0x0b, 0xaf, 0x23, 0xfe,
+	0x08, 0xfe, 0x32, 0x22, 0x1d, 0xfa, 0x36, 0x95, 0xf3, 0x9d, 0xa9, 0x6c, 0x8b, 0x3d, 0x59, 0xad,
+	0x18, 0xfd, 0xf6, 0xe0, 0xc4, 0x36, 0x8e, 0xf1, 0xa2, 0x5e, 0x8d, 0x19, 0xc0, 0x1a, 0x20, 0xbb,
+	0xff, 0x64, 0xdb, 0xc7, 0xd9, 0x1f, 0xdd, 0xc7, 0xe2, 0x6a, 0x30, 0x78, 0xf0, 0xfe, 0x35, 0x3c,
+	0x67, 0x62, 0xe9, 0x34, 0x8a, 0x66, 0x8c, 0x17, 0x5b, 0x17, 0x7c, 0x3d, 0xa8, 0x8d, 0x93, 0x96,
+	0x4d, 0xe4, 0xe2, 0xef, 0x00, 0xf0, 0x19, 0xfc, 0x76, 0x7e, 0x04, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// SamplingIntrospectionClient is the client API for SamplingIntrospection service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type SamplingIntrospectionClient interface {
+	Introspect(ctx context.Context, in *IntrospectionRequest, opts ...grpc.CallOption) (*IntrospectionResponse, error)
+}
+
+type samplingIntrospectionClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewSamplingIntrospectionClient(cc *grpc.ClientConn) SamplingIntrospectionClient {
+	return &samplingIntrospectionClient{cc}
+}
+
+func (c *samplingIntrospectionClient) Introspect(ctx context.Context, in *IntrospectionRequest, opts ...grpc.CallOption) (*IntrospectionResponse, error) {
+	out := new(IntrospectionResponse)
+	err := c.cc.Invoke(ctx, "/jaeger.api_v2.introspection.SamplingIntrospection/Introspect", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SamplingIntrospectionServer is the server API for SamplingIntrospection service.
+type SamplingIntrospectionServer interface {
+	Introspect(context.Context, *IntrospectionRequest) (*IntrospectionResponse, error)
+}
+
+// UnimplementedSamplingIntrospectionServer can be embedded to have forward compatible implementations.
+type UnimplementedSamplingIntrospectionServer struct {
+}
+
+func (*UnimplementedSamplingIntrospectionServer) Introspect(ctx context.Context, req *IntrospectionRequest) (*IntrospectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Introspect not implemented")
+}
+
+func RegisterSamplingIntrospectionServer(s *grpc.Server, srv SamplingIntrospectionServer) {
+	s.RegisterService(&_SamplingIntrospection_serviceDesc, srv)
+}
+
+func _SamplingIntrospection_Introspect_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IntrospectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SamplingIntrospectionServer).Introspect(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/jaeger.api_v2.introspection.SamplingIntrospection/Introspect",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SamplingIntrospectionServer).Introspect(ctx, req.(*IntrospectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _SamplingIntrospection_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "jaeger.api_v2.introspection.SamplingIntrospection",
+	HandlerType: (*SamplingIntrospectionServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Introspect",
+			Handler:    _SamplingIntrospection_Introspect_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "introspection.proto",
+}
@@ -151,6 +151,7 @@ func (m *RateLimitingSamplingStrategy) GetMaxTracesPerSecond() int32 {
 type OperationSamplingStrategy struct {
 	Operation             string                         `protobuf:"bytes,1,opt,name=operation,proto3" json:"operation,omitempty"`
 	ProbabilisticSampling *ProbabilisticSamplingStrategy `protobuf:"bytes,2,opt,name=probabilisticSampling,proto3" json:"probabilisticSampling,omitempty"`
+	RateLimitingSampling  *RateLimitingSamplingStrategy  `protobuf:"bytes,3,opt,name=rateLimitingSampling,proto3" json:"rateLimitingSampling,omitempty"`
 	XXX_NoUnkeyedLiteral  struct{}                       `json:"-"`
 	XXX_unrecognized      []byte                         `json:"-"`
 	XXX_sizecache         int32                          `json:"-"`
@@ -203,6 +204,13 @@ func (m *OperationSamplingStrategy) GetProbabilisticSampling() *ProbabilisticSam
 	return nil
 }
 
+func (m *OperationSamplingStrategy) GetRateLimitingSampling() *RateLimitingSamplingStrategy {
+	if m != nil {
+		return m.RateLimitingSampling
+	}
+	return nil
+}
+
 type PerOperationSamplingStrategies struct {
 	DefaultSamplingProbability       float64                      `protobuf:"fixed64,1,opt,name=defaultSamplingProbability,proto3" json:"defaultSamplingProbability,omitempty"`
 	DefaultLowerBoundTracesPerSecond float64                      `protobuf:"fixed64,2,opt,name=defaultLowerBoundTracesPerSecond,proto3" json:"defaultLowerBoundTracesPerSecond,omitempty"`
@@ -405,43 +413,43 @@ func init() {
 func init() { proto.RegisterFile("sampling.proto", fileDescriptor_79c798842d009798) }
 
 var fileDescriptor_79c798842d009798 = []byte{
-	// 569 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x54, 0x41, 0x6f, 0x12, 0x41,
-	0x14, 0x76, 0x40, 0x9b, 0xf4, 0xd1, 0xd6, 0x76, 0xac, 0xba, 0x12, 0x4a, 0xc8, 0xf6, 0x20, 0x56,
+	// 566 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xdc, 0x55, 0x4f, 0x6f, 0x12, 0x41,
+	0x14, 0x77, 0x40, 0x9b, 0xf4, 0xd1, 0xd6, 0x76, 0xac, 0xba, 0x12, 0x4a, 0xc8, 0xf6, 0x20, 0x56,
 	0x0b, 0xc9, 0x7a, 0x33, 0xa6, 0x49, 0x69, 0x0c, 0x59, 0x43, 0x29, 0x59, 0xf0, 0xa2, 0x07, 0x1c,
-	0xe0, 0xb9, 0x19, 0x03, 0x3b, 0x9b, 0x99, 0x29, 0xca, 0xd5, 0xc4, 0xab, 0x17, 0xcf, 0x5e, 0xfc,
-	0x35, 0x1e, 0x4d, 0xbc, 0x79, 0x32, 0xc4, 0x1f, 0x62, 0x76, 0x59, 0x5a, 0x58, 0x16, 0xb8, 0x79,
-	0xda, 0xcd, 0x7b, 0xdf, 0x7c, 0xdf, 0xf7, 0xde, 0xbc, 0x79, 0xb0, 0xa3, 0xd8, 0xc0, 0xef, 0x73,
+	0xe0, 0xb9, 0x19, 0x03, 0x3b, 0x9b, 0x99, 0x29, 0xca, 0xd5, 0xc4, 0xab, 0x17, 0xbf, 0x81, 0x9f,
+	0xc6, 0xa3, 0x89, 0x37, 0x4f, 0x86, 0xf8, 0x39, 0x8c, 0xd9, 0x65, 0x69, 0x61, 0x59, 0xe0, 0xee,
+	0x69, 0xc8, 0x7b, 0xbf, 0xf7, 0x7b, 0xbf, 0xf7, 0x87, 0xb7, 0xb0, 0xa3, 0xd8, 0xc0, 0xef, 0x73,
 	0xcf, 0x2d, 0xf9, 0x52, 0x68, 0x41, 0xb7, 0xdf, 0x33, 0x74, 0x51, 0x96, 0x98, 0xcf, 0xdb, 0x43,
-	0x2b, 0xbb, 0xef, 0x0a, 0x57, 0x84, 0x99, 0x72, 0xf0, 0x37, 0x01, 0x65, 0x73, 0xae, 0x10, 0x6e,
-	0x1f, 0xcb, 0xcc, 0xe7, 0x65, 0xe6, 0x79, 0x42, 0x33, 0xcd, 0x85, 0xa7, 0x26, 0x59, 0xf3, 0x0c,
-	0x0e, 0x1a, 0x52, 0x74, 0x58, 0x87, 0xf7, 0xb9, 0xd2, 0xbc, 0xdb, 0x8c, 0x14, 0x9a, 0x5a, 0x32,
-	0x8d, 0xee, 0x88, 0x9a, 0xb0, 0x35, 0x55, 0x75, 0x98, 0x46, 0x83, 0x14, 0x48, 0x91, 0x38, 0x73,
-	0x31, 0xb3, 0x0e, 0xb9, 0xe0, 0x5b, 0xe3, 0x03, 0xae, 0x83, 0xb3, 0x71, 0x8e, 0x12, 0xd0, 0x01,
-	0xfb, 0xd8, 0x92, 0xac, 0x8b, 0xaa, 0x81, 0xb2, 0x89, 0x5d, 0xe1, 0xf5, 0x42, 0xa6, 0x5b, 0x4e,
-	0x42, 0xc6, 0xfc, 0x46, 0xe0, 0xc1, 0x85, 0x8f, 0x32, 0x74, 0xba, 0xc0, 0x96, 0x83, 0x4d, 0x31,
-	0x4d, 0x86, 0x24, 0x9b, 0xce, 0x75, 0x80, 0x76, 0xe0, 0xae, 0x9f, 0x54, 0x90, 0x91, 0x2a, 0x90,
-	0x62, 0xc6, 0x7a, 0x52, 0x9a, 0xeb, 0x59, 0x69, 0x65, 0xf1, 0x4e, 0x32, 0x95, 0xf9, 0x3b, 0x05,
-	0xf9, 0x06, 0xca, 0x65, 0x16, 0x39, 0x2a, 0x7a, 0x02, 0xd9, 0x1e, 0xbe, 0x63, 0x97, 0x7d, 0x3d,
-	0x4d, 0x5e, 0x29, 0xe9, 0x51, 0xd4, 0xc4, 0x15, 0x08, 0xfa, 0x12, 0x0a, 0x51, 0xb6, 0x26, 0x3e,
-	0xa0, 0xac, 0x88, 0x4b, 0xaf, 0x17, 0x6f, 0x60, 0x2a, 0x64, 0x59, 0x8b, 0xa3, 0x6f, 0xe1, 0x9e,
-	0x3f, 0xeb, 0xf6, 0xca, 0xa5, 0x91, 0x2e, 0xa4, 0x8b, 0x19, 0xab, 0x18, 0xeb, 0xc9, 0xd2, 0xd6,
-	0x3b, 0x4b, 0x78, 0x66, 0xdc, 0xbe, 0xf2, 0xfd, 0x25, 0x6e, 0x6f, 0xce, 0xb9, 0x5d, 0x8a, 0x33,
-	0x3f, 0xa7, 0xc1, 0x58, 0x10, 0x46, 0xe5, 0x0b, 0x4f, 0x21, 0xad, 0xc2, 0x96, 0x8a, 0x62, 0xad,
-	0x91, 0x3f, 0x99, 0xc6, 0x1d, 0xeb, 0x30, 0x56, 0x40, 0xfc, 0x78, 0x00, 0x75, 0xe6, 0x0e, 0xfe,
-	0x8f, 0x31, 0xa1, 0x6d, 0xd8, 0x97, 0x09, 0xcf, 0xc2, 0x48, 0x87, 0x12, 0x8f, 0x63, 0x12, 0xab,
-	0x5e, 0x90, 0x93, 0x48, 0x44, 0xdf, 0xc0, 0x9e, 0x88, 0xdf, 0x55, 0xd8, 0xe7, 0x8c, 0x75, 0x1c,
-	0x2f, 0x60, 0xe5, 0xb8, 0x3a, 0x8b, 0x3c, 0xe6, 0x09, 0x64, 0xe3, 0x36, 0x1a, 0x4c, 0xb2, 0x01,
-	0x6a, 0x94, 0x8a, 0x16, 0x20, 0xa3, 0x50, 0x0e, 0x79, 0x17, 0xeb, 0x6c, 0x80, 0xd1, 0x33, 0x9c,
-	0x0d, 0x1d, 0x3d, 0x87, 0xfd, 0xa4, 0x7b, 0xa0, 0x7b, 0xb0, 0xdd, 0x70, 0x2e, 0x2a, 0xa7, 0x15,
-	0xbb, 0x66, 0x37, 0x5b, 0xf6, 0xd9, 0xee, 0x8d, 0x20, 0xe4, 0x9c, 0xb6, 0x5e, 0xb4, 0x6b, 0xf6,
-	0xb9, 0xdd, 0xb2, 0xeb, 0xd5, 0x5d, 0x62, 0x7d, 0x27, 0x70, 0x7b, 0x7a, 0xfc, 0x9c, 0x79, 0xcc,
-	0x45, 0x49, 0xbf, 0x10, 0xb8, 0x53, 0x45, 0xbd, 0xb0, 0x10, 0x1e, 0xad, 0xb9, 0xfe, 0x6b, 0xdb,
-	0xd9, 0x87, 0x6b, 0xa0, 0xd3, 0x41, 0x33, 0x0f, 0x3f, 0xfd, 0xfa, 0xfb, 0x35, 0x75, 0x60, 0x1a,
-	0xe1, 0xde, 0x1c, 0x5a, 0x65, 0x15, 0x43, 0x3e, 0x23, 0x47, 0x95, 0xe3, 0x1f, 0xe3, 0x3c, 0xf9,
-	0x39, 0xce, 0x93, 0x3f, 0xe3, 0x3c, 0x81, 0xfb, 0x5c, 0x44, 0xec, 0x5a, 0xb2, 0x6e, 0xb0, 0xa5,
-	0x27, 0x22, 0xaf, 0x37, 0x26, 0xdf, 0xce, 0x46, 0xb8, 0x72, 0x9f, 0xfe, 0x0b, 0x00, 0x00, 0xff,
-	0xff, 0xf1, 0xcc, 0x49, 0x9b, 0xc7, 0x05, 0x00, 0x00,
+	0x2b, 0xbb, 0xef, 0x0a, 0x57, 0x84, 0x9e, 0x72, 0xf0, 0x6b, 0x02, 0xca, 0xe6, 0x5c, 0x21, 0xdc,
+	0x3e, 0x96, 0x99, 0xcf, 0xcb, 0xcc, 0xf3, 0x84, 0x66, 0x9a, 0x0b, 0x4f, 0x4d, 0xbc, 0xe6, 0x19,
+	0x1c, 0x34, 0xa4, 0xe8, 0xb0, 0x0e, 0xef, 0x73, 0xa5, 0x79, 0xb7, 0x19, 0x65, 0x68, 0x6a, 0xc9,
+	0x34, 0xba, 0x23, 0x6a, 0xc2, 0xd6, 0x34, 0xab, 0xc3, 0x34, 0x1a, 0xa4, 0x40, 0x8a, 0xc4, 0x99,
+	0xb3, 0x99, 0x75, 0xc8, 0x05, 0x6f, 0x8d, 0x0f, 0xb8, 0x0e, 0x62, 0xe3, 0x1c, 0x25, 0xa0, 0x03,
+	0xf6, 0xb1, 0x25, 0x59, 0x17, 0x55, 0x03, 0x65, 0x13, 0xbb, 0xc2, 0xeb, 0x85, 0x4c, 0xb7, 0x9c,
+	0x04, 0x8f, 0xf9, 0x97, 0xc0, 0x83, 0x0b, 0x1f, 0x65, 0xa8, 0x74, 0x81, 0x2d, 0x07, 0x9b, 0x62,
+	0xea, 0x0c, 0x49, 0x36, 0x9d, 0x6b, 0x03, 0xed, 0xc0, 0x5d, 0x3f, 0xa9, 0x20, 0x23, 0x55, 0x20,
+	0xc5, 0x8c, 0xf5, 0xa4, 0x34, 0xd7, 0xb3, 0xd2, 0xca, 0xe2, 0x9d, 0x64, 0x2a, 0xda, 0x86, 0x7d,
+	0x99, 0x50, 0xaf, 0x91, 0x0e, 0x53, 0x3c, 0x8e, 0xa5, 0x58, 0xd5, 0x1a, 0x27, 0x91, 0xc8, 0xfc,
+	0x95, 0x82, 0x7c, 0x03, 0xe5, 0xb2, 0x1e, 0x70, 0x54, 0xf4, 0x04, 0xb2, 0x3d, 0x7c, 0xc7, 0x2e,
+	0xfb, 0x7a, 0xea, 0xbc, 0x2a, 0x45, 0x8f, 0xa2, 0x29, 0xad, 0x40, 0xd0, 0x97, 0x50, 0x88, 0xbc,
+	0x35, 0xf1, 0x01, 0x65, 0x45, 0x5c, 0x7a, 0xbd, 0xf8, 0x84, 0x52, 0x21, 0xcb, 0x5a, 0x1c, 0x7d,
+	0x0b, 0xf7, 0xfc, 0x59, 0xb5, 0x57, 0x2a, 0x8d, 0x74, 0x21, 0x5d, 0xcc, 0x58, 0xc5, 0x58, 0x47,
+	0x96, 0xce, 0xd6, 0x59, 0xc2, 0x33, 0xa3, 0xf6, 0x95, 0xef, 0x2f, 0x51, 0x7b, 0x73, 0x4e, 0xed,
+	0x52, 0x9c, 0xf9, 0x39, 0x0d, 0xc6, 0x42, 0x62, 0x54, 0xbe, 0xf0, 0x14, 0xd2, 0x2a, 0x6c, 0xa9,
+	0xc8, 0xd6, 0x1a, 0xf9, 0x93, 0x75, 0xdf, 0xb1, 0x0e, 0x63, 0x05, 0xc4, 0xc3, 0x03, 0xa8, 0x33,
+	0x17, 0xf8, 0x5f, 0xec, 0x21, 0x7d, 0x03, 0x7b, 0x22, 0x3e, 0xab, 0xb0, 0xcf, 0x19, 0xeb, 0x38,
+	0x5e, 0xc0, 0xca, 0x75, 0x75, 0x16, 0x79, 0xcc, 0x13, 0xc8, 0xc6, 0x65, 0x34, 0x98, 0x64, 0x03,
+	0xd4, 0x28, 0x15, 0x2d, 0x40, 0x46, 0xa1, 0x1c, 0xf2, 0x2e, 0xd6, 0xd9, 0x00, 0xa3, 0xff, 0xf9,
+	0xac, 0xe9, 0xe8, 0x39, 0xec, 0x27, 0xcd, 0x81, 0xee, 0xc1, 0x76, 0xc3, 0xb9, 0xa8, 0x9c, 0x56,
+	0xec, 0x9a, 0xdd, 0x6c, 0xd9, 0x67, 0xbb, 0x37, 0x02, 0x93, 0x73, 0xda, 0x7a, 0xd1, 0xae, 0xd9,
+	0xe7, 0x76, 0xcb, 0xae, 0x57, 0x77, 0x89, 0xf5, 0x8d, 0xc0, 0xed, 0x69, 0xf8, 0x39, 0xf3, 0x98,
+	0x8b, 0x92, 0x7e, 0x21, 0x70, 0xa7, 0x8a, 0x7a, 0xe1, 0xe2, 0x3c, 0x5a, 0x33, 0xfe, 0x6b, 0xd9,
+	0xd9, 0x87, 0x6b, 0xa0, 0xd3, 0x45, 0x33, 0x0f, 0x3f, 0xfd, 0xfc, 0xf3, 0x35, 0x75, 0x60, 0x1a,
+	0xe1, 0x61, 0x1e, 0x5a, 0x65, 0x15, 0x43, 0x3e, 0x23, 0x47, 0x95, 0xe3, 0xef, 0xe3, 0x3c, 0xf9,
+	0x31, 0xce, 0x93, 0xdf, 0xe3, 0x3c, 0x81, 0xfb, 0x5c, 0x44, 0xec, 0x5a, 0xb2, 0x6e, 0xf0, 0x19,
+	0x98, 0x24, 0x79, 0xbd, 0x31, 0x79, 0x3b, 0x1b, 0xe1, 0x4d, 0x7f, 0xfa, 0x6f, 0x00, 0x61, 0x56,
+	0xac, 0x00, 0x28, 0x06, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -613,6 +621,18 @@ func (m *OperationSamplingStrategy) MarshalToSizedBuffer(dAtA []byte) (int, erro
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.RateLimitingSampling != nil {
+		{
+			size, err := m.RateLimitingSampling.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSampling(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
 	if m.ProbabilisticSampling != nil {
 		{
 			size, err := m.ProbabilisticSampling.MarshalToSizedBuffer(dAtA[:i])
@@ -851,6 +871,10 @@ func (m *OperationSamplingStrategy) Size() (n int) {
 		l = m.ProbabilisticSampling.Size()
 		n += 1 + l + sovSampling(uint64(l))
 	}
+	if m.RateLimitingSampling != nil {
+		l = m.RateLimitingSampling.Size()
+		n += 1 + l + sovSampling(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1162,6 +1186,42 @@ func (m *OperationSamplingStrategy) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RateLimitingSampling", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSampling
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSampling
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSampling
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.RateLimitingSampling == nil {
+				m.RateLimitingSampling = &RateLimitingSamplingStrategy{}
+			}
+			if err := m.RateLimitingSampling.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipSampling(dAtA[iNdEx:])
@@ -797,6 +797,218 @@ func (m *FindTraceIDsResponse) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_FindTraceIDsResponse proto.InternalMessageInfo
 
+type AcquireLockRequest struct {
+	Resource             string        `protobuf:"bytes,1,opt,name=resource,proto3" json:"resource,omitempty"`
+	Owner                string        `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	Ttl                  time.Duration `protobuf:"bytes,3,opt,name=ttl,proto3,stdduration" json:"ttl"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *AcquireLockRequest) Reset()         { *m = AcquireLockRequest{} }
+func (m *AcquireLockRequest) String() string { return proto.CompactTextString(m) }
+func (*AcquireLockRequest) ProtoMessage()    {}
+func (*AcquireLockRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0d2c4ccf1453ffdb, []int{15}
+}
+func (m *AcquireLockRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *AcquireLockRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_AcquireLockRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *AcquireLockRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AcquireLockRequest.Merge(m, src)
+}
+func (m *AcquireLockRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *AcquireLockRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AcquireLockRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AcquireLockRequest proto.InternalMessageInfo
+
+func (m *AcquireLockRequest) GetResource() string {
+	if m != nil {
+		return m.Resource
+	}
+	return ""
+}
+
+func (m *AcquireLockRequest) GetOwner() string {
+	if m != nil {
+		return m.Owner
+	}
+	return ""
+}
+
+func (m *AcquireLockRequest) GetTtl() time.Duration {
+	if m != nil {
+		return m.Ttl
+	}
+	return 0
+}
+
+type AcquireLockResponse struct {
+	Acquired             bool     `protobuf:"varint,1,opt,name=acquired,proto3" json:"acquired,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AcquireLockResponse) Reset()         { *m = AcquireLockResponse{} }
+func (m *AcquireLockResponse) String() string { return proto.CompactTextString(m) }
+func (*AcquireLockResponse) ProtoMessage()    {}
+func (*AcquireLockResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0d2c4ccf1453ffdb, []int{16}
+}
+func (m *AcquireLockResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *AcquireLockResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_AcquireLockResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *AcquireLockResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AcquireLockResponse.Merge(m, src)
+}
+func (m *AcquireLockResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *AcquireLockResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_AcquireLockResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AcquireLockResponse proto.InternalMessageInfo
+
+func (m *AcquireLockResponse) GetAcquired() bool {
+	if m != nil {
+		return m.Acquired
+	}
+	return false
+}
+
+type ForfeitLockRequest struct {
+	Resource             string   `protobuf:"bytes,1,opt,name=resource,proto3" json:"resource,omitempty"`
+	Owner                string   `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ForfeitLockRequest) Reset()         { *m = ForfeitLockRequest{} }
+func (m *ForfeitLockRequest) String() string { return proto.CompactTextString(m) }
+func (*ForfeitLockRequest) ProtoMessage()    {}
+func (*ForfeitLockRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0d2c4ccf1453ffdb, []int{17}
+}
+func (m *ForfeitLockRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ForfeitLockRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ForfeitLockRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ForfeitLockRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ForfeitLockRequest.Merge(m, src)
+}
+func (m *ForfeitLockRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *ForfeitLockRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ForfeitLockRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ForfeitLockRequest proto.InternalMessageInfo
+
+func (m *ForfeitLockRequest) GetResource() string {
+	if m != nil {
+		return m.Resource
+	}
+	return ""
+}
+
+func (m *ForfeitLockRequest) GetOwner() string {
+	if m != nil {
+		return m.Owner
+	}
+	return ""
+}
+
+type ForfeitLockResponse struct {
+	Forfeited            bool     `protobuf:"varint,1,opt,name=forfeited,proto3" json:"forfeited,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ForfeitLockResponse) Reset()         { *m = ForfeitLockResponse{} }
+func (m *ForfeitLockResponse) String() string { return proto.CompactTextString(m) }
+func (*ForfeitLockResponse) ProtoMessage()    {}
+func (*ForfeitLockResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0d2c4ccf1453ffdb, []int{18}
+}
+func (m *ForfeitLockResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ForfeitLockResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ForfeitLockResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ForfeitLockResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ForfeitLockResponse.Merge(m, src)
+}
+func (m *ForfeitLockResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *ForfeitLockResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ForfeitLockResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ForfeitLockResponse proto.InternalMessageInfo
+
+func (m *ForfeitLockResponse) GetForfeited() bool {
+	if m != nil {
+		return m.Forfeited
+	}
+	return false
+}
+
 // empty; extensible in the future
 type CapabilitiesRequest struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -808,7 +1020,7 @@ func (m *CapabilitiesRequest) Reset()         { *m = CapabilitiesRequest{} }
 func (m *CapabilitiesRequest) String() string { return proto.CompactTextString(m) }
 func (*CapabilitiesRequest) ProtoMessage()    {}
 func (*CapabilitiesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0d2c4ccf1453ffdb, []int{15}
+	return fileDescriptor_0d2c4ccf1453ffdb, []int{19}
 }
 func (m *CapabilitiesRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -840,6 +1052,7 @@ var xxx_messageInfo_CapabilitiesRequest proto.InternalMessageInfo
 type CapabilitiesResponse struct {
 	ArchiveSpanReader    bool     `protobuf:"varint,1,opt,name=archiveSpanReader,proto3" json:"archiveSpanReader,omitempty"`
 	ArchiveSpanWriter    bool     `protobuf:"varint,2,opt,name=archiveSpanWriter,proto3" json:"archiveSpanWriter,omitempty"`
+	DistributedLock      bool     `protobuf:"varint,3,opt,name=distributedLock,proto3" json:"distributedLock,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -849,7 +1062,7 @@ func (m *CapabilitiesResponse) Reset()         { *m = CapabilitiesResponse{} }
 func (m *CapabilitiesResponse) String() string { return proto.CompactTextString(m) }
 func (*CapabilitiesResponse) ProtoMessage()    {}
 func (*CapabilitiesResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0d2c4ccf1453ffdb, []int{16}
+	return fileDescriptor_0d2c4ccf1453ffdb, []int{20}
 }
 func (m *CapabilitiesResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -892,6 +1105,13 @@ func (m *CapabilitiesResponse) GetArchiveSpanWriter() bool {
 	return false
 }
 
+func (m *CapabilitiesResponse) GetDistributedLock() bool {
+	if m != nil {
+		return m.DistributedLock
+	}
+	return false
+}
+
 func init() {
 	proto.RegisterType((*GetDependenciesRequest)(nil), "jaeger.storage.v1.GetDependenciesRequest")
 	proto.RegisterType((*GetDependenciesResponse)(nil), "jaeger.storage.v1.GetDependenciesResponse")
@@ -909,6 +1129,10 @@ func init() {
 	proto.RegisterType((*SpansResponseChunk)(nil), "jaeger.storage.v1.SpansResponseChunk")
 	proto.RegisterType((*FindTraceIDsRequest)(nil), "jaeger.storage.v1.FindTraceIDsRequest")
 	proto.RegisterType((*FindTraceIDsResponse)(nil), "jaeger.storage.v1.FindTraceIDsResponse")
+	proto.RegisterType((*AcquireLockRequest)(nil), "jaeger.storage.v1.AcquireLockRequest")
+	proto.RegisterType((*AcquireLockResponse)(nil), "jaeger.storage.v1.AcquireLockResponse")
+	proto.RegisterType((*ForfeitLockRequest)(nil), "jaeger.storage.v1.ForfeitLockRequest")
+	proto.RegisterType((*ForfeitLockResponse)(nil), "jaeger.storage.v1.ForfeitLockResponse")
 	proto.RegisterType((*CapabilitiesRequest)(nil), "jaeger.storage.v1.CapabilitiesRequest")
 	proto.RegisterType((*CapabilitiesResponse)(nil), "jaeger.storage.v1.CapabilitiesResponse")
 }
@@ -916,73 +1140,82 @@ func init() {
 func init() { proto.RegisterFile("storage.proto", fileDescriptor_0d2c4ccf1453ffdb) }
 
 var fileDescriptor_0d2c4ccf1453ffdb = []byte{
-	// 1045 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0x4d, 0x73, 0xdb, 0xc4,
-	0x1b, 0xff, 0x2b, 0xb1, 0x6b, 0xfb, 0xb1, 0xd3, 0x7f, 0xb2, 0x76, 0xa9, 0x10, 0x34, 0x0e, 0x82,
-	0xbc, 0xc0, 0x80, 0x4c, 0xcc, 0x01, 0x06, 0xca, 0x40, 0x9d, 0xa4, 0x9e, 0x00, 0x85, 0xa2, 0x66,
-	0xe8, 0x0c, 0x85, 0x7a, 0xd6, 0xd6, 0xa2, 0xa8, 0xb1, 0x56, 0xaa, 0x5e, 0x3c, 0xf6, 0x81, 0x1b,
-	0x1f, 0x80, 0x23, 0x27, 0xae, 0x7c, 0x95, 0x1e, 0x39, 0x73, 0x08, 0x4c, 0xae, 0x7c, 0x09, 0x46,
-	0xbb, 0x2b, 0x59, 0x92, 0x35, 0x49, 0x9a, 0xc9, 0x4d, 0xfb, 0xec, 0x6f, 0x7f, 0xcf, 0xfb, 0xf3,
-	0x08, 0x56, 0xfc, 0xc0, 0xf1, 0xb0, 0x49, 0x34, 0xd7, 0x73, 0x02, 0x07, 0xad, 0x3d, 0xc3, 0xc4,
-	0x24, 0x9e, 0x16, 0x4b, 0x27, 0xbb, 0x4a, 0xcb, 0x74, 0x4c, 0x87, 0xdd, 0x76, 0xa2, 0x2f, 0x0e,
-	0x54, 0xda, 0xa6, 0xe3, 0x98, 0x63, 0xd2, 0x61, 0xa7, 0x61, 0xf8, 0x53, 0x27, 0xb0, 0x6c, 0xe2,
-	0x07, 0xd8, 0x76, 0x05, 0x60, 0x3d, 0x0f, 0x30, 0x42, 0x0f, 0x07, 0x96, 0x43, 0xc5, 0x7d, 0xdd,
-	0x76, 0x0c, 0x32, 0xe6, 0x07, 0xf5, 0x77, 0x09, 0x5e, 0xe9, 0x93, 0x60, 0x9f, 0xb8, 0x84, 0x1a,
-	0x84, 0x8e, 0x2c, 0xe2, 0xeb, 0xe4, 0x79, 0x48, 0xfc, 0x00, 0xed, 0x01, 0xf8, 0x01, 0xf6, 0x82,
-	0x41, 0xa4, 0x40, 0x96, 0x36, 0xa4, 0x9d, 0x7a, 0x57, 0xd1, 0x38, 0xb9, 0x16, 0x93, 0x6b, 0x47,
-	0xb1, 0xf6, 0x5e, 0xf5, 0xc5, 0x69, 0xfb, 0x7f, 0xbf, 0xfe, 0xdd, 0x96, 0xf4, 0x1a, 0x7b, 0x17,
-	0xdd, 0xa0, 0xcf, 0xa0, 0x4a, 0xa8, 0xc1, 0x29, 0x96, 0x5e, 0x82, 0xa2, 0x42, 0xa8, 0x11, 0xc9,
-	0xd5, 0x21, 0xdc, 0x5e, 0xb0, 0xcf, 0x77, 0x1d, 0xea, 0x13, 0xd4, 0x87, 0x86, 0x91, 0x92, 0xcb,
-	0xd2, 0xc6, 0xf2, 0x4e, 0xbd, 0x7b, 0x47, 0x13, 0x91, 0xc4, 0xae, 0x35, 0x98, 0x74, 0xb5, 0xe4,
-	0xe9, 0xec, 0x2b, 0x8b, 0x9e, 0xf4, 0x4a, 0x91, 0x0a, 0x3d, 0xf3, 0x50, 0xfd, 0x04, 0x56, 0x1f,
-	0x7b, 0x56, 0x40, 0x1e, 0xb9, 0x98, 0xc6, 0xde, 0x6f, 0x43, 0xc9, 0x77, 0x31, 0x15, 0x7e, 0x37,
-	0x73, 0xa4, 0x0c, 0xc9, 0x00, 0x6a, 0x13, 0xd6, 0x52, 0x8f, 0xb9, 0x69, 0x2a, 0x85, 0xff, 0xf7,
-	0x49, 0x70, 0xe4, 0xe1, 0x11, 0x89, 0x09, 0x9f, 0x40, 0x35, 0x88, 0xce, 0x03, 0xcb, 0x60, 0xa4,
-	0x8d, 0xde, 0xe7, 0x91, 0x29, 0x7f, 0x9d, 0xb6, 0xdf, 0x33, 0xad, 0xe0, 0x38, 0x1c, 0x6a, 0x23,
-	0xc7, 0xee, 0x70, 0x35, 0x11, 0xd0, 0xa2, 0xa6, 0x38, 0x75, 0x78, 0xc2, 0x18, 0xdb, 0xe1, 0xfe,
-	0xd9, 0x69, 0xbb, 0x22, 0x3e, 0xf5, 0x0a, 0x63, 0x3c, 0x34, 0xd4, 0x16, 0xa0, 0x3e, 0x09, 0x1e,
-	0x11, 0x6f, 0x62, 0x8d, 0x92, 0x0c, 0xaa, 0xbb, 0xd0, 0xcc, 0x48, 0x45, 0xdc, 0x14, 0xa8, 0xfa,
-	0x42, 0xc6, 0x62, 0x56, 0xd3, 0x93, 0xb3, 0xfa, 0x00, 0x5a, 0x7d, 0x12, 0x7c, 0xe3, 0x12, 0x5e,
-	0x32, 0x49, 0x31, 0xc8, 0x50, 0x11, 0x18, 0x66, 0x7c, 0x4d, 0x8f, 0x8f, 0xe8, 0x35, 0xa8, 0x45,
-	0x71, 0x18, 0x9c, 0x58, 0xd4, 0x60, 0x29, 0x8e, 0xe8, 0x5c, 0x4c, 0xbf, 0xb4, 0xa8, 0xa1, 0xde,
-	0x85, 0x5a, 0xc2, 0x85, 0x10, 0x94, 0x28, 0xb6, 0x63, 0x02, 0xf6, 0x7d, 0xfe, 0xeb, 0x9f, 0xe1,
-	0x56, 0xce, 0x18, 0xe1, 0xc1, 0x16, 0xdc, 0x74, 0x62, 0xe9, 0xd7, 0xd8, 0x4e, 0xfc, 0xc8, 0x49,
-	0xd1, 0x5d, 0x80, 0x44, 0xe2, 0xcb, 0x4b, 0xac, 0x3e, 0x5e, 0xd7, 0x16, 0x3a, 0x4d, 0x4b, 0x54,
-	0xe8, 0x29, 0xbc, 0xfa, 0x47, 0x09, 0x5a, 0x2c, 0xd2, 0xdf, 0x86, 0xc4, 0x9b, 0x3d, 0xc4, 0x1e,
-	0xb6, 0x49, 0x40, 0x3c, 0x1f, 0xbd, 0x01, 0x0d, 0xe1, 0xfd, 0x20, 0xe5, 0x50, 0x5d, 0xc8, 0x22,
-	0xd5, 0x68, 0x33, 0x65, 0x21, 0x07, 0x71, 0xe7, 0x56, 0x32, 0x16, 0xa2, 0x03, 0x28, 0x05, 0xd8,
-	0xf4, 0xe5, 0x65, 0x66, 0xda, 0x6e, 0x81, 0x69, 0x45, 0x06, 0x68, 0x47, 0xd8, 0xf4, 0x0f, 0x68,
-	0xe0, 0xcd, 0x74, 0xf6, 0x1c, 0x7d, 0x01, 0x37, 0xe7, 0xad, 0x3a, 0xb0, 0x2d, 0x2a, 0x97, 0x5e,
-	0xa2, 0xd7, 0x1a, 0x49, 0xbb, 0x3e, 0xb0, 0x68, 0x9e, 0x0b, 0x4f, 0xe5, 0xf2, 0xd5, 0xb8, 0xf0,
-	0x14, 0xdd, 0x87, 0x46, 0x3c, 0x7c, 0x98, 0x55, 0x37, 0x18, 0xd3, 0xab, 0x0b, 0x4c, 0xfb, 0x02,
-	0xc4, 0x89, 0x7e, 0x8b, 0x88, 0xea, 0xf1, 0xc3, 0xc8, 0xa6, 0x0c, 0x0f, 0x9e, 0xca, 0x95, 0xab,
-	0xf0, 0xe0, 0x29, 0xba, 0x03, 0x40, 0x43, 0x7b, 0xc0, 0xba, 0xc6, 0x97, 0xab, 0x1b, 0xd2, 0x4e,
-	0x59, 0xaf, 0xd1, 0xd0, 0x66, 0x41, 0xf6, 0x95, 0x0f, 0xa1, 0x96, 0x44, 0x16, 0xad, 0xc2, 0xf2,
-	0x09, 0x99, 0x89, 0xdc, 0x46, 0x9f, 0xa8, 0x05, 0xe5, 0x09, 0x1e, 0x87, 0x71, 0x2a, 0xf9, 0xe1,
-	0xe3, 0xa5, 0x8f, 0x24, 0x55, 0x87, 0xb5, 0xfb, 0x16, 0x35, 0x38, 0x4d, 0xdc, 0x32, 0x9f, 0x42,
-	0xf9, 0x79, 0x94, 0x37, 0x31, 0x42, 0xb6, 0x2f, 0x99, 0x5c, 0x9d, 0xbf, 0x52, 0x0f, 0x00, 0x45,
-	0x23, 0x25, 0x29, 0xfa, 0xbd, 0xe3, 0x90, 0x9e, 0xa0, 0x0e, 0x94, 0xa3, 0xf6, 0x88, 0x87, 0x5d,
-	0xd1, 0x5c, 0x12, 0x23, 0x8e, 0xe3, 0xd4, 0x23, 0x68, 0x26, 0xa6, 0x1d, 0xee, 0x5f, 0x97, 0x71,
-	0x13, 0x68, 0x65, 0x59, 0x45, 0x63, 0x3e, 0x85, 0x5a, 0x3c, 0xe4, 0xb8, 0x89, 0x8d, 0xde, 0xbd,
-	0xab, 0x4e, 0xb9, 0x6a, 0xc2, 0x5e, 0x15, 0x63, 0xce, 0x57, 0x6f, 0x41, 0x73, 0x0f, 0xbb, 0x78,
-	0x68, 0x8d, 0xad, 0x60, 0xbe, 0xaa, 0x54, 0x0f, 0x5a, 0x59, 0xb1, 0x30, 0xe7, 0x5d, 0x58, 0xc3,
-	0xde, 0xe8, 0xd8, 0x9a, 0x88, 0xe9, 0x8c, 0x0d, 0xe2, 0x31, 0x8f, 0xab, 0xfa, 0xe2, 0x45, 0x0e,
-	0xcd, 0x86, 0xba, 0xc7, 0x72, 0x9d, 0x45, 0xf3, 0x8b, 0xee, 0x33, 0x58, 0x9d, 0x9f, 0x1e, 0x8e,
-	0x43, 0xd3, 0xa2, 0xe8, 0x3b, 0xa8, 0x25, 0xbb, 0x00, 0xbd, 0x59, 0x10, 0xd3, 0xfc, 0x9a, 0x51,
-	0xde, 0x3a, 0x1f, 0xc4, 0xfd, 0xe8, 0xfe, 0xbb, 0xcc, 0x95, 0x71, 0x43, 0x85, 0xb2, 0xc7, 0x50,
-	0x8d, 0x77, 0x0c, 0x52, 0x0b, 0x68, 0x72, 0x0b, 0x48, 0xd9, 0x2c, 0xc0, 0x2c, 0x56, 0xd8, 0xfb,
-	0x12, 0xfa, 0x01, 0xea, 0xa9, 0xb5, 0x81, 0x36, 0x8b, 0xb9, 0x73, 0xcb, 0x46, 0xd9, 0xba, 0x08,
-	0x26, 0x72, 0x32, 0x84, 0x95, 0xcc, 0x50, 0x47, 0xdb, 0xc5, 0x0f, 0x17, 0x76, 0x90, 0xb2, 0x73,
-	0x31, 0x50, 0xe8, 0x78, 0x02, 0x30, 0xef, 0x47, 0x54, 0x14, 0xe3, 0x85, 0x76, 0xbd, 0x7c, 0x78,
-	0x06, 0xd0, 0x48, 0xd7, 0x3e, 0xda, 0x3a, 0x8f, 0x7e, 0xde, 0x72, 0xca, 0xf6, 0x85, 0x38, 0x91,
-	0xed, 0x29, 0xdc, 0xbe, 0x97, 0x2f, 0x37, 0x91, 0xf3, 0x1f, 0xc5, 0x9f, 0x4a, 0xea, 0xfe, 0x3a,
-	0xeb, 0x6c, 0x96, 0xd1, 0x9c, 0xa9, 0xb6, 0xa7, 0xec, 0x8f, 0x46, 0xdc, 0x5e, 0x7f, 0xd1, 0x75,
-	0x7f, 0x91, 0x40, 0xce, 0xfe, 0xe5, 0xa5, 0x94, 0x1f, 0x33, 0xe5, 0xe9, 0x6b, 0xf4, 0x76, 0xb1,
-	0xf2, 0x82, 0x1f, 0x59, 0xe5, 0x9d, 0xcb, 0x40, 0x45, 0x04, 0x42, 0x40, 0x5c, 0x67, 0x7a, 0x9e,
-	0x44, 0x29, 0xcf, 0x9c, 0x8b, 0x52, 0x5e, 0x30, 0x97, 0x0a, 0x53, 0x5e, 0x34, 0xa8, 0x7a, 0xf2,
-	0x8b, 0xb3, 0x75, 0xe9, 0xcf, 0xb3, 0x75, 0xe9, 0x9f, 0xb3, 0x75, 0xe9, 0x7b, 0x10, 0xf0, 0xc1,
-	0x64, 0x77, 0x78, 0x83, 0x2d, 0xb7, 0x0f, 0xfe, 0x0b, 0x00, 0x00, 0xff, 0xff, 0xf0, 0x66, 0x94,
-	0xe3, 0x2f, 0x0c, 0x00, 0x00,
+	// 1195 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x57, 0xcf, 0x73, 0xdb, 0xc4,
+	0x17, 0xff, 0xaa, 0x76, 0x1a, 0xf9, 0xd9, 0x6d, 0x93, 0xb5, 0xf3, 0xad, 0x10, 0x6d, 0x12, 0x04,
+	0xf9, 0x01, 0x03, 0x36, 0x71, 0x87, 0x81, 0x81, 0x32, 0x90, 0xdf, 0x13, 0x68, 0xa1, 0xa8, 0x19,
+	0x3a, 0x43, 0x43, 0x3d, 0x6b, 0x6b, 0xe3, 0x6c, 0x63, 0xaf, 0x1c, 0x69, 0x65, 0x92, 0x43, 0x6f,
+	0xfc, 0x01, 0x1c, 0x39, 0x30, 0x5c, 0xf9, 0x57, 0x7a, 0x64, 0x86, 0x1b, 0x87, 0xc0, 0xe4, 0xca,
+	0x3f, 0xc1, 0x68, 0x77, 0x25, 0x4b, 0xb6, 0x26, 0x4e, 0x43, 0x6e, 0xda, 0xb7, 0x9f, 0xfd, 0xbc,
+	0xf7, 0xf6, 0xbd, 0xfd, 0x3c, 0x1b, 0x6e, 0xf8, 0xdc, 0xf5, 0x70, 0x9b, 0x54, 0x7b, 0x9e, 0xcb,
+	0x5d, 0x34, 0xfd, 0x1c, 0x93, 0x36, 0xf1, 0xaa, 0x91, 0xb5, 0xbf, 0x62, 0x56, 0xda, 0x6e, 0xdb,
+	0x15, 0xbb, 0xb5, 0xf0, 0x4b, 0x02, 0xcd, 0xb9, 0xb6, 0xeb, 0xb6, 0x3b, 0xa4, 0x26, 0x56, 0xcd,
+	0x60, 0xbf, 0xc6, 0x69, 0x97, 0xf8, 0x1c, 0x77, 0x7b, 0x0a, 0x30, 0x3b, 0x0c, 0x70, 0x02, 0x0f,
+	0x73, 0xea, 0x32, 0xb5, 0x5f, 0xec, 0xba, 0x0e, 0xe9, 0xc8, 0x85, 0xf5, 0xab, 0x06, 0xff, 0xdf,
+	0x26, 0x7c, 0x83, 0xf4, 0x08, 0x73, 0x08, 0x6b, 0x51, 0xe2, 0xdb, 0xe4, 0x28, 0x20, 0x3e, 0x47,
+	0xeb, 0x00, 0x3e, 0xc7, 0x1e, 0x6f, 0x84, 0x0e, 0x0c, 0x6d, 0x5e, 0x5b, 0x2e, 0xd6, 0xcd, 0xaa,
+	0x24, 0xaf, 0x46, 0xe4, 0xd5, 0xdd, 0xc8, 0xfb, 0x9a, 0xfe, 0xf2, 0x74, 0xee, 0x7f, 0x3f, 0xfd,
+	0x35, 0xa7, 0xd9, 0x05, 0x71, 0x2e, 0xdc, 0x41, 0x9f, 0x81, 0x4e, 0x98, 0x23, 0x29, 0xae, 0xbd,
+	0x02, 0xc5, 0x24, 0x61, 0x4e, 0x68, 0xb7, 0x9a, 0x70, 0x7b, 0x24, 0x3e, 0xbf, 0xe7, 0x32, 0x9f,
+	0xa0, 0x6d, 0x28, 0x39, 0x09, 0xbb, 0xa1, 0xcd, 0xe7, 0x96, 0x8b, 0xf5, 0xbb, 0x55, 0x75, 0x93,
+	0xb8, 0x47, 0x1b, 0xfd, 0x7a, 0x35, 0x3e, 0x7a, 0xf2, 0x80, 0xb2, 0xc3, 0xb5, 0x7c, 0xe8, 0xc2,
+	0x4e, 0x1d, 0xb4, 0x3e, 0x81, 0xa9, 0x27, 0x1e, 0xe5, 0xe4, 0x71, 0x0f, 0xb3, 0x28, 0xfb, 0x25,
+	0xc8, 0xfb, 0x3d, 0xcc, 0x54, 0xde, 0xe5, 0x21, 0x52, 0x81, 0x14, 0x00, 0xab, 0x0c, 0xd3, 0x89,
+	0xc3, 0x32, 0x34, 0x8b, 0xc1, 0xad, 0x6d, 0xc2, 0x77, 0x3d, 0xdc, 0x22, 0x11, 0xe1, 0x53, 0xd0,
+	0x79, 0xb8, 0x6e, 0x50, 0x47, 0x90, 0x96, 0xd6, 0x3e, 0x0f, 0x43, 0xf9, 0xf3, 0x74, 0xee, 0xbd,
+	0x36, 0xe5, 0x07, 0x41, 0xb3, 0xda, 0x72, 0xbb, 0x35, 0xe9, 0x26, 0x04, 0x52, 0xd6, 0x56, 0xab,
+	0x9a, 0x2c, 0x98, 0x60, 0xdb, 0xd9, 0x38, 0x3b, 0x9d, 0x9b, 0x54, 0x9f, 0xf6, 0xa4, 0x60, 0xdc,
+	0x71, 0xac, 0x0a, 0xa0, 0x6d, 0xc2, 0x1f, 0x13, 0xaf, 0x4f, 0x5b, 0x71, 0x05, 0xad, 0x15, 0x28,
+	0xa7, 0xac, 0xea, 0xde, 0x4c, 0xd0, 0x7d, 0x65, 0x13, 0x77, 0x56, 0xb0, 0xe3, 0xb5, 0xf5, 0x10,
+	0x2a, 0xdb, 0x84, 0x7f, 0xdd, 0x23, 0xb2, 0x65, 0xe2, 0x66, 0x30, 0x60, 0x52, 0x61, 0x44, 0xf0,
+	0x05, 0x3b, 0x5a, 0xa2, 0xd7, 0xa1, 0x10, 0xde, 0x43, 0xe3, 0x90, 0x32, 0x47, 0x94, 0x38, 0xa4,
+	0xeb, 0x61, 0xf6, 0x25, 0x65, 0x8e, 0x75, 0x1f, 0x0a, 0x31, 0x17, 0x42, 0x90, 0x67, 0xb8, 0x1b,
+	0x11, 0x88, 0xef, 0xf3, 0x4f, 0xbf, 0x80, 0x99, 0xa1, 0x60, 0x54, 0x06, 0x8b, 0x70, 0xd3, 0x8d,
+	0xac, 0x5f, 0xe1, 0x6e, 0x9c, 0xc7, 0x90, 0x15, 0xdd, 0x07, 0x88, 0x2d, 0xbe, 0x71, 0x4d, 0xf4,
+	0xc7, 0x9d, 0xea, 0xc8, 0x4b, 0xab, 0xc6, 0x2e, 0xec, 0x04, 0xde, 0xfa, 0x2d, 0x0f, 0x15, 0x71,
+	0xd3, 0xdf, 0x04, 0xc4, 0x3b, 0x79, 0x84, 0x3d, 0xdc, 0x25, 0x9c, 0x78, 0x3e, 0x7a, 0x03, 0x4a,
+	0x2a, 0xfb, 0x46, 0x22, 0xa1, 0xa2, 0xb2, 0x85, 0xae, 0xd1, 0x42, 0x22, 0x42, 0x09, 0x92, 0xc9,
+	0xdd, 0x48, 0x45, 0x88, 0x36, 0x21, 0xcf, 0x71, 0xdb, 0x37, 0x72, 0x22, 0xb4, 0x95, 0x8c, 0xd0,
+	0xb2, 0x02, 0xa8, 0xee, 0xe2, 0xb6, 0xbf, 0xc9, 0xb8, 0x77, 0x62, 0x8b, 0xe3, 0xe8, 0x0b, 0xb8,
+	0x39, 0x78, 0xaa, 0x8d, 0x2e, 0x65, 0x46, 0xfe, 0x15, 0xde, 0x5a, 0x29, 0x7e, 0xae, 0x0f, 0x29,
+	0x1b, 0xe6, 0xc2, 0xc7, 0xc6, 0xc4, 0xe5, 0xb8, 0xf0, 0x31, 0xda, 0x82, 0x52, 0x24, 0x3e, 0x22,
+	0xaa, 0xeb, 0x82, 0xe9, 0xb5, 0x11, 0xa6, 0x0d, 0x05, 0x92, 0x44, 0x3f, 0x87, 0x44, 0xc5, 0xe8,
+	0x60, 0x18, 0x53, 0x8a, 0x07, 0x1f, 0x1b, 0x93, 0x97, 0xe1, 0xc1, 0xc7, 0xe8, 0x2e, 0x00, 0x0b,
+	0xba, 0x0d, 0xf1, 0x6a, 0x7c, 0x43, 0x9f, 0xd7, 0x96, 0x27, 0xec, 0x02, 0x0b, 0xba, 0xe2, 0x92,
+	0x7d, 0xf3, 0x43, 0x28, 0xc4, 0x37, 0x8b, 0xa6, 0x20, 0x77, 0x48, 0x4e, 0x54, 0x6d, 0xc3, 0x4f,
+	0x54, 0x81, 0x89, 0x3e, 0xee, 0x04, 0x51, 0x29, 0xe5, 0xe2, 0xe3, 0x6b, 0x1f, 0x69, 0x96, 0x0d,
+	0xd3, 0x5b, 0x94, 0x39, 0x92, 0x26, 0x7a, 0x32, 0x9f, 0xc2, 0xc4, 0x51, 0x58, 0x37, 0x25, 0x21,
+	0x4b, 0x17, 0x2c, 0xae, 0x2d, 0x4f, 0x59, 0x9b, 0x80, 0x42, 0x49, 0x89, 0x9b, 0x7e, 0xfd, 0x20,
+	0x60, 0x87, 0xa8, 0x06, 0x13, 0xe1, 0xf3, 0x88, 0xc4, 0x2e, 0x4b, 0x97, 0x94, 0xc4, 0x49, 0x9c,
+	0xb5, 0x0b, 0xe5, 0x38, 0xb4, 0x9d, 0x8d, 0xab, 0x0a, 0xae, 0x0f, 0x95, 0x34, 0xab, 0x7a, 0x98,
+	0xcf, 0xa0, 0x10, 0x89, 0x9c, 0x0c, 0xb1, 0xb4, 0xb6, 0x7a, 0x59, 0x95, 0xd3, 0x63, 0x76, 0x5d,
+	0xc9, 0x9c, 0x6f, 0xbd, 0x00, 0xb4, 0xda, 0x3a, 0x0a, 0xa8, 0x47, 0x1e, 0xb8, 0xad, 0xc3, 0x28,
+	0x19, 0x13, 0x74, 0x8f, 0xf8, 0x6e, 0xe0, 0xc5, 0xea, 0x14, 0xaf, 0xc3, 0xa2, 0xb9, 0x3f, 0x30,
+	0xe2, 0x45, 0x45, 0x13, 0x0b, 0xf4, 0x01, 0xe4, 0x38, 0xef, 0x18, 0xb9, 0x8b, 0xf7, 0x51, 0x88,
+	0x0f, 0x05, 0x35, 0xe5, 0x7e, 0x20, 0xa8, 0x58, 0x9a, 0xa5, 0xb4, 0xeb, 0x76, 0xbc, 0xb6, 0xb6,
+	0x00, 0x6d, 0xb9, 0xde, 0x3e, 0xa1, 0xfc, 0x3f, 0x45, 0x6c, 0xdd, 0x83, 0x72, 0x8a, 0x47, 0xb9,
+	0xbe, 0x03, 0x85, 0x7d, 0x69, 0x8e, 0x7d, 0x0f, 0x0c, 0xd6, 0x0c, 0x94, 0xd7, 0x71, 0x0f, 0x37,
+	0x69, 0x87, 0xf2, 0xc1, 0x64, 0xb7, 0x7e, 0xd1, 0xa0, 0x92, 0xb6, 0x2b, 0xb6, 0x77, 0x61, 0x1a,
+	0x7b, 0xad, 0x03, 0xda, 0x57, 0xd3, 0x0c, 0x3b, 0xc4, 0x53, 0xac, 0xa3, 0x1b, 0x43, 0x68, 0x31,
+	0x04, 0x65, 0xd0, 0xba, 0x3d, 0xba, 0x81, 0x96, 0xe1, 0x96, 0x43, 0x7d, 0xee, 0xd1, 0x66, 0xc0,
+	0x89, 0x13, 0x26, 0x21, 0xae, 0x5f, 0xb7, 0x87, 0xcd, 0xf5, 0xe7, 0x30, 0x35, 0x38, 0xf7, 0xa8,
+	0x13, 0xb4, 0x29, 0x43, 0xdf, 0x42, 0x21, 0x9e, 0xb2, 0xe8, 0xcd, 0x8c, 0x6e, 0x1d, 0x1e, 0xe0,
+	0xe6, 0x5b, 0xe7, 0x83, 0x64, 0xc6, 0xf5, 0x7f, 0x72, 0x30, 0x35, 0x48, 0x49, 0x39, 0x7b, 0x02,
+	0x7a, 0x34, 0xbd, 0x91, 0x95, 0x41, 0x33, 0x34, 0xda, 0xcd, 0x85, 0x0c, 0xcc, 0xe8, 0xdb, 0x7d,
+	0x5f, 0x43, 0x7b, 0x50, 0x4c, 0x0c, 0x64, 0xb4, 0x90, 0xcd, 0x3d, 0x34, 0xc6, 0xcd, 0xc5, 0x71,
+	0x30, 0x55, 0xbd, 0x26, 0xdc, 0x48, 0x8d, 0x4b, 0xb4, 0x94, 0x7d, 0x70, 0x64, 0xba, 0x9b, 0xcb,
+	0xe3, 0x81, 0xca, 0xc7, 0x53, 0x80, 0x81, 0xd2, 0xa1, 0xac, 0x3b, 0x1e, 0x11, 0xc2, 0x8b, 0x5f,
+	0x4f, 0x03, 0x4a, 0x49, 0x55, 0x41, 0x8b, 0xe7, 0xd1, 0x0f, 0xc4, 0xcc, 0x5c, 0x1a, 0x8b, 0x53,
+	0xd5, 0x3e, 0x86, 0xdb, 0xab, 0xc3, 0x8d, 0xa9, 0x6a, 0xfe, 0xbd, 0xfa, 0x0d, 0x98, 0xd8, 0xbf,
+	0xca, 0x3e, 0x3b, 0x49, 0x79, 0x4e, 0x75, 0xdb, 0x33, 0xf1, 0x5b, 0x51, 0xed, 0x5e, 0x7d, 0xd3,
+	0xd5, 0x7f, 0xd4, 0xc0, 0x48, 0xff, 0x7e, 0x4e, 0x38, 0x3f, 0x10, 0xce, 0x93, 0xdb, 0xe8, 0xed,
+	0x6c, 0xe7, 0x19, 0x7f, 0x11, 0xcc, 0x77, 0x2e, 0x02, 0x55, 0x37, 0xf0, 0x87, 0x06, 0x33, 0x1b,
+	0xe9, 0x97, 0xae, 0x62, 0xd8, 0x83, 0x62, 0x42, 0x55, 0x33, 0x5f, 0xc5, 0xa8, 0xe8, 0x9b, 0x8b,
+	0xe3, 0x60, 0xaa, 0x63, 0xf7, 0xa0, 0x98, 0x10, 0xce, 0x4c, 0xf6, 0x51, 0x81, 0x36, 0x17, 0xc7,
+	0xc1, 0x54, 0x56, 0x01, 0x20, 0x99, 0x45, 0x52, 0x4f, 0xc3, 0x46, 0x4e, 0xad, 0xb3, 0xd8, 0x32,
+	0x84, 0xd9, 0x5c, 0x1a, 0x8b, 0x93, 0x6e, 0xd7, 0x8c, 0x97, 0x67, 0xb3, 0xda, 0xef, 0x67, 0xb3,
+	0xda, 0xdf, 0x67, 0xb3, 0xda, 0x77, 0xa0, 0xe0, 0x8d, 0xfe, 0x4a, 0xf3, 0xba, 0x18, 0x62, 0xf7,
+	0xfe, 0x1d, 0x00, 0xf3, 0x51, 0x9c, 0xe2, 0x5f, 0x0e, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1589,6 +1822,116 @@ var _DependenciesReaderPlugin_serviceDesc = grpc.ServiceDesc{
 	Metadata: "storage.proto",
 }
 
+// DistributedLockPluginClient is the client API for DistributedLockPlugin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type DistributedLockPluginClient interface {
+	// distributedlock/Lock
+	AcquireLock(ctx context.Context, in *AcquireLockRequest, opts ...grpc.CallOption) (*AcquireLockResponse, error)
+	ForfeitLock(ctx context.Context, in *ForfeitLockRequest, opts ...grpc.CallOption) (*ForfeitLockResponse, error)
+}
+
+type distributedLockPluginClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewDistributedLockPluginClient(cc *grpc.ClientConn) DistributedLockPluginClient {
+	return &distributedLockPluginClient{cc}
+}
+
+func (c *distributedLockPluginClient) AcquireLock(ctx context.Context, in *AcquireLockRequest, opts ...grpc.CallOption) (*AcquireLockResponse, error) {
+	out := new(AcquireLockResponse)
+	err := c.cc.Invoke(ctx, "/jaeger.storage.v1.DistributedLockPlugin/AcquireLock", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *distributedLockPluginClient) ForfeitLock(ctx context.Context, in *ForfeitLockRequest, opts ...grpc.CallOption) (*ForfeitLockResponse, error) {
+	out := new(ForfeitLockResponse)
+	err := c.cc.Invoke(ctx, "/jaeger.storage.v1.DistributedLockPlugin/ForfeitLock", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DistributedLockPluginServer is the server API for DistributedLockPlugin service.
+type DistributedLockPluginServer interface {
+	// distributedlock/Lock
+	AcquireLock(context.Context, *AcquireLockRequest) (*AcquireLockResponse, error)
+	ForfeitLock(context.Context, *ForfeitLockRequest) (*ForfeitLockResponse, error)
+}
+
+// UnimplementedDistributedLockPluginServer can be embedded to have forward compatible implementations.
+type UnimplementedDistributedLockPluginServer struct {
+}
+
+func (*UnimplementedDistributedLockPluginServer) AcquireLock(ctx context.Context, req *AcquireLockRequest) (*AcquireLockResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcquireLock not implemented")
+}
+func (*UnimplementedDistributedLockPluginServer) ForfeitLock(ctx context.Context, req *ForfeitLockRequest) (*ForfeitLockResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ForfeitLock not implemented")
+}
+
+func RegisterDistributedLockPluginServer(s *grpc.Server, srv DistributedLockPluginServer) {
+	s.RegisterService(&_DistributedLockPlugin_serviceDesc, srv)
+}
+
+func _DistributedLockPlugin_AcquireLock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AcquireLockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DistributedLockPluginServer).AcquireLock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/jaeger.storage.v1.DistributedLockPlugin/AcquireLock",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DistributedLockPluginServer).AcquireLock(ctx, req.(*AcquireLockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DistributedLockPlugin_ForfeitLock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ForfeitLockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DistributedLockPluginServer).ForfeitLock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/jaeger.storage.v1.DistributedLockPlugin/ForfeitLock",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DistributedLockPluginServer).ForfeitLock(ctx, req.(*ForfeitLockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _DistributedLockPlugin_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "jaeger.storage.v1.DistributedLockPlugin",
+	HandlerType: (*DistributedLockPluginServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AcquireLock",
+			Handler:    _DistributedLockPlugin_AcquireLock_Handler,
+		},
+		{
+			MethodName: "ForfeitLock",
+			Handler:    _DistributedLockPlugin_ForfeitLock_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "storage.proto",
+}
+
 // PluginCapabilitiesClient is the client API for PluginCapabilities service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
@@ -2300,7 +2643,7 @@ func (m *FindTraceIDsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
-func (m *CapabilitiesRequest) Marshal() (dAtA []byte, err error) {
+func (m *AcquireLockRequest) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -2310,12 +2653,12 @@ func (m *CapabilitiesRequest) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *CapabilitiesRequest) MarshalTo(dAtA []byte) (int, error) {
+func (m *AcquireLockRequest) MarshalTo(dAtA []byte) (int, error) {
 	size := m.Size()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *CapabilitiesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *AcquireLockRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
@@ -2324,10 +2667,32 @@ func (m *CapabilitiesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	n10, err10 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.Ttl, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.Ttl):])
+	if err10 != nil {
+		return 0, err10
+	}
+	i -= n10
+	i = encodeVarintStorage(dAtA, i, uint64(n10))
+	i--
+	dAtA[i] = 0x1a
+	if len(m.Owner) > 0 {
+		i -= len(m.Owner)
+		copy(dAtA[i:], m.Owner)
+		i = encodeVarintStorage(dAtA, i, uint64(len(m.Owner)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Resource) > 0 {
+		i -= len(m.Resource)
+		copy(dAtA[i:], m.Resource)
+		i = encodeVarintStorage(dAtA, i, uint64(len(m.Resource)))
+		i--
+		dAtA[i] = 0xa
+	}
 	return len(dAtA) - i, nil
 }
 
-func (m *CapabilitiesResponse) Marshal() (dAtA []byte, err error) {
+func (m *AcquireLockResponse) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -2337,12 +2702,12 @@ func (m *CapabilitiesResponse) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *CapabilitiesResponse) MarshalTo(dAtA []byte) (int, error) {
+func (m *AcquireLockResponse) MarshalTo(dAtA []byte) (int, error) {
 	size := m.Size()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *CapabilitiesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *AcquireLockResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
@@ -2351,19 +2716,9 @@ func (m *CapabilitiesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	if m.ArchiveSpanWriter {
-		i--
-		if m.ArchiveSpanWriter {
-			dAtA[i] = 1
-		} else {
-			dAtA[i] = 0
-		}
-		i--
-		dAtA[i] = 0x10
-	}
-	if m.ArchiveSpanReader {
+	if m.Acquired {
 		i--
-		if m.ArchiveSpanReader {
+		if m.Acquired {
 			dAtA[i] = 1
 		} else {
 			dAtA[i] = 0
@@ -2374,26 +2729,188 @@ func (m *CapabilitiesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
-func encodeVarintStorage(dAtA []byte, offset int, v uint64) int {
-	offset -= sovStorage(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+func (m *ForfeitLockRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	dAtA[offset] = uint8(v)
-	return base
+	return dAtA[:n], nil
 }
-func (m *GetDependenciesRequest) Size() (n int) {
-	if m == nil {
-		return 0
-	}
+
+func (m *ForfeitLockRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ForfeitLockRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	l = github_com_gogo_protobuf_types.SizeOfStdTime(m.StartTime)
-	n += 1 + l + sovStorage(uint64(l))
-	l = github_com_gogo_protobuf_types.SizeOfStdTime(m.EndTime)
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Owner) > 0 {
+		i -= len(m.Owner)
+		copy(dAtA[i:], m.Owner)
+		i = encodeVarintStorage(dAtA, i, uint64(len(m.Owner)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Resource) > 0 {
+		i -= len(m.Resource)
+		copy(dAtA[i:], m.Resource)
+		i = encodeVarintStorage(dAtA, i, uint64(len(m.Resource)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ForfeitLockResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ForfeitLockResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ForfeitLockResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Forfeited {
+		i--
+		if m.Forfeited {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *CapabilitiesRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CapabilitiesRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *CapabilitiesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *CapabilitiesResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CapabilitiesResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *CapabilitiesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.DistributedLock {
+		i--
+		if m.DistributedLock {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.ArchiveSpanWriter {
+		i--
+		if m.ArchiveSpanWriter {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.ArchiveSpanReader {
+		i--
+		if m.ArchiveSpanReader {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintStorage(dAtA []byte, offset int, v uint64) int {
+	offset -= sovStorage(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *GetDependenciesRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = github_com_gogo_protobuf_types.SizeOfStdTime(m.StartTime)
+	n += 1 + l + sovStorage(uint64(l))
+	l = github_com_gogo_protobuf_types.SizeOfStdTime(m.EndTime)
 	n += 1 + l + sovStorage(uint64(l))
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
@@ -2662,6 +3179,78 @@ func (m *FindTraceIDsResponse) Size() (n int) {
 	return n
 }
 
+func (m *AcquireLockRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Resource)
+	if l > 0 {
+		n += 1 + l + sovStorage(uint64(l))
+	}
+	l = len(m.Owner)
+	if l > 0 {
+		n += 1 + l + sovStorage(uint64(l))
+	}
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.Ttl)
+	n += 1 + l + sovStorage(uint64(l))
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *AcquireLockResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Acquired {
+		n += 2
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ForfeitLockRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Resource)
+	if l > 0 {
+		n += 1 + l + sovStorage(uint64(l))
+	}
+	l = len(m.Owner)
+	if l > 0 {
+		n += 1 + l + sovStorage(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ForfeitLockResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Forfeited {
+		n += 2
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
 func (m *CapabilitiesRequest) Size() (n int) {
 	if m == nil {
 		return 0
@@ -2686,6 +3275,9 @@ func (m *CapabilitiesResponse) Size() (n int) {
 	if m.ArchiveSpanWriter {
 		n += 2
 	}
+	if m.DistributedLock {
+		n += 2
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -4341,7 +4933,7 @@ func (m *FindTraceIDsResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *CapabilitiesRequest) Unmarshal(dAtA []byte) error {
+func (m *AcquireLockRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -4364,37 +4956,442 @@ func (m *CapabilitiesRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: CapabilitiesRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: AcquireLockRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: CapabilitiesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: AcquireLockRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		default:
-			iNdEx = preIndex
-			skippy, err := skipStorage(dAtA[iNdEx:])
-			if err != nil {
-				return err
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Resource", wireType)
 			}
-			if (skippy < 0) || (iNdEx+skippy) < 0 {
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStorage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthStorage
 			}
-			if (iNdEx + skippy) > l {
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthStorage
+			}
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *CapabilitiesResponse) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
+			m.Resource = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Owner", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStorage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthStorage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthStorage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Owner = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Ttl", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStorage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStorage
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStorage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.Ttl, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipStorage(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthStorage
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *AcquireLockResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowStorage
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: AcquireLockResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: AcquireLockResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Acquired", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStorage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Acquired = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipStorage(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthStorage
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ForfeitLockRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowStorage
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ForfeitLockRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ForfeitLockRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Resource", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStorage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthStorage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthStorage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Resource = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Owner", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStorage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthStorage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthStorage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Owner = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipStorage(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthStorage
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ForfeitLockResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowStorage
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ForfeitLockResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ForfeitLockResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Forfeited", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStorage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Forfeited = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipStorage(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthStorage
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *CapabilitiesRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowStorage
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CapabilitiesRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CapabilitiesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipStorage(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthStorage
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *CapabilitiesResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
 	for iNdEx < l {
 		preIndex := iNdEx
 		var wire uint64
@@ -4461,6 +5458,26 @@ func (m *CapabilitiesResponse) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.ArchiveSpanWriter = bool(v != 0)
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DistributedLock", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStorage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.DistributedLock = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStorage(dAtA[iNdEx:])
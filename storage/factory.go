@@ -21,8 +21,10 @@ import (
 	"github.com/uber/jaeger-lib/metrics"
 	"go.uber.org/zap"
 
+	"github.com/jaegertracing/jaeger/pkg/distributedlock"
 	"github.com/jaegertracing/jaeger/storage/dependencystore"
 	metricsstore "github.com/jaegertracing/jaeger/storage/metricsstore"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 )
 
@@ -53,6 +55,14 @@ var (
 
 	// ErrArchiveStorageNotSupported can be returned by the ArchiveFactory when the archive storage is not supported by the backend.
 	ErrArchiveStorageNotSupported = errors.New("archive storage not supported")
+
+	// ErrSamplingStrategiesNotSupported can be returned by the SamplingStrategiesFactory when the backend
+	// cannot store sampling strategies.
+	ErrSamplingStrategiesNotSupported = errors.New("sampling strategies storage not supported")
+
+	// ErrSamplingStoreNotSupported can be returned by the SamplingStoreFactory when the backend
+	// cannot store the adaptive sampling data.
+	ErrSamplingStoreNotSupported = errors.New("sampling storage not supported")
 )
 
 // ArchiveFactory is an additional interface that can be implemented by a factory to support trace archiving.
@@ -64,6 +74,23 @@ type ArchiveFactory interface {
 	CreateArchiveSpanWriter() (spanstore.Writer, error)
 }
 
+// SamplingStrategiesFactory is an additional interface that can be implemented by a factory to store
+// the sampling strategies managed at runtime.
+type SamplingStrategiesFactory interface {
+	// CreateSamplingStrategiesStore creates a samplingstore.StrategiesStore.
+	CreateSamplingStrategiesStore() (samplingstore.StrategiesStore, error)
+}
+
+// SamplingStoreFactory is an additional interface that can be implemented by a factory to store
+// the throughput and the probabilities of the adaptive sampling.
+type SamplingStoreFactory interface {
+	// CreateSamplingStore creates a samplingstore.Store.
+	CreateSamplingStore() (samplingstore.Store, error)
+
+	// CreateLock creates the distributed lock electing the collector which calculates the probabilities.
+	CreateLock() (distributedlock.Lock, error)
+}
+
 // MetricsFactory defines an interface for a factory that can create implementations of different metrics storage components.
 // Implementations are also encouraged to implement plugin.Configurable interface.
 //
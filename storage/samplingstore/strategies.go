@@ -0,0 +1,86 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package samplingstore
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrStrategyNotFound is returned by a StrategiesStore when a service has no stored strategy.
+	ErrStrategyNotFound = errors.New("sampling strategy not found")
+	// ErrVersionConflict is returned by a StrategiesStore when the stored strategy was changed concurrently.
+	ErrVersionConflict = errors.New("sampling strategy was changed concurrently")
+)
+
+// Strategy is the sampling strategy of a service managed at runtime.
+type Strategy struct {
+	// Service is the service name, or the service pattern, of the strategy
+	Service string
+	// Document is the strategy in JSON, in the format of the service strategies of the static strategies file
+	Document []byte
+	// Version is incremented by every change of the strategy, starting at 1
+	Version int64
+	// UpdatedBy is the subject who made the latest change
+	UpdatedBy string
+	// UpdatedAt is the time of the latest change
+	UpdatedAt time.Time
+}
+
+// StrategyChange is an entry of the audit trail of the strategies managed at runtime.
+type StrategyChange struct {
+	Service string
+	// Version is the version of the strategy after the change
+	Version int64
+	// Action is the operation which made the change, e.g. "put" or "patch"
+	Action string
+	// Subject is who made the change
+	Subject string
+	Time    time.Time
+	// Before is the document replaced by the change, nil if the change created the strategy
+	Before []byte
+	// After is the document stored by the change
+	After []byte
+}
+
+// Strategy returns the strategy stored by the change.
+func (c *StrategyChange) Strategy() *Strategy {
+	return &Strategy{
+		Service:   c.Service,
+		Document:  c.After,
+		Version:   c.Version,
+		UpdatedBy: c.Subject,
+		UpdatedAt: c.Time,
+	}
+}
+
+// StrategiesStore stores the sampling strategies managed at runtime, shared by all collectors.
+type StrategiesStore interface {
+	// GetStrategies returns the strategies of all services.
+	GetStrategies() ([]*Strategy, error)
+
+	// GetStrategy returns the strategy of a service, or ErrStrategyNotFound.
+	GetStrategy(service string) (*Strategy, error)
+
+	// PutStrategy stores the strategy resulting from the change and appends the change to the audit trail.
+	// It returns ErrVersionConflict unless the stored version is the version preceding the change,
+	// or no strategy is stored for the service and the change creates version 1.
+	PutStrategy(change *StrategyChange) error
+
+	// GetStrategyChanges returns the latest changes of the strategy of a service, the latest first.
+	// All changes are returned if limit is zero.
+	GetStrategyChanges(service string, limit int) ([]*StrategyChange, error)
+}
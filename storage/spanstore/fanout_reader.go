@@ -0,0 +1,184 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanstore
+
+import (
+	"context"
+	"sort"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/multierror"
+)
+
+// FanOutReader is a span Reader that reads from several underlying span Readers and merges
+// their results, e.g. to read back the traces a RoutingWriter split between several Writers.
+type FanOutReader struct {
+	spanReaders []Reader
+}
+
+// NewFanOutReader creates a FanOutReader
+func NewFanOutReader(spanReaders ...Reader) *FanOutReader {
+	return &FanOutReader{
+		spanReaders: spanReaders,
+	}
+}
+
+// GetTrace merges the spans of the trace found by each span reader.
+// It returns ErrTraceNotFound if no span reader finds the trace.
+func (r *FanOutReader) GetTrace(ctx context.Context, traceID model.TraceID) (*model.Trace, error) {
+	var errors []error
+	var merged *model.Trace
+	for _, reader := range r.spanReaders {
+		trace, err := reader.GetTrace(ctx, traceID)
+		if err == ErrTraceNotFound {
+			continue
+		}
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		merged = mergeTraces(merged, trace)
+	}
+	if err := multierror.Wrap(errors); err != nil {
+		return nil, err
+	}
+	if merged == nil {
+		return nil, ErrTraceNotFound
+	}
+	return merged, nil
+}
+
+// GetServices returns the sorted union of the services of all span readers.
+func (r *FanOutReader) GetServices(ctx context.Context) ([]string, error) {
+	var errors []error
+	seen := make(map[string]struct{})
+	var services []string
+	for _, reader := range r.spanReaders {
+		readerServices, err := reader.GetServices(ctx)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		for _, service := range readerServices {
+			if _, ok := seen[service]; !ok {
+				seen[service] = struct{}{}
+				services = append(services, service)
+			}
+		}
+	}
+	if err := multierror.Wrap(errors); err != nil {
+		return nil, err
+	}
+	sort.Strings(services)
+	return services, nil
+}
+
+// GetOperations returns the union of the operations of all span readers.
+func (r *FanOutReader) GetOperations(ctx context.Context, query OperationQueryParameters) ([]Operation, error) {
+	var errors []error
+	seen := make(map[Operation]struct{})
+	var operations []Operation
+	for _, reader := range r.spanReaders {
+		readerOperations, err := reader.GetOperations(ctx, query)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		for _, operation := range readerOperations {
+			if _, ok := seen[operation]; !ok {
+				seen[operation] = struct{}{}
+				operations = append(operations, operation)
+			}
+		}
+	}
+	if err := multierror.Wrap(errors); err != nil {
+		return nil, err
+	}
+	return operations, nil
+}
+
+// FindTraces merges the traces found by each span reader, at most query.NumTraces traces are returned.
+func (r *FanOutReader) FindTraces(ctx context.Context, query *TraceQueryParameters) ([]*model.Trace, error) {
+	var errors []error
+	byID := make(map[model.TraceID]*model.Trace)
+	var traces []*model.Trace
+	for _, reader := range r.spanReaders {
+		readerTraces, err := reader.FindTraces(ctx, query)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		for _, trace := range readerTraces {
+			if len(trace.Spans) == 0 {
+				continue
+			}
+			traceID := trace.Spans[0].TraceID
+			if existing, ok := byID[traceID]; ok {
+				mergeTraces(existing, trace)
+				continue
+			}
+			merged := mergeTraces(nil, trace)
+			byID[traceID] = merged
+			traces = append(traces, merged)
+		}
+	}
+	if err := multierror.Wrap(errors); err != nil {
+		return nil, err
+	}
+	if query.NumTraces > 0 && len(traces) > query.NumTraces {
+		traces = traces[:query.NumTraces]
+	}
+	return traces, nil
+}
+
+// FindTraceIDs returns the union of the trace IDs found by each span reader,
+// at most query.NumTraces trace IDs are returned.
+func (r *FanOutReader) FindTraceIDs(ctx context.Context, query *TraceQueryParameters) ([]model.TraceID, error) {
+	var errors []error
+	seen := make(map[model.TraceID]struct{})
+	var traceIDs []model.TraceID
+	for _, reader := range r.spanReaders {
+		readerTraceIDs, err := reader.FindTraceIDs(ctx, query)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		for _, traceID := range readerTraceIDs {
+			if _, ok := seen[traceID]; !ok {
+				seen[traceID] = struct{}{}
+				traceIDs = append(traceIDs, traceID)
+			}
+		}
+	}
+	if err := multierror.Wrap(errors); err != nil {
+		return nil, err
+	}
+	if query.NumTraces > 0 && len(traceIDs) > query.NumTraces {
+		traceIDs = traceIDs[:query.NumTraces]
+	}
+	return traceIDs, nil
+}
+
+// mergeTraces appends the spans and warnings of the trace to merged, which is created if nil.
+// The traces returned by the span readers are never modified.
+func mergeTraces(merged, trace *model.Trace) *model.Trace {
+	if merged == nil {
+		merged = &model.Trace{}
+	}
+	merged.Spans = append(merged.Spans, trace.Spans...)
+	merged.ProcessMap = append(merged.ProcessMap, trace.ProcessMap...)
+	merged.Warnings = append(merged.Warnings, trace.Warnings...)
+	return merged
+}
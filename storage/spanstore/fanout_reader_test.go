@@ -0,0 +1,117 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanstore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/model"
+	. "github.com/jaegertracing/jaeger/storage/spanstore"
+	"github.com/jaegertracing/jaeger/storage/spanstore/mocks"
+)
+
+func TestFanOutReaderGetTrace(t *testing.T) {
+	traceID := model.NewTraceID(1, 2)
+	frontend := &model.Span{TraceID: traceID, SpanID: 1}
+	vault := &model.Span{TraceID: traceID, SpanID: 2}
+	r1, r2, r3 := &mocks.Reader{}, &mocks.Reader{}, &mocks.Reader{}
+	r1.On("GetTrace", mock.Anything, traceID).Return(&model.Trace{Spans: []*model.Span{frontend}}, nil)
+	r2.On("GetTrace", mock.Anything, traceID).Return(nil, ErrTraceNotFound)
+	r3.On("GetTrace", mock.Anything, traceID).Return(&model.Trace{Spans: []*model.Span{vault}, Warnings: []string{"w"}}, nil)
+
+	trace, err := NewFanOutReader(r1, r2, r3).GetTrace(context.Background(), traceID)
+	require.NoError(t, err)
+	assert.Equal(t, &model.Trace{Spans: []*model.Span{frontend, vault}, Warnings: []string{"w"}}, trace)
+
+	_, err = NewFanOutReader(r2).GetTrace(context.Background(), traceID)
+	assert.Equal(t, ErrTraceNotFound, err)
+
+	failing := &mocks.Reader{}
+	failing.On("GetTrace", mock.Anything, traceID).Return(nil, errors.New("unavailable"))
+	_, err = NewFanOutReader(r1, failing).GetTrace(context.Background(), traceID)
+	assert.EqualError(t, err, "unavailable")
+}
+
+func TestFanOutReaderServicesAndOperations(t *testing.T) {
+	query := OperationQueryParameters{ServiceName: "vault"}
+	r1, r2 := &mocks.Reader{}, &mocks.Reader{}
+	r1.On("GetServices", mock.Anything).Return([]string{"vault", "frontend"}, nil)
+	r2.On("GetServices", mock.Anything).Return([]string{"card-api", "vault"}, nil)
+	r1.On("GetOperations", mock.Anything, query).Return([]Operation{{Name: "get"}}, nil)
+	r2.On("GetOperations", mock.Anything, query).Return([]Operation{{Name: "get"}, {Name: "put", SpanKind: "server"}}, nil)
+	r := NewFanOutReader(r1, r2)
+
+	services, err := r.GetServices(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"card-api", "frontend", "vault"}, services)
+
+	operations, err := r.GetOperations(context.Background(), query)
+	require.NoError(t, err)
+	assert.Equal(t, []Operation{{Name: "get"}, {Name: "put", SpanKind: "server"}}, operations)
+
+	failing := &mocks.Reader{}
+	failing.On("GetServices", mock.Anything).Return(nil, errors.New("unavailable"))
+	failing.On("GetOperations", mock.Anything, query).Return(nil, errors.New("unavailable"))
+	_, err = NewFanOutReader(r1, failing).GetServices(context.Background())
+	assert.EqualError(t, err, "unavailable")
+	_, err = NewFanOutReader(r1, failing).GetOperations(context.Background(), query)
+	assert.EqualError(t, err, "unavailable")
+}
+
+func TestFanOutReaderFindTraces(t *testing.T) {
+	query := &TraceQueryParameters{ServiceName: "frontend", NumTraces: 2}
+	id1, id2, id3 := model.NewTraceID(0, 1), model.NewTraceID(0, 2), model.NewTraceID(0, 3)
+	span := func(traceID model.TraceID, spanID model.SpanID) *model.Span {
+		return &model.Span{TraceID: traceID, SpanID: spanID}
+	}
+	r1, r2 := &mocks.Reader{}, &mocks.Reader{}
+	r1.On("FindTraces", mock.Anything, query).Return([]*model.Trace{
+		{Spans: []*model.Span{span(id1, 1)}},
+		{},
+	}, nil)
+	r2.On("FindTraces", mock.Anything, query).Return([]*model.Trace{
+		{Spans: []*model.Span{span(id1, 2)}},
+		{Spans: []*model.Span{span(id2, 1)}},
+		{Spans: []*model.Span{span(id3, 1)}},
+	}, nil)
+	r1.On("FindTraceIDs", mock.Anything, query).Return([]model.TraceID{id1}, nil)
+	r2.On("FindTraceIDs", mock.Anything, query).Return([]model.TraceID{id1, id2, id3}, nil)
+	r := NewFanOutReader(r1, r2)
+
+	traces, err := r.FindTraces(context.Background(), query)
+	require.NoError(t, err)
+	assert.Equal(t, []*model.Trace{
+		{Spans: []*model.Span{span(id1, 1), span(id1, 2)}},
+		{Spans: []*model.Span{span(id2, 1)}},
+	}, traces)
+
+	traceIDs, err := r.FindTraceIDs(context.Background(), query)
+	require.NoError(t, err)
+	assert.Equal(t, []model.TraceID{id1, id2}, traceIDs)
+
+	failing := &mocks.Reader{}
+	failing.On("FindTraces", mock.Anything, query).Return(nil, errors.New("unavailable"))
+	failing.On("FindTraceIDs", mock.Anything, query).Return(nil, errors.New("unavailable"))
+	_, err = NewFanOutReader(r1, failing).FindTraces(context.Background(), query)
+	assert.EqualError(t, err, "unavailable")
+	_, err = NewFanOutReader(r1, failing).FindTraceIDs(context.Background(), query)
+	assert.EqualError(t, err, "unavailable")
+}
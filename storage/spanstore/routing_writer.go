@@ -0,0 +1,190 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanstore
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/uber/jaeger-lib/metrics"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/glob"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
+)
+
+// DefaultRouteName is the name of the route of the spans not matching any other route.
+const DefaultRouteName = "default"
+
+// SpanRoute sends the spans matching any of its criteria to a span Writer.
+// The criteria only look at the process and the tenant of the spans, never at the spans
+// themselves, so that all the spans a process reports for a trace go to the same Writer.
+type SpanRoute struct {
+	// Name identifies the route in the metrics
+	Name string
+	// Services are glob patterns matched against the service name of the process
+	Services []string
+	// Tags are glob patterns matched against the process tag with the same key
+	Tags map[string]string
+	// Tenants are glob patterns matched against the tenant of the span
+	Tenants []string
+	// Writer saves the spans of the route
+	Writer Writer
+}
+
+// RoutingOptions contains the options for constructing a RoutingWriter.
+type RoutingOptions struct {
+	// Routes are evaluated in order, the first matching route wins
+	Routes []SpanRoute
+	// DefaultWriter saves the spans not matching any route
+	DefaultWriter  Writer
+	MetricsFactory metrics.Factory
+}
+
+type routeMetrics struct {
+	SpansRouted metrics.Counter `metric:"spans_routed"`
+	SpansFailed metrics.Counter `metric:"spans_failed"`
+}
+
+type route struct {
+	SpanRoute
+	metrics routeMetrics
+}
+
+// RoutingWriter is a span Writer that saves each span into the Writer of the first route it matches.
+//
+// Spans of a trace reported by different services can match different routes, so a trace
+// may be split between several Writers. Use a FanOutReader over the readers of all the
+// routes to read such traces back.
+type RoutingWriter struct {
+	metricsFactory metrics.Factory
+	table          atomic.Value // *routingTable
+}
+
+type routingTable struct {
+	routes       []route
+	defaultRoute route
+}
+
+// NewRoutingWriter creates a RoutingWriter, it returns an error if a route is invalid.
+func NewRoutingWriter(options RoutingOptions) (*RoutingWriter, error) {
+	if options.MetricsFactory == nil {
+		options.MetricsFactory = metrics.NullFactory
+	}
+	w := &RoutingWriter{metricsFactory: options.MetricsFactory}
+	if err := w.UpdateRoutes(options.Routes, options.DefaultWriter); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// UpdateRoutes replaces the routes of the writer, e.g. when the routing rules are reloaded.
+// The previous routes are kept if a route is invalid.
+func (w *RoutingWriter) UpdateRoutes(routes []SpanRoute, defaultWriter Writer) error {
+	if defaultWriter == nil {
+		return fmt.Errorf("the default span writer is required")
+	}
+	names := map[string]struct{}{DefaultRouteName: {}}
+	table := &routingTable{
+		defaultRoute: newRoute(SpanRoute{Name: DefaultRouteName, Writer: defaultWriter}, w.metricsFactory),
+	}
+	for i, r := range routes {
+		if err := validateRoute(r); err != nil {
+			return fmt.Errorf("invalid span route %d: %w", i, err)
+		}
+		if _, ok := names[r.Name]; ok {
+			return fmt.Errorf("duplicate span route %s", r.Name)
+		}
+		names[r.Name] = struct{}{}
+		table.routes = append(table.routes, newRoute(r, w.metricsFactory))
+	}
+	w.table.Store(table)
+	return nil
+}
+
+func newRoute(r SpanRoute, metricsFactory metrics.Factory) route {
+	rt := route{SpanRoute: r}
+	metrics.MustInit(&rt.metrics, metricsFactory, map[string]string{"route": r.Name})
+	return rt
+}
+
+func validateRoute(r SpanRoute) error {
+	if r.Name == "" {
+		return fmt.Errorf("the name is required")
+	}
+	if r.Writer == nil {
+		return fmt.Errorf("the span writer of route %s is required", r.Name)
+	}
+	if len(r.Services) == 0 && len(r.Tags) == 0 && len(r.Tenants) == 0 {
+		return fmt.Errorf("route %s has no services, tags or tenants to match", r.Name)
+	}
+	patterns := append(append([]string{}, r.Services...), r.Tenants...)
+	for _, pattern := range r.Tags {
+		patterns = append(patterns, pattern)
+	}
+	for _, pattern := range patterns {
+		if err := glob.Validate(pattern); err != nil {
+			return fmt.Errorf("invalid pattern %q of route %s: %w", pattern, r.Name, err)
+		}
+	}
+	return nil
+}
+
+// WriteSpan calls WriteSpan on the span writer of the route matching the span.
+func (w *RoutingWriter) WriteSpan(ctx context.Context, span *model.Span) error {
+	table := w.table.Load().(*routingTable)
+	r := &table.defaultRoute
+	for i := range table.routes {
+		if table.routes[i].matches(ctx, span) {
+			r = &table.routes[i]
+			break
+		}
+	}
+	r.metrics.SpansRouted.Inc(1)
+	if err := r.Writer.WriteSpan(ctx, span); err != nil {
+		r.metrics.SpansFailed.Inc(1)
+		return err
+	}
+	return nil
+}
+
+// matches returns true if the span matches any of the services, process tags or tenants of the route.
+func (r *route) matches(ctx context.Context, span *model.Span) bool {
+	if len(r.Tenants) > 0 && matchAny(r.Tenants, tenancy.GetTenant(ctx)) {
+		return true
+	}
+	if span.Process == nil {
+		return false
+	}
+	if matchAny(r.Services, span.Process.ServiceName) {
+		return true
+	}
+	for key, pattern := range r.Tags {
+		if tag, ok := model.KeyValues(span.Process.Tags).FindByKey(key); ok && glob.Match(pattern, tag.AsString()) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if glob.Match(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanstore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
+	. "github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+type recordingWriter struct {
+	spans []*model.Span
+}
+
+func (r *recordingWriter) WriteSpan(ctx context.Context, span *model.Span) error {
+	r.spans = append(r.spans, span)
+	return nil
+}
+
+func TestRoutingWriter(t *testing.T) {
+	pci, payments, defaultWriter := &recordingWriter{}, &recordingWriter{}, &recordingWriter{}
+	mf := metricstest.NewFactory(0)
+	w, err := NewRoutingWriter(RoutingOptions{
+		Routes: []SpanRoute{
+			{Name: "pci", Services: []string{"card-*", "vault"}, Tags: map[string]string{"pci": "true"}, Writer: pci},
+			{Name: "payments", Tags: map[string]string{"tenant": "payments"}, Tenants: []string{"pay*"}, Writer: payments},
+		},
+		DefaultWriter:  defaultWriter,
+		MetricsFactory: mf,
+	})
+	require.NoError(t, err)
+
+	spans := []struct {
+		ctx    context.Context
+		span   *model.Span
+		writer *recordingWriter
+	}{
+		{span: &model.Span{Process: &model.Process{ServiceName: "card-api"}}, writer: pci},
+		{span: &model.Span{Process: &model.Process{ServiceName: "vault"}}, writer: pci},
+		// span tags are ignored, so that the spans of a process are not split between writers
+		{span: &model.Span{Tags: model.KeyValues{model.Bool("pci", true)}, Process: &model.Process{ServiceName: "frontend"}}, writer: defaultWriter},
+		{span: &model.Span{Process: &model.Process{ServiceName: "frontend", Tags: model.KeyValues{model.Bool("pci", true)}}}, writer: pci},
+		{span: &model.Span{Process: &model.Process{ServiceName: "frontend", Tags: model.KeyValues{model.String("tenant", "payments")}}}, writer: payments},
+		{ctx: tenancy.WithTenant(context.Background(), "payments-eu"), span: &model.Span{Process: &model.Process{ServiceName: "frontend"}}, writer: payments},
+		{span: &model.Span{Process: &model.Process{ServiceName: "frontend"}}, writer: defaultWriter},
+		{span: &model.Span{Tags: model.KeyValues{model.String("tenant", "payments")}}, writer: defaultWriter},
+		{span: &model.Span{}, writer: defaultWriter},
+	}
+	for i, s := range spans {
+		ctx := s.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		before := len(s.writer.spans)
+		require.NoError(t, w.WriteSpan(ctx, s.span))
+		require.Len(t, s.writer.spans, before+1, "span %d", i)
+		assert.Same(t, s.span, s.writer.spans[before], "span %d", i)
+	}
+
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "spans_routed", Tags: map[string]string{"route": "pci"}, Value: 3},
+		metricstest.ExpectedMetric{Name: "spans_routed", Tags: map[string]string{"route": "payments"}, Value: 2},
+		metricstest.ExpectedMetric{Name: "spans_routed", Tags: map[string]string{"route": DefaultRouteName}, Value: 4},
+	)
+}
+
+func TestRoutingWriterUpdateRoutes(t *testing.T) {
+	pci, defaultWriter := &recordingWriter{}, &recordingWriter{}
+	w, err := NewRoutingWriter(RoutingOptions{DefaultWriter: defaultWriter})
+	require.NoError(t, err)
+	span := &model.Span{Process: &model.Process{ServiceName: "vault"}}
+
+	require.NoError(t, w.WriteSpan(context.Background(), span))
+	assert.Len(t, defaultWriter.spans, 1)
+
+	require.NoError(t, w.UpdateRoutes([]SpanRoute{{Name: "pci", Services: []string{"vault"}, Writer: pci}}, defaultWriter))
+	require.NoError(t, w.WriteSpan(context.Background(), span))
+	assert.Len(t, pci.spans, 1)
+
+	// invalid routes keep the previous ones
+	assert.Error(t, w.UpdateRoutes([]SpanRoute{{Name: "pci", Writer: pci}}, defaultWriter))
+	assert.Error(t, w.UpdateRoutes(nil, nil))
+	require.NoError(t, w.WriteSpan(context.Background(), span))
+	assert.Len(t, pci.spans, 2)
+}
+
+func TestRoutingWriterFailure(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	w, err := NewRoutingWriter(RoutingOptions{
+		Routes:         []SpanRoute{{Name: "pci", Services: []string{"vault"}, Writer: &noopWriteSpanStore{}}},
+		DefaultWriter:  &errProneWriteSpanStore{},
+		MetricsFactory: mf,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, errIWillAlwaysFail, w.WriteSpan(context.Background(), &model.Span{}))
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "spans_failed", Tags: map[string]string{"route": DefaultRouteName}, Value: 1},
+		metricstest.ExpectedMetric{Name: "spans_failed", Tags: map[string]string{"route": "pci"}, Value: 0},
+	)
+}
+
+func TestNewRoutingWriterErrors(t *testing.T) {
+	writer := &noopWriteSpanStore{}
+	tests := []struct {
+		name    string
+		options RoutingOptions
+		err     string
+	}{
+		{
+			name:    "no default writer",
+			options: RoutingOptions{},
+			err:     "the default span writer is required",
+		},
+		{
+			name:    "no name",
+			options: RoutingOptions{DefaultWriter: writer, Routes: []SpanRoute{{Services: []string{"a"}, Writer: writer}}},
+			err:     "invalid span route 0: the name is required",
+		},
+		{
+			name:    "no writer",
+			options: RoutingOptions{DefaultWriter: writer, Routes: []SpanRoute{{Name: "a", Services: []string{"a"}}}},
+			err:     "invalid span route 0: the span writer of route a is required",
+		},
+		{
+			name:    "no criteria",
+			options: RoutingOptions{DefaultWriter: writer, Routes: []SpanRoute{{Name: "a", Writer: writer}}},
+			err:     "invalid span route 0: route a has no services, tags or tenants to match",
+		},
+		{
+			name:    "invalid pattern",
+			options: RoutingOptions{DefaultWriter: writer, Routes: []SpanRoute{{Name: "a", Tags: map[string]string{"k": "[a"}, Writer: writer}}},
+			err:     `invalid span route 0: invalid pattern "[a" of route a: syntax error in pattern`,
+		},
+		{
+			name:    "duplicate",
+			options: RoutingOptions{DefaultWriter: writer, Routes: []SpanRoute{{Name: DefaultRouteName, Services: []string{"a"}, Writer: writer}}},
+			err:     "duplicate span route default",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewRoutingWriter(test.options)
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
@@ -287,9 +287,11 @@ func (p *RateLimitingSamplingStrategy) String() string {
 // Attributes:
 //  - Operation
 //  - ProbabilisticSampling
+//  - RateLimitingSampling
 type OperationSamplingStrategy struct {
   Operation string `thrift:"operation,1,required" db:"operation" json:"operation"`
   ProbabilisticSampling *ProbabilisticSamplingStrategy `thrift:"probabilisticSampling,2,required" db:"probabilisticSampling" json:"probabilisticSampling"`
+  RateLimitingSampling *RateLimitingSamplingStrategy `thrift:"rateLimitingSampling,3" db:"rateLimitingSampling" json:"rateLimitingSampling,omitempty"`
 }
 
 func NewOperationSamplingStrategy() *OperationSamplingStrategy {
@@ -307,10 +309,21 @@ func (p *OperationSamplingStrategy) GetProbabilisticSampling() *ProbabilisticSam
   }
 return p.ProbabilisticSampling
 }
+var OperationSamplingStrategy_RateLimitingSampling_DEFAULT *RateLimitingSamplingStrategy
+func (p *OperationSamplingStrategy) GetRateLimitingSampling() *RateLimitingSamplingStrategy {
+  if !p.IsSetRateLimitingSampling() {
+    return OperationSamplingStrategy_RateLimitingSampling_DEFAULT
+  }
+return p.RateLimitingSampling
+}
 func (p *OperationSamplingStrategy) IsSetProbabilisticSampling() bool {
   return p.ProbabilisticSampling != nil
 }
 
+func (p *OperationSamplingStrategy) IsSetRateLimitingSampling() bool {
+  return p.RateLimitingSampling != nil
+}
+
 func (p *OperationSamplingStrategy) Read(ctx context.Context, iprot thrift.TProtocol) error {
   if _, err := iprot.ReadStructBegin(ctx); err != nil {
     return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
@@ -348,6 +361,16 @@ func (p *OperationSamplingStrategy) Read(ctx context.Context, iprot thrift.TProt
           return err
         }
       }
+    case 3:
+      if fieldTypeId == thrift.STRUCT {
+        if err := p.ReadField3(ctx, iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(ctx, fieldTypeId); err != nil {
+          return err
+        }
+      }
     default:
       if err := iprot.Skip(ctx, fieldTypeId); err != nil {
         return err
@@ -386,12 +409,21 @@ func (p *OperationSamplingStrategy)  ReadField2(ctx context.Context, iprot thrif
   return nil
 }
 
+func (p *OperationSamplingStrategy)  ReadField3(ctx context.Context, iprot thrift.TProtocol) error {
+  p.RateLimitingSampling = &RateLimitingSamplingStrategy{}
+  if err := p.RateLimitingSampling.Read(ctx, iprot); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.RateLimitingSampling), err)
+  }
+  return nil
+}
+
 func (p *OperationSamplingStrategy) Write(ctx context.Context, oprot thrift.TProtocol) error {
   if err := oprot.WriteStructBegin(ctx, "OperationSamplingStrategy"); err != nil {
     return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err) }
   if p != nil {
     if err := p.writeField1(ctx, oprot); err != nil { return err }
     if err := p.writeField2(ctx, oprot); err != nil { return err }
+    if err := p.writeField3(ctx, oprot); err != nil { return err }
   }
   if err := oprot.WriteFieldStop(ctx); err != nil {
     return thrift.PrependError("write field stop error: ", err) }
@@ -421,6 +453,19 @@ func (p *OperationSamplingStrategy) writeField2(ctx context.Context, oprot thrif
   return err
 }
 
+func (p *OperationSamplingStrategy) writeField3(ctx context.Context, oprot thrift.TProtocol) (err error) {
+  if p.IsSetRateLimitingSampling() {
+    if err := oprot.WriteFieldBegin(ctx, "rateLimitingSampling", thrift.STRUCT, 3); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:rateLimitingSampling: ", p), err) }
+    if err := p.RateLimitingSampling.Write(ctx, oprot); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.RateLimitingSampling), err)
+    }
+    if err := oprot.WriteFieldEnd(ctx); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field end error 3:rateLimitingSampling: ", p), err) }
+  }
+  return err
+}
+
 func (p *OperationSamplingStrategy) Equals(other *OperationSamplingStrategy) bool {
   if p == other {
     return true
@@ -429,6 +474,7 @@ func (p *OperationSamplingStrategy) Equals(other *OperationSamplingStrategy) boo
   }
   if p.Operation != other.Operation { return false }
   if !p.ProbabilisticSampling.Equals(other.ProbabilisticSampling) { return false }
+  if !p.RateLimitingSampling.Equals(other.RateLimitingSampling) { return false }
   return true
 }
 